@@ -0,0 +1,62 @@
+package api
+
+import "time"
+
+// AccessCampaignReport is the sign-off report of an access recertification campaign.
+type AccessCampaignReport struct {
+	Title       string                   `json:"title"`
+	GeneratedAt time.Time                `json:"generatedAt"`
+	Campaign    AccessCampaignInfo       `json:"campaign"`
+	Summary     AccessCampaignSummary    `json:"summary"`
+	Reviewers   []AccessCampaignReviewer `json:"reviewers"`
+	Decisions   []AccessCampaignDecision `json:"decisions"`
+}
+
+type AccessCampaignInfo struct {
+	ID          string     `json:"id"`
+	Name        string     `json:"name"`
+	Description string     `json:"description,omitempty"`
+	Status      string     `json:"status"`
+	CreatedBy   string     `json:"createdBy,omitempty"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	DueAt       time.Time  `json:"dueAt"`
+	Overdue     bool       `json:"overdue"`
+	SignedOffBy string     `json:"signedOffBy,omitempty"`
+	SignedOffAt *time.Time `json:"signedOffAt,omitempty"`
+	// Digest is the sha256 of the decisions recorded at sign off.
+	Digest string `json:"digest,omitempty"`
+}
+
+type AccessCampaignSummary struct {
+	Total    int `json:"total"`
+	Approved int `json:"approved"`
+	Revoked  int `json:"revoked"`
+	Pending  int `json:"pending"`
+	Failed   int `json:"failed"`
+	Configs  int `json:"configs"`
+}
+
+// AccessCampaignReviewer is the progress of a reviewer, a person or a team.
+type AccessCampaignReviewer struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Total    int    `json:"total"`
+	Approved int    `json:"approved"`
+	Revoked  int    `json:"revoked"`
+	Pending  int    `json:"pending"`
+}
+
+type AccessCampaignDecision struct {
+	ConfigID   string     `json:"configId"`
+	ConfigName string     `json:"configName"`
+	ConfigType string     `json:"configType"`
+	User       string     `json:"user"`
+	Email      string     `json:"email,omitempty"`
+	Role       string     `json:"role,omitempty"`
+	Reviewers  []string   `json:"reviewers"`
+	Decision   string     `json:"decision"`
+	DecidedBy  string     `json:"decidedBy,omitempty"`
+	DecidedAt  *time.Time `json:"decidedAt,omitempty"`
+	Comment    string     `json:"comment,omitempty"`
+	Error      string     `json:"error,omitempty"`
+}
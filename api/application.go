@@ -13,19 +13,21 @@ const (
 	SectionTypeConfigs    = "configs"
 	SectionTypeAccess     = "access"
 	SectionTypeAccessLogs = "accessLogs"
+	SectionTypeDORA       = "dora"
 )
 
 // ApplicationSection is a typed section in an application response.
 // Only the field matching the type is populated.
 type ApplicationSection struct {
-	Type       string                  `json:"type"`
-	Title      string                  `json:"title"`
-	Icon       string                  `json:"icon,omitempty"`
-	View       *ApplicationViewData    `json:"view,omitempty"`
-	Changes    []ApplicationChange     `json:"changes,omitempty"`
-	Configs    []ApplicationConfigItem `json:"configs,omitempty"`
-	Access     []AccessItem            `json:"access,omitempty"`
-	AccessLogs []AccessLogItem         `json:"accessLogs,omitempty"`
+	Type       string                   `json:"type"`
+	Title      string                   `json:"title"`
+	Icon       string                   `json:"icon,omitempty"`
+	View       *ApplicationViewData     `json:"view,omitempty"`
+	Changes    []ApplicationChange      `json:"changes,omitempty"`
+	Configs    []ApplicationConfigItem  `json:"configs,omitempty"`
+	Access     []AccessItem             `json:"access,omitempty"`
+	AccessLogs []AccessLogItem          `json:"accessLogs,omitempty"`
+	DORA       []ApplicationDORAMetrics `json:"dora,omitempty"`
 }
 
 // ApplicationViewData holds the data-only fields from a resolved ViewRef section.
@@ -80,13 +82,15 @@ type AccessLogItem struct {
 // Application is the schema that UI uses.
 type Application struct {
 	ApplicationDetail `json:",inline"`
-	AccessControl     ApplicationAccessControl   `json:"accessControl"`
-	Incidents         []ApplicationIncident      `json:"incidents"`
-	Locations         []ApplicationLocation      `json:"locations"`
-	Backups           []ApplicationBackup        `json:"backups"`
-	Restores          []ApplicationBackupRestore `json:"restores"`
-	Findings          []ApplicationFinding       `json:"findings"`
-	Sections          []ApplicationSection       `json:"sections"`
+	AccessControl     ApplicationAccessControl      `json:"accessControl"`
+	Incidents         []ApplicationIncident         `json:"incidents"`
+	Locations         []ApplicationLocation         `json:"locations"`
+	Backups           []ApplicationBackup           `json:"backups"`
+	Restores          []ApplicationBackupRestore    `json:"restores"`
+	Findings          []ApplicationFinding          `json:"findings"`
+	SLOs              []ApplicationSLOStatus        `json:"slos"`
+	BackupCompliance  []ApplicationBackupCompliance `json:"backupCompliance"`
+	Sections          []ApplicationSection          `json:"sections"`
 }
 
 type ApplicationFinding struct {
@@ -0,0 +1,99 @@
+package api
+
+import "time"
+
+// Recovery objectives of a backup policy.
+const (
+	BackupObjectiveRPO         = "rpo"
+	BackupObjectiveRTO         = "rto"
+	BackupObjectiveRestoreTest = "restore_test"
+)
+
+// ApplicationBackupCompliance is the compliance of a datasource with the
+// recovery objectives of its backup policy.
+type ApplicationBackupCompliance struct {
+	ConfigID    string `json:"configId"`
+	Name        string `json:"name"`
+	Type        string `json:"type,omitempty"`
+	Environment string `json:"environment,omitempty"`
+
+	RPO                 string `json:"rpo,omitempty"`
+	RTO                 string `json:"rto,omitempty"`
+	RestoreTestInterval string `json:"restoreTestInterval,omitempty"`
+
+	// LastBackup is the time of the last successful backup.
+	LastBackup *time.Time `json:"lastBackup,omitempty"`
+	// LastRestore is the time of the last restore.
+	LastRestore *time.Time `json:"lastRestore,omitempty"`
+	// RestoreDurationSeconds is the duration of the last measured restore.
+	RestoreDurationSeconds *float64 `json:"restoreDurationSeconds,omitempty"`
+
+	Compliant  bool              `json:"compliant"`
+	Violations []BackupViolation `json:"violations,omitempty"`
+}
+
+// BackupViolation is a recovery objective that a datasource doesn't meet.
+type BackupViolation struct {
+	Objective string `json:"objective"`
+	Target    string `json:"target"`
+	Message   string `json:"message"`
+}
+
+// ApplicationBackupEvent is a recovery objective of a datasource carried by
+// the application.backup.* events.
+type ApplicationBackupEvent struct {
+	ApplicationID string `json:"application_id"`
+	Application   string `json:"application"`
+	Namespace     string `json:"namespace"`
+	ConfigID      string `json:"config_id"`
+	Datasource    string `json:"datasource"`
+	Objective     string `json:"objective"`
+	Target        string `json:"target"`
+	Message       string `json:"message,omitempty"`
+}
+
+// Properties returns the event as event_queue properties.
+func (t ApplicationBackupEvent) Properties() map[string]string {
+	return map[string]string{
+		"application_id": t.ApplicationID,
+		"application":    t.Application,
+		"namespace":      t.Namespace,
+		"config_id":      t.ConfigID,
+		"datasource":     t.Datasource,
+		"objective":      t.Objective,
+		"target":         t.Target,
+		"message":        t.Message,
+	}
+}
+
+// ApplicationBackupEventFromProperties is the inverse of ApplicationBackupEvent.Properties.
+func ApplicationBackupEventFromProperties(properties map[string]string) *ApplicationBackupEvent {
+	return &ApplicationBackupEvent{
+		ApplicationID: properties["application_id"],
+		Application:   properties["application"],
+		Namespace:     properties["namespace"],
+		ConfigID:      properties["config_id"],
+		Datasource:    properties["datasource"],
+		Objective:     properties["objective"],
+		Target:        properties["target"],
+		Message:       properties["message"],
+	}
+}
+
+// AsMap returns the CEL env of the event.
+func (t ApplicationBackupEvent) AsMap() map[string]any {
+	return map[string]any{
+		"application": map[string]any{
+			"id":        t.ApplicationID,
+			"name":      t.Application,
+			"namespace": t.Namespace,
+		},
+		"backup": map[string]any{
+			"config_id":  t.ConfigID,
+			"datasource": t.Datasource,
+			"objective":  t.Objective,
+			"target":     t.Target,
+			"message":    t.Message,
+		},
+	}
+}
@@ -0,0 +1,33 @@
+package api
+
+import "time"
+
+// ApplicationDORAMetrics are the DORA metrics of an environment of an
+// application over a window.
+type ApplicationDORAMetrics struct {
+	Environment string    `json:"environment"`
+	Window      string    `json:"window"`
+	From        time.Time `json:"from"`
+	To          time.Time `json:"to"`
+
+	Deployments int `json:"deployments"`
+	// DeploymentFrequency is the number of deployments per day.
+	DeploymentFrequency float64 `json:"deploymentFrequency"`
+
+	// LeadTimeSeconds is the median time from a pull request being merged to
+	// the next deployment of the same config item.
+	// It's nil when no merged pull request was deployed.
+	LeadTimeSeconds *float64 `json:"leadTimeSeconds,omitempty"`
+
+	FailedDeployments int `json:"failedDeployments"`
+	// ChangeFailureRate is the percentage of deployments that were followed by
+	// a failure. It's nil when there were no deployments.
+	ChangeFailureRate *float64 `json:"changeFailureRate,omitempty"`
+
+	Incidents int `json:"incidents"`
+	// Restores are the incidents resolved and the unhealthy config items that
+	// became healthy again in the window.
+	Restores int `json:"restores"`
+	// MTTRSeconds is the mean time to restore. It's nil when nothing was restored.
+	MTTRSeconds *float64 `json:"mttrSeconds,omitempty"`
+}
@@ -2,6 +2,7 @@ package api
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/flanksource/clicky/api"
 	"github.com/flanksource/duty/view"
@@ -177,6 +178,85 @@ func (f ApplicationFinding) Pretty() api.Text {
 		AddText(f.Description)
 }
 
+// Pretty returns the objective with its burn badge, attainment and error budget.
+func (s ApplicationSLOStatus) Pretty() api.Text {
+	burn := api.Badge("ok", "text-green-700", "bg-green-100")
+	switch {
+	case s.Error != "":
+		burn = api.Badge("error", "text-gray-600", "bg-gray-100")
+	case s.Burn == SLOBurnFast:
+		burn = api.Badge("fast burn", "text-red-700", "bg-red-100")
+	case s.Burn == SLOBurnSlow:
+		burn = api.Badge("slow burn", "text-orange-700", "bg-orange-100")
+	}
+
+	percent := func(p *float64) string {
+		if p == nil {
+			return "-"
+		}
+		return fmt.Sprintf("%.2f%%", *p)
+	}
+
+	t := api.Text{}.
+		Add(burn).
+		AddText(" ").
+		Add(api.Text{Content: s.Name, Style: "font-semibold"}).
+		NewLine().
+		Add(api.DescriptionList{Items: []api.KeyValuePair{
+			api.KeyValue("Target", fmt.Sprintf("%s%%", formatFloat(s.Target))),
+			api.KeyValue("Window", s.Window),
+			api.KeyValue("Attainment", percent(s.Attainment)),
+			api.KeyValue("Error Budget", percent(s.ErrorBudgetRemaining)),
+		}})
+	if s.Error != "" {
+		t = t.NewLine().AddText(s.Error, "text-red-600")
+	}
+	return t
+}
+
+// Pretty returns the objectives of the datasource with a compliance badge
+// and its violations.
+func (c ApplicationBackupCompliance) Pretty() api.Text {
+	status := api.Badge("compliant", "text-green-700", "bg-green-100")
+	if !c.Compliant {
+		status = api.Badge("violated", "text-red-700", "bg-red-100")
+	}
+
+	age := func(t *time.Time) string {
+		if t == nil {
+			return "-"
+		}
+		return t.Format(time.RFC3339)
+	}
+
+	items := []api.KeyValuePair{
+		api.KeyValue("Last Backup", age(c.LastBackup)),
+		api.KeyValue("Last Restore", age(c.LastRestore)),
+	}
+	if c.RPO != "" {
+		items = append(items, api.KeyValue("RPO", c.RPO))
+	}
+	if c.RTO != "" {
+		items = append(items, api.KeyValue("RTO", c.RTO))
+	}
+	if c.RestoreTestInterval != "" {
+		items = append(items, api.KeyValue("Restore Test", c.RestoreTestInterval))
+	}
+
+	t := api.Text{}.
+		Add(status).
+		AddText(" ").
+		Add(api.Text{Content: c.Name, Style: "font-semibold"})
+	if c.Environment != "" {
+		t = t.AddText(" (" + c.Environment + ")")
+	}
+	t = t.NewLine().Add(api.DescriptionList{Items: items})
+	for _, v := range c.Violations {
+		t = t.NewLine().AddText(v.Message, "text-red-600")
+	}
+	return t
+}
+
 // Pretty returns a row text with provider badge.
 func (l ApplicationLocation) Pretty() api.Text {
 	return api.Text{Content: l.Name}.
@@ -238,6 +318,10 @@ func (s ApplicationSection) Pretty() api.Text {
 		if len(s.Configs) > 0 {
 			return configsTable(s.Configs)
 		}
+	case SectionTypeDORA:
+		if len(s.DORA) > 0 {
+			return doraTable(s.DORA)
+		}
 	}
 	return api.Text{}
 }
@@ -328,3 +412,38 @@ func configsTable(configs []ApplicationConfigItem) api.Text {
 	}
 	return api.Text{}.Add(api.TextTable{Headers: headers, Rows: rows})
 }
+
+func doraTable(metrics []ApplicationDORAMetrics) api.Text {
+	headers := api.TextList{
+		api.Text{Content: "Environment"},
+		api.Text{Content: "Deployments"},
+		api.Text{Content: "Per Day"},
+		api.Text{Content: "Lead Time"},
+		api.Text{Content: "Change Failure Rate"},
+		api.Text{Content: "MTTR"},
+	}
+
+	seconds := func(s *float64) api.Text {
+		if s == nil {
+			return api.Text{Content: "-"}
+		}
+		return api.Text{Content: time.Duration(*s * float64(time.Second)).Round(time.Second).String()}
+	}
+
+	rows := make([]api.TableRow, 0, len(metrics))
+	for _, m := range metrics {
+		failureRate := "-"
+		if m.ChangeFailureRate != nil {
+			failureRate = fmt.Sprintf("%.1f%% (%d/%d)", *m.ChangeFailureRate, m.FailedDeployments, m.Deployments)
+		}
+		rows = append(rows, api.TableRow{
+			"Environment":         api.NewTypedValue(api.Text{Content: m.Environment}),
+			"Deployments":         api.NewTypedValue(api.Text{Content: fmt.Sprintf("%d", m.Deployments)}),
+			"Per Day":             api.NewTypedValue(api.Text{Content: fmt.Sprintf("%.2f", m.DeploymentFrequency)}),
+			"Lead Time":           api.NewTypedValue(seconds(m.LeadTimeSeconds)),
+			"Change Failure Rate": api.NewTypedValue(api.Text{Content: failureRate}),
+			"MTTR":                api.NewTypedValue(seconds(m.MTTRSeconds)),
+		})
+	}
+	return api.Text{}.Add(api.TextTable{Headers: headers, Rows: rows})
+}
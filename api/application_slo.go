@@ -0,0 +1,140 @@
+package api
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// SLO burn states.
+const (
+	SLOBurnFast = "fast"
+	SLOBurnSlow = "slow"
+)
+
+// ApplicationSLOStatus is the last evaluation of a service level objective.
+type ApplicationSLOStatus struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	// Target in percent.
+	Target float64 `json:"target"`
+	Window string  `json:"window"`
+
+	Good  float64 `json:"good"`
+	Total float64 `json:"total"`
+	// Attainment is the percentage of good events over the window.
+	// It's nil when there were no events.
+	Attainment *float64 `json:"attainment,omitempty"`
+	// ErrorBudgetRemaining is the percentage of the error budget that's left.
+	// It's negative once the objective is missed.
+	ErrorBudgetRemaining *float64 `json:"errorBudgetRemaining,omitempty"`
+
+	// BurnRates are keyed by the window they're measured over e.g. 1h.
+	BurnRates map[string]float64 `json:"burnRates,omitempty"`
+	// Burn is fast or slow when the error budget is burning too fast.
+	Burn string `json:"burn,omitempty"`
+
+	EvaluatedAt time.Time `json:"evaluatedAt"`
+	Error       string    `json:"error,omitempty"`
+}
+
+// ApplicationSLOEvent is the state of an objective carried by the
+// application.slo.* events.
+type ApplicationSLOEvent struct {
+	ApplicationID string  `json:"application_id"`
+	Application   string  `json:"application"`
+	Namespace     string  `json:"namespace"`
+	SLO           string  `json:"slo"`
+	Burn          string  `json:"burn,omitempty"`
+	BurnRate      float64 `json:"burn_rate"`
+	Target        float64 `json:"target"`
+	Window        string  `json:"window"`
+
+	Attainment           *float64 `json:"attainment,omitempty"`
+	ErrorBudgetRemaining *float64 `json:"error_budget_remaining,omitempty"`
+}
+
+// Properties returns the event as event_queue properties.
+func (t ApplicationSLOEvent) Properties() map[string]string {
+	properties := map[string]string{
+		"application_id": t.ApplicationID,
+		"application":    t.Application,
+		"namespace":      t.Namespace,
+		"slo":            t.SLO,
+		"burn":           t.Burn,
+		"burn_rate":      formatFloat(t.BurnRate),
+		"target":         formatFloat(t.Target),
+		"window":         t.Window,
+	}
+	if t.Attainment != nil {
+		properties["attainment"] = formatFloat(*t.Attainment)
+	}
+	if t.ErrorBudgetRemaining != nil {
+		properties["error_budget_remaining"] = formatFloat(*t.ErrorBudgetRemaining)
+	}
+	return properties
+}
+
+// ApplicationSLOEventFromProperties is the inverse of ApplicationSLOEvent.Properties.
+func ApplicationSLOEventFromProperties(properties map[string]string) (*ApplicationSLOEvent, error) {
+	t := &ApplicationSLOEvent{
+		ApplicationID: properties["application_id"],
+		Application:   properties["application"],
+		Namespace:     properties["namespace"],
+		SLO:           properties["slo"],
+		Burn:          properties["burn"],
+		Window:        properties["window"],
+	}
+
+	for key, v := range map[string]*float64{"burn_rate": &t.BurnRate, "target": &t.Target} {
+		if raw := properties[key]; raw != "" {
+			f, err := strconv.ParseFloat(raw, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s: %w", key, err)
+			}
+			*v = f
+		}
+	}
+
+	for key, v := range map[string]**float64{"attainment": &t.Attainment, "error_budget_remaining": &t.ErrorBudgetRemaining} {
+		if raw := properties[key]; raw != "" {
+			f, err := strconv.ParseFloat(raw, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s: %w", key, err)
+			}
+			*v = &f
+		}
+	}
+
+	return t, nil
+}
+
+// AsMap returns the CEL env of the event.
+func (t ApplicationSLOEvent) AsMap() map[string]any {
+	slo := map[string]any{
+		"name":      t.SLO,
+		"burn":      t.Burn,
+		"burn_rate": t.BurnRate,
+		"target":    t.Target,
+		"window":    t.Window,
+	}
+	if t.Attainment != nil {
+		slo["attainment"] = *t.Attainment
+	}
+	if t.ErrorBudgetRemaining != nil {
+		slo["error_budget_remaining"] = *t.ErrorBudgetRemaining
+	}
+
+	return map[string]any{
+		"application": map[string]any{
+			"id":        t.ApplicationID,
+			"name":      t.Application,
+			"namespace": t.Namespace,
+		},
+		"slo": slo,
+	}
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
@@ -0,0 +1,50 @@
+package api_test
+
+import (
+	ginkgo "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/samber/lo"
+
+	"github.com/flanksource/incident-commander/api"
+)
+
+var _ = ginkgo.Describe("ApplicationSLOEvent", func() {
+	event := api.ApplicationSLOEvent{
+		ApplicationID:        "0198c1c5-7c8a-7d5c-9c42-1f3e3a3b8a11",
+		Application:          "checkout",
+		Namespace:            "mc",
+		SLO:                  "availability",
+		Burn:                 api.SLOBurnFast,
+		BurnRate:             16.2,
+		Target:               99.9,
+		Window:               "30d",
+		Attainment:           lo.ToPtr(99.5),
+		ErrorBudgetRemaining: lo.ToPtr(-400.0),
+	}
+
+	ginkgo.It("round trips through event properties", func() {
+		properties := event.Properties()
+		Expect(properties["burn_rate"]).To(Equal("16.2"))
+
+		parsed, err := api.ApplicationSLOEventFromProperties(properties)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(*parsed).To(Equal(event))
+	})
+
+	ginkgo.It("omits the attainment when there were no events", func() {
+		empty := event
+		empty.Attainment, empty.ErrorBudgetRemaining = nil, nil
+
+		parsed, err := api.ApplicationSLOEventFromProperties(empty.Properties())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(parsed.Attainment).To(BeNil())
+		Expect(empty.AsMap()["slo"]).ToNot(HaveKey("attainment"))
+	})
+
+	ginkgo.It("exposes the objective in the CEL env", func() {
+		env := event.AsMap()
+		Expect(env["application"]).To(HaveKeyWithValue("name", "checkout"))
+		Expect(env["slo"]).To(HaveKeyWithValue("burn", api.SLOBurnFast))
+		Expect(env["slo"]).To(HaveKeyWithValue("error_budget_remaining", -400.0))
+	})
+})
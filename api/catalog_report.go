@@ -77,6 +77,9 @@ type CatalogReportQuery struct {
 }
 
 type CatalogReport struct {
+	// ID and Hash are set once the report is persisted.
+	ID          string                    `json:"id,omitempty"`
+	Hash        string                    `json:"hash,omitempty"`
 	Title       string                    `json:"title"`
 	GeneratedAt time.Time                 `json:"generatedAt"`
 	PublicURL   string                    `json:"publicURL,omitempty"`
@@ -107,6 +110,39 @@ type CatalogReport struct {
 	ConfigJSON       *string                     `json:"configJSON,omitempty"`
 	ConfigJSONItems  []CatalogReportConfigJSON   `json:"configJSONItems,omitempty"`
 	ConfigGroups     []CatalogReportConfigGroup  `json:"configGroups,omitempty"`
+
+	// Diff lists what changed since a previous report.
+	Diff *CatalogReportDiff `json:"diff,omitempty"`
+}
+
+// CatalogReportSummary identifies a persisted report.
+type CatalogReportSummary struct {
+	ID          string    `json:"id"`
+	Title       string    `json:"title,omitempty"`
+	Hash        string    `json:"hash"`
+	ItemCount   int       `json:"itemCount"`
+	GeneratedAt time.Time `json:"generatedAt"`
+}
+
+// CatalogReportDiff lists what changed between a previous report and the current one.
+// Insights and access are only compared when both reports include the section.
+type CatalogReportDiff struct {
+	Against          CatalogReportSummary      `json:"against"`
+	NewConfigs       []CatalogReportConfigItem `json:"newConfigs"`
+	RemovedConfigs   []CatalogReportConfigItem `json:"removedConfigs"`
+	NewInsights      []CatalogReportAnalysis   `json:"newInsights"`
+	ResolvedInsights []CatalogReportAnalysis   `json:"resolvedInsights"`
+	GrantsAdded      []CatalogReportAccess     `json:"grantsAdded"`
+	GrantsRevoked    []CatalogReportAccess     `json:"grantsRevoked"`
+	NewUsers         []CatalogReportUser       `json:"newUsers"`
+}
+
+// CatalogReportUser is a user that has access to a config of the report.
+type CatalogReportUser struct {
+	UserID   string `json:"userId"`
+	UserName string `json:"userName"`
+	Email    string `json:"email,omitempty"`
+	UserType string `json:"userType,omitempty"`
 }
 
 type CatalogReportConfigJSON struct {
@@ -50,6 +50,20 @@ const (
 	EventIncidentStatusOpen          = "incident.status.open"
 	EventIncidentStatusResolved      = "incident.status.resolved"
 
+	// Generated by the view threshold evaluator when a panel threshold changes state.
+	EventViewThresholdBreached  = "view.threshold.breached"
+	EventViewThresholdRecovered = "view.threshold.recovered"
+
+	// Generated by the application SLO evaluator when the error budget burn rate changes.
+	EventApplicationSLOFastBurn  = "application.slo.fast_burn"
+	EventApplicationSLOSlowBurn  = "application.slo.slow_burn"
+	EventApplicationSLORecovered = "application.slo.recovered"
+
+	// Generated by the backup compliance check when a datasource starts or stops
+	// meeting a recovery objective.
+	EventApplicationBackupViolated  = "application.backup.violated"
+	EventApplicationBackupCompliant = "application.backup.compliant"
+
 	// List of async events.
 	//
 	// Async events require the handler to talk to 3rd party services.
@@ -111,6 +125,19 @@ var (
 		EventIncidentStatusOpen,
 		EventIncidentStatusResolved,
 	}
+	EventViewThresholdGroup = []string{
+		EventViewThresholdBreached,
+		EventViewThresholdRecovered,
+	}
+	EventApplicationSLOGroup = []string{
+		EventApplicationSLOFastBurn,
+		EventApplicationSLOSlowBurn,
+		EventApplicationSLORecovered,
+	}
+	EventApplicationBackupGroup = []string{
+		EventApplicationBackupViolated,
+		EventApplicationBackupCompliant,
+	}
 )
 
 func EventToHealth(event string) models.Health {
@@ -36,6 +36,7 @@ type RBACResource struct {
 	Users           []RBACUserRole        `json:"users"`
 	Changelog       []RBACChangeEntry     `json:"changelog"`
 	TemporaryAccess []RBACTemporaryAccess `json:"temporaryAccess,omitempty"`
+	Elevations      []RBACElevation       `json:"elevations,omitempty"`
 }
 
 type RBACTemporaryAccess struct {
@@ -48,6 +49,19 @@ type RBACTemporaryAccess struct {
 	Duration  string    `json:"duration"`
 }
 
+// RBACElevation is a just-in-time permission elevation that covered the config item.
+type RBACElevation struct {
+	ConfigID  string     `json:"configId"`
+	User      string     `json:"user"`
+	Email     string     `json:"email"`
+	Role      string     `json:"role"`
+	Reason    string     `json:"reason"`
+	Status    string     `json:"status"`
+	GrantedAt time.Time  `json:"grantedAt"`
+	ExpiresAt time.Time  `json:"expiresAt"`
+	EndedAt   *time.Time `json:"endedAt,omitempty"`
+}
+
 type RBACUserRole struct {
 	UserID          string     `json:"userId"`
 	UserName        string     `json:"userName"`
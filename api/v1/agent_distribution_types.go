@@ -0,0 +1,102 @@
+package v1
+
+import (
+	"github.com/flanksource/commons/collections"
+	"github.com/flanksource/duty/models"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// Kinds of resources that can be distributed to agents.
+const (
+	AgentDistributionKindPlaybook     = "Playbook"
+	AgentDistributionKindNotification = "Notification"
+	AgentDistributionKindView         = "View"
+	AgentDistributionKindConnection   = "Connection"
+)
+
+// AgentDistributionKinds lists the kinds, in the order agents apply them.
+// Connections come first as the other kinds may reference them.
+var AgentDistributionKinds = []string{
+	AgentDistributionKindConnection,
+	AgentDistributionKindPlaybook,
+	AgentDistributionKindNotification,
+	AgentDistributionKindView,
+}
+
+// AgentDistributionTarget selects the agents a distribution applies to.
+// An agent is selected when it matches either the names or the selector.
+// +kubebuilder:object:generate=true
+type AgentDistributionTarget struct {
+	// Names of the agents. Supports match expressions
+	// e.g. "prod-*" or "!prod-eu".
+	//+kubebuilder:validation:Optional
+	Names []string `json:"names,omitempty"`
+
+	// Selector is a label selector matched against the agent properties.
+	// Example: "env=prod,region in (us-east,us-west)"
+	//+kubebuilder:validation:Optional
+	Selector string `json:"selector,omitempty"`
+}
+
+// Matches returns true if the agent is targeted.
+func (t AgentDistributionTarget) Matches(agent models.Agent) bool {
+	if len(t.Names) > 0 && collections.MatchItems(agent.Name, t.Names...) {
+		return true
+	}
+
+	if t.Selector != "" {
+		selector, err := labels.Parse(t.Selector)
+		if err != nil {
+			return false
+		}
+
+		return selector.Matches(labels.Set(agent.Properties))
+	}
+
+	return false
+}
+
+// AgentDistributionResource references a resource on the upstream cluster.
+// +kubebuilder:object:generate=true
+type AgentDistributionResource struct {
+	// +kubebuilder:validation:Enum=Playbook;Notification;View;Connection
+	Kind string `json:"kind"`
+
+	Name string `json:"name"`
+
+	// Namespace of the resource. Defaults to the namespace of the distribution.
+	//+kubebuilder:validation:Optional
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// +kubebuilder:object:generate=true
+type AgentDistributionSpec struct {
+	// Agents selects the agents that receive the resources.
+	Agents AgentDistributionTarget `json:"agents"`
+
+	// Resources to apply on the selected agents.
+	// +kubebuilder:validation:MinItems=1
+	Resources []AgentDistributionResource `json:"resources"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Namespaced
+//
+// AgentDistribution declares resources that agents pull from the upstream and
+// apply to their local database.
+type AgentDistribution struct {
+	metav1.TypeMeta   `json:",inline" yaml:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty" yaml:"metadata,omitempty"`
+
+	Spec AgentDistributionSpec `json:"spec,omitempty" yaml:"spec,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+//
+// AgentDistributionList contains a list of AgentDistribution
+type AgentDistributionList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []AgentDistribution `json:"items"`
+}
@@ -0,0 +1,33 @@
+package v1
+
+import (
+	"github.com/flanksource/duty/models"
+	"github.com/flanksource/duty/types"
+	ginkgo "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = ginkgo.Describe("AgentDistributionTarget", func() {
+	agent := models.Agent{Name: "prod-us", Properties: types.JSONStringMap{"env": "prod", "region": "us-east"}}
+
+	tests := []struct {
+		name     string
+		target   AgentDistributionTarget
+		expected bool
+	}{
+		{name: "exact name", target: AgentDistributionTarget{Names: []string{"prod-us"}}, expected: true},
+		{name: "name wildcard", target: AgentDistributionTarget{Names: []string{"prod-*"}}, expected: true},
+		{name: "excluded name", target: AgentDistributionTarget{Names: []string{"prod-*", "!prod-us"}}, expected: false},
+		{name: "selector", target: AgentDistributionTarget{Selector: "env=prod,region in (us-east,us-west)"}, expected: true},
+		{name: "non matching selector", target: AgentDistributionTarget{Selector: "env=staging"}, expected: false},
+		{name: "name or selector", target: AgentDistributionTarget{Names: []string{"dev"}, Selector: "env=prod"}, expected: true},
+		{name: "invalid selector", target: AgentDistributionTarget{Selector: "env in"}, expected: false},
+		{name: "empty target", target: AgentDistributionTarget{}, expected: false},
+	}
+
+	for _, tt := range tests {
+		ginkgo.It("matches "+tt.name, func() {
+			Expect(tt.target.Matches(agent)).To(Equal(tt.expected))
+		})
+	}
+})
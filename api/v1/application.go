@@ -2,12 +2,20 @@ package v1
 
 import (
 	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/flanksource/commons/duration"
+	"github.com/flanksource/duty/connection"
 
 	"github.com/flanksource/duty/models"
 	"github.com/flanksource/duty/types"
 	"github.com/flanksource/kopper"
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/uuid"
+	"github.com/samber/lo"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	k8sTypes "k8s.io/apimachinery/pkg/types"
@@ -83,6 +91,276 @@ type ApplicationSpec struct {
 	Mapping ApplicationMapping `json:"mapping,omitempty"`
 
 	Sections []api.ViewSection `json:"sections,omitempty"`
+
+	// SLOs are the service level objectives of the application.
+	SLOs []ApplicationSLO `json:"slos,omitempty"`
+
+	// DORA computes the deployment frequency, lead time for changes, change failure
+	// rate and time to restore of every mapped environment.
+	DORA *ApplicationDORA `json:"dora,omitempty"`
+
+	// BackupPolicies set the recovery objectives of the datasources.
+	// The first policy that matches a datasource applies to it.
+	BackupPolicies []ApplicationBackupPolicy `json:"backupPolicies,omitempty"`
+}
+
+// ApplicationBackupPolicy sets the recovery objectives of the datasources of the
+// application. Violations are recorded as insights on the datasource.
+type ApplicationBackupPolicy struct {
+	// Datasources restricts the policy to the matching datasources.
+	// Only config items selected by mapping.datasources are considered.
+	Datasources []types.ResourceSelector `json:"datasources,omitempty"`
+
+	// Environment restricts the policy to the datasources of a mapped environment.
+	Environment string `json:"environment,omitempty"`
+
+	// RPO is the maximum time since the last successful backup e.g. 24h.
+	RPO string `json:"rpo,omitempty"`
+
+	// RTO is the maximum duration of a restore e.g. 4h.
+	// Restores are measured from a BackupRestored change to the next RestoreCompleted change.
+	RTO string `json:"rto,omitempty"`
+
+	// RestoreTestInterval is the maximum time since the last restore e.g. 90d.
+	RestoreTestInterval string `json:"restoreTestInterval,omitempty"`
+}
+
+// Objectives returns the parsed RPO, RTO and restore test interval.
+// Objectives that aren't set are zero.
+func (p ApplicationBackupPolicy) Objectives() (rpo, rto, restoreTest time.Duration, err error) {
+	for _, o := range []struct {
+		field, value string
+		out          *time.Duration
+	}{
+		{"rpo", p.RPO, &rpo},
+		{"rto", p.RTO, &rto},
+		{"restoreTestInterval", p.RestoreTestInterval, &restoreTest},
+	} {
+		if o.value == "" {
+			continue
+		}
+		if *o.out, err = parsePositiveDuration(o.field, o.value); err != nil {
+			return 0, 0, 0, err
+		}
+	}
+	return rpo, rto, restoreTest, nil
+}
+
+// ValidateBackupPolicies checks that every policy sets an objective that parses
+// and only references mapped environments.
+func (spec ApplicationSpec) ValidateBackupPolicies() error {
+	for i, policy := range spec.BackupPolicies {
+		if policy.RPO == "" && policy.RTO == "" && policy.RestoreTestInterval == "" {
+			return fmt.Errorf("backup policy %d: one of rpo, rto or restoreTestInterval is required", i)
+		}
+		if _, _, _, err := policy.Objectives(); err != nil {
+			return fmt.Errorf("backup policy %d: %w", i, err)
+		}
+		if policy.Environment != "" {
+			if _, ok := spec.Mapping.Environments[policy.Environment]; !ok {
+				return fmt.Errorf("backup policy %d: environment %s is not mapped", i, policy.Environment)
+			}
+		}
+	}
+	return nil
+}
+
+// ApplicationDORA configures which changes of the config items of an environment
+// count as deployments and failures.
+type ApplicationDORA struct {
+	// Window the metrics are computed over e.g. 7d. Defaults to 30d.
+	Window string `json:"window,omitempty"`
+
+	// DeploymentChangeTypes are the config change types that count as a deployment.
+	// Defaults to Deployment and Promotion.
+	DeploymentChangeTypes []string `json:"deploymentChangeTypes,omitempty"`
+
+	// FailureChangeTypes are the config change types that mark the last deployment
+	// of the same config item as failed. Defaults to Rollback and Unhealthy.
+	// Incidents with evidence on the config item also mark it as failed.
+	FailureChangeTypes []string `json:"failureChangeTypes,omitempty"`
+
+	// MergeChangeTypes are the config change types that record a pull request
+	// being merged. The lead time for changes runs from a merge to the next
+	// deployment of the same config item. Defaults to PullRequestMerged.
+	MergeChangeTypes []string `json:"mergeChangeTypes,omitempty"`
+
+	// FailureWindow is how long after a deployment a failure is attributed to it.
+	// Defaults to 1h.
+	FailureWindow string `json:"failureWindow,omitempty"`
+}
+
+var (
+	DefaultDORADeploymentChangeTypes = []string{"Deployment", "Promotion"}
+	DefaultDORAFailureChangeTypes    = []string{"Rollback", "Unhealthy"}
+	DefaultDORAMergeChangeTypes      = []string{"PullRequestMerged"}
+)
+
+const (
+	DefaultDORAWindow        = "30d"
+	DefaultDORAFailureWindow = "1h"
+)
+
+// WindowDuration returns the window, defaulting to 30 days.
+func (d ApplicationDORA) WindowDuration() (time.Duration, error) {
+	return parsePositiveDuration("window", lo.CoalesceOrEmpty(d.Window, DefaultDORAWindow))
+}
+
+// FailureWindowDuration returns the failure window, defaulting to 1 hour.
+func (d ApplicationDORA) FailureWindowDuration() (time.Duration, error) {
+	return parsePositiveDuration("failureWindow", lo.CoalesceOrEmpty(d.FailureWindow, DefaultDORAFailureWindow))
+}
+
+func (d ApplicationDORA) DeploymentTypes() []string {
+	return lo.Ternary(len(d.DeploymentChangeTypes) > 0, d.DeploymentChangeTypes, DefaultDORADeploymentChangeTypes)
+}
+
+func (d ApplicationDORA) FailureTypes() []string {
+	return lo.Ternary(len(d.FailureChangeTypes) > 0, d.FailureChangeTypes, DefaultDORAFailureChangeTypes)
+}
+
+func (d ApplicationDORA) MergeTypes() []string {
+	return lo.Ternary(len(d.MergeChangeTypes) > 0, d.MergeChangeTypes, DefaultDORAMergeChangeTypes)
+}
+
+// Validate checks that the windows parse and that no change type is both a
+// deployment and a failure.
+func (d ApplicationDORA) Validate() error {
+	if _, err := d.WindowDuration(); err != nil {
+		return err
+	}
+	if _, err := d.FailureWindowDuration(); err != nil {
+		return err
+	}
+	if both := lo.Intersect(d.DeploymentTypes(), d.FailureTypes()); len(both) > 0 {
+		return fmt.Errorf("change types %s can't be both deployments and failures", strings.Join(both, ", "))
+	}
+	return nil
+}
+
+func parsePositiveDuration(field, value string) (time.Duration, error) {
+	d, err := duration.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q: %w", field, value, err)
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("%s %q must be positive", field, value)
+	}
+	return time.Duration(d), nil
+}
+
+// ApplicationSLO is a service level objective: the ratio of good events
+// measured by the SLI must stay above the target over the window.
+type ApplicationSLO struct {
+	// Name of the objective, unique within the application.
+	Name string `json:"name"`
+
+	Description string `json:"description,omitempty"`
+
+	// Target is the percentage of good events to attain e.g. "99.9".
+	Target string `json:"target"`
+
+	// Window is the rolling window the target is measured over e.g. 28d.
+	// Defaults to 30d.
+	Window string `json:"window,omitempty"`
+
+	// SLI measures the good and total events. Exactly one indicator must be set.
+	SLI ApplicationSLI `json:"sli"`
+}
+
+type ApplicationSLI struct {
+	// Checks measures the ratio of passing results of the selected health checks.
+	Checks []types.ResourceSelector `json:"checks,omitempty"`
+
+	// Prometheus measures the ratio of two PromQL queries.
+	Prometheus *ApplicationPrometheusSLI `json:"prometheus,omitempty"`
+
+	// ConfigHealth measures the ratio of healthy config items in the mapped environments.
+	// It's sampled on every evaluation.
+	ConfigHealth *ApplicationConfigHealthSLI `json:"configHealth,omitempty"`
+}
+
+type ApplicationPrometheusSLI struct {
+	connection.PrometheusConnection `json:",inline"`
+
+	// Good is the PromQL query that counts the good events.
+	// $window is replaced with the window being measured e.g. sum(increase(http_requests_total{code!~"5.."}[$window]))
+	Good string `json:"good"`
+
+	// Total is the PromQL query that counts all the events.
+	Total string `json:"total"`
+}
+
+type ApplicationConfigHealthSLI struct {
+	// Environments to measure. Defaults to all the mapped environments.
+	Environments []string `json:"environments,omitempty"`
+}
+
+// DefaultSLOWindow is the window of objectives that don't specify one.
+const DefaultSLOWindow = "30d"
+
+// TargetRatio returns the target as a ratio between 0 and 1.
+func (s ApplicationSLO) TargetRatio() (float64, error) {
+	target, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(s.Target), "%"), 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid target %q: %w", s.Target, err)
+	}
+	if target <= 0 || target >= 100 {
+		return 0, fmt.Errorf("target %q must be between 0 and 100 (exclusive)", s.Target)
+	}
+	return target / 100, nil
+}
+
+// WindowDuration returns the window, defaulting to 30 days.
+func (s ApplicationSLO) WindowDuration() (time.Duration, error) {
+	d, err := duration.ParseDuration(lo.CoalesceOrEmpty(s.Window, DefaultSLOWindow))
+	if err != nil {
+		return 0, fmt.Errorf("invalid window %q: %w", s.Window, err)
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("window %q must be positive", s.Window)
+	}
+	return time.Duration(d), nil
+}
+
+// ValidateSLOs checks that objective names are unique, that targets and
+// windows parse and that every SLI has exactly one indicator.
+func (spec ApplicationSpec) ValidateSLOs() error {
+	names := map[string]bool{}
+	for _, slo := range spec.SLOs {
+		if slo.Name == "" {
+			return fmt.Errorf("slo name is required")
+		} else if names[slo.Name] {
+			return fmt.Errorf("slo %s is defined more than once", slo.Name)
+		}
+		names[slo.Name] = true
+
+		if _, err := slo.TargetRatio(); err != nil {
+			return fmt.Errorf("slo %s: %w", slo.Name, err)
+		}
+		if _, err := slo.WindowDuration(); err != nil {
+			return fmt.Errorf("slo %s: %w", slo.Name, err)
+		}
+
+		indicators := lo.Count([]bool{len(slo.SLI.Checks) > 0, slo.SLI.Prometheus != nil, slo.SLI.ConfigHealth != nil}, true)
+		if indicators != 1 {
+			return fmt.Errorf("slo %s: sli must have exactly one of checks, prometheus or configHealth", slo.Name)
+		}
+
+		if p := slo.SLI.Prometheus; p != nil && (p.Good == "" || p.Total == "") {
+			return fmt.Errorf("slo %s: prometheus sli requires both good and total queries", slo.Name)
+		}
+
+		if c := slo.SLI.ConfigHealth; c != nil {
+			for _, env := range c.Environments {
+				if _, ok := spec.Mapping.Environments[env]; !ok {
+					return fmt.Errorf("slo %s: environment %s is not mapped", slo.Name, env)
+				}
+			}
+		}
+	}
+
+	return nil
 }
 
 // ApplicationStatus defines the observed state of Application
@@ -164,6 +442,15 @@ func (a *Application) AllSelectors() []types.ResourceSelector {
 	return selectors
 }
 
+// AccessSelectors returns the selectors of the configs whose access is
+// reviewed: the logins of the application, or else all its mapped configs.
+func (a *Application) AccessSelectors() []types.ResourceSelector {
+	if len(a.Spec.Mapping.Logins) > 0 {
+		return a.Spec.Mapping.Logins
+	}
+	return a.AllSelectors()
+}
+
 func ApplicationFromModel(app models.Application) (*Application, error) {
 	var spec ApplicationSpec
 	if err := json.Unmarshal([]byte(app.Spec), &spec); err != nil {
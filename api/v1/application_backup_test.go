@@ -0,0 +1,32 @@
+package v1
+
+import (
+	"time"
+
+	ginkgo "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = ginkgo.Describe("ApplicationBackupPolicy", func() {
+	ginkgo.It("parses the objectives that are set", func() {
+		rpo, rto, restoreTest, err := ApplicationBackupPolicy{RPO: "24h", RestoreTestInterval: "30d"}.Objectives()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(rpo).To(Equal(24 * time.Hour))
+		Expect(rto).To(BeZero())
+		Expect(restoreTest).To(Equal(30 * 24 * time.Hour))
+	})
+
+	ginkgo.DescribeTable("rejects invalid policies",
+		func(policy ApplicationBackupPolicy, message string) {
+			spec := ApplicationSpec{
+				Mapping:        ApplicationMapping{Environments: map[string][]ApplicationEnvironment{"prod": nil}},
+				BackupPolicies: []ApplicationBackupPolicy{policy},
+			}
+			Expect(spec.ValidateBackupPolicies()).To(MatchError(ContainSubstring(message)))
+		},
+		ginkgo.Entry("no objective", ApplicationBackupPolicy{Environment: "prod"}, "one of rpo, rto or restoreTestInterval is required"),
+		ginkgo.Entry("invalid rpo", ApplicationBackupPolicy{RPO: "daily"}, "invalid rpo"),
+		ginkgo.Entry("negative rto", ApplicationBackupPolicy{RTO: "-1h"}, "must be positive"),
+		ginkgo.Entry("unmapped environment", ApplicationBackupPolicy{RPO: "1d", Environment: "staging"}, "environment staging is not mapped"),
+	)
+})
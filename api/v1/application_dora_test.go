@@ -0,0 +1,37 @@
+package v1
+
+import (
+	"time"
+
+	ginkgo "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = ginkgo.Describe("ApplicationDORA", func() {
+	ginkgo.It("defaults the windows and change types", func() {
+		dora := ApplicationDORA{}
+		Expect(dora.Validate()).To(Succeed())
+
+		window, err := dora.WindowDuration()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(window).To(Equal(30 * 24 * time.Hour))
+
+		failureWindow, err := dora.FailureWindowDuration()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(failureWindow).To(Equal(time.Hour))
+
+		Expect(dora.DeploymentTypes()).To(Equal(DefaultDORADeploymentChangeTypes))
+		Expect(dora.FailureTypes()).To(Equal(DefaultDORAFailureChangeTypes))
+	})
+
+	ginkgo.DescribeTable("rejects invalid configurations",
+		func(dora ApplicationDORA, message string) {
+			Expect(dora.Validate()).To(MatchError(ContainSubstring(message)))
+		},
+		ginkgo.Entry("invalid window", ApplicationDORA{Window: "a month"}, "invalid window"),
+		ginkgo.Entry("negative failure window", ApplicationDORA{FailureWindow: "-1h"}, "must be positive"),
+		ginkgo.Entry("overlapping change types", ApplicationDORA{
+			DeploymentChangeTypes: []string{"Deployment", "Rollback"},
+		}, "Rollback can't be both"),
+	)
+})
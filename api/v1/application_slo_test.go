@@ -0,0 +1,71 @@
+package v1
+
+import (
+	"time"
+
+	"github.com/flanksource/duty/types"
+	ginkgo "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = ginkgo.Describe("ApplicationSpec SLOs", func() {
+	spec := func(slos ...ApplicationSLO) ApplicationSpec {
+		return ApplicationSpec{
+			Mapping: ApplicationMapping{
+				Environments: map[string][]ApplicationEnvironment{"Production": {}},
+			},
+			SLOs: slos,
+		}
+	}
+
+	checks := ApplicationSLI{Checks: []types.ResourceSelector{{Name: "frontend"}}}
+
+	ginkgo.It("parses the target and defaults the window", func() {
+		slo := ApplicationSLO{Name: "availability", Target: "99.9%", SLI: checks}
+		target, err := slo.TargetRatio()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(target).To(BeNumerically("~", 0.999, 1e-9))
+
+		window, err := slo.WindowDuration()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(window).To(Equal(30 * 24 * time.Hour))
+	})
+
+	ginkgo.It("accepts one indicator per SLI", func() {
+		Expect(spec(
+			ApplicationSLO{Name: "availability", Target: "99.9", Window: "7d", SLI: checks},
+			ApplicationSLO{Name: "latency", Target: "99", SLI: ApplicationSLI{
+				Prometheus: &ApplicationPrometheusSLI{Good: "sum(good)", Total: "sum(total)"},
+			}},
+			ApplicationSLO{Name: "health", Target: "95", SLI: ApplicationSLI{
+				ConfigHealth: &ApplicationConfigHealthSLI{Environments: []string{"Production"}},
+			}},
+		).ValidateSLOs()).To(Succeed())
+	})
+
+	ginkgo.DescribeTable("rejects invalid SLOs",
+		func(slo ApplicationSLO, message string) {
+			Expect(spec(slo).ValidateSLOs()).To(MatchError(ContainSubstring(message)))
+		},
+		ginkgo.Entry("missing name", ApplicationSLO{Target: "99", SLI: checks}, "name is required"),
+		ginkgo.Entry("target above 100", ApplicationSLO{Name: "a", Target: "100", SLI: checks}, "between 0 and 100"),
+		ginkgo.Entry("unparseable target", ApplicationSLO{Name: "a", Target: "high", SLI: checks}, "invalid target"),
+		ginkgo.Entry("invalid window", ApplicationSLO{Name: "a", Target: "99", Window: "soon", SLI: checks}, "invalid window"),
+		ginkgo.Entry("no indicator", ApplicationSLO{Name: "a", Target: "99"}, "exactly one"),
+		ginkgo.Entry("two indicators", ApplicationSLO{Name: "a", Target: "99", SLI: ApplicationSLI{
+			Checks:       checks.Checks,
+			ConfigHealth: &ApplicationConfigHealthSLI{},
+		}}, "exactly one"),
+		ginkgo.Entry("prometheus without total", ApplicationSLO{Name: "a", Target: "99", SLI: ApplicationSLI{
+			Prometheus: &ApplicationPrometheusSLI{Good: "sum(good)"},
+		}}, "both good and total"),
+		ginkgo.Entry("unmapped environment", ApplicationSLO{Name: "a", Target: "99", SLI: ApplicationSLI{
+			ConfigHealth: &ApplicationConfigHealthSLI{Environments: []string{"Staging"}},
+		}}, "not mapped"),
+	)
+
+	ginkgo.It("rejects duplicate names", func() {
+		slo := ApplicationSLO{Name: "availability", Target: "99", SLI: checks}
+		Expect(spec(slo, slo).ValidateSLOs()).To(MatchError(ContainSubstring("more than once")))
+	})
+})
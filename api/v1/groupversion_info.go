@@ -22,6 +22,7 @@ var (
 
 func addKnownTypes(scheme *runtime.Scheme) error {
 	scheme.AddKnownTypes(groupVersion,
+		&AgentDistribution{}, &AgentDistributionList{},
 		&Application{}, &ApplicationList{},
 		&Connection{}, &ConnectionList{},
 		&IncidentRule{}, &IncidentRuleList{},
@@ -34,6 +35,7 @@ func addKnownTypes(scheme *runtime.Scheme) error {
 		&Scope{}, &ScopeList{},
 		&Team{}, &TeamList{},
 		&View{}, &ViewList{},
+		&ViewSubscription{}, &ViewSubscriptionList{},
 	)
 
 	metav1.AddToGroupVersion(scheme, groupVersion)
@@ -760,7 +760,7 @@ type ReportAction struct {
 	// File selects the TSX template that renders the catalog report.
 	// When unset, the embedded CatalogReport.tsx is used.
 	File *ReportFile `json:"file,omitempty" yaml:"file,omitempty" template:"true"`
-	// Output format: json, csv, facet-html, facet-pdf, markdown, slack, html, pdf
+	// Output format: json, csv, xlsx, sarif, ocsf, facet-html, facet-pdf, markdown, slack, html, pdf
 	Format string `json:"format,omitempty" yaml:"format,omitempty" template:"true"`
 	// Variables passed to the view queries
 	Variables map[string]string `json:"variables,omitempty" yaml:"variables,omitempty" template:"true"`
@@ -797,6 +797,15 @@ type ReportAction struct {
 	Filters []string `json:"filters,omitempty" yaml:"filters,omitempty" template:"true"`
 	// Sections selects which catalog report sections to include.
 	Sections *ReportSections `json:"sections,omitempty" yaml:"sections,omitempty" template:"true"`
+	// Persist saves the catalog report so that later reports can be diffed against it. Defaults to false.
+	// The value must be true, false, or a template that renders to true or false.
+	// +kubebuilder:validation:Schemaless
+	// +kubebuilder:pruning:PreserveUnknownFields
+	Persist TemplatedBool `json:"persist,omitempty" yaml:"persist,omitempty" template:"true"`
+	// DiffAgainst adds what changed since a saved catalog report. It's the id of the report
+	// or a date (YYYY-MM-DD, RFC3339 or a duration ago e.g. 90d) that selects the latest
+	// report of the same configs generated at that time.
+	DiffAgainst string `json:"diffAgainst,omitempty" yaml:"diffAgainst,omitempty" template:"true"`
 }
 
 // ReportSections controls the catalog sections included in a report action.
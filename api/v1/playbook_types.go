@@ -152,6 +152,9 @@ type PlaybookTriggerEvents struct {
 	Canary    []PlaybookTriggerEvent `json:"canary,omitempty" yaml:"canary,omitempty"`
 	Config    []PlaybookTriggerEvent `json:"config,omitempty" yaml:"config,omitempty"`
 	Component []PlaybookTriggerEvent `json:"component,omitempty" yaml:"component,omitempty"`
+
+	// View triggers on view panel thresholds. The event is either breached or recovered.
+	View []PlaybookTriggerEvent `json:"view,omitempty" yaml:"view,omitempty"`
 }
 
 type PlaybookTriggerSchedule struct {
@@ -68,6 +68,12 @@ type PluginSpec struct {
 	// When set, deps refuses to install a binary with a different checksum.
 	Checksum string `json:"checksum,omitempty"`
 
+	// Signature requires the installed binary to be signed by a trusted signer.
+	// It is verified before the binary is launched, restarted or hot-reloaded.
+	// Ignored for remote plugins.
+	//+kubebuilder:validation:Optional
+	Signature *PluginSignature `json:"signature,omitempty"`
+
 	// Selector decides which catalog (config) items this plugin's tabs
 	// attach to. The same ResourceSelector semantics used by Playbook.Configs
 	// apply: filter by config type, labels, tags, agent, namespace, name.
@@ -97,6 +103,59 @@ type PluginSpec struct {
 	Properties map[string]string `json:"properties,omitempty"`
 }
 
+// PluginSignature describes how a plugin binary's provenance is verified.
+// Exactly one of PublicKey or Keyless must be set.
+//
+// +kubebuilder:validation:XValidation:rule="has(self.publicKey) != has(self.keyless)",message="exactly one of publicKey or keyless is required"
+// +kubebuilder:validation:XValidation:rule="has(self.signatureURL) || has(self.bundleURL)",message="signatureURL or bundleURL is required"
+type PluginSignature struct {
+	// PublicKey is a PEM encoded ECDSA, Ed25519 or RSA public key the binary
+	// must be signed with (e.g. `cosign sign-blob --key`).
+	//+kubebuilder:validation:Optional
+	PublicKey string `json:"publicKey,omitempty"`
+
+	// Keyless verifies the binary against the signing certificate shipped in
+	// the bundle instead of a pinned key.
+	//+kubebuilder:validation:Optional
+	Keyless *PluginKeylessIdentity `json:"keyless,omitempty"`
+
+	// SignatureURL is where the base64 encoded detached signature of the
+	// binary is downloaded from.
+	//+kubebuilder:validation:Optional
+	SignatureURL string `json:"signatureURL,omitempty"`
+
+	// BundleURL is where a `cosign sign-blob --bundle` bundle is downloaded
+	// from. The bundle carries the signature and, for keyless signing, the
+	// signing certificate.
+	//+kubebuilder:validation:Optional
+	BundleURL string `json:"bundleURL,omitempty"`
+}
+
+// PluginKeylessIdentity is the identity a keyless signing certificate must
+// have been issued to.
+type PluginKeylessIdentity struct {
+	// Issuer is the OIDC issuer recorded in the signing certificate,
+	// e.g. https://token.actions.githubusercontent.com
+	Issuer string `json:"issuer"`
+
+	// Subject is the identity (SAN) of the signer, e.g. the workflow URI or
+	// the email address the certificate was issued to.
+	Subject string `json:"subject"`
+
+	// Roots is a PEM bundle of the certificate authorities trusted to issue
+	// signing certificates.
+	Roots string `json:"roots"`
+
+	// RekorPublicKey is the PEM encoded public key of the transparency log
+	// that must have recorded the signature. The certificate is validated at
+	// the time the log recorded it.
+	RekorPublicKey string `json:"rekorPublicKey"`
+}
+
+// PluginConditionSignatureVerified reports whether the installed plugin
+// binary passed signature verification.
+const PluginConditionSignatureVerified = "SignatureVerified"
+
 // PluginStatus reflects the supervised state of the plugin process.
 type PluginStatus struct {
 	ObservedGeneration int64              `json:"observedGeneration,omitempty" yaml:"observedGeneration,omitempty"`
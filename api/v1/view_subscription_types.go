@@ -0,0 +1,129 @@
+package v1
+
+import (
+	"fmt"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Formats a view subscription can deliver.
+const (
+	ViewSubscriptionFormatPDF  = "pdf"
+	ViewSubscriptionFormatHTML = "html"
+	ViewSubscriptionFormatCSV  = "csv"
+	ViewSubscriptionFormatJSON = "json"
+	ViewSubscriptionFormatYAML = "yaml"
+)
+
+// ViewSubscriptionViewRef references the view to deliver.
+// +kubebuilder:object:generate=true
+type ViewSubscriptionViewRef struct {
+	Name string `json:"name"`
+
+	// Namespace of the view. Defaults to the namespace of the subscription.
+	//+kubebuilder:validation:Optional
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// +kubebuilder:object:generate=true
+type ViewSubscriptionSpec struct {
+	// View to export.
+	View ViewSubscriptionViewRef `json:"view"`
+
+	// Variables passed to the view queries.
+	//+kubebuilder:validation:Optional
+	Variables map[string]string `json:"variables,omitempty"`
+
+	// Format of the attachment.
+	// +kubebuilder:validation:Enum=pdf;html;csv;json;yaml
+	// +kubebuilder:default=pdf
+	//+kubebuilder:validation:Optional
+	Format string `json:"format,omitempty"`
+
+	// Facet rendering options for the pdf and html formats.
+	//+kubebuilder:validation:Optional
+	Facet *FacetOptions `json:"facet,omitempty"`
+
+	// Schedule is a cron expression e.g. "0 8 * * 1" for every Monday at 8am.
+	Schedule string `json:"schedule"`
+
+	// Timezone the schedule is evaluated in e.g. "Europe/London".
+	// Defaults to the timezone of the server.
+	//+kubebuilder:validation:Optional
+	Timezone string `json:"timezone,omitempty"`
+
+	// Title of the message. Defaults to the title of the view.
+	//+kubebuilder:validation:Optional
+	Title string `json:"title,omitempty"`
+
+	// Message sent along with the attachment.
+	//+kubebuilder:validation:Optional
+	Message string `json:"message,omitempty"`
+
+	// SkipEmpty skips the delivery when the view has no rows.
+	//+kubebuilder:validation:Optional
+	SkipEmpty bool `json:"skipEmpty,omitempty"`
+
+	// To is the list of recipients. Playbook and webhook recipients
+	// are not supported.
+	// +kubebuilder:validation:MinItems=1
+	To []NotificationRecipientSpec `json:"to"`
+}
+
+// ExportFormat returns the views export format of the subscription's format.
+func (t ViewSubscriptionSpec) ExportFormat() string {
+	switch t.Format {
+	case ViewSubscriptionFormatPDF, "":
+		return "facet-pdf"
+	case ViewSubscriptionFormatHTML:
+		return "facet-html"
+	default:
+		return t.Format
+	}
+}
+
+// CronSchedule returns the schedule with the timezone applied.
+func (t ViewSubscriptionSpec) CronSchedule() (string, error) {
+	if t.Schedule == "" {
+		return "", fmt.Errorf("schedule is required")
+	}
+
+	if t.Timezone == "" {
+		return t.Schedule, nil
+	}
+
+	if _, err := time.LoadLocation(t.Timezone); err != nil {
+		return "", fmt.Errorf("invalid timezone %q: %w", t.Timezone, err)
+	}
+
+	return fmt.Sprintf("CRON_TZ=%s %s", t.Timezone, t.Schedule), nil
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Namespaced
+//
+// ViewSubscription delivers the export of a view to recipients on a schedule.
+type ViewSubscription struct {
+	metav1.TypeMeta   `json:",inline" yaml:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty" yaml:"metadata,omitempty"`
+
+	Spec ViewSubscriptionSpec `json:"spec,omitempty" yaml:"spec,omitempty"`
+}
+
+// GetViewNamespace returns the namespace of the referenced view.
+func (t ViewSubscription) GetViewNamespace() string {
+	if t.Spec.View.Namespace != "" {
+		return t.Spec.View.Namespace
+	}
+	return t.Namespace
+}
+
+// +kubebuilder:object:root=true
+//
+// ViewSubscriptionList contains a list of ViewSubscription
+type ViewSubscriptionList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ViewSubscription `json:"items"`
+}
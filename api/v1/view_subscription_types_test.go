@@ -0,0 +1,30 @@
+package v1
+
+import (
+	ginkgo "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = ginkgo.Describe("ViewSubscriptionSpec", func() {
+	ginkgo.It("maps formats to export formats", func() {
+		Expect(ViewSubscriptionSpec{}.ExportFormat()).To(Equal("facet-pdf"))
+		Expect(ViewSubscriptionSpec{Format: ViewSubscriptionFormatPDF}.ExportFormat()).To(Equal("facet-pdf"))
+		Expect(ViewSubscriptionSpec{Format: ViewSubscriptionFormatHTML}.ExportFormat()).To(Equal("facet-html"))
+		Expect(ViewSubscriptionSpec{Format: ViewSubscriptionFormatCSV}.ExportFormat()).To(Equal("csv"))
+	})
+
+	ginkgo.It("applies the timezone to the schedule", func() {
+		schedule, err := ViewSubscriptionSpec{Schedule: "0 8 * * 1", Timezone: "Europe/London"}.CronSchedule()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(schedule).To(Equal("CRON_TZ=Europe/London 0 8 * * 1"))
+
+		schedule, err = ViewSubscriptionSpec{Schedule: "0 8 * * 1"}.CronSchedule()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(schedule).To(Equal("0 8 * * 1"))
+	})
+
+	ginkgo.It("rejects an unknown timezone", func() {
+		_, err := ViewSubscriptionSpec{Schedule: "0 8 * * 1", Timezone: "Mars/Olympus"}.CronSchedule()
+		Expect(err).To(HaveOccurred())
+	})
+})
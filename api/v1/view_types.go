@@ -3,6 +3,7 @@ package v1
 import (
 	"encoding/json"
 	"fmt"
+	"regexp"
 	"strings"
 	"time"
 
@@ -13,6 +14,7 @@ import (
 	"github.com/flanksource/kopper"
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/uuid"
+	"github.com/samber/lo"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	k8stypes "k8s.io/apimachinery/pkg/types"
@@ -116,6 +118,37 @@ type ViewSpec struct {
 	// MCP metadata for tool registration with LLM clients.
 	//+kubebuilder:validation:Optional
 	MCP MCPMetadata `json:"mcp,omitempty" yaml:"mcp,omitempty"`
+
+	// Metrics export panel values or table columns as Prometheus gauges on /metrics.
+	//+kubebuilder:validation:Optional
+	Metrics []ViewMetric `json:"metrics,omitempty" yaml:"metrics,omitempty"`
+}
+
+// ViewMetric maps the rows of a panel or of the view table to the series of a gauge.
+//
+// The rows are read from the cache of the view with the default variables and
+// are refreshed at most once per the max age of the cache.
+type ViewMetric struct {
+	// Name of the gauge. It's prefixed with the metrics prefix.
+	//+kubebuilder:validation:Pattern=`^[a-zA-Z_:][a-zA-Z0-9_:]*$`
+	Name string `json:"name" yaml:"name"`
+
+	// Help text of the gauge
+	Help string `json:"help,omitempty" yaml:"help,omitempty"`
+
+	// Panel is the name of the panel whose rows are exported.
+	// When empty, the rows of the view table are exported.
+	Panel string `json:"panel,omitempty" yaml:"panel,omitempty"`
+
+	// Value is the column that holds the value of the series.
+	// Defaults to the value of the panel, or to 1 for each table row so that
+	// the gauge counts the rows.
+	Value string `json:"value,omitempty" yaml:"value,omitempty"`
+
+	// Labels are the columns whose values label the series.
+	// Table rows with the same labels are summed while the last panel row wins,
+	// i.e. the latest value of a timeseries.
+	Labels []string `json:"labels,omitempty" yaml:"labels,omitempty"`
 }
 
 type ViewQueryWithColumnDefs struct {
@@ -128,6 +161,29 @@ type ViewQueryWithColumnDefs struct {
 	// If not provided, the column types will be inferred from the query results.
 	// However, if this isn't provided and the query results are empty, the query will result in an error.
 	Columns map[string]models.ColumnType `json:"columns,omitempty"`
+
+	// Plugin invokes a plugin operation and uses the result as the query rows.
+	Plugin *PluginViewQuery `json:"plugin,omitempty" yaml:"plugin,omitempty" template:"true"`
+}
+
+func (q ViewQueryWithColumnDefs) IsEmpty() bool {
+	return q.Plugin == nil && q.Query.IsEmpty()
+}
+
+// PluginViewQuery feeds a view query from a plugin operation.
+// The operation must return a JSON array of objects (or a single object),
+// each of which becomes a row.
+type PluginViewQuery struct {
+	// Name is the plugin id, namespace/name or unique name.
+	Name string `json:"name" yaml:"name" template:"true"`
+
+	Operation string `json:"operation" yaml:"operation" template:"true"`
+
+	// ConfigID is the config item the operation targets.
+	ConfigID string `json:"configID,omitempty" yaml:"configID,omitempty" template:"true"`
+
+	// Params are passed to the operation as a JSON object.
+	Params map[string]string `json:"params,omitempty" yaml:"params,omitempty" template:"true"`
 }
 
 func (t ViewSpec) Validate() error {
@@ -137,6 +193,26 @@ func (t ViewSpec) Validate() error {
 		}
 	}
 
+	for _, panel := range t.Panels {
+		if len(panel.Thresholds) > 0 && !panel.Type.SupportsThresholds() {
+			return fmt.Errorf("panel %s: thresholds are not supported on %s panels", panel.Name, panel.Type)
+		}
+
+		names := map[string]bool{}
+		for _, threshold := range panel.Thresholds {
+			if threshold.Condition == "" {
+				return fmt.Errorf("panel %s: threshold %s has no condition", panel.Name, threshold.Name)
+			} else if names[threshold.Name] {
+				return fmt.Errorf("panel %s: duplicate threshold %s", panel.Name, threshold.Name)
+			}
+			names[threshold.Name] = true
+		}
+	}
+
+	if err := t.validateMetrics(); err != nil {
+		return err
+	}
+
 	sectionOnlyView := len(t.Columns) == 0 && len(t.Panels) == 0 && len(t.Queries) == 0 && len(t.Sections) > 0
 	if sectionOnlyView {
 		// This is a view that only aggregates other views.
@@ -165,6 +241,38 @@ func (t ViewSpec) Validate() error {
 	return nil
 }
 
+var metricNameRegex = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+
+func (t ViewSpec) validateMetrics() error {
+	names := map[string]bool{}
+	for _, metric := range t.Metrics {
+		if !metricNameRegex.MatchString(metric.Name) {
+			return fmt.Errorf("metric %q: invalid name", metric.Name)
+		} else if names[metric.Name] {
+			return fmt.Errorf("duplicate metric %s", metric.Name)
+		}
+		names[metric.Name] = true
+
+		if metric.Panel != "" {
+			if !lo.ContainsBy(t.Panels, func(p api.PanelDef) bool { return p.Name == metric.Panel }) {
+				return fmt.Errorf("metric %s: panel %s not found", metric.Name, metric.Panel)
+			}
+			continue
+		}
+
+		if len(t.Columns) == 0 {
+			return fmt.Errorf("metric %s: a panel is required for views without a table", metric.Name)
+		}
+		for _, column := range append([]string{metric.Value}, metric.Labels...) {
+			if column != "" && !lo.ContainsBy(t.Columns, func(c view.ColumnDef) bool { return c.Name == column }) {
+				return fmt.Errorf("metric %s: column %s not found", metric.Name, column)
+			}
+		}
+	}
+
+	return nil
+}
+
 // ViewStatus defines the observed state of View
 type ViewStatus struct {
 	ObservedGeneration int64              `json:"observedGeneration,omitempty" yaml:"observedGeneration,omitempty"`
@@ -291,6 +399,57 @@ type ViewCache struct {
 	// RefreshTimeout is the duration to wait for a view to process before returning stale data.
 	// Default: 5s
 	RefreshTimeout string `json:"refreshTimeout,omitempty" yaml:"refreshTimeout,omitempty"`
+
+	// History keeps periodic snapshots of the view results so that
+	// past results can be read and compared.
+	// Disabled when not set.
+	History *ViewCacheHistory `json:"history,omitempty" yaml:"history,omitempty"`
+}
+
+type ViewCacheHistory struct {
+	// Interval is the minimum duration between two snapshots of the same request.
+	// Default: 1h
+	Interval string `json:"interval,omitempty" yaml:"interval,omitempty"`
+
+	// Retention is how long snapshots are kept.
+	// Default: 30d
+	Retention string `json:"retention,omitempty" yaml:"retention,omitempty"`
+
+	// MaxSnapshots is the most snapshots kept per request, oldest are
+	// deleted first.
+	// Default: 720
+	MaxSnapshots int `json:"maxSnapshots,omitempty" yaml:"maxSnapshots,omitempty"`
+
+	// Schedule is a cron expression on which the view is refreshed and
+	// snapshotted with its default variables, whether or not it is read.
+	// Without it snapshots are only taken when the view is refreshed.
+	Schedule string `json:"schedule,omitempty" yaml:"schedule,omitempty"`
+}
+
+// GetInterval returns the snapshot interval, defaulting to 1h.
+func (h ViewCacheHistory) GetInterval() (time.Duration, error) {
+	if h.Interval == "" {
+		return time.Hour, nil // Default
+	}
+	d, err := duration.ParseDuration(h.Interval)
+	return time.Duration(d), err
+}
+
+// GetRetention returns how long snapshots are kept, defaulting to 30d.
+func (h ViewCacheHistory) GetRetention() (time.Duration, error) {
+	if h.Retention == "" {
+		return 30 * 24 * time.Hour, nil // Default
+	}
+	d, err := duration.ParseDuration(h.Retention)
+	return time.Duration(d), err
+}
+
+// GetMaxSnapshots returns the most snapshots kept per request, defaulting to 720.
+func (h ViewCacheHistory) GetMaxSnapshots() int {
+	if h.MaxSnapshots <= 0 {
+		return 720 // Default
+	}
+	return h.MaxSnapshots
 }
 
 // CacheOptions represents cache control options from headers and spec
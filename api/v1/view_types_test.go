@@ -0,0 +1,42 @@
+package v1
+
+import (
+	"github.com/flanksource/duty/types"
+	"github.com/flanksource/duty/view"
+	ginkgo "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/flanksource/incident-commander/api"
+)
+
+var _ = ginkgo.Describe("ViewSpec metrics", func() {
+	spec := func(metrics ...ViewMetric) ViewSpec {
+		return ViewSpec{
+			Columns: view.ViewColumnDefList{
+				{Name: "name", Type: view.ColumnTypeString, PrimaryKey: true},
+				{Name: "health", Type: view.ColumnTypeHealth},
+			},
+			Panels:  []api.PanelDef{{PanelMeta: api.PanelMeta{Name: "Unhealthy", Type: api.PanelTypeNumber}}},
+			Queries: map[string]ViewQueryWithColumnDefs{"pods": {Query: view.Query{Configs: &types.ResourceSelector{Types: []string{"Kubernetes::Pod"}}}}},
+			Metrics: metrics,
+		}
+	}
+
+	ginkgo.It("accepts table and panel metrics", func() {
+		Expect(spec(
+			ViewMetric{Name: "pods", Labels: []string{"health"}},
+			ViewMetric{Name: "unhealthy_pods", Panel: "Unhealthy"},
+		).Validate()).To(Succeed())
+	})
+
+	ginkgo.It("rejects invalid names", func() {
+		Expect(spec(ViewMetric{Name: "pod-count"}).Validate()).To(MatchError(ContainSubstring("invalid name")))
+		Expect(spec(ViewMetric{Name: "pods"}, ViewMetric{Name: "pods"}).Validate()).To(MatchError(ContainSubstring("duplicate metric")))
+	})
+
+	ginkgo.It("rejects unknown panels and columns", func() {
+		Expect(spec(ViewMetric{Name: "pods", Panel: "Missing"}).Validate()).To(MatchError(ContainSubstring("panel Missing not found")))
+		Expect(spec(ViewMetric{Name: "pods", Labels: []string{"namespace"}}).Validate()).To(MatchError(ContainSubstring("column namespace not found")))
+		Expect(spec(ViewMetric{Name: "pods", Value: "restarts"}).Validate()).To(MatchError(ContainSubstring("column restarts not found")))
+	})
+})
@@ -162,6 +162,120 @@ func (in *AWSConnection) DeepCopy() *AWSConnection {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AgentDistribution) DeepCopyInto(out *AgentDistribution) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AgentDistribution.
+func (in *AgentDistribution) DeepCopy() *AgentDistribution {
+	if in == nil {
+		return nil
+	}
+	out := new(AgentDistribution)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *AgentDistribution) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AgentDistributionList) DeepCopyInto(out *AgentDistributionList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]AgentDistribution, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AgentDistributionList.
+func (in *AgentDistributionList) DeepCopy() *AgentDistributionList {
+	if in == nil {
+		return nil
+	}
+	out := new(AgentDistributionList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *AgentDistributionList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AgentDistributionResource) DeepCopyInto(out *AgentDistributionResource) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AgentDistributionResource.
+func (in *AgentDistributionResource) DeepCopy() *AgentDistributionResource {
+	if in == nil {
+		return nil
+	}
+	out := new(AgentDistributionResource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AgentDistributionSpec) DeepCopyInto(out *AgentDistributionSpec) {
+	*out = *in
+	in.Agents.DeepCopyInto(&out.Agents)
+	if in.Resources != nil {
+		in, out := &in.Resources, &out.Resources
+		*out = make([]AgentDistributionResource, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AgentDistributionSpec.
+func (in *AgentDistributionSpec) DeepCopy() *AgentDistributionSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(AgentDistributionSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AgentDistributionTarget) DeepCopyInto(out *AgentDistributionTarget) {
+	*out = *in
+	if in.Names != nil {
+		in, out := &in.Names, &out.Names
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AgentDistributionTarget.
+func (in *AgentDistributionTarget) DeepCopy() *AgentDistributionTarget {
+	if in == nil {
+		return nil
+	}
+	out := new(AgentDistributionTarget)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Application) DeepCopyInto(out *Application) {
 	*out = *in
@@ -189,6 +303,78 @@ func (in *Application) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ApplicationBackupPolicy) DeepCopyInto(out *ApplicationBackupPolicy) {
+	*out = *in
+	if in.Datasources != nil {
+		in, out := &in.Datasources, &out.Datasources
+		*out = make([]types.ResourceSelector, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ApplicationBackupPolicy.
+func (in *ApplicationBackupPolicy) DeepCopy() *ApplicationBackupPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(ApplicationBackupPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ApplicationConfigHealthSLI) DeepCopyInto(out *ApplicationConfigHealthSLI) {
+	*out = *in
+	if in.Environments != nil {
+		in, out := &in.Environments, &out.Environments
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ApplicationConfigHealthSLI.
+func (in *ApplicationConfigHealthSLI) DeepCopy() *ApplicationConfigHealthSLI {
+	if in == nil {
+		return nil
+	}
+	out := new(ApplicationConfigHealthSLI)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ApplicationDORA) DeepCopyInto(out *ApplicationDORA) {
+	*out = *in
+	if in.DeploymentChangeTypes != nil {
+		in, out := &in.DeploymentChangeTypes, &out.DeploymentChangeTypes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.FailureChangeTypes != nil {
+		in, out := &in.FailureChangeTypes, &out.FailureChangeTypes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.MergeChangeTypes != nil {
+		in, out := &in.MergeChangeTypes, &out.MergeChangeTypes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ApplicationDORA.
+func (in *ApplicationDORA) DeepCopy() *ApplicationDORA {
+	if in == nil {
+		return nil
+	}
+	out := new(ApplicationDORA)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ApplicationEnvironment) DeepCopyInto(out *ApplicationEnvironment) {
 	*out = *in
@@ -298,6 +484,22 @@ func (in *ApplicationMapping) DeepCopy() *ApplicationMapping {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ApplicationPrometheusSLI) DeepCopyInto(out *ApplicationPrometheusSLI) {
+	*out = *in
+	in.PrometheusConnection.DeepCopyInto(&out.PrometheusConnection)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ApplicationPrometheusSLI.
+func (in *ApplicationPrometheusSLI) DeepCopy() *ApplicationPrometheusSLI {
+	if in == nil {
+		return nil
+	}
+	out := new(ApplicationPrometheusSLI)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ApplicationRoleMapping) DeepCopyInto(out *ApplicationRoleMapping) {
 	*out = *in
@@ -314,6 +516,54 @@ func (in *ApplicationRoleMapping) DeepCopy() *ApplicationRoleMapping {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ApplicationSLI) DeepCopyInto(out *ApplicationSLI) {
+	*out = *in
+	if in.Checks != nil {
+		in, out := &in.Checks, &out.Checks
+		*out = make([]types.ResourceSelector, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Prometheus != nil {
+		in, out := &in.Prometheus, &out.Prometheus
+		*out = new(ApplicationPrometheusSLI)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ConfigHealth != nil {
+		in, out := &in.ConfigHealth, &out.ConfigHealth
+		*out = new(ApplicationConfigHealthSLI)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ApplicationSLI.
+func (in *ApplicationSLI) DeepCopy() *ApplicationSLI {
+	if in == nil {
+		return nil
+	}
+	out := new(ApplicationSLI)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ApplicationSLO) DeepCopyInto(out *ApplicationSLO) {
+	*out = *in
+	in.SLI.DeepCopyInto(&out.SLI)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ApplicationSLO.
+func (in *ApplicationSLO) DeepCopy() *ApplicationSLO {
+	if in == nil {
+		return nil
+	}
+	out := new(ApplicationSLO)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ApplicationSpec) DeepCopyInto(out *ApplicationSpec) {
 	*out = *in
@@ -332,6 +582,25 @@ func (in *ApplicationSpec) DeepCopyInto(out *ApplicationSpec) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.SLOs != nil {
+		in, out := &in.SLOs, &out.SLOs
+		*out = make([]ApplicationSLO, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.DORA != nil {
+		in, out := &in.DORA, &out.DORA
+		*out = new(ApplicationDORA)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.BackupPolicies != nil {
+		in, out := &in.BackupPolicies, &out.BackupPolicies
+		*out = make([]ApplicationBackupPolicy, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ApplicationSpec.
@@ -3348,6 +3617,13 @@ func (in *PlaybookTriggerEvents) DeepCopyInto(out *PlaybookTriggerEvents) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.View != nil {
+		in, out := &in.View, &out.View
+		*out = make([]PlaybookTriggerEvent, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlaybookTriggerEvents.
@@ -3458,6 +3734,21 @@ func (in *PluginConnectionMappings) DeepCopy() *PluginConnectionMappings {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PluginKeylessIdentity) DeepCopyInto(out *PluginKeylessIdentity) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PluginKeylessIdentity.
+func (in *PluginKeylessIdentity) DeepCopy() *PluginKeylessIdentity {
+	if in == nil {
+		return nil
+	}
+	out := new(PluginKeylessIdentity)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PluginList) DeepCopyInto(out *PluginList) {
 	*out = *in
@@ -3490,9 +3781,34 @@ func (in *PluginList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PluginSignature) DeepCopyInto(out *PluginSignature) {
+	*out = *in
+	if in.Keyless != nil {
+		in, out := &in.Keyless, &out.Keyless
+		*out = new(PluginKeylessIdentity)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PluginSignature.
+func (in *PluginSignature) DeepCopy() *PluginSignature {
+	if in == nil {
+		return nil
+	}
+	out := new(PluginSignature)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PluginSpec) DeepCopyInto(out *PluginSpec) {
 	*out = *in
+	if in.Signature != nil {
+		in, out := &in.Signature, &out.Signature
+		*out = new(PluginSignature)
+		(*in).DeepCopyInto(*out)
+	}
 	in.Selector.DeepCopyInto(&out.Selector)
 	in.Connections.DeepCopyInto(&out.Connections)
 	if in.Audit != nil {
@@ -3541,6 +3857,28 @@ func (in *PluginStatus) DeepCopy() *PluginStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PluginViewQuery) DeepCopyInto(out *PluginViewQuery) {
+	*out = *in
+	if in.Params != nil {
+		in, out := &in.Params, &out.Params
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PluginViewQuery.
+func (in *PluginViewQuery) DeepCopy() *PluginViewQuery {
+	if in == nil {
+		return nil
+	}
+	out := new(PluginViewQuery)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PodAction) DeepCopyInto(out *PodAction) {
 	*out = *in
@@ -3621,6 +3959,11 @@ func (in *ReportAction) DeepCopyInto(out *ReportAction) {
 		*out = new(ReportSections)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Persist != nil {
+		in, out := &in.Persist, &out.Persist
+		*out = make(TemplatedBool, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReportAction.
@@ -4066,6 +4409,11 @@ func (in *View) DeepCopyObject() runtime.Object {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ViewCache) DeepCopyInto(out *ViewCache) {
 	*out = *in
+	if in.History != nil {
+		in, out := &in.History, &out.History
+		*out = new(ViewCacheHistory)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ViewCache.
@@ -4078,6 +4426,21 @@ func (in *ViewCache) DeepCopy() *ViewCache {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ViewCacheHistory) DeepCopyInto(out *ViewCacheHistory) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ViewCacheHistory.
+func (in *ViewCacheHistory) DeepCopy() *ViewCacheHistory {
+	if in == nil {
+		return nil
+	}
+	out := new(ViewCacheHistory)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ViewConfigUIPlugin) DeepCopyInto(out *ViewConfigUIPlugin) {
 	*out = *in
@@ -4165,6 +4528,26 @@ func (in *ViewList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ViewMetric) DeepCopyInto(out *ViewMetric) {
+	*out = *in
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ViewMetric.
+func (in *ViewMetric) DeepCopy() *ViewMetric {
+	if in == nil {
+		return nil
+	}
+	out := new(ViewMetric)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ViewQueryWithColumnDefs) DeepCopyInto(out *ViewQueryWithColumnDefs) {
 	*out = *in
@@ -4176,6 +4559,11 @@ func (in *ViewQueryWithColumnDefs) DeepCopyInto(out *ViewQueryWithColumnDefs) {
 			(*out)[key] = val
 		}
 	}
+	if in.Plugin != nil {
+		in, out := &in.Plugin, &out.Plugin
+		*out = new(PluginViewQuery)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ViewQueryWithColumnDefs.
@@ -4225,7 +4613,7 @@ func (in *ViewSpec) DeepCopyInto(out *ViewSpec) {
 			(*out)[key] = val
 		}
 	}
-	out.Cache = in.Cache
+	in.Cache.DeepCopyInto(&out.Cache)
 	if in.Templating != nil {
 		in, out := &in.Templating, &out.Templating
 		*out = make([]api.ViewVariable, len(*in))
@@ -4246,6 +4634,13 @@ func (in *ViewSpec) DeepCopyInto(out *ViewSpec) {
 		(*in).DeepCopyInto(*out)
 	}
 	in.MCP.DeepCopyInto(&out.MCP)
+	if in.Metrics != nil {
+		in, out := &in.Metrics, &out.Metrics
+		*out = make([]ViewMetric, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ViewSpec.
@@ -4283,3 +4678,111 @@ func (in *ViewStatus) DeepCopy() *ViewStatus {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ViewSubscription) DeepCopyInto(out *ViewSubscription) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ViewSubscription.
+func (in *ViewSubscription) DeepCopy() *ViewSubscription {
+	if in == nil {
+		return nil
+	}
+	out := new(ViewSubscription)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ViewSubscription) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ViewSubscriptionList) DeepCopyInto(out *ViewSubscriptionList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ViewSubscription, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ViewSubscriptionList.
+func (in *ViewSubscriptionList) DeepCopy() *ViewSubscriptionList {
+	if in == nil {
+		return nil
+	}
+	out := new(ViewSubscriptionList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ViewSubscriptionList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ViewSubscriptionSpec) DeepCopyInto(out *ViewSubscriptionSpec) {
+	*out = *in
+	out.View = in.View
+	if in.Variables != nil {
+		in, out := &in.Variables, &out.Variables
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Facet != nil {
+		in, out := &in.Facet, &out.Facet
+		*out = new(FacetOptions)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.To != nil {
+		in, out := &in.To, &out.To
+		*out = make([]NotificationRecipientSpec, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ViewSubscriptionSpec.
+func (in *ViewSubscriptionSpec) DeepCopy() *ViewSubscriptionSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ViewSubscriptionSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ViewSubscriptionViewRef) DeepCopyInto(out *ViewSubscriptionViewRef) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ViewSubscriptionViewRef.
+func (in *ViewSubscriptionViewRef) DeepCopy() *ViewSubscriptionViewRef {
+	if in == nil {
+		return nil
+	}
+	out := new(ViewSubscriptionViewRef)
+	in.DeepCopyInto(out)
+	return out
+}
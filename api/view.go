@@ -417,10 +417,51 @@ const (
 	ViewRefreshStatusFresh = "fresh"
 	ViewRefreshStatusError = "error"
 
-	ViewResponseSourceCache = "cache"
-	ViewResponseSourceFresh = "fresh"
+	ViewResponseSourceCache    = "cache"
+	ViewResponseSourceFresh    = "fresh"
+	ViewResponseSourceSnapshot = "snapshot"
 )
 
+// ViewSnapshotResult is a view result read from a historical snapshot.
+//
+// Unlike a live result, the rows are returned inline as the view table
+// only holds the latest results.
+type ViewSnapshotResult struct {
+	ViewResult `json:",inline"`
+
+	SnapshotID uuid.UUID        `json:"snapshotId"`
+	SnapshotAt time.Time        `json:"snapshotAt"`
+	Data       []map[string]any `json:"data"`
+}
+
+// ViewSnapshotSummary identifies a snapshot of a view.
+type ViewSnapshotSummary struct {
+	ID        uuid.UUID `json:"id"`
+	CreatedAt time.Time `json:"createdAt"`
+	RowCount  int       `json:"rowCount"`
+}
+
+// ViewDiff is the difference between the rows of two snapshots of a view.
+// Rows are matched on the primary key columns of the view.
+type ViewDiff struct {
+	From ViewSnapshotSummary `json:"from"`
+	To   ViewSnapshotSummary `json:"to"`
+
+	Added   []map[string]any `json:"added"`
+	Removed []map[string]any `json:"removed"`
+	Changed []ViewRowChange  `json:"changed"`
+}
+
+// ViewRowChange is a row that exists in both snapshots with different values.
+type ViewRowChange struct {
+	Key    map[string]any `json:"key"`
+	Before map[string]any `json:"before"`
+	After  map[string]any `json:"after"`
+
+	// Fields are the columns whose values changed.
+	Fields []string `json:"fields"`
+}
+
 // +kubebuilder:object:generate=true
 // +kubebuilder:validation:XValidation:rule="(has(self.values) && !has(self.valueFrom)) || (!has(self.values) && has(self.valueFrom))",message="exactly one of values or valueFrom is required"
 //
@@ -30,11 +30,56 @@ const (
 // +kubebuilder:validation:XValidation:rule="self.type!='bargauge' ? !has(self.bargauge) : true",message="bargauge config not allowed for this type"
 // +kubebuilder:validation:XValidation:rule="self.type!='timeseries' ? !has(self.timeseries) : true",message="timeseries config not allowed for this type"
 // +kubebuilder:validation:XValidation:rule="self.type!='heatmap' ? !has(self.heatmap) : true",message="heatmap config not allowed for this type"
+// +kubebuilder:validation:XValidation:rule="has(self.thresholds) ? self.type in ['number', 'gauge', 'bargauge', 'timeseries'] : true",message="thresholds are only allowed on number, gauge, bargauge and timeseries panels"
 type PanelDef struct {
 	PanelMeta `json:",inline" yaml:",inline"`
 
 	// Query is a raw SQL query that has access to the queries as tables
 	Query string `json:"query" yaml:"query"`
+
+	// Thresholds emit view.threshold.breached and view.threshold.recovered
+	// events when their condition changes.
+	Thresholds []PanelThreshold `json:"thresholds,omitempty" yaml:"thresholds,omitempty"`
+}
+
+// PanelThreshold is an alert on the result of a panel.
+// +kubebuilder:object:generate=true
+type PanelThreshold struct {
+	// Name of the threshold. Must be unique within the panel.
+	Name string `json:"name" yaml:"name"`
+
+	// Condition is a CEL expression that is true when the threshold is breached.
+	// It has access to:
+	//   - value: the value of the panel (the last row for timeseries)
+	//   - panel.values: the values of all the rows
+	//   - panel.rows: the rows of the panel
+	//
+	// e.g. "value > 3" or "panel.values.exists(v, v > 10)"
+	Condition string `json:"condition" yaml:"condition"`
+
+	// Severity of the breach e.g. critical, warning
+	Severity string `json:"severity,omitempty" yaml:"severity,omitempty"`
+
+	// Message describes the breach. Supports Go templates with the same
+	// variables as the condition.
+	Message string `json:"message,omitempty" yaml:"message,omitempty"`
+}
+
+// SupportsThresholds returns true if the panel type can declare thresholds.
+func (p PanelType) SupportsThresholds() bool {
+	switch p {
+	case PanelTypeNumber, PanelTypeGauge, PanelTypeBargauge, PanelTypeTimeseries:
+		return true
+	}
+	return false
+}
+
+// ValueKey returns the column that holds the value of the panel.
+func (p PanelMeta) ValueKey() string {
+	if p.Type == PanelTypeTimeseries && p.Timeseries != nil && p.Timeseries.ValueKey != "" {
+		return p.Timeseries.ValueKey
+	}
+	return "value"
 }
 
 // +kubebuilder:object:generate=true
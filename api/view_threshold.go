@@ -0,0 +1,109 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/flanksource/duty/dataquery"
+)
+
+// ViewThresholdEvent is the state of a panel threshold carried by the
+// view.threshold.breached and view.threshold.recovered events.
+type ViewThresholdEvent struct {
+	ViewID    string `json:"view_id"`
+	View      string `json:"view"`
+	Namespace string `json:"namespace"`
+	Panel     string `json:"panel"`
+	PanelType string `json:"panel_type"`
+	Threshold string `json:"threshold"`
+	Condition string `json:"condition"`
+	Severity  string `json:"severity,omitempty"`
+	Message   string `json:"message,omitempty"`
+
+	// Value of the panel when the threshold was evaluated.
+	Value  any                        `json:"value,omitempty"`
+	Values []any                      `json:"values,omitempty"`
+	Rows   []dataquery.QueryResultRow `json:"rows,omitempty"`
+}
+
+// Properties returns the event as event_queue properties.
+// The value, values and rows are JSON encoded.
+func (t ViewThresholdEvent) Properties() (map[string]string, error) {
+	properties := map[string]string{
+		"view_id":    t.ViewID,
+		"view":       t.View,
+		"namespace":  t.Namespace,
+		"panel":      t.Panel,
+		"panel_type": t.PanelType,
+		"threshold":  t.Threshold,
+		"condition":  t.Condition,
+		"severity":   t.Severity,
+		"message":    t.Message,
+	}
+
+	for key, v := range map[string]any{"value": t.Value, "values": t.Values, "rows": t.Rows} {
+		b, err := json.Marshal(v)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal %s: %w", key, err)
+		}
+		properties[key] = string(b)
+	}
+
+	return properties, nil
+}
+
+// ViewThresholdEventFromProperties is the inverse of ViewThresholdEvent.Properties.
+func ViewThresholdEventFromProperties(properties map[string]string) (*ViewThresholdEvent, error) {
+	t := &ViewThresholdEvent{
+		ViewID:    properties["view_id"],
+		View:      properties["view"],
+		Namespace: properties["namespace"],
+		Panel:     properties["panel"],
+		PanelType: properties["panel_type"],
+		Threshold: properties["threshold"],
+		Condition: properties["condition"],
+		Severity:  properties["severity"],
+		Message:   properties["message"],
+	}
+
+	for key, v := range map[string]any{"value": &t.Value, "values": &t.Values, "rows": &t.Rows} {
+		if raw := properties[key]; raw != "" {
+			if err := json.Unmarshal([]byte(raw), v); err != nil {
+				return nil, fmt.Errorf("invalid %s: %w", key, err)
+			}
+		}
+	}
+
+	return t, nil
+}
+
+// AsMap returns the CEL env of the event. It has the same shape as the env
+// the condition of the threshold was evaluated with.
+func (t ViewThresholdEvent) AsMap() map[string]any {
+	rows := make([]any, 0, len(t.Rows))
+	for _, row := range t.Rows {
+		rows = append(rows, map[string]any(row))
+	}
+
+	return map[string]any{
+		"view": map[string]any{
+			"id":        t.ViewID,
+			"name":      t.View,
+			"namespace": t.Namespace,
+		},
+		"panel": map[string]any{
+			"name":   t.Panel,
+			"type":   t.PanelType,
+			"value":  t.Value,
+			"values": t.Values,
+			"rows":   rows,
+		},
+		"threshold": map[string]any{
+			"name":      t.Threshold,
+			"condition": t.Condition,
+			"severity":  t.Severity,
+			"message":   t.Message,
+		},
+		"value": t.Value,
+	}
+}
@@ -0,0 +1,45 @@
+package api_test
+
+import (
+	"github.com/flanksource/duty/dataquery"
+	ginkgo "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/flanksource/incident-commander/api"
+)
+
+var _ = ginkgo.Describe("ViewThresholdEvent", func() {
+	event := api.ViewThresholdEvent{
+		ViewID:    "0198c1c5-7c8a-7d5c-9c42-1f3e3a3b8a11",
+		View:      "backups",
+		Namespace: "mc",
+		Panel:     "Failing Backups",
+		PanelType: string(api.PanelTypeNumber),
+		Threshold: "too-many-failures",
+		Condition: "value > 3",
+		Severity:  "critical",
+		Message:   "5 backups are failing",
+		Value:     float64(5),
+		Values:    []any{float64(5)},
+		Rows:      []dataquery.QueryResultRow{{"value": float64(5)}},
+	}
+
+	ginkgo.It("round trips through event properties", func() {
+		properties, err := event.Properties()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(properties["value"]).To(Equal("5"))
+
+		parsed, err := api.ViewThresholdEventFromProperties(properties)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(*parsed).To(Equal(event))
+	})
+
+	ginkgo.It("exposes the panel values in the CEL env", func() {
+		env := event.AsMap()
+		Expect(env["value"]).To(Equal(float64(5)))
+		Expect(env["view"]).To(HaveKeyWithValue("name", "backups"))
+		Expect(env["panel"]).To(HaveKeyWithValue("name", "Failing Backups"))
+		Expect(env["threshold"]).To(HaveKeyWithValue("severity", "critical"))
+		Expect(env["panel"]).To(HaveKeyWithValue("rows", HaveLen(1)))
+	})
+})
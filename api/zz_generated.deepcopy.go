@@ -437,6 +437,11 @@ func (in *PanelBargaugeConfig) DeepCopy() *PanelBargaugeConfig {
 func (in *PanelDef) DeepCopyInto(out *PanelDef) {
 	*out = *in
 	in.PanelMeta.DeepCopyInto(&out.PanelMeta)
+	if in.Thresholds != nil {
+		in, out := &in.Thresholds, &out.Thresholds
+		*out = make([]PanelThreshold, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PanelDef.
@@ -560,6 +565,21 @@ func (in *PanelTableConfig) DeepCopy() *PanelTableConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PanelThreshold) DeepCopyInto(out *PanelThreshold) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PanelThreshold.
+func (in *PanelThreshold) DeepCopy() *PanelThreshold {
+	if in == nil {
+		return nil
+	}
+	out := new(PanelThreshold)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PanelTimeseriesConfig) DeepCopyInto(out *PanelTimeseriesConfig) {
 	*out = *in
@@ -1,6 +1,8 @@
 package application
 
 import (
+	"time"
+
 	"github.com/flanksource/duty/context"
 	"github.com/flanksource/duty/models"
 	"github.com/flanksource/duty/query"
@@ -126,6 +128,22 @@ func buildApplication(ctx context.Context, app *v1.Application) (*api.Applicatio
 		}
 	}
 
+	if len(app.Spec.SLOs) > 0 {
+		slos, err := getSLOStatuses(ctx, app)
+		if err != nil {
+			return nil, ctx.Oops().Errorf("failed to get slos: %w", err)
+		}
+		response.SLOs = slos
+	}
+
+	if len(app.Spec.BackupPolicies) > 0 {
+		compliance, err := GetBackupCompliance(ctx, app, time.Now())
+		if err != nil {
+			return nil, ctx.Oops().Errorf("failed to get backup compliance: %w", err)
+		}
+		response.BackupCompliance = compliance
+	}
+
 	for _, section := range app.Spec.Sections {
 		appSection, err := buildSection(ctx, section)
 		if err != nil {
@@ -134,6 +152,19 @@ func buildApplication(ctx context.Context, app *v1.Application) (*api.Applicatio
 		response.Sections = append(response.Sections, appSection)
 	}
 
+	if app.Spec.DORA != nil {
+		metrics, err := GetDORAMetrics(ctx, app, time.Now())
+		if err != nil {
+			return nil, ctx.Oops().Errorf("failed to compute dora metrics: %w", err)
+		}
+		response.Sections = append(response.Sections, api.ApplicationSection{
+			Type:  api.SectionTypeDORA,
+			Title: "DORA Metrics",
+			Icon:  "rocket",
+			DORA:  metrics,
+		})
+	}
+
 	return &response, nil
 }
 
@@ -227,6 +258,18 @@ func viewResultToSectionData(r *api.ViewResult) api.ApplicationViewData {
 }
 
 func PersistApplication(ctx context.Context, app *v1.Application) error {
+	if err := app.Spec.ValidateSLOs(); err != nil {
+		return ctx.Oops().Errorf("invalid slos: %w", err)
+	}
+	if app.Spec.DORA != nil {
+		if err := app.Spec.DORA.Validate(); err != nil {
+			return ctx.Oops().Errorf("invalid dora: %w", err)
+		}
+	}
+	if err := app.Spec.ValidateBackupPolicies(); err != nil {
+		return ctx.Oops().Errorf("invalid backup policies: %w", err)
+	}
+
 	if err := db.PersistApplicationFromCRD(ctx, app); err != nil {
 		return err
 	}
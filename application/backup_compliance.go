@@ -0,0 +1,377 @@
+package application
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/flanksource/commons/duration"
+	"github.com/flanksource/duty/context"
+	"github.com/flanksource/duty/job"
+	"github.com/flanksource/duty/models"
+	"github.com/flanksource/duty/query"
+	"github.com/flanksource/duty/types"
+	"github.com/google/uuid"
+	"github.com/samber/lo"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm/clause"
+
+	"github.com/flanksource/incident-commander/api"
+	v1 "github.com/flanksource/incident-commander/api/v1"
+	"github.com/flanksource/incident-commander/db"
+	"github.com/flanksource/incident-commander/events"
+)
+
+// BackupInsightSource is the source of the insights of backup policy violations.
+const BackupInsightSource = "application-backup-policy"
+
+var (
+	successfulBackupChangeTypes = []string{"BackupCompleted", "BackupSuccessful"}
+
+	backupAnalyzers = map[string]string{
+		api.BackupObjectiveRPO:         "backup-rpo",
+		api.BackupObjectiveRTO:         "backup-rto",
+		api.BackupObjectiveRestoreTest: "backup-restore-test",
+	}
+)
+
+// CheckBackupCompliance evaluates the datasources of every application against
+// its backup policies, records violations as insights and emits an event
+// when a datasource starts or stops meeting an objective.
+func CheckBackupCompliance(ctx context.Context) *job.Job {
+	return &job.Job{
+		Name:       "CheckBackupCompliance",
+		Schedule:   "@every 15m",
+		Context:    ctx,
+		Singleton:  true,
+		Retention:  job.RetentionFailed,
+		JobHistory: true,
+		RunNow:     true,
+		Fn: func(run job.JobRuntime) error {
+			ctx := run.Context
+			if api.SystemUserID != nil {
+				ctx = ctx.WithUser(&models.Person{ID: *api.SystemUserID})
+			}
+
+			applications, err := db.GetAllApplications(ctx)
+			if err != nil {
+				return ctx.Oops().Errorf("failed to get applications: %w", err)
+			}
+
+			for _, application := range applications {
+				app, err := v1.ApplicationFromModel(application)
+				if err != nil {
+					run.History.AddErrorf("failed to get application %s/%s: %v", application.Namespace, application.Name, err)
+					continue
+				} else if len(app.Spec.BackupPolicies) == 0 {
+					continue
+				}
+
+				compliance, err := GetBackupCompliance(ctx, app, time.Now())
+				if err != nil {
+					run.History.AddErrorf("application %s/%s: %v", app.Namespace, app.Name, err)
+					continue
+				}
+
+				for _, c := range compliance {
+					if err := saveBackupCompliance(ctx, app, c, time.Now()); err != nil {
+						run.History.AddErrorf("application %s/%s: datasource %s: %v", app.Namespace, app.Name, c.Name, err)
+						continue
+					}
+					run.History.IncrSuccess()
+				}
+			}
+
+			return nil
+		},
+	}
+}
+
+// BackupInsightID returns the id of the insight of an objective of a datasource.
+// It's also the event_id of the events of the objective.
+func BackupInsightID(applicationID, configID, objective string) uuid.UUID {
+	return uuid.NewSHA1(uuid.NameSpaceOID, []byte(fmt.Sprintf("%s/%s/%s/%s", BackupInsightSource, applicationID, configID, objective)))
+}
+
+// GetBackupCompliance evaluates every datasource of the application that's
+// covered by a backup policy.
+func GetBackupCompliance(ctx context.Context, app *v1.Application, now time.Time) ([]api.ApplicationBackupCompliance, error) {
+	if len(app.Spec.BackupPolicies) == 0 || len(app.Spec.Mapping.Datasources) == 0 {
+		return nil, nil
+	}
+
+	datasources, err := query.FindConfigsByResourceSelector(ctx, -1, app.Spec.Mapping.Datasources...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find datasources: %w", err)
+	}
+	datasources = lo.UniqBy(datasources, func(c models.ConfigItem) uuid.UUID { return c.ID })
+	configIDs := lo.Map(datasources, func(c models.ConfigItem, _ int) uuid.UUID { return c.ID })
+
+	policies, err := matchBackupPolicies(ctx, app, configIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	backups, err := db.GetApplicationBackups(ctx, configIDs, backupChangeTypes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find backups: %w", err)
+	}
+	restores, err := db.GetApplicationRestores(ctx, configIDs, backupRestoreChangeTypes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find restores: %w", err)
+	}
+	backupsByConfig := lo.GroupBy(backups, func(b db.ApplicationBackup) uuid.UUID { return b.ConfigID })
+	restoresByConfig := lo.GroupBy(restores, func(r db.ApplicationRestore) uuid.UUID { return r.ConfigID })
+
+	var compliance []api.ApplicationBackupCompliance
+	for _, datasource := range datasources {
+		i, ok := policies[datasource.ID]
+		if !ok {
+			continue
+		}
+
+		policy := app.Spec.BackupPolicies[i]
+		c, err := evaluateBackupPolicy(policy, backupsByConfig[datasource.ID], restoresByConfig[datasource.ID], now)
+		if err != nil {
+			return nil, fmt.Errorf("backup policy %d: %w", i, err)
+		}
+		c.ConfigID = datasource.ID.String()
+		c.Name = lo.FromPtr(datasource.Name)
+		c.Type = lo.FromPtr(datasource.Type)
+		c.Environment = policy.Environment
+		compliance = append(compliance, c)
+	}
+
+	sort.Slice(compliance, func(i, j int) bool { return compliance[i].Name < compliance[j].Name })
+	return compliance, nil
+}
+
+// matchBackupPolicies returns the index of the first policy that matches
+// each datasource.
+func matchBackupPolicies(ctx context.Context, app *v1.Application, datasources []uuid.UUID) (map[uuid.UUID]int, error) {
+	matched := map[uuid.UUID]int{}
+	for i, policy := range app.Spec.BackupPolicies {
+		candidates := datasources
+
+		if len(policy.Datasources) > 0 {
+			ids, err := query.FindConfigIDsByResourceSelector(ctx, -1, policy.Datasources...)
+			if err != nil {
+				return nil, fmt.Errorf("backup policy %d: failed to find datasources: %w", i, err)
+			}
+			candidates = lo.Intersect(candidates, ids)
+		}
+
+		if policy.Environment != "" {
+			selectors := lo.Map(app.Spec.Mapping.Environments[policy.Environment], func(e v1.ApplicationEnvironment, _ int) types.ResourceSelector {
+				return e.ResourceSelector
+			})
+			ids, err := query.FindConfigIDsByResourceSelector(ctx, -1, selectors...)
+			if err != nil {
+				return nil, fmt.Errorf("backup policy %d: failed to find configs of environment %s: %w", i, policy.Environment, err)
+			}
+			candidates = lo.Intersect(candidates, ids)
+		}
+
+		for _, id := range candidates {
+			if _, ok := matched[id]; !ok {
+				matched[id] = i
+			}
+		}
+	}
+	return matched, nil
+}
+
+// evaluateBackupPolicy checks the backups and restores of a datasource,
+// oldest first, against the objectives of the policy.
+//
+// A restore is measured from a BackupRestored change to the next
+// RestoreCompleted change.
+func evaluateBackupPolicy(policy v1.ApplicationBackupPolicy, backups []db.ApplicationBackup, restores []db.ApplicationRestore, now time.Time) (api.ApplicationBackupCompliance, error) {
+	c := api.ApplicationBackupCompliance{
+		RPO:                 policy.RPO,
+		RTO:                 policy.RTO,
+		RestoreTestInterval: policy.RestoreTestInterval,
+	}
+
+	rpo, rto, restoreTest, err := policy.Objectives()
+	if err != nil {
+		return c, err
+	}
+
+	for _, b := range backups {
+		if lo.Contains(successfulBackupChangeTypes, b.ChangeType) && !isFailedStatus(b.Status) {
+			if c.LastBackup == nil || b.CreatedAt.After(*c.LastBackup) {
+				c.LastBackup = lo.ToPtr(b.CreatedAt)
+			}
+		}
+	}
+
+	var restoreStarted *time.Time
+	for _, r := range restores {
+		if isFailedStatus(r.Status) {
+			restoreStarted = nil
+			continue
+		}
+		c.LastRestore = lo.ToPtr(r.CreatedAt)
+
+		switch r.ChangeType {
+		case "BackupRestored":
+			restoreStarted = lo.ToPtr(r.CreatedAt)
+		case "RestoreCompleted":
+			if restoreStarted != nil {
+				c.RestoreDurationSeconds = lo.ToPtr(r.CreatedAt.Sub(*restoreStarted).Seconds())
+				restoreStarted = nil
+			}
+		}
+	}
+
+	violate := func(objective, target, format string, args ...any) {
+		c.Violations = append(c.Violations, api.BackupViolation{Objective: objective, Target: target, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if rpo > 0 {
+		if c.LastBackup == nil {
+			violate(api.BackupObjectiveRPO, policy.RPO, "no successful backup")
+		} else if age := now.Sub(*c.LastBackup); age > rpo {
+			violate(api.BackupObjectiveRPO, policy.RPO, "last successful backup was %s ago, exceeding the RPO of %s", formatAge(age), policy.RPO)
+		}
+	}
+
+	if rto > 0 {
+		if c.RestoreDurationSeconds == nil {
+			violate(api.BackupObjectiveRTO, policy.RTO, "no restore has been measured")
+		} else if took := time.Duration(*c.RestoreDurationSeconds * float64(time.Second)); took > rto {
+			violate(api.BackupObjectiveRTO, policy.RTO, "last restore took %s, exceeding the RTO of %s", formatAge(took), policy.RTO)
+		}
+	}
+
+	if restoreTest > 0 {
+		if c.LastRestore == nil {
+			violate(api.BackupObjectiveRestoreTest, policy.RestoreTestInterval, "no restore has been tested")
+		} else if age := now.Sub(*c.LastRestore); age > restoreTest {
+			violate(api.BackupObjectiveRestoreTest, policy.RestoreTestInterval, "last restore was tested %s ago, exceeding the interval of %s", formatAge(age), policy.RestoreTestInterval)
+		}
+	}
+
+	c.Compliant = len(c.Violations) == 0
+	return c, nil
+}
+
+// saveBackupCompliance opens an insight for every violated objective of the
+// datasource and resolves the insights of the objectives it meets again.
+// Silenced insights are kept silenced.
+func saveBackupCompliance(ctx context.Context, app *v1.Application, c api.ApplicationBackupCompliance, now time.Time) error {
+	configID, err := uuid.Parse(c.ConfigID)
+	if err != nil {
+		return err
+	}
+
+	targets := map[string]string{
+		api.BackupObjectiveRPO:         c.RPO,
+		api.BackupObjectiveRTO:         c.RTO,
+		api.BackupObjectiveRestoreTest: c.RestoreTestInterval,
+	}
+	ids := map[string]uuid.UUID{}
+	for objective, target := range targets {
+		if target != "" {
+			ids[objective] = BackupInsightID(app.GetID().String(), c.ConfigID, objective)
+		}
+	}
+
+	var existing []models.ConfigAnalysis
+	if err := ctx.DB().Where("id IN ?", lo.Values(ids)).Find(&existing).Error; err != nil {
+		return fmt.Errorf("failed to get backup insights: %w", err)
+	}
+	statuses := lo.SliceToMap(existing, func(a models.ConfigAnalysis) (uuid.UUID, string) { return a.ID, a.Status })
+
+	violations := lo.SliceToMap(c.Violations, func(v api.BackupViolation) (string, api.BackupViolation) { return v.Objective, v })
+
+	return ctx.Transaction(func(ctx context.Context, _ trace.Span) error {
+		for objective, id := range ids {
+			status, exists := statuses[id]
+			violation, violated := violations[objective]
+
+			var transition string
+			switch {
+			case violated:
+				insight := models.ConfigAnalysis{
+					ID:           id,
+					ConfigID:     configID,
+					Analyzer:     backupAnalyzers[objective],
+					Summary:      fmt.Sprintf("%s of %s not met", strings.ToUpper(strings.ReplaceAll(objective, "_", " ")), violation.Target),
+					Message:      violation.Message,
+					Status:       models.AnalysisStatusOpen,
+					Severity:     lo.Ternary(objective == api.BackupObjectiveRestoreTest, models.SeverityMedium, models.SeverityHigh),
+					AnalysisType: models.AnalysisTypeAvailability,
+					Source:       BackupInsightSource,
+					LastObserved: lo.ToPtr(now),
+					Analysis: types.JSONMap{
+						"application_id": app.GetID().String(),
+						"application":    app.Name,
+						"objective":      objective,
+						"target":         violation.Target,
+					},
+				}
+				if err := ctx.DB().Clauses(clause.OnConflict{
+					Columns: []clause.Column{{Name: "id"}},
+					DoUpdates: clause.Assignments(map[string]any{
+						"summary":       insight.Summary,
+						"message":       insight.Message,
+						"last_observed": now,
+						"status":        gormExprKeepSilenced,
+					}),
+				}).Create(&insight).Error; err != nil {
+					return fmt.Errorf("failed to save %s insight: %w", objective, err)
+				}
+				if !exists || status == models.AnalysisStatusResolved {
+					transition = api.EventApplicationBackupViolated
+				}
+
+			case exists && status == models.AnalysisStatusOpen:
+				if err := ctx.DB().Model(&models.ConfigAnalysis{}).Where("id = ?", id).
+					Updates(map[string]any{"status": models.AnalysisStatusResolved, "last_observed": now}).Error; err != nil {
+					return fmt.Errorf("failed to resolve %s insight: %w", objective, err)
+				}
+				transition = api.EventApplicationBackupCompliant
+			}
+
+			if transition == "" {
+				continue
+			}
+
+			event := api.ApplicationBackupEvent{
+				ApplicationID: app.GetID().String(),
+				Application:   app.Name,
+				Namespace:     app.Namespace,
+				ConfigID:      c.ConfigID,
+				Datasource:    c.Name,
+				Objective:     objective,
+				Target:        targets[objective],
+				Message:       violation.Message,
+			}
+			if err := ctx.DB().Clauses(events.EventQueueOnConflictClause).Create(&models.Event{
+				Name:       transition,
+				EventID:    id,
+				Properties: event.Properties(),
+			}).Error; err != nil {
+				return fmt.Errorf("failed to create %s event: %w", transition, err)
+			}
+		}
+		return nil
+	})
+}
+
+// gormExprKeepSilenced reopens a resolved insight but keeps a silenced one silenced.
+var gormExprKeepSilenced = clause.Expr{
+	SQL:  "CASE WHEN config_analysis.status = ? THEN config_analysis.status ELSE ? END",
+	Vars: []any{models.AnalysisStatusSilenced, models.AnalysisStatusOpen},
+}
+
+func isFailedStatus(status string) bool {
+	return strings.Contains(strings.ToLower(status), "fail")
+}
+
+func formatAge(d time.Duration) string {
+	return duration.Duration(d.Truncate(time.Minute)).String()
+}
@@ -0,0 +1,80 @@
+package application
+
+import (
+	"time"
+
+	ginkgo "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/samber/lo"
+
+	"github.com/flanksource/incident-commander/api"
+	v1 "github.com/flanksource/incident-commander/api/v1"
+	"github.com/flanksource/incident-commander/db"
+)
+
+var _ = ginkgo.Describe("Backup compliance", func() {
+	now := time.Date(2025, 6, 30, 12, 0, 0, 0, time.UTC)
+	policy := v1.ApplicationBackupPolicy{RPO: "24h", RTO: "1h", RestoreTestInterval: "30d"}
+
+	backup := func(changeType, status string, ago time.Duration) db.ApplicationBackup {
+		return db.ApplicationBackup{ChangeType: changeType, Status: status, CreatedAt: now.Add(-ago)}
+	}
+	restore := func(changeType string, ago time.Duration) db.ApplicationRestore {
+		return db.ApplicationRestore{ChangeType: changeType, CreatedAt: now.Add(-ago)}
+	}
+
+	ginkgo.It("is compliant with recent backups and restore tests", func() {
+		c, err := evaluateBackupPolicy(policy,
+			[]db.ApplicationBackup{
+				backup("BackupCompleted", "", 6*time.Hour),
+				backup("BackupCompleted", "", 30*time.Hour),
+			},
+			[]db.ApplicationRestore{
+				restore("BackupRestored", 72*time.Hour),
+				restore("RestoreCompleted", 71*time.Hour+20*time.Minute),
+			},
+			now)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(c.Compliant).To(BeTrue())
+		Expect(c.Violations).To(BeEmpty())
+		Expect(c.LastBackup).To(Equal(lo.ToPtr(now.Add(-6 * time.Hour))))
+		Expect(c.LastRestore).To(Equal(lo.ToPtr(now.Add(-71*time.Hour - 20*time.Minute))))
+		Expect(c.RestoreDurationSeconds).To(Equal(lo.ToPtr(40 * 60.0)))
+	})
+
+	ginkgo.It("reports every objective that isn't met", func() {
+		c, err := evaluateBackupPolicy(policy,
+			[]db.ApplicationBackup{
+				// Failed and in-progress backups don't count
+				backup("BackupFailed", "failed", time.Hour),
+				backup("BackupCompleted", "Failed", 2*time.Hour),
+				backup("BackupStarted", "", 3*time.Hour),
+				backup("BackupSuccessful", "", 50*time.Hour),
+			},
+			[]db.ApplicationRestore{
+				restore("BackupRestored", 40*24*time.Hour),
+				restore("RestoreCompleted", 40*24*time.Hour-2*time.Hour),
+			},
+			now)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(c.Compliant).To(BeFalse())
+		Expect(lo.Map(c.Violations, func(v api.BackupViolation, _ int) string { return v.Objective })).To(Equal([]string{
+			api.BackupObjectiveRPO,
+			api.BackupObjectiveRTO,
+			api.BackupObjectiveRestoreTest,
+		}))
+		Expect(c.Violations[0].Message).To(Equal("last successful backup was 2d2h ago, exceeding the RPO of 24h"))
+		Expect(c.Violations[1].Message).To(Equal("last restore took 2h, exceeding the RTO of 1h"))
+	})
+
+	ginkgo.It("requires a backup and a measured restore", func() {
+		c, err := evaluateBackupPolicy(v1.ApplicationBackupPolicy{RPO: "24h", RTO: "1h"}, nil,
+			[]db.ApplicationRestore{restore("BackupRestored", time.Hour)},
+			now)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(c.Violations).To(ConsistOf(
+			api.BackupViolation{Objective: api.BackupObjectiveRPO, Target: "24h", Message: "no successful backup"},
+			api.BackupViolation{Objective: api.BackupObjectiveRTO, Target: "1h", Message: "no restore has been measured"},
+		))
+	})
+})
@@ -0,0 +1,173 @@
+package application
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/flanksource/duty/context"
+	"github.com/flanksource/duty/query"
+	"github.com/flanksource/duty/types"
+	"github.com/google/uuid"
+	"github.com/samber/lo"
+
+	"github.com/flanksource/incident-commander/api"
+	v1 "github.com/flanksource/incident-commander/api/v1"
+	"github.com/flanksource/incident-commander/db"
+)
+
+// doraInputs are the events of the config items of an environment the DORA
+// metrics are computed from.
+type doraInputs struct {
+	deployments    []db.ConfigEventTime
+	failures       []db.ConfigEventTime
+	merges         []db.ConfigEventTime
+	incidents      []db.ConfigIncident
+	healthRestores []time.Duration
+}
+
+// GetDORAMetrics computes the DORA metrics of every mapped environment of the
+// application over the window that ends now.
+func GetDORAMetrics(ctx context.Context, app *v1.Application, now time.Time) ([]api.ApplicationDORAMetrics, error) {
+	if app.Spec.DORA == nil {
+		return nil, nil
+	}
+
+	spec := *app.Spec.DORA
+	window, err := spec.WindowDuration()
+	if err != nil {
+		return nil, err
+	}
+	failureWindow, err := spec.FailureWindowDuration()
+	if err != nil {
+		return nil, err
+	}
+	from := now.Add(-window)
+
+	environments := lo.Keys(app.Spec.Mapping.Environments)
+	sort.Strings(environments)
+
+	metrics := make([]api.ApplicationDORAMetrics, 0, len(environments))
+	for _, env := range environments {
+		selectors := lo.Map(app.Spec.Mapping.Environments[env], func(e v1.ApplicationEnvironment, _ int) types.ResourceSelector {
+			return e.ResourceSelector
+		})
+		configIDs, err := query.FindConfigIDsByResourceSelector(ctx, -1, selectors...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find configs of environment %s: %w", env, err)
+		}
+
+		inputs, err := getDORAInputs(ctx, spec, lo.Uniq(configIDs), from, window)
+		if err != nil {
+			return nil, fmt.Errorf("environment %s: %w", env, err)
+		}
+
+		m := computeDORA(inputs, from, now, failureWindow)
+		m.Environment = env
+		m.Window = lo.CoalesceOrEmpty(spec.Window, v1.DefaultDORAWindow)
+		metrics = append(metrics, m)
+	}
+
+	return metrics, nil
+}
+
+func getDORAInputs(ctx context.Context, spec v1.ApplicationDORA, configIDs []uuid.UUID, from time.Time, window time.Duration) (doraInputs, error) {
+	var inputs doraInputs
+	var err error
+
+	if inputs.deployments, err = db.GetConfigChangeTimes(ctx, configIDs, spec.DeploymentTypes(), from); err != nil {
+		return inputs, err
+	}
+	if inputs.failures, err = db.GetConfigChangeTimes(ctx, configIDs, spec.FailureTypes(), from); err != nil {
+		return inputs, err
+	}
+	if inputs.merges, err = db.GetConfigChangeTimes(ctx, configIDs, spec.MergeTypes(), from); err != nil {
+		return inputs, err
+	}
+	if inputs.incidents, err = db.GetConfigIncidents(ctx, configIDs, from); err != nil {
+		return inputs, err
+	}
+	if inputs.healthRestores, err = db.GetHealthRestoreDurations(ctx, configIDs, from, window); err != nil {
+		return inputs, err
+	}
+
+	return inputs, nil
+}
+
+// computeDORA computes the metrics of the events between from and to.
+//
+// A deployment fails when a failure change or an incident hits the same
+// config item within the failure window. The lead time of a merge runs until
+// the next deployment of its config item.
+func computeDORA(in doraInputs, from, to time.Time, failureWindow time.Duration) api.ApplicationDORAMetrics {
+	m := api.ApplicationDORAMetrics{From: from, To: to}
+
+	failuresByConfig := map[uuid.UUID][]time.Time{}
+	for _, f := range in.failures {
+		failuresByConfig[f.ConfigID] = append(failuresByConfig[f.ConfigID], f.Time)
+	}
+	for _, i := range in.incidents {
+		failuresByConfig[i.ConfigID] = append(failuresByConfig[i.ConfigID], i.CreatedAt)
+	}
+
+	deploymentsByConfig := map[uuid.UUID][]time.Time{}
+	for _, d := range in.deployments {
+		if d.Time.Before(from) || d.Time.After(to) {
+			continue
+		}
+		m.Deployments++
+		deploymentsByConfig[d.ConfigID] = append(deploymentsByConfig[d.ConfigID], d.Time)
+
+		failed := lo.ContainsBy(failuresByConfig[d.ConfigID], func(t time.Time) bool {
+			return !t.Before(d.Time) && !t.After(d.Time.Add(failureWindow))
+		})
+		if failed {
+			m.FailedDeployments++
+		}
+	}
+
+	if days := to.Sub(from).Hours() / 24; days > 0 {
+		m.DeploymentFrequency = float64(m.Deployments) / days
+	}
+	if m.Deployments > 0 {
+		m.ChangeFailureRate = lo.ToPtr(float64(m.FailedDeployments) / float64(m.Deployments) * 100)
+	}
+
+	var leadTimes []time.Duration
+	for _, merge := range in.merges {
+		deployments := deploymentsByConfig[merge.ConfigID]
+		sort.Slice(deployments, func(i, j int) bool { return deployments[i].Before(deployments[j]) })
+		if next, ok := lo.Find(deployments, func(t time.Time) bool { return !t.Before(merge.Time) }); ok {
+			leadTimes = append(leadTimes, next.Sub(merge.Time))
+		}
+	}
+	if len(leadTimes) > 0 {
+		m.LeadTimeSeconds = lo.ToPtr(medianDuration(leadTimes).Seconds())
+	}
+
+	restores := append([]time.Duration{}, in.healthRestores...)
+	for _, i := range lo.UniqBy(in.incidents, func(i db.ConfigIncident) uuid.UUID { return i.ID }) {
+		if !i.CreatedAt.Before(from) {
+			m.Incidents++
+		}
+		if i.Resolved != nil && !i.Resolved.Before(from) && !i.Resolved.After(to) {
+			restores = append(restores, i.Resolved.Sub(i.CreatedAt))
+		}
+	}
+	m.Restores = len(restores)
+	if len(restores) > 0 {
+		m.MTTRSeconds = lo.ToPtr((lo.Sum(restores) / time.Duration(len(restores))).Seconds())
+	}
+
+	return m
+}
+
+func medianDuration(durations []time.Duration) time.Duration {
+	sorted := append([]time.Duration{}, durations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
@@ -0,0 +1,83 @@
+package application
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	ginkgo "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/samber/lo"
+
+	"github.com/flanksource/incident-commander/db"
+)
+
+var _ = ginkgo.Describe("DORA metrics", func() {
+	to := time.Date(2025, 6, 30, 0, 0, 0, 0, time.UTC)
+	from := to.Add(-10 * 24 * time.Hour)
+	server, worker := uuid.New(), uuid.New()
+	at := func(config uuid.UUID, t time.Time) db.ConfigEventTime {
+		return db.ConfigEventTime{ConfigID: config, Time: t}
+	}
+
+	ginkgo.It("computes the metrics of an environment", func() {
+		incident := db.ConfigIncident{
+			ID:        uuid.New(),
+			ConfigID:  worker,
+			CreatedAt: to.Add(-47 * time.Hour),
+			Resolved:  lo.ToPtr(to.Add(-45 * time.Hour)),
+		}
+		m := computeDORA(doraInputs{
+			deployments: []db.ConfigEventTime{
+				at(server, to.Add(-96*time.Hour)),
+				at(server, to.Add(-72*time.Hour)),
+				at(worker, to.Add(-48*time.Hour)),
+				at(worker, to.Add(-24*time.Hour)),
+				// Outside the window
+				at(server, from.Add(-time.Hour)),
+			},
+			failures: []db.ConfigEventTime{
+				// 30m after the first deployment
+				at(server, to.Add(-96*time.Hour+30*time.Minute)),
+				// After the failure window of the second deployment
+				at(server, to.Add(-70*time.Hour)),
+			},
+			merges: []db.ConfigEventTime{
+				at(server, to.Add(-98*time.Hour)),
+				at(server, to.Add(-76*time.Hour)),
+				at(worker, to.Add(-26*time.Hour)),
+				// Never deployed
+				at(worker, to.Add(-time.Hour)),
+			},
+			// The same incident with evidence on two configs
+			incidents:      []db.ConfigIncident{incident, {ID: incident.ID, ConfigID: server, CreatedAt: incident.CreatedAt, Resolved: incident.Resolved}},
+			healthRestores: []time.Duration{time.Hour},
+		}, from, to, time.Hour)
+
+		Expect(m.Deployments).To(Equal(4))
+		Expect(m.DeploymentFrequency).To(BeNumerically("~", 0.4, 1e-9))
+
+		// The first server deployment failed and the incident hit the worker 1h after its deployment.
+		Expect(m.FailedDeployments).To(Equal(2))
+		Expect(*m.ChangeFailureRate).To(BeNumerically("~", 50, 1e-9))
+
+		// 2h, 4h and 2h
+		Expect(*m.LeadTimeSeconds).To(Equal((2 * time.Hour).Seconds()))
+
+		Expect(m.Incidents).To(Equal(1))
+		Expect(m.Restores).To(Equal(2))
+		Expect(*m.MTTRSeconds).To(Equal((90 * time.Minute).Seconds()))
+	})
+
+	ginkgo.It("leaves the ratios empty when there's nothing to measure", func() {
+		m := computeDORA(doraInputs{}, from, to, time.Hour)
+		Expect(m.Deployments).To(BeZero())
+		Expect(m.DeploymentFrequency).To(BeZero())
+		Expect(m.ChangeFailureRate).To(BeNil())
+		Expect(m.LeadTimeSeconds).To(BeNil())
+		Expect(m.MTTRSeconds).To(BeNil())
+	})
+
+	ginkgo.It("takes the middle of an even number of durations", func() {
+		Expect(medianDuration([]time.Duration{4 * time.Hour, time.Hour, 2 * time.Hour, 3 * time.Hour})).To(Equal(150 * time.Minute))
+	})
+})
@@ -56,6 +56,20 @@ func Render(app *icapi.Application) api.TextList {
 		}
 	}
 
+	if len(app.SLOs) > 0 {
+		out = append(out, heading("SLOs"))
+		for _, s := range app.SLOs {
+			out = append(out, s.Pretty())
+		}
+	}
+
+	if len(app.BackupCompliance) > 0 {
+		out = append(out, heading("Backup Compliance"))
+		for _, c := range app.BackupCompliance {
+			out = append(out, c.Pretty())
+		}
+	}
+
 	for _, section := range app.Sections {
 		content := section.Pretty()
 		if content.IsEmpty() {
@@ -43,6 +43,12 @@ func initSlices(app *icapi.Application) icapi.Application {
 	if out.Findings == nil {
 		out.Findings = []icapi.ApplicationFinding{}
 	}
+	if out.SLOs == nil {
+		out.SLOs = []icapi.ApplicationSLOStatus{}
+	}
+	if out.BackupCompliance == nil {
+		out.BackupCompliance = []icapi.ApplicationBackupCompliance{}
+	}
 	if out.Sections == nil {
 		out.Sections = []icapi.ApplicationSection{}
 	}
@@ -0,0 +1,433 @@
+package application
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/flanksource/duty/context"
+	"github.com/flanksource/duty/dataquery"
+	"github.com/flanksource/duty/job"
+	"github.com/flanksource/duty/models"
+	"github.com/flanksource/duty/query"
+	"github.com/flanksource/duty/types"
+	"github.com/google/uuid"
+	"github.com/samber/lo"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/flanksource/incident-commander/api"
+	v1 "github.com/flanksource/incident-commander/api/v1"
+	"github.com/flanksource/incident-commander/db"
+	"github.com/flanksource/incident-commander/events"
+)
+
+// SLOResourceType namespaces the event ids of the SLOs.
+const SLOResourceType = "application_slo"
+
+// burnAlerts are the multiwindow burn-rate alerts of the SRE workbook: fast
+// when 2% of the error budget is spent within 1/720 of the period (1h of
+// 30d), slow when 5% is spent within 1/120 of it (6h of 30d).
+var burnAlerts = []struct {
+	burn    string
+	budget  float64
+	divisor time.Duration
+}{
+	{api.SLOBurnFast, 0.02, 720},
+	{api.SLOBurnSlow, 0.05, 120},
+}
+
+// burnWindow is a burn-rate alert for an SLO period. The budget burns when
+// the burn rate over both the long and the short window exceeds the threshold.
+type burnWindow struct {
+	burn        string
+	long, short time.Duration
+	threshold   float64
+}
+
+// burnWindows returns the burn-rate alerts for the period. The short window
+// is 1/12 of the long one, and neither is shorter than a minute.
+func burnWindows(period time.Duration) []burnWindow {
+	windows := make([]burnWindow, 0, len(burnAlerts))
+	for _, a := range burnAlerts {
+		long := max((period / a.divisor).Truncate(time.Minute), time.Minute)
+		short := max((long / 12).Truncate(time.Minute), time.Minute)
+		windows = append(windows, burnWindow{
+			burn:      a.burn,
+			long:      long,
+			short:     short,
+			threshold: a.budget * float64(period) / float64(long),
+		})
+	}
+	return windows
+}
+
+// sloMeasure returns the good and total events of the SLI over the window
+// that ends now.
+type sloMeasure func(window time.Duration) (good, total float64, err error)
+
+// EvaluateApplicationSLOs computes the attainment and error budget of every
+// application SLO and emits an event when the budget starts or stops burning.
+func EvaluateApplicationSLOs(ctx context.Context) *job.Job {
+	return &job.Job{
+		Name:       "EvaluateApplicationSLOs",
+		Schedule:   "@every 5m",
+		Context:    ctx,
+		Singleton:  true,
+		Retention:  job.RetentionFailed,
+		JobHistory: true,
+		RunNow:     true,
+		Fn: func(run job.JobRuntime) error {
+			ctx := run.Context
+			if api.SystemUserID != nil {
+				ctx = ctx.WithUser(&models.Person{ID: *api.SystemUserID})
+			}
+
+			applications, err := db.GetAllApplications(ctx)
+			if err != nil {
+				return ctx.Oops().Errorf("failed to get applications: %w", err)
+			}
+
+			for _, application := range applications {
+				app, err := v1.ApplicationFromModel(application)
+				if err != nil {
+					run.History.AddErrorf("failed to get application %s/%s: %v", application.Namespace, application.Name, err)
+					continue
+				}
+
+				if err := EvaluateSLOs(ctx, app, time.Now(), run.History); err != nil {
+					run.History.AddErrorf("application %s/%s: %v", app.Namespace, app.Name, err)
+				}
+			}
+
+			return nil
+		},
+	}
+}
+
+// SLOStateID returns the event_id of the events of an SLO.
+func SLOStateID(applicationID, slo string) uuid.UUID {
+	return uuid.NewSHA1(uuid.NameSpaceOID, []byte(fmt.Sprintf("%s/%s/%s", SLOResourceType, applicationID, slo)))
+}
+
+// EvaluateSLOs evaluates the SLOs of the application and saves their status.
+// SLOs that fail to evaluate keep their previous burn state.
+func EvaluateSLOs(ctx context.Context, app *v1.Application, now time.Time, history *models.JobHistory) error {
+	previous, err := db.GetApplicationSLOStatuses(ctx, app.GetID())
+	if err != nil {
+		return err
+	}
+
+	for _, slo := range app.Spec.SLOs {
+		prev, hasPrev := previous[slo.Name]
+
+		status := evaluateSLO(ctx, app, slo, now)
+		if status.Error != "" {
+			history.AddErrorf("slo %s: %s", slo.Name, status.Error)
+			if hasPrev {
+				prev.Error, prev.EvaluatedAt = status.Error, status.EvaluatedAt
+				status = prev
+			}
+		}
+
+		if err := saveSLOState(ctx, app, slo, prev.Burn, status); err != nil {
+			history.AddErrorf("slo %s: %v", slo.Name, err)
+			continue
+		}
+		history.IncrSuccess()
+	}
+
+	// Forget the SLOs that were removed from the application.
+	names := lo.Map(app.Spec.SLOs, func(slo v1.ApplicationSLO, _ int) string { return slo.Name })
+	if _, err := db.DeleteApplicationSLOSamplesExcept(ctx, app.GetID(), names); err != nil {
+		return fmt.Errorf("failed to delete the state of removed slos: %w", err)
+	}
+
+	return nil
+}
+
+func evaluateSLO(ctx context.Context, app *v1.Application, slo v1.ApplicationSLO, now time.Time) api.ApplicationSLOStatus {
+	status := api.ApplicationSLOStatus{
+		Name:        slo.Name,
+		Description: slo.Description,
+		Window:      lo.CoalesceOrEmpty(slo.Window, v1.DefaultSLOWindow),
+		EvaluatedAt: now,
+	}
+
+	target, err := slo.TargetRatio()
+	if err != nil {
+		status.Error = err.Error()
+		return status
+	}
+	status.Target = target * 100
+
+	window, err := slo.WindowDuration()
+	if err != nil {
+		status.Error = err.Error()
+		return status
+	}
+
+	measure, err := newSLOMeasure(ctx, app, slo, window, now)
+	if err != nil {
+		status.Error = err.Error()
+		return status
+	}
+
+	status.Good, status.Total, err = measure(window)
+	if err != nil {
+		status.Error = err.Error()
+		return status
+	}
+	status.Attainment, status.ErrorBudgetRemaining = SLOAttainment(status.Good, status.Total, target)
+
+	rates := map[time.Duration]float64{}
+	for _, w := range burnWindows(window) {
+		for _, d := range []time.Duration{w.long, w.short} {
+			if _, ok := rates[d]; ok {
+				continue
+			}
+			good, total, err := measure(d)
+			if err != nil {
+				status.Error = err.Error()
+				return status
+			}
+			rates[d] = BurnRate(good, total, target)
+		}
+	}
+	status.BurnRates = lo.MapKeys(rates, func(_ float64, d time.Duration) string { return promDuration(d) })
+	status.Burn = ClassifyBurn(window, rates)
+
+	return status
+}
+
+func newSLOMeasure(ctx context.Context, app *v1.Application, slo v1.ApplicationSLO, window time.Duration, now time.Time) (sloMeasure, error) {
+	sli := slo.SLI
+	switch {
+	case len(sli.Checks) > 0:
+		checkIDs, err := query.FindCheckIDs(ctx, -1, sli.Checks...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find checks: %w", err)
+		}
+		return func(d time.Duration) (float64, float64, error) {
+			return db.CountCheckStatuses(ctx, checkIDs, now.Add(-d))
+		}, nil
+
+	case sli.Prometheus != nil:
+		return func(d time.Duration) (float64, float64, error) {
+			good, err := runPromQLSum(ctx, *sli.Prometheus, sli.Prometheus.Good, d)
+			if err != nil {
+				return 0, 0, fmt.Errorf("good query: %w", err)
+			}
+			total, err := runPromQLSum(ctx, *sli.Prometheus, sli.Prometheus.Total, d)
+			if err != nil {
+				return 0, 0, fmt.Errorf("total query: %w", err)
+			}
+			return good, total, nil
+		}, nil
+
+	case sli.ConfigHealth != nil:
+		if err := sampleConfigHealth(ctx, app, slo, window, now); err != nil {
+			return nil, err
+		}
+		return func(d time.Duration) (float64, float64, error) {
+			return db.SumApplicationSLOSamples(ctx, app.GetID(), slo.Name, now.Add(-d))
+		}, nil
+	}
+
+	return nil, fmt.Errorf("sli must have one of checks, prometheus or configHealth")
+}
+
+// sampleConfigHealth records how many config items of the environments are
+// healthy and drops the samples that fell out of the window.
+func sampleConfigHealth(ctx context.Context, app *v1.Application, slo v1.ApplicationSLO, window time.Duration, now time.Time) error {
+	var selectors []types.ResourceSelector
+	for name, environments := range app.Spec.Mapping.Environments {
+		if len(slo.SLI.ConfigHealth.Environments) > 0 && !lo.Contains(slo.SLI.ConfigHealth.Environments, name) {
+			continue
+		}
+		for _, env := range environments {
+			selectors = append(selectors, env.ResourceSelector)
+		}
+	}
+
+	sample := db.ApplicationSLOSample{ApplicationID: app.GetID(), SLO: slo.Name, Time: now}
+	if len(selectors) > 0 {
+		configs, err := query.FindConfigsByResourceSelector(ctx, -1, selectors...)
+		if err != nil {
+			return fmt.Errorf("failed to find configs: %w", err)
+		}
+		for _, config := range lo.UniqBy(configs, func(c models.ConfigItem) uuid.UUID { return c.ID }) {
+			sample.Total++
+			if lo.FromPtr(config.Health) == models.HealthHealthy {
+				sample.Good++
+			}
+		}
+	}
+
+	if err := db.SaveApplicationSLOSample(ctx, &sample); err != nil {
+		return err
+	}
+	_, err := db.DeleteApplicationSLOSamplesBefore(ctx, app.GetID(), slo.Name, now.Add(-window))
+	return err
+}
+
+// runPromQLSum runs the query with $window replaced and sums the values of
+// the resulting series.
+func runPromQLSum(ctx context.Context, sli v1.ApplicationPrometheusSLI, promql string, window time.Duration) (float64, error) {
+	rows, err := dataquery.ExecuteQuery(ctx, dataquery.Query{Prometheus: &dataquery.PrometheusQuery{
+		PrometheusConnection: sli.PrometheusConnection,
+		Query:                strings.ReplaceAll(promql, "$window", promDuration(window)),
+	}})
+	if err != nil {
+		return 0, err
+	}
+
+	var sum float64
+	for _, row := range rows {
+		if v, ok := row["value"].(float64); ok {
+			sum += v
+		}
+	}
+	return sum, nil
+}
+
+// SLOAttainment returns the attainment and the remaining error budget in
+// percent. Both are nil when there were no events.
+func SLOAttainment(good, total, target float64) (attainment, budgetRemaining *float64) {
+	if total <= 0 {
+		return nil, nil
+	}
+	ratio := good / total
+	budget := 1 - (1-ratio)/(1-target)
+	return lo.ToPtr(ratio * 100), lo.ToPtr(budget * 100)
+}
+
+// BurnRate is how many times faster than allowed the error budget is spent.
+// A burn rate of 1 spends the whole budget in exactly one window.
+func BurnRate(good, total, target float64) float64 {
+	if total <= 0 {
+		return 0
+	}
+	return (1 - good/total) / (1 - target)
+}
+
+// ClassifyBurn returns fast or slow when the burn rates over both windows of
+// an alert for the SLO period exceed its threshold, and empty otherwise.
+func ClassifyBurn(period time.Duration, rates map[time.Duration]float64) string {
+	for _, w := range burnWindows(period) {
+		if rates[w.long] > w.threshold && rates[w.short] > w.threshold {
+			return w.burn
+		}
+	}
+	return ""
+}
+
+// SLOTransition returns the event to emit when the burn state of an SLO
+// changes. Slowing down from a fast to a slow burn isn't an event.
+func SLOTransition(previous, current string) string {
+	switch {
+	case current == previous:
+		return ""
+	case current == api.SLOBurnFast:
+		return api.EventApplicationSLOFastBurn
+	case current == api.SLOBurnSlow && previous == "":
+		return api.EventApplicationSLOSlowBurn
+	case current == "":
+		return api.EventApplicationSLORecovered
+	}
+	return ""
+}
+
+// saveSLOState records the evaluation on the SLO samples and emits an event
+// when the burn state changed.
+func saveSLOState(ctx context.Context, app *v1.Application, slo v1.ApplicationSLO, previousBurn string, status api.ApplicationSLOStatus) error {
+	transition := SLOTransition(previousBurn, status.Burn)
+
+	return ctx.Transaction(func(ctx context.Context, _ trace.Span) error {
+		if err := db.SaveApplicationSLOStatus(ctx, app.GetID(), status); err != nil {
+			return err
+		}
+
+		// Sampled SLIs drop the samples out of their window when sampling,
+		// the others only need their last evaluation.
+		if slo.SLI.ConfigHealth == nil {
+			if _, err := db.DeleteApplicationSLOSamplesBefore(ctx, app.GetID(), slo.Name, status.EvaluatedAt); err != nil {
+				return err
+			}
+		}
+
+		if transition == "" {
+			return nil
+		}
+
+		event := api.ApplicationSLOEvent{
+			ApplicationID:        app.GetID().String(),
+			Application:          app.Name,
+			Namespace:            app.Namespace,
+			SLO:                  status.Name,
+			Burn:                 status.Burn,
+			Target:               status.Target,
+			Window:               status.Window,
+			Attainment:           status.Attainment,
+			ErrorBudgetRemaining: status.ErrorBudgetRemaining,
+		}
+		period, _ := slo.WindowDuration()
+		for _, w := range burnWindows(period) {
+			if w.burn == lo.CoalesceOrEmpty(status.Burn, previousBurn) {
+				event.BurnRate = status.BurnRates[promDuration(w.long)]
+			}
+		}
+
+		if err := ctx.DB().Clauses(events.EventQueueOnConflictClause).Create(&models.Event{
+			Name:       transition,
+			EventID:    SLOStateID(app.GetID().String(), slo.Name),
+			Properties: event.Properties(),
+		}).Error; err != nil {
+			return fmt.Errorf("failed to create %s event: %w", transition, err)
+		}
+
+		return nil
+	})
+}
+
+// getSLOStatuses returns the last evaluation of the SLOs of the application
+// in the order they're defined. SLOs that haven't been evaluated yet only
+// have their target.
+func getSLOStatuses(ctx context.Context, app *v1.Application) ([]api.ApplicationSLOStatus, error) {
+	states, err := db.GetApplicationSLOStatuses(ctx, app.GetID())
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]api.ApplicationSLOStatus, 0, len(app.Spec.SLOs))
+	for _, slo := range app.Spec.SLOs {
+		if state, ok := states[slo.Name]; ok {
+			statuses = append(statuses, state)
+			continue
+		}
+
+		status := api.ApplicationSLOStatus{
+			Name:        slo.Name,
+			Description: slo.Description,
+			Window:      lo.CoalesceOrEmpty(slo.Window, v1.DefaultSLOWindow),
+		}
+		if target, err := slo.TargetRatio(); err == nil {
+			status.Target = target * 100
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// promDuration formats the duration in the largest whole unit e.g. 30d, 6h.
+func promDuration(d time.Duration) string {
+	day := 24 * time.Hour
+	switch {
+	case d%day == 0:
+		return fmt.Sprintf("%dd", d/day)
+	case d%time.Hour == 0:
+		return fmt.Sprintf("%dh", d/time.Hour)
+	case d%time.Minute == 0:
+		return fmt.Sprintf("%dm", d/time.Minute)
+	}
+	return fmt.Sprintf("%ds", d/time.Second)
+}
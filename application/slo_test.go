@@ -0,0 +1,86 @@
+package application
+
+import (
+	"time"
+
+	ginkgo "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/flanksource/incident-commander/api"
+)
+
+var _ = ginkgo.Describe("Application SLOs", func() {
+	ginkgo.It("computes the attainment and the remaining error budget", func() {
+		attainment, budget := SLOAttainment(995, 1000, 0.99)
+		Expect(*attainment).To(BeNumerically("~", 99.5, 1e-9))
+		Expect(*budget).To(BeNumerically("~", 50, 1e-9))
+
+		_, budget = SLOAttainment(980, 1000, 0.99)
+		Expect(*budget).To(BeNumerically("~", -100, 1e-9))
+
+		attainment, budget = SLOAttainment(0, 0, 0.99)
+		Expect(attainment).To(BeNil())
+		Expect(budget).To(BeNil())
+	})
+
+	ginkgo.It("computes the burn rate", func() {
+		Expect(BurnRate(990, 1000, 0.99)).To(BeNumerically("~", 1, 1e-9))
+		Expect(BurnRate(856, 1000, 0.99)).To(BeNumerically("~", 14.4, 1e-9))
+		Expect(BurnRate(0, 0, 0.99)).To(BeZero())
+	})
+
+	ginkgo.It("classifies the burn only when both windows exceed the threshold", func() {
+		month := 30 * 24 * time.Hour
+		Expect(ClassifyBurn(month, map[time.Duration]float64{time.Hour: 20, 5 * time.Minute: 15})).To(Equal(api.SLOBurnFast))
+		Expect(ClassifyBurn(month, map[time.Duration]float64{time.Hour: 20, 5 * time.Minute: 2})).To(BeEmpty())
+		Expect(ClassifyBurn(month, map[time.Duration]float64{6 * time.Hour: 7, 30 * time.Minute: 8})).To(Equal(api.SLOBurnSlow))
+		Expect(ClassifyBurn(month, map[time.Duration]float64{
+			time.Hour: 20, 5 * time.Minute: 15, 6 * time.Hour: 7, 30 * time.Minute: 8,
+		})).To(Equal(api.SLOBurnFast))
+		Expect(ClassifyBurn(month, nil)).To(BeEmpty())
+	})
+
+	ginkgo.It("derives the burn windows from the period", func() {
+		month := burnWindows(30 * 24 * time.Hour)
+		Expect(month).To(HaveLen(2))
+		Expect(month[0].burn).To(Equal(api.SLOBurnFast))
+		Expect(month[0].long).To(Equal(time.Hour))
+		Expect(month[0].short).To(Equal(5 * time.Minute))
+		Expect(month[0].threshold).To(BeNumerically("~", 14.4, 1e-9))
+		Expect(month[1].burn).To(Equal(api.SLOBurnSlow))
+		Expect(month[1].long).To(Equal(6 * time.Hour))
+		Expect(month[1].short).To(Equal(30 * time.Minute))
+		Expect(month[1].threshold).To(BeNumerically("~", 6, 1e-9))
+
+		week := burnWindows(7 * 24 * time.Hour)
+		Expect(week[0].long).To(Equal(14 * time.Minute))
+		Expect(week[0].short).To(Equal(time.Minute))
+		Expect(week[0].threshold).To(BeNumerically("~", 14.4, 1e-9))
+		Expect(week[1].long).To(Equal(84 * time.Minute))
+		Expect(week[1].short).To(Equal(7 * time.Minute))
+
+		// A 7d period burns fast over 14m, not over the 1h of a 30d period
+		Expect(ClassifyBurn(7*24*time.Hour, map[time.Duration]float64{time.Hour: 20, 5 * time.Minute: 15})).To(BeEmpty())
+		Expect(ClassifyBurn(7*24*time.Hour, map[time.Duration]float64{14 * time.Minute: 20, time.Minute: 15})).To(Equal(api.SLOBurnFast))
+	})
+
+	ginkgo.DescribeTable("emits an event when the burn state changes",
+		func(previous, current, event string) {
+			Expect(SLOTransition(previous, current)).To(Equal(event))
+		},
+		ginkgo.Entry("no burn", "", "", ""),
+		ginkgo.Entry("starts burning fast", "", api.SLOBurnFast, api.EventApplicationSLOFastBurn),
+		ginkgo.Entry("starts burning slowly", "", api.SLOBurnSlow, api.EventApplicationSLOSlowBurn),
+		ginkgo.Entry("speeds up", api.SLOBurnSlow, api.SLOBurnFast, api.EventApplicationSLOFastBurn),
+		ginkgo.Entry("slows down", api.SLOBurnFast, api.SLOBurnSlow, ""),
+		ginkgo.Entry("keeps burning", api.SLOBurnFast, api.SLOBurnFast, ""),
+		ginkgo.Entry("recovers", api.SLOBurnSlow, "", api.EventApplicationSLORecovered),
+	)
+
+	ginkgo.It("formats PromQL durations", func() {
+		Expect(promDuration(30 * 24 * time.Hour)).To(Equal("30d"))
+		Expect(promDuration(6 * time.Hour)).To(Equal("6h"))
+		Expect(promDuration(90 * time.Minute)).To(Equal("90m"))
+		Expect(promDuration(45 * time.Second)).To(Equal("45s"))
+	})
+})
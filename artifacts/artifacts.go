@@ -153,3 +153,35 @@ func UploadArtifact(ctx context.Context, artifactID string, reader io.ReadCloser
 	_, err = fs.Write(ctx, artifact.Path, reader)
 	return err
 }
+
+// SaveJobHistoryArtifacts saves artifacts produced by a job run or session
+// recorded as the given job history.
+func SaveJobHistoryArtifacts(ctx context.Context, jobHistoryID uuid.UUID, generatedArtifacts []artifacts.Artifact) error {
+	if len(generatedArtifacts) == 0 {
+		return nil
+	}
+
+	if api.DefaultArtifactConnection == "" {
+		logger.Warnf("no artifact connection configured")
+		return nil
+	}
+
+	fs, connection, err := GetArtifactFS(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get artifact fs: %w", err)
+	}
+	defer fs.Close()
+
+	for _, a := range generatedArtifacts {
+		artifact := models.Artifact{
+			JobHistoryID: utils.Ptr(jobHistoryID),
+			ConnectionID: connection.ID,
+		}
+
+		if err := artifacts.SaveArtifact(ctx, fs, &artifact, a); err != nil {
+			return fmt.Errorf("error saving artifact to db: %w", err)
+		}
+	}
+
+	return nil
+}
@@ -1,6 +1,8 @@
 package artifacts
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 
 	"github.com/flanksource/artifacts"
@@ -9,9 +11,12 @@ import (
 	"github.com/flanksource/duty/context"
 	"github.com/flanksource/duty/models"
 	"github.com/flanksource/duty/upstream"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 
 	"github.com/flanksource/incident-commander/api"
+	"github.com/flanksource/incident-commander/upstream/outbox"
 )
 
 // agentArtifactConnection is the cached agent artifact store connection
@@ -38,43 +43,103 @@ func getArtifactStore(ctx context.Context) (fs.FilesystemRW, error) {
 	return agentArtifactStore, nil
 }
 
+const artifactsSource = "artifacts"
+
+func init() {
+	outbox.RegisterHandler(outbox.KindArtifact, pushArtifactFromOutbox)
+	outbox.RegisterAck(artifactsSource, markArtifactsDataPushed)
+}
+
+func markArtifactsDataPushed(tx *gorm.DB, ids []string) error {
+	if err := tx.Model(&models.Artifact{}).Where("id IN ?", ids).Update("is_data_pushed", true).Error; err != nil {
+		return fmt.Errorf("failed to update is_data_pushed on artifacts: %w", err)
+	}
+	return nil
+}
+
+// pushArtifactFromOutbox pushes the data of an artifact queued in the outbox.
+func pushArtifactFromOutbox(ctx context.Context, client *upstream.UpstreamClient, payload []byte) error {
+	var item outbox.ArtifactPayload
+	if err := json.Unmarshal(payload, &item); err != nil {
+		return fmt.Errorf("invalid artifact payload: %w", err)
+	}
+
+	var artifact models.Artifact
+	if err := ctx.DB().Where("id = ?", item.ID).First(&artifact).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ctx.Warnf("dropping outbox item of deleted artifact %s", item.ID)
+			return nil
+		}
+		return fmt.Errorf("failed to fetch artifact: %w", err)
+	}
+
+	fs, err := getArtifactStore(ctx)
+	if err != nil {
+		return err
+	}
+	defer fs.Close()
+
+	reader, err := fs.Read(ctx, artifact.Path)
+	if err != nil {
+		return fmt.Errorf("failed to read remote artifact store: %w", err)
+	}
+
+	return client.PushArtifacts(ctx, artifact.ID, reader)
+}
+
 // SyncArtifactItems pushes the artifact data.
-func SyncArtifactItems(ctx context.Context, config upstream.UpstreamConfig, batchSize int) (int, error) {
+// Artifacts that fail to push are handed over to the outbox and counted as
+// queued; their data is only marked pushed once the outbox delivers it.
+func SyncArtifactItems(ctx context.Context, config upstream.UpstreamConfig, batchSize int) (pushed, queued int, err error) {
 	client := upstream.NewUpstreamClient(config)
-	var count int
-	var err error
 	var fs fs.FilesystemRW
+	offline := false
 	for {
 		var artifacts []models.Artifact
-		if err := ctx.DB().Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).Where("is_data_pushed IS FALSE").Where("is_pushed IS TRUE").Order("created_at").Limit(batchSize).Find(&artifacts).Error; err != nil {
-			return 0, fmt.Errorf("failed to fetch artifacts: %w", err)
+		if err := ctx.DB().Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).Where("is_data_pushed IS FALSE").Where("is_pushed IS TRUE").Where(outbox.NotQueued(artifactsSource)).Order("created_at").Limit(batchSize).Find(&artifacts).Error; err != nil {
+			return pushed, queued, fmt.Errorf("failed to fetch artifacts: %w", err)
 		}
 
 		if len(artifacts) == 0 {
-			return count, nil
+			return pushed, queued, nil
 		}
 
 		for _, artifact := range artifacts {
 			if fs == nil {
 				fs, err = getArtifactStore(ctx)
 				if err != nil {
-					return 0, err
+					return pushed, queued, err
 				}
 			}
-			reader, err := fs.Read(ctx, artifact.Path)
-			if err != nil {
-				return count, fmt.Errorf("failed to read remote artifact store: %w", err)
+			var pushErr error
+			if !offline {
+				reader, err := fs.Read(ctx, artifact.Path)
+				if err != nil {
+					return pushed, queued, fmt.Errorf("failed to read remote artifact store: %w", err)
+				}
+				pushErr = client.PushArtifacts(ctx, artifact.ID, reader)
 			}
 
-			if err := client.PushArtifacts(ctx, artifact.ID, reader); err != nil {
-				return count, fmt.Errorf("failed to push artifact (%s): %w", artifact.ID, err)
+			if offline || pushErr != nil {
+				if err := outbox.EnqueueRows(ctx, artifactsSource, []uuid.UUID{artifact.ID}, outbox.KindArtifact, outbox.PriorityNormal, outbox.ArtifactPayload{ID: artifact.ID}); err != nil {
+					if pushErr == nil {
+						pushErr = fmt.Errorf("upstream is unreachable")
+					}
+					return pushed, queued, fmt.Errorf("failed to push artifact (%s): %w (outbox: %v)", artifact.ID, pushErr, err)
+				}
+				if !offline {
+					ctx.Warnf("queued artifacts in the outbox: %v", pushErr)
+				}
+				offline = true
+				queued++
+				continue
 			}
 
-			if err := ctx.DB().Model(&models.Artifact{}).Where("id = ?", artifact.ID).Update("is_data_pushed", true).Error; err != nil {
-				return 0, fmt.Errorf("failed to update is_pushed on artifacts: %w", err)
+			if err := markArtifactsDataPushed(ctx.DB(), []string{artifact.ID.String()}); err != nil {
+				return pushed, queued, err
 			}
 
-			count++
+			pushed++
 		}
 	}
 }
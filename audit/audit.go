@@ -0,0 +1,232 @@
+package audit
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/flanksource/commons/logger"
+	dutyAPI "github.com/flanksource/duty/api"
+	"github.com/flanksource/duty/context"
+	"github.com/google/uuid"
+
+	"github.com/flanksource/incident-commander/db"
+)
+
+// KeyFile is the path of the secret the chain of the audit log is keyed
+// with. Without it anyone who can write to the database can rewrite the
+// chain along with the entries.
+var KeyFile string
+
+var key []byte
+
+// HeadFile is the path the head of the chain is written to. Deleting the
+// latest entries leaves a chain that is still intact, so Verify checks it
+// against the head kept here, outside of the database.
+var HeadFile string
+
+// Head is the latest entry of the chain: its id, which only increases, and
+// its hash. It is written to HeadFile and streamed to the sink after every
+// append.
+type Head struct {
+	Type     string `json:"type"`
+	Sequence int64  `json:"sequence"`
+	Hash     string `json:"hash"`
+}
+
+const headRecordType = "audit_head"
+
+var headMu sync.Mutex
+
+// LoadKey reads the key from KeyFile. Every process appending to the audit
+// log, the server and the CLI alike, must load the same key.
+func LoadKey() error {
+	if KeyFile == "" {
+		logger.Warnf("--audit-key is not set, the audit log is chained with unkeyed hashes")
+		key = nil
+		return nil
+	}
+
+	data, err := os.ReadFile(KeyFile)
+	if err != nil {
+		return fmt.Errorf("failed to read audit key: %w", err)
+	}
+	if data = bytes.TrimSpace(data); len(data) == 0 {
+		return fmt.Errorf("audit key %s is empty", KeyFile)
+	}
+	key = data
+	return nil
+}
+
+// Record appends the entry to the audit log and streams it to the sink.
+func Record(ctx context.Context, entry *db.AuditLog) error {
+	prepare(entry)
+	if err := db.AppendAuditLog(ctx, []*db.AuditLog{entry}, Hash); err != nil {
+		return err
+	}
+
+	stream(ctx, *entry)
+	advanceHead(ctx, *entry)
+	return nil
+}
+
+// advanceHead records the entry as the head of the chain, unless a later one
+// already is.
+func advanceHead(ctx context.Context, entry db.AuditLog) {
+	head := Head{Type: headRecordType, Sequence: entry.ID, Hash: entry.Hash}
+	stream(ctx, head)
+
+	if HeadFile == "" {
+		return
+	}
+
+	headMu.Lock()
+	defer headMu.Unlock()
+	if current, err := ReadHead(HeadFile); err == nil && current != nil && current.Sequence >= head.Sequence {
+		return
+	}
+
+	data, _ := json.Marshal(head)
+	tmp := HeadFile + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		ctx.Errorf("failed to write the audit log head: %v", err)
+		return
+	}
+	if err := os.Rename(tmp, HeadFile); err != nil {
+		ctx.Errorf("failed to write the audit log head: %v", err)
+	}
+}
+
+// ReadHead reads the head written to path, nil when there is none yet.
+func ReadHead(path string) (*Head, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var head Head
+	if err := json.Unmarshal(data, &head); err != nil {
+		return nil, fmt.Errorf("invalid audit log head %s: %w", path, err)
+	}
+	return &head, nil
+}
+
+func prepare(entry *db.AuditLog) {
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now()
+	}
+	// The hash must survive the round trip through postgres
+	entry.CreatedAt = entry.CreatedAt.UTC().Truncate(time.Microsecond)
+}
+
+// Hash returns the HMAC of the entry, with the loaded key, chained to the
+// hash of the entry before it. Without a key it's a plain SHA-256.
+func Hash(entry db.AuditLog, prevHash string) string {
+	fields := []string{
+		prevHash,
+		entry.CreatedAt.UTC().Format(time.RFC3339Nano),
+		uuidString(entry.ActorID),
+		uuidString(entry.TokenID),
+		entry.Impersonation,
+		entry.Method,
+		entry.Route,
+		entry.Path,
+		entry.Object,
+		entry.Action,
+		entry.Decision,
+		strconv.Itoa(entry.Status),
+		entry.RemoteIP,
+		entry.BodyHash,
+	}
+	// Appended only when set to keep the hashes of earlier entries
+	if entry.BreakGlassID != nil {
+		fields = append(fields, entry.BreakGlassID.String())
+	}
+
+	var h hash.Hash
+	if len(key) > 0 {
+		h = hmac.New(sha256.New, key)
+	} else {
+		h = sha256.New()
+	}
+	h.Write([]byte(strings.Join(fields, "\x00")))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func uuidString(id *uuid.UUID) string {
+	if id == nil {
+		return ""
+	}
+	return id.String()
+}
+
+// VerifyResult is the outcome of verifying the chain of the audit log.
+type VerifyResult struct {
+	Entries int  `json:"entries"`
+	Valid   bool `json:"valid"`
+	// BrokenAt is the first entry that doesn't match the chain.
+	BrokenAt int64  `json:"broken_at,omitempty"`
+	Reason   string `json:"reason,omitempty"`
+	// Head is the head kept outside of the database the chain was checked
+	// against.
+	Head *Head `json:"head,omitempty"`
+}
+
+// Verify recomputes the chain of the audit log. An edited entry no longer
+// matches its hash and a deleted entry leaves the next one pointing to a hash
+// that isn't the one before it. The latest entries have no entry after them,
+// so the chain must also reach head: the one given, e.g. as received by the
+// sink, or else the one in HeadFile.
+func Verify(ctx context.Context, head *Head) (*VerifyResult, error) {
+	if head == nil && HeadFile != "" {
+		var err error
+		if head, err = ReadHead(HeadFile); err != nil {
+			return nil, ctx.Oops().Code(dutyAPI.EINTERNAL).Wrap(err)
+		}
+	}
+
+	result := VerifyResult{Valid: true, Head: head}
+	var prevHash string
+	var reachedHead bool
+
+	err := db.WalkAuditLog(ctx, 1000, func(entries []db.AuditLog) error {
+		for _, entry := range entries {
+			if !result.Valid {
+				return nil
+			}
+
+			result.Entries++
+			if entry.PrevHash != prevHash {
+				result.Valid, result.BrokenAt, result.Reason = false, entry.ID, "previous hash doesn't match the entry before it"
+			} else if Hash(entry, entry.PrevHash) != entry.Hash {
+				result.Valid, result.BrokenAt, result.Reason = false, entry.ID, "hash doesn't match the entry"
+			} else if head != nil && entry.ID == head.Sequence {
+				reachedHead = true
+				if entry.Hash != head.Hash {
+					result.Valid, result.BrokenAt, result.Reason = false, entry.ID, "hash doesn't match the head kept outside of the database"
+				}
+			}
+			prevHash = entry.Hash
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if result.Valid && head != nil && !reachedHead {
+		result.Valid, result.BrokenAt, result.Reason = false, head.Sequence, "the chain ends before the head kept outside of the database"
+	}
+	return &result, nil
+}
@@ -0,0 +1,272 @@
+package audit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/flanksource/commons/properties"
+	dutyRBAC "github.com/flanksource/duty/rbac"
+	"github.com/flanksource/duty/rbac/policy"
+	"github.com/flanksource/duty/tests/fixtures/dummy"
+	"github.com/labstack/echo/v4"
+	ginkgo "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/flanksource/incident-commander/db"
+	"github.com/flanksource/incident-commander/rbac"
+	"github.com/flanksource/incident-commander/rbac/adapter"
+)
+
+var _ = ginkgo.Describe("Audit log", ginkgo.Ordered, func() {
+	var e *echo.Echo
+
+	ginkgo.BeforeAll(func() {
+		Expect(dutyRBAC.Init(DefaultContext, []string{"admin"}, adapter.NewPermissionAdapter)).To(Succeed())
+		properties.Set("audit.log", "true")
+
+		e = echo.New()
+		e.Use(func(next echo.HandlerFunc) echo.HandlerFunc {
+			return func(c echo.Context) error {
+				c.SetRequest(c.Request().WithContext(DefaultContext.Wrap(c.Request().Context())))
+				return next(c)
+			}
+		})
+		e.Use(Middleware)
+		// Stands in for the auth middleware
+		e.Use(func(next echo.HandlerFunc) echo.HandlerFunc {
+			return func(c echo.Context) error {
+				ctx := DefaultContext.WithUser(&dummy.JohnWick).Wrap(c.Request().Context())
+				c.SetRequest(c.Request().WithContext(ctx))
+				return next(c)
+			}
+		})
+
+		e.POST("/things/:id", func(c echo.Context) error {
+			buf := make([]byte, 4)
+			_, _ = c.Request().Body.Read(buf)
+			return c.NoContent(http.StatusCreated)
+		})
+		e.GET("/things", func(c echo.Context) error { return c.NoContent(http.StatusOK) })
+		e.GET("/audited", func(c echo.Context) error { return c.NoContent(http.StatusOK) },
+			rbac.Authorization(rbac.ObjectAudit, policy.ActionRead))
+	})
+
+	ginkgo.AfterAll(func() {
+		properties.Set("audit.log", "")
+	})
+
+	serve := func(method, path, body string) int {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	last := func() db.AuditLog {
+		Expect(Flush(5 * time.Second)).To(BeTrue())
+		entries, err := db.SearchAuditLog(DefaultContext, db.AuditLogFilter{Limit: 1})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(entries).To(HaveLen(1))
+		return entries[0]
+	}
+
+	ginkgo.It("should record mutating requests", func() {
+		body := `{"name": "a thing"}`
+		Expect(serve(http.MethodPost, "/things/1", body)).To(Equal(http.StatusCreated))
+
+		entry := last()
+		sum := sha256.Sum256([]byte(body))
+		Expect(entry.Method).To(Equal(http.MethodPost))
+		Expect(entry.Route).To(Equal("/things/:id"))
+		Expect(entry.Path).To(Equal("/things/1"))
+		Expect(entry.Status).To(Equal(http.StatusCreated))
+		Expect(entry.ActorID).To(Equal(&dummy.JohnWick.ID))
+		Expect(entry.Decision).To(BeEmpty())
+		Expect(entry.BodyHash).To(Equal(hex.EncodeToString(sum[:])))
+	})
+
+	ginkgo.It("should not record reads", func() {
+		before := last()
+		Expect(serve(http.MethodGet, "/things", "")).To(Equal(http.StatusOK))
+		Expect(last().ID).To(Equal(before.ID))
+	})
+
+	ginkgo.It("should record RBAC denials", func() {
+		Expect(serve(http.MethodGet, "/audited", "")).To(Equal(http.StatusForbidden))
+
+		entry := last()
+		Expect(entry.Route).To(Equal("/audited"))
+		Expect(entry.Object).To(Equal(rbac.ObjectAudit))
+		Expect(entry.Action).To(Equal(policy.ActionRead))
+		Expect(entry.Decision).To(Equal(db.AuditDecisionDeny))
+		Expect(entry.Status).To(Equal(http.StatusForbidden))
+	})
+
+	ginkgo.It("should chain the entries", func() {
+		Expect(Record(DefaultContext, &db.AuditLog{Method: http.MethodDelete, Route: "/things/:id", Path: "/things/2", Status: http.StatusOK})).To(Succeed())
+
+		entries, err := db.SearchAuditLog(DefaultContext, db.AuditLogFilter{Limit: 2})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(entries[0].PrevHash).To(Equal(entries[1].Hash))
+
+		result, err := Verify(DefaultContext, nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.Valid).To(BeTrue())
+		Expect(result.Entries).To(BeNumerically(">=", 3))
+	})
+
+	ginkgo.It("should batch queued entries", func() {
+		before := last()
+		for i := 0; i < 3; i++ {
+			Expect(serve(http.MethodPost, "/things/3", "{}")).To(Equal(http.StatusCreated))
+		}
+
+		entries, err := db.SearchAuditLog(DefaultContext, db.AuditLogFilter{Limit: 4})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(entries[3].ID).To(Equal(before.ID))
+		for i := 0; i < 3; i++ {
+			Expect(entries[i].PrevHash).To(Equal(entries[i+1].Hash))
+		}
+	})
+
+	ginkgo.It("should detect edited entries", func() {
+		entries, err := db.SearchAuditLog(DefaultContext, db.AuditLogFilter{Limit: 2})
+		Expect(err).ToNot(HaveOccurred())
+		edited := entries[1]
+
+		Expect(DefaultContext.DB().Model(&db.AuditLog{}).Where("id = ?", edited.ID).Update("status", http.StatusOK).Error).To(Succeed())
+
+		result, err := Verify(DefaultContext, nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.Valid).To(BeFalse())
+		Expect(result.BrokenAt).To(Equal(edited.ID))
+
+		Expect(DefaultContext.DB().Model(&db.AuditLog{}).Where("id = ?", edited.ID).Update("status", edited.Status).Error).To(Succeed())
+	})
+
+	ginkgo.It("should detect deleted latest entries against the head", func() {
+		HeadFile = filepath.Join(ginkgo.GinkgoT().TempDir(), "audit-head.json")
+		ginkgo.DeferCleanup(func() { HeadFile = "" })
+
+		Expect(Record(DefaultContext, &db.AuditLog{Method: http.MethodDelete, Route: "/things/:id", Path: "/things/4", Status: http.StatusOK})).To(Succeed())
+		latest := last()
+
+		head, err := ReadHead(HeadFile)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(head.Sequence).To(Equal(latest.ID))
+		Expect(head.Hash).To(Equal(latest.Hash))
+
+		Expect(DefaultContext.DB().Delete(&latest).Error).To(Succeed())
+
+		result, err := Verify(DefaultContext, &Head{Sequence: latest.ID - 1, Hash: "unknown"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.Valid).To(BeFalse())
+
+		result, err = Verify(DefaultContext, nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.Valid).To(BeFalse())
+		Expect(result.BrokenAt).To(Equal(latest.ID))
+		Expect(result.Reason).To(ContainSubstring("ends before the head"))
+	})
+
+	ginkgo.It("should detect deleted entries", func() {
+		entries, err := db.SearchAuditLog(DefaultContext, db.AuditLogFilter{Limit: 2})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(DefaultContext.DB().Delete(&entries[1]).Error).To(Succeed())
+
+		result, err := Verify(DefaultContext, nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.Valid).To(BeFalse())
+		Expect(result.BrokenAt).To(Equal(entries[0].ID))
+	})
+})
+
+var _ = ginkgo.Describe("Hash", func() {
+	ginkgo.AfterEach(func() {
+		key = nil
+	})
+
+	ginkgo.It("should key the hash", func() {
+		entry := db.AuditLog{Method: http.MethodPost, Route: "/things/:id", Path: "/things/1", Status: http.StatusOK, CreatedAt: time.Now()}
+		unkeyed := Hash(entry, "")
+
+		key = []byte("a secret")
+		keyed := Hash(entry, "")
+		Expect(keyed).ToNot(Equal(unkeyed))
+
+		key = []byte("another secret")
+		Expect(Hash(entry, "")).ToNot(Equal(keyed))
+	})
+})
+
+var _ = ginkgo.Describe("ParseFilter", func() {
+	now := time.Date(2026, 1, 10, 0, 0, 0, 0, time.UTC)
+
+	ginkgo.It("should parse the parameters", func() {
+		params := map[string]string{
+			"actor":    dummy.JohnDoe.ID.String(),
+			"object":   policy.ObjectPlaybooks,
+			"decision": db.AuditDecisionDeny,
+			"since":    "7d",
+			"until":    "2026-01-09T00:00:00Z",
+			"limit":    "10",
+		}
+		filter, err := ParseFilter(func(name string) string { return params[name] }, now)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(filter.ActorID).To(Equal(&dummy.JohnDoe.ID))
+		Expect(filter.Object).To(Equal(policy.ObjectPlaybooks))
+		Expect(filter.Decision).To(Equal(db.AuditDecisionDeny))
+		Expect(*filter.Since).To(Equal(now.Add(-7 * 24 * time.Hour)))
+		Expect(*filter.Until).To(Equal(time.Date(2026, 1, 9, 0, 0, 0, 0, time.UTC)))
+		Expect(filter.Limit).To(Equal(10))
+	})
+
+	ginkgo.It("should reject invalid parameters", func() {
+		for _, params := range []map[string]string{{"actor": "john"}, {"since": "yesterday"}, {"limit": "-1"}} {
+			_, err := ParseFilter(func(name string) string { return params[name] }, now)
+			Expect(err).To(HaveOccurred())
+		}
+	})
+})
+
+type fakeSink struct {
+	down     bool
+	received []string
+}
+
+func (s *fakeSink) Send(record []byte) error {
+	if s.down {
+		return errors.New("sink is down")
+	}
+	s.received = append(s.received, string(record))
+	return nil
+}
+
+var _ = ginkgo.Describe("spool", func() {
+	ginkgo.It("should replay the spooled records in order once the sink is back", func() {
+		path := filepath.Join(ginkgo.GinkgoT().TempDir(), "spool.jsonl")
+		sp := newSpool(path)
+		for _, record := range []string{`{"id":1}`, `{"id":2}`} {
+			Expect(sp.add([]byte(record))).To(Succeed())
+		}
+
+		s := &fakeSink{down: true}
+		Expect(sp.replay(s)).ToNot(Succeed())
+		Expect(sp.pending()).To(BeTrue())
+
+		// A restart picks up what was left behind
+		sp = newSpool(path)
+		Expect(sp.pending()).To(BeTrue())
+
+		s.down = false
+		Expect(sp.replay(s)).To(Succeed())
+		Expect(s.received).To(Equal([]string{`{"id":1}`, `{"id":2}`}))
+		Expect(sp.pending()).To(BeFalse())
+	})
+})
@@ -0,0 +1,140 @@
+package audit
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/flanksource/commons/duration"
+	"github.com/flanksource/commons/logger"
+	dutyAPI "github.com/flanksource/duty/api"
+	"github.com/flanksource/duty/context"
+	"github.com/flanksource/duty/rbac/policy"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+
+	"github.com/flanksource/incident-commander/db"
+	"github.com/flanksource/incident-commander/rbac"
+)
+
+const defaultSearchLimit = 100
+
+func RegisterRoutes(e *echo.Echo) {
+	logger.Infof("Registering /audit routes")
+
+	g := e.Group("/audit", rbac.Authorization(rbac.ObjectAudit, policy.ActionRead))
+	g.GET("", SearchHandler)
+	g.GET("/verify", VerifyHandler)
+}
+
+// SearchHandler searches the audit log by actor, token, object, action,
+// decision, route, break-glass access and time.
+func SearchHandler(c echo.Context) error {
+	ctx := c.Request().Context().(context.Context)
+
+	filter, err := ParseFilter(c.QueryParams().Get, time.Now())
+	if err != nil {
+		return dutyAPI.WriteError(c, err)
+	}
+
+	entries, err := db.SearchAuditLog(ctx, filter)
+	if err != nil {
+		return dutyAPI.WriteError(c, err)
+	}
+	return c.JSON(http.StatusOK, dutyAPI.HTTPSuccess{Payload: entries})
+}
+
+// VerifyHandler verifies the chain of the audit log. head_sequence and
+// head_hash, e.g. of the latest head the sink received, override the head in
+// --audit-head.
+func VerifyHandler(c echo.Context) error {
+	ctx := c.Request().Context().(context.Context)
+
+	head, err := parseHead(c.QueryParam("head_sequence"), c.QueryParam("head_hash"))
+	if err != nil {
+		return dutyAPI.WriteError(c, err)
+	}
+
+	result, err := Verify(ctx, head)
+	if err != nil {
+		return dutyAPI.WriteError(c, err)
+	}
+	return c.JSON(http.StatusOK, dutyAPI.HTTPSuccess{Payload: result})
+}
+
+func parseHead(sequence, hash string) (*Head, error) {
+	if sequence == "" && hash == "" {
+		return nil, nil
+	}
+
+	id, err := strconv.ParseInt(sequence, 10, 64)
+	if err != nil || id <= 0 {
+		return nil, dutyAPI.Errorf(dutyAPI.EINVALID, "invalid head_sequence %q", sequence)
+	}
+	if hash == "" {
+		return nil, dutyAPI.Errorf(dutyAPI.EINVALID, "head_hash is required with head_sequence")
+	}
+	return &Head{Type: headRecordType, Sequence: id, Hash: hash}, nil
+}
+
+// ParseFilter reads a filter from named parameters. since and until are
+// either timestamps or durations before now, e.g. 24h or 7d.
+func ParseFilter(get func(string) string, now time.Time) (db.AuditLogFilter, error) {
+	filter := db.AuditLogFilter{
+		Object:   get("object"),
+		Action:   get("action"),
+		Decision: get("decision"),
+		Route:    get("route"),
+		Limit:    defaultSearchLimit,
+	}
+
+	if value := get("break_glass"); value != "" {
+		breakGlass, err := strconv.ParseBool(value)
+		if err != nil {
+			return filter, dutyAPI.Errorf(dutyAPI.EINVALID, "invalid break_glass %q", value)
+		}
+		filter.BreakGlass = breakGlass
+	}
+
+	for param, target := range map[string]**uuid.UUID{"actor": &filter.ActorID, "token": &filter.TokenID} {
+		if value := get(param); value != "" {
+			id, err := uuid.Parse(value)
+			if err != nil {
+				return filter, dutyAPI.Errorf(dutyAPI.EINVALID, "invalid %s %q", param, value)
+			}
+			*target = &id
+		}
+	}
+
+	for param, target := range map[string]**time.Time{"since": &filter.Since, "until": &filter.Until} {
+		if value := get(param); value != "" {
+			t, err := parseTime(value, now)
+			if err != nil {
+				return filter, dutyAPI.Errorf(dutyAPI.EINVALID, "invalid %s %q", param, value)
+			}
+			*target = &t
+		}
+	}
+
+	if value := get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit <= 0 {
+			return filter, dutyAPI.Errorf(dutyAPI.EINVALID, "invalid limit %q", value)
+		}
+		filter.Limit = limit
+	}
+
+	return filter, nil
+}
+
+func parseTime(value string, now time.Time) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+
+	d, err := duration.ParseDuration(value)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return now.Add(-time.Duration(d)), nil
+}
@@ -0,0 +1,25 @@
+package audit
+
+import "github.com/prometheus/client_golang/prometheus"
+
+func init() {
+	prometheus.MustRegister(appendFailedCounter, lostCounter)
+}
+
+var (
+	appendFailedCounter = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name:      "append_failures_total",
+			Subsystem: "audit_log",
+			Help:      "Total number of failed attempts to append a batch of entries to the audit log",
+		},
+	)
+
+	lostCounter = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name:      "lost_total",
+			Subsystem: "audit_log",
+			Help:      "Total number of entries that couldn't be appended to the audit log",
+		},
+	)
+)
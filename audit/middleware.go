@@ -0,0 +1,122 @@
+package audit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"hash"
+	"io"
+	"net/http"
+
+	"github.com/flanksource/duty/context"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+
+	"github.com/flanksource/incident-commander/auth"
+	"github.com/flanksource/incident-commander/db"
+	"github.com/flanksource/incident-commander/rbac"
+)
+
+// Middleware records every mutating request and every request denied by
+// RBAC in the audit log when the audit.log property is on. Every request made
+// under break-glass access is recorded regardless. Entries are appended in the
+// background by Enqueue.
+func Middleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		ctx := c.Request().Context().(context.Context)
+		enabled := ctx.Properties().On(false, "audit.log")
+		mutating := isMutating(c.Request().Method)
+
+		var body *hashingReader
+		if mutating && c.Request().Body != nil && c.Request().Body != http.NoBody {
+			body = &hashingReader{ReadCloser: c.Request().Body, hash: sha256.New()}
+			c.Request().Body = body
+		}
+
+		err := next(c)
+
+		// auth replaces the request to carry the user
+		req := c.Request()
+		ctx = req.Context().(context.Context)
+
+		var actorID, breakGlassID *uuid.UUID
+		if user := ctx.User(); user != nil && user.ID != uuid.Nil {
+			actorID = &user.ID
+			if activation, err := rbac.GetBreakGlass(ctx); err != nil {
+				ctx.Errorf("failed to get break-glass activation of %s: %v", user.ID, err)
+			} else if activation != nil {
+				breakGlassID = &activation.ID
+			}
+		}
+
+		decision := rbac.GetDecision(c)
+		if breakGlassID == nil && (!enabled || (!mutating && (decision == nil || decision.Allowed))) {
+			return err
+		}
+
+		entry := db.AuditLog{
+			ActorID:       actorID,
+			TokenID:       auth.AccessTokenIDOfRequest(req),
+			Impersonation: req.Header.Get(auth.HeaderFlanksourceScope),
+			BreakGlassID:  breakGlassID,
+			Method:        req.Method,
+			Route:         c.Path(),
+			Path:          req.URL.Path,
+			Status:        responseStatus(c, err),
+			RemoteIP:      c.RealIP(),
+		}
+		if decision != nil {
+			entry.Object, entry.Action = decision.Object, decision.Action
+			entry.Decision = db.AuditDecisionAllow
+			if !decision.Allowed {
+				entry.Decision = db.AuditDecisionDeny
+			}
+		} else if entry.Status == http.StatusUnauthorized && entry.ActorID == nil {
+			entry.Decision = db.AuditDecisionUnauthenticated
+		}
+		if body != nil {
+			entry.BodyHash = body.Sum()
+		}
+
+		Enqueue(ctx, entry)
+		return err
+	}
+}
+
+func isMutating(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return false
+	}
+	return true
+}
+
+func responseStatus(c echo.Context, err error) int {
+	if err == nil || c.Response().Committed {
+		return c.Response().Status
+	}
+
+	var httpErr *echo.HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.Code
+	}
+	return http.StatusInternalServerError
+}
+
+// hashingReader hashes a request body as the handler reads it.
+type hashingReader struct {
+	io.ReadCloser
+	hash hash.Hash
+}
+
+func (r *hashingReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.hash.Write(p[:n])
+	return n, err
+}
+
+// Sum returns the hash of the whole body, reading what the handler left.
+func (r *hashingReader) Sum() string {
+	_, _ = io.Copy(r.hash, r.ReadCloser)
+	return hex.EncodeToString(r.hash.Sum(nil))
+}
@@ -0,0 +1,281 @@
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/syslog"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/flanksource/commons/logger"
+	"github.com/flanksource/duty/context"
+)
+
+const (
+	sinkQueueSize     = 1000
+	sinkAttempts      = 3
+	sinkRetryInterval = 30 * time.Second
+)
+
+var (
+	sinkOnce  sync.Once
+	sinkQueue chan []byte
+	sinkSpool *spool
+)
+
+// sink receives a copy of every audit log entry, and of the head of the
+// chain, keeping them outside of the database as well.
+type sink interface {
+	Send(record []byte) error
+}
+
+// stream queues the record for the sink configured by the audit.sink
+// property: syslog://host:514, syslog+tcp://host:514 or an http(s) url
+// records are posted to. The property is read once. Records the sink can't
+// take are spooled to audit.sink.spool and sent, in order, once it can.
+func stream(ctx context.Context, record any) {
+	sinkOnce.Do(func() {
+		target := ctx.Properties().String("audit.sink", "")
+		if target == "" {
+			return
+		}
+
+		s, err := newSink(target)
+		if err != nil {
+			ctx.Errorf("invalid audit.sink: %v", err)
+			return
+		}
+
+		sinkSpool = newSpool(ctx.Properties().String("audit.sink.spool", filepath.Join(os.TempDir(), "mission-control-audit-sink.jsonl")))
+		sinkQueue = make(chan []byte, sinkQueueSize)
+		go deliver(s, sinkSpool, sinkQueue)
+	})
+
+	if sinkQueue == nil {
+		return
+	}
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		ctx.Errorf("failed to marshal audit sink record: %v", err)
+		return
+	}
+
+	select {
+	case sinkQueue <- data:
+	default:
+		if err := sinkSpool.add(data); err != nil {
+			ctx.Errorf("audit sink queue is full and the record couldn't be spooled: %v: %s", err, data)
+		}
+	}
+}
+
+// deliver sends the queued records. Once a record is spooled the ones after
+// it are spooled too, so that the sink receives them in order when the spool
+// is replayed.
+func deliver(s sink, sp *spool, queue <-chan []byte) {
+	ticker := time.NewTicker(sinkRetryInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case data, ok := <-queue:
+			if !ok {
+				return
+			}
+			if sp.pending() {
+				if err := sp.add(data); err != nil {
+					logger.Errorf("failed to spool audit sink record: %v: %s", err, data)
+				}
+				continue
+			}
+			if err := send(s, data); err != nil {
+				logger.Warnf("failed to send to the audit sink, spooling: %v", err)
+				if err := sp.add(data); err != nil {
+					logger.Errorf("failed to spool audit sink record: %v: %s", err, data)
+				}
+			}
+
+		case <-ticker.C:
+			if err := sp.replay(s); err != nil {
+				logger.Warnf("failed to replay the audit sink spool: %v", err)
+			}
+		}
+	}
+}
+
+func send(s sink, data []byte) error {
+	var err error
+	for attempt := 1; attempt <= sinkAttempts; attempt++ {
+		if err = s.Send(data); err == nil {
+			return nil
+		}
+		if attempt < sinkAttempts {
+			time.Sleep(time.Duration(attempt) * time.Second)
+		}
+	}
+	return err
+}
+
+// spool holds, one per line, the records the sink couldn't take.
+type spool struct {
+	path string
+	mu   sync.Mutex
+	size int
+}
+
+// newSpool opens the spool at path, picking up the records a previous run
+// left behind.
+func newSpool(path string) *spool {
+	s := &spool{path: path}
+	if data, err := os.ReadFile(path); err == nil {
+		for _, line := range bytes.Split(data, []byte("\n")) {
+			if len(bytes.TrimSpace(line)) > 0 {
+				s.size++
+			}
+		}
+	}
+	return s
+}
+
+func (s *spool) pending() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.size > 0
+}
+
+func (s *spool) add(data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := os.OpenFile(s.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return err
+	}
+	s.size++
+	return nil
+}
+
+// replay sends the spooled records in order, keeping those from the first
+// one the sink rejects onwards.
+func (s *spool) replay(sk sink) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.size == 0 {
+		return nil
+	}
+
+	data, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		s.size = 0
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	var records [][]byte
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		if line := bytes.TrimSpace(scanner.Bytes()); len(line) > 0 {
+			records = append(records, append([]byte(nil), line...))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	sent := 0
+	var sendErr error
+	for _, record := range records {
+		if sendErr = sk.Send(record); sendErr != nil {
+			break
+		}
+		sent++
+	}
+
+	remaining := records[sent:]
+	if len(remaining) == 0 {
+		s.size = 0
+		return os.Remove(s.path)
+	}
+
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, append(bytes.Join(remaining, []byte("\n")), '\n'), 0o600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return err
+	}
+	s.size = len(remaining)
+	return sendErr
+}
+
+func newSink(target string) (sink, error) {
+	u, err := url.Parse(target)
+	if err != nil {
+		return nil, err
+	}
+
+	switch u.Scheme {
+	case "syslog", "syslog+udp":
+		return &syslogSink{network: "udp", addr: u.Host}, nil
+	case "syslog+tcp":
+		return &syslogSink{network: "tcp", addr: u.Host}, nil
+	case "http", "https":
+		return &httpSink{url: target, client: &http.Client{Timeout: 10 * time.Second}}, nil
+	default:
+		return nil, fmt.Errorf("unsupported scheme %q", u.Scheme)
+	}
+}
+
+type syslogSink struct {
+	network, addr string
+	writer        *syslog.Writer
+}
+
+func (s *syslogSink) Send(record []byte) error {
+	var err error
+	if s.writer == nil {
+		if s.writer, err = syslog.Dial(s.network, s.addr, syslog.LOG_INFO|syslog.LOG_AUTH, "mission-control"); err != nil {
+			return err
+		}
+	}
+
+	if err := s.writer.Info(string(record)); err != nil {
+		// Redial on the next record
+		_ = s.writer.Close()
+		s.writer = nil
+		return err
+	}
+	return nil
+}
+
+type httpSink struct {
+	url    string
+	client *http.Client
+}
+
+func (s *httpSink) Send(record []byte) error {
+	resp, err := s.client.Post(s.url, "application/json", bytes.NewReader(record))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("sink responded with %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package audit
+
+import (
+	gocontext "context"
+	"encoding/json"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/flanksource/commons/logger"
+	"github.com/flanksource/duty/context"
+	"github.com/flanksource/duty/shutdown"
+
+	"github.com/flanksource/incident-commander/db"
+)
+
+const (
+	defaultQueueSize = 10000
+	writerBatchSize  = 100
+	writerAttempts   = 5
+
+	flushTimeout = 30 * time.Second
+)
+
+var (
+	writerOnce    sync.Once
+	writerQueue   chan db.AuditLog
+	writerPending atomic.Int64
+)
+
+// Enqueue hands the entry to the writer of the audit log, which appends the
+// queued entries in batches so that requests don't take turns on the lock of
+// the chain. When the queue is full the entry is appended right away.
+func Enqueue(ctx context.Context, entry db.AuditLog) {
+	writerOnce.Do(func() {
+		writerQueue = make(chan db.AuditLog, ctx.Properties().Int("audit.queue_size", defaultQueueSize))
+		go write(context.NewContext(gocontext.Background()).WithDB(ctx.DB(), ctx.Pool()), writerQueue)
+		shutdown.AddHookWithPriority("audit log", shutdown.PriorityJobs, func() {
+			if !Flush(flushTimeout) {
+				logger.Errorf("audit log entries were still queued after %s", flushTimeout)
+			}
+		})
+	})
+
+	prepare(&entry)
+	writerPending.Add(1)
+	select {
+	case writerQueue <- entry:
+	default:
+		writerPending.Add(-1)
+		if err := Record(ctx, &entry); err != nil {
+			lost(ctx, entry, err)
+		}
+	}
+}
+
+// Flush waits, at most for timeout, until the queued entries are appended.
+// It reports whether they were.
+func Flush(timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for writerPending.Load() > 0 {
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return true
+}
+
+func write(ctx context.Context, queue <-chan db.AuditLog) {
+	for entry := range queue {
+		batch := []*db.AuditLog{&entry}
+	collect:
+		for len(batch) < writerBatchSize {
+			select {
+			case next := <-queue:
+				batch = append(batch, &next)
+			default:
+				break collect
+			}
+		}
+
+		appendBatch(ctx, batch)
+		writerPending.Add(-int64(len(batch)))
+	}
+}
+
+// appendBatch appends the entries, retrying with a backoff. Entries that
+// still can't be appended are logged in full rather than dropped.
+func appendBatch(ctx context.Context, batch []*db.AuditLog) {
+	var err error
+	for attempt := 1; attempt <= writerAttempts; attempt++ {
+		if err = db.AppendAuditLog(ctx, batch, Hash); err == nil {
+			for _, entry := range batch {
+				stream(ctx, *entry)
+			}
+			advanceHead(ctx, *batch[len(batch)-1])
+			return
+		}
+
+		appendFailedCounter.Inc()
+		ctx.Errorf("failed to append %d entries to the audit log (attempt %d of %d): %v", len(batch), attempt, writerAttempts, err)
+		if attempt < writerAttempts {
+			time.Sleep(time.Duration(attempt) * time.Second)
+		}
+	}
+
+	for _, entry := range batch {
+		lost(ctx, *entry, err)
+	}
+}
+
+// lost reports an entry that couldn't be appended. It is logged in full and
+// still streamed to the sink, so that the gap it leaves can be filled in.
+func lost(ctx context.Context, entry db.AuditLog, err error) {
+	lostCounter.Inc()
+	data, _ := json.Marshal(entry)
+	ctx.Errorf("audit log entry lost: %v: %s", err, data)
+	stream(ctx, entry)
+}
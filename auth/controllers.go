@@ -5,13 +5,13 @@ import (
 	"net/http"
 	"os"
 	"slices"
-	"time"
 
-	"github.com/flanksource/commons/duration"
 	dutyAPI "github.com/flanksource/duty/api"
 	"github.com/flanksource/duty/context"
+	"github.com/flanksource/duty/models"
 	"github.com/flanksource/duty/rbac"
 	"github.com/flanksource/duty/rbac/policy"
+	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 	"github.com/samber/lo"
 
@@ -156,6 +156,20 @@ type CreateTokenRequest struct {
 
 	// Empty scope means all permissions the user has
 	Scope []policy.Permission `json:"scope"`
+
+	// ScopeRef limits the rows the token can read to those of a Scope.
+	ScopeRef *rbac.NamespacedNameIDSelector `json:"scope_ref,omitempty"`
+
+	// CIDRs are the networks the token can be used from. Empty means any.
+	CIDRs []string `json:"cidrs,omitempty"`
+}
+
+// TokenPayload is an access token along with its restrictions and the
+// permissions it effectively holds.
+type TokenPayload struct {
+	db.AccessTokenWithUser `json:",inline"`
+	Scope                  *db.AccessTokenScope `json:"scope,omitempty"`
+	Permissions            []policy.Permission  `json:"permissions"`
 }
 
 func CreateToken(c echo.Context) error {
@@ -165,58 +179,62 @@ func CreateToken(c echo.Context) error {
 		return dutyAPI.WriteError(c, dutyAPI.Errorf(dutyAPI.EUNAUTHORIZED, "error fetching user"))
 	}
 
-	var err error
 	var reqData CreateTokenRequest
 	if err := c.Bind(&reqData); err != nil {
 		return dutyAPI.WriteError(c, dutyAPI.Errorf(dutyAPI.EINVALID, "invalid request body: %v", err))
 	}
 
-	var expiry duration.Duration = 0 // Default
-	if reqData.Expiry != "" {
-		expiry, err = duration.ParseDuration(reqData.Expiry)
-		if err != nil {
-			return dutyAPI.WriteError(c, dutyAPI.Errorf(dutyAPI.EINVALID, "error parsing expiry[%s]: %v", reqData.Expiry, err))
-		}
-	}
-
-	tokenResult, err := db.CreateAccessTokenForPerson(ctx, ctx.User(), reqData.Name, time.Duration(expiry), reqData.AutoRenew)
+	token, err := CreateScopedToken(ctx, reqData)
 	if err != nil {
-		return dutyAPI.WriteError(c, ctx.Oops().Wrapf(err, "error creating access token"))
-	}
-
-	if _, err := rbac.Enforcer().AddGroupingPolicy(tokenResult.Person.ID.String(), user.ID.String()); err != nil {
-		return dutyAPI.WriteError(c, ctx.Oops().Wrapf(err, "error grouping token with user"))
+		return dutyAPI.WriteError(c, err)
 	}
 
-	if len(reqData.Scope) > 0 {
-		// Remove subject from scope if exists
-		for i := range reqData.Scope {
-			reqData.Scope[i].Subject = ""
-		}
-
-		var permsToDeny [][]string
-		diff, _ := lo.Difference(icrbac.AllPermissions, reqData.Scope)
-		for _, p := range diff {
-			p.Deny = true
-			permsToDeny = append(permsToDeny, p.ToArgsWithoutSubject())
-		}
+	return c.JSON(http.StatusOK, dutyAPI.HTTPSuccess{Message: "success", Payload: map[string]any{
+		"token":       token.Token.PlainText(),
+		"permissions": token.Permissions,
+	}})
+}
 
-		if len(permsToDeny) > 0 {
-			if _, err := rbac.Enforcer().AddPermissionsForUser(tokenResult.Person.ID.String(), permsToDeny...); err != nil {
-				return dutyAPI.WriteError(c, ctx.Oops().Wrapf(err, "unable to create token"))
-			}
-		}
+// resolveScopeRef returns the id of the Scope a token is limited to.
+func resolveScopeRef(ctx context.Context, ref rbac.NamespacedNameIDSelector) (*uuid.UUID, error) {
+	q := ctx.DB().Model(&models.Scope{}).Where("deleted_at IS NULL")
+	if ref.ID != "" {
+		q = q.Where("id = ?", ref.ID)
+	} else if ref.Name != "" {
+		q = q.Where("name = ? AND namespace = ?", ref.Name, lo.CoalesceOrEmpty(ref.Namespace, "default"))
+	} else {
+		return nil, dutyAPI.Errorf(dutyAPI.EINVALID, "scope_ref requires an id or a name")
 	}
 
-	return c.JSON(http.StatusOK, dutyAPI.HTTPSuccess{Message: "success", Payload: map[string]string{"token": tokenResult.Token.PlainText()}})
+	var ids []uuid.UUID
+	if err := q.Limit(1).Pluck("id", &ids).Error; err != nil {
+		return nil, ctx.Oops().Wrapf(err, "failed to get scope")
+	} else if len(ids) == 0 {
+		return nil, dutyAPI.Errorf(dutyAPI.EINVALID, "scope %s not found", lo.CoalesceOrEmpty(ref.ID, ref.Namespace+"/"+ref.Name))
+	}
+	return &ids[0], nil
 }
 
 func ListTokens(c echo.Context) error {
 	ctx := c.Request().Context().(context.Context)
-	payload, err := db.ListAccessTokens(ctx)
+	tokens, err := db.ListAccessTokens(ctx)
 	if err != nil {
 		return dutyAPI.WriteError(c, ctx.Oops().Wrapf(err, "unable to list tokens"))
 	}
+
+	payload := make([]TokenPayload, 0, len(tokens))
+	for _, token := range tokens {
+		scope, err := db.GetAccessTokenScope(ctx, token.PersonID)
+		if err != nil {
+			return dutyAPI.WriteError(c, ctx.Oops().Wrapf(err, "unable to list tokens"))
+		}
+
+		permissions, err := icrbac.EffectivePermissions(ctx, token.PersonID)
+		if err != nil {
+			return dutyAPI.WriteError(c, ctx.Oops().Wrapf(err, "unable to get permissions of token %s", token.ID))
+		}
+		payload = append(payload, TokenPayload{AccessTokenWithUser: token, Scope: scope, Permissions: permissions})
+	}
 	return c.JSON(http.StatusOK, dutyAPI.HTTPSuccess{Message: "success", Payload: payload})
 }
 
@@ -18,6 +18,8 @@ import (
 	. "github.com/onsi/gomega"
 	"gorm.io/gorm"
 
+	"github.com/flanksource/incident-commander/db"
+	icrbac "github.com/flanksource/incident-commander/rbac"
 	"github.com/flanksource/incident-commander/rbac/adapter"
 )
 
@@ -144,6 +146,37 @@ var _ = Describe("CreateToken", Ordered, func() {
 			Expect(canEnforce(token.PersonID.String(), policy.ObjectCatalog, policy.ActionCRUD)).To(BeFalse())
 			Expect(canEnforce(token.PersonID.String(), policy.ObjectCanary, policy.ActionAll)).To(BeFalse())
 		})
+		It("should persist the restrictions and return the effective permissions", func() {
+			reqData := CreateTokenRequest{
+				Name:  "ci-token",
+				Scope: []policy.Permission{{Object: policy.ObjectCatalog, Action: policy.ActionRead}},
+				CIDRs: []string{"10.0.0.0/8", "192.168.1.1"},
+			}
+
+			ctx := DefaultContext.WithUser(testUser)
+			resp := postCreateToken(ctx, e, reqData, http.StatusOK)
+			payload, ok := resp.Payload.(map[string]any)
+			Expect(ok).To(BeTrue())
+			Expect(payload["permissions"]).To(ConsistOf(HaveKeyWithValue("object", policy.ObjectCatalog)))
+
+			token := findTokenByName(ctx, reqData.Name)
+			scope, err := db.GetAccessTokenScope(ctx, token.PersonID)
+			Expect(err).To(BeNil())
+			Expect(scope).ToNot(BeNil())
+			Expect([]string(scope.CIDRs)).To(Equal(reqData.CIDRs))
+
+			tokenScope, err := icrbac.GetTokenScope(ctx, token.PersonID)
+			Expect(err).To(BeNil())
+			Expect(tokenScope.Allows(policy.ObjectCatalog, policy.ActionRead)).To(BeTrue())
+			Expect(tokenScope.Allows(policy.ObjectPlaybooks, policy.ActionRead)).To(BeFalse())
+			Expect(tokenScope.AllowsIP("192.168.1.1")).To(BeTrue())
+			Expect(tokenScope.AllowsIP("172.16.0.1")).To(BeFalse())
+		})
+
+		It("should reject invalid networks", func() {
+			reqData := CreateTokenRequest{Name: "bad-cidr-token", CIDRs: []string{"10.0.0.0/33"}}
+			_ = postCreateToken(DefaultContext.WithUser(testUser), e, reqData, http.StatusBadRequest)
+		})
 	})
 
 	Context("with invalid request", func() {
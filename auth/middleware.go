@@ -51,6 +51,7 @@ var (
 	Clerk           = "clerk"
 	Kratos          = "kratos"
 	Basic           = "basic"
+	OIDC            = "oidc"
 )
 
 var skipAuthPathPrefixes = []string{
@@ -58,11 +59,13 @@ var skipAuthPathPrefixes = []string{
 	"/canary/webhook/",
 	"/playbook/webhook/", // Playbook webhooks handle the authentication themselves
 	"/auth/basic/",
+	"/auth/oidc/", // Relying party login, callback and logout
 	"/auth/kratos/error",
 	"/auth/kratos/hooks/",
 	"/oidc/",
 	"/.well-known/",
 	"/oauth/", // Standard OIDC protocol endpoints (mounted at root to match the issuer URL).
+	"/scim/",  // SCIM provisioning authenticates its scoped access tokens itself
 }
 
 var skipAuthPathsExact = []string{
@@ -165,10 +168,29 @@ func Middleware(ctx context.Context, e *echo.Echo) error {
 			return fmt.Errorf("error setting property in database: %v", err)
 		}
 
+	case OIDC:
+		rpHandler, err := NewRelyingPartyHandler(ctx, RelyingParty)
+		if err != nil {
+			return fmt.Errorf("failed to initialize oidc relying party: %w", err)
+		}
+		rpHandler.MountRoutes(e)
+		e.Use(rpHandler.Session)
+
+		if OIDCEnabled {
+			rpChecker := NewRelyingPartyCredentialChecker(rpHandler)
+			if err := oidc.MountRoutes(e, ctx, OIDCIssuerURL(), nil, rpChecker, nil); err != nil {
+				return fmt.Errorf("failed to mount OIDC routes: %w", err)
+			}
+			logger.Infof("OIDC provider enabled at %s (OIDC auth via %s)", OIDCIssuerURL(), RelyingParty.Issuer)
+		}
+
 	default:
 		return fmt.Errorf("invalid auth provider: %s", vars.AuthMode)
 	}
 
+	// Runs after the session middleware has resolved the access token
+	e.Use(rbac.EnforceTokenScopes)
+
 	// Initiate RBAC
 	if err := dutyRBAC.Init(ctx, []string{adminUserID, api.SystemUserID.String()}, adapter.NewPermissionAdapter); err != nil {
 		return fmt.Errorf("failed to initialize rbac: %w", err)
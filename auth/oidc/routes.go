@@ -53,6 +53,7 @@ func MountRoutes(e *echo.Echo, ctx context.Context, issuerURL string, passwordCh
 	e.GET("/oidc/kratos/callback", loginHandler.HandleExternalCallback)
 	e.GET("/oidc/clerk/callback", loginHandler.HandleExternalCallback)
 	e.POST("/oidc/clerk/callback", loginHandler.HandleExternalCallback)
+	e.GET("/oidc/sso/callback", loginHandler.HandleExternalCallback)
 
 	// MCP Clients need OAuth well-known discovery endpoints (not just OIDC discovery).
 	mountOAuthRoutes(e, oidcIssuer, provider.Handler)
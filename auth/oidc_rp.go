@@ -0,0 +1,655 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/flanksource/commons/hash"
+	"github.com/flanksource/commons/logger"
+	"github.com/flanksource/duty/context"
+	"github.com/flanksource/duty/models"
+	"github.com/flanksource/duty/query"
+	dutyRBAC "github.com/flanksource/duty/rbac"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/patrickmn/go-cache"
+	"github.com/samber/lo"
+	"github.com/zitadel/oidc/v3/pkg/client/rp"
+	httphelper "github.com/zitadel/oidc/v3/pkg/http"
+	oidclib "github.com/zitadel/oidc/v3/pkg/oidc"
+	"golang.org/x/sync/singleflight"
+	"gorm.io/gorm"
+
+	"github.com/flanksource/incident-commander/auth/accesstoken"
+	"github.com/flanksource/incident-commander/auth/oidc"
+	"github.com/flanksource/incident-commander/auth/signing"
+	"github.com/flanksource/incident-commander/db"
+)
+
+const (
+	oidcLoginPath    = "/auth/oidc/login"
+	oidcCallbackPath = "/auth/oidc/callback"
+	oidcLogoutPath   = "/auth/oidc/logout"
+
+	oidcSessionCookie = "mc_oidc_session"
+	oidcNextCookie    = "mc_oidc_next"
+
+	// oidcSessionMaxAge is how long a session cookie lives without being
+	// refreshed. The session ends earlier when the identity provider refuses
+	// to refresh it.
+	oidcSessionMaxAge = 7 * 24 * time.Hour
+
+	// oidcTeamSource is the source of the team memberships synced from the group claim.
+	oidcTeamSource = "OIDC"
+)
+
+var (
+	errOIDCSessionExpired = errors.New("oidc session has expired")
+	errPersonDeleted      = errors.New("person has been deleted")
+)
+
+// RelyingPartyConfig configures Mission Control as a relying party of an
+// upstream OIDC identity provider (--auth=oidc).
+type RelyingPartyConfig struct {
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	// RedirectURL is the callback registered with the identity provider.
+	// Defaults to {issuer url}/auth/oidc/callback.
+	RedirectURL string
+	Scopes      []string
+
+	// NameClaim, EmailClaim and GroupsClaim are the ID token claims the
+	// person's name, email and groups are read from.
+	NameClaim   string
+	EmailClaim  string
+	GroupsClaim string
+
+	// RoleMapping maps a group to a role.
+	// Users that aren't in any of the mapped groups get the DefaultRole.
+	RoleMapping map[string]string
+	DefaultRole string
+
+	// TeamMapping maps a group to a team name or id.
+	TeamMapping map[string]string
+}
+
+var RelyingParty RelyingPartyConfig
+
+// oidcSession is the login state kept in the encrypted session cookie.
+type oidcSession struct {
+	ID       string `json:"sid"`
+	PersonID string `json:"pid"`
+	// ExpiresAt is when the upstream tokens expire and the session must be refreshed.
+	ExpiresAt    time.Time `json:"exp"`
+	RefreshToken string    `json:"rt,omitempty"`
+}
+
+type RelyingPartyHandler struct {
+	config   RelyingPartyConfig
+	provider rp.RelyingParty
+	sessions *httphelper.CookieHandler
+
+	// userCache caches the person of a session or bearer token.
+	userCache *cache.Cache
+
+	// refreshed keeps refreshed sessions for a short while so that concurrent
+	// requests carrying the same expired cookie don't each spend the refresh
+	// token. Identity providers that rotate refresh tokens revoke the session
+	// when an old one is reused.
+	refreshed    *cache.Cache
+	refreshGroup singleflight.Group
+}
+
+func NewRelyingPartyHandler(ctx context.Context, config RelyingPartyConfig) (*RelyingPartyHandler, error) {
+	if config.Issuer == "" {
+		return nil, fmt.Errorf("auth-oidc-issuer is required")
+	}
+	if config.ClientID == "" {
+		return nil, fmt.Errorf("auth-oidc-client-id is required")
+	}
+	if config.RedirectURL == "" {
+		config.RedirectURL = OIDCIssuerURL() + oidcCallbackPath
+	}
+	config.NameClaim = lo.CoalesceOrEmpty(config.NameClaim, "name")
+	config.EmailClaim = lo.CoalesceOrEmpty(config.EmailClaim, "email")
+	config.GroupsClaim = lo.CoalesceOrEmpty(config.GroupsClaim, "groups")
+	if !lo.Contains(config.Scopes, oidclib.ScopeOpenID) {
+		config.Scopes = append([]string{oidclib.ScopeOpenID}, config.Scopes...)
+	}
+
+	hashKey, encryptKey, err := relyingPartyCookieKeys()
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive cookie keys: %w", err)
+	}
+
+	var cookieOpts []httphelper.CookieHandlerOpt
+	if !strings.HasPrefix(strings.ToLower(config.RedirectURL), "https://") {
+		cookieOpts = append(cookieOpts, httphelper.WithUnsecure())
+	}
+
+	provider, err := rp.NewRelyingPartyOIDC(ctx, config.Issuer, config.ClientID, config.ClientSecret, config.RedirectURL, config.Scopes,
+		rp.WithPKCE(httphelper.NewCookieHandler(hashKey, encryptKey, cookieOpts...)),
+		rp.WithUnauthorizedHandler(func(w http.ResponseWriter, r *http.Request, desc, _ string) {
+			logger.Warnf("oidc login failed: %s", desc)
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		}),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover oidc issuer %s: %w", config.Issuer, err)
+	}
+
+	return &RelyingPartyHandler{
+		config:    config,
+		provider:  provider,
+		sessions:  httphelper.NewCookieHandler(hashKey, encryptKey, append(cookieOpts, httphelper.WithMaxAge(int(oidcSessionMaxAge.Seconds())))...),
+		userCache: cache.New(5*time.Minute, 10*time.Minute),
+		refreshed: cache.New(time.Minute, 5*time.Minute),
+	}, nil
+}
+
+// relyingPartyCookieKeys derives the keys of the session, state and PKCE cookies
+// from the signing key so that sessions survive restarts and work across replicas.
+func relyingPartyCookieKeys() (hashKey, encryptKey []byte, err error) {
+	privateKey, _, err := signing.PrivateKey()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	derive := func(label string) []byte {
+		mac := hmac.New(sha256.New, x509.MarshalPKCS1PrivateKey(privateKey))
+		mac.Write([]byte(label))
+		return mac.Sum(nil)
+	}
+	return derive("oidc-rp-cookie-hash"), derive("oidc-rp-cookie-encrypt"), nil
+}
+
+func (h *RelyingPartyHandler) MountRoutes(e *echo.Echo) {
+	e.GET(oidcLoginPath, h.Login)
+	e.GET(oidcCallbackPath, h.Callback)
+	e.GET(oidcLogoutPath, h.Logout)
+	e.POST(oidcLogoutPath, h.Logout)
+}
+
+// Login redirects to the identity provider, or straight to ?next= when the
+// browser already has a session.
+func (h *RelyingPartyHandler) Login(c echo.Context) error {
+	next := sanitizeNext(c.QueryParam("next"))
+	if sess, err := h.readSession(c.Request()); err == nil && time.Now().Before(sess.ExpiresAt) {
+		return c.Redirect(http.StatusFound, next)
+	}
+
+	if err := h.provider.CookieHandler().SetCookie(c.Response(), oidcNextCookie, next); err != nil {
+		return c.String(http.StatusInternalServerError, "failed to start login")
+	}
+
+	rp.AuthURLHandler(uuid.NewString, h.provider).ServeHTTP(c.Response(), c.Request())
+	return nil
+}
+
+// Callback completes the authorization code flow, syncs the person from the
+// ID token claims and starts a session.
+func (h *RelyingPartyHandler) Callback(c echo.Context) error {
+	ctx := c.Request().Context().(context.Context)
+
+	completeLogin := func(w http.ResponseWriter, r *http.Request, tokens *oidclib.Tokens[*oidclib.IDTokenClaims], _ string, _ rp.RelyingParty) {
+		person, err := h.login(ctx, tokens.IDTokenClaims)
+		if err != nil {
+			ctx.Errorf("oidc login of %s failed: %v", tokens.IDTokenClaims.GetSubject(), err)
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		sess := oidcSession{
+			ID:           uuid.NewString(),
+			PersonID:     person.ID.String(),
+			ExpiresAt:    tokensExpiry(tokens),
+			RefreshToken: tokens.RefreshToken,
+		}
+		if err := h.writeSession(w, sess); err != nil {
+			ctx.Errorf("failed to write oidc session cookie: %v", err)
+			http.Error(w, "failed to start session", http.StatusInternalServerError)
+			return
+		}
+
+		next, _ := h.provider.CookieHandler().CheckCookie(r, oidcNextCookie)
+		h.provider.CookieHandler().DeleteCookie(w, oidcNextCookie)
+
+		AddLoginContext(c, person)
+		http.Redirect(w, r, sanitizeNext(next), http.StatusFound)
+	}
+
+	rp.CodeExchangeHandler(completeLogin, h.provider).ServeHTTP(c.Response(), c.Request())
+	return nil
+}
+
+// Logout ends the session, revokes its refresh token and sends browsers to
+// the end session endpoint of the identity provider.
+func (h *RelyingPartyHandler) Logout(c echo.Context) error {
+	ctx := c.Request().Context().(context.Context)
+
+	if sess, err := h.readSession(c.Request()); err == nil {
+		h.userCache.Delete(sess.ID)
+		if sess.RefreshToken != "" && h.provider.GetRevokeEndpoint() != "" {
+			if err := rp.RevokeToken(ctx, h.provider, sess.RefreshToken, "refresh_token"); err != nil {
+				ctx.Warnf("failed to revoke oidc refresh token: %v", err)
+			}
+		}
+	}
+	h.sessions.DeleteCookie(c.Response(), oidcSessionCookie)
+
+	if wantsHTML(c) && h.provider.GetEndSessionEndpoint() != "" {
+		if endSession, err := rp.EndSession(ctx, h.provider, "", "", "", "", nil); err == nil {
+			return c.Redirect(http.StatusFound, endSession.String())
+		}
+	}
+	return c.JSON(http.StatusOK, map[string]string{"message": "logged out"})
+}
+
+func (h *RelyingPartyHandler) Session(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if canSkipAuth(c) {
+			return next(c)
+		}
+
+		ctx := c.Request().Context().(context.Context)
+
+		var (
+			person    *models.Person
+			sessionID string
+			err       error
+		)
+		if token, ok := extractBearerAuthToken(c.Request().Header); ok {
+			if OIDCEnabled {
+				if authenticated, err := authenticateOIDCToken(c, token); err != nil {
+					return c.JSON(http.StatusInternalServerError, map[string]string{"error": "internal error"})
+				} else if authenticated {
+					return next(c)
+				}
+			}
+			person, err = h.authenticateBearer(ctx, token)
+		} else if username, password, ok := c.Request().BasicAuth(); ok {
+			// Agents use basic auth with `token:<access_token>` format
+			if strings.ToLower(username) != "token" {
+				return c.JSON(http.StatusUnauthorized, map[string]string{"error": "invalid username for basic auth"})
+			}
+			person, err = h.authenticateAccessToken(ctx, password)
+		} else {
+			person, sessionID, err = h.authenticateSession(c)
+		}
+
+		if err != nil || person == nil {
+			if err != nil {
+				ctx.GetSpan().RecordError(err)
+			}
+			return h.rejectUnauthenticated(c)
+		}
+
+		if err := InjectToken(ctx, c, person, sessionID); err != nil {
+			return err
+		}
+
+		ctx = ctx.WithUser(person)
+		c.SetRequest(c.Request().WithContext(ctx))
+		return next(c)
+	}
+}
+
+func (h *RelyingPartyHandler) rejectUnauthenticated(c echo.Context) error {
+	if wantsHTML(c) {
+		return c.Redirect(http.StatusFound, oidcLoginPath+"?next="+url.QueryEscape(c.Request().URL.RequestURI()))
+	}
+	setWWWAuthenticate(c)
+	return c.JSON(http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+}
+
+// authenticateSession authenticates the session cookie, refreshing it with the
+// identity provider once the upstream tokens have expired.
+func (h *RelyingPartyHandler) authenticateSession(c echo.Context) (*models.Person, string, error) {
+	ctx := c.Request().Context().(context.Context)
+
+	sess, err := h.readSession(c.Request())
+	if err != nil {
+		return nil, "", err
+	}
+
+	if !time.Now().Before(sess.ExpiresAt) {
+		refreshed, err := h.refresh(ctx, sess)
+		if err != nil {
+			h.sessions.DeleteCookie(c.Response(), oidcSessionCookie)
+			return nil, "", err
+		}
+		if err := h.writeSession(c.Response(), refreshed); err != nil {
+			return nil, "", err
+		}
+		sess = refreshed
+	}
+
+	if cached, ok := h.userCache.Get(sess.ID); ok {
+		return cached.(*models.Person), sess.ID, nil
+	}
+
+	person, err := db.GetUserByID(ctx, sess.PersonID)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to get person %s: %w", sess.PersonID, err)
+	}
+	if deleted, err := db.IsPersonDeleted(ctx, person.ID); err != nil {
+		return nil, "", err
+	} else if deleted {
+		h.sessions.DeleteCookie(c.Response(), oidcSessionCookie)
+		return nil, "", errPersonDeleted
+	}
+	h.userCache.SetDefault(sess.ID, &person)
+	return &person, sess.ID, nil
+}
+
+// refresh exchanges the refresh token of an expired session for new tokens and
+// re-syncs the person when the identity provider returns a new ID token.
+func (h *RelyingPartyHandler) refresh(ctx context.Context, sess oidcSession) (oidcSession, error) {
+	if refreshed, ok := h.refreshed.Get(sess.ID); ok {
+		return refreshed.(oidcSession), nil
+	}
+	if sess.RefreshToken == "" {
+		return sess, errOIDCSessionExpired
+	}
+
+	result, err, _ := h.refreshGroup.Do(sess.ID, func() (any, error) {
+		tokens, err := rp.RefreshTokens[*oidclib.IDTokenClaims](ctx, h.provider, sess.RefreshToken, "", "")
+		if err != nil {
+			return nil, fmt.Errorf("failed to refresh oidc session: %w", err)
+		}
+
+		refreshed := sess
+		refreshed.ExpiresAt = tokensExpiry(tokens)
+		if tokens.RefreshToken != "" {
+			refreshed.RefreshToken = tokens.RefreshToken
+		}
+
+		if tokens.IDTokenClaims != nil {
+			person, err := h.login(ctx, tokens.IDTokenClaims)
+			if err != nil {
+				return nil, err
+			}
+			if person.ID.String() != sess.PersonID {
+				return nil, fmt.Errorf("refreshed oidc session belongs to another person")
+			}
+		}
+
+		h.userCache.Delete(sess.ID)
+		h.refreshed.SetDefault(sess.ID, refreshed)
+		return refreshed, nil
+	})
+	if err != nil {
+		return sess, err
+	}
+	return result.(oidcSession), nil
+}
+
+// authenticateBearer authenticates Mission Control access tokens and ID tokens
+// issued by the identity provider to this client.
+func (h *RelyingPartyHandler) authenticateBearer(ctx context.Context, token string) (*models.Person, error) {
+	if _, err := accesstoken.Parse(token); err == nil {
+		return h.authenticateAccessToken(ctx, token)
+	}
+
+	cacheKey := "bearer:" + hash.Sha256Hex(token)
+	if cached, ok := h.userCache.Get(cacheKey); ok {
+		return cached.(*models.Person), nil
+	}
+
+	claims, err := rp.VerifyIDToken[*oidclib.IDTokenClaims](ctx, token, h.provider.IDTokenVerifier())
+	if err != nil {
+		return nil, fmt.Errorf("invalid oidc bearer token: %w", err)
+	}
+
+	person, err := h.login(ctx, claims)
+	if err != nil {
+		return nil, err
+	}
+
+	h.userCache.Set(cacheKey, person, min(time.Until(claims.GetExpiration()), 5*time.Minute))
+	return person, nil
+}
+
+func (h *RelyingPartyHandler) authenticateAccessToken(ctx context.Context, token string) (*models.Person, error) {
+	accessToken, err := getAccessToken(ctx, token)
+	if err != nil {
+		return nil, err
+	} else if accessToken == nil {
+		return nil, fmt.Errorf("access token not found")
+	}
+
+	person, err := db.GetUserByID(ctx, accessToken.PersonID.String())
+	if err != nil {
+		return nil, fmt.Errorf("error fetching user by id[%s]: %w", accessToken.PersonID, err)
+	}
+	if deleted, err := db.IsPersonDeleted(ctx, person.ID); err != nil {
+		return nil, err
+	} else if deleted {
+		return nil, errPersonDeleted
+	}
+	return &person, nil
+}
+
+// login syncs the person, their roles and their teams from the ID token claims.
+func (h *RelyingPartyHandler) login(ctx context.Context, claims *oidclib.IDTokenClaims) (*models.Person, error) {
+	person, err := h.syncPerson(ctx, claims)
+	if err != nil {
+		return nil, err
+	}
+
+	groups := claimStrings(claims.Claims, h.config.GroupsClaim)
+	if err := h.syncRoles(person.ID.String(), groups); err != nil {
+		return nil, ctx.Oops().Wrapf(err, "failed to sync roles of %s", person.ID)
+	}
+	if err := h.syncTeams(ctx, person.ID, groups); err != nil {
+		return nil, ctx.Oops().Wrapf(err, "failed to sync teams of %s", person.ID)
+	}
+	return person, nil
+}
+
+// syncPerson finds the person of the subject and keeps their profile in sync
+// with the identity provider.
+//
+// A person without an external id whose email matches a verified email
+// claim, e.g. one created before SSO was enabled, is linked to the subject.
+func (h *RelyingPartyHandler) syncPerson(ctx context.Context, claims *oidclib.IDTokenClaims) (*models.Person, error) {
+	subject := claims.GetSubject()
+	if subject == "" {
+		return nil, fmt.Errorf("id token has no subject")
+	}
+
+	email := claimString(claims.Claims, h.config.EmailClaim)
+	profile := models.Person{
+		ExternalID: subject,
+		Email:      email,
+		Name:       lo.CoalesceOrEmpty(claimString(claims.Claims, h.config.NameClaim), claims.PreferredUsername, email, subject),
+		Avatar:     claims.Picture,
+	}
+
+	person, err := db.GetUserByExternalID(ctx, subject)
+	if errors.Is(err, gorm.ErrRecordNotFound) && email != "" && bool(claims.EmailVerified) {
+		err = ctx.DB().Where("LOWER(email) = LOWER(?) AND COALESCE(external_id, '') = '' AND deleted_at IS NULL", email).First(&person).Error
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		created, err := db.CreateUser(ctx, profile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create person for %s: %w", subject, err)
+		}
+		return &created, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to find person for %s: %w", subject, err)
+	}
+
+	// Deprovisioned people aren't brought back by logging in again.
+	if deleted, err := db.IsPersonDeleted(ctx, person.ID); err != nil {
+		return nil, err
+	} else if deleted {
+		return nil, errPersonDeleted
+	}
+
+	updates := map[string]any{}
+	if person.ExternalID != profile.ExternalID {
+		updates["external_id"] = profile.ExternalID
+	}
+	if person.Name != profile.Name {
+		updates["name"] = profile.Name
+	}
+	if profile.Email != "" && person.Email != profile.Email {
+		updates["email"] = profile.Email
+	}
+	if profile.Avatar != "" && person.Avatar != profile.Avatar {
+		updates["avatar"] = profile.Avatar
+	}
+	if len(updates) > 0 {
+		if err := ctx.DB().Model(&models.Person{}).Where("id = ?", person.ID).Updates(updates).Error; err != nil {
+			return nil, fmt.Errorf("failed to update person %s: %w", person.ID, err)
+		}
+		if err := ctx.DB().Where("id = ?", person.ID).First(&person).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	return &person, nil
+}
+
+// syncRoles grants the roles mapped from the groups, or the default role when
+// no group is mapped, and revokes the other mapped roles.
+// Roles that aren't part of the mapping are left untouched.
+func (h *RelyingPartyHandler) syncRoles(personID string, groups []string) error {
+	roles := lo.Uniq(lo.FilterMap(groups, func(group string, _ int) (string, bool) {
+		role, ok := h.config.RoleMapping[group]
+		return role, ok && role != ""
+	}))
+	if len(roles) == 0 && h.config.DefaultRole != "" {
+		roles = []string{h.config.DefaultRole}
+	}
+
+	managed := lo.Compact(lo.Uniq(append(lo.Values(h.config.RoleMapping), h.config.DefaultRole)))
+	for _, role := range managed {
+		if lo.Contains(roles, role) {
+			if err := dutyRBAC.AddRoleForUser(personID, role); err != nil {
+				return fmt.Errorf("failed to add role %s: %w", role, err)
+			}
+		} else if err := dutyRBAC.DeleteRoleForUser(personID, role); err != nil {
+			return fmt.Errorf("failed to delete role %s: %w", role, err)
+		}
+	}
+	return nil
+}
+
+// syncTeams makes the person a member of the teams mapped from their groups
+// and removes them from the mapped teams they no longer belong to.
+func (h *RelyingPartyHandler) syncTeams(ctx context.Context, personID uuid.UUID, groups []string) error {
+	if len(h.config.TeamMapping) == 0 {
+		return nil
+	}
+
+	teamNames := lo.Uniq(lo.FilterMap(groups, func(group string, _ int) (string, bool) {
+		team, ok := h.config.TeamMapping[group]
+		return team, ok && team != ""
+	}))
+	sort.Strings(teamNames)
+
+	var teamIDs []uuid.UUID
+	for _, name := range teamNames {
+		team, err := query.FindTeam(ctx, name)
+		if err != nil || team == nil {
+			ctx.Warnf("oidc group mapped to unknown team %s: %v", name, err)
+			continue
+		}
+		teamIDs = append(teamIDs, team.ID)
+	}
+
+	return db.SyncPersonTeams(ctx, personID, teamIDs, oidcTeamSource)
+}
+
+func (h *RelyingPartyHandler) readSession(r *http.Request) (oidcSession, error) {
+	var sess oidcSession
+	value, err := h.sessions.CheckCookie(r, oidcSessionCookie)
+	if err != nil {
+		return sess, err
+	}
+	if err := json.Unmarshal([]byte(value), &sess); err != nil {
+		return sess, err
+	}
+	if sess.ID == "" || sess.PersonID == "" {
+		return sess, fmt.Errorf("invalid oidc session")
+	}
+	return sess, nil
+}
+
+func (h *RelyingPartyHandler) writeSession(w http.ResponseWriter, sess oidcSession) error {
+	value, err := json.Marshal(sess)
+	if err != nil {
+		return err
+	}
+	return h.sessions.SetCookie(w, oidcSessionCookie, string(value))
+}
+
+// tokensExpiry returns when the access token, or else the ID token, expires.
+func tokensExpiry(tokens *oidclib.Tokens[*oidclib.IDTokenClaims]) time.Time {
+	if tokens.Token != nil && !tokens.Expiry.IsZero() {
+		return tokens.Expiry
+	}
+	if tokens.IDTokenClaims != nil {
+		return tokens.IDTokenClaims.GetExpiration()
+	}
+	return time.Now().Add(time.Hour)
+}
+
+func claimString(claims map[string]any, key string) string {
+	value, _ := claims[key].(string)
+	return value
+}
+
+// claimStrings reads a claim that's either a list of strings or a single string.
+func claimStrings(claims map[string]any, key string) []string {
+	switch value := claims[key].(type) {
+	case string:
+		return []string{value}
+	case []string:
+		return value
+	case []any:
+		return lo.FilterMap(value, func(v any, _ int) (string, bool) {
+			s, ok := v.(string)
+			return s, ok
+		})
+	default:
+		return nil
+	}
+}
+
+var _ oidc.ExternalLoginProvider = (*RelyingPartyCredentialChecker)(nil)
+
+// RelyingPartyCredentialChecker completes logins to the embedded OIDC provider
+// with the upstream identity provider session.
+type RelyingPartyCredentialChecker struct {
+	handler *RelyingPartyHandler
+}
+
+func NewRelyingPartyCredentialChecker(h *RelyingPartyHandler) *RelyingPartyCredentialChecker {
+	return &RelyingPartyCredentialChecker{handler: h}
+}
+
+func (r *RelyingPartyCredentialChecker) LoginRedirectURL(authRequestID string) (string, error) {
+	callback := url.URL{Path: "/oidc/sso/callback", RawQuery: url.Values{"auth_request_id": {authRequestID}}.Encode()}
+	return oidcLoginPath + "?" + url.Values{"next": {callback.String()}}.Encode(), nil
+}
+
+func (r *RelyingPartyCredentialChecker) CallbackSubject(c echo.Context) (string, error) {
+	person, _, err := r.handler.authenticateSession(c)
+	if err != nil {
+		return "", err
+	}
+	return person.ID.String(), nil
+}
@@ -0,0 +1,349 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/flanksource/duty/models"
+	dutyRBAC "github.com/flanksource/duty/rbac"
+	"github.com/flanksource/duty/tests/fixtures/dummy"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	ginkgo "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	dbModels "github.com/flanksource/incident-commander/db/models"
+	"github.com/flanksource/incident-commander/rbac/adapter"
+)
+
+// mockIdP is an upstream OIDC identity provider that serves discovery, JWKS
+// and a token endpoint verifying PKCE and rotating refresh tokens.
+type mockIdP struct {
+	server   *httptest.Server
+	key      *rsa.PrivateKey
+	clientID string
+
+	mutex         sync.Mutex
+	subject       string
+	groups        []string
+	code          string
+	codeChallenge string
+	refreshToken  string
+	refreshes     int
+}
+
+func newMockIdP(clientID string) *mockIdP {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	Expect(err).ToNot(HaveOccurred())
+
+	idp := &mockIdP{key: key, clientID: clientID, subject: "idp|" + uuid.NewString()}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"issuer":                                idp.server.URL,
+			"authorization_endpoint":                idp.server.URL + "/authorize",
+			"token_endpoint":                        idp.server.URL + "/token",
+			"jwks_uri":                              idp.server.URL + "/keys",
+			"end_session_endpoint":                  idp.server.URL + "/logout",
+			"response_types_supported":              []string{"code"},
+			"subject_types_supported":               []string{"public"},
+			"id_token_signing_alg_values_supported": []string{"RS256"},
+		})
+	})
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"use": "sig",
+				"alg": "RS256",
+				"kid": "mock-idp",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	})
+	mux.HandleFunc("/token", idp.token)
+	idp.server = httptest.NewServer(mux)
+	return idp
+}
+
+func (idp *mockIdP) token(w http.ResponseWriter, r *http.Request) {
+	idp.mutex.Lock()
+	defer idp.mutex.Unlock()
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	switch r.Form.Get("grant_type") {
+	case "authorization_code":
+		verifier := sha256.Sum256([]byte(r.Form.Get("code_verifier")))
+		if r.Form.Get("code") != idp.code || base64.RawURLEncoding.EncodeToString(verifier[:]) != idp.codeChallenge {
+			http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+			return
+		}
+		idp.code = ""
+	case "refresh_token":
+		if r.Form.Get("refresh_token") != idp.refreshToken {
+			http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+			return
+		}
+		idp.refreshes++
+	default:
+		http.Error(w, `{"error":"unsupported_grant_type"}`, http.StatusBadRequest)
+		return
+	}
+
+	idp.refreshToken = uuid.NewString()
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"access_token":  uuid.NewString(),
+		"token_type":    "Bearer",
+		"expires_in":    300,
+		"refresh_token": idp.refreshToken,
+		"id_token":      idp.idToken(),
+	})
+}
+
+func (idp *mockIdP) idToken() string {
+	now := time.Now()
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss":            idp.server.URL,
+		"sub":            idp.subject,
+		"aud":            idp.clientID,
+		"iat":            now.Unix(),
+		"exp":            now.Add(5 * time.Minute).Unix(),
+		"name":           "SSO User",
+		"email":          "sso-user@example.com",
+		"email_verified": true,
+		"groups":         idp.groups,
+	})
+	token.Header["kid"] = "mock-idp"
+	signed, err := token.SignedString(idp.key)
+	Expect(err).ToNot(HaveOccurred())
+	return signed
+}
+
+func cookieHeader(cookies []*http.Cookie) string {
+	var pairs []string
+	for _, c := range cookies {
+		if c.MaxAge >= 0 && c.Value != "" {
+			pairs = append(pairs, c.Name+"="+c.Value)
+		}
+	}
+	return strings.Join(pairs, "; ")
+}
+
+var _ = ginkgo.Describe("OIDC relying party", ginkgo.Ordered, func() {
+	var (
+		idp     *mockIdP
+		handler *RelyingPartyHandler
+		e       *echo.Echo
+		team    models.Team
+		person  models.Person
+	)
+
+	ginkgo.BeforeAll(func() {
+		if dutyRBAC.Enforcer() == nil {
+			Expect(dutyRBAC.Init(DefaultContext, []string{}, adapter.NewPermissionAdapter)).To(Succeed())
+		}
+
+		team = models.Team{ID: uuid.New(), Name: "sso-sre-" + uuid.NewString()[:8], CreatedBy: dummy.JohnDoe.ID}
+		Expect(DefaultContext.DB().Create(&team).Error).To(Succeed())
+
+		idp = newMockIdP("mission-control")
+		idp.groups = []string{"sre", "platform-admins"}
+
+		var err error
+		handler, err = NewRelyingPartyHandler(DefaultContext, RelyingPartyConfig{
+			Issuer:      idp.server.URL,
+			ClientID:    idp.clientID,
+			RedirectURL: "http://localhost:3000/auth/oidc/callback",
+			Scopes:      []string{"openid", "profile", "email", "offline_access"},
+			RoleMapping: map[string]string{"platform-admins": "admin"},
+			TeamMapping: map[string]string{"sre": team.Name},
+			DefaultRole: "viewer",
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		e = newEchoInstance(DefaultContext)
+		handler.MountRoutes(e)
+		e.Use(handler.Session)
+		e.GET("/whoami", func(c echo.Context) error {
+			return c.String(http.StatusOK, c.Request().Header.Get(echo.HeaderAuthorization))
+		})
+	})
+
+	ginkgo.AfterAll(func() {
+		idp.server.Close()
+		if person.ID != uuid.Nil {
+			_, _ = dutyRBAC.Enforcer().DeleteRolesForUser(person.ID.String())
+			DefaultContext.DB().Where("person_id = ?", person.ID).Delete(&dbModels.TeamMember{})
+			DefaultContext.DB().Where("id = ?", person.ID).Delete(&models.Person{})
+		}
+		DefaultContext.DB().Where("id = ?", team.ID).Delete(&models.Team{})
+	})
+
+	var sessionCookies []*http.Cookie
+
+	ginkgo.It("logs in with the authorization code flow", func() {
+		req := httptest.NewRequest(http.MethodGet, "/auth/oidc/login?next=/whoami", nil)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		Expect(rec.Code).To(Equal(http.StatusFound))
+
+		authorize, err := url.Parse(rec.Header().Get("Location"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(authorize.Path).To(Equal("/authorize"))
+		Expect(authorize.Query().Get("client_id")).To(Equal(idp.clientID))
+		Expect(authorize.Query().Get("code_challenge_method")).To(Equal("S256"))
+
+		idp.code = uuid.NewString()
+		idp.codeChallenge = authorize.Query().Get("code_challenge")
+
+		callback := "/auth/oidc/callback?" + url.Values{"code": {idp.code}, "state": {authorize.Query().Get("state")}}.Encode()
+		req = httptest.NewRequest(http.MethodGet, callback, nil)
+		req.Header.Set("Cookie", cookieHeader(rec.Result().Cookies()))
+		rec = httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		Expect(rec.Code).To(Equal(http.StatusFound), rec.Body.String())
+		Expect(rec.Header().Get("Location")).To(Equal("/whoami"))
+
+		sessionCookies = rec.Result().Cookies()
+		Expect(cookieHeader(sessionCookies)).To(ContainSubstring(oidcSessionCookie))
+	})
+
+	ginkgo.It("syncs the person, roles and teams from the claims", func() {
+		Expect(DefaultContext.DB().Where("external_id = ?", idp.subject).First(&person).Error).To(Succeed())
+		Expect(person.Email).To(Equal("sso-user@example.com"))
+		Expect(person.Name).To(Equal("SSO User"))
+
+		roles, err := dutyRBAC.RolesForUser(person.ID.String())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(roles).To(ContainElement("admin"))
+		Expect(roles).ToNot(ContainElement("viewer"))
+
+		var members []dbModels.TeamMember
+		Expect(DefaultContext.DB().Where("person_id = ?", person.ID).Find(&members).Error).To(Succeed())
+		Expect(members).To(HaveLen(1))
+		Expect(members[0].TeamID).To(Equal(team.ID))
+		Expect(members[0].Source).To(Equal(oidcTeamSource))
+	})
+
+	ginkgo.It("authenticates requests with the session cookie", func() {
+		req := httptest.NewRequest(http.MethodGet, "/whoami", nil)
+		req.Header.Set("Cookie", cookieHeader(sessionCookies))
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		Expect(rec.Code).To(Equal(http.StatusOK))
+		Expect(rec.Body.String()).To(HavePrefix("Bearer "))
+	})
+
+	ginkgo.It("refreshes an expired session and re-syncs the claims", func() {
+		idp.groups = []string{"developers"}
+
+		rec := httptest.NewRecorder()
+		Expect(handler.writeSession(rec, oidcSession{
+			ID:           uuid.NewString(),
+			PersonID:     person.ID.String(),
+			ExpiresAt:    time.Now().Add(-time.Minute),
+			RefreshToken: idp.refreshToken,
+		})).To(Succeed())
+
+		req := httptest.NewRequest(http.MethodGet, "/whoami", nil)
+		req.Header.Set("Cookie", cookieHeader(rec.Result().Cookies()))
+		rec = httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		Expect(rec.Code).To(Equal(http.StatusOK))
+		Expect(idp.refreshes).To(Equal(1))
+
+		refreshed, err := handler.readSession(&http.Request{Header: http.Header{"Cookie": {cookieHeader(rec.Result().Cookies())}}})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(refreshed.RefreshToken).To(Equal(idp.refreshToken))
+		Expect(refreshed.ExpiresAt).To(BeTemporally(">", time.Now()))
+
+		roles, err := dutyRBAC.RolesForUser(person.ID.String())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(roles).To(ContainElement("viewer"))
+		Expect(roles).ToNot(ContainElement("admin"))
+
+		var count int64
+		Expect(DefaultContext.DB().Model(&dbModels.TeamMember{}).Where("person_id = ?", person.ID).Count(&count).Error).To(Succeed())
+		Expect(count).To(BeZero())
+	})
+
+	ginkgo.It("ends a session the identity provider refuses to refresh", func() {
+		rec := httptest.NewRecorder()
+		Expect(handler.writeSession(rec, oidcSession{
+			ID:           uuid.NewString(),
+			PersonID:     person.ID.String(),
+			ExpiresAt:    time.Now().Add(-time.Minute),
+			RefreshToken: "revoked",
+		})).To(Succeed())
+
+		req := httptest.NewRequest(http.MethodGet, "/whoami", nil)
+		req.Header.Set("Cookie", cookieHeader(rec.Result().Cookies()))
+		rec = httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		Expect(rec.Code).To(Equal(http.StatusUnauthorized))
+	})
+
+	ginkgo.It("authenticates ID tokens of the identity provider as bearer tokens", func() {
+		req := httptest.NewRequest(http.MethodGet, "/whoami", nil)
+		req.Header.Set(echo.HeaderAuthorization, "Bearer "+idp.idToken())
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		Expect(rec.Code).To(Equal(http.StatusOK))
+	})
+
+	ginkgo.It("redirects unauthenticated browsers to the login", func() {
+		req := httptest.NewRequest(http.MethodGet, "/whoami", nil)
+		req.Header.Set(echo.HeaderAccept, "text/html")
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		Expect(rec.Code).To(Equal(http.StatusFound))
+		Expect(rec.Header().Get("Location")).To(Equal(oidcLoginPath + "?next=%2Fwhoami"))
+	})
+
+	ginkgo.It("rejects people that have been deleted", func() {
+		Expect(DefaultContext.DB().Model(&models.Person{}).Where("id = ?", person.ID).Update("deleted_at", time.Now()).Error).To(Succeed())
+
+		rec := httptest.NewRecorder()
+		Expect(handler.writeSession(rec, oidcSession{
+			ID:        uuid.NewString(),
+			PersonID:  person.ID.String(),
+			ExpiresAt: time.Now().Add(time.Minute),
+		})).To(Succeed())
+
+		req := httptest.NewRequest(http.MethodGet, "/whoami", nil)
+		req.Header.Set("Cookie", cookieHeader(rec.Result().Cookies()))
+		rec = httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		Expect(rec.Code).To(Equal(http.StatusUnauthorized))
+
+		// A fresh ID token of the same subject doesn't bring them back.
+		idp.groups = []string{"sre"}
+		req = httptest.NewRequest(http.MethodGet, "/whoami", nil)
+		req.Header.Set(echo.HeaderAuthorization, "Bearer "+idp.idToken())
+		rec = httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		Expect(rec.Code).To(Equal(http.StatusUnauthorized))
+
+		var count int64
+		Expect(DefaultContext.DB().Model(&models.Person{}).Where("external_id = ?", idp.subject).Count(&count).Error).To(Succeed())
+		Expect(count).To(Equal(int64(1)))
+	})
+})
@@ -13,11 +13,13 @@ import (
 	"github.com/flanksource/duty/rbac/policy"
 	"github.com/flanksource/duty/rls"
 	"github.com/flanksource/duty/types"
+	"github.com/google/uuid"
 	"github.com/samber/lo"
 	"go.opentelemetry.io/otel/trace"
 	"gorm.io/gorm"
 
 	v1 "github.com/flanksource/incident-commander/api/v1"
+	icrbac "github.com/flanksource/incident-commander/rbac"
 	"github.com/flanksource/incident-commander/vars"
 )
 
@@ -51,26 +53,16 @@ func GetRLSPayload(ctx context.Context) (*rls.Payload, error) {
 		return cached.(*rls.Payload), nil
 	}
 
+	var payload *rls.Payload
 	if roles, err := dutyRBAC.RolesForUser(ctx.User().ID.String()); err != nil {
 		return nil, err
 	} else if !lo.Contains(roles, policy.RoleGuest) {
-		payload := &rls.Payload{Disable: true}
-		if impersonated != nil {
-			result, err := applyImpersonation(payload, impersonated)
-			if err != nil {
-				return nil, err
-			}
-			tokenCache.SetDefault(cacheKey, result)
-			return result, nil
+		payload = &rls.Payload{Disable: true}
+	} else {
+		// Build RLS payload from permissions and scopes
+		if payload, err = buildRLSPayloadFromScopes(ctx); err != nil {
+			return nil, ctx.Oops().Wrap(err)
 		}
-		tokenCache.SetDefault(cacheKey, payload)
-		return payload, nil
-	}
-
-	// Build RLS payload from permissions and scopes
-	payload, err := buildRLSPayloadFromScopes(ctx)
-	if err != nil {
-		return nil, ctx.Oops().Wrap(err)
 	}
 
 	if impersonated != nil {
@@ -78,14 +70,44 @@ func GetRLSPayload(ctx context.Context) (*rls.Payload, error) {
 		if err != nil {
 			return nil, err
 		}
-		tokenCache.SetDefault(cacheKey, result)
-		return result, nil
+		payload = result
+	}
+
+	payload, err := applyTokenScope(ctx, payload)
+	if err != nil {
+		return nil, err
 	}
 
 	tokenCache.SetDefault(cacheKey, payload)
 	return payload, nil
 }
 
+// applyTokenScope limits the payload of a scoped access token to the rows of
+// its Scope. Like impersonation, the result never exceeds the payload of the
+// token's creator.
+func applyTokenScope(ctx context.Context, payload *rls.Payload) (*rls.Payload, error) {
+	scope, err := icrbac.GetTokenScope(ctx, ctx.User().ID)
+	if err != nil {
+		return nil, err
+	} else if scope == nil || scope.ScopeID == nil {
+		return payload, nil
+	}
+
+	var model models.Scope
+	if err := ctx.DB().Where("id = ? AND deleted_at IS NULL", *scope.ScopeID).Limit(1).Find(&model).Error; err != nil {
+		return nil, ctx.Oops().Wrapf(err, "failed to get scope %s of access token", *scope.ScopeID)
+	}
+
+	// A deleted scope grants nothing rather than falling back to everything.
+	scoped := &rls.Payload{}
+	if model.ID != uuid.Nil {
+		scoped.Scopes = []string{model.ID.String()}
+		addScopeTargets(ctx, model, scoped, false)
+	}
+
+	return applyImpersonation(payload, scoped)
+}
+
 // WithRLS wraps a function with RLS enforcement in a transaction.
 // This ensures that Row Level Security is applied to all database queries
 // within the function for guest users.
@@ -120,11 +142,17 @@ func buildRLSPayloadFromScopes(ctx context.Context) (*rls.Payload, error) {
 		return nil, fmt.Errorf("failed to get roles for user: %w", err)
 	}
 
+	return BuildRLSPayload(ctx, ctx.User().ID.String(), roles)
+}
+
+// BuildRLSPayload builds the RLS payload of a user with the given roles from
+// the permissions and scopes in the database.
+func BuildRLSPayload(ctx context.Context, userID string, roles []string) (*rls.Payload, error) {
 	// Build list of subjects (user ID + roles)
-	subjects := append([]string{ctx.User().ID.String()}, roles...)
+	subjects := append([]string{userID}, roles...)
 
 	var permissions []models.Permission
-	err = ctx.DB().
+	err := ctx.DB().
 		Where("subject IN ?", subjects).
 		Where("action = ?", policy.ActionRead).
 		Where("deleted_at IS NULL").
@@ -252,42 +280,47 @@ func processScopeRefs(ctx context.Context, scopeRefs []dutyRBAC.NamespacedNameID
 			payload.Scopes = append(payload.Scopes, scope.ID.String())
 		}
 
-		var targets []v1.ScopeTarget
-		if err := json.Unmarshal([]byte(scope.Targets), &targets); err != nil {
-			ctx.Warnf("failed to unmarshal targets for scope %s: %v", scope.ID, err)
-			continue
-		}
-
-		for _, target := range targets {
-			if target.Config != nil {
-				addConfigScope(payload, convertToRLSScope(target.Config), deny)
-			}
-			if target.Component != nil {
-				addComponentScope(payload, convertToRLSScope(target.Component), deny)
-			}
-			if target.Playbook != nil {
-				addPlaybookScope(payload, convertToRLSScope(target.Playbook), deny)
-			}
-			if target.Canary != nil {
-				addCanaryScope(payload, convertToRLSScope(target.Canary), deny)
-			}
-			if target.View != nil {
-				addViewScope(payload, convertToRLSScope(target.View), deny)
-			}
-			if target.Global != nil {
-				rlsScope := convertToRLSScope(target.Global)
-				addConfigScope(payload, rlsScope, deny)
-				addComponentScope(payload, rlsScope, deny)
-				addPlaybookScope(payload, rlsScope, deny)
-				addCanaryScope(payload, rlsScope, deny)
-				addViewScope(payload, rlsScope, deny)
-			}
-		}
+		addScopeTargets(ctx, scope, payload, deny)
 	}
 
 	return nil
 }
 
+// addScopeTargets adds the targets of the scope to the payload.
+func addScopeTargets(ctx context.Context, scope models.Scope, payload *rls.Payload, deny bool) {
+	var targets []v1.ScopeTarget
+	if err := json.Unmarshal([]byte(scope.Targets), &targets); err != nil {
+		ctx.Warnf("failed to unmarshal targets for scope %s: %v", scope.ID, err)
+		return
+	}
+
+	for _, target := range targets {
+		if target.Config != nil {
+			addConfigScope(payload, convertToRLSScope(target.Config), deny)
+		}
+		if target.Component != nil {
+			addComponentScope(payload, convertToRLSScope(target.Component), deny)
+		}
+		if target.Playbook != nil {
+			addPlaybookScope(payload, convertToRLSScope(target.Playbook), deny)
+		}
+		if target.Canary != nil {
+			addCanaryScope(payload, convertToRLSScope(target.Canary), deny)
+		}
+		if target.View != nil {
+			addViewScope(payload, convertToRLSScope(target.View), deny)
+		}
+		if target.Global != nil {
+			rlsScope := convertToRLSScope(target.Global)
+			addConfigScope(payload, rlsScope, deny)
+			addComponentScope(payload, rlsScope, deny)
+			addPlaybookScope(payload, rlsScope, deny)
+			addCanaryScope(payload, rlsScope, deny)
+			addViewScope(payload, rlsScope, deny)
+		}
+	}
+}
+
 func convertToRLSScope(selector *v1.ScopeResourceSelector) rls.Scope {
 	rlsScope := rls.Scope{}
 
@@ -5,9 +5,11 @@ import (
 
 	"github.com/flanksource/duty/context"
 	"github.com/flanksource/duty/tests/setup"
-	"github.com/flanksource/incident-commander/auth/signing"
 	ginkgo "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+
+	"github.com/flanksource/incident-commander/auth/signing"
+	"github.com/flanksource/incident-commander/db/schema"
 )
 
 func TestAuth(t *testing.T) {
@@ -21,6 +23,7 @@ var (
 
 var _ = ginkgo.BeforeSuite(func() {
 	DefaultContext = setup.BeforeSuiteFn()
+	Expect(schema.Apply(DefaultContext)).To(Succeed())
 	if _, _, err := signing.Initialize("/tmp/dummy"); err != nil {
 		ginkgo.Fail(err.Error())
 	}
@@ -1,6 +1,7 @@
 package auth
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
@@ -9,20 +10,27 @@ import (
 
 	"github.com/MicahParks/keyfunc"
 	"github.com/flanksource/commons/collections"
+	"github.com/flanksource/commons/duration"
 	"github.com/flanksource/commons/logger"
 	"github.com/flanksource/duty"
 	"github.com/flanksource/duty/api"
 	"github.com/flanksource/duty/context"
 	"github.com/flanksource/duty/models"
 	"github.com/flanksource/duty/rbac"
+	"github.com/flanksource/duty/rbac/policy"
+	"github.com/flanksource/duty/secret"
 	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 	"github.com/patrickmn/go-cache"
+	"github.com/samber/lo"
+	"go.opentelemetry.io/otel/trace"
 	"gorm.io/gorm"
 
 	"github.com/flanksource/incident-commander/auth/accesstoken"
 	"github.com/flanksource/incident-commander/auth/signing"
 	"github.com/flanksource/incident-commander/db"
+	icrbac "github.com/flanksource/incident-commander/rbac"
 )
 
 func FlushTokenCache() {
@@ -198,6 +206,23 @@ func extractBearerAuthToken(header http.Header) (string, bool) {
 	return strings.TrimPrefix(auth, "Bearer "), true
 }
 
+// AccessTokenIDOfRequest returns the id of the access token an authenticated
+// request was made with, or nil if it wasn't made with an access token.
+func AccessTokenIDOfRequest(req *http.Request) *uuid.UUID {
+	token, ok := extractBearerAuthToken(req.Header)
+	if !ok {
+		_, token, ok = req.BasicAuth()
+	}
+	if !ok || token == "" {
+		return nil
+	}
+
+	if cachedToken, ok := tokenCache.Get(token); ok {
+		return &cachedToken.(*models.AccessToken).ID
+	}
+	return nil
+}
+
 // DeleteAccessToken deletes an access token and soft-deletes the associated person.
 // Uses deletedTokensCache blacklist for immediate invalidation since direct cache
 // eviction isn't possible (cache key vs token ID mismatch).
@@ -220,15 +245,198 @@ func DeleteAccessToken(ctx context.Context, tokenID string) error {
 		return ctx.Oops().Wrapf(err, "failed to delete access token %s", tokenID)
 	}
 
-	if personType == db.PersonTypeAccessToken {
-		if _, err := rbac.Enforcer().DeleteRolesForUser(tokenPersonID); err != nil {
-			ctx.Errorf("failed to delete roles for token person %s: %v", tokenPersonID, err)
+	icrbac.FlushTokenScope(tokenPersonID)
+
+	if personType == db.PersonTypeAccessToken && rbac.Enforcer() != nil {
+		revokeTokenPermissions(ctx, tokenPersonID)
+	}
+
+	return nil
+}
+
+// AuthenticateAccessToken returns the access token and its person.
+func AuthenticateAccessToken(ctx context.Context, token string) (*models.AccessToken, *models.Person, error) {
+	accessToken, err := getAccessToken(ctx, token)
+	if err != nil {
+		return nil, nil, err
+	} else if accessToken == nil {
+		return nil, nil, api.Errorf(api.EUNAUTHORIZED, "access token not found")
+	}
+
+	person, err := db.GetUserByID(ctx, accessToken.PersonID.String())
+	if err != nil {
+		return nil, nil, ctx.Oops().Wrapf(err, "failed to get person %s of access token", accessToken.PersonID)
+	}
+	return accessToken, &person, nil
+}
+
+// RevokeAccessOfPerson deletes the access tokens of the person and the tokens
+// they created, and invalidates their cached RLS payload.
+func RevokeAccessOfPerson(ctx context.Context, personID string) error {
+	var tokenIDs []string
+	if err := ctx.DB().Model(&models.AccessToken{}).
+		Where("person_id = ? OR created_by = ?", personID, personID).
+		Pluck("id", &tokenIDs).Error; err != nil {
+		return ctx.Oops().Wrapf(err, "failed to get access tokens of %s", personID)
+	}
+
+	for _, tokenID := range tokenIDs {
+		if err := DeleteAccessToken(ctx, tokenID); err != nil {
+			return err
 		}
+	}
+
+	InvalidateRLSCacheForUser(personID)
+	return nil
+}
 
-		if _, err := rbac.Enforcer().DeletePermissionsForUser(tokenPersonID); err != nil {
-			ctx.Errorf("failed to delete permissions for token person %s: %v", tokenPersonID, err)
+// ScopedToken is a new access token and the permissions it effectively holds.
+type ScopedToken struct {
+	Token       secret.Sensitive
+	Person      *models.Person
+	Permissions []policy.Permission
+}
+
+// CreateScopedToken creates an access token acting for the current user,
+// restricted to the permissions, Scope and networks of the request.
+func CreateScopedToken(ctx context.Context, req CreateTokenRequest) (*ScopedToken, error) {
+	user := ctx.User()
+	if user == nil {
+		return nil, api.Errorf(api.EUNAUTHORIZED, "error fetching user")
+	}
+
+	var expiry duration.Duration = 0 // Default
+	if req.Expiry != "" {
+		var err error
+		if expiry, err = duration.ParseDuration(req.Expiry); err != nil {
+			return nil, api.Errorf(api.EINVALID, "error parsing expiry[%s]: %v", req.Expiry, err)
 		}
 	}
 
+	scimScope, err := scimScopeOf(ctx, req.Scope)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := icrbac.ParseCIDRs(req.CIDRs); err != nil {
+		return nil, api.Errorf(api.EINVALID, "%v", err)
+	}
+
+	var scopeID *uuid.UUID
+	if req.ScopeRef != nil {
+		if scopeID, err = resolveScopeRef(ctx, *req.ScopeRef); err != nil {
+			return nil, err
+		}
+	}
+
+	// Remove subject from scope if exists
+	for i := range req.Scope {
+		req.Scope[i].Subject = ""
+	}
+
+	var tokenResult db.CreateAccessTokenForPersonResult
+	err = ctx.Transaction(func(ctx context.Context, _ trace.Span) error {
+		var err error
+		if tokenResult, err = db.CreateAccessTokenForPerson(ctx, user, req.Name, time.Duration(expiry), req.AutoRenew); err != nil {
+			return ctx.Oops().Wrapf(err, "error creating access token")
+		}
+
+		// The allowed permissions, Scope and networks are also enforced outside
+		// of casbin: by the rbac middleware and in the RLS payload of the token.
+		if len(req.Scope) > 0 || scopeID != nil || len(req.CIDRs) > 0 {
+			tokenScope := db.AccessTokenScope{
+				PersonID:      tokenResult.Person.ID,
+				AccessTokenID: tokenResult.AccessToken.ID,
+				ScopeID:       scopeID,
+				CIDRs:         req.CIDRs,
+			}
+			if len(req.Scope) > 0 {
+				allowed := lo.Map(req.Scope, func(p policy.Permission, _ int) policy.Permission {
+					return policy.Permission{Object: p.Object, Action: p.Action}
+				})
+				if tokenScope.Permissions, err = json.Marshal(allowed); err != nil {
+					return ctx.Oops().Wrap(err)
+				}
+			}
+			if err := db.SaveAccessTokenScope(ctx, &tokenScope); err != nil {
+				return ctx.Oops().Wrapf(err, "unable to create token")
+			}
+		}
+
+		// The casbin rules aren't part of the transaction, so they are added
+		// last and removed below when the token isn't created.
+		return grantTokenPermissions(ctx, tokenResult.Person.ID.String(), user.ID.String(), req.Scope, scimScope)
+	})
+	if err != nil {
+		if tokenResult.Person != nil {
+			revokeTokenPermissions(ctx, tokenResult.Person.ID.String())
+		}
+		return nil, err
+	}
+	icrbac.FlushTokenScope(tokenResult.Person.ID.String())
+
+	permissions, err := icrbac.EffectivePermissions(ctx, tokenResult.Person.ID)
+	if err != nil {
+		return nil, ctx.Oops().Wrapf(err, "unable to get permissions of token")
+	}
+
+	return &ScopedToken{Token: tokenResult.Token, Person: tokenResult.Person, Permissions: permissions}, nil
+}
+
+// scimScopeOf returns the scim permissions of a token scope. SCIM is only
+// available to tokens scoped to it, which must not grant more than the
+// creator has.
+func scimScopeOf(ctx context.Context, scope []policy.Permission) ([]policy.Permission, error) {
+	scimScope := lo.Filter(scope, func(p policy.Permission, _ int) bool { return p.Object == icrbac.ObjectSCIM })
+	for _, p := range scimScope {
+		if !rbac.CheckContext(ctx, icrbac.ObjectSCIM, p.Action) {
+			return nil, api.Errorf(api.EFORBIDDEN, "not allowed to create a token with %s:%s", p.Object, p.Action)
+		}
+	}
+	return scimScope, nil
+}
+
+// grantTokenPermissions makes the token person act for its creator, denied
+// everything outside the scope. The scim actions of the scope are allowed
+// explicitly as they aren't inherited from the creator.
+func grantTokenPermissions(ctx context.Context, tokenPersonID, creatorID string, scope, scimScope []policy.Permission) error {
+	if _, err := rbac.Enforcer().AddGroupingPolicy(tokenPersonID, creatorID); err != nil {
+		return ctx.Oops().Wrapf(err, "error grouping token with user")
+	}
+
+	if len(scope) == 0 {
+		return nil
+	}
+
+	var permsToDeny [][]string
+	diff, _ := lo.Difference(icrbac.AllPermissions, scope)
+	for _, p := range diff {
+		p.Deny = true
+		permsToDeny = append(permsToDeny, p.ToArgsWithoutSubject())
+	}
+	if len(permsToDeny) > 0 {
+		if _, err := rbac.Enforcer().AddPermissionsForUser(tokenPersonID, permsToDeny...); err != nil {
+			return ctx.Oops().Wrapf(err, "unable to create token")
+		}
+	}
+
+	if len(scimScope) > 0 {
+		grants := lo.Map(scimScope, func(p policy.Permission, _ int) []string {
+			return []string{icrbac.ObjectSCIM, p.Action, "allow", "", "na"}
+		})
+		if _, err := rbac.Enforcer().AddPermissionsForUser(tokenPersonID, grants...); err != nil {
+			return ctx.Oops().Wrapf(err, "unable to create token")
+		}
+	}
 	return nil
 }
+
+// revokeTokenPermissions removes the casbin rules of a token person.
+func revokeTokenPermissions(ctx context.Context, tokenPersonID string) {
+	if _, err := rbac.Enforcer().DeleteRolesForUser(tokenPersonID); err != nil {
+		ctx.Errorf("failed to delete roles for token person %s: %v", tokenPersonID, err)
+	}
+	if _, err := rbac.Enforcer().DeletePermissionsForUser(tokenPersonID); err != nil {
+		ctx.Errorf("failed to delete permissions for token person %s: %v", tokenPersonID, err)
+	}
+}
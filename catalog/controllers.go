@@ -31,6 +31,7 @@ func RegisterRoutes(e *echo.Echo) {
 	apiGroup.GET("/changes", SearchCatalogChanges, echoSrv.RLSMiddleware)
 	apiGroup.POST("/report/preview", PreviewCatalogReport, echoSrv.RLSMiddleware)
 	apiGroup.POST("/report", GenerateCatalogReport, echoSrv.RLSMiddleware)
+	apiGroup.POST("/findings", ExportCatalogFindings, echoSrv.RLSMiddleware)
 	apiGroup.GET("/:id/relationships", GetConfigRelationships, echoSrv.RLSMiddleware)
 
 	deleteGroup := e.Group("/catalog", rbac.Catalog(policy.ActionDelete))
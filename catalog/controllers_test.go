@@ -109,6 +109,12 @@ var _ = ginkgo.Describe("catalog report endpoints", func() {
 		Expect(contentType).To(Equal("text/html; charset=utf-8"))
 		Expect(extension).To(Equal("html"))
 
+		format, contentType, extension, err = normalizeCatalogReportFormat("sarif")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(format).To(Equal("sarif"))
+		Expect(contentType).To(Equal("application/sarif+json"))
+		Expect(extension).To(Equal("sarif"))
+
 		_, _, _, err = normalizeCatalogReportFormat("docx")
 		Expect(err).To(HaveOccurred())
 	})
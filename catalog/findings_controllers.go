@@ -0,0 +1,59 @@
+package catalog
+
+import (
+	"mime"
+	"net/http"
+	"time"
+
+	"github.com/flanksource/duty/api"
+	"github.com/flanksource/duty/context"
+	"github.com/flanksource/duty/types"
+	"github.com/labstack/echo/v4"
+
+	"github.com/flanksource/incident-commander/report/findings"
+)
+
+type CatalogFindingsRequest struct {
+	// Format is either sarif (default) or ocsf.
+	Format string `json:"format"`
+	// Selectors select the configs whose insights are exported.
+	Selectors       []types.ResourceSelector `json:"selectors"`
+	IncludeResolved bool                     `json:"includeResolved"`
+}
+
+// ExportCatalogFindings exports the insights of the selected configs as a
+// SARIF log or as OCSF findings.
+func ExportCatalogFindings(c echo.Context) error {
+	var req CatalogFindingsRequest
+	if err := c.Bind(&req); err != nil {
+		return api.WriteError(c, api.Errorf(api.EINVALID, "invalid request: %v", err))
+	}
+	if len(req.Selectors) == 0 {
+		return api.WriteError(c, api.Errorf(api.EINVALID, "at least one selector is required"))
+	}
+
+	ctx := c.Request().Context().(context.Context)
+	analyses, err := findings.Query(ctx, req.IncludeResolved, req.Selectors...)
+	if err != nil {
+		return api.WriteError(c, err)
+	}
+
+	var data []byte
+	var contentType, filename string
+	switch req.Format {
+	case "", "sarif":
+		data, err = findings.RenderSARIF(analyses)
+		contentType, filename = findings.SARIFContentType, "findings.sarif"
+	case "ocsf":
+		data, err = findings.RenderOCSF(analyses, time.Now())
+		contentType, filename = "application/json", "findings.ocsf.json"
+	default:
+		return api.WriteError(c, api.Errorf(api.EINVALID, "invalid format %q", req.Format))
+	}
+	if err != nil {
+		return api.WriteError(c, ctx.Oops().Wrapf(err, "failed to render findings"))
+	}
+
+	c.Response().Header().Set(echo.HeaderContentDisposition, mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+	return c.Blob(http.StatusOK, contentType, data)
+}
@@ -17,6 +17,8 @@ import (
 	"github.com/flanksource/duty/query"
 	reportAPI "github.com/flanksource/incident-commander/api"
 	reportCatalog "github.com/flanksource/incident-commander/report/catalog"
+	"github.com/flanksource/incident-commander/report/findings"
+	"github.com/flanksource/incident-commander/report/xlsx"
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 	"gorm.io/gorm"
@@ -422,6 +424,12 @@ func normalizeCatalogReportFormat(format string) (string, string, string, error)
 		return "facet-html", "text/html; charset=utf-8", "html", nil
 	case "json":
 		return "json", "application/json", "json", nil
+	case "xlsx":
+		return "xlsx", xlsx.ContentType, "xlsx", nil
+	case "sarif":
+		return "sarif", findings.SARIFContentType, "sarif", nil
+	case "ocsf":
+		return "ocsf", "application/json", "ocsf.json", nil
 	default:
 		return "", "", "", api.Errorf(api.EINVALID, "invalid format %q", format)
 	}
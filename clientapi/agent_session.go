@@ -0,0 +1,106 @@
+package clientapi
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Upgrade protocols of agent sessions. Both are requested with an HTTP
+// upgrade and carried over the upstream's tunnel to the agent.
+const (
+	// AgentExecProtocol carries a command session as frames.
+	AgentExecProtocol = "mission-control-exec"
+
+	// AgentForwardProtocol carries raw TCP bytes to the forwarded address.
+	AgentForwardProtocol = "tcp"
+)
+
+// FrameType identifies the payload of an exec session frame.
+type FrameType byte
+
+const (
+	// FrameStdin carries input for the command. Sent by the client.
+	FrameStdin FrameType = iota + 1
+
+	// FrameStdinEOF closes the command's stdin. Sent by the client.
+	FrameStdinEOF
+
+	// FrameStdout carries output of the command. Sent by the agent.
+	FrameStdout
+
+	// FrameStderr carries error output of the command. Sent by the agent.
+	FrameStderr
+
+	// FrameExit carries the exit code of the command as a big endian int32
+	// and is always the last frame sent by the agent.
+	FrameExit
+)
+
+// MaxFramePayload is the largest payload a frame may carry.
+const MaxFramePayload = 1 << 20
+
+// AgentSessionFrame is a single message of an exec session.
+type AgentSessionFrame struct {
+	Type    FrameType
+	Payload []byte
+}
+
+// ExitCode decodes the exit code of a FrameExit frame.
+func (f AgentSessionFrame) ExitCode() int {
+	if f.Type != FrameExit || len(f.Payload) != 4 {
+		return -1
+	}
+	return int(int32(binary.BigEndian.Uint32(f.Payload)))
+}
+
+// WriteAgentSessionFrame writes a frame as a 1 byte type, a 4 byte big
+// endian payload length and the payload.
+func WriteAgentSessionFrame(w io.Writer, typ FrameType, payload []byte) error {
+	if len(payload) > MaxFramePayload {
+		return fmt.Errorf("frame payload of %d bytes exceeds %d bytes", len(payload), MaxFramePayload)
+	}
+
+	buf := make([]byte, 5+len(payload))
+	buf[0] = byte(typ)
+	binary.BigEndian.PutUint32(buf[1:5], uint32(len(payload)))
+	copy(buf[5:], payload)
+	_, err := w.Write(buf)
+	return err
+}
+
+// WriteAgentSessionExit writes the FrameExit frame.
+func WriteAgentSessionExit(w io.Writer, code int) error {
+	payload := make([]byte, 4)
+	binary.BigEndian.PutUint32(payload, uint32(int32(code)))
+	return WriteAgentSessionFrame(w, FrameExit, payload)
+}
+
+// ReadAgentSessionFrame reads the next frame. It returns io.EOF when the
+// stream ends cleanly between frames.
+func ReadAgentSessionFrame(r io.Reader) (AgentSessionFrame, error) {
+	var header [5]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			return AgentSessionFrame{}, fmt.Errorf("truncated frame header: %w", err)
+		}
+		return AgentSessionFrame{}, err
+	}
+
+	frame := AgentSessionFrame{Type: FrameType(header[0])}
+	if frame.Type < FrameStdin || frame.Type > FrameExit {
+		return frame, fmt.Errorf("unknown frame type %d", header[0])
+	}
+
+	size := binary.BigEndian.Uint32(header[1:5])
+	if size > MaxFramePayload {
+		return frame, fmt.Errorf("frame payload of %d bytes exceeds %d bytes", size, MaxFramePayload)
+	}
+
+	frame.Payload = make([]byte, size)
+	if _, err := io.ReadFull(r, frame.Payload); err != nil {
+		return frame, fmt.Errorf("truncated frame payload: %w", err)
+	}
+	return frame, nil
+}
@@ -0,0 +1,50 @@
+package clientapi
+
+import (
+	"bytes"
+	"io"
+
+	ginkgo "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = ginkgo.Describe("agent session frames", func() {
+	ginkgo.It("round trips frames", func() {
+		var buf bytes.Buffer
+		Expect(WriteAgentSessionFrame(&buf, FrameStdout, []byte("hello\n"))).To(Succeed())
+		Expect(WriteAgentSessionFrame(&buf, FrameStdinEOF, nil)).To(Succeed())
+		Expect(WriteAgentSessionExit(&buf, -2)).To(Succeed())
+
+		frame, err := ReadAgentSessionFrame(&buf)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(frame.Type).To(Equal(FrameStdout))
+		Expect(string(frame.Payload)).To(Equal("hello\n"))
+
+		frame, err = ReadAgentSessionFrame(&buf)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(frame.Type).To(Equal(FrameStdinEOF))
+		Expect(frame.Payload).To(BeEmpty())
+
+		frame, err = ReadAgentSessionFrame(&buf)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(frame.ExitCode()).To(Equal(-2))
+
+		_, err = ReadAgentSessionFrame(&buf)
+		Expect(err).To(MatchError(io.EOF))
+	})
+
+	ginkgo.It("rejects unknown and oversized frames", func() {
+		_, err := ReadAgentSessionFrame(bytes.NewReader([]byte{9, 0, 0, 0, 0}))
+		Expect(err).To(MatchError(ContainSubstring("unknown frame type")))
+
+		_, err = ReadAgentSessionFrame(bytes.NewReader([]byte{byte(FrameStdout), 0xff, 0xff, 0xff, 0xff}))
+		Expect(err).To(MatchError(ContainSubstring("exceeds")))
+
+		Expect(WriteAgentSessionFrame(io.Discard, FrameStdin, make([]byte, MaxFramePayload+1))).ToNot(Succeed())
+	})
+
+	ginkgo.It("reports truncated frames", func() {
+		_, err := ReadAgentSessionFrame(bytes.NewReader([]byte{byte(FrameStdout), 0, 0, 0, 4, 'a'}))
+		Expect(err).To(MatchError(ContainSubstring("truncated frame payload")))
+	})
+})
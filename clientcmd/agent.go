@@ -0,0 +1,175 @@
+package clientcmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/flanksource/commons/logger"
+	"github.com/spf13/cobra"
+
+	"github.com/flanksource/incident-commander/clientapi"
+)
+
+// AgentCmd opens remote sessions on agents through the upstream's tunnel.
+var AgentCmd = &cobra.Command{
+	Use:   "agent",
+	Short: "Open remote sessions on agents",
+}
+
+var agentExecCmd = &cobra.Command{
+	Use:   "exec <agent> -- <command> [args...]",
+	Short: "Run a command on an agent",
+	Long: "Run a command on an agent, streaming stdin, stdout and stderr. " +
+		"Requires the agent:session permission and agent.session.exec to be enabled on the agent. " +
+		"Sessions are recorded for audit.",
+	Args:              cobra.MinimumNArgs(2),
+	SilenceUsage:      true,
+	DisableAutoGenTag: true,
+	RunE:              runAgentExec,
+}
+
+var agentForwardCmd = &cobra.Command{
+	Use:   "forward <agent> <local-port>:<host>:<port>",
+	Short: "Forward a local port to an address reachable from an agent",
+	Long: "Listen on a local port and forward every connection through the agent to host:port. " +
+		"Requires the agent:session permission. Sessions are recorded for audit.",
+	Args:              cobra.ExactArgs(2),
+	SilenceUsage:      true,
+	DisableAutoGenTag: true,
+	RunE:              runAgentForward,
+}
+
+var agentForwardBindAddress string
+
+func init() {
+	agentForwardCmd.Flags().StringVar(&agentForwardBindAddress, "address", "127.0.0.1", "Local address to listen on")
+	AgentCmd.AddCommand(agentExecCmd, agentForwardCmd)
+}
+
+func runAgentExec(cmd *cobra.Command, args []string) error {
+	client, err := RemoteClient()
+	if err != nil {
+		return err
+	}
+
+	stream, err := client.AgentExec(cmd.Context(), args[0], args[1:])
+	if err != nil {
+		return err
+	}
+	defer stream.Close()
+
+	var writeMu sync.Mutex
+	go func() {
+		buf := make([]byte, 32*1024)
+		for {
+			n, err := cmd.InOrStdin().Read(buf)
+			if n > 0 {
+				writeMu.Lock()
+				werr := clientapi.WriteAgentSessionFrame(stream, clientapi.FrameStdin, buf[:n])
+				writeMu.Unlock()
+				if werr != nil {
+					return
+				}
+			}
+			if err != nil {
+				writeMu.Lock()
+				_ = clientapi.WriteAgentSessionFrame(stream, clientapi.FrameStdinEOF, nil)
+				writeMu.Unlock()
+				return
+			}
+		}
+	}()
+
+	reader := bufio.NewReader(stream)
+	for {
+		frame, err := clientapi.ReadAgentSessionFrame(reader)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return fmt.Errorf("session closed before the command exited")
+			}
+			return err
+		}
+
+		switch frame.Type {
+		case clientapi.FrameStdout:
+			_, _ = cmd.OutOrStdout().Write(frame.Payload)
+		case clientapi.FrameStderr:
+			_, _ = cmd.ErrOrStderr().Write(frame.Payload)
+		case clientapi.FrameExit:
+			if code := frame.ExitCode(); code != 0 {
+				_ = stream.Close()
+				os.Exit(code)
+			}
+			return nil
+		}
+	}
+}
+
+func runAgentForward(cmd *cobra.Command, args []string) error {
+	localPort, address, err := parseForwardSpec(args[1])
+	if err != nil {
+		return err
+	}
+
+	client, err := RemoteClient()
+	if err != nil {
+		return err
+	}
+
+	listener, err := net.Listen("tcp", net.JoinHostPort(agentForwardBindAddress, localPort))
+	if err != nil {
+		return err
+	}
+	defer listener.Close()
+
+	go func() {
+		<-cmd.Context().Done()
+		_ = listener.Close()
+	}()
+
+	fmt.Fprintf(cmd.ErrOrStderr(), "Forwarding %s -> %s via agent %s\n", listener.Addr(), address, args[0])
+	for {
+		local, err := listener.Accept()
+		if err != nil {
+			if cmd.Context().Err() != nil {
+				return nil
+			}
+			return err
+		}
+
+		go func() {
+			defer local.Close()
+
+			remote, err := client.AgentForward(cmd.Context(), args[0], address)
+			if err != nil {
+				logger.Errorf("failed to forward %s: %v", local.RemoteAddr(), err)
+				return
+			}
+			defer remote.Close()
+
+			done := make(chan struct{}, 2)
+			go func() { _, _ = io.Copy(remote, local); done <- struct{}{} }()
+			go func() { _, _ = io.Copy(local, remote); done <- struct{}{} }()
+			<-done
+		}()
+	}
+}
+
+// parseForwardSpec splits <local-port>:<host>:<port> into the local port and
+// the remote address.
+func parseForwardSpec(spec string) (string, string, error) {
+	localPort, address, ok := strings.Cut(spec, ":")
+	if !ok || localPort == "" {
+		return "", "", fmt.Errorf("expected <local-port>:<host>:<port>, got %q", spec)
+	}
+	if _, _, err := net.SplitHostPort(address); err != nil {
+		return "", "", fmt.Errorf("invalid remote address %q: %w", address, err)
+	}
+	return localPort, address, nil
+}
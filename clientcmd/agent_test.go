@@ -0,0 +1,23 @@
+package clientcmd
+
+import (
+	ginkgo "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = ginkgo.Describe("parseForwardSpec", func() {
+	ginkgo.It("splits the local port from the remote address", func() {
+		port, address, err := parseForwardSpec("15432:postgres.db.svc:5432")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(port).To(Equal("15432"))
+		Expect(address).To(Equal("postgres.db.svc:5432"))
+	})
+
+	ginkgo.It("rejects a spec without a remote port", func() {
+		_, _, err := parseForwardSpec("15432:postgres")
+		Expect(err).To(HaveOccurred())
+
+		_, _, err = parseForwardSpec("postgres:5432")
+		Expect(err).To(HaveOccurred())
+	})
+})
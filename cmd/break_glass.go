@@ -0,0 +1,139 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/flanksource/duty"
+	"github.com/flanksource/duty/rbac"
+	"github.com/spf13/cobra"
+
+	"github.com/flanksource/incident-commander/audit"
+	"github.com/flanksource/incident-commander/rbac/adapter"
+	"github.com/flanksource/incident-commander/rbac/breakglass"
+)
+
+var BreakGlass = &cobra.Command{
+	Use:   "break-glass",
+	Short: "Activate emergency admin access (reads the credential, or one share per line, from stdin)",
+	Long: `Activate emergency admin access without the identity provider.
+
+The sealed credential, or enough of its shares one per line, is read from stdin.
+An admin access token valid for --duration is printed to stdout, the configured
+break_glass.notify recipients are notified and every request made with the
+token is marked in the audit log.`,
+	PreRun: PreRun,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		parts, err := readLinesFromStdin()
+		if err != nil {
+			return err
+		}
+
+		ctx, stop, err := duty.Start("mission-control", duty.DisablePostgrest)
+		if err != nil {
+			return err
+		}
+		defer stop()
+
+		if err := audit.LoadKey(); err != nil {
+			return err
+		}
+
+		if err := rbac.Init(ctx, []string{}, adapter.NewPermissionAdapter); err != nil {
+			return err
+		}
+
+		activation, err := breakglass.Activate(ctx, parts, breakglass.ActivateRequest{
+			Operator: breakGlassOperator,
+			Reason:   breakGlassReason,
+			Duration: breakGlassDuration,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to activate break-glass access: %w", err)
+		}
+
+		fmt.Println(activation.Token.PlainText())
+		fmt.Fprintf(os.Stderr, "break-glass activation %s expires at %s\n", activation.ID, activation.ExpiresAt.Format(time.RFC3339))
+		return nil
+	},
+}
+
+var BreakGlassSeal = &cobra.Command{
+	Use:    "seal",
+	Short:  "Generate a new break-glass credential, revoking the previous one",
+	PreRun: PreRun,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx, stop, err := duty.Start("mission-control", duty.DisablePostgrest)
+		if err != nil {
+			return err
+		}
+		defer stop()
+
+		if err := audit.LoadKey(); err != nil {
+			return err
+		}
+
+		parts, err := breakglass.Seal(ctx, breakglass.SealRequest{
+			Shares:    breakGlassShares,
+			Threshold: breakGlassThreshold,
+			SealedBy:  breakGlassOperator,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to seal break-glass credential: %w", err)
+		}
+
+		for _, part := range parts {
+			fmt.Println(part)
+		}
+		if len(parts) > 1 {
+			threshold := breakGlassThreshold
+			if threshold <= 0 {
+				threshold = len(parts)
+			}
+			fmt.Fprintf(os.Stderr, "any %d of the %d shares activate break-glass access\n", threshold, len(parts))
+		}
+		return nil
+	},
+}
+
+var (
+	breakGlassOperator  string
+	breakGlassReason    string
+	breakGlassDuration  string
+	breakGlassShares    int
+	breakGlassThreshold int
+)
+
+func readLinesFromStdin() ([]string, error) {
+	var lines []string
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read from stdin: %w", err)
+	}
+	if len(lines) == 0 {
+		return nil, errors.New("credential cannot be empty")
+	}
+	return lines, nil
+}
+
+func init() {
+	Token.AddCommand(BreakGlass)
+	BreakGlass.AddCommand(BreakGlassSeal)
+
+	BreakGlass.PersistentFlags().StringVar(&audit.KeyFile, "audit-key", "", "Path to the secret the audit log hash chain is keyed with, as given to the server")
+	BreakGlass.PersistentFlags().StringVar(&breakGlassOperator, "operator", os.Getenv("USER"), "Who is activating or sealing break-glass access")
+	BreakGlass.Flags().StringVar(&breakGlassReason, "reason", "", "Why emergency access is needed")
+	BreakGlass.Flags().StringVar(&breakGlassDuration, "duration", "1h", "How long the access lasts, at most break_glass.max_duration")
+
+	BreakGlassSeal.Flags().IntVar(&breakGlassShares, "shares", 1, "Split the credential into this many shares")
+	BreakGlassSeal.Flags().IntVar(&breakGlassThreshold, "threshold", 0, "Number of shares needed to activate (default: all of them)")
+}
@@ -45,6 +45,9 @@ var (
 	catalogReportStaleDays         int
 	catalogReportReviewOverdueDays int
 	catalogReportFilters           []string
+
+	catalogReportPersist     bool
+	catalogReportDiffAgainst string
 )
 
 var CatalogReportCmd = &cobra.Command{
@@ -71,7 +74,13 @@ Examples:
   catalog report 018f4e6a-... --format facet-html -o report.html
 
   # JSON with config body included
-  catalog report 018f4e6a-... --format json --config-json`,
+  catalog report 018f4e6a-... --format json --config-json
+
+  # Insights as SARIF for GitHub code scanning
+  catalog report 'type=Kubernetes::Deployment' --format sarif -o insights.sarif
+
+  # What changed since the report of 90 days ago
+  catalog report 'type=AWS::IAM::Role' --diff-against 90d`,
 	Args:             cobra.MinimumNArgs(1),
 	PersistentPreRun: PreRun,
 	RunE: func(cmd *cobra.Command, args []string) error {
@@ -152,6 +161,8 @@ func buildCatalogReportOptions(cmd *cobra.Command) (catalog.Options, error) {
 		MaxItems:         catalogReportMaxItems,
 		MaxChanges:       catalogReportMaxChanges,
 		MaxItemArtifacts: catalogReportMaxItemArtifacts,
+		Persist:          catalogReportPersist,
+		DiffAgainst:      catalogReportDiffAgainst,
 		Sections: api.CatalogReportSections{
 			Changes:        catalogReportChanges,
 			Insights:       catalogReportInsights,
@@ -192,7 +203,7 @@ func buildCatalogReportOptions(cmd *cobra.Command) (catalog.Options, error) {
 }
 
 func init() {
-	CatalogReportCmd.Flags().StringVarP(&catalogReportFormat, "format", "f", "facet-pdf", "Output format: json, facet-html, facet-pdf")
+	CatalogReportCmd.Flags().StringVarP(&catalogReportFormat, "format", "f", "facet-pdf", "Output format: json, xlsx, sarif, ocsf, facet-html, facet-pdf")
 	CatalogReportCmd.Flags().StringVarP(&catalogReportOutFile, "out-file", "o", "", "Write output to file instead of stdout")
 	CatalogReportCmd.Flags().StringVar(&catalogReportSince, "since", "30d", "Time range for changes and access logs (supports d/w/y e.g. 7d, 2w, 30d)")
 	CatalogReportCmd.Flags().StringVar(&catalogReportTitle, "title", "", "Report title (default auto-generated)")
@@ -219,6 +230,8 @@ func init() {
 	CatalogReportCmd.Flags().IntVar(&catalogReportStaleDays, "stale-days", 0, "Days since last sign-in before access is flagged stale (overrides settings; default 90)")
 	CatalogReportCmd.Flags().IntVar(&catalogReportReviewOverdueDays, "review-overdue-days", 0, "Days since last review before access is flagged overdue (overrides settings; default 90)")
 	CatalogReportCmd.Flags().StringArrayVar(&catalogReportFilters, "filter", nil, "Extra query filter (repeatable, appended to settings.filters)")
+	CatalogReportCmd.Flags().BoolVar(&catalogReportPersist, "persist", false, "Save the report so that later reports can be diffed against it")
+	CatalogReportCmd.Flags().StringVar(&catalogReportDiffAgainst, "diff-against", "", "ID or date (YYYY-MM-DD, RFC3339 or a duration ago e.g. 90d) of a saved report to diff against")
 
 	clicky.RegisterSubCommand("catalog", CatalogReportCmd)
 }
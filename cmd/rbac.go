@@ -152,15 +152,11 @@ func selectorsFromAppYAML(path string) []types.ResourceSelector {
 		}
 	}
 
-	if len(app.Spec.Mapping.Logins) > 0 {
-		return app.Spec.Mapping.Logins
-	}
-
-	return app.AllSelectors()
+	return app.AccessSelectors()
 }
 
 func init() {
-	ExportRBAC.Flags().StringVarP(&rbacFormat, "format", "f", "json", "Output format: json, csv, facet-html, facet-pdf")
+	ExportRBAC.Flags().StringVarP(&rbacFormat, "format", "f", "json", "Output format: json, csv, xlsx, facet-html, facet-pdf")
 	addFacetFlags(ExportRBAC)
 	ExportRBAC.Flags().StringVarP(&rbacOutFile, "out-file", "o", "", "Write output to file instead of stdout")
 	ExportRBAC.Flags().IntVar(&rbacStaleDays, "stale-days", 0, "Days without sign-in before access is flagged stale (0 = disabled)")
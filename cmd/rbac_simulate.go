@@ -0,0 +1,188 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/flanksource/clicky"
+	"github.com/flanksource/clicky/api"
+	"github.com/flanksource/commons/logger"
+	"github.com/flanksource/commons/properties"
+	"github.com/flanksource/duty"
+	"github.com/samber/lo"
+	"github.com/spf13/cobra"
+
+	"github.com/flanksource/incident-commander/rbac/simulate"
+)
+
+var rbacSimulateFiles []string
+
+var SimulateRBAC = &cobra.Command{
+	Use:   "simulate -f <manifest.yaml>...",
+	Short: "Show the access a Permission, PermissionGroup or Scope change would add or remove",
+	Long: `Evaluates the Permission, PermissionGroup and Scope objects in the manifests
+alongside the existing ones, without saving them, and prints the access
+(subject, object, action) gained and lost along with the changes to the
+row level security scopes of guest users.
+
+Objects with the name and namespace of an existing one replace it.
+
+Examples:
+  rbac simulate -f permission.yaml
+  rbac simulate -f scope.yaml -f permission.yaml --json`,
+	Args:             cobra.NoArgs,
+	PersistentPreRun: PreRun,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		logger.UseSlog()
+		if err := properties.LoadFile("mission-control.properties"); err != nil {
+			logger.Errorf(err.Error())
+		}
+
+		if len(rbacSimulateFiles) == 0 {
+			return fmt.Errorf("at least one manifest is required (-f)")
+		}
+
+		var req simulate.Request
+		for _, file := range rbacSimulateFiles {
+			manifest, err := os.ReadFile(file)
+			if err != nil {
+				return fmt.Errorf("failed to read %s: %w", file, err)
+			}
+
+			parsed, err := simulate.ParseManifests(manifest)
+			if err != nil {
+				return fmt.Errorf("failed to parse %s: %w", file, err)
+			}
+			req.Permissions = append(req.Permissions, parsed.Permissions...)
+			req.PermissionGroups = append(req.PermissionGroups, parsed.PermissionGroups...)
+			req.Scopes = append(req.Scopes, parsed.Scopes...)
+		}
+
+		ctx, stop, err := duty.Start("mission-control", duty.ClientOnly)
+		if err != nil {
+			return err
+		}
+		defer stop()
+
+		result, err := simulate.Simulate(ctx, req)
+		if err != nil {
+			return err
+		}
+
+		clicky.MustPrint(SimulateRBACResult{*result}, clicky.Flags.FormatOptions)
+		return nil
+	},
+}
+
+// SimulateRBACResult is the printable value for `rbac simulate`.
+type SimulateRBACResult struct {
+	simulate.Result `json:",inline"`
+}
+
+func (r SimulateRBACResult) Pretty() api.Text {
+	if len(r.Gained) == 0 && len(r.Lost) == 0 && len(r.RLS) == 0 {
+		return clicky.Text("No change in access.", "text-gray-500")
+	}
+
+	t := clicky.Text(fmt.Sprintf("Access: +%d -%d, RLS changes for %d people", len(r.Gained), len(r.Lost), len(r.RLS)), "font-bold text-gray-700")
+	if len(r.Gained) > 0 {
+		rows := lo.Map(r.Gained, func(a simulate.Access, _ int) simulatedAccessRow { return simulatedAccessRow{a} })
+		t = t.NewLine().Append(clicky.Collapsed(fmt.Sprintf("Gained (%d)", len(rows)), api.NewTableFrom(rows)))
+	}
+	if len(r.Lost) > 0 {
+		rows := lo.Map(r.Lost, func(a simulate.Access, _ int) simulatedAccessRow { return simulatedAccessRow{a} })
+		t = t.NewLine().Append(clicky.Collapsed(fmt.Sprintf("Lost (%d)", len(rows)), api.NewTableFrom(rows)))
+	}
+
+	for _, change := range r.RLS {
+		label := fmt.Sprintf("RLS %s: +%d -%d", lo.CoalesceOrEmpty(change.Name, change.Subject), len(change.Gained), len(change.Lost))
+		if change.Before.Disable != change.After.Disable {
+			label += fmt.Sprintf(" (disabled: %t → %t)", change.Before.Disable, change.After.Disable)
+		}
+
+		var rows []simulatedScopeRow
+		for _, scope := range change.Gained {
+			rows = append(rows, simulatedScopeRow{Change: "+", RLSScope: scope})
+		}
+		for _, scope := range change.Lost {
+			rows = append(rows, simulatedScopeRow{Change: "-", RLSScope: scope})
+		}
+		t = t.NewLine().Append(clicky.Collapsed(label, api.NewTableFrom(rows)))
+	}
+
+	return t
+}
+
+type simulatedAccessRow struct {
+	simulate.Access
+}
+
+func (r simulatedAccessRow) Columns() []api.ColumnDef {
+	return []api.ColumnDef{
+		api.Column("Subject").Build(),
+		api.Column("Object").Build(),
+		api.Column("Action").Build(),
+		api.Column("Effect").Build(),
+		api.Column("Condition").Build(),
+	}
+}
+
+func (r simulatedAccessRow) Row() map[string]any {
+	effect := clicky.Text(r.Effect, "text-green-600")
+	if r.Effect == "deny" {
+		effect = clicky.Text(r.Effect, "text-red-600")
+	}
+	return map[string]any{
+		"Subject":   clicky.Text(lo.CoalesceOrEmpty(r.Name, r.Subject), "font-bold"),
+		"Object":    clicky.Text(r.Object),
+		"Action":    clicky.Text(r.Action),
+		"Effect":    effect,
+		"Condition": clicky.Text(r.Condition, "text-gray-500"),
+	}
+}
+
+type simulatedScopeRow struct {
+	Change string
+	simulate.RLSScope
+}
+
+func (r simulatedScopeRow) Columns() []api.ColumnDef {
+	return []api.ColumnDef{
+		api.Column("Change").Build(),
+		api.Column("Resource").Build(),
+		api.Column("Scope").Build(),
+	}
+}
+
+func (r simulatedScopeRow) Row() map[string]any {
+	change := clicky.Text(r.Change, "text-green-600")
+	if r.Change == "-" {
+		change = clicky.Text(r.Change, "text-red-600")
+	}
+
+	scope := r.ID
+	if len(r.Names) > 0 {
+		scope += fmt.Sprintf(" names=%v", r.Names)
+	}
+	if len(r.Agents) > 0 {
+		scope += fmt.Sprintf(" agents=%v", r.Agents)
+	}
+	if len(r.Tags) > 0 {
+		scope += fmt.Sprintf(" tags=%v", r.Tags)
+	}
+	if r.Deny {
+		scope += " (deny)"
+	}
+
+	return map[string]any{
+		"Change":   change,
+		"Resource": clicky.Text(r.Resource),
+		"Scope":    clicky.Text(scope, "text-gray-600"),
+	}
+}
+
+func init() {
+	SimulateRBAC.Flags().StringArrayVarP(&rbacSimulateFiles, "file", "f", nil, "Manifest of the Permission, PermissionGroup and Scope objects to simulate (repeatable)")
+	clicky.BindAllFlags(SimulateRBAC.PersistentFlags(), "format")
+	RBACCmd.AddCommand(SimulateRBAC)
+}
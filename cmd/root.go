@@ -19,6 +19,7 @@ import (
 	"go.opentelemetry.io/otel/attribute"
 
 	"github.com/flanksource/incident-commander/api"
+	"github.com/flanksource/incident-commander/audit"
 	"github.com/flanksource/incident-commander/auth"
 	"github.com/flanksource/incident-commander/auth/accesstoken"
 	"github.com/flanksource/incident-commander/auth/signing"
@@ -140,9 +141,22 @@ func ServerFlags(flags *pflag.FlagSet) {
 	flags.StringVar(&auth.KratosAdminAPI, "kratos-admin", "http://kratos-admin:80", "Kratos Admin API service")
 	flags.StringVar(&auth.ClerkJwksUrl, "clerk-jwks-url", "", "Clerk JWKS URL")
 	flags.StringVar(&auth.ClerkOrgID, "clerk-org-id", "", "Clerk Organization ID")
-	flags.StringVar(&vars.AuthMode, "auth", "", "Enable authentication via Kratos or Clerk. Valid values are [kratos, clerk, basic]")
+	flags.StringVar(&vars.AuthMode, "auth", "", "Enable authentication via Kratos or Clerk. Valid values are [kratos, clerk, basic, oidc]")
 	flags.StringVar(&auth.HtpasswdFile, "htpasswd-file", "htpasswd", "Path to htpasswd file for basic authentication")
 	flags.BoolVar(&auth.OIDCEnabled, "oidc", false, "Enable embedded OIDC provider (requires --auth basic)")
+	flags.StringVar(&auth.RelyingParty.Issuer, "auth-oidc-issuer", "", "Issuer URL of the upstream OIDC identity provider (--auth oidc)")
+	flags.StringVar(&auth.RelyingParty.ClientID, "auth-oidc-client-id", "", "Client ID registered with the upstream OIDC identity provider")
+	flags.StringVar(&auth.RelyingParty.ClientSecret, "auth-oidc-client-secret", "", "Client secret registered with the upstream OIDC identity provider")
+	flags.StringVar(&auth.RelyingParty.RedirectURL, "auth-oidc-redirect-url", "", "Redirect URL registered with the upstream OIDC identity provider (defaults to <frontend-url>/auth/oidc/callback)")
+	flags.StringSliceVar(&auth.RelyingParty.Scopes, "auth-oidc-scopes", []string{"openid", "profile", "email", "offline_access"}, "Scopes requested from the upstream OIDC identity provider")
+	flags.StringVar(&auth.RelyingParty.NameClaim, "auth-oidc-name-claim", "name", "ID token claim of the user's name")
+	flags.StringVar(&auth.RelyingParty.EmailClaim, "auth-oidc-email-claim", "email", "ID token claim of the user's email")
+	flags.StringVar(&auth.RelyingParty.GroupsClaim, "auth-oidc-groups-claim", "groups", "ID token claim of the user's groups")
+	flags.StringToStringVar(&auth.RelyingParty.RoleMapping, "auth-oidc-role-mapping", nil, "Maps upstream groups to roles, e.g. platform-admins=admin")
+	flags.StringToStringVar(&auth.RelyingParty.TeamMapping, "auth-oidc-team-mapping", nil, "Maps upstream groups to teams, e.g. sre=SRE")
+	flags.StringVar(&auth.RelyingParty.DefaultRole, "auth-oidc-default-role", "viewer", "Role of users whose groups aren't in the role mapping")
+	flags.StringVar(&audit.KeyFile, "audit-key", "", "Path to the secret the audit log hash chain is keyed with")
+	flags.StringVar(&audit.HeadFile, "audit-head", "", "Path the head of the audit log hash chain is kept at, outside of the database, to detect deleted latest entries")
 	flags.StringVar(&signing.PrivateKeyPath, "signing-private-key", "", "Path to RSA private key PEM used to sign Mission Control JWTs (ephemeral key generated on startup if missing)")
 	flags.StringVar(&emailFromAddress, "email-from-address", "no-reply@flanksource.com", "Email address of the sender")
 	flags.StringVar(&emailFromName, "email-from-name", "Mission Control", "Email name of the sender")
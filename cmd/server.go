@@ -9,6 +9,7 @@ import (
 
 	"github.com/flanksource/commons/logger"
 	"github.com/flanksource/duty"
+	dutyApi "github.com/flanksource/duty/api"
 	"github.com/flanksource/duty/context"
 	"github.com/flanksource/duty/models"
 	"github.com/flanksource/duty/postq/pg"
@@ -23,9 +24,11 @@ import (
 	"github.com/flanksource/incident-commander/api"
 	v1 "github.com/flanksource/incident-commander/api/v1"
 	"github.com/flanksource/incident-commander/application"
+	"github.com/flanksource/incident-commander/audit"
 	"github.com/flanksource/incident-commander/auth"
 	"github.com/flanksource/incident-commander/connection"
 	"github.com/flanksource/incident-commander/db"
+	"github.com/flanksource/incident-commander/db/schema"
 	"github.com/flanksource/incident-commander/echo"
 	"github.com/flanksource/incident-commander/events"
 	"github.com/flanksource/incident-commander/jobs"
@@ -43,6 +46,10 @@ import (
 	_ "github.com/flanksource/incident-commander/catalog"
 	_ "github.com/flanksource/incident-commander/playbook"
 	_ "github.com/flanksource/incident-commander/plugin/gateway"
+	_ "github.com/flanksource/incident-commander/rbac/breakglass"
+	_ "github.com/flanksource/incident-commander/rbac/elevation"
+	_ "github.com/flanksource/incident-commander/rbac/recertification"
+	_ "github.com/flanksource/incident-commander/scim"
 	_ "github.com/flanksource/incident-commander/shorturl"
 	_ "github.com/flanksource/incident-commander/snapshot"
 	"github.com/flanksource/incident-commander/teams"
@@ -207,6 +214,16 @@ var Serve = &cobra.Command{
 			shutdown.ShutdownAndExit(1, fmt.Sprintf("error setting up db connection: %v", err))
 		}
 
+		if dutyApi.DefaultConfig.Migrate() {
+			if err := schema.Apply(ctx); err != nil {
+				shutdown.ShutdownAndExit(1, fmt.Sprintf("error applying schema: %v", err))
+			}
+		}
+
+		if err := audit.LoadKey(); err != nil {
+			shutdown.ShutdownAndExit(1, err.Error())
+		}
+
 		// GetSystemUser sets api.SystemUserID
 		if _, err := db.GetSystemUser(ctx); err != nil {
 			shutdown.ShutdownAndExit(1, fmt.Sprintf("error setting up system user: %v", err))
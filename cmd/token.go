@@ -12,6 +12,7 @@ import (
 	"github.com/flanksource/duty"
 	"github.com/flanksource/duty/models"
 	"github.com/flanksource/duty/rbac"
+	"github.com/flanksource/duty/rbac/policy"
 	"github.com/flanksource/duty/types"
 	"github.com/google/uuid"
 	"github.com/spf13/cobra"
@@ -20,6 +21,7 @@ import (
 	"github.com/flanksource/incident-commander/auth"
 	"github.com/flanksource/incident-commander/clientcmd"
 	"github.com/flanksource/incident-commander/db"
+	"github.com/flanksource/incident-commander/rbac/adapter"
 	"github.com/flanksource/incident-commander/vars"
 )
 
@@ -41,12 +43,46 @@ var Token = &cobra.Command{
 			return errors.New("user not found")
 		}
 
-		token, _, err := db.CreateAccessToken(ctx, user.ID, "default", &tokenExpiry, nil, false)
+		if len(tokenPermissions) == 0 && tokenScope == "" && len(tokenCIDRs) == 0 {
+			token, _, err := db.CreateAccessToken(ctx, user.ID, "default", &tokenExpiry, nil, false)
+			if err != nil {
+				return fmt.Errorf("failed to create a new access token: %w", err)
+			}
+
+			fmt.Println(token)
+			return nil
+		}
+
+		// A scoped token acts as a separate person grouped under the user
+		if err := rbac.Init(ctx, []string{}, adapter.NewPermissionAdapter); err != nil {
+			return err
+		}
+
+		req := auth.CreateTokenRequest{Name: "default", Expiry: tokenExpiry.String(), CIDRs: tokenCIDRs}
+		for _, p := range tokenPermissions {
+			object, action, ok := strings.Cut(p, ":")
+			if !ok {
+				return fmt.Errorf("invalid permission %q: expected object:action", p)
+			}
+			req.Scope = append(req.Scope, policy.Permission{Object: object, Action: action})
+		}
+		if tokenScope != "" {
+			namespace, name, ok := strings.Cut(tokenScope, "/")
+			if !ok {
+				namespace, name = "default", tokenScope
+			}
+			req.ScopeRef = &rbac.NamespacedNameIDSelector{Namespace: namespace, Name: name}
+		}
+
+		token, err := auth.CreateScopedToken(ctx.WithUser(&user), req)
 		if err != nil {
 			return fmt.Errorf("failed to create a new access token: %w", err)
 		}
 
-		fmt.Println(token)
+		fmt.Println(token.Token.PlainText())
+		for _, p := range token.Permissions {
+			fmt.Fprintf(os.Stderr, "%s:%s\n", p.Object, p.Action)
+		}
 		return nil
 	},
 }
@@ -92,6 +128,9 @@ var PasswordReset = &cobra.Command{
 var (
 	tokenUser         string
 	tokenExpiry       time.Duration
+	tokenPermissions  []string
+	tokenScope        string
+	tokenCIDRs        []string
 	resetPasswordUser string
 )
 
@@ -179,6 +218,9 @@ func init() {
 
 	Token.Flags().StringVar(&tokenUser, "user", "", "User to generate a token for")
 	Token.Flags().DurationVar(&tokenExpiry, "expiry", time.Hour*4, "Expiry duration for token")
+	Token.Flags().StringArrayVar(&tokenPermissions, "permission", nil, "Restrict the token to an object:action, e.g. playbook:run (repeatable)")
+	Token.Flags().StringVar(&tokenScope, "scope", "", "Restrict the token to the rows of a Scope (namespace/name)")
+	Token.Flags().StringArrayVar(&tokenCIDRs, "cidr", nil, "Restrict the token to a source network (repeatable)")
 
 	PasswordReset.Flags().StringVar(&resetPasswordUser, "user", "", "User email to reset password for")
 	PasswordReset.Flags().StringVar(&vars.AuthMode, "auth", "", "Auth type: kratos, basic")
@@ -0,0 +1,238 @@
+package db
+
+import (
+	"time"
+
+	"github.com/flanksource/duty/context"
+	dutyTypes "github.com/flanksource/duty/types"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/samber/lo"
+	"gorm.io/gorm"
+)
+
+const (
+	AccessCampaignStatusOpen      = "open"
+	AccessCampaignStatusSignedOff = "signed_off"
+	AccessCampaignStatusCancelled = "cancelled"
+
+	AccessDecisionPending  = "pending"
+	AccessDecisionApproved = "approved"
+	AccessDecisionRevoked  = "revoked"
+)
+
+// AccessCampaign is a row of the access_campaigns table: a recertification of
+// the access to a set of config items, due by a date.
+type AccessCampaign struct {
+	ID          uuid.UUID `gorm:"primaryKey;default:gen_random_uuid()" json:"id"`
+	Name        string    `json:"name"`
+	Description string    `gorm:"default:NULL" json:"description,omitempty"`
+	// Spec is the recertification.Spec the campaign was opened with.
+	Spec   dutyTypes.JSON `json:"spec"`
+	Status string         `json:"status"`
+	DueAt  time.Time      `json:"due_at"`
+	// Digest is the sha256 of the decisions at sign off.
+	Digest         string     `gorm:"default:NULL" json:"digest,omitempty"`
+	SignedOffAt    *time.Time `json:"signed_off_at,omitempty"`
+	SignedOffBy    *uuid.UUID `json:"signed_off_by,omitempty"`
+	LastRemindedAt *time.Time `json:"last_reminded_at,omitempty"`
+	CreatedBy      *uuid.UUID `json:"created_by,omitempty"`
+	CreatedAt      time.Time  `gorm:"<-:create" json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+func (AccessCampaign) TableName() string { return "access_campaigns" }
+
+// Overdue reports whether the campaign is still open past its due date.
+func (t AccessCampaign) Overdue() bool {
+	return t.Status == AccessCampaignStatusOpen && time.Now().After(t.DueAt)
+}
+
+// AccessCampaignItem is a row of the access_campaign_items table: a snapshot
+// of one config_access grant at the opening of a campaign and the decision of
+// its reviewers.
+type AccessCampaignItem struct {
+	ID              uuid.UUID  `gorm:"primaryKey;default:gen_random_uuid()" json:"id"`
+	CampaignID      uuid.UUID  `json:"campaign_id"`
+	ConfigAccessID  string     `json:"config_access_id"`
+	ConfigID        uuid.UUID  `json:"config_id"`
+	ConfigName      string     `json:"config_name"`
+	ConfigType      string     `json:"config_type"`
+	ScraperID       *uuid.UUID `json:"scraper_id,omitempty"`
+	ExternalUserID  *uuid.UUID `json:"external_user_id,omitempty"`
+	ExternalGroupID *uuid.UUID `json:"external_group_id,omitempty"`
+	ExternalRoleID  *uuid.UUID `json:"external_role_id,omitempty"`
+	User            string     `json:"user"`
+	Email           string     `json:"email,omitempty"`
+	Role            string     `json:"role,omitempty"`
+	// ReviewerPersonIDs and ReviewerTeamIDs are who may decide on the item.
+	ReviewerPersonIDs pq.StringArray `gorm:"type:uuid[]" json:"reviewer_person_ids,omitempty"`
+	ReviewerTeamIDs   pq.StringArray `gorm:"type:uuid[]" json:"reviewer_team_ids,omitempty"`
+	Decision          string         `json:"decision"`
+	DecidedBy         *uuid.UUID     `json:"decided_by,omitempty"`
+	DecidedAt         *time.Time     `json:"decided_at,omitempty"`
+	Comment           string         `gorm:"default:NULL" json:"comment,omitempty"`
+	// PlaybookRunID is the run of the revocation playbook of a revoked item.
+	PlaybookRunID *uuid.UUID `json:"playbook_run_id,omitempty"`
+	Error         string     `gorm:"default:NULL" json:"error,omitempty"`
+	CreatedAt     time.Time  `gorm:"<-:create" json:"created_at"`
+}
+
+func (AccessCampaignItem) TableName() string { return "access_campaign_items" }
+
+// CreateAccessCampaign saves a new campaign along with its items.
+func CreateAccessCampaign(ctx context.Context, campaign *AccessCampaign, items []AccessCampaignItem) error {
+	return ctx.DB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(campaign).Error; err != nil {
+			return ctx.Oops().Wrapf(err, "failed to create access campaign")
+		}
+		if len(items) == 0 {
+			return nil
+		}
+
+		for i := range items {
+			items[i].CampaignID = campaign.ID
+		}
+		if err := tx.CreateInBatches(items, 500).Error; err != nil {
+			return ctx.Oops().Wrapf(err, "failed to create access campaign items")
+		}
+		return nil
+	})
+}
+
+// GetAccessCampaign returns the campaign with the id, or nil if there's none.
+func GetAccessCampaign(ctx context.Context, id uuid.UUID) (*AccessCampaign, error) {
+	var campaigns []AccessCampaign
+	if err := ctx.DB().Where("id = ?", id).Limit(1).Find(&campaigns).Error; err != nil {
+		return nil, ctx.Oops().Wrapf(err, "failed to get access campaign %s", id)
+	}
+	if len(campaigns) == 0 {
+		return nil, nil
+	}
+	return &campaigns[0], nil
+}
+
+// ListAccessCampaigns returns the campaigns, latest first, optionally
+// filtered by status.
+func ListAccessCampaigns(ctx context.Context, statuses ...string) ([]AccessCampaign, error) {
+	q := ctx.DB().Order("created_at DESC")
+	if len(statuses) > 0 {
+		q = q.Where("status IN ?", statuses)
+	}
+
+	var campaigns []AccessCampaign
+	if err := q.Find(&campaigns).Error; err != nil {
+		return nil, ctx.Oops().Wrapf(err, "failed to list access campaigns")
+	}
+	return campaigns, nil
+}
+
+// GetAccessCampaignItems returns the items of a campaign ordered by config and user.
+func GetAccessCampaignItems(ctx context.Context, campaignID uuid.UUID) ([]AccessCampaignItem, error) {
+	var items []AccessCampaignItem
+	if err := ctx.DB().Where("campaign_id = ?", campaignID).
+		Order(`config_name, "user", role`).
+		Find(&items).Error; err != nil {
+		return nil, ctx.Oops().Wrapf(err, "failed to get items of access campaign %s", campaignID)
+	}
+	return items, nil
+}
+
+// GetAccessCampaignItem returns the item with the id, or nil if there's none.
+func GetAccessCampaignItem(ctx context.Context, id uuid.UUID) (*AccessCampaignItem, error) {
+	var items []AccessCampaignItem
+	if err := ctx.DB().Where("id = ?", id).Limit(1).Find(&items).Error; err != nil {
+		return nil, ctx.Oops().Wrapf(err, "failed to get access campaign item %s", id)
+	}
+	if len(items) == 0 {
+		return nil, nil
+	}
+	return &items[0], nil
+}
+
+// DecideAccessCampaignItem saves the decision on the item if it's still
+// pending, so that only one of concurrent reviewers decides. It reports
+// whether the decision was saved.
+func DecideAccessCampaignItem(ctx context.Context, item *AccessCampaignItem) (bool, error) {
+	tx := ctx.DB().Model(item).
+		Where("decision = ?", AccessDecisionPending).
+		Select("decision", "decided_by", "decided_at", "comment", "error").
+		Updates(item)
+	if tx.Error != nil {
+		return false, ctx.Oops().Wrapf(tx.Error, "failed to save decision on access campaign item %s", item.ID)
+	}
+	return tx.RowsAffected > 0, nil
+}
+
+// GetAccessCampaignTasks returns the pending items of open campaigns that
+// any of the people or teams review.
+func GetAccessCampaignTasks(ctx context.Context, personIDs, teamIDs []uuid.UUID) ([]AccessCampaignItem, error) {
+	toArray := func(ids []uuid.UUID) pq.StringArray {
+		return lo.Map(ids, func(id uuid.UUID, _ int) string { return id.String() })
+	}
+
+	var items []AccessCampaignItem
+	if err := ctx.DB().Select("access_campaign_items.*").
+		Joins("JOIN access_campaigns ON access_campaigns.id = access_campaign_items.campaign_id").
+		Where("access_campaigns.status = ?", AccessCampaignStatusOpen).
+		Where("access_campaign_items.decision = ?", AccessDecisionPending).
+		Where("access_campaign_items.reviewer_person_ids && ?::uuid[] OR access_campaign_items.reviewer_team_ids && ?::uuid[]",
+			toArray(personIDs), toArray(teamIDs)).
+		Order(`access_campaigns.due_at, access_campaign_items.config_name, access_campaign_items."user"`).
+		Find(&items).Error; err != nil {
+		return nil, ctx.Oops().Wrapf(err, "failed to get access campaign tasks")
+	}
+	return items, nil
+}
+
+// AccessGrant is an active config_access grant with the names of its principal and role.
+type AccessGrant struct {
+	ID              string
+	ConfigID        uuid.UUID
+	ConfigName      string
+	ConfigType      string
+	Tags            dutyTypes.JSONStringMap
+	Labels          dutyTypes.JSONStringMap
+	ScraperID       *uuid.UUID
+	ExternalUserID  *uuid.UUID
+	ExternalGroupID *uuid.UUID
+	ExternalRoleID  *uuid.UUID
+	User            string
+	Email           string
+	Role            string
+}
+
+// GetAccessGrants returns the active grants on the config items.
+func GetAccessGrants(ctx context.Context, configIDs []uuid.UUID) ([]AccessGrant, error) {
+	if len(configIDs) == 0 {
+		return nil, nil
+	}
+
+	var grants []AccessGrant
+	if err := ctx.DB().Table("config_access").
+		Select(`config_access.id,
+			config_access.config_id,
+			config_items.name AS config_name,
+			config_items.type AS config_type,
+			config_items.tags,
+			config_items.labels,
+			config_access.scraper_id,
+			config_access.external_user_id,
+			config_access.external_group_id,
+			config_access.external_role_id,
+			COALESCE(external_users.name, external_groups.name, '') AS "user",
+			COALESCE(external_users.email, '') AS email,
+			COALESCE(external_roles.name, '') AS role`).
+		Joins("JOIN config_items ON config_items.id = config_access.config_id").
+		Joins("LEFT JOIN external_users ON external_users.id = config_access.external_user_id").
+		Joins("LEFT JOIN external_groups ON external_groups.id = config_access.external_group_id").
+		Joins("LEFT JOIN external_roles ON external_roles.id = config_access.external_role_id").
+		Where("config_access.deleted_at IS NULL").
+		Where("config_items.deleted_at IS NULL").
+		Where("config_access.config_id IN ?", configIDs).
+		Order(`config_items.name, "user"`).
+		Scan(&grants).Error; err != nil {
+		return nil, ctx.Oops().Wrapf(err, "failed to get access grants")
+	}
+	return grants, nil
+}
@@ -0,0 +1,93 @@
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/flanksource/duty/context"
+	"github.com/flanksource/duty/models"
+	"github.com/google/uuid"
+)
+
+// ConfigEventTime is when something happened to a config item.
+type ConfigEventTime struct {
+	ConfigID uuid.UUID
+	Time     time.Time
+}
+
+// ConfigIncident is an incident with evidence on a config item.
+type ConfigIncident struct {
+	ID        uuid.UUID
+	ConfigID  uuid.UUID
+	CreatedAt time.Time
+	Resolved  *time.Time
+}
+
+// GetConfigChangeTimes returns when the config items had a change of one of
+// the given types since the given time, oldest first.
+func GetConfigChangeTimes(ctx context.Context, configIDs []uuid.UUID, changeTypes []string, since time.Time) ([]ConfigEventTime, error) {
+	if len(configIDs) == 0 || len(changeTypes) == 0 {
+		return nil, nil
+	}
+
+	var changes []ConfigEventTime
+	if err := ctx.DB().Model(&models.ConfigChange{}).
+		Select("config_id, created_at AS time").
+		Where("config_id IN ? AND change_type IN ? AND created_at >= ?", configIDs, changeTypes, since).
+		Order("created_at").
+		Scan(&changes).Error; err != nil {
+		return nil, fmt.Errorf("failed to get config changes: %w", err)
+	}
+	return changes, nil
+}
+
+// GetConfigIncidents returns the incidents with evidence on the config items
+// that were created or resolved since the given time.
+func GetConfigIncidents(ctx context.Context, configIDs []uuid.UUID, since time.Time) ([]ConfigIncident, error) {
+	if len(configIDs) == 0 {
+		return nil, nil
+	}
+
+	var incidents []ConfigIncident
+	if err := ctx.DB().Table("incidents").
+		Distinct("incidents.id", "evidences.config_id", "incidents.created_at", "incidents.resolved").
+		Joins("JOIN hypotheses ON hypotheses.incident_id = incidents.id").
+		Joins("JOIN evidences ON evidences.hypothesis_id = hypotheses.id").
+		Where("evidences.config_id IN ?", configIDs).
+		Where("incidents.created_at >= ? OR incidents.resolved >= ?", since, since).
+		Scan(&incidents).Error; err != nil {
+		return nil, fmt.Errorf("failed to get incidents: %w", err)
+	}
+	return incidents, nil
+}
+
+// GetHealthRestoreDurations returns how long the config items that became
+// healthy since the given time had been unhealthy. Outages that began more
+// than lookback before since aren't counted.
+//
+// Health transitions are recorded as Healthy/Unhealthy config changes.
+func GetHealthRestoreDurations(ctx context.Context, configIDs []uuid.UUID, since time.Time, lookback time.Duration) ([]time.Duration, error) {
+	if len(configIDs) == 0 {
+		return nil, nil
+	}
+
+	var seconds []float64
+	if err := ctx.DB().Raw(`
+		SELECT EXTRACT(EPOCH FROM (created_at - previous_at)) FROM (
+			SELECT change_type, created_at,
+				LAG(change_type) OVER (PARTITION BY config_id ORDER BY created_at) AS previous_type,
+				LAG(created_at) OVER (PARTITION BY config_id ORDER BY created_at) AS previous_at
+			FROM config_changes
+			WHERE config_id IN ? AND change_type IN ('Healthy', 'Unhealthy') AND created_at >= ?
+		) transitions
+		WHERE change_type = 'Healthy' AND previous_type = 'Unhealthy' AND created_at >= ?`,
+		configIDs, since.Add(-lookback), since).Scan(&seconds).Error; err != nil {
+		return nil, fmt.Errorf("failed to get health transitions: %w", err)
+	}
+
+	durations := make([]time.Duration, len(seconds))
+	for i, s := range seconds {
+		durations[i] = time.Duration(s * float64(time.Second))
+	}
+	return durations, nil
+}
@@ -0,0 +1,70 @@
+package db
+
+import (
+	"time"
+
+	"github.com/flanksource/duty/models"
+	"github.com/flanksource/duty/tests/fixtures/dummy"
+	"github.com/google/uuid"
+	ginkgo "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/samber/lo"
+)
+
+var _ = ginkgo.Describe("Application DORA", ginkgo.Ordered, func() {
+	// Far enough in the future to not overlap with the dummy changes.
+	base := time.Date(2031, 1, 1, 12, 0, 0, 0, time.UTC)
+	configID := dummy.KubernetesNodeA.ID
+
+	var changes []models.ConfigChange
+
+	ginkgo.BeforeAll(func() {
+		for changeType, age := range map[string]time.Duration{
+			"Unhealthy":  3 * time.Hour,
+			"Warning":    150 * time.Minute,
+			"Healthy":    2 * time.Hour,
+			"Deployment": time.Hour,
+		} {
+			changes = append(changes, models.ConfigChange{
+				ID:         uuid.NewString(),
+				ConfigID:   configID.String(),
+				ChangeType: changeType,
+				Source:     "dora-test",
+				CreatedAt:  lo.ToPtr(base.Add(-age)),
+			})
+		}
+		Expect(DefaultContext.DB().Create(&changes).Error).To(Succeed())
+	})
+
+	ginkgo.AfterAll(func() {
+		Expect(DefaultContext.DB().Delete(&changes).Error).To(Succeed())
+	})
+
+	ginkgo.It("returns the changes of the given types", func() {
+		deployments, err := GetConfigChangeTimes(DefaultContext, []uuid.UUID{configID}, []string{"Deployment"}, base.Add(-4*time.Hour))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(deployments).To(HaveLen(1))
+		Expect(deployments[0].ConfigID).To(Equal(configID))
+		Expect(deployments[0].Time).To(BeTemporally("==", base.Add(-time.Hour)))
+	})
+
+	ginkgo.It("measures unhealthy to healthy transitions", func() {
+		restores, err := GetHealthRestoreDurations(DefaultContext, []uuid.UUID{configID}, base.Add(-4*time.Hour), 24*time.Hour)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(restores).To(ConsistOf(time.Hour))
+
+		restores, err = GetHealthRestoreDurations(DefaultContext, []uuid.UUID{configID}, base.Add(-time.Hour), 24*time.Hour)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(restores).To(BeEmpty())
+	})
+
+	ginkgo.It("ignores outages that began before the lookback", func() {
+		restores, err := GetHealthRestoreDurations(DefaultContext, []uuid.UUID{configID}, base.Add(-2*time.Hour), 2*time.Hour)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(restores).To(ConsistOf(time.Hour))
+
+		restores, err = GetHealthRestoreDurations(DefaultContext, []uuid.UUID{configID}, base.Add(-2*time.Hour), 30*time.Minute)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(restores).To(BeEmpty())
+	})
+})
@@ -0,0 +1,131 @@
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/flanksource/duty/context"
+	"github.com/google/uuid"
+	"gorm.io/gorm/clause"
+
+	"github.com/flanksource/incident-commander/api"
+)
+
+// ApplicationSLOSample is a row of the application_slo_samples table: the good
+// and total events of an objective whose SLI can only be sampled, like the
+// health of config items, and the evaluation of the objective at that time.
+type ApplicationSLOSample struct {
+	ApplicationID uuid.UUID `gorm:"primaryKey"`
+	SLO           string    `gorm:"primaryKey;column:slo"`
+	Time          time.Time `gorm:"primaryKey"`
+	Good          float64
+	Total         float64
+	Status        *api.ApplicationSLOStatus `gorm:"serializer:json"`
+}
+
+func (ApplicationSLOSample) TableName() string { return "application_slo_samples" }
+
+// SaveApplicationSLOSample inserts a new sample.
+func SaveApplicationSLOSample(ctx context.Context, sample *ApplicationSLOSample) error {
+	if sample.Time.IsZero() {
+		sample.Time = time.Now()
+	}
+
+	if err := ctx.DB().Create(sample).Error; err != nil {
+		return fmt.Errorf("failed to save slo sample: %w", err)
+	}
+
+	return nil
+}
+
+// SaveApplicationSLOStatus records the evaluation of the objective on its
+// sample taken at the time of the evaluation, creating the sample when the
+// SLI isn't sampled.
+func SaveApplicationSLOStatus(ctx context.Context, applicationID uuid.UUID, status api.ApplicationSLOStatus) error {
+	sample := ApplicationSLOSample{ApplicationID: applicationID, SLO: status.Name, Time: status.EvaluatedAt, Status: &status}
+	if err := ctx.DB().Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "application_id"}, {Name: "slo"}, {Name: "time"}},
+		DoUpdates: clause.AssignmentColumns([]string{"status"}),
+	}).Create(&sample).Error; err != nil {
+		return fmt.Errorf("failed to save slo status: %w", err)
+	}
+	return nil
+}
+
+// GetApplicationSLOStatuses returns the last evaluation of every objective of
+// the application, keyed by the name of the objective.
+func GetApplicationSLOStatuses(ctx context.Context, applicationID uuid.UUID) (map[string]api.ApplicationSLOStatus, error) {
+	var samples []ApplicationSLOSample
+	if err := ctx.DB().Raw(`
+		SELECT DISTINCT ON (slo) * FROM application_slo_samples
+		WHERE application_id = ? AND status IS NOT NULL
+		ORDER BY slo, time DESC`, applicationID).
+		Scan(&samples).Error; err != nil {
+		return nil, fmt.Errorf("failed to get slo statuses: %w", err)
+	}
+
+	statuses := make(map[string]api.ApplicationSLOStatus, len(samples))
+	for _, sample := range samples {
+		statuses[sample.SLO] = *sample.Status
+	}
+	return statuses, nil
+}
+
+// DeleteApplicationSLOSamplesExcept deletes the samples of the objectives of
+// the application that aren't in the given list.
+func DeleteApplicationSLOSamplesExcept(ctx context.Context, applicationID uuid.UUID, slos []string) (int64, error) {
+	tx := ctx.DB().Where("application_id = ?", applicationID)
+	if len(slos) > 0 {
+		tx = tx.Where("slo NOT IN ?", slos)
+	}
+	if tx = tx.Delete(&ApplicationSLOSample{}); tx.Error != nil {
+		return 0, fmt.Errorf("failed to delete slo samples: %w", tx.Error)
+	}
+	return tx.RowsAffected, nil
+}
+
+// SumApplicationSLOSamples returns the good and total events of the samples
+// taken since the given time.
+func SumApplicationSLOSamples(ctx context.Context, applicationID uuid.UUID, slo string, since time.Time) (good, total float64, err error) {
+	var sum struct {
+		Good  float64
+		Total float64
+	}
+	if err := ctx.DB().Model(&ApplicationSLOSample{}).
+		Select("COALESCE(SUM(good), 0) AS good, COALESCE(SUM(total), 0) AS total").
+		Where("application_id = ? AND slo = ? AND time >= ?", applicationID, slo, since).
+		Scan(&sum).Error; err != nil {
+		return 0, 0, fmt.Errorf("failed to sum slo samples: %w", err)
+	}
+	return sum.Good, sum.Total, nil
+}
+
+// DeleteApplicationSLOSamplesBefore deletes the samples of the application
+// that are older than the given time.
+func DeleteApplicationSLOSamplesBefore(ctx context.Context, applicationID uuid.UUID, slo string, before time.Time) (int64, error) {
+	tx := ctx.DB().Where("application_id = ? AND slo = ? AND time < ?", applicationID, slo, before).Delete(&ApplicationSLOSample{})
+	if tx.Error != nil {
+		return 0, fmt.Errorf("failed to delete slo samples: %w", tx.Error)
+	}
+	return tx.RowsAffected, nil
+}
+
+// CountCheckStatuses returns the passing and total results of the checks
+// since the given time.
+func CountCheckStatuses(ctx context.Context, checkIDs []uuid.UUID, since time.Time) (passed, total float64, err error) {
+	if len(checkIDs) == 0 {
+		return 0, 0, nil
+	}
+
+	var count struct {
+		Passed float64
+		Total  float64
+	}
+	if err := ctx.DB().Table("check_statuses").
+		Select("COUNT(*) FILTER (WHERE status) AS passed, COUNT(*) AS total").
+		Where("check_id IN ? AND created_at >= ?", checkIDs, since).
+		Scan(&count).Error; err != nil {
+		return 0, 0, fmt.Errorf("failed to count check statuses: %w", err)
+	}
+	return count.Passed, count.Total, nil
+}
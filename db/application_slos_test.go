@@ -0,0 +1,84 @@
+package db
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	ginkgo "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/flanksource/incident-commander/api"
+)
+
+var _ = ginkgo.Describe("Application SLO samples", ginkgo.Ordered, func() {
+	applicationID := uuid.New()
+	now := time.Now().Truncate(time.Second)
+
+	ginkgo.BeforeAll(func() {
+		for i, age := range []time.Duration{48 * time.Hour, 2 * time.Hour, 10 * time.Minute} {
+			Expect(SaveApplicationSLOSample(DefaultContext, &ApplicationSLOSample{
+				ApplicationID: applicationID,
+				SLO:           "healthy",
+				Time:          now.Add(-age),
+				Good:          float64(i + 1),
+				Total:         4,
+			})).To(Succeed())
+		}
+	})
+
+	ginkgo.It("sums the samples of a window", func() {
+		good, total, err := SumApplicationSLOSamples(DefaultContext, applicationID, "healthy", now.Add(-3*time.Hour))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(good).To(Equal(float64(5)))
+		Expect(total).To(Equal(float64(8)))
+
+		good, total, err = SumApplicationSLOSamples(DefaultContext, applicationID, "other", now.Add(-3*time.Hour))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(good).To(BeZero())
+		Expect(total).To(BeZero())
+	})
+
+	ginkgo.It("deletes samples outside the window", func() {
+		deleted, err := DeleteApplicationSLOSamplesBefore(DefaultContext, applicationID, "healthy", now.Add(-24*time.Hour))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(deleted).To(Equal(int64(1)))
+
+		_, total, err := SumApplicationSLOSamples(DefaultContext, applicationID, "healthy", now.Add(-72*time.Hour))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(total).To(Equal(float64(8)))
+	})
+
+	ginkgo.It("keeps the last evaluation on the samples", func() {
+		Expect(SaveApplicationSLOStatus(DefaultContext, applicationID, api.ApplicationSLOStatus{
+			Name: "healthy", Burn: api.SLOBurnSlow, EvaluatedAt: now.Add(-10 * time.Minute),
+		})).To(Succeed())
+		Expect(SaveApplicationSLOStatus(DefaultContext, applicationID, api.ApplicationSLOStatus{
+			Name: "healthy", Burn: api.SLOBurnFast, EvaluatedAt: now,
+		})).To(Succeed())
+		Expect(SaveApplicationSLOStatus(DefaultContext, applicationID, api.ApplicationSLOStatus{
+			Name: "latency", EvaluatedAt: now,
+		})).To(Succeed())
+
+		// The status of a sampled SLI goes on its sample
+		_, total, err := SumApplicationSLOSamples(DefaultContext, applicationID, "healthy", now.Add(-3*time.Hour))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(total).To(Equal(float64(8)))
+
+		statuses, err := GetApplicationSLOStatuses(DefaultContext, applicationID)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(statuses).To(HaveLen(2))
+		Expect(statuses["healthy"].Burn).To(Equal(api.SLOBurnFast))
+		Expect(statuses["latency"].Burn).To(BeEmpty())
+	})
+
+	ginkgo.It("deletes the samples of removed objectives", func() {
+		deleted, err := DeleteApplicationSLOSamplesExcept(DefaultContext, applicationID, []string{"healthy"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(deleted).To(Equal(int64(1)))
+
+		statuses, err := GetApplicationSLOStatuses(DefaultContext, applicationID)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(statuses).To(HaveKey("healthy"))
+		Expect(statuses).ToNot(HaveKey("latency"))
+	})
+})
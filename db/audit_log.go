@@ -0,0 +1,152 @@
+package db
+
+import (
+	"time"
+
+	"github.com/flanksource/duty/context"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	AuditDecisionAllow           = "allow"
+	AuditDecisionDeny            = "deny"
+	AuditDecisionUnauthenticated = "unauthenticated"
+)
+
+// AuditLog is a row of the audit_log table. Each entry carries the hash of
+// the entry before it, so that editing or deleting an entry breaks the chain.
+type AuditLog struct {
+	ID        int64      `gorm:"primaryKey;autoIncrement" json:"id"`
+	CreatedAt time.Time  `json:"created_at"`
+	ActorID   *uuid.UUID `json:"actor_id,omitempty"`
+	// TokenID is the access token the actor authenticated with.
+	TokenID *uuid.UUID `json:"token_id,omitempty"`
+	// Impersonation is the scope the actor impersonated, as sent in the
+	// X-Flanksource-Scope header.
+	Impersonation string `gorm:"default:NULL" json:"impersonation,omitempty"`
+	// BreakGlassID is the break-glass activation the actor acted under.
+	BreakGlassID *uuid.UUID `json:"break_glass_id,omitempty"`
+	Method       string     `json:"method"`
+	Route        string     `json:"route"`
+	Path         string     `json:"path"`
+	Object       string     `gorm:"default:NULL" json:"object,omitempty"`
+	Action       string     `gorm:"default:NULL" json:"action,omitempty"`
+	// Decision is the RBAC decision, empty when the route isn't authorized by RBAC.
+	Decision string `gorm:"default:NULL" json:"decision,omitempty"`
+	Status   int    `json:"status"`
+	RemoteIP string `gorm:"column:remote_ip" json:"remote_ip,omitempty"`
+	// BodyHash is the sha256 of the request body.
+	BodyHash string `json:"body_hash,omitempty"`
+	PrevHash string `json:"prev_hash"`
+	Hash     string `json:"hash"`
+}
+
+func (AuditLog) TableName() string { return "audit_log" }
+
+// auditLogLock serializes the appends to the chain across instances.
+const auditLogLock = "SELECT pg_advisory_xact_lock(hashtext('audit_log'))"
+
+// AppendAuditLog inserts the entries, in order, at the end of the chain.
+// hash is called with each entry and the hash of the entry before it.
+func AppendAuditLog(ctx context.Context, entries []*AuditLog, hash func(entry AuditLog, prevHash string) string) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	err := ctx.DB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(auditLogLock).Error; err != nil {
+			return err
+		}
+
+		var prev []string
+		if err := tx.Model(&AuditLog{}).Order("id DESC").Limit(1).Pluck("hash", &prev).Error; err != nil {
+			return err
+		}
+
+		var prevHash string
+		if len(prev) > 0 {
+			prevHash = prev[0]
+		}
+		for _, entry := range entries {
+			entry.PrevHash = prevHash
+			entry.Hash = hash(*entry, prevHash)
+			prevHash = entry.Hash
+		}
+		return tx.Create(entries).Error
+	})
+	if err != nil {
+		for _, entry := range entries {
+			entry.ID, entry.PrevHash, entry.Hash = 0, "", ""
+		}
+		return ctx.Oops().Wrapf(err, "failed to append to the audit log")
+	}
+	return nil
+}
+
+type AuditLogFilter struct {
+	ActorID *uuid.UUID
+	TokenID *uuid.UUID
+	// BreakGlass limits the entries to those made under break-glass access.
+	BreakGlass bool
+	Object     string
+	Action     string
+	Decision   string
+	Route      string
+	Since      *time.Time
+	Until      *time.Time
+	Limit      int
+}
+
+// SearchAuditLog returns the entries matching the filter, latest first.
+func SearchAuditLog(ctx context.Context, filter AuditLogFilter) ([]AuditLog, error) {
+	q := ctx.DB().Order("id DESC")
+	if filter.ActorID != nil {
+		q = q.Where("actor_id = ?", *filter.ActorID)
+	}
+	if filter.TokenID != nil {
+		q = q.Where("token_id = ?", *filter.TokenID)
+	}
+	if filter.BreakGlass {
+		q = q.Where("break_glass_id IS NOT NULL")
+	}
+	if filter.Object != "" {
+		q = q.Where("object = ?", filter.Object)
+	}
+	if filter.Action != "" {
+		q = q.Where("action = ?", filter.Action)
+	}
+	if filter.Decision != "" {
+		q = q.Where("decision = ?", filter.Decision)
+	}
+	if filter.Route != "" {
+		q = q.Where("route = ?", filter.Route)
+	}
+	if filter.Since != nil {
+		q = q.Where("created_at >= ?", *filter.Since)
+	}
+	if filter.Until != nil {
+		q = q.Where("created_at < ?", *filter.Until)
+	}
+	if filter.Limit > 0 {
+		q = q.Limit(filter.Limit)
+	}
+
+	var entries []AuditLog
+	if err := q.Find(&entries).Error; err != nil {
+		return nil, ctx.Oops().Wrapf(err, "failed to search the audit log")
+	}
+	return entries, nil
+}
+
+// WalkAuditLog calls fn with the entries in the order they were appended.
+func WalkAuditLog(ctx context.Context, batchSize int, fn func([]AuditLog) error) error {
+	var batch []AuditLog
+	err := ctx.DB().FindInBatches(&batch, batchSize, func(_ *gorm.DB, _ int) error {
+		return fn(batch)
+	}).Error
+	if err != nil {
+		return ctx.Oops().Wrapf(err, "failed to read the audit log")
+	}
+	return nil
+}
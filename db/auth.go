@@ -1,6 +1,7 @@
 package db
 
 import (
+	"errors"
 	"fmt"
 	"strings"
 	"time"
@@ -10,7 +11,11 @@ import (
 	"github.com/flanksource/duty/context"
 	"github.com/flanksource/duty/models"
 	"github.com/flanksource/duty/secret"
+	dutyTypes "github.com/flanksource/duty/types"
 	"github.com/google/uuid"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/lib/pq"
 	"github.com/samber/lo"
 	"go.opentelemetry.io/otel/trace"
 
@@ -49,8 +54,9 @@ func CreateAccessToken(ctx context.Context, personID uuid.UUID, name string, exp
 }
 
 type CreateAccessTokenForPersonResult struct {
-	Token  secret.Sensitive
-	Person *models.Person
+	Token       secret.Sensitive
+	AccessToken *models.AccessToken
+	Person      *models.Person
 }
 
 func CreateAccessTokenForPerson(ctx context.Context, user *models.Person, tokenName string, expiry time.Duration, autoRenew bool) (CreateAccessTokenForPersonResult, error) {
@@ -74,17 +80,62 @@ func CreateAccessTokenForPerson(ctx context.Context, user *models.Person, tokenN
 			expiry = properties.Duration(90*24*time.Hour, "access_token.default_expiry")
 		}
 
-		token, _, err := CreateAccessToken(ctx, person.ID, tokenName, &expiry, new(user.ID), autoRenew)
+		token, accessToken, err := CreateAccessToken(ctx, person.ID, tokenName, &expiry, new(user.ID), autoRenew)
 		if err != nil {
 			return ctx.Oops().Wrapf(err, "failed to create access token %q", tokenName)
 		}
 
 		output = CreateAccessTokenForPersonResult{
-			Token:  token,
-			Person: person,
+			Token:       token,
+			AccessToken: accessToken,
+			Person:      person,
 		}
 		return nil
 	})
 
 	return output, err
 }
+
+// AccessTokenScope is a row of the access_token_scopes table: the restrictions
+// of an access token on top of the permissions of its creator.
+type AccessTokenScope struct {
+	// PersonID is the person of the access token.
+	PersonID      uuid.UUID `gorm:"primaryKey" json:"person_id"`
+	AccessTokenID uuid.UUID `json:"access_token_id"`
+	// Permissions are the []policy.Permission (object, action) the token is
+	// limited to. Empty means unrestricted.
+	Permissions dutyTypes.JSON `gorm:"default:NULL" json:"permissions,omitempty"`
+	// ScopeID is the Scope the rows the token reads are limited to.
+	ScopeID   *uuid.UUID     `json:"scope_id,omitempty"`
+	CIDRs     pq.StringArray `gorm:"column:cidrs;type:text[]" json:"cidrs,omitempty"`
+	CreatedAt time.Time      `gorm:"<-:create" json:"created_at"`
+}
+
+func (AccessTokenScope) TableName() string { return "access_token_scopes" }
+
+func SaveAccessTokenScope(ctx context.Context, scope *AccessTokenScope) error {
+	if err := ctx.DB().Save(scope).Error; err != nil {
+		return ctx.Oops().Wrapf(err, "failed to save scope of access token %s", scope.AccessTokenID)
+	}
+	return nil
+}
+
+// GetAccessTokenScope returns the scope of the access token of the person, or
+// nil when the person isn't a scoped access token.
+//
+// A database without the access_token_scopes table has no scoped tokens, so
+// every token is treated as unscoped rather than denied.
+func GetAccessTokenScope(ctx context.Context, personID uuid.UUID) (*AccessTokenScope, error) {
+	var scopes []AccessTokenScope
+	if err := ctx.DB().Where("person_id = ?", personID).Limit(1).Find(&scopes).Error; err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UndefinedTable {
+			return nil, nil
+		}
+		return nil, ctx.Oops().Wrapf(err, "failed to get access token scope of %s", personID)
+	}
+	if len(scopes) == 0 {
+		return nil, nil
+	}
+	return &scopes[0], nil
+}
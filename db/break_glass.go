@@ -0,0 +1,163 @@
+package db
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/flanksource/duty"
+	"github.com/flanksource/duty/context"
+	"github.com/flanksource/duty/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	breakGlassEmailPrefix = "break-glass-"
+	breakGlassEmailDomain = "@local"
+)
+
+// BreakGlassEmail is the email of the person created for an activation.
+func BreakGlassEmail(suffix string) string {
+	return fmt.Sprintf("%s%s%s", breakGlassEmailPrefix, suffix, breakGlassEmailDomain)
+}
+
+// IsBreakGlassPerson reports whether the person was created for an
+// activation, telling them apart without a lookup.
+func IsBreakGlassPerson(person models.Person) bool {
+	return person.Type == PersonTypeAccessToken &&
+		strings.HasPrefix(person.Email, breakGlassEmailPrefix) &&
+		strings.HasSuffix(person.Email, breakGlassEmailDomain)
+}
+
+// BreakGlassCredential is a row of the break_glass_credentials table: the hash
+// of the sealed emergency credential. Only the latest unrevoked one is valid.
+type BreakGlassCredential struct {
+	ID   uuid.UUID `gorm:"primaryKey;default:gen_random_uuid()" json:"id"`
+	Hash string    `json:"-"`
+	// Shares is the number of shares the credential was split into and
+	// Threshold the number of them needed to activate it.
+	Shares    int        `json:"shares"`
+	Threshold int        `json:"threshold"`
+	SealedBy  string     `json:"sealed_by"`
+	CreatedAt time.Time  `gorm:"<-:create" json:"created_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+func (BreakGlassCredential) TableName() string { return "break_glass_credentials" }
+
+// BreakGlassActivation is a row of the break_glass_activations table: a use of
+// the credential and the access token it was issued.
+type BreakGlassActivation struct {
+	ID           uuid.UUID `gorm:"primaryKey;default:gen_random_uuid()" json:"id"`
+	CredentialID uuid.UUID `json:"credential_id"`
+	// PersonID is the admin the access token acts as.
+	PersonID      uuid.UUID  `json:"person_id"`
+	AccessTokenID uuid.UUID  `json:"access_token_id"`
+	Operator      string     `json:"operator"`
+	Reason        string     `json:"reason"`
+	ExpiresAt     time.Time  `json:"expires_at"`
+	EndedAt       *time.Time `json:"ended_at,omitempty"`
+	EndedBy       *uuid.UUID `json:"ended_by,omitempty"`
+	CreatedAt     time.Time  `gorm:"<-:create" json:"created_at"`
+}
+
+func (BreakGlassActivation) TableName() string { return "break_glass_activations" }
+
+func (a BreakGlassActivation) Active() bool {
+	return a.EndedAt == nil && time.Now().Before(a.ExpiresAt)
+}
+
+// SealBreakGlassCredential saves a new credential, revoking the previous one.
+func SealBreakGlassCredential(ctx context.Context, credential *BreakGlassCredential) error {
+	err := ctx.DB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&BreakGlassCredential{}).Where("revoked_at IS NULL").Update("revoked_at", duty.Now()).Error; err != nil {
+			return err
+		}
+		return tx.Create(credential).Error
+	})
+	if err != nil {
+		return ctx.Oops().Wrapf(err, "failed to seal break-glass credential")
+	}
+	return nil
+}
+
+// GetBreakGlassCredential returns the valid credential, or nil if none is sealed.
+func GetBreakGlassCredential(ctx context.Context) (*BreakGlassCredential, error) {
+	var credentials []BreakGlassCredential
+	if err := ctx.DB().Where("revoked_at IS NULL").Order("created_at DESC").Limit(1).Find(&credentials).Error; err != nil {
+		return nil, ctx.Oops().Wrapf(err, "failed to get break-glass credential")
+	}
+	if len(credentials) == 0 {
+		return nil, nil
+	}
+	return &credentials[0], nil
+}
+
+func CreateBreakGlassActivation(ctx context.Context, activation *BreakGlassActivation) error {
+	if err := ctx.DB().Create(activation).Error; err != nil {
+		return ctx.Oops().Wrapf(err, "failed to save break-glass activation")
+	}
+	return nil
+}
+
+// ListBreakGlassActivations returns the activations, latest first.
+func ListBreakGlassActivations(ctx context.Context, activeOnly bool) ([]BreakGlassActivation, error) {
+	q := ctx.DB().Order("created_at DESC")
+	if activeOnly {
+		q = q.Where("ended_at IS NULL AND expires_at > NOW()")
+	}
+
+	var activations []BreakGlassActivation
+	if err := q.Find(&activations).Error; err != nil {
+		return nil, ctx.Oops().Wrapf(err, "failed to list break-glass activations")
+	}
+	return activations, nil
+}
+
+// GetBreakGlassActivation returns the activation with the id, or nil if there's none.
+func GetBreakGlassActivation(ctx context.Context, id uuid.UUID) (*BreakGlassActivation, error) {
+	var activations []BreakGlassActivation
+	if err := ctx.DB().Where("id = ?", id).Limit(1).Find(&activations).Error; err != nil {
+		return nil, ctx.Oops().Wrapf(err, "failed to get break-glass activation %s", id)
+	}
+	if len(activations) == 0 {
+		return nil, nil
+	}
+	return &activations[0], nil
+}
+
+// GetBreakGlassActivationOfPerson returns the active activation acting as the
+// person, or nil if there's none.
+func GetBreakGlassActivationOfPerson(ctx context.Context, personID uuid.UUID) (*BreakGlassActivation, error) {
+	var activations []BreakGlassActivation
+	if err := ctx.DB().Where("person_id = ? AND ended_at IS NULL AND expires_at > NOW()", personID).Limit(1).Find(&activations).Error; err != nil {
+		return nil, ctx.Oops().Wrapf(err, "failed to get break-glass activation of %s", personID)
+	}
+	if len(activations) == 0 {
+		return nil, nil
+	}
+	return &activations[0], nil
+}
+
+// GetExpiredBreakGlassActivations returns the activations past their expiry
+// that haven't been ended yet.
+func GetExpiredBreakGlassActivations(ctx context.Context) ([]BreakGlassActivation, error) {
+	var activations []BreakGlassActivation
+	if err := ctx.DB().Where("ended_at IS NULL AND expires_at <= NOW()").Find(&activations).Error; err != nil {
+		return nil, ctx.Oops().Wrapf(err, "failed to get expired break-glass activations")
+	}
+	return activations, nil
+}
+
+// EndBreakGlassActivation marks the activation ended. It returns false when
+// the activation had already ended.
+func EndBreakGlassActivation(ctx context.Context, id uuid.UUID, by *uuid.UUID) (bool, error) {
+	result := ctx.DB().Model(&BreakGlassActivation{}).
+		Where("id = ? AND ended_at IS NULL", id).
+		Updates(map[string]any{"ended_at": duty.Now(), "ended_by": by})
+	if result.Error != nil {
+		return false, ctx.Oops().Wrapf(result.Error, "failed to end break-glass activation %s", id)
+	}
+	return result.RowsAffected > 0, nil
+}
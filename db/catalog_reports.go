@@ -0,0 +1,78 @@
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/flanksource/duty/context"
+	dutyTypes "github.com/flanksource/duty/types"
+	"github.com/google/uuid"
+)
+
+// CatalogReportRecord is a row of the catalog_reports table: a generated
+// catalog report kept so that later reports can be diffed against it.
+type CatalogReportRecord struct {
+	ID    uuid.UUID `gorm:"primaryKey;default:gen_random_uuid()"`
+	Title string
+	// SelectionKey identifies the roots and options the report was built for
+	// so that reports of the same selection can be found by date.
+	SelectionKey string
+	// Hash is the sha256 of the report.
+	Hash        string
+	ItemCount   int
+	Report      dutyTypes.JSON
+	CreatedBy   *uuid.UUID
+	GeneratedAt time.Time
+	CreatedAt   time.Time `gorm:"<-:create"`
+}
+
+func (CatalogReportRecord) TableName() string { return "catalog_reports" }
+
+// SaveCatalogReport inserts a new report.
+func SaveCatalogReport(ctx context.Context, record *CatalogReportRecord) error {
+	if record.CreatedAt.IsZero() {
+		record.CreatedAt = time.Now()
+	}
+
+	if err := ctx.DB().Create(record).Error; err != nil {
+		return fmt.Errorf("failed to save catalog report: %w", err)
+	}
+
+	return nil
+}
+
+// GetCatalogReport returns the report with the given id, or nil if there's none.
+func GetCatalogReport(ctx context.Context, id uuid.UUID) (*CatalogReportRecord, error) {
+	var records []CatalogReportRecord
+	if err := ctx.DB().Where("id = ?", id).Limit(1).Find(&records).Error; err != nil {
+		return nil, fmt.Errorf("failed to get catalog report: %w", err)
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+	return &records[0], nil
+}
+
+// GetCatalogReportAsOf returns the latest report of the selection generated
+// at or before asOf, or nil if there's none.
+func GetCatalogReportAsOf(ctx context.Context, selectionKey string, asOf time.Time) (*CatalogReportRecord, error) {
+	var records []CatalogReportRecord
+	if err := ctx.DB().Where("selection_key = ? AND generated_at <= ?", selectionKey, asOf).
+		Order("generated_at DESC").Limit(1).Find(&records).Error; err != nil {
+		return nil, fmt.Errorf("failed to get catalog report: %w", err)
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+	return &records[0], nil
+}
+
+// DeleteCatalogReportsBefore deletes the reports, across all selections,
+// that were generated before the given time.
+func DeleteCatalogReportsBefore(ctx context.Context, before time.Time) (int64, error) {
+	tx := ctx.DB().Where("generated_at < ?", before).Delete(&CatalogReportRecord{})
+	if tx.Error != nil {
+		return 0, fmt.Errorf("failed to delete expired catalog reports: %w", tx.Error)
+	}
+	return tx.RowsAffected, nil
+}
@@ -0,0 +1,67 @@
+package db
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	ginkgo "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = ginkgo.Describe("Catalog reports", ginkgo.Ordered, func() {
+	selectionKey := uuid.NewString()
+	now := time.Now().Truncate(time.Second)
+	var ids []uuid.UUID
+
+	ginkgo.BeforeAll(func() {
+		for i, age := range []time.Duration{90 * 24 * time.Hour, 30 * 24 * time.Hour} {
+			record := &CatalogReportRecord{
+				SelectionKey: selectionKey,
+				Hash:         uuid.NewString(),
+				ItemCount:    i,
+				Report:       []byte(`{}`),
+				GeneratedAt:  now.Add(-age),
+			}
+			Expect(SaveCatalogReport(DefaultContext, record)).To(Succeed())
+			ids = append(ids, record.ID)
+		}
+	})
+
+	ginkgo.It("returns a report by id", func() {
+		record, err := GetCatalogReport(DefaultContext, ids[0])
+		Expect(err).ToNot(HaveOccurred())
+		Expect(record.ItemCount).To(Equal(0))
+
+		record, err = GetCatalogReport(DefaultContext, uuid.New())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(record).To(BeNil())
+	})
+
+	ginkgo.It("returns the latest report of the selection as of a time", func() {
+		record, err := GetCatalogReportAsOf(DefaultContext, selectionKey, now)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(record.ID).To(Equal(ids[1]))
+
+		record, err = GetCatalogReportAsOf(DefaultContext, selectionKey, now.Add(-60*24*time.Hour))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(record.ID).To(Equal(ids[0]))
+
+		record, err = GetCatalogReportAsOf(DefaultContext, "other", now)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(record).To(BeNil())
+	})
+
+	ginkgo.It("deletes expired reports", func() {
+		deleted, err := DeleteCatalogReportsBefore(DefaultContext, now.Add(-60*24*time.Hour))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(deleted).To(BeNumerically(">=", 1))
+
+		record, err := GetCatalogReport(DefaultContext, ids[0])
+		Expect(err).ToNot(HaveOccurred())
+		Expect(record).To(BeNil())
+
+		record, err = GetCatalogReport(DefaultContext, ids[1])
+		Expect(err).ToNot(HaveOccurred())
+		Expect(record).ToNot(BeNil())
+	})
+})
@@ -43,6 +43,15 @@ func GetUserByID(ctx context.Context, id string) (models.Person, error) {
 	return user, err
 }
 
+// IsPersonDeleted returns whether the person has been soft deleted.
+func IsPersonDeleted(ctx context.Context, id uuid.UUID) (bool, error) {
+	var count int64
+	if err := ctx.DB().Table("people").Where("id = ? AND deleted_at IS NOT NULL", id).Count(&count).Error; err != nil {
+		return false, fmt.Errorf("failed to check if person %s is deleted: %w", id, err)
+	}
+	return count > 0, nil
+}
+
 func GetTeamsForUser(ctx context.Context, id string) ([]models.Team, error) {
 	var teams []models.Team
 	err := ctx.DB().Raw("SELECT teams.* FROM teams LEFT JOIN team_members ON teams.id = team_members.team_id WHERE team_members.person_id = ?", id).Scan(&teams).Error
@@ -126,6 +135,10 @@ func GetPersonType(ctx context.Context, id string) (string, error) {
 
 func DeleteAccessToken(ctx context.Context, id string, personID string) error {
 	return ctx.DB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("access_token_id = ?", id).Delete(&AccessTokenScope{}).Error; err != nil {
+			return ctx.Oops().Wrapf(err, "failed to delete scope of access token %s", id)
+		}
+
 		// Delete the access token (hard delete)
 		if err := tx.Where("id = ?", id).Delete(&models.AccessToken{}).Error; err != nil {
 			return ctx.Oops().Wrapf(err, "failed to hard delete access token %s", id)
@@ -156,6 +169,28 @@ func AddPersonToTeam(ctx context.Context, personID uuid.UUID, teamID uuid.UUID)
 		Error
 }
 
+// SyncPersonTeams makes the person a member of exactly the given teams among
+// the memberships of the given source. Memberships from other sources are kept.
+func SyncPersonTeams(ctx context.Context, personID uuid.UUID, teamIDs []uuid.UUID, source string) error {
+	return ctx.DB().Transaction(func(tx *gorm.DB) error {
+		stale := tx.Where("person_id = ? AND source = ?", personID, source)
+		if len(teamIDs) > 0 {
+			stale = stale.Where("team_id NOT IN ?", teamIDs)
+		}
+		if err := stale.Delete(&dbModels.TeamMember{}).Error; err != nil {
+			return ctx.Oops().Wrapf(err, "failed to remove person %s from teams", personID)
+		}
+
+		for _, teamID := range teamIDs {
+			if err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+				Create(&dbModels.TeamMember{PersonID: personID, TeamID: teamID, Source: source}).Error; err != nil {
+				return ctx.Oops().Wrapf(err, "failed to add person %s to team %s", personID, teamID)
+			}
+		}
+		return nil
+	})
+}
+
 func UpdateLastLogin(ctx context.Context, id string) error {
 	return ctx.DB().Table("people").Where("id = ?", id).UpdateColumn("last_login", "NOW()").Error
 }
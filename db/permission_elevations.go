@@ -0,0 +1,143 @@
+package db
+
+import (
+	"time"
+
+	"github.com/flanksource/duty"
+	"github.com/flanksource/duty/context"
+	dutyTypes "github.com/flanksource/duty/types"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const (
+	ElevationStatusPending  = "pending"
+	ElevationStatusActive   = "active"
+	ElevationStatusRejected = "rejected"
+	ElevationStatusExpired  = "expired"
+	ElevationStatusRevoked  = "revoked"
+)
+
+// PermissionElevation is a row of the permission_elevations table: a request
+// for temporary access and, once approved, the grant it made.
+type PermissionElevation struct {
+	ID       uuid.UUID `gorm:"primaryKey;default:gen_random_uuid()" json:"id"`
+	PersonID uuid.UUID `json:"person_id"`
+	// Role is granted to the person, or else Actions on Object.
+	Role    string         `gorm:"default:NULL" json:"role,omitempty"`
+	Actions pq.StringArray `gorm:"type:text[]" json:"actions,omitempty"`
+	// Object is the v1.PermissionObject the actions are granted on.
+	Object   dutyTypes.JSON `gorm:"default:NULL" json:"object,omitempty"`
+	Reason   string         `json:"reason"`
+	Duration string         `json:"duration"`
+	Status   string         `json:"status"`
+	// Approval is the v1.PlaybookApproval in effect when the request was made.
+	Approval dutyTypes.JSON `json:"approval,omitempty"`
+	// Approvals are the []ElevationApproval given so far.
+	Approvals dutyTypes.JSON `gorm:"default:NULL" json:"approvals,omitempty"`
+	// PermissionID is the permission granting the actions while active.
+	PermissionID *uuid.UUID `json:"permission_id,omitempty"`
+	// ConfigIDs are the config items the grant covered when it was approved.
+	ConfigIDs  pq.StringArray `gorm:"type:uuid[]" json:"config_ids,omitempty"`
+	ApprovedAt *time.Time     `json:"approved_at,omitempty"`
+	ExpiresAt  *time.Time     `json:"expires_at,omitempty"`
+	EndedAt    *time.Time     `json:"ended_at,omitempty"`
+	EndedBy    *uuid.UUID     `json:"ended_by,omitempty"`
+	CreatedAt  time.Time      `gorm:"<-:create" json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+}
+
+func (PermissionElevation) TableName() string { return "permission_elevations" }
+
+// ElevationApproval is an approval of an elevation, given by a person on their
+// own behalf or on behalf of one of their teams.
+type ElevationApproval struct {
+	ApprovedBy uuid.UUID  `json:"approved_by"`
+	PersonID   *uuid.UUID `json:"person_id,omitempty"`
+	TeamID     *uuid.UUID `json:"team_id,omitempty"`
+	ApprovedAt time.Time  `json:"approved_at"`
+}
+
+func CreatePermissionElevation(ctx context.Context, elevation *PermissionElevation) error {
+	if err := ctx.DB().Create(elevation).Error; err != nil {
+		return ctx.Oops().Wrapf(err, "failed to create permission elevation")
+	}
+	return nil
+}
+
+// GetPermissionElevation returns the elevation with the id, or nil if there's none.
+func GetPermissionElevation(ctx context.Context, id uuid.UUID) (*PermissionElevation, error) {
+	var elevations []PermissionElevation
+	if err := ctx.DB().Where("id = ?", id).Limit(1).Find(&elevations).Error; err != nil {
+		return nil, ctx.Oops().Wrapf(err, "failed to get permission elevation %s", id)
+	}
+	if len(elevations) == 0 {
+		return nil, nil
+	}
+	return &elevations[0], nil
+}
+
+// ListPermissionElevations returns the elevations, latest first, optionally
+// of a person and in one of the statuses.
+func ListPermissionElevations(ctx context.Context, personID *uuid.UUID, statuses ...string) ([]PermissionElevation, error) {
+	q := ctx.DB().Order("created_at DESC")
+	if personID != nil {
+		q = q.Where("person_id = ?", *personID)
+	}
+	if len(statuses) > 0 {
+		q = q.Where("status IN ?", statuses)
+	}
+
+	var elevations []PermissionElevation
+	if err := q.Find(&elevations).Error; err != nil {
+		return nil, ctx.Oops().Wrapf(err, "failed to list permission elevations")
+	}
+	return elevations, nil
+}
+
+// UpdatePermissionElevation updates the columns of the elevation if it's still
+// in the expected status, so that concurrent approvals and expiry don't race.
+// It reports whether the elevation was updated.
+func UpdatePermissionElevation(ctx context.Context, id uuid.UUID, status string, updates map[string]any) (bool, error) {
+	updates["updated_at"] = duty.Now()
+	tx := ctx.DB().Model(&PermissionElevation{}).Where("id = ? AND status = ?", id, status).Updates(updates)
+	if tx.Error != nil {
+		return false, ctx.Oops().Wrapf(tx.Error, "failed to update permission elevation %s", id)
+	}
+	return tx.RowsAffected > 0, nil
+}
+
+// UpdatePendingPermissionElevation updates the columns of a pending elevation
+// if its approvals are still the given ones, so that concurrent approvers
+// don't overwrite each other's approval. It reports whether the elevation was
+// updated.
+func UpdatePendingPermissionElevation(ctx context.Context, id uuid.UUID, approvals dutyTypes.JSON, updates map[string]any) (bool, error) {
+	previous := "[]"
+	if len(approvals) > 0 {
+		previous = string(approvals)
+	}
+
+	updates["updated_at"] = duty.Now()
+	tx := ctx.DB().Model(&PermissionElevation{}).
+		Where("id = ? AND status = ?", id, ElevationStatusPending).
+		Where("COALESCE(approvals, '[]'::jsonb) = ?::jsonb", previous).
+		Updates(updates)
+	if tx.Error != nil {
+		return false, ctx.Oops().Wrapf(tx.Error, "failed to update permission elevation %s", id)
+	}
+	return tx.RowsAffected > 0, nil
+}
+
+// GetDuePermissionElevations returns the active elevations past their expiry
+// and the pending ones requested before the given time.
+func GetDuePermissionElevations(ctx context.Context, pendingBefore time.Time) ([]PermissionElevation, error) {
+	var elevations []PermissionElevation
+	if err := ctx.DB().
+		Where("status = ? AND expires_at <= NOW()", ElevationStatusActive).
+		Or("status = ? AND created_at < ?", ElevationStatusPending, pendingBefore).
+		Order("created_at").
+		Find(&elevations).Error; err != nil {
+		return nil, ctx.Oops().Wrapf(err, "failed to get due permission elevations")
+	}
+	return elevations, nil
+}
@@ -0,0 +1,228 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/flanksource/duty/api"
+	"github.com/flanksource/duty/context"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	// PluginKVMaxKeyLength is the longest key a plugin may store.
+	PluginKVMaxKeyLength = 512
+
+	defaultPluginKVMaxValueSize = 256 * 1024
+	defaultPluginKVMaxTotalSize = 16 * 1024 * 1024
+	defaultPluginKVMaxKeys      = 10000
+)
+
+// ErrPluginKVQuotaExceeded is returned when a write would take a plugin over
+// its key or storage quota.
+var ErrPluginKVQuotaExceeded = errors.New("plugin kv quota exceeded")
+
+// PluginKV is a row of the plugin_kv table: durable state owned by a plugin.
+type PluginKV struct {
+	PluginID uuid.UUID `gorm:"primaryKey"`
+	// ConfigID is uuid.Nil for plugin wide keys.
+	ConfigID  uuid.UUID `gorm:"primaryKey"`
+	Key       string    `gorm:"primaryKey"`
+	Value     []byte
+	Version   int64
+	ExpiresAt *time.Time
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (PluginKV) TableName() string { return "plugin_kv" }
+
+// PluginKVScope identifies the keyspace of a plugin, optionally narrowed to a
+// single config item.
+type PluginKVScope struct {
+	PluginID uuid.UUID
+	ConfigID uuid.UUID
+}
+
+const pluginKVLive = "(expires_at IS NULL OR expires_at > NOW())"
+
+// GetPluginKV returns the live entry for key, or nil if it doesn't exist or has expired.
+func GetPluginKV(ctx context.Context, scope PluginKVScope, key string) (*PluginKV, error) {
+	return getPluginKV(ctx.DB(), scope, key)
+}
+
+func getPluginKV(tx *gorm.DB, scope PluginKVScope, key string) (*PluginKV, error) {
+	var rows []PluginKV
+	if err := tx.Where("plugin_id = ? AND config_id = ? AND key = ?", scope.PluginID, scope.ConfigID, key).
+		Where(pluginKVLive).Limit(1).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+	return &rows[0], nil
+}
+
+// ListPluginKV returns the live entries whose key starts with prefix, ordered by key.
+func ListPluginKV(ctx context.Context, scope PluginKVScope, prefix string, limit int, keysOnly bool) ([]PluginKV, error) {
+	q := ctx.DB().Where("plugin_id = ? AND config_id = ?", scope.PluginID, scope.ConfigID).Where(pluginKVLive)
+	if prefix != "" {
+		q = q.Where("starts_with(key, ?)", prefix)
+	}
+	if keysOnly {
+		q = q.Omit("value")
+	}
+	if limit > 0 {
+		q = q.Limit(limit)
+	}
+
+	var rows []PluginKV
+	if err := q.Order("key").Find(&rows).Error; err != nil {
+		return nil, ctx.Oops().Wrapf(err, "list plugin kv")
+	}
+	return rows, nil
+}
+
+// PutPluginKV creates or overwrites key. A ttl of 0 never expires.
+func PutPluginKV(ctx context.Context, scope PluginKVScope, key string, value []byte, ttl time.Duration) (*PluginKV, error) {
+	_, entry, err := writePluginKV(ctx, scope, key, value, ttl, nil)
+	return entry, err
+}
+
+// CompareAndSwapPluginKV writes key only when its current version matches
+// expectedVersion; 0 means the key must not exist. It returns the entry as
+// stored after the call, which is nil when the key does not exist.
+func CompareAndSwapPluginKV(ctx context.Context, scope PluginKVScope, key string, expectedVersion int64, value []byte, ttl time.Duration) (bool, *PluginKV, error) {
+	if expectedVersion < 0 {
+		return false, nil, ctx.Oops().Code(api.EINVALID).Errorf("expected version must not be negative")
+	}
+	return writePluginKV(ctx, scope, key, value, ttl, &expectedVersion)
+}
+
+// DeletePluginKV removes key. Deleting a missing key is not an error.
+func DeletePluginKV(ctx context.Context, scope PluginKVScope, key string) error {
+	return ctx.DB().Where("plugin_id = ? AND config_id = ? AND key = ?", scope.PluginID, scope.ConfigID, key).
+		Delete(&PluginKV{}).Error
+}
+
+// DeleteAllPluginKV drops every key owned by a plugin.
+func DeleteAllPluginKV(ctx context.Context, pluginID uuid.UUID) error {
+	return ctx.DB().Where("plugin_id = ?", pluginID).Delete(&PluginKV{}).Error
+}
+
+// DeleteExpiredPluginKV purges expired entries and returns how many were removed.
+func DeleteExpiredPluginKV(ctx context.Context) (int64, error) {
+	tx := ctx.DB().Where("expires_at <= NOW()").Delete(&PluginKV{})
+	return tx.RowsAffected, tx.Error
+}
+
+func writePluginKV(ctx context.Context, scope PluginKVScope, key string, value []byte, ttl time.Duration, expectedVersion *int64) (bool, *PluginKV, error) {
+	if key == "" {
+		return false, nil, ctx.Oops().Code(api.EINVALID).Errorf("key is required")
+	} else if len(key) > PluginKVMaxKeyLength {
+		return false, nil, ctx.Oops().Code(api.EINVALID).Errorf("key exceeds %d bytes", PluginKVMaxKeyLength)
+	}
+	if ttl < 0 {
+		return false, nil, ctx.Oops().Code(api.EINVALID).Errorf("ttl must not be negative")
+	}
+
+	maxValueSize := ctx.Properties().Int("plugins.kv.max_value_size", defaultPluginKVMaxValueSize)
+	if len(value) > maxValueSize {
+		return false, nil, fmt.Errorf("%w: value of %d bytes exceeds the %d byte limit", ErrPluginKVQuotaExceeded, len(value), maxValueSize)
+	}
+
+	args := map[string]any{
+		"plugin_id": scope.PluginID,
+		"config_id": scope.ConfigID,
+		"key":       key,
+		"value":     value,
+		"ttl":       int64(ttl / time.Second),
+	}
+
+	var (
+		swapped bool
+		entry   *PluginKV
+	)
+	err := ctx.DB().Transaction(func(tx *gorm.DB) error {
+		// Serialize writers of the same plugin so the quota check can't be raced.
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", "plugin_kv:"+scope.PluginID.String()).Error; err != nil {
+			return err
+		}
+		if err := checkPluginKVQuota(ctx, tx, scope, key, len(value)); err != nil {
+			return err
+		}
+
+		var rows []PluginKV
+		var err error
+		if expectedVersion != nil && *expectedVersion > 0 {
+			args["version"] = *expectedVersion
+			err = tx.Raw(`UPDATE plugin_kv SET value = @value, version = version + 1, updated_at = NOW(),
+				expires_at = CASE WHEN @ttl > 0 THEN NOW() + make_interval(secs => @ttl) END
+			WHERE plugin_id = @plugin_id AND config_id = @config_id AND key = @key AND version = @version
+				AND (expires_at IS NULL OR expires_at > NOW())
+			RETURNING *`, args).Scan(&rows).Error
+		} else {
+			// An expired row is treated as absent, so create-only writes may
+			// replace it. The version keeps increasing so stale CAS tokens
+			// never match the new entry.
+			onConflict := ""
+			if expectedVersion != nil {
+				onConflict = "WHERE plugin_kv.expires_at IS NOT NULL AND plugin_kv.expires_at <= NOW()"
+			}
+			err = tx.Raw(`INSERT INTO plugin_kv (plugin_id, config_id, key, value, version, expires_at, created_at, updated_at)
+			VALUES (@plugin_id, @config_id, @key, @value, 1, CASE WHEN @ttl > 0 THEN NOW() + make_interval(secs => @ttl) END, NOW(), NOW())
+			ON CONFLICT (plugin_id, config_id, key) DO UPDATE SET
+				value = EXCLUDED.value,
+				version = plugin_kv.version + 1,
+				expires_at = EXCLUDED.expires_at,
+				updated_at = NOW() `+onConflict+`
+			RETURNING *`, args).Scan(&rows).Error
+		}
+		if err != nil {
+			return err
+		}
+
+		if len(rows) > 0 {
+			swapped, entry = true, &rows[0]
+			return nil
+		}
+
+		entry, err = getPluginKV(tx, scope, key)
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, ErrPluginKVQuotaExceeded) {
+			return false, nil, err
+		}
+		return false, nil, ctx.Oops().Wrapf(err, "write plugin kv %s", key)
+	}
+	return swapped, entry, nil
+}
+
+// checkPluginKVQuota rejects a write of key that would take the plugin over
+// its key count or total storage quota. Quotas span all config items.
+func checkPluginKVQuota(ctx context.Context, tx *gorm.DB, scope PluginKVScope, key string, valueSize int) error {
+	maxKeys := ctx.Properties().Int("plugins.kv.max_keys", defaultPluginKVMaxKeys)
+	maxTotalSize := ctx.Properties().Int("plugins.kv.max_total_size", defaultPluginKVMaxTotalSize)
+
+	var usage struct {
+		Keys  int64
+		Bytes int64
+	}
+	if err := tx.Raw(`SELECT COUNT(*) AS keys, COALESCE(SUM(octet_length(key) + octet_length(value)), 0) AS bytes
+		FROM plugin_kv
+		WHERE plugin_id = ? AND NOT (config_id = ? AND key = ?) AND `+pluginKVLive,
+		scope.PluginID, scope.ConfigID, key).Scan(&usage).Error; err != nil {
+		return err
+	}
+
+	if usage.Keys+1 > int64(maxKeys) {
+		return fmt.Errorf("%w: plugin already stores %d keys", ErrPluginKVQuotaExceeded, usage.Keys)
+	}
+	if size := usage.Bytes + int64(len(key)+valueSize); size > int64(maxTotalSize) {
+		return fmt.Errorf("%w: %d bytes exceeds the %d byte limit", ErrPluginKVQuotaExceeded, size, maxTotalSize)
+	}
+	return nil
+}
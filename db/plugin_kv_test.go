@@ -0,0 +1,112 @@
+package db
+
+import (
+	"time"
+
+	"github.com/flanksource/commons/properties"
+	"github.com/google/uuid"
+	ginkgo "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = ginkgo.Describe("Plugin KV", ginkgo.Ordered, func() {
+	var scope PluginKVScope
+
+	ginkgo.BeforeEach(func() {
+		scope = PluginKVScope{PluginID: uuid.New()}
+	})
+
+	ginkgo.It("bumps the version on every write", func() {
+		entry, err := PutPluginKV(DefaultContext, scope, "cursor", []byte("1"), 0)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(entry.Version).To(Equal(int64(1)))
+		Expect(entry.ExpiresAt).To(BeNil())
+
+		entry, err = PutPluginKV(DefaultContext, scope, "cursor", []byte("2"), time.Hour)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(entry.Version).To(Equal(int64(2)))
+		Expect(entry.ExpiresAt).ToNot(BeNil())
+
+		got, err := GetPluginKV(DefaultContext, scope, "cursor")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(got.Value)).To(Equal("2"))
+	})
+
+	ginkgo.It("isolates plugins and config items", func() {
+		configScope := PluginKVScope{PluginID: scope.PluginID, ConfigID: uuid.New()}
+		_, err := PutPluginKV(DefaultContext, configScope, "token", []byte("secret"), 0)
+		Expect(err).ToNot(HaveOccurred())
+
+		for _, other := range []PluginKVScope{scope, {PluginID: uuid.New(), ConfigID: configScope.ConfigID}} {
+			got, err := GetPluginKV(DefaultContext, other, "token")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(got).To(BeNil())
+		}
+	})
+
+	ginkgo.It("hides and purges expired keys", func() {
+		_, err := PutPluginKV(DefaultContext, scope, "stale", []byte("x"), time.Hour)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(DefaultContext.DB().Model(&PluginKV{}).Where("plugin_id = ?", scope.PluginID).
+			Update("expires_at", time.Now().Add(-time.Minute)).Error).To(Succeed())
+
+		got, err := GetPluginKV(DefaultContext, scope, "stale")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(got).To(BeNil())
+
+		deleted, err := DeleteExpiredPluginKV(DefaultContext)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(deleted).To(BeNumerically(">=", 1))
+	})
+
+	ginkgo.It("lists keys by prefix", func() {
+		for _, key := range []string{"a/2", "a/1", "b/1", "a%"} {
+			_, err := PutPluginKV(DefaultContext, scope, key, []byte(key), 0)
+			Expect(err).ToNot(HaveOccurred())
+		}
+
+		entries, err := ListPluginKV(DefaultContext, scope, "a/", 0, true)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(entries).To(HaveLen(2))
+		Expect(entries[0].Key).To(Equal("a/1"))
+		Expect(entries[0].Value).To(BeEmpty())
+	})
+
+	ginkgo.It("compares and swaps", func() {
+		swapped, entry, err := CompareAndSwapPluginKV(DefaultContext, scope, "lock", 0, []byte("owner-a"), 0)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(swapped).To(BeTrue())
+
+		swapped, current, err := CompareAndSwapPluginKV(DefaultContext, scope, "lock", 0, []byte("owner-b"), 0)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(swapped).To(BeFalse())
+		Expect(string(current.Value)).To(Equal("owner-a"))
+
+		swapped, entry, err = CompareAndSwapPluginKV(DefaultContext, scope, "lock", entry.Version, []byte("owner-b"), 0)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(swapped).To(BeTrue())
+		Expect(entry.Version).To(Equal(int64(2)))
+
+		swapped, current, err = CompareAndSwapPluginKV(DefaultContext, scope, "missing", 5, []byte("x"), 0)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(swapped).To(BeFalse())
+		Expect(current).To(BeNil())
+	})
+
+	ginkgo.It("enforces quotas", func() {
+		properties.Set("plugins.kv.max_keys", "2")
+		defer properties.Set("plugins.kv.max_keys", "")
+
+		_, err := PutPluginKV(DefaultContext, scope, "one", []byte("1"), 0)
+		Expect(err).ToNot(HaveOccurred())
+		_, err = PutPluginKV(DefaultContext, scope, "two", []byte("2"), 0)
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = PutPluginKV(DefaultContext, scope, "three", []byte("3"), 0)
+		Expect(err).To(MatchError(ErrPluginKVQuotaExceeded))
+
+		// Overwriting an existing key doesn't count against the key quota.
+		_, err = PutPluginKV(DefaultContext, scope, "two", []byte("22"), 0)
+		Expect(err).ToNot(HaveOccurred())
+	})
+})
@@ -275,7 +275,7 @@ func GetRBACTemporaryAccess(ctx context.Context, configIDs []uuid.UUID, since ti
 	}
 
 	var rows []row
-	if err := ctx.DB().Raw(sql, configIDs, since).Scan(&rows).Error; err != nil {
+	if err := ctx.DB().Raw(sql, configIDs, configIDs, since).Scan(&rows).Error; err != nil {
 		return nil, ctx.Oops().Wrapf(err, "failed to query temporary access")
 	}
 
@@ -294,6 +294,40 @@ func GetRBACTemporaryAccess(ctx context.Context, configIDs []uuid.UUID, since ti
 	return results, nil
 }
 
+// GetRBACElevations returns the elevations that covered any of the config items
+// and were active at some point since the given time. Role elevations cover
+// every config item.
+func GetRBACElevations(ctx context.Context, configIDs []uuid.UUID, since time.Time) ([]api.RBACElevation, error) {
+	if len(configIDs) == 0 {
+		return nil, nil
+	}
+
+	sql := `
+		SELECT
+			config_id::text AS config_id,
+			COALESCE(p.name, '') AS "user",
+			COALESCE(p.email, '') AS email,
+			COALESCE(pe.role, array_to_string(pe.actions, ',')) AS role,
+			pe.reason,
+			pe.status,
+			pe.approved_at AS granted_at,
+			pe.expires_at,
+			pe.ended_at
+		FROM permission_elevations pe
+		CROSS JOIN LATERAL unnest(CASE WHEN pe.role IS NOT NULL THEN ARRAY[?]::uuid[] ELSE pe.config_ids END) AS config_id
+		LEFT JOIN people p ON pe.person_id = p.id
+		WHERE config_id IN (?)
+			AND pe.approved_at IS NOT NULL
+			AND COALESCE(pe.ended_at, pe.expires_at) >= ?
+		ORDER BY pe.approved_at DESC`
+
+	var results []api.RBACElevation
+	if err := ctx.DB().Raw(sql, configIDs, since).Scan(&results).Error; err != nil {
+		return nil, ctx.Oops().Wrapf(err, "failed to query permission elevations")
+	}
+	return results, nil
+}
+
 type AccessLogRow struct {
 	ConfigID  uuid.UUID `json:"config_id" gorm:"column:config_id"`
 	UserName  string    `json:"user_name" gorm:"column:user_name"`
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS plugin_kv (
+  plugin_id  UUID NOT NULL,
+  config_id  UUID NOT NULL DEFAULT '00000000-0000-0000-0000-000000000000',
+  key        TEXT NOT NULL,
+  value      BYTEA NOT NULL DEFAULT '',
+  version    BIGINT NOT NULL DEFAULT 1,
+  expires_at TIMESTAMPTZ,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  PRIMARY KEY (plugin_id, config_id, key)
+);
+
+CREATE INDEX IF NOT EXISTS plugin_kv_expires_at_idx ON plugin_kv (expires_at) WHERE expires_at IS NOT NULL;
@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS agent_outbox (
+  id              UUID PRIMARY KEY DEFAULT generate_ulid(),
+  kind            TEXT NOT NULL,
+  priority        INTEGER NOT NULL DEFAULT 0,
+  payload         BYTEA NOT NULL,
+  size            INTEGER NOT NULL DEFAULT 0,
+  source          TEXT,
+  source_ids      UUID[],
+  attempts        INTEGER NOT NULL DEFAULT 0,
+  last_error      TEXT NOT NULL DEFAULT '',
+  next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS agent_outbox_next_attempt_at_idx ON agent_outbox (next_attempt_at);
+CREATE INDEX IF NOT EXISTS agent_outbox_priority_idx ON agent_outbox (priority, created_at);
+CREATE INDEX IF NOT EXISTS agent_outbox_source_idx ON agent_outbox (source) WHERE source IS NOT NULL;
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS view_snapshots (
+  id                  UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  view_id             UUID NOT NULL,
+  request_fingerprint TEXT NOT NULL,
+  columns             JSONB,
+  rows                JSONB,
+  panels              JSONB,
+  row_count           INTEGER NOT NULL DEFAULT 0,
+  created_at          TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS view_snapshots_view_id_created_at_idx ON view_snapshots (view_id, request_fingerprint, created_at DESC);
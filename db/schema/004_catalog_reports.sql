@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS catalog_reports (
+  id            UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  title         TEXT,
+  selection_key TEXT NOT NULL,
+  hash          TEXT NOT NULL,
+  item_count    INTEGER NOT NULL DEFAULT 0,
+  report        JSONB NOT NULL,
+  created_by    UUID,
+  generated_at  TIMESTAMPTZ NOT NULL,
+  created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS catalog_reports_selection_key_generated_at_idx ON catalog_reports (selection_key, generated_at DESC);
+CREATE INDEX IF NOT EXISTS catalog_reports_generated_at_idx ON catalog_reports (generated_at);
@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS application_slo_samples (
+  application_id UUID NOT NULL,
+  slo            TEXT NOT NULL,
+  time           TIMESTAMPTZ NOT NULL,
+  good           DOUBLE PRECISION NOT NULL DEFAULT 0,
+  total          DOUBLE PRECISION NOT NULL DEFAULT 0,
+  PRIMARY KEY (application_id, slo, time)
+);
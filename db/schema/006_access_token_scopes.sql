@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS access_token_scopes (
+  person_id       UUID PRIMARY KEY REFERENCES people (id),
+  access_token_id UUID NOT NULL REFERENCES access_tokens (id) ON DELETE CASCADE,
+  permissions     JSONB,
+  scope_id        UUID,
+  cidrs           TEXT[],
+  created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS access_token_scopes_access_token_id_idx ON access_token_scopes (access_token_id);
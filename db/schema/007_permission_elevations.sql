@@ -0,0 +1,24 @@
+CREATE TABLE IF NOT EXISTS permission_elevations (
+  id            UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  person_id     UUID NOT NULL REFERENCES people (id),
+  role          TEXT,
+  actions       TEXT[],
+  object        JSONB,
+  reason        TEXT NOT NULL,
+  duration      TEXT NOT NULL,
+  status        TEXT NOT NULL,
+  approval      JSONB,
+  approvals     JSONB,
+  permission_id UUID,
+  config_ids    UUID[],
+  approved_at   TIMESTAMPTZ,
+  expires_at    TIMESTAMPTZ,
+  ended_at      TIMESTAMPTZ,
+  ended_by      UUID REFERENCES people (id),
+  created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  updated_at    TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS permission_elevations_person_id_idx ON permission_elevations (person_id);
+
+CREATE INDEX IF NOT EXISTS permission_elevations_status_expires_at_idx ON permission_elevations (status, expires_at);
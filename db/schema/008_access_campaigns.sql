@@ -0,0 +1,48 @@
+CREATE TABLE IF NOT EXISTS access_campaigns (
+  id               UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  name             TEXT NOT NULL,
+  description      TEXT,
+  spec             JSONB,
+  status           TEXT NOT NULL,
+  due_at           TIMESTAMPTZ NOT NULL,
+  digest           TEXT,
+  signed_off_at    TIMESTAMPTZ,
+  signed_off_by    UUID REFERENCES people (id),
+  last_reminded_at TIMESTAMPTZ,
+  created_by       UUID REFERENCES people (id),
+  created_at       TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  updated_at       TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS access_campaigns_status_idx ON access_campaigns (status);
+
+CREATE TABLE IF NOT EXISTS access_campaign_items (
+  id                  UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  campaign_id         UUID NOT NULL REFERENCES access_campaigns (id) ON DELETE CASCADE,
+  config_access_id    TEXT NOT NULL,
+  config_id           UUID NOT NULL,
+  config_name         TEXT,
+  config_type         TEXT,
+  scraper_id          UUID,
+  external_user_id    UUID,
+  external_group_id   UUID,
+  external_role_id    UUID,
+  "user"              TEXT,
+  email               TEXT,
+  role                TEXT,
+  reviewer_person_ids UUID[],
+  reviewer_team_ids   UUID[],
+  decision            TEXT NOT NULL,
+  decided_by          UUID REFERENCES people (id),
+  decided_at          TIMESTAMPTZ,
+  comment             TEXT,
+  playbook_run_id     UUID,
+  error               TEXT,
+  created_at          TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS access_campaign_items_campaign_id_idx ON access_campaign_items (campaign_id);
+
+CREATE INDEX IF NOT EXISTS access_campaign_items_reviewer_person_ids_idx ON access_campaign_items USING GIN (reviewer_person_ids);
+
+CREATE INDEX IF NOT EXISTS access_campaign_items_reviewer_team_ids_idx ON access_campaign_items USING GIN (reviewer_team_ids);
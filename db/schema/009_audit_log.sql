@@ -0,0 +1,27 @@
+CREATE TABLE IF NOT EXISTS audit_log (
+  id             BIGSERIAL PRIMARY KEY,
+  created_at     TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  actor_id       UUID,
+  token_id       UUID,
+  impersonation  TEXT,
+  break_glass_id UUID,
+  method         TEXT NOT NULL,
+  route          TEXT NOT NULL,
+  path           TEXT NOT NULL,
+  object         TEXT,
+  action         TEXT,
+  decision       TEXT,
+  status         INTEGER NOT NULL DEFAULT 0,
+  remote_ip      TEXT,
+  body_hash      TEXT,
+  prev_hash      TEXT NOT NULL,
+  hash           TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS audit_log_created_at_idx ON audit_log (created_at);
+
+CREATE INDEX IF NOT EXISTS audit_log_actor_id_idx ON audit_log (actor_id);
+
+CREATE INDEX IF NOT EXISTS audit_log_token_id_idx ON audit_log (token_id);
+
+CREATE INDEX IF NOT EXISTS audit_log_break_glass_id_idx ON audit_log (break_glass_id) WHERE break_glass_id IS NOT NULL;
@@ -0,0 +1,26 @@
+CREATE TABLE IF NOT EXISTS break_glass_credentials (
+  id         UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  hash       TEXT NOT NULL,
+  shares     INTEGER NOT NULL DEFAULT 1,
+  threshold  INTEGER NOT NULL DEFAULT 1,
+  sealed_by  TEXT NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  revoked_at TIMESTAMPTZ
+);
+
+CREATE TABLE IF NOT EXISTS break_glass_activations (
+  id              UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  credential_id   UUID NOT NULL REFERENCES break_glass_credentials (id),
+  person_id       UUID NOT NULL REFERENCES people (id),
+  access_token_id UUID NOT NULL,
+  operator        TEXT NOT NULL,
+  reason          TEXT NOT NULL,
+  expires_at      TIMESTAMPTZ NOT NULL,
+  ended_at        TIMESTAMPTZ,
+  ended_by        UUID REFERENCES people (id),
+  created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS break_glass_activations_person_id_idx ON break_glass_activations (person_id) WHERE ended_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS break_glass_activations_expires_at_idx ON break_glass_activations (expires_at) WHERE ended_at IS NULL;
@@ -0,0 +1,4 @@
+CREATE TABLE IF NOT EXISTS agent_outbox_state (
+  id            BOOLEAN PRIMARY KEY DEFAULT TRUE CHECK (id),
+  reconciled_at TIMESTAMPTZ NOT NULL
+);
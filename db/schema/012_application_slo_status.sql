@@ -0,0 +1,4 @@
+ALTER TABLE application_slo_samples ADD COLUMN IF NOT EXISTS status JSONB;
+
+-- The last evaluation of an SLO used to be kept in job_history.
+DELETE FROM job_history WHERE name = 'ApplicationSLO' AND resource_type = 'application_slo';
@@ -0,0 +1,43 @@
+// Package schema creates the tables of mission-control that aren't part of
+// duty's migrations.
+package schema
+
+import (
+	"embed"
+	"io/fs"
+	"sort"
+
+	"github.com/flanksource/duty/context"
+	"gorm.io/gorm"
+)
+
+//go:embed *.sql
+var files embed.FS
+
+// Apply runs every statement file in name order. The statements are
+// idempotent, so Apply runs on every start. Replicas starting together
+// serialize on an advisory lock.
+func Apply(ctx context.Context) error {
+	names, err := fs.Glob(files, "*.sql")
+	if err != nil {
+		return err
+	}
+	sort.Strings(names)
+
+	return ctx.DB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext('mission-control-schema'))").Error; err != nil {
+			return ctx.Oops().Wrapf(err, "failed to lock schema")
+		}
+
+		for _, name := range names {
+			ddl, err := files.ReadFile(name)
+			if err != nil {
+				return err
+			}
+			if err := tx.Exec(string(ddl)).Error; err != nil {
+				return ctx.Oops().Wrapf(err, "failed to apply %s", name)
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,171 @@
+package db
+
+import (
+	"time"
+
+	"github.com/flanksource/duty"
+	"github.com/flanksource/duty/context"
+	"github.com/flanksource/duty/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	dbModels "github.com/flanksource/incident-commander/db/models"
+)
+
+// ProvisionedPerson is a person with the timestamps SCIM exposes.
+// People are deactivated by soft-deleting them.
+type ProvisionedPerson struct {
+	models.Person `gorm:"embedded"`
+	CreatedAt     time.Time
+	UpdatedAt     *time.Time
+	DeletedAt     *time.Time
+}
+
+func (ProvisionedPerson) TableName() string { return "people" }
+
+// provisionedPeople selects the human users: the latest record of every email
+// so that a person re-created after being deactivated is listed once.
+func provisionedPeople(ctx context.Context, where []any) *gorm.DB {
+	latest := ctx.DB().Table("people").
+		Select("DISTINCT ON (COALESCE(LOWER(email), id::text)) *").
+		Where("COALESCE(type, '') = ''").
+		Order("COALESCE(LOWER(email), id::text), deleted_at IS NOT NULL, deleted_at DESC")
+	if len(where) > 0 {
+		latest = latest.Where(where[0], where[1:]...)
+	}
+	return ctx.DB().Table("(?) AS people", latest)
+}
+
+// ListProvisionedPeople returns a page of the human users matching the
+// optional condition, and their total.
+func ListProvisionedPeople(ctx context.Context, offset, limit int, where ...any) ([]ProvisionedPerson, int64, error) {
+	var total int64
+	if err := provisionedPeople(ctx, where).Count(&total).Error; err != nil {
+		return nil, 0, ctx.Oops().Wrapf(err, "failed to count people")
+	}
+
+	var people []ProvisionedPerson
+	if limit > 0 {
+		if err := provisionedPeople(ctx, where).Order("created_at, id").Offset(offset).Limit(limit).Find(&people).Error; err != nil {
+			return nil, 0, ctx.Oops().Wrapf(err, "failed to list people")
+		}
+	}
+	return people, total, nil
+}
+
+// GetProvisionedPerson returns the human user with the id, including
+// deactivated ones, or nil if there's none.
+func GetProvisionedPerson(ctx context.Context, id uuid.UUID) (*ProvisionedPerson, error) {
+	var people []ProvisionedPerson
+	if err := ctx.DB().Where("id = ? AND COALESCE(type, '') = ''", id).Limit(1).Find(&people).Error; err != nil {
+		return nil, ctx.Oops().Wrapf(err, "failed to get person %s", id)
+	}
+	if len(people) == 0 {
+		return nil, nil
+	}
+	return &people[0], nil
+}
+
+// DeactivatePerson soft-deletes the person and removes them from all teams.
+func DeactivatePerson(ctx context.Context, id uuid.UUID) error {
+	return ctx.DB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Person{}).Where("id = ? AND deleted_at IS NULL", id).
+			UpdateColumn("deleted_at", duty.Now()).Error; err != nil {
+			return ctx.Oops().Wrapf(err, "failed to deactivate person %s", id)
+		}
+		if err := tx.Where("person_id = ?", id).Delete(&dbModels.TeamMember{}).Error; err != nil {
+			return ctx.Oops().Wrapf(err, "failed to remove person %s from teams", id)
+		}
+		return nil
+	})
+}
+
+// ReactivatePerson restores a deactivated person.
+func ReactivatePerson(ctx context.Context, id uuid.UUID) error {
+	if err := ctx.DB().Model(&models.Person{}).Where("id = ?", id).
+		UpdateColumn("deleted_at", nil).Error; err != nil {
+		return ctx.Oops().Wrapf(err, "failed to reactivate person %s", id)
+	}
+	return nil
+}
+
+// ListTeams returns a page of the teams matching the optional condition, and
+// their total.
+func ListTeams(ctx context.Context, offset, limit int, where ...any) ([]dbModels.Team, int64, error) {
+	query := func() *gorm.DB {
+		q := ctx.DB().Model(&dbModels.Team{}).Where("deleted_at IS NULL")
+		if len(where) > 0 {
+			q = q.Where(where[0], where[1:]...)
+		}
+		return q
+	}
+
+	var total int64
+	if err := query().Count(&total).Error; err != nil {
+		return nil, 0, ctx.Oops().Wrapf(err, "failed to count teams")
+	}
+
+	var teams []dbModels.Team
+	if limit > 0 {
+		if err := query().Order("created_at, id").Offset(offset).Limit(limit).Find(&teams).Error; err != nil {
+			return nil, 0, ctx.Oops().Wrapf(err, "failed to list teams")
+		}
+	}
+	return teams, total, nil
+}
+
+// GetTeam returns the team with the id, or nil if there's none.
+func GetTeam(ctx context.Context, id uuid.UUID) (*dbModels.Team, error) {
+	var teams []dbModels.Team
+	if err := ctx.DB().Where("id = ? AND deleted_at IS NULL", id).Limit(1).Find(&teams).Error; err != nil {
+		return nil, ctx.Oops().Wrapf(err, "failed to get team %s", id)
+	}
+	if len(teams) == 0 {
+		return nil, nil
+	}
+	return &teams[0], nil
+}
+
+// GetTeamMembers returns the active members of the teams by team id.
+func GetTeamMembers(ctx context.Context, teamIDs ...uuid.UUID) (map[uuid.UUID][]models.Person, error) {
+	var rows []struct {
+		MemberTeamID uuid.UUID
+		models.Person
+	}
+	if err := ctx.DB().Table("team_members").
+		Select("team_members.team_id AS member_team_id, people.*").
+		Joins("JOIN people ON people.id = team_members.person_id").
+		Where("team_members.team_id IN ? AND people.deleted_at IS NULL", teamIDs).
+		Order("people.name").
+		Scan(&rows).Error; err != nil {
+		return nil, ctx.Oops().Wrapf(err, "failed to get team members")
+	}
+
+	members := make(map[uuid.UUID][]models.Person, len(teamIDs))
+	for _, row := range rows {
+		members[row.MemberTeamID] = append(members[row.MemberTeamID], row.Person)
+	}
+	return members, nil
+}
+
+// GetTeamsOfPeople returns the teams of the people by person id.
+func GetTeamsOfPeople(ctx context.Context, personIDs ...uuid.UUID) (map[uuid.UUID][]models.Team, error) {
+	var rows []struct {
+		MemberPersonID uuid.UUID
+		models.Team
+	}
+	if err := ctx.DB().Table("team_members").
+		Select("team_members.person_id AS member_person_id, teams.*").
+		Joins("JOIN teams ON teams.id = team_members.team_id").
+		Where("team_members.person_id IN ? AND teams.deleted_at IS NULL", personIDs).
+		Order("teams.name").
+		Scan(&rows).Error; err != nil {
+		return nil, ctx.Oops().Wrapf(err, "failed to get teams of people")
+	}
+
+	teams := make(map[uuid.UUID][]models.Team, len(personIDs))
+	for _, row := range rows {
+		teams[row.MemberPersonID] = append(teams[row.MemberPersonID], row.Team)
+	}
+	return teams, nil
+}
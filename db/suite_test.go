@@ -7,6 +7,8 @@ import (
 	"github.com/flanksource/duty/tests/setup"
 	ginkgo "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+
+	"github.com/flanksource/incident-commander/db/schema"
 )
 
 func TestDB(t *testing.T) {
@@ -20,6 +22,7 @@ var (
 
 var _ = ginkgo.BeforeSuite(func() {
 	DefaultContext = setup.BeforeSuiteFn()
+	Expect(schema.Apply(DefaultContext)).To(Succeed())
 })
 
 var _ = ginkgo.AfterSuite(setup.AfterSuiteFn)
@@ -32,18 +32,19 @@ func PersistTeamFromCRD(ctx context.Context, obj *v1.Team) error {
 	}
 
 	// Sync team members
-	if err := syncTeamMembers(ctx, uid, obj.Spec.Members); err != nil {
+	if err := SyncTeamMembers(ctx, uid, obj.Spec.Members, models.SourceCRD); err != nil {
 		return ctx.Oops().Wrapf(err, "failed to sync team members")
 	}
 
 	return nil
 }
 
-// syncTeamMembers syncs the team members from the CRD spec
-func syncTeamMembers(ctx context.Context, teamID uuid.UUID, members []string) error {
+// SyncTeamMembers makes the given members (person ids or emails) the members
+// of the team from the given source. Members from other sources are kept.
+func SyncTeamMembers(ctx context.Context, teamID uuid.UUID, members []string, source string) error {
 	// Get existing team members
 	var existingMembers []dbModels.TeamMember
-	if err := ctx.DB().Where("team_id = ? AND source = ?", teamID, models.SourceCRD).Find(&existingMembers).Error; err != nil {
+	if err := ctx.DB().Where("team_id = ? AND source = ?", teamID, source).Find(&existingMembers).Error; err != nil {
 		return ctx.Oops().Wrapf(err, "failed to get existing team members")
 	}
 
@@ -69,7 +70,7 @@ func syncTeamMembers(ctx context.Context, teamID uuid.UUID, members []string) er
 			if err := ctx.DB().Clauses(clause.OnConflict{DoNothing: true}).Create(&dbModels.TeamMember{
 				TeamID:   teamID,
 				PersonID: personID,
-				Source:   models.SourceCRD,
+				Source:   source,
 			}).Error; err != nil {
 				return ctx.Oops().Wrapf(err, "failed to add member %s to team", personID)
 			}
@@ -79,7 +80,7 @@ func syncTeamMembers(ctx context.Context, teamID uuid.UUID, members []string) er
 	// Remove members not in the new list
 	for personID := range existingMemberIDs {
 		if _, exists := newMemberIDs[personID]; !exists {
-			if err := ctx.DB().Where("team_id = ? AND person_id = ? AND source = ?", teamID, personID, models.SourceCRD).
+			if err := ctx.DB().Where("team_id = ? AND person_id = ? AND source = ?", teamID, personID, source).
 				Delete(&dbModels.TeamMember{}).Error; err != nil {
 				return ctx.Oops().Wrapf(err, "failed to remove member %s from team", personID)
 			}
@@ -0,0 +1,119 @@
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/flanksource/duty/context"
+	dutyTypes "github.com/flanksource/duty/types"
+	"github.com/google/uuid"
+)
+
+// ViewSnapshot is a row of the view_snapshots table: the rows and panels of
+// a view request at a point in time.
+type ViewSnapshot struct {
+	ID                 uuid.UUID `gorm:"primaryKey;default:gen_random_uuid()"`
+	ViewID             uuid.UUID
+	RequestFingerprint string
+	// Columns is the list of column definitions the rows were produced with.
+	Columns dutyTypes.JSON
+	// Rows are objects keyed by column name. The grants of the rows aren't
+	// kept.
+	Rows      dutyTypes.JSON
+	Panels    dutyTypes.JSON
+	RowCount  int
+	CreatedAt time.Time
+}
+
+func (ViewSnapshot) TableName() string { return "view_snapshots" }
+
+// SaveViewSnapshot inserts a new snapshot.
+func SaveViewSnapshot(ctx context.Context, snapshot *ViewSnapshot) error {
+	if snapshot.CreatedAt.IsZero() {
+		snapshot.CreatedAt = time.Now()
+	}
+
+	if err := ctx.DB().Create(snapshot).Error; err != nil {
+		return fmt.Errorf("failed to save view snapshot: %w", err)
+	}
+
+	return nil
+}
+
+// GetLastViewSnapshotTime returns when the latest snapshot of the request was
+// taken, or nil if there's none.
+func GetLastViewSnapshotTime(ctx context.Context, viewID uuid.UUID, fingerprint string) (*time.Time, error) {
+	var snapshots []ViewSnapshot
+	if err := ctx.DB().Select("created_at").
+		Where("view_id = ? AND request_fingerprint = ?", viewID, fingerprint).
+		Order("created_at DESC").Limit(1).Find(&snapshots).Error; err != nil {
+		return nil, fmt.Errorf("failed to get last view snapshot: %w", err)
+	}
+	if len(snapshots) == 0 {
+		return nil, nil
+	}
+	return &snapshots[0].CreatedAt, nil
+}
+
+// GetViewSnapshotAsOf returns the latest snapshot of the request taken at or
+// before asOf, or nil if there's none.
+func GetViewSnapshotAsOf(ctx context.Context, viewID uuid.UUID, fingerprint string, asOf time.Time) (*ViewSnapshot, error) {
+	var snapshots []ViewSnapshot
+	if err := ctx.DB().Where("view_id = ? AND request_fingerprint = ? AND created_at <= ?", viewID, fingerprint, asOf).
+		Order("created_at DESC").Limit(1).Find(&snapshots).Error; err != nil {
+		return nil, fmt.Errorf("failed to get view snapshot: %w", err)
+	}
+	if len(snapshots) == 0 {
+		return nil, nil
+	}
+	return &snapshots[0], nil
+}
+
+// GetViewSnapshot returns the snapshot with the given id, or nil if the view
+// has no such snapshot.
+func GetViewSnapshot(ctx context.Context, viewID, id uuid.UUID) (*ViewSnapshot, error) {
+	var snapshots []ViewSnapshot
+	if err := ctx.DB().Where("view_id = ? AND id = ?", viewID, id).Limit(1).Find(&snapshots).Error; err != nil {
+		return nil, fmt.Errorf("failed to get view snapshot: %w", err)
+	}
+	if len(snapshots) == 0 {
+		return nil, nil
+	}
+	return &snapshots[0], nil
+}
+
+// ListViewSnapshots returns the snapshots of the request, latest first,
+// without their rows and panels.
+func ListViewSnapshots(ctx context.Context, viewID uuid.UUID, fingerprint string) ([]ViewSnapshot, error) {
+	var snapshots []ViewSnapshot
+	if err := ctx.DB().Omit("columns", "rows", "panels").
+		Where("view_id = ? AND request_fingerprint = ?", viewID, fingerprint).
+		Order("created_at DESC").Find(&snapshots).Error; err != nil {
+		return nil, fmt.Errorf("failed to list view snapshots: %w", err)
+	}
+	return snapshots, nil
+}
+
+// DeleteViewSnapshotsBefore deletes the snapshots of the view, across all
+// requests, that were taken before the given time.
+func DeleteViewSnapshotsBefore(ctx context.Context, viewID uuid.UUID, before time.Time) (int64, error) {
+	tx := ctx.DB().Where("view_id = ? AND created_at < ?", viewID, before).Delete(&ViewSnapshot{})
+	if tx.Error != nil {
+		return 0, fmt.Errorf("failed to delete expired view snapshots: %w", tx.Error)
+	}
+	return tx.RowsAffected, nil
+}
+
+// DeleteViewSnapshotsBeyond deletes the snapshots of the request but the
+// latest keep ones.
+func DeleteViewSnapshotsBeyond(ctx context.Context, viewID uuid.UUID, fingerprint string, keep int) (int64, error) {
+	tx := ctx.DB().Where("view_id = ? AND request_fingerprint = ?", viewID, fingerprint).
+		Where("id NOT IN (?)", ctx.DB().Model(&ViewSnapshot{}).Select("id").
+			Where("view_id = ? AND request_fingerprint = ?", viewID, fingerprint).
+			Order("created_at DESC").Limit(keep)).
+		Delete(&ViewSnapshot{})
+	if tx.Error != nil {
+		return 0, fmt.Errorf("failed to delete excess view snapshots: %w", tx.Error)
+	}
+	return tx.RowsAffected, nil
+}
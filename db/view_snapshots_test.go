@@ -0,0 +1,69 @@
+package db
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	ginkgo "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = ginkgo.Describe("View snapshots", ginkgo.Ordered, func() {
+	viewID := uuid.New()
+	now := time.Now().Truncate(time.Second)
+
+	ginkgo.BeforeAll(func() {
+		for i, age := range []time.Duration{48 * time.Hour, 24 * time.Hour, time.Hour} {
+			Expect(SaveViewSnapshot(DefaultContext, &ViewSnapshot{
+				ViewID:             viewID,
+				RequestFingerprint: "default",
+				Rows:               []byte(`[]`),
+				RowCount:           i,
+				CreatedAt:          now.Add(-age),
+			})).To(Succeed())
+		}
+	})
+
+	ginkgo.It("returns the latest snapshot as of a time", func() {
+		snapshot, err := GetViewSnapshotAsOf(DefaultContext, viewID, "default", now.Add(-2*time.Hour))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(snapshot).ToNot(BeNil())
+		Expect(snapshot.RowCount).To(Equal(1))
+
+		snapshot, err = GetViewSnapshotAsOf(DefaultContext, viewID, "default", now.Add(-72*time.Hour))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(snapshot).To(BeNil())
+	})
+
+	ginkgo.It("keeps requests apart", func() {
+		last, err := GetLastViewSnapshotTime(DefaultContext, viewID, "other")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(last).To(BeNil())
+
+		last, err = GetLastViewSnapshotTime(DefaultContext, viewID, "default")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(last.Equal(now.Add(-time.Hour))).To(BeTrue())
+	})
+
+	ginkgo.It("deletes expired snapshots", func() {
+		deleted, err := DeleteViewSnapshotsBefore(DefaultContext, viewID, now.Add(-36*time.Hour))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(deleted).To(Equal(int64(1)))
+
+		snapshots, err := ListViewSnapshots(DefaultContext, viewID, "default")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(snapshots).To(HaveLen(2))
+		Expect(snapshots[0].RowCount).To(Equal(2))
+	})
+
+	ginkgo.It("keeps the latest snapshots of a request", func() {
+		deleted, err := DeleteViewSnapshotsBeyond(DefaultContext, viewID, "default", 1)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(deleted).To(Equal(int64(1)))
+
+		snapshots, err := ListViewSnapshots(DefaultContext, viewID, "default")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(snapshots).To(HaveLen(1))
+		Expect(snapshots[0].RowCount).To(Equal(2))
+	})
+})
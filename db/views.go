@@ -74,6 +74,10 @@ func DeleteView(ctx context.Context, id string) error {
 		return fmt.Errorf("failed to delete view panels: %w", err)
 	}
 
+	if err := ctx.DB().Where("view_id = ?", id).Delete(&ViewSnapshot{}).Error; err != nil {
+		return fmt.Errorf("failed to delete view snapshots: %w", err)
+	}
+
 	if err := ctx.DB().Model(&models.View{}).Where("id = ?", id).Update("deleted_at", duty.Now()).Error; err != nil {
 		return fmt.Errorf("failed to soft-delete view: %w", err)
 	}
@@ -99,6 +103,73 @@ func GetView(ctx context.Context, namespace, name string) (*v1.View, error) {
 		return nil, err
 	}
 
+	return viewFromModel(view)
+}
+
+// GetViewsWithPanelThresholds returns the views that have at least one panel threshold.
+func GetViewsWithPanelThresholds(ctx context.Context) ([]v1.View, error) {
+	var rows []models.View
+	if err := ctx.DB().Where("deleted_at IS NULL").
+		Where("jsonb_path_exists(spec, '$.panels[*].thresholds[*]')").
+		Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to list views with panel thresholds: %w", err)
+	}
+
+	views := make([]v1.View, 0, len(rows))
+	for _, row := range rows {
+		view, err := viewFromModel(row)
+		if err != nil {
+			return nil, fmt.Errorf("invalid view %s/%s: %w", row.Namespace, row.Name, err)
+		}
+		views = append(views, *view)
+	}
+
+	return views, nil
+}
+
+// GetViewsWithHistorySchedule returns the views that snapshot their history on a schedule.
+func GetViewsWithHistorySchedule(ctx context.Context) ([]v1.View, error) {
+	var rows []models.View
+	if err := ctx.DB().Where("deleted_at IS NULL").
+		Where("COALESCE(spec->'cache'->'history'->>'schedule', '') != ''").
+		Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to list views with a history schedule: %w", err)
+	}
+
+	views := make([]v1.View, 0, len(rows))
+	for _, row := range rows {
+		view, err := viewFromModel(row)
+		if err != nil {
+			return nil, fmt.Errorf("invalid view %s/%s: %w", row.Namespace, row.Name, err)
+		}
+		views = append(views, *view)
+	}
+
+	return views, nil
+}
+
+// GetViewsWithMetrics returns the views that export at least one metric.
+func GetViewsWithMetrics(ctx context.Context) ([]v1.View, error) {
+	var rows []models.View
+	if err := ctx.DB().Where("deleted_at IS NULL").
+		Where("jsonb_path_exists(spec, '$.metrics[*]')").
+		Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to list views with metrics: %w", err)
+	}
+
+	views := make([]v1.View, 0, len(rows))
+	for _, row := range rows {
+		view, err := viewFromModel(row)
+		if err != nil {
+			return nil, fmt.Errorf("invalid view %s/%s: %w", row.Namespace, row.Name, err)
+		}
+		views = append(views, *view)
+	}
+
+	return views, nil
+}
+
+func viewFromModel(view models.View) (*v1.View, error) {
 	var spec v1.ViewSpec
 	if err := json.Unmarshal(view.Spec, &spec); err != nil {
 		return nil, err
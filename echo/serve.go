@@ -34,12 +34,14 @@ import (
 	"github.com/labstack/echo/v4/middleware"
 	prom "github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/samber/lo"
 	"go.opentelemetry.io/contrib/instrumentation/github.com/labstack/echo/otelecho"
 	"go.opentelemetry.io/otel/attribute"
 
 	"github.com/flanksource/incident-commander/agent"
 	"github.com/flanksource/incident-commander/api"
 	v1 "github.com/flanksource/incident-commander/api/v1"
+	"github.com/flanksource/incident-commander/audit"
 	"github.com/flanksource/incident-commander/auth"
 	"github.com/flanksource/incident-commander/db"
 	"github.com/flanksource/incident-commander/logs"
@@ -79,6 +81,28 @@ func RegisterRoutes(fn func(e *echov4.Echo)) {
 	handlers = append(handlers, fn)
 }
 
+// NewIPExtractor returns how the client address of a request is found, e.g.
+// for the networks of scoped access tokens. X-Forwarded-For is only honoured
+// from the trusted proxies; without any the peer address is used.
+func NewIPExtractor(trustedProxies []string) (echov4.IPExtractor, error) {
+	networks, err := rbac.ParseCIDRs(lo.Compact(lo.Map(trustedProxies, func(s string, _ int) string { return strings.TrimSpace(s) })))
+	if err != nil {
+		return nil, err
+	} else if len(networks) == 0 {
+		return echov4.ExtractIPDirect(), nil
+	}
+
+	options := []echov4.TrustOption{
+		echov4.TrustLoopback(false),
+		echov4.TrustLinkLocal(false),
+		echov4.TrustPrivateNet(false),
+	}
+	for _, network := range networks {
+		options = append(options, echov4.TrustIPRange(network))
+	}
+	return echov4.ExtractIPFromXFFHeader(options...), nil
+}
+
 // stripUpstreamCORS removes CORS headers from an upstream proxy response
 // before they are written to the client.
 func stripUpstreamCORS(resp *http.Response) error {
@@ -97,6 +121,14 @@ func New(ctx context.Context) *echov4.Echo {
 	ctx.ClearCache()
 	e := echov4.New()
 	e.HideBanner = true
+	rbac.TrackScopedRoutes(e)
+
+	ipExtractor, err := NewIPExtractor(strings.Split(ctx.Properties().String("http.trusted_proxies", ""), ","))
+	if err != nil {
+		ctx.Warnf("ignoring http.trusted_proxies: %v", err)
+		ipExtractor = echov4.ExtractIPDirect()
+	}
+	e.IPExtractor = ipExtractor
 
 	otelShutdown = telemetry.InitTracer()
 
@@ -143,6 +175,7 @@ func New(ctx context.Context) *echov4.Echo {
 	e.Use(ServerCache)
 	e.Use(mcMiddleware.ServerTiming)
 	e.Use(auth.ScopeImpersonation)
+	e.Use(audit.Middleware)
 
 	e.GET("/kubeconfig", DownloadKubeConfig, rbac.Authorization(policy.ObjectKubernetesProxy, policy.ActionCreate))
 	Forward(ctx, e, "/kubeproxy", "https://kubernetes.default.svc", &ForwardOptions{
@@ -202,6 +235,7 @@ func New(ctx context.Context) *echov4.Echo {
 
 	auth.RegisterRoutes(e)
 	rbac.RegisterRoutes(e)
+	audit.RegisterRoutes(e)
 
 	// Serve openapi schemas
 	schemaServer, err := utils.HTTPFileserver(openapi.Schemas)
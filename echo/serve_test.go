@@ -0,0 +1,58 @@
+package echo
+
+import (
+	"net/http"
+	"net/http/httptest"
+
+	echov4 "github.com/labstack/echo/v4"
+	ginkgo "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/flanksource/incident-commander/db"
+	"github.com/flanksource/incident-commander/rbac"
+)
+
+var _ = ginkgo.Describe("NewIPExtractor", func() {
+	// realIP returns the client address of a request from the peer with the
+	// X-Forwarded-For header.
+	realIP := func(trustedProxies []string, peer, forwardedFor string) string {
+		extractor, err := NewIPExtractor(trustedProxies)
+		Expect(err).ToNot(HaveOccurred())
+
+		e := echov4.New()
+		e.IPExtractor = extractor
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = peer + ":4321"
+		req.Header.Set(echov4.HeaderXForwardedFor, forwardedFor)
+		return e.NewContext(req, httptest.NewRecorder()).RealIP()
+	}
+
+	var scope *rbac.TokenScope
+	ginkgo.BeforeEach(func() {
+		var err error
+		scope, err = rbac.NewTokenScope(db.AccessTokenScope{CIDRs: []string{"10.0.0.0/8"}})
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	ginkgo.It("rejects a token used with a spoofed X-Forwarded-For", func() {
+		ip := realIP([]string{""}, "203.0.113.5", "10.0.0.1")
+		Expect(ip).To(Equal("203.0.113.5"))
+		Expect(scope.AllowsIP(ip)).To(BeFalse())
+
+		// Private peers aren't trusted either unless configured.
+		Expect(realIP(nil, "192.168.1.10", "10.0.0.1")).To(Equal("192.168.1.10"))
+	})
+
+	ginkgo.It("honours X-Forwarded-For from a trusted proxy", func() {
+		ip := realIP([]string{"203.0.113.0/24"}, "203.0.113.5", "10.0.0.1")
+		Expect(ip).To(Equal("10.0.0.1"))
+		Expect(scope.AllowsIP(ip)).To(BeTrue())
+
+		Expect(realIP([]string{"203.0.113.0/24"}, "198.51.100.7", "10.0.0.1")).To(Equal("198.51.100.7"))
+	})
+
+	ginkgo.It("rejects invalid proxies", func() {
+		_, err := NewIPExtractor([]string{"not-a-network"})
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -3,6 +3,7 @@ package events
 import (
 	"errors"
 
+	"github.com/flanksource/commons/collections"
 	"github.com/flanksource/duty"
 	dutyAPI "github.com/flanksource/duty/api"
 	"github.com/flanksource/duty/context"
@@ -18,6 +19,10 @@ type EventResource struct {
 	Check        *models.Check        `json:"check,omitempty"`
 	CheckSummary *models.CheckSummary `json:"check_summary,omitempty"`
 	Canary       *models.Canary       `json:"canary,omitempty"`
+
+	ViewThreshold     *api.ViewThresholdEvent     `json:"view_threshold,omitempty"`
+	ApplicationSLO    *api.ApplicationSLOEvent    `json:"application_slo,omitempty"`
+	ApplicationBackup *api.ApplicationBackupEvent `json:"application_backup,omitempty"`
 }
 
 func (t *EventResource) AsMap() map[string]any {
@@ -38,6 +43,15 @@ func (t *EventResource) AsMap() map[string]any {
 	if t.CheckSummary != nil {
 		output["check_summary"] = t.CheckSummary.AsMap()
 	}
+	if t.ViewThreshold != nil {
+		output = collections.MergeMap(output, t.ViewThreshold.AsMap())
+	}
+	if t.ApplicationSLO != nil {
+		output = collections.MergeMap(output, t.ApplicationSLO.AsMap())
+	}
+	if t.ApplicationBackup != nil {
+		output = collections.MergeMap(output, t.ApplicationBackup.AsMap())
+	}
 
 	return output
 }
@@ -111,6 +125,23 @@ func BuildEventResource(ctx context.Context, event models.Event) (EventResource,
 			return eventResource, err
 		}
 		eventResource.Config = &config
+
+	case api.EventViewThresholdBreached, api.EventViewThresholdRecovered:
+		viewThreshold, err := api.ViewThresholdEventFromProperties(event.Properties)
+		if err != nil {
+			return eventResource, dutyAPI.Errorf(dutyAPI.EINVALID, "invalid view threshold event(id=%s): %v", event.EventID, err)
+		}
+		eventResource.ViewThreshold = viewThreshold
+
+	case api.EventApplicationSLOFastBurn, api.EventApplicationSLOSlowBurn, api.EventApplicationSLORecovered:
+		slo, err := api.ApplicationSLOEventFromProperties(event.Properties)
+		if err != nil {
+			return eventResource, dutyAPI.Errorf(dutyAPI.EINVALID, "invalid application slo event(id=%s): %v", event.EventID, err)
+		}
+		eventResource.ApplicationSLO = slo
+
+	case api.EventApplicationBackupViolated, api.EventApplicationBackupCompliant:
+		eventResource.ApplicationBackup = api.ApplicationBackupEventFromProperties(event.Properties)
 	}
 	return eventResource, nil
 }
@@ -89,6 +89,7 @@ func printToolSummary(names []string, tools map[string]*server.ServerTool) {
 	}
 	fmt.Println(mdRow("`{playbook}_{namespace}_{category}`", "mutating", "Dynamic per-session playbook tools. Parameters derived from playbook spec."))
 	fmt.Println(mdRow("`view_{name}_{namespace}`", "read-only", "Dynamic view tools synced hourly. Returns table rows by default with select/page/limit controls."))
+	fmt.Println(mdRow("`plugin_{namespace}_{name}_{operation}`", "mutating", "Dynamic plugin operation tools kept in sync with the plugin registry."))
 	fmt.Println()
 }
 
@@ -115,6 +116,16 @@ func printDynamicTools() {
 	fmt.Println()
 	fmt.Println("Views may also expose template variables as additional string parameters.")
 	fmt.Println()
+	fmt.Println("**Plugin Tools** — Each operation of every running local or remote plugin is registered as an MCP tool.")
+	fmt.Println("Tools are added and removed as plugins register, restart or are deleted.")
+	fmt.Println("Names follow the pattern `plugin_{namespace}_{name}_{operation}` (e.g. `plugin_mission-control_kubernetes-logs_tail`).")
+	fmt.Println("Calls go through the plugin invoke path, so plugin roles, `invoke` permissions and invocation auditing apply.")
+	fmt.Println()
+	fmt.Println(mdRow("Name", "Type", "Required", "Description"))
+	fmt.Println("|------|------|----------|-------------|")
+	fmt.Println(mdRow("`config_id`", "string", "yes", "ID of the config item the operation targets"))
+	fmt.Println(mdRow("`params`", "object", "no", "Operation parameters as described by the operation's params schema"))
+	fmt.Println()
 }
 
 func printToolDetail(tool mcplib.Tool) {
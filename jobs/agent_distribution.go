@@ -0,0 +1,53 @@
+package jobs
+
+import (
+	"fmt"
+
+	"github.com/flanksource/duty/job"
+	"github.com/flanksource/duty/upstream"
+
+	"github.com/flanksource/incident-commander/api"
+	"github.com/flanksource/incident-commander/upstream/distribution"
+	"github.com/flanksource/incident-commander/upstream/outbox"
+)
+
+// PullAgentDistribution applies the resources the upstream distributes to
+// this agent and reports the outcome of every resource back to the upstream.
+var PullAgentDistribution = &job.Job{
+	Name:       "PullAgentDistribution",
+	Schedule:   "@every 5m",
+	Retention:  job.RetentionFailed,
+	JobHistory: true,
+	RunNow:     true,
+	Singleton:  true,
+	Fn: func(ctx job.JobRuntime) error {
+		ctx.History.ResourceType = job.ResourceTypeUpstream
+		ctx.History.ResourceID = api.UpstreamConf.Host
+
+		client := upstream.NewUpstreamClient(api.UpstreamConf)
+		resources, err := distribution.Pull(ctx.Context, client)
+		if err != nil {
+			return err
+		}
+
+		statuses, pruneErr := distribution.Apply(ctx.Context, api.UpstreamConf.AgentName, resources)
+		for _, status := range statuses {
+			if status.ErrorCount > 0 {
+				ctx.History.AddError(fmt.Sprintf("%v/%v/%v: %v", status.Details["kind"], status.Details["namespace"], status.Details["name"], status.Details["error"]))
+			} else {
+				ctx.History.SuccessCount++
+			}
+		}
+
+		report := upstream.PushData{JobHistory: statuses}
+		if err := client.Push(ctx.Context, &report); err != nil {
+			if qerr := outbox.Enqueue(ctx.Context, outbox.KindPush, outbox.PriorityNormal, report); qerr != nil {
+				ctx.History.AddError(fmt.Sprintf("failed to report the distribution to the upstream: %v, nor queue it: %v", err, qerr))
+			} else {
+				ctx.History.AddDetails("queued", len(statuses))
+			}
+		}
+
+		return pruneErr
+	},
+}
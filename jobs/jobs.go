@@ -21,7 +21,13 @@ import (
 	"github.com/flanksource/incident-commander/auth/oidc"
 	"github.com/flanksource/incident-commander/notification"
 	"github.com/flanksource/incident-commander/playbook"
+	"github.com/flanksource/incident-commander/rbac/breakglass"
+	"github.com/flanksource/incident-commander/rbac/elevation"
+	"github.com/flanksource/incident-commander/rbac/recertification"
 	"github.com/flanksource/incident-commander/shorturl"
+	"github.com/flanksource/incident-commander/views"
+	"github.com/flanksource/incident-commander/views/subscription"
+	"github.com/flanksource/incident-commander/views/threshold"
 )
 
 const (
@@ -39,6 +45,8 @@ func agentJobs(ctx context.Context) []*job.Job {
 		PingUpstream,
 		ReconcileAllJob(api.UpstreamConf),
 		RegisterPluginsWithUpstream,
+		PullAgentDistribution,
+		DrainOutbox,
 		SyncArtifactData,
 		ResetIsPushed,
 		PushPlaybookActions(ctx),
@@ -83,6 +91,26 @@ func Start(ctx context.Context, mcpServer *server.MCPServer) {
 		}
 	}
 
+	if err := application.EvaluateApplicationSLOs(ctx).AddToScheduler(FuncScheduler); err != nil {
+		shutdown.ShutdownAndExit(1, fmt.Sprintf("failed to schedule job EvaluateApplicationSLOs: %v", err))
+	}
+
+	if err := application.CheckBackupCompliance(ctx).AddToScheduler(FuncScheduler); err != nil {
+		shutdown.ShutdownAndExit(1, fmt.Sprintf("failed to schedule job CheckBackupCompliance: %v", err))
+	}
+
+	if err := elevation.ExpireElevations(ctx).AddToScheduler(FuncScheduler); err != nil {
+		shutdown.ShutdownAndExit(1, fmt.Sprintf("failed to schedule job ExpireElevations: %v", err))
+	}
+
+	if err := breakglass.ExpireBreakGlass(ctx).AddToScheduler(FuncScheduler); err != nil {
+		shutdown.ShutdownAndExit(1, fmt.Sprintf("failed to schedule job ExpireBreakGlass: %v", err))
+	}
+
+	if err := recertification.RemindReviewers(ctx).AddToScheduler(FuncScheduler); err != nil {
+		shutdown.ShutdownAndExit(1, fmt.Sprintf("failed to schedule job RemindAccessReviewers: %v", err))
+	}
+
 	if err := SyncPlaybookConfigAccess(ctx).AddToScheduler(FuncScheduler); err != nil {
 		shutdown.ShutdownAndExit(1, fmt.Sprintf("failed to schedule job SyncPlaybookConfigAccess: %v", err))
 	}
@@ -106,6 +134,14 @@ func Start(ctx context.Context, mcpServer *server.MCPServer) {
 		shutdown.ShutdownAndExit(1, fmt.Sprintf("failed to schedule job SchedulePlaybooks: %v", err))
 	}
 
+	if err := threshold.EvaluateViewThresholds(ctx).AddToScheduler(FuncScheduler); err != nil {
+		shutdown.ShutdownAndExit(1, fmt.Sprintf("failed to schedule job EvaluateViewThresholds: %v", err))
+	}
+
+	if err := views.ScheduleViewSnapshots(ctx, FuncScheduler).AddToScheduler(FuncScheduler); err != nil {
+		shutdown.ShutdownAndExit(1, fmt.Sprintf("failed to schedule job ScheduleViewSnapshots: %v", err))
+	}
+
 	if !api.DisableOperators {
 		if err := notification.SyncCRDStatusJob(ctx).AddToScheduler(FuncScheduler); err != nil {
 			shutdown.ShutdownAndExit(1, fmt.Sprintf("failed to schedule job SyncCRDStatusJob: %v", err))
@@ -114,6 +150,10 @@ func Start(ctx context.Context, mcpServer *server.MCPServer) {
 		if err := notification.InitCRDStatusUpdates(ctx); err != nil {
 			logger.Errorf("failed to start notification status update queue: %v", err)
 		}
+
+		if err := subscription.ScheduleViewSubscriptions(ctx, FuncScheduler).AddToScheduler(FuncScheduler); err != nil {
+			shutdown.ShutdownAndExit(1, fmt.Sprintf("failed to schedule job ScheduleViewSubscriptions: %v", err))
+		}
 	}
 
 	if err := job.NewJob(ctx, "Cleanup NotificationSend History", CleanupNotificationSendHistorySchedule, CleanupNotificationSendHistory).
@@ -180,6 +220,11 @@ func Start(ctx context.Context, mcpServer *server.MCPServer) {
 		logger.Errorf("Failed to schedule job for cleaning up expired tokens: %v", err)
 	}
 
+	cleanupExpiredPluginKV.Context = ctx
+	if err := cleanupExpiredPluginKV.AddToScheduler(FuncScheduler); err != nil {
+		logger.Errorf("Failed to schedule job for cleaning up expired plugin kv entries: %v", err)
+	}
+
 	if auth.OIDCEnabled {
 		if err := oidc.CleanupJob(ctx).AddToScheduler(FuncScheduler); err != nil {
 			logger.Errorf("Failed to schedule OIDC cleanup job: %v", err)
@@ -0,0 +1,42 @@
+package jobs
+
+import (
+	"github.com/flanksource/duty/job"
+	"github.com/flanksource/duty/upstream"
+
+	"github.com/flanksource/incident-commander/api"
+	"github.com/flanksource/incident-commander/upstream/outbox"
+)
+
+// DrainOutbox delivers the data queued in the agent's outbox while the
+// upstream was unreachable and reports the remaining backlog to the upstream.
+var DrainOutbox = &job.Job{
+	Name:       "DrainOutbox",
+	Schedule:   "@every 30s",
+	Retention:  job.RetentionFailed,
+	JobHistory: true,
+	RunNow:     true,
+	Singleton:  true,
+	Fn: func(ctx job.JobRuntime) error {
+		ctx.History.ResourceType = job.ResourceTypeUpstream
+		ctx.History.ResourceID = api.UpstreamConf.Host
+
+		client := upstream.NewUpstreamClient(api.UpstreamConf)
+		result, drainErr := outbox.Drain(ctx.Context, client, ctx.Properties().Int("outbox.batch_size", 50))
+		ctx.History.SuccessCount = result.Delivered
+		ctx.History.ErrorCount = result.Failed
+
+		status, err := outbox.GetStatus(ctx.Context)
+		if err != nil {
+			return err
+		}
+		ctx.History.AddDetails("status", status)
+
+		if drainErr != nil {
+			// The upstream is most likely unreachable, don't bother reporting.
+			return drainErr
+		}
+
+		return outbox.Report(ctx.Context, client, api.UpstreamConf.AgentName, status)
+	},
+}
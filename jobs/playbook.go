@@ -40,10 +40,13 @@ func PushPlaybookActions(ctx context.Context) *job.Job {
 		Fn: func(ctx job.JobRuntime) error {
 			ctx.History.ResourceType = job.ResourceTypePlaybook
 			ctx.History.ResourceID = api.UpstreamConf.Host
-			if count, err := playbook.PushPlaybookActions(ctx.Context, api.UpstreamConf, 200); err != nil {
+			pushed, queued, err := playbook.PushPlaybookActions(ctx.Context, api.UpstreamConf, 200)
+			ctx.History.SuccessCount += pushed
+			if queued > 0 {
+				ctx.History.AddDetails("queued", queued)
+			}
+			if err != nil {
 				return err
-			} else {
-				ctx.History.SuccessCount += count
 			}
 
 			return nil
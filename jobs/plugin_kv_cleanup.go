@@ -0,0 +1,24 @@
+package jobs
+
+import (
+	"github.com/flanksource/duty/job"
+
+	"github.com/flanksource/incident-commander/db"
+)
+
+var cleanupExpiredPluginKV = &job.Job{
+	Name:       "CleanupExpiredPluginKV",
+	Schedule:   "@every 1h",
+	Singleton:  true,
+	JobHistory: true,
+	Retention:  job.RetentionFew,
+	RunNow:     false,
+	Fn: func(ctx job.JobRuntime) error {
+		deleted, err := db.DeleteExpiredPluginKV(ctx.Context)
+		if err != nil {
+			return ctx.Oops().Wrapf(err, "error deleting expired plugin kv entries")
+		}
+		ctx.History.SuccessCount = int(deleted)
+		return nil
+	},
+}
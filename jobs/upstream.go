@@ -98,8 +98,11 @@ var SyncArtifactData = &job.Job{
 		// It's run frequently and can run concurrently, so a small batch size is fine.
 		batchSize := 10
 
-		var err error
-		ctx.History.SuccessCount, err = artifacts.SyncArtifactItems(ctx.Context, api.UpstreamConf, batchSize)
+		pushed, queued, err := artifacts.SyncArtifactItems(ctx.Context, api.UpstreamConf, batchSize)
+		ctx.History.SuccessCount = pushed
+		if queued > 0 {
+			ctx.History.AddDetails("queued", queued)
+		}
 		if err != nil {
 			ctx.History.AddError(err.Error())
 		}
@@ -0,0 +1,107 @@
+package mcp
+
+import (
+	gocontext "context"
+	"time"
+
+	"github.com/flanksource/duty/rbac"
+	"github.com/flanksource/duty/rbac/policy"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+
+	"github.com/flanksource/incident-commander/audit"
+	"github.com/flanksource/incident-commander/db"
+	icrbac "github.com/flanksource/incident-commander/rbac"
+)
+
+const (
+	toolSearchAuditLog = "search_audit_log"
+	toolVerifyAuditLog = "verify_audit_log"
+)
+
+func searchAuditLogHandler(goctx gocontext.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	ctx, err := getDutyCtx(goctx)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	if !rbac.CheckContext(ctx, icrbac.ObjectAudit, policy.ActionRead) {
+		return mcp.NewToolResultError("forbidden: audit:read permission is required"), nil
+	}
+
+	filter, err := audit.ParseFilter(func(name string) string {
+		if name == "since" {
+			return req.GetString(name, "24h")
+		}
+		return req.GetString(name, "")
+	}, time.Now())
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	filter.Limit = req.GetInt("limit", 50)
+
+	entries, err := db.SearchAuditLog(ctx, filter)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	return structToMCPResponse(req, entries), nil
+}
+
+func verifyAuditLogHandler(goctx gocontext.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	ctx, err := getDutyCtx(goctx)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	if !rbac.CheckContext(ctx, icrbac.ObjectAudit, policy.ActionRead) {
+		return mcp.NewToolResultError("forbidden: audit:read permission is required"), nil
+	}
+
+	result, err := audit.Verify(ctx, nil)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	return structToMCPResponse(req, result), nil
+}
+
+func registerAudit(s *server.MCPServer) {
+	s.AddTool(mcp.NewTool(toolSearchAuditLog,
+		mcp.WithDescription("Search the audit log of Mission Control: every mutating API call and every request denied by RBAC. Answers questions like 'who changed this playbook?', 'what did this access token do?' or 'which requests were denied today?'. Returns the actor and access token, impersonated scope, route, RBAC object/action/decision, response status, request body hash and the hash chaining the entry to the one before it."),
+		mcp.WithReadOnlyHintAnnotation(true),
+		mcp.WithString("actor",
+			mcp.Description("Person ID (UUID) that made the requests"),
+		),
+		mcp.WithString("token",
+			mcp.Description("Access token ID (UUID) the requests were made with"),
+		),
+		mcp.WithString("object",
+			mcp.Description("RBAC object, e.g. playbooks, catalog, rbac"),
+		),
+		mcp.WithString("action",
+			mcp.Description("RBAC action, e.g. create, update, delete, playbook:run"),
+		),
+		mcp.WithString("decision",
+			mcp.Description("RBAC decision: allow, deny or unauthenticated"),
+		),
+		mcp.WithString("break_glass",
+			mcp.Description("'true' to only return requests made under break-glass emergency access"),
+		),
+		mcp.WithString("route",
+			mcp.Description("API route, e.g. /playbook/run"),
+		),
+		mcp.WithString("since",
+			mcp.Description("Timestamp (RFC3339) or duration before now like '24h', '7d' (default: '24h')"),
+		),
+		mcp.WithString("until",
+			mcp.Description("Timestamp (RFC3339) or duration before now"),
+		),
+		mcp.WithNumber("limit",
+			mcp.Description("Max results to return (default: 50)"),
+		),
+	), searchAuditLogHandler)
+
+	s.AddTool(mcp.NewTool(toolVerifyAuditLog,
+		mcp.WithDescription("Verify the hash chain of the audit log to detect entries that were edited or deleted. Returns the number of entries checked and, if the chain is broken, the first entry that doesn't match."),
+		mcp.WithReadOnlyHintAnnotation(true),
+	), verifyAuditLogHandler)
+}
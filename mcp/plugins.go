@@ -0,0 +1,233 @@
+package mcp
+
+import (
+	gocontext "context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"strings"
+	"sync"
+
+	"github.com/flanksource/commons/logger"
+	"github.com/flanksource/duty/query"
+	"github.com/google/uuid"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/samber/lo"
+
+	"github.com/flanksource/incident-commander/plugin"
+	pluginAPI "github.com/flanksource/incident-commander/plugin/api"
+	"github.com/flanksource/incident-commander/plugin/gateway"
+	"github.com/flanksource/incident-commander/plugin/machinery"
+)
+
+type pluginOperationRef struct {
+	PluginID  uuid.UUID
+	Operation string
+}
+
+type pluginTool struct {
+	tool mcp.Tool
+	ref  pluginOperationRef
+
+	// fingerprint is the serialized tool definition. It lets a registry sync
+	// skip tools that did not change so clients aren't spammed with
+	// tools/list_changed notifications.
+	fingerprint string
+}
+
+var (
+	// ToolName -> plugin operation
+	currentPluginTools   = make(map[string]pluginTool)
+	currentPluginToolsMu sync.RWMutex
+)
+
+// registerPluginTools exposes every operation of every healthy plugin as an
+// MCP tool and keeps the tool list in sync with the plugin registry.
+func registerPluginTools(s *server.MCPServer) {
+	plugin.DefaultRegistry.Watch(func() {
+		syncPluginTools(s, plugin.DefaultRegistry.List())
+	})
+	syncPluginTools(s, plugin.DefaultRegistry.List())
+}
+
+func syncPluginTools(s *server.MCPServer, entries []*plugin.Entry) {
+	desired := getPluginOperationsAsTools(entries)
+
+	currentPluginToolsMu.Lock()
+	defer currentPluginToolsMu.Unlock()
+
+	var stale []string
+	for name := range currentPluginTools {
+		if _, ok := desired[name]; !ok {
+			stale = append(stale, name)
+		}
+	}
+
+	var added []server.ServerTool
+	for name, t := range desired {
+		if existing, ok := currentPluginTools[name]; ok && existing.fingerprint == t.fingerprint {
+			continue
+		}
+		added = append(added, server.ServerTool{Tool: t.tool, Handler: pluginOperationHandler})
+	}
+
+	if len(stale) > 0 {
+		s.DeleteTools(stale...)
+	}
+	if len(added) > 0 {
+		s.AddTools(added...)
+	}
+
+	clear(currentPluginTools)
+	maps.Copy(currentPluginTools, desired)
+
+	if len(stale) > 0 || len(added) > 0 {
+		logger.Debugf("synced plugin tools: added=%d removed=%d total=%d", len(added), len(stale), len(desired))
+	}
+}
+
+// pluginHealthy reports whether the plugin can be invoked in-process.
+// Proxied plugins are reached over the agent tunnel and are not exposed.
+func pluginHealthy(e *plugin.Entry) bool {
+	if e == nil || e.Manifest == nil || e.Runtime == nil {
+		return false
+	}
+	return e.Kind == "" || e.Kind == pluginAPI.PluginKindLocal || e.Kind == pluginAPI.PluginKindRemote
+}
+
+func getPluginOperationsAsTools(entries []*plugin.Entry) map[string]pluginTool {
+	tools := make(map[string]pluginTool)
+	for _, e := range entries {
+		if !pluginHealthy(e) {
+			continue
+		}
+
+		for _, def := range e.Manifest.Operations {
+			if def == nil || def.Name == "" {
+				continue
+			}
+
+			tool, err := pluginOperationAsTool(e, def)
+			if err != nil {
+				logger.Warnf("skipping plugin[%s] operation[%s]: %v", e.Name, def.Name, err)
+				continue
+			}
+
+			fingerprint, err := json.Marshal(tool)
+			if err != nil {
+				logger.Warnf("skipping plugin[%s] operation[%s]: error marshaling tool: %v", e.Name, def.Name, err)
+				continue
+			}
+
+			tools[tool.Name] = pluginTool{
+				tool:        tool,
+				ref:         pluginOperationRef{PluginID: e.ID, Operation: def.Name},
+				fingerprint: string(fingerprint),
+			}
+		}
+	}
+	return tools
+}
+
+func pluginOperationAsTool(e *plugin.Entry, def *pluginAPI.OperationDef) (mcp.Tool, error) {
+	paramsSchema := map[string]any{"type": "object"}
+	if def.ParamsSchema != nil {
+		paramsSchema = def.ParamsSchema.AsMap()
+	}
+
+	root := map[string]any{
+		"type":     "object",
+		"required": []string{"config_id"},
+		"properties": map[string]any{
+			"config_id": map[string]any{
+				"type":        "string",
+				"format":      "uuid",
+				"description": "ID of the config item the operation targets.",
+			},
+			"params": lo.Assign(map[string]any{
+				"description": "Parameters for the operation.",
+			}, paramsSchema),
+		},
+	}
+
+	rj, err := json.Marshal(root)
+	if err != nil {
+		return mcp.Tool{}, fmt.Errorf("error marshaling json schema: %w", err)
+	}
+
+	description := fmt.Sprintf(
+		`Invoke operation %s of plugin %s. %s
+Pass the target config item in config_id and the operation parameters in params.`,
+		def.Name, e.Name, lo.CoalesceOrEmpty(def.Description, e.Manifest.Description),
+	)
+
+	tool := mcp.NewToolWithRawSchema(generatePluginToolName(e, def.Name), description, rj)
+	tool.Annotations.Title = fmt.Sprintf("%s: %s", e.Name, def.Name)
+	tool.Annotations.DestructiveHint = lo.ToPtr(def.Destructive)
+	tool.Annotations.OpenWorldHint = lo.ToPtr(true)
+	return tool, nil
+}
+
+func generatePluginToolName(e *plugin.Entry, op string) string {
+	parts := []string{"plugin"}
+	if e.Namespace != "" {
+		parts = append(parts, e.Namespace)
+	}
+	parts = append(parts, e.Name, op)
+	toolName := strings.ToLower(strings.ReplaceAll(strings.Join(parts, "_"), " ", "-"))
+	return fixMCPToolNameIfRequired(toolName)
+}
+
+func pluginOperationHandler(goctx gocontext.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	ctx, err := getDutyCtx(goctx)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	toolName := req.Params.Name
+	currentPluginToolsMu.RLock()
+	t, ok := currentPluginTools[toolName]
+	currentPluginToolsMu.RUnlock()
+	if !ok {
+		return mcp.NewToolResultError(fmt.Sprintf("tool[%s] is not associated with any plugin operation", toolName)), nil
+	}
+
+	configID, err := req.RequireString("config_id")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	params := []byte("{}")
+	if raw, ok := req.GetArguments()["params"]; ok && raw != nil {
+		params, err = json.Marshal(raw)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+	}
+
+	entry, err := machinery.ResolvePlugin(ctx, t.ref.PluginID.String())
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	// Plugins act on behalf of the human behind the access token, so
+	// roles and invoke permissions are evaluated against the owner.
+	owner, err := resolveOwner(ctx)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	person, err := query.FindPerson(ctx, owner)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	} else if person == nil {
+		return mcp.NewToolResultError(fmt.Sprintf("person %s not found", owner)), nil
+	}
+
+	resp, err := gateway.InvokeOperationAsUser(ctx.WithUser(person).WithSubject(owner), entry, t.ref.Operation, configID, params)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	return mcp.NewToolResultText(string(resp.Result)), nil
+}
@@ -0,0 +1,60 @@
+package mcp
+
+import (
+	gocontext "context"
+	"encoding/json"
+
+	"github.com/google/uuid"
+	ginkgo "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/flanksource/incident-commander/plugin"
+	pluginAPI "github.com/flanksource/incident-commander/plugin/api"
+)
+
+type fakePluginRuntime struct{}
+
+func (fakePluginRuntime) Invoke(gocontext.Context, *pluginAPI.InvokeRequest) (*pluginAPI.InvokeResponse, error) {
+	return &pluginAPI.InvokeResponse{}, nil
+}
+func (fakePluginRuntime) UIPort() uint32 { return 0 }
+func (fakePluginRuntime) Stop()          {}
+
+var _ = ginkgo.Describe("getPluginOperationsAsTools", func() {
+	paramsSchema, _ := structpb.NewStruct(map[string]any{
+		"type":       "object",
+		"properties": map[string]any{"tail": map[string]any{"type": "integer"}},
+	})
+
+	manifest := &pluginAPI.PluginManifest{
+		Operations: []*pluginAPI.OperationDef{
+			{Name: "logs", Description: "Fetch pod logs", ParamsSchema: paramsSchema},
+			{Name: "restart", Destructive: true},
+		},
+	}
+
+	ginkgo.It("creates a tool per operation of running plugins", func() {
+		entries := []*plugin.Entry{
+			{ID: uuid.New(), Name: "kubectl", Namespace: "mc", Manifest: manifest, Runtime: fakePluginRuntime{}},
+			{ID: uuid.New(), Name: "starting", Manifest: nil, Runtime: fakePluginRuntime{}},
+			{ID: uuid.New(), Name: "proxied", Kind: pluginAPI.PluginKindProxied, Manifest: manifest, Runtime: fakePluginRuntime{}},
+		}
+
+		tools := getPluginOperationsAsTools(entries)
+		Expect(tools).To(HaveLen(2))
+		Expect(tools).To(HaveKey("plugin_mc_kubectl_logs"))
+		Expect(tools).To(HaveKey("plugin_mc_kubectl_restart"))
+
+		logs := tools["plugin_mc_kubectl_logs"]
+		Expect(logs.ref.Operation).To(Equal("logs"))
+		Expect(logs.ref.PluginID).To(Equal(entries[0].ID))
+		Expect(*tools["plugin_mc_kubectl_restart"].tool.Annotations.DestructiveHint).To(BeTrue())
+
+		var schema map[string]any
+		Expect(json.Unmarshal(logs.tool.RawInputSchema, &schema)).To(Succeed())
+		Expect(schema["required"]).To(ConsistOf("config_id"))
+		params := schema["properties"].(map[string]any)["params"].(map[string]any)
+		Expect(params["properties"]).To(HaveKey("tail"))
+	})
+})
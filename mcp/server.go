@@ -88,6 +88,7 @@ func RegisterStaticTools(s *server.MCPServer) {
 	registerNotifications(s)
 	registerTemplates(s)
 	registerAccess(s)
+	registerAudit(s)
 	registerResolve(s)
 }
 
@@ -105,6 +106,7 @@ func Server(ctx context.Context, serverOpts ...server.StreamableHTTPOption) *MCP
 	RegisterStaticTools(s)
 	registerPlaybookTools(s, hooks)
 	registerViewTools(s, hooks)
+	registerPluginTools(s)
 
 	logger.Infof("Registering /mcp routes")
 
@@ -0,0 +1,124 @@
+package metrics
+
+import (
+	"sync"
+	"time"
+
+	"github.com/flanksource/duty/context"
+	"github.com/flanksource/duty/models"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/flanksource/incident-commander/api"
+	v1 "github.com/flanksource/incident-commander/api/v1"
+	"github.com/flanksource/incident-commander/application"
+	"github.com/flanksource/incident-commander/db"
+)
+
+const (
+	applicationDORACacheTTLProperty = "metrics.application_dora.cache_ttl"
+	defaultApplicationDORACacheTTL  = 5 * time.Minute
+)
+
+// applicationsCollector exports the DORA metrics of the applications that
+// enable them.
+type applicationsCollector struct {
+	ctx   context.Context
+	mutex sync.Mutex
+	cache map[string]doraCache
+
+	deployments         *prometheus.Desc
+	deploymentFrequency *prometheus.Desc
+	leadTime            *prometheus.Desc
+	changeFailureRatio  *prometheus.Desc
+	mttr                *prometheus.Desc
+}
+
+type doraCache struct {
+	computedAt time.Time
+	metrics    []api.ApplicationDORAMetrics
+}
+
+func newApplicationsCollector(ctx context.Context) *applicationsCollector {
+	labels := []string{"namespace", "application", "environment"}
+	return &applicationsCollector{
+		ctx:                 ctx,
+		cache:               make(map[string]doraCache),
+		deployments:         prometheus.NewDesc(getMetricName(ctx, "application_deployments"), "Deployments of the application environment in the DORA window.", labels, nil),
+		deploymentFrequency: prometheus.NewDesc(getMetricName(ctx, "application_deployment_frequency_per_day"), "Deployments per day of the application environment.", labels, nil),
+		leadTime:            prometheus.NewDesc(getMetricName(ctx, "application_lead_time_seconds"), "Median time from a merged pull request to its deployment.", labels, nil),
+		changeFailureRatio:  prometheus.NewDesc(getMetricName(ctx, "application_change_failure_ratio"), "Ratio of deployments followed by a failure.", labels, nil),
+		mttr:                prometheus.NewDesc(getMetricName(ctx, "application_mttr_seconds"), "Mean time to restore from incidents and unhealthy config items.", labels, nil),
+	}
+}
+
+func (c *applicationsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.deployments
+	ch <- c.deploymentFrequency
+	ch <- c.leadTime
+	ch <- c.changeFailureRatio
+	ch <- c.mttr
+}
+
+func (c *applicationsCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx := c.ctx
+	if api.SystemUserID != nil {
+		ctx = ctx.WithUser(&models.Person{ID: *api.SystemUserID})
+	}
+
+	apps, err := db.GetAllApplications(ctx)
+	if err != nil {
+		c.ctx.Logger.Errorf("failed to collect applications: %v", err)
+		return
+	}
+
+	cacheTTL := c.ctx.Properties().Duration(applicationDORACacheTTLProperty, defaultApplicationDORACacheTTL)
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	current := map[string]bool{}
+	for _, model := range apps {
+		app, err := v1.ApplicationFromModel(model)
+		if err != nil {
+			c.ctx.Logger.Errorf("failed to parse application %s/%s: %v", model.Namespace, model.Name, err)
+			continue
+		} else if app.Spec.DORA == nil {
+			continue
+		}
+
+		id := model.ID.String()
+		current[id] = true
+
+		cached, ok := c.cache[id]
+		if !ok || time.Since(cached.computedAt) >= cacheTTL {
+			metrics, err := application.GetDORAMetrics(ctx, app, time.Now())
+			if err != nil {
+				c.ctx.Logger.Errorf("failed to compute dora metrics of application %s/%s: %v", app.Namespace, app.Name, err)
+				continue
+			}
+			cached = doraCache{computedAt: time.Now(), metrics: metrics}
+			c.cache[id] = cached
+		}
+
+		for _, m := range cached.metrics {
+			labels := []string{app.Namespace, app.Name, m.Environment}
+			ch <- prometheus.MustNewConstMetric(c.deployments, prometheus.GaugeValue, float64(m.Deployments), labels...)
+			ch <- prometheus.MustNewConstMetric(c.deploymentFrequency, prometheus.GaugeValue, m.DeploymentFrequency, labels...)
+			if m.LeadTimeSeconds != nil {
+				ch <- prometheus.MustNewConstMetric(c.leadTime, prometheus.GaugeValue, *m.LeadTimeSeconds, labels...)
+			}
+			if m.ChangeFailureRate != nil {
+				ch <- prometheus.MustNewConstMetric(c.changeFailureRatio, prometheus.GaugeValue, *m.ChangeFailureRate/100, labels...)
+			}
+			if m.MTTRSeconds != nil {
+				ch <- prometheus.MustNewConstMetric(c.mttr, prometheus.GaugeValue, *m.MTTRSeconds, labels...)
+			}
+		}
+	}
+
+	for id := range c.cache {
+		if !current[id] {
+			delete(c.cache, id)
+		}
+	}
+}
@@ -39,6 +39,14 @@ func RegisterDBStats(ctx context.Context) {
 		if metricEnabled(ctx, "scrapers_info") {
 			prometheus.MustRegister(newScrapersCollector(ctx))
 		}
+
+		if metricEnabled(ctx, "views") {
+			prometheus.MustRegister(newViewsCollector(ctx))
+		}
+
+		if metricEnabled(ctx, "application_dora") {
+			prometheus.MustRegister(newApplicationsCollector(ctx))
+		}
 	})
 }
 
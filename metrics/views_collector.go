@@ -0,0 +1,301 @@
+package metrics
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/flanksource/duty/context"
+	"github.com/flanksource/duty/models"
+	pkgView "github.com/flanksource/duty/view"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/samber/lo"
+	k8sTypes "k8s.io/apimachinery/pkg/types"
+
+	"github.com/flanksource/incident-commander/api"
+	v1 "github.com/flanksource/incident-commander/api/v1"
+	"github.com/flanksource/incident-commander/db"
+	"github.com/flanksource/incident-commander/views"
+)
+
+// viewsCollector exports the metrics declared on views.
+//
+// The metrics are only known once the views are read so the collector is
+// unchecked i.e. it doesn't describe any metric. Collect only reads the
+// cache; reading the views, which may refresh them, happens in the background.
+type viewsCollector struct {
+	ctx        context.Context
+	mutex      sync.Mutex
+	cache      map[k8sTypes.UID]viewMetricsCache
+	refreshing atomic.Bool
+}
+
+// viewMetricsCache holds the metrics of a view until the view's cache expires
+// so that scrapes don't read the view table every time.
+type viewMetricsCache struct {
+	view     string
+	cachedAt time.Time
+	spec     []v1.ViewMetric
+	families []viewMetricFamily
+}
+
+type viewMetricFamily struct {
+	name    string
+	metrics []prometheus.Metric
+}
+
+type viewSeries struct {
+	labels []string
+	value  float64
+}
+
+func newViewsCollector(ctx context.Context) *viewsCollector {
+	return &viewsCollector{
+		ctx:   ctx,
+		cache: make(map[k8sTypes.UID]viewMetricsCache),
+	}
+}
+
+func (c *viewsCollector) Describe(ch chan<- *prometheus.Desc) {}
+
+func (c *viewsCollector) Collect(ch chan<- prometheus.Metric) {
+	if c.refreshing.CompareAndSwap(false, true) {
+		go func() {
+			defer c.refreshing.Store(false)
+			c.refresh()
+		}()
+	}
+
+	c.mutex.Lock()
+	cached := lo.Values(c.cache)
+	c.mutex.Unlock()
+
+	sort.Slice(cached, func(i, j int) bool { return cached[i].view < cached[j].view })
+
+	// A metric name can only be exported by one view.
+	exported := map[string]string{}
+	for _, entry := range cached {
+		for _, family := range entry.families {
+			if owner, ok := exported[family.name]; ok {
+				c.ctx.Logger.Errorf("view %s: metric %s is already exported by view %s", entry.view, family.name, owner)
+				continue
+			}
+			exported[family.name] = entry.view
+			for _, metric := range family.metrics {
+				ch <- metric
+			}
+		}
+	}
+}
+
+// refresh rebuilds the metrics of the views whose cache has expired and
+// forgets the views that no longer export metrics.
+func (c *viewsCollector) refresh() {
+	ctx := c.ctx
+	if api.SystemUserID != nil {
+		ctx = ctx.WithUser(&models.Person{ID: *api.SystemUserID})
+	}
+
+	viewList, err := db.GetViewsWithMetrics(ctx)
+	if err != nil {
+		c.ctx.Logger.Errorf("failed to collect view metrics: %v", err)
+		return
+	}
+
+	current := map[k8sTypes.UID]bool{}
+	for _, view := range viewList {
+		current[view.UID] = true
+
+		c.mutex.Lock()
+		cached, hasCache := c.cache[view.UID]
+		c.mutex.Unlock()
+
+		cacheOptions, err := view.GetCacheOptions(0, 0)
+		if err != nil {
+			c.ctx.Logger.Errorf("failed to collect metrics of view %s/%s: %v", view.Namespace, view.Name, err)
+			continue
+		}
+		if hasCache && time.Since(cached.cachedAt) < cacheOptions.MaxAge && reflect.DeepEqual(cached.spec, view.Spec.Metrics) {
+			continue
+		}
+
+		families, err := c.viewMetrics(ctx, view)
+		if err != nil {
+			// Keep exporting the last metrics of the view
+			c.ctx.Logger.Errorf("failed to collect metrics of view %s/%s: %v", view.Namespace, view.Name, err)
+			continue
+		}
+
+		c.mutex.Lock()
+		c.cache[view.UID] = viewMetricsCache{
+			view:     view.Namespace + "/" + view.Name,
+			cachedAt: time.Now(),
+			spec:     view.Spec.Metrics,
+			families: families,
+		}
+		c.mutex.Unlock()
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for uid := range c.cache {
+		if !current[uid] {
+			delete(c.cache, uid)
+		}
+	}
+}
+
+// viewMetrics reads the view and returns its metrics. A metric or a series
+// that can't be built is logged and skipped, the others are still exported.
+func (c *viewsCollector) viewMetrics(ctx context.Context, view v1.View) ([]viewMetricFamily, error) {
+	// ReadOrPopulateViewTable only refreshes the view when its cache is older
+	// than the max age and falls back to the cache after the refresh timeout.
+	result, err := views.ReadOrPopulateViewTable(ctx, view.Namespace, view.Name, views.WithIncludeRows(true))
+	if err != nil {
+		return nil, err
+	}
+
+	var families []viewMetricFamily
+	for _, metric := range view.Spec.Metrics {
+		if !metricEnabled(c.ctx, metric.Name) {
+			continue
+		}
+
+		series, err := viewMetricSeries(metric, result)
+		if err != nil {
+			c.ctx.Logger.Errorf("view %s/%s: metric %s: %v", view.Namespace, view.Name, metric.Name, err)
+			continue
+		}
+
+		labels := make([]string, 0, len(metric.Labels))
+		used := map[string]struct{}{}
+		for _, label := range metric.Labels {
+			labels = append(labels, ensureUniqueLabel(sanitizeTagLabel(label), used))
+		}
+
+		help := lo.CoalesceOrEmpty(metric.Help, fmt.Sprintf("Exported from view %s/%s.", view.Namespace, view.Name))
+		family := viewMetricFamily{name: getMetricName(c.ctx, metric.Name)}
+		desc := prometheus.NewDesc(family.name, help, labels, nil)
+		for _, s := range series {
+			m, err := prometheus.NewConstMetric(desc, prometheus.GaugeValue, s.value, s.labels...)
+			if err != nil {
+				c.ctx.Logger.Errorf("view %s/%s: metric %s: skipping series %v: %v", view.Namespace, view.Name, metric.Name, s.labels, err)
+				continue
+			}
+			family.metrics = append(family.metrics, m)
+		}
+		families = append(families, family)
+	}
+
+	return families, nil
+}
+
+// viewMetricSeries returns the series of the metric from the rows of its
+// panel or of the view table.
+func viewMetricSeries(metric v1.ViewMetric, result *api.ViewResult) ([]viewSeries, error) {
+	var series []viewSeries
+	index := map[string]int{}
+	add := func(labels []string, value float64, sum bool) {
+		key := strings.Join(labels, "\x00")
+		if i, ok := index[key]; ok {
+			series[i].value = lo.Ternary(sum, series[i].value+value, value)
+			return
+		}
+		index[key] = len(series)
+		series = append(series, viewSeries{labels: labels, value: value})
+	}
+
+	if metric.Panel != "" {
+		panel, ok := lo.Find(result.Panels, func(p api.PanelResult) bool { return p.Name == metric.Panel })
+		if !ok {
+			return nil, fmt.Errorf("panel %s has no result", metric.Panel)
+		}
+
+		valueKey := lo.CoalesceOrEmpty(metric.Value, panel.ValueKey())
+		for _, row := range panel.Rows {
+			value, ok := toFloat64(row[valueKey])
+			if !ok {
+				continue
+			}
+			labels := lo.Map(metric.Labels, func(label string, _ int) string { return labelValue(row[label]) })
+			add(labels, value, false)
+		}
+		return series, nil
+	}
+
+	columns := map[string]int{}
+	for i, column := range result.Columns {
+		columns[column.Name] = i
+	}
+	cell := func(row pkgView.Row, column string) any {
+		if i, ok := columns[column]; ok && i < len(row) {
+			return row[i]
+		}
+		return nil
+	}
+
+	for _, row := range result.Rows {
+		value := float64(1)
+		if metric.Value != "" {
+			var ok bool
+			if value, ok = toFloat64(cell(row, metric.Value)); !ok {
+				continue
+			}
+		}
+		labels := lo.Map(metric.Labels, func(label string, _ int) string { return labelValue(cell(row, label)) })
+		add(labels, value, true)
+	}
+
+	return series, nil
+}
+
+func labelValue(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case time.Time:
+		return v.Format(time.RFC3339)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func toFloat64(value any) (float64, bool) {
+	switch v := value.(type) {
+	case int:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint:
+		return float64(v), true
+	case uint32:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case float32:
+		return float64(v), true
+	case float64:
+		return v, true
+	case bool:
+		return lo.Ternary(v, 1.0, 0.0), true
+	case time.Duration:
+		return v.Seconds(), true
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return f, err == nil
+	}
+	return 0, false
+}
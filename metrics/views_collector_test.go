@@ -0,0 +1,79 @@
+package metrics
+
+import (
+	"github.com/flanksource/duty/dataquery"
+	pkgView "github.com/flanksource/duty/view"
+	ginkgo "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/flanksource/incident-commander/api"
+	v1 "github.com/flanksource/incident-commander/api/v1"
+)
+
+var _ = ginkgo.Describe("viewMetricSeries", func() {
+	table := &api.ViewResult{
+		Columns: []pkgView.ColumnDef{{Name: "namespace"}, {Name: "status"}, {Name: "replicas"}},
+		Rows: []pkgView.Row{
+			{"default", "healthy", 2},
+			{"default", "healthy", "3"},
+			{"default", "unhealthy", 1},
+			{"kube-system", "healthy", "n/a"},
+		},
+		Panels: []api.PanelResult{
+			{
+				PanelMeta: api.PanelMeta{Name: "latency", Type: api.PanelTypeTimeseries},
+				Rows: []dataquery.QueryResultRow{
+					{"service": "api", "value": 10.5},
+					{"service": "api", "value": 12.0},
+					{"service": "web", "value": "bad"},
+					{"service": "db", "value": int64(3)},
+				},
+			},
+		},
+	}
+
+	seriesOf := func(series []viewSeries) map[string]float64 {
+		out := map[string]float64{}
+		for _, s := range series {
+			key := ""
+			for _, label := range s.labels {
+				key += label + "/"
+			}
+			out[key] = s.value
+		}
+		return out
+	}
+
+	ginkgo.It("counts the table rows by label", func() {
+		series, err := viewMetricSeries(v1.ViewMetric{Name: "rows", Labels: []string{"namespace", "status"}}, table)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(seriesOf(series)).To(Equal(map[string]float64{
+			"default/healthy/":     2,
+			"default/unhealthy/":   1,
+			"kube-system/healthy/": 1,
+		}))
+	})
+
+	ginkgo.It("sums the value column and skips rows without a number", func() {
+		series, err := viewMetricSeries(v1.ViewMetric{Name: "replicas", Value: "replicas", Labels: []string{"namespace"}}, table)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(seriesOf(series)).To(Equal(map[string]float64{"default/": 6}))
+	})
+
+	ginkgo.It("keeps the last value of each panel series", func() {
+		series, err := viewMetricSeries(v1.ViewMetric{Name: "latency", Panel: "latency", Labels: []string{"service"}}, table)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(seriesOf(series)).To(Equal(map[string]float64{"api/": 12, "db/": 3}))
+	})
+
+	ginkgo.It("labels missing columns with an empty value", func() {
+		series, err := viewMetricSeries(v1.ViewMetric{Name: "rows", Labels: []string{"cluster"}}, table)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(seriesOf(series)).To(Equal(map[string]float64{"/": 4}))
+	})
+
+	ginkgo.It("fails when the panel has no result", func() {
+		_, err := viewMetricSeries(v1.ViewMetric{Name: "missing", Panel: "missing"}, table)
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -13,6 +13,8 @@ import (
 	"github.com/flanksource/duty/models"
 	"github.com/flanksource/duty/types"
 	"github.com/samber/lo"
+
+	"github.com/flanksource/incident-commander/api"
 )
 
 type celVariables struct {
@@ -40,6 +42,10 @@ type celVariables struct {
 	Comment *models.Comment
 	Author  *models.Person
 
+	ViewThreshold     *api.ViewThresholdEvent
+	ApplicationSLO    *api.ApplicationSLOEvent
+	ApplicationBackup *api.ApplicationBackupEvent
+
 	NewState   string
 	Permalink  string
 	SilenceURL string
@@ -152,6 +158,18 @@ func (t *celVariables) AsMap(ctx context.Context, opts ...celAsMapOption) map[st
 		output["new_state"] = t.NewState
 	}
 
+	if t.ViewThreshold != nil {
+		output = collections.MergeMap(output, t.ViewThreshold.AsMap())
+	}
+
+	if t.ApplicationSLO != nil {
+		output = collections.MergeMap(output, t.ApplicationSLO.AsMap())
+	}
+
+	if t.ApplicationBackup != nil {
+		output = collections.MergeMap(output, t.ApplicationBackup.AsMap())
+	}
+
 	resourceContext := duty.GetResourceContext(ctx, t.SelectableResource())
 	if ctx.DB() != nil && slices.Contains(opts, celVarGetLatestHealthStatus) {
 		if r, err := t.GetResourceCurrentHealthStatus(ctx); err == nil {
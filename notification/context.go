@@ -49,7 +49,12 @@ func (t *Context) StartLog() error {
 }
 
 func (t *Context) EndLog() error {
-	return t.DB().Save(t.log.End()).Error
+	if err := t.DB().Save(t.log.End()).Error; err != nil {
+		return err
+	}
+
+	reportToUpstream(t.Context, *t.log)
+	return nil
 }
 
 func (t *Context) WithMessage(message string) {
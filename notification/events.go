@@ -49,7 +49,7 @@ func init() {
 func RegisterEvents(ctx context.Context) {
 	EventRing = events.NewEventRing(ctx.Properties().Int("events.audit.size", events.DefaultEventLogSize))
 	nh := notificationHandler{Ring: EventRing}
-	events.RegisterSyncHandlerNamed("notification.addNotificationEvent", nh.addNotificationEvent, append(api.EventStatusGroup, api.EventIncidentGroup...)...)
+	events.RegisterSyncHandlerNamed("notification.addNotificationEvent", nh.addNotificationEvent, lo.Flatten([][]string{api.EventStatusGroup, api.EventIncidentGroup, api.EventViewThresholdGroup, api.EventApplicationSLOGroup, api.EventApplicationBackupGroup})...)
 
 	events.RegisterAsyncHandler("notification.sendNotifications", sendNotifications, 1, 5, api.EventNotificationSend)
 }
@@ -1038,6 +1038,32 @@ func GetEnvForEvent(ctx context.Context, event models.Event) (*celVariables, err
 		env.Permalink = fmt.Sprintf("%s/catalog/%s", api.FrontendURL, configID)
 	}
 
+	if strings.HasPrefix(event.Name, "view.threshold.") {
+		viewThreshold, err := api.ViewThresholdEventFromProperties(event.Properties)
+		if err != nil {
+			return nil, fmt.Errorf("invalid view threshold event(id=%s): %w", event.EventID, err)
+		}
+
+		env.ViewThreshold = viewThreshold
+		env.Permalink = fmt.Sprintf("%s/view/%s/%s", api.FrontendURL, viewThreshold.Namespace, viewThreshold.View)
+	}
+
+	if strings.HasPrefix(event.Name, "application.slo.") {
+		slo, err := api.ApplicationSLOEventFromProperties(event.Properties)
+		if err != nil {
+			return nil, fmt.Errorf("invalid application slo event(id=%s): %w", event.EventID, err)
+		}
+
+		env.ApplicationSLO = slo
+		env.Permalink = fmt.Sprintf("%s/applications/%s", api.FrontendURL, slo.ApplicationID)
+	}
+
+	if strings.HasPrefix(event.Name, "application.backup.") {
+		backup := api.ApplicationBackupEventFromProperties(event.Properties)
+		env.ApplicationBackup = backup
+		env.Permalink = fmt.Sprintf("%s/applications/%s", api.FrontendURL, backup.ApplicationID)
+	}
+
 	env.SetSilenceURL(api.FrontendURL)
 	return &env, nil
 }
@@ -159,6 +159,44 @@ func BuildNotificationMessagePayload(payload NotificationEventPayload, env *celV
 		msg.Title = fmt.Sprintf("%s status updated", lo.FromPtr(env.Incident).Title)
 		msg.Description = fmt.Sprintf("New Status: %s", lo.FromPtr(env.Incident).Status)
 		msg.Actions = []NotificationAction{{Label: "Reference", URL: env.Permalink}}
+	case icapi.EventViewThresholdBreached, icapi.EventViewThresholdRecovered:
+		threshold := lo.FromPtr(env.ViewThreshold)
+		state := lo.Ternary(payload.EventName == icapi.EventViewThresholdBreached, "breached", "recovered")
+		msg.Title = fmt.Sprintf("%s: %s %s", safeName(threshold.Panel), safeName(threshold.Threshold), state)
+		msg.Description = threshold.Message
+		msg.Attributes = append(msg.Attributes,
+			keyValue("View", fmt.Sprintf("%s/%s", threshold.Namespace, threshold.View)),
+			keyValue("Value", lo.Ternary(threshold.Value == nil, "", fmt.Sprint(threshold.Value))),
+			keyValue("Condition", threshold.Condition),
+			keyValue("Severity", threshold.Severity),
+		)
+		msg.Actions = []NotificationAction{{Label: "View", URL: env.Permalink}}
+	case icapi.EventApplicationSLOFastBurn, icapi.EventApplicationSLOSlowBurn, icapi.EventApplicationSLORecovered:
+		slo := lo.FromPtr(env.ApplicationSLO)
+		state := lo.Ternary(payload.EventName == icapi.EventApplicationSLORecovered, "recovered", slo.Burn+" burn")
+		msg.Title = fmt.Sprintf("%s: SLO %s %s", safeName(slo.Application), safeName(slo.SLO), state)
+		msg.Attributes = append(msg.Attributes,
+			keyValue("Application", fmt.Sprintf("%s/%s", slo.Namespace, slo.Application)),
+			keyValue("Target", fmt.Sprintf("%g%% over %s", slo.Target, slo.Window)),
+			keyValue("Attainment", formatPercent(slo.Attainment)),
+			keyValue("Error Budget Remaining", formatPercent(slo.ErrorBudgetRemaining)),
+		)
+		if slo.Burn != "" {
+			msg.Attributes = append(msg.Attributes, keyValue("Burn Rate", fmt.Sprintf("%.1fx", slo.BurnRate)))
+		}
+		msg.Actions = []NotificationAction{{Label: "Application", URL: env.Permalink}}
+	case icapi.EventApplicationBackupViolated, icapi.EventApplicationBackupCompliant:
+		backup := lo.FromPtr(env.ApplicationBackup)
+		state := lo.Ternary(payload.EventName == icapi.EventApplicationBackupCompliant, "met", "violated")
+		msg.Title = fmt.Sprintf("%s: %s %s of %s %s", safeName(backup.Application), safeName(backup.Datasource), strings.ToUpper(strings.ReplaceAll(backup.Objective, "_", " ")), backup.Target, state)
+		msg.Description = backup.Message
+		msg.Attributes = append(msg.Attributes,
+			keyValue("Application", fmt.Sprintf("%s/%s", backup.Namespace, backup.Application)),
+			keyValue("Datasource", backup.Datasource),
+			keyValue("Objective", backup.Objective),
+			keyValue("Target", backup.Target),
+		)
+		msg.Actions = []NotificationAction{{Label: "Application", URL: env.Permalink}}
 	default:
 		msg.Title = payload.EventName
 	}
@@ -471,3 +509,10 @@ func stringPtr(value *string) string {
 	}
 	return *value
 }
+
+func formatPercent(value *float64) string {
+	if value == nil {
+		return ""
+	}
+	return fmt.Sprintf("%.2f%%", *value)
+}
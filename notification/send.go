@@ -13,12 +13,14 @@ import (
 	pkgConnection "github.com/flanksource/duty/connection"
 	"github.com/flanksource/duty/context"
 	"github.com/flanksource/duty/models"
+	"github.com/flanksource/duty/query"
 	"github.com/flanksource/duty/types"
 	"github.com/flanksource/gomplate/v3"
 	"github.com/google/uuid"
 	"go.opentelemetry.io/otel/trace"
 
 	"github.com/flanksource/incident-commander/api"
+	v1 "github.com/flanksource/incident-commander/api/v1"
 	"github.com/flanksource/incident-commander/db"
 	"github.com/flanksource/incident-commander/events"
 	"github.com/flanksource/incident-commander/logs"
@@ -230,6 +232,83 @@ func resolveRecipientAndSend(ctx *Context, payload NotificationEventPayload, cel
 	return fmt.Errorf("no recipient resolved for notification(id=%s) event=%s", payload.NotificationID, payload.EventName)
 }
 
+// SendToRecipient sends data to a recipient outside of a notification, e.g. a
+// scheduled report. A team is sent to through all of its notification channels.
+// Playbook and webhook recipients are not supported as they act on an event.
+func SendToRecipient(ctx *Context, recipient v1.NotificationRecipientSpec, celEnv map[string]any, data NotificationTemplate) error {
+	data.Properties = collections.MergeMap(data.Properties, recipient.Properties)
+
+	switch {
+	case recipient.Person != "":
+		person, err := query.FindPerson(ctx.Context, recipient.Person)
+		if err != nil {
+			return fmt.Errorf("failed to get person %s: %w", recipient.Person, err)
+		} else if person == nil {
+			return fmt.Errorf("person %s not found", recipient.Person)
+		} else if strings.TrimSpace(person.Email) == "" {
+			return fmt.Errorf("person %s has no email address", recipient.Person)
+		}
+
+		ctx.WithRecipient(RecipientTypePerson, &person.ID)
+		smtpURL := fmt.Sprintf("%s?ToAddresses=%s", api.SystemSMTP, url.QueryEscape(person.Email))
+		_, err = SendRawNotification(ctx, "", smtpURL, celEnv, data, nil)
+		return err
+
+	case recipient.Email != "":
+		ctx.WithRecipient(RecipientTypeURL, nil)
+		smtpURL := fmt.Sprintf("%s?ToAddresses=%s", api.SystemSMTP, url.QueryEscape(recipient.Email))
+		_, err := SendRawNotification(ctx, "", smtpURL, celEnv, data, nil)
+		return err
+
+	case recipient.Team != "":
+		team, err := query.FindTeam(ctx.Context, recipient.Team)
+		if err != nil {
+			return fmt.Errorf("failed to get team %s: %w", recipient.Team, err)
+		} else if team == nil {
+			return fmt.Errorf("team %s not found", recipient.Team)
+		}
+
+		ctx.WithRecipient(RecipientTypeTeam, &team.ID)
+		teamSpec, err := teams.GetTeamSpec(ctx.Context, team.ID.String())
+		if err != nil {
+			return fmt.Errorf("failed to get team(id=%s); %v", team.ID, err)
+		}
+
+		var sent int
+		for _, cn := range teamSpec.Notifications {
+			if cn.Webhook != nil {
+				continue
+			}
+
+			teamData := data
+			teamData.Properties = collections.MergeMap(collections.MergeMap(nil, cn.Properties), data.Properties)
+			if _, err := SendRawNotification(ctx, cn.Connection, cn.URL, celEnv, teamData, nil); err != nil {
+				return fmt.Errorf("failed to send to team %s notification %s: %w", recipient.Team, cn.Name, err)
+			}
+			sent++
+		}
+
+		if sent == 0 {
+			return fmt.Errorf("team %s has no notification channels", recipient.Team)
+		}
+		return nil
+
+	case recipient.Connection != "":
+		_, err := SendRawNotification(ctx, recipient.Connection, "", celEnv, data, nil)
+		return err
+
+	case recipient.URL != "":
+		ctx.WithRecipient(RecipientTypeURL, nil)
+		_, err := SendRawNotification(ctx, "", recipient.URL, celEnv, data, nil)
+		return err
+
+	case recipient.Playbook != nil, recipient.Webhook != nil:
+		return fmt.Errorf("playbook and webhook recipients are not supported")
+	}
+
+	return fmt.Errorf("recipient is empty")
+}
+
 // PrepareAndSendEventNotification generates the notification from the given event and sends it.
 func PrepareAndSendEventNotification(ctx *Context, payload NotificationEventPayload, celEnv *celVariables) error {
 	notification, err := GetNotification(ctx.Context, payload.NotificationID.String())
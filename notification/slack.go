@@ -1,6 +1,7 @@
 package notification
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -59,7 +60,7 @@ func SlackSend(ctx *Context, apiToken, channel string, msg NotificationTemplate)
 		}
 	}
 
-	_, _, err := api.PostMessageContext(ctx, channel, opts...)
+	channelID, _, err := api.PostMessageContext(ctx, channel, opts...)
 
 	var slackError slack.SlackErrorResponse
 	if errors.As(err, &slackError) {
@@ -69,5 +70,27 @@ func SlackSend(ctx *Context, apiToken, channel string, msg NotificationTemplate)
 		}
 	}
 
-	return ctx.Oops().Hint(msg.Message).Wrap(err)
+	if err != nil {
+		return ctx.Oops().Hint(msg.Message).Wrap(err)
+	}
+
+	// Files can only be shared to a channel by its id, which the message
+	// response carries even when the message was posted by channel name.
+	for _, attachment := range msg.Attachments {
+		if len(attachment.Content) == 0 {
+			continue
+		}
+
+		if _, err := api.UploadFileContext(ctx, slack.UploadFileParameters{
+			Channel:  channelID,
+			Filename: attachment.Filename,
+			Title:    attachment.Filename,
+			FileSize: len(attachment.Content),
+			Reader:   bytes.NewReader(attachment.Content),
+		}); err != nil {
+			return ctx.Oops().Hint("ensure the bot has the files:write scope").Wrapf(err, "failed to upload %s to slack", attachment.Filename)
+		}
+	}
+
+	return nil
 }
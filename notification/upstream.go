@@ -0,0 +1,64 @@
+package notification
+
+import (
+	"time"
+
+	"github.com/flanksource/duty/context"
+	"github.com/flanksource/duty/models"
+	"github.com/flanksource/duty/types"
+	"github.com/flanksource/duty/upstream"
+	"github.com/samber/lo"
+
+	"github.com/flanksource/incident-commander/api"
+	"github.com/flanksource/incident-commander/upstream/outbox"
+)
+
+// Job history an agent pushes to report the notifications it sent to the
+// upstream.
+const (
+	UpstreamReportJobName      = "NotificationSend"
+	UpstreamReportResourceType = "notification_send_history"
+)
+
+// reportToUpstream queues the outcome of a notification an agent sent for
+// delivery to the upstream, so it's delivered once the upstream is reachable.
+// Sends that are still in progress are reported when they end.
+func reportToUpstream(ctx context.Context, history models.NotificationSendHistory) {
+	if !api.UpstreamConf.Valid() || !ctx.Properties().On(true, "notifications.upstream.report") {
+		return
+	}
+
+	status := models.StatusSkipped
+	switch history.Status {
+	case models.NotificationStatusSent:
+		status = models.StatusSuccess
+	case models.NotificationStatusError:
+		status = models.StatusFailed
+	case models.NotificationStatusPending, models.NotificationStatusPendingPlaybookRun, models.NotificationStatusPendingPlaybookCompletion,
+		models.NotificationStatusEvaluatingWaitFor, models.NotificationStatusAttemptingFallback:
+		return
+	}
+
+	report := models.JobHistory{
+		ID:           history.ID,
+		Name:         UpstreamReportJobName,
+		ResourceType: UpstreamReportResourceType,
+		ResourceID:   history.NotificationID.String(),
+		TimeStart:    history.CreatedAt,
+		TimeEnd:      lo.ToPtr(history.CreatedAt.Add(time.Duration(history.DurationMillis) * time.Millisecond)),
+		Status:       status,
+		Details: types.JSONMap{
+			"status":       history.Status,
+			"source_event": history.SourceEvent,
+			"resource_id":  history.ResourceID,
+			"count":        history.Count,
+		},
+	}
+	if history.Error != nil {
+		report.Details["error"] = *history.Error
+	}
+
+	if err := outbox.Enqueue(ctx, outbox.KindPush, outbox.PriorityNormal, upstream.PushData{JobHistory: []models.JobHistory{report}}); err != nil {
+		ctx.Warnf("failed to queue notification send %s for the upstream: %v", history.ID, err)
+	}
+}
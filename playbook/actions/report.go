@@ -26,6 +26,8 @@ import (
 	"github.com/flanksource/incident-commander/pkg/clients/git/connectors"
 	"github.com/flanksource/incident-commander/report"
 	"github.com/flanksource/incident-commander/report/catalog"
+	"github.com/flanksource/incident-commander/report/findings"
+	"github.com/flanksource/incident-commander/report/xlsx"
 	"github.com/flanksource/incident-commander/views"
 )
 
@@ -193,6 +195,12 @@ func (r *Report) renderCatalog(ctx context.Context, action v1.ReportAction, data
 		return r.renderCatalogFacet(ctx, action, data, "html")
 	case "pdf", "facet-pdf":
 		return r.renderCatalogFacet(ctx, action, data, "pdf")
+	case "xlsx":
+		return catalog.RenderXLSX(&data)
+	case "sarif":
+		return findings.RenderSARIF(findings.FromCatalogReport(&data))
+	case "ocsf":
+		return findings.RenderOCSF(findings.FromCatalogReport(&data), data.GeneratedAt)
 	default:
 		return json.MarshalIndent(data, "", "  ")
 	}
@@ -254,6 +262,10 @@ func catalogOptions(action v1.ReportAction) (catalog.Options, error) {
 	if err != nil {
 		return catalog.Options{}, err
 	}
+	persist, err := reportBool(action.Persist, false, "persist")
+	if err != nil {
+		return catalog.Options{}, err
+	}
 	settings, settingsSource, err := catalog.ResolveSettings("")
 	if err != nil {
 		return catalog.Options{}, fmt.Errorf("load report settings: %w", err)
@@ -275,6 +287,8 @@ func catalogOptions(action v1.ReportAction) (catalog.Options, error) {
 		Audit:           audit,
 		Settings:        settings,
 		SettingsPath:    settingsSource,
+		Persist:         persist,
+		DiffAgainst:     action.DiffAgainst,
 	}
 
 	if action.Sections != nil {
@@ -443,6 +457,9 @@ var formatMeta = map[string]struct {
 	"html":       {"text/html", ".html"},
 	"facet-html": {"text/html", ".html"},
 	"csv":        {"text/csv", ".csv"},
+	"xlsx":       {xlsx.ContentType, xlsx.Extension},
+	"sarif":      {findings.SARIFContentType, ".sarif"},
+	"ocsf":       {"application/json", ".ocsf.json"},
 }
 
 func formatContentType(format string) string {
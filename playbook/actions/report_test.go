@@ -53,6 +53,8 @@ var _ = ginkgo.Describe("Report action catalog options", func() {
 			ChangeArtifacts: v1.TemplatedBool(`true`),
 			ExpandGroups:    v1.TemplatedBool(`true`),
 			Audit:           v1.TemplatedBool(`true`),
+			Persist:         v1.TemplatedBool(`false`),
+			DiffAgainst:     "30d",
 			Filters:         []string{"type=Kubernetes::Pod", "health=unhealthy,status=warning"},
 			Sections: &v1.ReportSections{
 				Changes:          v1.TemplatedBool(`true`),
@@ -74,6 +76,8 @@ var _ = ginkgo.Describe("Report action catalog options", func() {
 		Expect(opts.ChangeArtifacts).To(BeTrue())
 		Expect(opts.ExpandGroups).To(BeTrue())
 		Expect(opts.Audit).To(BeTrue())
+		Expect(opts.Persist).To(BeFalse())
+		Expect(opts.DiffAgainst).To(Equal("30d"))
 		Expect(opts.Settings.Filters).To(ContainElements("type=Kubernetes::Pod", "health=unhealthy", "status=warning"))
 		Expect(opts.Sections.Changes).To(BeTrue())
 		Expect(opts.Sections.Insights).To(BeFalse())
@@ -88,6 +92,7 @@ var _ = ginkgo.Describe("Report action catalog options", func() {
 	ginkgo.It("uses section defaults for omitted values", func() {
 		opts, err := catalogOptions(v1.ReportAction{Sections: &v1.ReportSections{}})
 		Expect(err).NotTo(HaveOccurred())
+		Expect(opts.Persist).To(BeTrue())
 		Expect(opts.Sections.Changes).To(BeTrue())
 		Expect(opts.Sections.Insights).To(BeTrue())
 		Expect(opts.Sections.Relationships).To(BeTrue())
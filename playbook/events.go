@@ -27,7 +27,7 @@ import (
 )
 
 type PlaybookSpecEvent struct {
-	Class string // canary or component
+	Class string // canary, component, config or view
 	Event string // varies depending on the type
 }
 
@@ -50,6 +50,9 @@ var eventToSpecEvent = map[string]PlaybookSpecEvent{
 	api.EventComponentUnhealthy: {"component", "unhealthy"},
 	api.EventComponentWarning:   {"component", "warning"},
 	api.EventComponentUnknown:   {"component", "unknown"},
+
+	api.EventViewThresholdBreached:  {"view", "breached"},
+	api.EventViewThresholdRecovered: {"view", "recovered"},
 }
 
 var (
@@ -69,7 +72,7 @@ func init() {
 func RegisterEvents(ctx context.Context) {
 	EventRing = events.NewEventRing(ctx.Properties().Int("events.audit.size", events.DefaultEventLogSize))
 	ps := playbookScheduler{Ring: EventRing}
-	events.RegisterSyncHandlerNamed("playbook.Handle", ps.Handle, api.EventStatusGroup...)
+	events.RegisterSyncHandlerNamed("playbook.Handle", ps.Handle, append(api.EventStatusGroup, api.EventViewThresholdGroup...)...)
 
 	events.RegisterSyncHandlerNamed("playbook.onNewRun", onNewRun, api.EventPlaybookRun)
 	events.RegisterSyncHandlerNamed("playbook.onApprovalUpdated", onApprovalUpdated, api.EventPlaybookSpecApprovalUpdated)
@@ -156,6 +159,18 @@ func (t *playbookScheduler) Handle(ctx context.Context, event models.Event) erro
 					return err
 				}
 			}
+		case "view":
+			// There's no resource to run the playbook on.
+			// The threshold is passed as the request of the run.
+			run.Request = celEnv
+			if ok, err := matchResource(ctx, nil, celEnv, playbook.Spec.On.View); err != nil {
+				logToJobHistory(ctx, p.ID.String(), err.Error())
+				continue
+			} else if ok {
+				if err := ctx.DB().Create(&run).Error; err != nil {
+					return err
+				}
+			}
 		}
 	}
 
@@ -12,33 +12,75 @@ import (
 	"github.com/flanksource/duty/models"
 	"github.com/flanksource/duty/upstream"
 	"github.com/flanksource/incident-commander/playbook/runner"
+	"github.com/flanksource/incident-commander/upstream/outbox"
 	"github.com/google/uuid"
+	"github.com/samber/lo"
 	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
 )
 
 var pushPullLagBuckets = []float64{100, 200, 500, 1000, 1500, 3000, 5000, 10_000, 20_000, 30_000, 60_000, 100_000}
 
-// PushPlaybookActions pushes unpushed playbook actions to the upstream
-func PushPlaybookActions(ctx context.Context, upstreamConfig upstream.UpstreamConfig, batchSize int) (int, error) {
+const playbookActionsSource = "playbook_run_actions"
+
+func init() {
+	outbox.RegisterAck(playbookActionsSource, markPlaybookActionsPushed)
+}
+
+func markPlaybookActionsPushed(tx *gorm.DB, ids []string) error {
+	if err := tx.Model(&models.PlaybookRunAction{}).Where("id IN ?", ids).Update("is_pushed", true).Error; err != nil {
+		return fmt.Errorf("failed to update is_pushed on playbook actions: %w", err)
+	}
+	return nil
+}
+
+// PushPlaybookActions pushes unpushed playbook actions to the upstream.
+// It returns the number of actions pushed and the number handed over to the
+// outbox, which are only marked pushed once the outbox delivers them.
+func PushPlaybookActions(ctx context.Context, upstreamConfig upstream.UpstreamConfig, batchSize int) (pushed, queued int, err error) {
 	client := upstream.NewUpstreamClient(upstreamConfig)
-	count := 0
+	offline := false
 	for {
 		var actions []models.PlaybookRunAction
 		if err := ctx.DB().Select("id, status, result, error, start_time, end_time").
 			Where("is_pushed IS FALSE").
 			Where("status IN ?", models.PlaybookActionFinalStates).
+			Where(outbox.NotQueued(playbookActionsSource)).
 			Limit(batchSize).
 			Find(&actions).Error; err != nil {
-			return 0, fmt.Errorf("failed to fetch playbook_run_actions: %w", err)
+			return pushed, queued, fmt.Errorf("failed to fetch playbook_run_actions: %w", err)
 		}
 
 		if len(actions) == 0 {
-			return count, nil
+			return pushed, queued, nil
+		}
+
+		ids := make([]uuid.UUID, len(actions))
+		for i := range actions {
+			ids[i] = actions[i].ID
 		}
 
-		ctx.Tracef("pushing %d playbook actions to upstream", len(actions))
-		if err := client.Push(ctx, &upstream.PushData{PlaybookActions: actions}); err != nil {
-			return 0, fmt.Errorf("failed to push playbook actions to upstream: %w", err)
+		var pushErr error
+		if !offline {
+			ctx.Tracef("pushing %d playbook actions to upstream", len(actions))
+			pushErr = client.Push(ctx, &upstream.PushData{PlaybookActions: actions})
+		}
+
+		if offline || pushErr != nil {
+			// Hand the results over to the outbox so they are retried with a
+			// backoff. If it is full they stay unpushed and are retried from here.
+			if err := outbox.EnqueueRows(ctx, playbookActionsSource, ids, outbox.KindPush, outbox.PriorityHigh, upstream.PushData{PlaybookActions: actions}); err != nil {
+				if pushErr == nil {
+					pushErr = fmt.Errorf("upstream is unreachable")
+				}
+				return pushed, queued, fmt.Errorf("failed to push playbook actions to upstream: %w (outbox: %v)", pushErr, err)
+			}
+			if !offline {
+				ctx.Warnf("queued playbook actions in the outbox: %v", pushErr)
+			}
+			offline = true
+			queued += len(actions)
+			continue
 		}
 
 		for _, action := range actions {
@@ -53,15 +95,11 @@ func PushPlaybookActions(ctx context.Context, upstreamConfig upstream.UpstreamCo
 			}
 		}
 
-		ids := make([]uuid.UUID, len(actions))
-		for i := range actions {
-			ids[i] = actions[i].ID
-		}
-		if err := ctx.DB().Model(&models.PlaybookRunAction{}).Where("id IN ?", ids).Update("is_pushed", true).Error; err != nil {
-			return 0, fmt.Errorf("failed to update is_pushed on playbook actions: %w", err)
+		if err := markPlaybookActionsPushed(ctx.DB(), lo.Map(ids, func(id uuid.UUID, _ int) string { return id.String() })); err != nil {
+			return pushed, queued, err
 		}
 
-		count += len(actions)
+		pushed += len(actions)
 	}
 }
 
@@ -829,7 +829,7 @@ var _ = Describe("Playbook", Ordered, func() {
 		})
 
 		It("(aws) should push the action result to the upstream", func() {
-			pushed, err := PushPlaybookActions(awsAgentContext, awsAgentUpstreamConfig, 10)
+			pushed, _, err := PushPlaybookActions(awsAgentContext, awsAgentUpstreamConfig, 10)
 			Expect(err).To(BeNil())
 			Expect(pushed).To(Equal(1))
 		})
@@ -860,7 +860,7 @@ var _ = Describe("Playbook", Ordered, func() {
 		})
 
 		It("(azure) should push the action result to the upstream", func() {
-			pushed, err := PushPlaybookActions(azureAgentContext, awsAgentUpstreamConfig, 10)
+			pushed, _, err := PushPlaybookActions(azureAgentContext, awsAgentUpstreamConfig, 10)
 			Expect(err).To(BeNil())
 			Expect(pushed).To(Equal(1))
 		})
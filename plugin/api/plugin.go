@@ -27,3 +27,11 @@ const InvocationTokenGRPCMetadataKey = "x-flanksource-plugin-invocation"
 // InvocationTokenHTTPHeader is the HTTP header used to pass the short-lived
 // plugin invocation JWT from Mission Control to plugin HTTP operations.
 const InvocationTokenHTTPHeader = "X-Flanksource-Plugin-Invocation"
+
+// BackgroundTokenEnvKey is the RegisterRequest.Env key carrying the token a
+// plugin process uses for host callbacks made outside an invocation.
+const BackgroundTokenEnvKey = "MC_PLUGIN_BACKGROUND_TOKEN"
+
+// BackgroundTokenGRPCMetadataKey is the gRPC response header the host uses to
+// hand a plugin a refreshed background token before the current one expires.
+const BackgroundTokenGRPCMetadataKey = "x-flanksource-plugin-background"
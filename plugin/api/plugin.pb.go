@@ -1784,6 +1784,505 @@ func (x *ArtifactRef) GetUrl() string {
 	return ""
 }
 
+type KVEntry struct {
+	state        protoimpl.MessageState `protogen:"open.v1"`
+	Key          string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Value        []byte                 `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+	ConfigItemId string                 `protobuf:"bytes,3,opt,name=config_item_id,json=configItemId,proto3" json:"config_item_id,omitempty"`
+	// version is bumped on every write and is the token for KVCompareAndSwap.
+	Version       int64                  `protobuf:"varint,4,opt,name=version,proto3" json:"version,omitempty"`
+	ExpiresAt     *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
+	UpdatedAt     *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *KVEntry) Reset() {
+	*x = KVEntry{}
+	mi := &file_plugin_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KVEntry) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KVEntry) ProtoMessage() {}
+
+func (x *KVEntry) ProtoReflect() protoreflect.Message {
+	mi := &file_plugin_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KVEntry.ProtoReflect.Descriptor instead.
+func (*KVEntry) Descriptor() ([]byte, []int) {
+	return file_plugin_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *KVEntry) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *KVEntry) GetValue() []byte {
+	if x != nil {
+		return x.Value
+	}
+	return nil
+}
+
+func (x *KVEntry) GetConfigItemId() string {
+	if x != nil {
+		return x.ConfigItemId
+	}
+	return ""
+}
+
+func (x *KVEntry) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *KVEntry) GetExpiresAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return nil
+}
+
+func (x *KVEntry) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+type KVEntryList struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Entries       []*KVEntry             `protobuf:"bytes,1,rep,name=entries,proto3" json:"entries,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *KVEntryList) Reset() {
+	*x = KVEntryList{}
+	mi := &file_plugin_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KVEntryList) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KVEntryList) ProtoMessage() {}
+
+func (x *KVEntryList) ProtoReflect() protoreflect.Message {
+	mi := &file_plugin_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KVEntryList.ProtoReflect.Descriptor instead.
+func (*KVEntryList) Descriptor() ([]byte, []int) {
+	return file_plugin_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *KVEntryList) GetEntries() []*KVEntry {
+	if x != nil {
+		return x.Entries
+	}
+	return nil
+}
+
+type KVGetRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	ConfigItemId  string                 `protobuf:"bytes,2,opt,name=config_item_id,json=configItemId,proto3" json:"config_item_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *KVGetRequest) Reset() {
+	*x = KVGetRequest{}
+	mi := &file_plugin_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KVGetRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KVGetRequest) ProtoMessage() {}
+
+func (x *KVGetRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_plugin_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KVGetRequest.ProtoReflect.Descriptor instead.
+func (*KVGetRequest) Descriptor() ([]byte, []int) {
+	return file_plugin_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *KVGetRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *KVGetRequest) GetConfigItemId() string {
+	if x != nil {
+		return x.ConfigItemId
+	}
+	return ""
+}
+
+type KVPutRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Value         []byte                 `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+	ConfigItemId  string                 `protobuf:"bytes,3,opt,name=config_item_id,json=configItemId,proto3" json:"config_item_id,omitempty"`
+	TtlSeconds    int64                  `protobuf:"varint,4,opt,name=ttl_seconds,json=ttlSeconds,proto3" json:"ttl_seconds,omitempty"` // 0 = never expires
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *KVPutRequest) Reset() {
+	*x = KVPutRequest{}
+	mi := &file_plugin_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KVPutRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KVPutRequest) ProtoMessage() {}
+
+func (x *KVPutRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_plugin_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KVPutRequest.ProtoReflect.Descriptor instead.
+func (*KVPutRequest) Descriptor() ([]byte, []int) {
+	return file_plugin_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *KVPutRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *KVPutRequest) GetValue() []byte {
+	if x != nil {
+		return x.Value
+	}
+	return nil
+}
+
+func (x *KVPutRequest) GetConfigItemId() string {
+	if x != nil {
+		return x.ConfigItemId
+	}
+	return ""
+}
+
+func (x *KVPutRequest) GetTtlSeconds() int64 {
+	if x != nil {
+		return x.TtlSeconds
+	}
+	return 0
+}
+
+type KVDeleteRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	ConfigItemId  string                 `protobuf:"bytes,2,opt,name=config_item_id,json=configItemId,proto3" json:"config_item_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *KVDeleteRequest) Reset() {
+	*x = KVDeleteRequest{}
+	mi := &file_plugin_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KVDeleteRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KVDeleteRequest) ProtoMessage() {}
+
+func (x *KVDeleteRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_plugin_proto_msgTypes[28]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KVDeleteRequest.ProtoReflect.Descriptor instead.
+func (*KVDeleteRequest) Descriptor() ([]byte, []int) {
+	return file_plugin_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *KVDeleteRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *KVDeleteRequest) GetConfigItemId() string {
+	if x != nil {
+		return x.ConfigItemId
+	}
+	return ""
+}
+
+type KVListRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Prefix        string                 `protobuf:"bytes,1,opt,name=prefix,proto3" json:"prefix,omitempty"`
+	ConfigItemId  string                 `protobuf:"bytes,2,opt,name=config_item_id,json=configItemId,proto3" json:"config_item_id,omitempty"`
+	Limit         int32                  `protobuf:"varint,3,opt,name=limit,proto3" json:"limit,omitempty"`
+	KeysOnly      bool                   `protobuf:"varint,4,opt,name=keys_only,json=keysOnly,proto3" json:"keys_only,omitempty"` // omit values
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *KVListRequest) Reset() {
+	*x = KVListRequest{}
+	mi := &file_plugin_proto_msgTypes[29]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KVListRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KVListRequest) ProtoMessage() {}
+
+func (x *KVListRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_plugin_proto_msgTypes[29]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KVListRequest.ProtoReflect.Descriptor instead.
+func (*KVListRequest) Descriptor() ([]byte, []int) {
+	return file_plugin_proto_rawDescGZIP(), []int{29}
+}
+
+func (x *KVListRequest) GetPrefix() string {
+	if x != nil {
+		return x.Prefix
+	}
+	return ""
+}
+
+func (x *KVListRequest) GetConfigItemId() string {
+	if x != nil {
+		return x.ConfigItemId
+	}
+	return ""
+}
+
+func (x *KVListRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *KVListRequest) GetKeysOnly() bool {
+	if x != nil {
+		return x.KeysOnly
+	}
+	return false
+}
+
+type KVCompareAndSwapRequest struct {
+	state        protoimpl.MessageState `protogen:"open.v1"`
+	Key          string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	ConfigItemId string                 `protobuf:"bytes,2,opt,name=config_item_id,json=configItemId,proto3" json:"config_item_id,omitempty"`
+	// expected_version 0 only succeeds when the key does not exist.
+	ExpectedVersion int64  `protobuf:"varint,3,opt,name=expected_version,json=expectedVersion,proto3" json:"expected_version,omitempty"`
+	Value           []byte `protobuf:"bytes,4,opt,name=value,proto3" json:"value,omitempty"`
+	TtlSeconds      int64  `protobuf:"varint,5,opt,name=ttl_seconds,json=ttlSeconds,proto3" json:"ttl_seconds,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *KVCompareAndSwapRequest) Reset() {
+	*x = KVCompareAndSwapRequest{}
+	mi := &file_plugin_proto_msgTypes[30]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KVCompareAndSwapRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KVCompareAndSwapRequest) ProtoMessage() {}
+
+func (x *KVCompareAndSwapRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_plugin_proto_msgTypes[30]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KVCompareAndSwapRequest.ProtoReflect.Descriptor instead.
+func (*KVCompareAndSwapRequest) Descriptor() ([]byte, []int) {
+	return file_plugin_proto_rawDescGZIP(), []int{30}
+}
+
+func (x *KVCompareAndSwapRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *KVCompareAndSwapRequest) GetConfigItemId() string {
+	if x != nil {
+		return x.ConfigItemId
+	}
+	return ""
+}
+
+func (x *KVCompareAndSwapRequest) GetExpectedVersion() int64 {
+	if x != nil {
+		return x.ExpectedVersion
+	}
+	return 0
+}
+
+func (x *KVCompareAndSwapRequest) GetValue() []byte {
+	if x != nil {
+		return x.Value
+	}
+	return nil
+}
+
+func (x *KVCompareAndSwapRequest) GetTtlSeconds() int64 {
+	if x != nil {
+		return x.TtlSeconds
+	}
+	return 0
+}
+
+type KVCompareAndSwapResponse struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Swapped bool                   `protobuf:"varint,1,opt,name=swapped,proto3" json:"swapped,omitempty"`
+	// entry is the stored entry after the call, or nil when the key does not exist.
+	Entry         *KVEntry `protobuf:"bytes,2,opt,name=entry,proto3" json:"entry,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *KVCompareAndSwapResponse) Reset() {
+	*x = KVCompareAndSwapResponse{}
+	mi := &file_plugin_proto_msgTypes[31]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KVCompareAndSwapResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KVCompareAndSwapResponse) ProtoMessage() {}
+
+func (x *KVCompareAndSwapResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_plugin_proto_msgTypes[31]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KVCompareAndSwapResponse.ProtoReflect.Descriptor instead.
+func (*KVCompareAndSwapResponse) Descriptor() ([]byte, []int) {
+	return file_plugin_proto_rawDescGZIP(), []int{31}
+}
+
+func (x *KVCompareAndSwapResponse) GetSwapped() bool {
+	if x != nil {
+		return x.Swapped
+	}
+	return false
+}
+
+func (x *KVCompareAndSwapResponse) GetEntry() *KVEntry {
+	if x != nil {
+		return x.Entry
+	}
+	return nil
+}
+
 var File_plugin_proto protoreflect.FileDescriptor
 
 const file_plugin_proto_rawDesc = "" +
@@ -1950,14 +2449,52 @@ const file_plugin_proto_rawDesc = "" +
 	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"/\n" +
 	"\vArtifactRef\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\tR\x02id\x12\x10\n" +
-	"\x03url\x18\x02 \x01(\tR\x03url2\xb6\x04\n" +
+	"\x03url\x18\x02 \x01(\tR\x03url\"\xe7\x01\n" +
+	"\aKVEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\fR\x05value\x12$\n" +
+	"\x0econfig_item_id\x18\x03 \x01(\tR\fconfigItemId\x12\x18\n" +
+	"\aversion\x18\x04 \x01(\x03R\aversion\x129\n" +
+	"\n" +
+	"expires_at\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\texpiresAt\x129\n" +
+	"\n" +
+	"updated_at\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\"J\n" +
+	"\vKVEntryList\x12;\n" +
+	"\aentries\x18\x01 \x03(\v2!.missioncontrol.plugin.v1.KVEntryR\aentries\"F\n" +
+	"\fKVGetRequest\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12$\n" +
+	"\x0econfig_item_id\x18\x02 \x01(\tR\fconfigItemId\"}\n" +
+	"\fKVPutRequest\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\fR\x05value\x12$\n" +
+	"\x0econfig_item_id\x18\x03 \x01(\tR\fconfigItemId\x12\x1f\n" +
+	"\vttl_seconds\x18\x04 \x01(\x03R\n" +
+	"ttlSeconds\"I\n" +
+	"\x0fKVDeleteRequest\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12$\n" +
+	"\x0econfig_item_id\x18\x02 \x01(\tR\fconfigItemId\"\x80\x01\n" +
+	"\rKVListRequest\x12\x16\n" +
+	"\x06prefix\x18\x01 \x01(\tR\x06prefix\x12$\n" +
+	"\x0econfig_item_id\x18\x02 \x01(\tR\fconfigItemId\x12\x14\n" +
+	"\x05limit\x18\x03 \x01(\x05R\x05limit\x12\x1b\n" +
+	"\tkeys_only\x18\x04 \x01(\bR\bkeysOnly\"\xb3\x01\n" +
+	"\x17KVCompareAndSwapRequest\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12$\n" +
+	"\x0econfig_item_id\x18\x02 \x01(\tR\fconfigItemId\x12)\n" +
+	"\x10expected_version\x18\x03 \x01(\x03R\x0fexpectedVersion\x12\x14\n" +
+	"\x05value\x18\x04 \x01(\fR\x05value\x12\x1f\n" +
+	"\vttl_seconds\x18\x05 \x01(\x03R\n" +
+	"ttlSeconds\"m\n" +
+	"\x18KVCompareAndSwapResponse\x12\x18\n" +
+	"\aswapped\x18\x01 \x01(\bR\aswapped\x127\n" +
+	"\x05entry\x18\x02 \x01(\v2!.missioncontrol.plugin.v1.KVEntryR\x05entry2\xb6\x04\n" +
 	"\rPluginService\x12e\n" +
 	"\x0eRegisterPlugin\x12).missioncontrol.plugin.v1.RegisterRequest\x1a(.missioncontrol.plugin.v1.PluginManifest\x12d\n" +
 	"\tConfigure\x12*.missioncontrol.plugin.v1.ConfigureRequest\x1a+.missioncontrol.plugin.v1.ConfigureResponse\x12Z\n" +
 	"\x0eListOperations\x12\x1f.missioncontrol.plugin.v1.Empty\x1a'.missioncontrol.plugin.v1.OperationList\x12[\n" +
 	"\x06Invoke\x12'.missioncontrol.plugin.v1.InvokeRequest\x1a(.missioncontrol.plugin.v1.InvokeResponse\x12Q\n" +
 	"\x06Health\x12\x1f.missioncontrol.plugin.v1.Empty\x1a&.missioncontrol.plugin.v1.HealthStatus\x12L\n" +
-	"\bShutdown\x12\x1f.missioncontrol.plugin.v1.Empty\x1a\x1f.missioncontrol.plugin.v1.Empty2\xb6\x05\n" +
+	"\bShutdown\x12\x1f.missioncontrol.plugin.v1.Empty\x1a\x1f.missioncontrol.plugin.v1.Empty2\x8b\t\n" +
 	"\vHostService\x12e\n" +
 	"\rGetConfigItem\x12..missioncontrol.plugin.v1.GetConfigItemRequest\x1a$.missioncontrol.plugin.v1.ConfigItem\x12e\n" +
 	"\vListConfigs\x12,.missioncontrol.plugin.v1.ListConfigsRequest\x1a(.missioncontrol.plugin.v1.ConfigItemList\x12m\n" +
@@ -1965,7 +2502,12 @@ const file_plugin_proto_rawDesc = "" +
 	"\x03Log\x12\".missioncontrol.plugin.v1.LogEntry\x1a\x1f.missioncontrol.plugin.v1.Empty\x12Z\n" +
 	"\rWriteArtifact\x12\".missioncontrol.plugin.v1.Artifact\x1a%.missioncontrol.plugin.v1.ArtifactRef\x12Y\n" +
 	"\fReadArtifact\x12%.missioncontrol.plugin.v1.ArtifactRef\x1a\".missioncontrol.plugin.v1.Artifact\x12g\n" +
-	"\fInvokePlugin\x12-.missioncontrol.plugin.v1.InvokePluginRequest\x1a(.missioncontrol.plugin.v1.InvokeResponseB:Z8github.com/flanksource/incident-commander/plugin/api;apib\x06proto3"
+	"\fInvokePlugin\x12-.missioncontrol.plugin.v1.InvokePluginRequest\x1a(.missioncontrol.plugin.v1.InvokeResponse\x12R\n" +
+	"\x05KVGet\x12&.missioncontrol.plugin.v1.KVGetRequest\x1a!.missioncontrol.plugin.v1.KVEntry\x12R\n" +
+	"\x05KVPut\x12&.missioncontrol.plugin.v1.KVPutRequest\x1a!.missioncontrol.plugin.v1.KVEntry\x12V\n" +
+	"\bKVDelete\x12).missioncontrol.plugin.v1.KVDeleteRequest\x1a\x1f.missioncontrol.plugin.v1.Empty\x12X\n" +
+	"\x06KVList\x12'.missioncontrol.plugin.v1.KVListRequest\x1a%.missioncontrol.plugin.v1.KVEntryList\x12y\n" +
+	"\x10KVCompareAndSwap\x121.missioncontrol.plugin.v1.KVCompareAndSwapRequest\x1a2.missioncontrol.plugin.v1.KVCompareAndSwapResponseB:Z8github.com/flanksource/incident-commander/plugin/api;apib\x06proto3"
 
 var (
 	file_plugin_proto_rawDescOnce sync.Once
@@ -1979,94 +2521,116 @@ func file_plugin_proto_rawDescGZIP() []byte {
 	return file_plugin_proto_rawDescData
 }
 
-var file_plugin_proto_msgTypes = make([]protoimpl.MessageInfo, 29)
+var file_plugin_proto_msgTypes = make([]protoimpl.MessageInfo, 37)
 var file_plugin_proto_goTypes = []any{
-	(*Empty)(nil),                 // 0: missioncontrol.plugin.v1.Empty
-	(*PluginManifest)(nil),        // 1: missioncontrol.plugin.v1.PluginManifest
-	(*PluginRole)(nil),            // 2: missioncontrol.plugin.v1.PluginRole
-	(*TabSpec)(nil),               // 3: missioncontrol.plugin.v1.TabSpec
-	(*OperationDef)(nil),          // 4: missioncontrol.plugin.v1.OperationDef
-	(*HTTPBinding)(nil),           // 5: missioncontrol.plugin.v1.HTTPBinding
-	(*RegisterRequest)(nil),       // 6: missioncontrol.plugin.v1.RegisterRequest
-	(*ConfigureRequest)(nil),      // 7: missioncontrol.plugin.v1.ConfigureRequest
-	(*ConfigureResponse)(nil),     // 8: missioncontrol.plugin.v1.ConfigureResponse
-	(*InvokeRequest)(nil),         // 9: missioncontrol.plugin.v1.InvokeRequest
-	(*InvokeResponse)(nil),        // 10: missioncontrol.plugin.v1.InvokeResponse
-	(*InvokePluginRequest)(nil),   // 11: missioncontrol.plugin.v1.InvokePluginRequest
-	(*OperationList)(nil),         // 12: missioncontrol.plugin.v1.OperationList
-	(*HealthStatus)(nil),          // 13: missioncontrol.plugin.v1.HealthStatus
-	(*ConfigItem)(nil),            // 14: missioncontrol.plugin.v1.ConfigItem
-	(*ConfigItemList)(nil),        // 15: missioncontrol.plugin.v1.ConfigItemList
-	(*GetConfigItemRequest)(nil),  // 16: missioncontrol.plugin.v1.GetConfigItemRequest
-	(*ResourceSelector)(nil),      // 17: missioncontrol.plugin.v1.ResourceSelector
-	(*ListConfigsRequest)(nil),    // 18: missioncontrol.plugin.v1.ListConfigsRequest
-	(*GetConnectionRequest)(nil),  // 19: missioncontrol.plugin.v1.GetConnectionRequest
-	(*ResolvedConnection)(nil),    // 20: missioncontrol.plugin.v1.ResolvedConnection
-	(*LogEntry)(nil),              // 21: missioncontrol.plugin.v1.LogEntry
-	(*Artifact)(nil),              // 22: missioncontrol.plugin.v1.Artifact
-	(*ArtifactRef)(nil),           // 23: missioncontrol.plugin.v1.ArtifactRef
-	nil,                           // 24: missioncontrol.plugin.v1.RegisterRequest.EnvEntry
-	nil,                           // 25: missioncontrol.plugin.v1.ConfigItem.LabelsEntry
-	nil,                           // 26: missioncontrol.plugin.v1.ConfigItem.TagsEntry
-	nil,                           // 27: missioncontrol.plugin.v1.LogEntry.FieldsEntry
-	nil,                           // 28: missioncontrol.plugin.v1.Artifact.MetadataEntry
-	(*structpb.Struct)(nil),       // 29: google.protobuf.Struct
-	(*timestamppb.Timestamp)(nil), // 30: google.protobuf.Timestamp
+	(*Empty)(nil),                    // 0: missioncontrol.plugin.v1.Empty
+	(*PluginManifest)(nil),           // 1: missioncontrol.plugin.v1.PluginManifest
+	(*PluginRole)(nil),               // 2: missioncontrol.plugin.v1.PluginRole
+	(*TabSpec)(nil),                  // 3: missioncontrol.plugin.v1.TabSpec
+	(*OperationDef)(nil),             // 4: missioncontrol.plugin.v1.OperationDef
+	(*HTTPBinding)(nil),              // 5: missioncontrol.plugin.v1.HTTPBinding
+	(*RegisterRequest)(nil),          // 6: missioncontrol.plugin.v1.RegisterRequest
+	(*ConfigureRequest)(nil),         // 7: missioncontrol.plugin.v1.ConfigureRequest
+	(*ConfigureResponse)(nil),        // 8: missioncontrol.plugin.v1.ConfigureResponse
+	(*InvokeRequest)(nil),            // 9: missioncontrol.plugin.v1.InvokeRequest
+	(*InvokeResponse)(nil),           // 10: missioncontrol.plugin.v1.InvokeResponse
+	(*InvokePluginRequest)(nil),      // 11: missioncontrol.plugin.v1.InvokePluginRequest
+	(*OperationList)(nil),            // 12: missioncontrol.plugin.v1.OperationList
+	(*HealthStatus)(nil),             // 13: missioncontrol.plugin.v1.HealthStatus
+	(*ConfigItem)(nil),               // 14: missioncontrol.plugin.v1.ConfigItem
+	(*ConfigItemList)(nil),           // 15: missioncontrol.plugin.v1.ConfigItemList
+	(*GetConfigItemRequest)(nil),     // 16: missioncontrol.plugin.v1.GetConfigItemRequest
+	(*ResourceSelector)(nil),         // 17: missioncontrol.plugin.v1.ResourceSelector
+	(*ListConfigsRequest)(nil),       // 18: missioncontrol.plugin.v1.ListConfigsRequest
+	(*GetConnectionRequest)(nil),     // 19: missioncontrol.plugin.v1.GetConnectionRequest
+	(*ResolvedConnection)(nil),       // 20: missioncontrol.plugin.v1.ResolvedConnection
+	(*LogEntry)(nil),                 // 21: missioncontrol.plugin.v1.LogEntry
+	(*Artifact)(nil),                 // 22: missioncontrol.plugin.v1.Artifact
+	(*ArtifactRef)(nil),              // 23: missioncontrol.plugin.v1.ArtifactRef
+	(*KVEntry)(nil),                  // 24: missioncontrol.plugin.v1.KVEntry
+	(*KVEntryList)(nil),              // 25: missioncontrol.plugin.v1.KVEntryList
+	(*KVGetRequest)(nil),             // 26: missioncontrol.plugin.v1.KVGetRequest
+	(*KVPutRequest)(nil),             // 27: missioncontrol.plugin.v1.KVPutRequest
+	(*KVDeleteRequest)(nil),          // 28: missioncontrol.plugin.v1.KVDeleteRequest
+	(*KVListRequest)(nil),            // 29: missioncontrol.plugin.v1.KVListRequest
+	(*KVCompareAndSwapRequest)(nil),  // 30: missioncontrol.plugin.v1.KVCompareAndSwapRequest
+	(*KVCompareAndSwapResponse)(nil), // 31: missioncontrol.plugin.v1.KVCompareAndSwapResponse
+	nil,                              // 32: missioncontrol.plugin.v1.RegisterRequest.EnvEntry
+	nil,                              // 33: missioncontrol.plugin.v1.ConfigItem.LabelsEntry
+	nil,                              // 34: missioncontrol.plugin.v1.ConfigItem.TagsEntry
+	nil,                              // 35: missioncontrol.plugin.v1.LogEntry.FieldsEntry
+	nil,                              // 36: missioncontrol.plugin.v1.Artifact.MetadataEntry
+	(*structpb.Struct)(nil),          // 37: google.protobuf.Struct
+	(*timestamppb.Timestamp)(nil),    // 38: google.protobuf.Timestamp
 }
 var file_plugin_proto_depIdxs = []int32{
 	4,  // 0: missioncontrol.plugin.v1.PluginManifest.operations:type_name -> missioncontrol.plugin.v1.OperationDef
 	3,  // 1: missioncontrol.plugin.v1.PluginManifest.tabs:type_name -> missioncontrol.plugin.v1.TabSpec
 	2,  // 2: missioncontrol.plugin.v1.PluginManifest.roles:type_name -> missioncontrol.plugin.v1.PluginRole
-	29, // 3: missioncontrol.plugin.v1.OperationDef.params_schema:type_name -> google.protobuf.Struct
+	37, // 3: missioncontrol.plugin.v1.OperationDef.params_schema:type_name -> google.protobuf.Struct
 	5,  // 4: missioncontrol.plugin.v1.OperationDef.http:type_name -> missioncontrol.plugin.v1.HTTPBinding
-	24, // 5: missioncontrol.plugin.v1.RegisterRequest.env:type_name -> missioncontrol.plugin.v1.RegisterRequest.EnvEntry
-	29, // 6: missioncontrol.plugin.v1.ConfigureRequest.settings:type_name -> google.protobuf.Struct
-	30, // 7: missioncontrol.plugin.v1.InvokeRequest.deadline:type_name -> google.protobuf.Timestamp
+	32, // 5: missioncontrol.plugin.v1.RegisterRequest.env:type_name -> missioncontrol.plugin.v1.RegisterRequest.EnvEntry
+	37, // 6: missioncontrol.plugin.v1.ConfigureRequest.settings:type_name -> google.protobuf.Struct
+	38, // 7: missioncontrol.plugin.v1.InvokeRequest.deadline:type_name -> google.protobuf.Timestamp
 	21, // 8: missioncontrol.plugin.v1.InvokeResponse.logs:type_name -> missioncontrol.plugin.v1.LogEntry
-	30, // 9: missioncontrol.plugin.v1.InvokePluginRequest.deadline:type_name -> google.protobuf.Timestamp
+	38, // 9: missioncontrol.plugin.v1.InvokePluginRequest.deadline:type_name -> google.protobuf.Timestamp
 	4,  // 10: missioncontrol.plugin.v1.OperationList.operations:type_name -> missioncontrol.plugin.v1.OperationDef
-	29, // 11: missioncontrol.plugin.v1.ConfigItem.properties:type_name -> google.protobuf.Struct
-	29, // 12: missioncontrol.plugin.v1.ConfigItem.config:type_name -> google.protobuf.Struct
-	25, // 13: missioncontrol.plugin.v1.ConfigItem.labels:type_name -> missioncontrol.plugin.v1.ConfigItem.LabelsEntry
-	26, // 14: missioncontrol.plugin.v1.ConfigItem.tags:type_name -> missioncontrol.plugin.v1.ConfigItem.TagsEntry
+	37, // 11: missioncontrol.plugin.v1.ConfigItem.properties:type_name -> google.protobuf.Struct
+	37, // 12: missioncontrol.plugin.v1.ConfigItem.config:type_name -> google.protobuf.Struct
+	33, // 13: missioncontrol.plugin.v1.ConfigItem.labels:type_name -> missioncontrol.plugin.v1.ConfigItem.LabelsEntry
+	34, // 14: missioncontrol.plugin.v1.ConfigItem.tags:type_name -> missioncontrol.plugin.v1.ConfigItem.TagsEntry
 	14, // 15: missioncontrol.plugin.v1.ConfigItemList.items:type_name -> missioncontrol.plugin.v1.ConfigItem
 	17, // 16: missioncontrol.plugin.v1.ListConfigsRequest.selector:type_name -> missioncontrol.plugin.v1.ResourceSelector
-	29, // 17: missioncontrol.plugin.v1.ResolvedConnection.properties:type_name -> google.protobuf.Struct
-	30, // 18: missioncontrol.plugin.v1.ResolvedConnection.expires_at:type_name -> google.protobuf.Timestamp
-	27, // 19: missioncontrol.plugin.v1.LogEntry.fields:type_name -> missioncontrol.plugin.v1.LogEntry.FieldsEntry
-	30, // 20: missioncontrol.plugin.v1.LogEntry.ts:type_name -> google.protobuf.Timestamp
-	28, // 21: missioncontrol.plugin.v1.Artifact.metadata:type_name -> missioncontrol.plugin.v1.Artifact.MetadataEntry
-	6,  // 22: missioncontrol.plugin.v1.PluginService.RegisterPlugin:input_type -> missioncontrol.plugin.v1.RegisterRequest
-	7,  // 23: missioncontrol.plugin.v1.PluginService.Configure:input_type -> missioncontrol.plugin.v1.ConfigureRequest
-	0,  // 24: missioncontrol.plugin.v1.PluginService.ListOperations:input_type -> missioncontrol.plugin.v1.Empty
-	9,  // 25: missioncontrol.plugin.v1.PluginService.Invoke:input_type -> missioncontrol.plugin.v1.InvokeRequest
-	0,  // 26: missioncontrol.plugin.v1.PluginService.Health:input_type -> missioncontrol.plugin.v1.Empty
-	0,  // 27: missioncontrol.plugin.v1.PluginService.Shutdown:input_type -> missioncontrol.plugin.v1.Empty
-	16, // 28: missioncontrol.plugin.v1.HostService.GetConfigItem:input_type -> missioncontrol.plugin.v1.GetConfigItemRequest
-	18, // 29: missioncontrol.plugin.v1.HostService.ListConfigs:input_type -> missioncontrol.plugin.v1.ListConfigsRequest
-	19, // 30: missioncontrol.plugin.v1.HostService.GetConnection:input_type -> missioncontrol.plugin.v1.GetConnectionRequest
-	21, // 31: missioncontrol.plugin.v1.HostService.Log:input_type -> missioncontrol.plugin.v1.LogEntry
-	22, // 32: missioncontrol.plugin.v1.HostService.WriteArtifact:input_type -> missioncontrol.plugin.v1.Artifact
-	23, // 33: missioncontrol.plugin.v1.HostService.ReadArtifact:input_type -> missioncontrol.plugin.v1.ArtifactRef
-	11, // 34: missioncontrol.plugin.v1.HostService.InvokePlugin:input_type -> missioncontrol.plugin.v1.InvokePluginRequest
-	1,  // 35: missioncontrol.plugin.v1.PluginService.RegisterPlugin:output_type -> missioncontrol.plugin.v1.PluginManifest
-	8,  // 36: missioncontrol.plugin.v1.PluginService.Configure:output_type -> missioncontrol.plugin.v1.ConfigureResponse
-	12, // 37: missioncontrol.plugin.v1.PluginService.ListOperations:output_type -> missioncontrol.plugin.v1.OperationList
-	10, // 38: missioncontrol.plugin.v1.PluginService.Invoke:output_type -> missioncontrol.plugin.v1.InvokeResponse
-	13, // 39: missioncontrol.plugin.v1.PluginService.Health:output_type -> missioncontrol.plugin.v1.HealthStatus
-	0,  // 40: missioncontrol.plugin.v1.PluginService.Shutdown:output_type -> missioncontrol.plugin.v1.Empty
-	14, // 41: missioncontrol.plugin.v1.HostService.GetConfigItem:output_type -> missioncontrol.plugin.v1.ConfigItem
-	15, // 42: missioncontrol.plugin.v1.HostService.ListConfigs:output_type -> missioncontrol.plugin.v1.ConfigItemList
-	20, // 43: missioncontrol.plugin.v1.HostService.GetConnection:output_type -> missioncontrol.plugin.v1.ResolvedConnection
-	0,  // 44: missioncontrol.plugin.v1.HostService.Log:output_type -> missioncontrol.plugin.v1.Empty
-	23, // 45: missioncontrol.plugin.v1.HostService.WriteArtifact:output_type -> missioncontrol.plugin.v1.ArtifactRef
-	22, // 46: missioncontrol.plugin.v1.HostService.ReadArtifact:output_type -> missioncontrol.plugin.v1.Artifact
-	10, // 47: missioncontrol.plugin.v1.HostService.InvokePlugin:output_type -> missioncontrol.plugin.v1.InvokeResponse
-	35, // [35:48] is the sub-list for method output_type
-	22, // [22:35] is the sub-list for method input_type
-	22, // [22:22] is the sub-list for extension type_name
-	22, // [22:22] is the sub-list for extension extendee
-	0,  // [0:22] is the sub-list for field type_name
+	37, // 17: missioncontrol.plugin.v1.ResolvedConnection.properties:type_name -> google.protobuf.Struct
+	38, // 18: missioncontrol.plugin.v1.ResolvedConnection.expires_at:type_name -> google.protobuf.Timestamp
+	35, // 19: missioncontrol.plugin.v1.LogEntry.fields:type_name -> missioncontrol.plugin.v1.LogEntry.FieldsEntry
+	38, // 20: missioncontrol.plugin.v1.LogEntry.ts:type_name -> google.protobuf.Timestamp
+	36, // 21: missioncontrol.plugin.v1.Artifact.metadata:type_name -> missioncontrol.plugin.v1.Artifact.MetadataEntry
+	38, // 22: missioncontrol.plugin.v1.KVEntry.expires_at:type_name -> google.protobuf.Timestamp
+	38, // 23: missioncontrol.plugin.v1.KVEntry.updated_at:type_name -> google.protobuf.Timestamp
+	24, // 24: missioncontrol.plugin.v1.KVEntryList.entries:type_name -> missioncontrol.plugin.v1.KVEntry
+	24, // 25: missioncontrol.plugin.v1.KVCompareAndSwapResponse.entry:type_name -> missioncontrol.plugin.v1.KVEntry
+	6,  // 26: missioncontrol.plugin.v1.PluginService.RegisterPlugin:input_type -> missioncontrol.plugin.v1.RegisterRequest
+	7,  // 27: missioncontrol.plugin.v1.PluginService.Configure:input_type -> missioncontrol.plugin.v1.ConfigureRequest
+	0,  // 28: missioncontrol.plugin.v1.PluginService.ListOperations:input_type -> missioncontrol.plugin.v1.Empty
+	9,  // 29: missioncontrol.plugin.v1.PluginService.Invoke:input_type -> missioncontrol.plugin.v1.InvokeRequest
+	0,  // 30: missioncontrol.plugin.v1.PluginService.Health:input_type -> missioncontrol.plugin.v1.Empty
+	0,  // 31: missioncontrol.plugin.v1.PluginService.Shutdown:input_type -> missioncontrol.plugin.v1.Empty
+	16, // 32: missioncontrol.plugin.v1.HostService.GetConfigItem:input_type -> missioncontrol.plugin.v1.GetConfigItemRequest
+	18, // 33: missioncontrol.plugin.v1.HostService.ListConfigs:input_type -> missioncontrol.plugin.v1.ListConfigsRequest
+	19, // 34: missioncontrol.plugin.v1.HostService.GetConnection:input_type -> missioncontrol.plugin.v1.GetConnectionRequest
+	21, // 35: missioncontrol.plugin.v1.HostService.Log:input_type -> missioncontrol.plugin.v1.LogEntry
+	22, // 36: missioncontrol.plugin.v1.HostService.WriteArtifact:input_type -> missioncontrol.plugin.v1.Artifact
+	23, // 37: missioncontrol.plugin.v1.HostService.ReadArtifact:input_type -> missioncontrol.plugin.v1.ArtifactRef
+	11, // 38: missioncontrol.plugin.v1.HostService.InvokePlugin:input_type -> missioncontrol.plugin.v1.InvokePluginRequest
+	26, // 39: missioncontrol.plugin.v1.HostService.KVGet:input_type -> missioncontrol.plugin.v1.KVGetRequest
+	27, // 40: missioncontrol.plugin.v1.HostService.KVPut:input_type -> missioncontrol.plugin.v1.KVPutRequest
+	28, // 41: missioncontrol.plugin.v1.HostService.KVDelete:input_type -> missioncontrol.plugin.v1.KVDeleteRequest
+	29, // 42: missioncontrol.plugin.v1.HostService.KVList:input_type -> missioncontrol.plugin.v1.KVListRequest
+	30, // 43: missioncontrol.plugin.v1.HostService.KVCompareAndSwap:input_type -> missioncontrol.plugin.v1.KVCompareAndSwapRequest
+	1,  // 44: missioncontrol.plugin.v1.PluginService.RegisterPlugin:output_type -> missioncontrol.plugin.v1.PluginManifest
+	8,  // 45: missioncontrol.plugin.v1.PluginService.Configure:output_type -> missioncontrol.plugin.v1.ConfigureResponse
+	12, // 46: missioncontrol.plugin.v1.PluginService.ListOperations:output_type -> missioncontrol.plugin.v1.OperationList
+	10, // 47: missioncontrol.plugin.v1.PluginService.Invoke:output_type -> missioncontrol.plugin.v1.InvokeResponse
+	13, // 48: missioncontrol.plugin.v1.PluginService.Health:output_type -> missioncontrol.plugin.v1.HealthStatus
+	0,  // 49: missioncontrol.plugin.v1.PluginService.Shutdown:output_type -> missioncontrol.plugin.v1.Empty
+	14, // 50: missioncontrol.plugin.v1.HostService.GetConfigItem:output_type -> missioncontrol.plugin.v1.ConfigItem
+	15, // 51: missioncontrol.plugin.v1.HostService.ListConfigs:output_type -> missioncontrol.plugin.v1.ConfigItemList
+	20, // 52: missioncontrol.plugin.v1.HostService.GetConnection:output_type -> missioncontrol.plugin.v1.ResolvedConnection
+	0,  // 53: missioncontrol.plugin.v1.HostService.Log:output_type -> missioncontrol.plugin.v1.Empty
+	23, // 54: missioncontrol.plugin.v1.HostService.WriteArtifact:output_type -> missioncontrol.plugin.v1.ArtifactRef
+	22, // 55: missioncontrol.plugin.v1.HostService.ReadArtifact:output_type -> missioncontrol.plugin.v1.Artifact
+	10, // 56: missioncontrol.plugin.v1.HostService.InvokePlugin:output_type -> missioncontrol.plugin.v1.InvokeResponse
+	24, // 57: missioncontrol.plugin.v1.HostService.KVGet:output_type -> missioncontrol.plugin.v1.KVEntry
+	24, // 58: missioncontrol.plugin.v1.HostService.KVPut:output_type -> missioncontrol.plugin.v1.KVEntry
+	0,  // 59: missioncontrol.plugin.v1.HostService.KVDelete:output_type -> missioncontrol.plugin.v1.Empty
+	25, // 60: missioncontrol.plugin.v1.HostService.KVList:output_type -> missioncontrol.plugin.v1.KVEntryList
+	31, // 61: missioncontrol.plugin.v1.HostService.KVCompareAndSwap:output_type -> missioncontrol.plugin.v1.KVCompareAndSwapResponse
+	44, // [44:62] is the sub-list for method output_type
+	26, // [26:44] is the sub-list for method input_type
+	26, // [26:26] is the sub-list for extension type_name
+	26, // [26:26] is the sub-list for extension extendee
+	0,  // [0:26] is the sub-list for field type_name
 }
 
 func init() { file_plugin_proto_init() }
@@ -2086,7 +2650,7 @@ func file_plugin_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_plugin_proto_rawDesc), len(file_plugin_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   29,
+			NumMessages:   37,
 			NumExtensions: 0,
 			NumServices:   2,
 		},
@@ -315,13 +315,18 @@ var PluginService_ServiceDesc = grpc.ServiceDesc{
 }
 
 const (
-	HostService_GetConfigItem_FullMethodName = "/missioncontrol.plugin.v1.HostService/GetConfigItem"
-	HostService_ListConfigs_FullMethodName   = "/missioncontrol.plugin.v1.HostService/ListConfigs"
-	HostService_GetConnection_FullMethodName = "/missioncontrol.plugin.v1.HostService/GetConnection"
-	HostService_Log_FullMethodName           = "/missioncontrol.plugin.v1.HostService/Log"
-	HostService_WriteArtifact_FullMethodName = "/missioncontrol.plugin.v1.HostService/WriteArtifact"
-	HostService_ReadArtifact_FullMethodName  = "/missioncontrol.plugin.v1.HostService/ReadArtifact"
-	HostService_InvokePlugin_FullMethodName  = "/missioncontrol.plugin.v1.HostService/InvokePlugin"
+	HostService_GetConfigItem_FullMethodName    = "/missioncontrol.plugin.v1.HostService/GetConfigItem"
+	HostService_ListConfigs_FullMethodName      = "/missioncontrol.plugin.v1.HostService/ListConfigs"
+	HostService_GetConnection_FullMethodName    = "/missioncontrol.plugin.v1.HostService/GetConnection"
+	HostService_Log_FullMethodName              = "/missioncontrol.plugin.v1.HostService/Log"
+	HostService_WriteArtifact_FullMethodName    = "/missioncontrol.plugin.v1.HostService/WriteArtifact"
+	HostService_ReadArtifact_FullMethodName     = "/missioncontrol.plugin.v1.HostService/ReadArtifact"
+	HostService_InvokePlugin_FullMethodName     = "/missioncontrol.plugin.v1.HostService/InvokePlugin"
+	HostService_KVGet_FullMethodName            = "/missioncontrol.plugin.v1.HostService/KVGet"
+	HostService_KVPut_FullMethodName            = "/missioncontrol.plugin.v1.HostService/KVPut"
+	HostService_KVDelete_FullMethodName         = "/missioncontrol.plugin.v1.HostService/KVDelete"
+	HostService_KVList_FullMethodName           = "/missioncontrol.plugin.v1.HostService/KVList"
+	HostService_KVCompareAndSwap_FullMethodName = "/missioncontrol.plugin.v1.HostService/KVCompareAndSwap"
 )
 
 // HostServiceClient is the client API for HostService service.
@@ -338,6 +343,13 @@ type HostServiceClient interface {
 	WriteArtifact(ctx context.Context, in *Artifact, opts ...grpc.CallOption) (*ArtifactRef, error)
 	ReadArtifact(ctx context.Context, in *ArtifactRef, opts ...grpc.CallOption) (*Artifact, error)
 	InvokePlugin(ctx context.Context, in *InvokePluginRequest, opts ...grpc.CallOption) (*InvokeResponse, error)
+	// Durable key-value state, scoped to the calling plugin and optionally to
+	// a config item. Survives plugin restarts and works for remote plugins.
+	KVGet(ctx context.Context, in *KVGetRequest, opts ...grpc.CallOption) (*KVEntry, error)
+	KVPut(ctx context.Context, in *KVPutRequest, opts ...grpc.CallOption) (*KVEntry, error)
+	KVDelete(ctx context.Context, in *KVDeleteRequest, opts ...grpc.CallOption) (*Empty, error)
+	KVList(ctx context.Context, in *KVListRequest, opts ...grpc.CallOption) (*KVEntryList, error)
+	KVCompareAndSwap(ctx context.Context, in *KVCompareAndSwapRequest, opts ...grpc.CallOption) (*KVCompareAndSwapResponse, error)
 }
 
 type hostServiceClient struct {
@@ -418,6 +430,56 @@ func (c *hostServiceClient) InvokePlugin(ctx context.Context, in *InvokePluginRe
 	return out, nil
 }
 
+func (c *hostServiceClient) KVGet(ctx context.Context, in *KVGetRequest, opts ...grpc.CallOption) (*KVEntry, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(KVEntry)
+	err := c.cc.Invoke(ctx, HostService_KVGet_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *hostServiceClient) KVPut(ctx context.Context, in *KVPutRequest, opts ...grpc.CallOption) (*KVEntry, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(KVEntry)
+	err := c.cc.Invoke(ctx, HostService_KVPut_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *hostServiceClient) KVDelete(ctx context.Context, in *KVDeleteRequest, opts ...grpc.CallOption) (*Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, HostService_KVDelete_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *hostServiceClient) KVList(ctx context.Context, in *KVListRequest, opts ...grpc.CallOption) (*KVEntryList, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(KVEntryList)
+	err := c.cc.Invoke(ctx, HostService_KVList_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *hostServiceClient) KVCompareAndSwap(ctx context.Context, in *KVCompareAndSwapRequest, opts ...grpc.CallOption) (*KVCompareAndSwapResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(KVCompareAndSwapResponse)
+	err := c.cc.Invoke(ctx, HostService_KVCompareAndSwap_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // HostServiceServer is the server API for HostService service.
 // All implementations must embed UnimplementedHostServiceServer
 // for forward compatibility.
@@ -432,6 +494,13 @@ type HostServiceServer interface {
 	WriteArtifact(context.Context, *Artifact) (*ArtifactRef, error)
 	ReadArtifact(context.Context, *ArtifactRef) (*Artifact, error)
 	InvokePlugin(context.Context, *InvokePluginRequest) (*InvokeResponse, error)
+	// Durable key-value state, scoped to the calling plugin and optionally to
+	// a config item. Survives plugin restarts and works for remote plugins.
+	KVGet(context.Context, *KVGetRequest) (*KVEntry, error)
+	KVPut(context.Context, *KVPutRequest) (*KVEntry, error)
+	KVDelete(context.Context, *KVDeleteRequest) (*Empty, error)
+	KVList(context.Context, *KVListRequest) (*KVEntryList, error)
+	KVCompareAndSwap(context.Context, *KVCompareAndSwapRequest) (*KVCompareAndSwapResponse, error)
 	mustEmbedUnimplementedHostServiceServer()
 }
 
@@ -463,6 +532,21 @@ func (UnimplementedHostServiceServer) ReadArtifact(context.Context, *ArtifactRef
 func (UnimplementedHostServiceServer) InvokePlugin(context.Context, *InvokePluginRequest) (*InvokeResponse, error) {
 	return nil, status.Error(codes.Unimplemented, "method InvokePlugin not implemented")
 }
+func (UnimplementedHostServiceServer) KVGet(context.Context, *KVGetRequest) (*KVEntry, error) {
+	return nil, status.Error(codes.Unimplemented, "method KVGet not implemented")
+}
+func (UnimplementedHostServiceServer) KVPut(context.Context, *KVPutRequest) (*KVEntry, error) {
+	return nil, status.Error(codes.Unimplemented, "method KVPut not implemented")
+}
+func (UnimplementedHostServiceServer) KVDelete(context.Context, *KVDeleteRequest) (*Empty, error) {
+	return nil, status.Error(codes.Unimplemented, "method KVDelete not implemented")
+}
+func (UnimplementedHostServiceServer) KVList(context.Context, *KVListRequest) (*KVEntryList, error) {
+	return nil, status.Error(codes.Unimplemented, "method KVList not implemented")
+}
+func (UnimplementedHostServiceServer) KVCompareAndSwap(context.Context, *KVCompareAndSwapRequest) (*KVCompareAndSwapResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method KVCompareAndSwap not implemented")
+}
 func (UnimplementedHostServiceServer) mustEmbedUnimplementedHostServiceServer() {}
 func (UnimplementedHostServiceServer) testEmbeddedByValue()                     {}
 
@@ -610,6 +694,96 @@ func _HostService_InvokePlugin_Handler(srv interface{}, ctx context.Context, dec
 	return interceptor(ctx, in, info, handler)
 }
 
+func _HostService_KVGet_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(KVGetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(HostServiceServer).KVGet(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: HostService_KVGet_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(HostServiceServer).KVGet(ctx, req.(*KVGetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _HostService_KVPut_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(KVPutRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(HostServiceServer).KVPut(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: HostService_KVPut_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(HostServiceServer).KVPut(ctx, req.(*KVPutRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _HostService_KVDelete_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(KVDeleteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(HostServiceServer).KVDelete(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: HostService_KVDelete_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(HostServiceServer).KVDelete(ctx, req.(*KVDeleteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _HostService_KVList_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(KVListRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(HostServiceServer).KVList(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: HostService_KVList_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(HostServiceServer).KVList(ctx, req.(*KVListRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _HostService_KVCompareAndSwap_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(KVCompareAndSwapRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(HostServiceServer).KVCompareAndSwap(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: HostService_KVCompareAndSwap_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(HostServiceServer).KVCompareAndSwap(ctx, req.(*KVCompareAndSwapRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // HostService_ServiceDesc is the grpc.ServiceDesc for HostService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -645,6 +819,26 @@ var HostService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "InvokePlugin",
 			Handler:    _HostService_InvokePlugin_Handler,
 		},
+		{
+			MethodName: "KVGet",
+			Handler:    _HostService_KVGet_Handler,
+		},
+		{
+			MethodName: "KVPut",
+			Handler:    _HostService_KVPut_Handler,
+		},
+		{
+			MethodName: "KVDelete",
+			Handler:    _HostService_KVDelete_Handler,
+		},
+		{
+			MethodName: "KVList",
+			Handler:    _HostService_KVList_Handler,
+		},
+		{
+			MethodName: "KVCompareAndSwap",
+			Handler:    _HostService_KVCompareAndSwap_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "plugin.proto",
@@ -20,9 +20,11 @@
 package gateway
 
 import (
+	"bytes"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"time"
 
 	dutyAPI "github.com/flanksource/duty/api"
@@ -227,7 +229,7 @@ func invokeProxiedOperation(c echo.Context, ctx dutyContext.Context, entry *plug
 	if err != nil {
 		return dutyAPI.WriteError(c, err)
 	}
-	invocationToken, err := plugin.MintInvocationToken(user.ID.String(), entry.ID, 0, roles...)
+	invocationToken, err := plugin.MintInvocationToken(user.ID.String(), entry.ID, configID, 0, roles...)
 	if err != nil {
 		return dutyAPI.WriteError(c, ctx.Oops().Wrapf(err, "mint plugin invocation token"))
 	}
@@ -261,6 +263,40 @@ func invokeLocalOperation(ctx dutyContext.Context, req *http.Request, entry *plu
 	return invokeLocalOperationWithRoles(ctx, req, entry, pluginRef, op, configID, configUUID, roles, subject, invocationToken)
 }
 
+// InvokeOperationAsUser invokes a local or remote plugin operation on behalf of
+// the context user with the same role derivation, RBAC and invocation audit as
+// POST /api/plugins/:name/invoke/:op. It is used by callers that are not
+// serving the plugin HTTP route themselves, e.g. the MCP server.
+func InvokeOperationAsUser(ctx dutyContext.Context, entry *plugin.Entry, op, configID string, params []byte) (*api.InvokeResponse, error) {
+	configUUID, err := uuid.Parse(configID)
+	if err != nil {
+		return nil, ctx.Oops().Code(dutyAPI.EINVALID).Errorf("config_id is invalid")
+	}
+
+	switch entry.Kind {
+	case "", api.PluginKindLocal, api.PluginKindRemote:
+	default:
+		return nil, ctx.Oops().Code(dutyAPI.EINVALID).Errorf("plugin %q has unsupported connection kind %q", entry.Name, entry.Kind)
+	}
+
+	if ctx.User() == nil {
+		return nil, ctx.Oops().Code(dutyAPI.EUNAUTHORIZED).Errorf("not logged in")
+	}
+	roles, err := pluginRolesForUser(ctx, entry, configID)
+	if err != nil {
+		return nil, err
+	}
+
+	pluginRef := entry.ID.String()
+	target := fmt.Sprintf("/api/plugins/%s/invoke/%s?config_id=%s", pluginRef, url.PathEscape(op), url.QueryEscape(configID))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(params))
+	if err != nil {
+		return nil, ctx.Oops().Wrap(err)
+	}
+
+	return invokeLocalOperationWithRoles(ctx, req, entry, pluginRef, op, configID, configUUID, roles, ctx.User().ID.String(), "")
+}
+
 func invokeLocalOperationWithRoles(ctx dutyContext.Context, req *http.Request, entry *plugin.Entry, pluginRef, op, configID string, configUUID uuid.UUID, roles []string, subject string, invocationToken string) (*api.InvokeResponse, error) {
 	body, err := io.ReadAll(req.Body)
 	if err != nil {
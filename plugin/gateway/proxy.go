@@ -68,7 +68,7 @@ func pluginUIToken(c echo.Context) error {
 		return dutyAPI.WriteError(c, err)
 	}
 
-	token, err := plugin.MintInvocationToken(user.ID.String(), entry.ID, 0, roles...)
+	token, err := plugin.MintInvocationToken(user.ID.String(), entry.ID, configID, 0, roles...)
 	if err != nil {
 		return dutyAPI.WriteError(c, ctx.Oops().Wrapf(err, "mint plugin invocation token"))
 	}
@@ -166,6 +166,9 @@ func operationHTTPProxy(c echo.Context) error {
 		if err != nil {
 			return dutyAPI.WriteError(c, ctx.Oops().Code(dutyAPI.EUNAUTHORIZED).Errorf("invalid plugin invocation token: %v", err))
 		}
+		if claims.ConfigID != "" && claims.ConfigID != configID {
+			return dutyAPI.WriteError(c, ctx.Oops().Code(dutyAPI.EFORBIDDEN).Errorf("plugin invocation token was issued for config %s", claims.ConfigID))
+		}
 
 		subject = claims.Subject
 		roles = claims.Roles
@@ -199,7 +202,7 @@ func operationHTTPProxy(c echo.Context) error {
 	}
 
 	if entry.Kind == api.PluginKindProxied {
-		invocationToken, err = plugin.MintInvocationToken(subject, entry.ID, 0, roles...)
+		invocationToken, err = plugin.MintInvocationToken(subject, entry.ID, configID, 0, roles...)
 		if err != nil {
 			return dutyAPI.WriteError(c, ctx.Oops().Wrapf(err, "mint plugin invocation token"))
 		}
@@ -216,7 +219,7 @@ func operationHTTPProxy(c echo.Context) error {
 	}
 
 	if invocationToken == "" {
-		invocationToken, err = plugin.MintInvocationToken(subject, entry.ID, 0, roles...)
+		invocationToken, err = plugin.MintInvocationToken(subject, entry.ID, configID, 0, roles...)
 		if err != nil {
 			return dutyAPI.WriteError(c, ctx.Oops().Wrapf(err, "mint plugin invocation token"))
 		}
@@ -3,8 +3,10 @@ package machinery
 import (
 	"context"
 	"errors"
+	"time"
 
 	dutyAPI "github.com/flanksource/duty/api"
+	dutyContext "github.com/flanksource/duty/context"
 	"github.com/flanksource/duty/models"
 	commanderAPI "github.com/flanksource/incident-commander/api"
 	"github.com/flanksource/incident-commander/plugin"
@@ -122,6 +124,9 @@ func (s *Service) contextWithInvocation(ctx context.Context) (context.Context, e
 	}
 
 	baseCtx := s.ctx.Wrap(ctx).WithSubject(claims.Subject).WithValue(invocationClaimsContextKey{}, claims)
+	if claims.Background {
+		return backgroundContext(ctx, baseCtx, claims)
+	}
 	if commanderAPI.UpstreamConf.Valid() {
 		return baseCtx, nil
 	}
@@ -137,6 +142,43 @@ func (s *Service) contextWithInvocation(ctx context.Context) (context.Context, e
 	return baseCtx.WithUser(&person), nil
 }
 
+// backgroundContext serves a call made with a plugin's background token. Such
+// calls act for the plugin itself, so they are limited to its own KV state and
+// run as the system user. When the token is past half its lifetime a fresh one
+// is returned in the response header for the SDK to use from then on.
+func backgroundContext(ctx context.Context, baseCtx dutyContext.Context, claims *plugin.InvocationTokenClaims) (context.Context, error) {
+	method, _ := grpc.Method(ctx)
+	if !isKVMethod(method) {
+		return nil, status.Errorf(codes.PermissionDenied, "plugin background token is not allowed for %s", method)
+	}
+
+	if claims.ExpiresAt != nil && time.Until(claims.ExpiresAt.Time) < plugin.PluginBackgroundJWTTTL/2 {
+		if token, err := plugin.MintBackgroundToken(claims.Plugin); err != nil {
+			baseCtx.Logger.Warnf("plugin %s: refresh background token: %v", claims.Plugin, err)
+		} else {
+			_ = grpc.SetHeader(ctx, metadata.Pairs(pluginAPI.BackgroundTokenGRPCMetadataKey, token))
+		}
+	}
+
+	if commanderAPI.SystemUserID != nil {
+		return baseCtx.WithUser(&models.Person{ID: *commanderAPI.SystemUserID}), nil
+	}
+	return baseCtx, nil
+}
+
+func isKVMethod(method string) bool {
+	switch method {
+	case pluginAPI.HostService_KVGet_FullMethodName,
+		pluginAPI.HostService_KVPut_FullMethodName,
+		pluginAPI.HostService_KVDelete_FullMethodName,
+		pluginAPI.HostService_KVList_FullMethodName,
+		pluginAPI.HostService_KVCompareAndSwap_FullMethodName:
+		return true
+	default:
+		return false
+	}
+}
+
 func requiresInvocation(method string) bool {
 	switch method {
 	case pluginAPI.HostService_GetConfigItem_FullMethodName,
@@ -145,6 +187,6 @@ func requiresInvocation(method string) bool {
 		pluginAPI.HostService_InvokePlugin_FullMethodName:
 		return true
 	default:
-		return false
+		return isKVMethod(method)
 	}
 }
@@ -0,0 +1,150 @@
+package machinery
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/flanksource/duty/api"
+	dutyContext "github.com/flanksource/duty/context"
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/flanksource/incident-commander/db"
+	pluginAPI "github.com/flanksource/incident-commander/plugin/api"
+)
+
+// KV entries are always scoped to the plugin named in the invocation token, so
+// plugins can neither read nor overwrite each other's state.
+
+func (s *Service) KVGet(ctx context.Context, req *pluginAPI.KVGetRequest) (*pluginAPI.KVEntry, error) {
+	kvCtx, scope, err := s.kvScope(ctx, req.ConfigItemId)
+	if err != nil {
+		return nil, err
+	}
+
+	entry, err := db.GetPluginKV(kvCtx, scope, req.Key)
+	if err != nil {
+		return nil, err
+	} else if entry == nil {
+		return nil, kvCtx.Oops().Code(api.ENOTFOUND).Errorf("key %q not found", req.Key)
+	}
+	return kvEntryToProto(entry, req.ConfigItemId), nil
+}
+
+func (s *Service) KVPut(ctx context.Context, req *pluginAPI.KVPutRequest) (*pluginAPI.KVEntry, error) {
+	kvCtx, scope, err := s.kvScope(ctx, req.ConfigItemId)
+	if err != nil {
+		return nil, err
+	}
+
+	entry, err := db.PutPluginKV(kvCtx, scope, req.Key, req.Value, time.Duration(req.TtlSeconds)*time.Second)
+	if err != nil {
+		return nil, kvError(err)
+	}
+	return kvEntryToProto(entry, req.ConfigItemId), nil
+}
+
+func (s *Service) KVDelete(ctx context.Context, req *pluginAPI.KVDeleteRequest) (*pluginAPI.Empty, error) {
+	kvCtx, scope, err := s.kvScope(ctx, req.ConfigItemId)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := db.DeletePluginKV(kvCtx, scope, req.Key); err != nil {
+		return nil, err
+	}
+	return &pluginAPI.Empty{}, nil
+}
+
+func (s *Service) KVList(ctx context.Context, req *pluginAPI.KVListRequest) (*pluginAPI.KVEntryList, error) {
+	kvCtx, scope, err := s.kvScope(ctx, req.ConfigItemId)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := db.ListPluginKV(kvCtx, scope, req.Prefix, int(req.Limit), req.KeysOnly)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &pluginAPI.KVEntryList{Entries: make([]*pluginAPI.KVEntry, 0, len(entries))}
+	for i := range entries {
+		out.Entries = append(out.Entries, kvEntryToProto(&entries[i], req.ConfigItemId))
+	}
+	return out, nil
+}
+
+func (s *Service) KVCompareAndSwap(ctx context.Context, req *pluginAPI.KVCompareAndSwapRequest) (*pluginAPI.KVCompareAndSwapResponse, error) {
+	kvCtx, scope, err := s.kvScope(ctx, req.ConfigItemId)
+	if err != nil {
+		return nil, err
+	}
+
+	swapped, entry, err := db.CompareAndSwapPluginKV(kvCtx, scope, req.Key, req.ExpectedVersion, req.Value, time.Duration(req.TtlSeconds)*time.Second)
+	if err != nil {
+		return nil, kvError(err)
+	}
+
+	out := &pluginAPI.KVCompareAndSwapResponse{Swapped: swapped}
+	if entry != nil {
+		out.Entry = kvEntryToProto(entry, req.ConfigItemId)
+	}
+	return out, nil
+}
+
+func (s *Service) kvScope(ctx context.Context, configItemID string) (dutyContext.Context, db.PluginKVScope, error) {
+	entry, err := pluginEntryFromInvocation(ctx)
+	if err != nil {
+		return dutyContext.Context{}, db.PluginKVScope{}, err
+	}
+
+	kvCtx := invocationDutyContext(s.ctx, ctx)
+	scope := db.PluginKVScope{PluginID: entry.ID}
+	if configItemID == "" {
+		return kvCtx, scope, nil
+	}
+	if scope.ConfigID, err = uuid.Parse(configItemID); err != nil {
+		return kvCtx, scope, kvCtx.Oops().Code(api.EINVALID).Errorf("invalid config_item_id %q", configItemID)
+	}
+
+	// An invocation for a config item may only touch that item's keys. Calls
+	// without one (background work, config-less operations) may address any
+	// config item the plugin is enabled for.
+	claims, _ := invocationClaimsFromContext(ctx)
+	if claims.ConfigID != "" {
+		if claims.ConfigID != configItemID {
+			return kvCtx, scope, kvCtx.Oops().Code(api.EFORBIDDEN).Errorf("config_item_id %s does not match the invocation's config %s", configItemID, claims.ConfigID)
+		}
+		return kvCtx, scope, nil
+	}
+	if matches, err := SelectorMatches(kvCtx, entry, configItemID); err != nil {
+		return kvCtx, scope, err
+	} else if !matches {
+		return kvCtx, scope, kvCtx.Oops().Code(api.EFORBIDDEN).Errorf("plugin %s is not enabled for config %s", entry.Name, configItemID)
+	}
+	return kvCtx, scope, nil
+}
+
+func kvError(err error) error {
+	if errors.Is(err, db.ErrPluginKVQuotaExceeded) {
+		return status.Error(codes.ResourceExhausted, err.Error())
+	}
+	return err
+}
+
+func kvEntryToProto(e *db.PluginKV, configItemID string) *pluginAPI.KVEntry {
+	out := &pluginAPI.KVEntry{
+		Key:          e.Key,
+		Value:        e.Value,
+		ConfigItemId: configItemID,
+		Version:      e.Version,
+		UpdatedAt:    timestamppb.New(e.UpdatedAt),
+	}
+	if e.ExpiresAt != nil {
+		out.ExpiresAt = timestamppb.New(*e.ExpiresAt)
+	}
+	return out
+}
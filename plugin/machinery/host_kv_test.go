@@ -0,0 +1,60 @@
+package machinery
+
+import (
+	"context"
+
+	dutyContext "github.com/flanksource/duty/context"
+	"github.com/google/uuid"
+	ginkgo "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	v1 "github.com/flanksource/incident-commander/api/v1"
+	"github.com/flanksource/incident-commander/plugin"
+)
+
+var _ = ginkgo.Describe("kvScope", func() {
+	var (
+		svc      *Service
+		pluginID uuid.UUID
+	)
+
+	ginkgo.BeforeEach(func() {
+		svc = NewGRPCService(dutyContext.NewContext(context.Background()))
+		pluginID = uuid.New()
+		_, err := plugin.DefaultRegistry.Upsert(pluginID, "default", "kv", v1.PluginSpec{})
+		Expect(err).ToNot(HaveOccurred())
+		ginkgo.DeferCleanup(func() { plugin.DefaultRegistry.Remove(pluginID) })
+	})
+
+	withClaims := func(claims plugin.InvocationTokenClaims) context.Context {
+		claims.Plugin = pluginID
+		return context.WithValue(context.Background(), invocationClaimsContextKey{}, &claims)
+	}
+
+	ginkgo.It("scopes keys to the invocation's config item", func() {
+		configID := uuid.New()
+		_, scope, err := svc.kvScope(withClaims(plugin.InvocationTokenClaims{ConfigID: configID.String()}), configID.String())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(scope.PluginID).To(Equal(pluginID))
+		Expect(scope.ConfigID).To(Equal(configID))
+	})
+
+	ginkgo.It("rejects a config item other than the invocation's", func() {
+		_, _, err := svc.kvScope(withClaims(plugin.InvocationTokenClaims{ConfigID: uuid.NewString()}), uuid.NewString())
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("does not match the invocation's config"))
+	})
+
+	ginkgo.It("allows background calls to address plugin-wide and selected config keys", func() {
+		ctx := withClaims(plugin.InvocationTokenClaims{Background: true})
+
+		_, scope, err := svc.kvScope(ctx, "")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(scope.ConfigID).To(Equal(uuid.Nil))
+
+		configID := uuid.New()
+		_, scope, err = svc.kvScope(ctx, configID.String())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(scope.ConfigID).To(Equal(configID))
+	})
+})
@@ -80,6 +80,9 @@ func InvokeOperation(ctx dutyContext.Context, req Request) (*api.InvokeResponse,
 		if err != nil {
 			return nil, entry, dutyAPI.Errorf(dutyAPI.EUNAUTHORIZED, "invalid plugin invocation token: %v", err)
 		}
+		if claims.ConfigID != "" && claims.ConfigID != req.ConfigItemID {
+			return nil, entry, dutyAPI.Errorf(dutyAPI.EFORBIDDEN, "plugin invocation token was issued for config %s", claims.ConfigID)
+		}
 		subject = claims.Subject
 		req.Roles = claims.Roles
 	} else {
@@ -92,7 +95,7 @@ func InvokeOperation(ctx dutyContext.Context, req Request) (*api.InvokeResponse,
 		}
 
 		var err error
-		token, err = plugin.MintInvocationToken(subject, entry.ID, req.Depth, req.Roles...)
+		token, err = plugin.MintInvocationToken(subject, entry.ID, req.ConfigItemID, req.Depth, req.Roles...)
 		if err != nil {
 			return nil, entry, ctx.Oops().Wrapf(err, "mint plugin invocation token")
 		}
@@ -0,0 +1,389 @@
+package local
+
+import (
+	"bytes"
+	gocontext "context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	v1 "github.com/flanksource/incident-commander/api/v1"
+)
+
+// PropertyRequireSignature is the cluster-wide policy that refuses to launch
+// local plugins that don't declare spec.signature.
+const PropertyRequireSignature = "plugins.signature.required"
+
+// ErrUnsigned is returned when the signature policy requires a signature but
+// the plugin does not declare one.
+var ErrUnsigned = errors.New("plugin is not signed")
+
+const signatureFetchTimeout = 30 * time.Second
+
+var (
+	// Fulcio certificate extensions carrying the OIDC issuer.
+	oidFulcioIssuerV1 = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 1}
+	oidFulcioIssuerV2 = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 8}
+)
+
+// signatureBundle is the subset of the `cosign sign-blob --bundle` format we
+// rely on. Cert is a base64 encoded PEM certificate chain, leaf first.
+type signatureBundle struct {
+	Base64Signature string       `json:"base64Signature"`
+	Cert            string       `json:"cert,omitempty"`
+	RekorBundle     *rekorBundle `json:"rekorBundle,omitempty"`
+}
+
+// rekorBundle is the transparency log entry of a keyless signature and the
+// log's signed promise to include it (SET).
+type rekorBundle struct {
+	SignedEntryTimestamp string        `json:"SignedEntryTimestamp"`
+	Payload              rekorLogEntry `json:"Payload"`
+}
+
+// rekorLogEntry is what the SET signs. The fields are in the order of the
+// canonical JSON encoding.
+type rekorLogEntry struct {
+	Body           string `json:"body"`
+	IntegratedTime int64  `json:"integratedTime"`
+	LogID          string `json:"logID"`
+	LogIndex       int64  `json:"logIndex"`
+}
+
+// hashedRekord is the subset of the hashedrekord log entry we check against
+// the signature.
+type hashedRekord struct {
+	Kind string `json:"kind"`
+	Spec struct {
+		Data struct {
+			Hash struct {
+				Algorithm string `json:"algorithm"`
+				Value     string `json:"value"`
+			} `json:"hash"`
+		} `json:"data"`
+		Signature struct {
+			Content   string `json:"content"`
+			PublicKey struct {
+				Content string `json:"content"`
+			} `json:"publicKey"`
+		} `json:"signature"`
+	} `json:"spec"`
+}
+
+// VerifyBinary checks the binary at path against spec. It returns the signer
+// identity on success: the key fingerprint for key based signatures or the
+// certificate subject for keyless ones.
+func VerifyBinary(ctx gocontext.Context, path string, spec v1.PluginSignature) (string, error) {
+	if (spec.PublicKey == "") == (spec.Keyless == nil) {
+		return "", fmt.Errorf("exactly one of publicKey or keyless is required")
+	}
+
+	bundle, err := fetchSignatureBundle(ctx, spec)
+	if err != nil {
+		return "", err
+	}
+
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(bundle.Base64Signature))
+	if err != nil {
+		return "", fmt.Errorf("decode signature: %w", err)
+	}
+
+	blob, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("read plugin binary: %w", err)
+	}
+
+	if spec.PublicKey != "" {
+		pub, err := parsePublicKey([]byte(spec.PublicKey))
+		if err != nil {
+			return "", err
+		}
+		if err := verifyBlob(pub, blob, sig); err != nil {
+			return "", err
+		}
+		return publicKeyFingerprint(pub)
+	}
+
+	signedAt, err := verifyRekorEntry(bundle, blob, sig, *spec.Keyless)
+	if err != nil {
+		return "", err
+	}
+	leaf, err := verifyKeylessCertificate(bundle.Cert, *spec.Keyless, signedAt)
+	if err != nil {
+		return "", err
+	}
+	if err := verifyBlob(leaf.PublicKey, blob, sig); err != nil {
+		return "", err
+	}
+	return spec.Keyless.Subject, nil
+}
+
+func fetchSignatureBundle(ctx gocontext.Context, spec v1.PluginSignature) (*signatureBundle, error) {
+	if spec.BundleURL != "" {
+		body, err := fetchURL(ctx, spec.BundleURL)
+		if err != nil {
+			return nil, fmt.Errorf("fetch signature bundle: %w", err)
+		}
+		var bundle signatureBundle
+		if err := json.Unmarshal(body, &bundle); err != nil {
+			return nil, fmt.Errorf("parse signature bundle: %w", err)
+		}
+		if bundle.Base64Signature == "" {
+			return nil, fmt.Errorf("signature bundle has no base64Signature")
+		}
+		return &bundle, nil
+	}
+
+	if spec.SignatureURL == "" {
+		return nil, fmt.Errorf("signatureURL or bundleURL is required")
+	}
+	if spec.Keyless != nil {
+		return nil, fmt.Errorf("keyless verification requires bundleURL")
+	}
+
+	body, err := fetchURL(ctx, spec.SignatureURL)
+	if err != nil {
+		return nil, fmt.Errorf("fetch signature: %w", err)
+	}
+	return &signatureBundle{Base64Signature: string(body)}, nil
+}
+
+func fetchURL(ctx gocontext.Context, url string) ([]byte, error) {
+	ctx, cancel := gocontext.WithTimeout(ctx, signatureFetchTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: unexpected status %s", url, resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+}
+
+func parsePublicKey(data []byte) (crypto.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("publicKey is not PEM encoded")
+	}
+	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parse publicKey: %w", err)
+	}
+	return pub, nil
+}
+
+func publicKeyFingerprint(pub crypto.PublicKey) (string, error) {
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(der)
+	return fmt.Sprintf("sha256:%x", sum), nil
+}
+
+// verifyBlob verifies sig over blob the same way cosign sign-blob produces it:
+// ECDSA and RSA sign the SHA-256 digest, Ed25519 signs the raw message.
+func verifyBlob(pub crypto.PublicKey, blob, sig []byte) error {
+	digest := sha256.Sum256(blob)
+	switch key := pub.(type) {
+	case *ecdsa.PublicKey:
+		if !ecdsa.VerifyASN1(key, digest[:], sig) {
+			return fmt.Errorf("invalid signature")
+		}
+	case *rsa.PublicKey:
+		if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], sig); err != nil {
+			return fmt.Errorf("invalid signature: %w", err)
+		}
+	case ed25519.PublicKey:
+		if !ed25519.Verify(key, blob, sig) {
+			return fmt.Errorf("invalid signature")
+		}
+	default:
+		return fmt.Errorf("unsupported public key type %T", pub)
+	}
+	return nil
+}
+
+// verifyRekorEntry checks the transparency log entry of a keyless signature:
+// the log signed it and it records this signature, certificate and binary.
+// It returns the time the log integrated the entry.
+func verifyRekorEntry(bundle *signatureBundle, blob, sig []byte, identity v1.PluginKeylessIdentity) (time.Time, error) {
+	if bundle.RekorBundle == nil {
+		return time.Time{}, fmt.Errorf("signature bundle has no transparency log entry")
+	}
+	if identity.RekorPublicKey == "" {
+		return time.Time{}, fmt.Errorf("keyless.rekorPublicKey is required")
+	}
+
+	rekorKey, err := parsePublicKey([]byte(identity.RekorPublicKey))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("keyless.rekorPublicKey: %w", err)
+	}
+	set, err := base64.StdEncoding.DecodeString(bundle.RekorBundle.SignedEntryTimestamp)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("decode signed entry timestamp: %w", err)
+	}
+	// The Go encoding of rekorLogEntry is canonical: its keys are sorted and
+	// base64 and hex strings have nothing to escape.
+	payload, err := json.Marshal(bundle.RekorBundle.Payload)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if err := verifyBlob(rekorKey, payload, set); err != nil {
+		return time.Time{}, fmt.Errorf("transparency log entry: %w", err)
+	}
+
+	body, err := base64.StdEncoding.DecodeString(bundle.RekorBundle.Payload.Body)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("decode transparency log entry: %w", err)
+	}
+	var entry hashedRekord
+	if err := json.Unmarshal(body, &entry); err != nil {
+		return time.Time{}, fmt.Errorf("parse transparency log entry: %w", err)
+	}
+
+	digest := sha256.Sum256(blob)
+	logSig, _ := base64.StdEncoding.DecodeString(entry.Spec.Signature.Content)
+	logCert, _ := base64.StdEncoding.DecodeString(entry.Spec.Signature.PublicKey.Content)
+	bundleCert, err := base64.StdEncoding.DecodeString(bundle.Cert)
+	if err != nil {
+		bundleCert = []byte(bundle.Cert)
+	}
+	switch {
+	case entry.Kind != "hashedrekord":
+		return time.Time{}, fmt.Errorf("unsupported transparency log entry kind %q", entry.Kind)
+	case entry.Spec.Data.Hash.Algorithm != "sha256" || entry.Spec.Data.Hash.Value != fmt.Sprintf("%x", digest):
+		return time.Time{}, fmt.Errorf("transparency log entry is for another binary")
+	case !bytes.Equal(logSig, sig):
+		return time.Time{}, fmt.Errorf("transparency log entry is for another signature")
+	case !sameLeafCertificate(logCert, bundleCert):
+		return time.Time{}, fmt.Errorf("transparency log entry is for another signing certificate")
+	}
+
+	return time.Unix(bundle.RekorBundle.Payload.IntegratedTime, 0), nil
+}
+
+func sameLeafCertificate(a, b []byte) bool {
+	certsA, errA := parseCertificates(a)
+	certsB, errB := parseCertificates(b)
+	if errA != nil || errB != nil || len(certsA) == 0 || len(certsB) == 0 {
+		return false
+	}
+	return certsA[0].Equal(certsB[0])
+}
+
+// verifyKeylessCertificate validates the bundled signing certificate against
+// the trusted roots and the expected identity.
+//
+// Keyless certificates are short lived, so the chain is validated at the time
+// the transparency log recorded the signature.
+func verifyKeylessCertificate(encoded string, identity v1.PluginKeylessIdentity, signedAt time.Time) (*x509.Certificate, error) {
+	if encoded == "" {
+		return nil, fmt.Errorf("signature bundle has no signing certificate")
+	}
+	chainPEM, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		// Some tooling writes the PEM without the extra base64 layer.
+		chainPEM = []byte(encoded)
+	}
+
+	certs, err := parseCertificates(chainPEM)
+	if err != nil {
+		return nil, fmt.Errorf("parse signing certificate: %w", err)
+	} else if len(certs) == 0 {
+		return nil, fmt.Errorf("signature bundle has no signing certificate")
+	}
+	leaf := certs[0]
+
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM([]byte(identity.Roots)) {
+		return nil, fmt.Errorf("keyless.roots has no PEM certificates")
+	}
+	intermediates := x509.NewCertPool()
+	for _, c := range certs[1:] {
+		intermediates.AddCert(c)
+	}
+
+	if _, err := leaf.Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		CurrentTime:   signedAt,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+	}); err != nil {
+		return nil, fmt.Errorf("verify signing certificate: %w", err)
+	}
+
+	if !slices.Contains(certificateIdentities(leaf), identity.Subject) {
+		return nil, fmt.Errorf("signing certificate was not issued to %q", identity.Subject)
+	}
+	if issuer := certificateIssuer(leaf); issuer != identity.Issuer {
+		return nil, fmt.Errorf("signing certificate issuer %q does not match %q", issuer, identity.Issuer)
+	}
+
+	return leaf, nil
+}
+
+func parseCertificates(data []byte) ([]*x509.Certificate, error) {
+	var certs []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, cert)
+	}
+	return certs, nil
+}
+
+func certificateIdentities(cert *x509.Certificate) []string {
+	identities := append([]string{}, cert.EmailAddresses...)
+	for _, uri := range cert.URIs {
+		identities = append(identities, uri.String())
+	}
+	return identities
+}
+
+func certificateIssuer(cert *x509.Certificate) string {
+	for _, ext := range cert.Extensions {
+		switch {
+		case ext.Id.Equal(oidFulcioIssuerV2):
+			var issuer string
+			if _, err := asn1.Unmarshal(ext.Value, &issuer); err == nil {
+				return issuer
+			}
+		case ext.Id.Equal(oidFulcioIssuerV1):
+			return string(bytes.TrimSpace(ext.Value))
+		}
+	}
+	return ""
+}
@@ -0,0 +1,267 @@
+package local
+
+import (
+	gocontext "context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	ginkgo "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	v1 "github.com/flanksource/incident-commander/api/v1"
+)
+
+var _ = ginkgo.Describe("plugin binary signatures", func() {
+	var (
+		binary  string
+		content = []byte("#!/bin/sh\necho plugin\n")
+		server  *httptest.Server
+		files   map[string][]byte
+	)
+
+	ginkgo.BeforeEach(func() {
+		binary = filepath.Join(ginkgo.GinkgoT().TempDir(), "plugin")
+		Expect(os.WriteFile(binary, content, 0o755)).To(Succeed())
+
+		files = map[string][]byte{}
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, ok := files[r.URL.Path]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			_, _ = w.Write(body)
+		}))
+		ginkgo.DeferCleanup(server.Close)
+	})
+
+	publicKeyPEM := func(pub crypto.PublicKey) string {
+		der, err := x509.MarshalPKIXPublicKey(pub)
+		Expect(err).ToNot(HaveOccurred())
+		return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+	}
+
+	ginkgo.It("verifies an ECDSA signature", func() {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		Expect(err).ToNot(HaveOccurred())
+		digest := sha256.Sum256(content)
+		sig, err := ecdsa.SignASN1(rand.Reader, key, digest[:])
+		Expect(err).ToNot(HaveOccurred())
+		files["/plugin.sig"] = []byte(base64.StdEncoding.EncodeToString(sig))
+
+		signer, err := VerifyBinary(gocontext.Background(), binary, v1.PluginSignature{
+			PublicKey:    publicKeyPEM(&key.PublicKey),
+			SignatureURL: server.URL + "/plugin.sig",
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(signer).To(HavePrefix("sha256:"))
+	})
+
+	ginkgo.It("verifies an Ed25519 signature from a bundle", func() {
+		pub, priv, err := ed25519.GenerateKey(rand.Reader)
+		Expect(err).ToNot(HaveOccurred())
+		bundle, _ := json.Marshal(signatureBundle{Base64Signature: base64.StdEncoding.EncodeToString(ed25519.Sign(priv, content))})
+		files["/plugin.bundle"] = bundle
+
+		_, err = VerifyBinary(gocontext.Background(), binary, v1.PluginSignature{
+			PublicKey: publicKeyPEM(pub),
+			BundleURL: server.URL + "/plugin.bundle",
+		})
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	ginkgo.It("rejects a tampered binary", func() {
+		pub, priv, err := ed25519.GenerateKey(rand.Reader)
+		Expect(err).ToNot(HaveOccurred())
+		files["/plugin.sig"] = []byte(base64.StdEncoding.EncodeToString(ed25519.Sign(priv, content)))
+		Expect(os.WriteFile(binary, []byte("tampered"), 0o755)).To(Succeed())
+
+		_, err = VerifyBinary(gocontext.Background(), binary, v1.PluginSignature{
+			PublicKey:    publicKeyPEM(pub),
+			SignatureURL: server.URL + "/plugin.sig",
+		})
+		Expect(err).To(MatchError(ContainSubstring("invalid signature")))
+	})
+
+	ginkgo.It("rejects a signature from another key", func() {
+		_, priv, err := ed25519.GenerateKey(rand.Reader)
+		Expect(err).ToNot(HaveOccurred())
+		otherPub, _, err := ed25519.GenerateKey(rand.Reader)
+		Expect(err).ToNot(HaveOccurred())
+		files["/plugin.sig"] = []byte(base64.StdEncoding.EncodeToString(ed25519.Sign(priv, content)))
+
+		_, err = VerifyBinary(gocontext.Background(), binary, v1.PluginSignature{
+			PublicKey:    publicKeyPEM(otherPub),
+			SignatureURL: server.URL + "/plugin.sig",
+		})
+		Expect(err).To(HaveOccurred())
+	})
+
+	ginkgo.Describe("keyless", func() {
+		const (
+			issuer  = "https://token.actions.githubusercontent.com"
+			subject = "https://github.com/flanksource/plugins/.github/workflows/release.yml@refs/heads/main"
+		)
+
+		var (
+			rootPEM  string
+			rootCert *x509.Certificate
+			rootKey  *ecdsa.PrivateKey
+			rekorKey *ecdsa.PrivateKey
+			rekorPEM string
+		)
+
+		ginkgo.BeforeEach(func() {
+			var err error
+			rootKey, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+			Expect(err).ToNot(HaveOccurred())
+			template := &x509.Certificate{
+				SerialNumber:          big.NewInt(1),
+				Subject:               pkix.Name{CommonName: "test root"},
+				NotBefore:             time.Now().Add(-time.Hour),
+				NotAfter:              time.Now().Add(time.Hour),
+				IsCA:                  true,
+				BasicConstraintsValid: true,
+				KeyUsage:              x509.KeyUsageCertSign,
+			}
+			der, err := x509.CreateCertificate(rand.Reader, template, template, &rootKey.PublicKey, rootKey)
+			Expect(err).ToNot(HaveOccurred())
+			rootCert, err = x509.ParseCertificate(der)
+			Expect(err).ToNot(HaveOccurred())
+			rootPEM = string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+
+			rekorKey, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+			Expect(err).ToNot(HaveOccurred())
+			rekorPEM = publicKeyPEM(&rekorKey.PublicKey)
+		})
+
+		// logEntry records the signature in the transparency log at integratedTime.
+		logEntry := func(sig []byte, certPEM []byte, integratedTime time.Time) *rekorBundle {
+			var entry hashedRekord
+			entry.Kind = "hashedrekord"
+			entry.Spec.Data.Hash.Algorithm = "sha256"
+			entry.Spec.Data.Hash.Value = fmt.Sprintf("%x", sha256.Sum256(content))
+			entry.Spec.Signature.Content = base64.StdEncoding.EncodeToString(sig)
+			entry.Spec.Signature.PublicKey.Content = base64.StdEncoding.EncodeToString(certPEM)
+			body, err := json.Marshal(entry)
+			Expect(err).ToNot(HaveOccurred())
+
+			payload := rekorLogEntry{
+				Body:           base64.StdEncoding.EncodeToString(body),
+				IntegratedTime: integratedTime.Unix(),
+				LogID:          "c0d23d6ad406973f9559f3ba2d1ca01f84147d8ffc5b8445c224f98b9591801d",
+				LogIndex:       42,
+			}
+			canonical, err := json.Marshal(payload)
+			Expect(err).ToNot(HaveOccurred())
+			digest := sha256.Sum256(canonical)
+			set, err := ecdsa.SignASN1(rand.Reader, rekorKey, digest[:])
+			Expect(err).ToNot(HaveOccurred())
+
+			return &rekorBundle{SignedEntryTimestamp: base64.StdEncoding.EncodeToString(set), Payload: payload}
+		}
+
+		signKeyless := func(identity string, integratedTime time.Time) {
+			key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+			Expect(err).ToNot(HaveOccurred())
+			issuerExt, err := asn1.Marshal(issuer)
+			Expect(err).ToNot(HaveOccurred())
+			uri, err := url.Parse(identity)
+			Expect(err).ToNot(HaveOccurred())
+
+			// Short lived, already expired certificate as issued by Fulcio.
+			template := &x509.Certificate{
+				SerialNumber:    big.NewInt(2),
+				NotBefore:       time.Now().Add(-30 * time.Minute),
+				NotAfter:        time.Now().Add(-20 * time.Minute),
+				KeyUsage:        x509.KeyUsageDigitalSignature,
+				ExtKeyUsage:     []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+				URIs:            []*url.URL{uri},
+				ExtraExtensions: []pkix.Extension{{Id: oidFulcioIssuerV2, Value: issuerExt}},
+			}
+			der, err := x509.CreateCertificate(rand.Reader, template, rootCert, &key.PublicKey, rootKey)
+			Expect(err).ToNot(HaveOccurred())
+
+			digest := sha256.Sum256(content)
+			sig, err := ecdsa.SignASN1(rand.Reader, key, digest[:])
+			Expect(err).ToNot(HaveOccurred())
+
+			certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+			bundle, _ := json.Marshal(signatureBundle{
+				Base64Signature: base64.StdEncoding.EncodeToString(sig),
+				Cert:            base64.StdEncoding.EncodeToString(certPEM),
+				RekorBundle:     logEntry(sig, certPEM, integratedTime),
+			})
+			files["/plugin.bundle"] = bundle
+		}
+
+		verify := func() (string, error) {
+			return VerifyBinary(gocontext.Background(), binary, v1.PluginSignature{
+				Keyless:   &v1.PluginKeylessIdentity{Issuer: issuer, Subject: subject, Roots: rootPEM, RekorPublicKey: rekorPEM},
+				BundleURL: server.URL + "/plugin.bundle",
+			})
+		}
+
+		ginkgo.It("verifies the certificate identity", func() {
+			signKeyless(subject, time.Now().Add(-25*time.Minute))
+
+			signer, err := verify()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(signer).To(Equal(subject))
+		})
+
+		ginkgo.It("rejects certificates issued to another identity", func() {
+			signKeyless("https://github.com/someone/else/.github/workflows/release.yml@refs/heads/main", time.Now().Add(-25*time.Minute))
+
+			_, err := verify()
+			Expect(err).To(MatchError(ContainSubstring("was not issued to")))
+		})
+
+		ginkgo.It("rejects signatures logged after the certificate expired", func() {
+			signKeyless(subject, time.Now())
+
+			_, err := verify()
+			Expect(err).To(MatchError(ContainSubstring("verify signing certificate")))
+		})
+
+		ginkgo.It("rejects signatures without a transparency log entry", func() {
+			signKeyless(subject, time.Now().Add(-25*time.Minute))
+			var bundle signatureBundle
+			Expect(json.Unmarshal(files["/plugin.bundle"], &bundle)).To(Succeed())
+			bundle.RekorBundle = nil
+			files["/plugin.bundle"], _ = json.Marshal(bundle)
+
+			_, err := verify()
+			Expect(err).To(MatchError(ContainSubstring("no transparency log entry")))
+		})
+
+		ginkgo.It("rejects log entries not signed by the transparency log", func() {
+			signKeyless(subject, time.Now().Add(-25*time.Minute))
+			var bundle signatureBundle
+			Expect(json.Unmarshal(files["/plugin.bundle"], &bundle)).To(Succeed())
+			bundle.RekorBundle.Payload.IntegratedTime--
+			files["/plugin.bundle"], _ = json.Marshal(bundle)
+
+			_, err := verify()
+			Expect(err).To(MatchError(ContainSubstring("transparency log entry")))
+		})
+	})
+})
@@ -53,6 +53,10 @@ type Supervisor struct {
 	// OnStart is invoked after every successful plugin start, including restarts.
 	OnStart func(dutyContext.Context)
 
+	// Verify is invoked with the binary path before every launch, including
+	// restarts and hot-reloads. A non-nil error aborts the launch.
+	Verify func(ctx dutyContext.Context, binaryPath string) error
+
 	// restartFn is invoked when the binary watcher decides to respawn the
 	// plugin. Tests can substitute a counter; production uses (*Supervisor).restart.
 	restartFn func(dutyContext.Context) error
@@ -94,6 +98,12 @@ func (s *Supervisor) Start(ctx dutyContext.Context, startHost func(broker *goplu
 		return errors.New("supervisor already started")
 	}
 
+	if s.Verify != nil {
+		if err := s.Verify(ctx, s.BinaryPath); err != nil {
+			return fmt.Errorf("plugin %s: verify binary: %w", s.Name, err)
+		}
+	}
+
 	cmd := exec.Command(s.BinaryPath)
 	cmd.Env = append(cmd.Env,
 		fmt.Sprintf("%s=%s", pluginAPI.Handshake.MagicCookieKey, pluginAPI.Handshake.MagicCookieValue),
@@ -135,9 +145,16 @@ func (s *Supervisor) Start(ctx dutyContext.Context, startHost func(broker *goplu
 	}
 	s.hostBrkID = hostBrkID
 
+	env, err := plugin.RegisterEnv(s.ID)
+	if err != nil {
+		cli.Kill()
+		return fmt.Errorf("plugin %s: mint background token: %w", s.Name, err)
+	}
+
 	manifest, err := pluginCli.Service.RegisterPlugin(dialCtx, &pluginAPI.RegisterRequest{
 		HostProtocolVersion: uint32(pluginAPI.ProtocolVersion),
 		HostBrokerId:        hostBrkID,
+		Env:                 env,
 	})
 	if err != nil {
 		cli.Kill()
@@ -99,6 +99,7 @@ func startLocalPlugin(ctx dutyContext.Context, entry *plugin.Entry) error {
 
 func startLocalPluginWithHost(ctx dutyContext.Context, entry *plugin.Entry, startHost func(*goplugin.GRPCBroker) (uint32, error)) error {
 	sup := local.New(entry.ID, entry.Name, entry.InstalledPath)
+	sup.Verify = binaryVerifier(entry.ID)
 	sup.OnStart = func(ctx dutyContext.Context) {
 		writeManifestCache(ctx, entry, sup)
 	}
@@ -83,6 +83,12 @@ func startRemotePlugin(ctx dutyContext.Context, entry *plugin.Entry) error {
 		return fmt.Errorf("plugin %s: %w", entry.Name, err)
 	}
 
+	env, err := plugin.RegisterEnv(entry.ID)
+	if err != nil {
+		_ = conn.Close()
+		return fmt.Errorf("plugin %s: mint background token: %w", entry.Name, err)
+	}
+
 	registerCtx, cancel := gocontext.WithTimeout(ctx, remoteRegisterTimeout)
 	defer cancel()
 	manifest, err := service.RegisterPlugin(registerCtx, &pluginAPI.RegisterRequest{
@@ -90,6 +96,7 @@ func startRemotePlugin(ctx dutyContext.Context, entry *plugin.Entry) error {
 		HostGrpcAddress:     hostGRPCAddress,
 		HostGrpcTls:         hostTLS,
 		HostGrpcCaCert:      hostCACert,
+		Env:                 env,
 	})
 	if err != nil {
 		_ = conn.Close()
@@ -0,0 +1,54 @@
+package machinery
+
+import (
+	"time"
+
+	dutyContext "github.com/flanksource/duty/context"
+	"github.com/google/uuid"
+
+	"github.com/flanksource/incident-commander/plugin"
+	"github.com/flanksource/incident-commander/plugin/machinery/local"
+)
+
+// binaryVerifier enforces the plugin signature policy before the supervisor
+// launches, restarts or hot-reloads a binary. The spec is read from the
+// registry on every call so a restart always checks against the latest CRD.
+// The outcome is recorded on the registry entry for the Plugin status.
+func binaryVerifier(id uuid.UUID) func(dutyContext.Context, string) error {
+	return func(ctx dutyContext.Context, binaryPath string) error {
+		entry := plugin.DefaultRegistry.Get(id)
+		if entry == nil {
+			return ctx.Oops().Errorf("plugin %s not registered", id)
+		}
+
+		result := plugin.Verification{Binary: binaryPath, CheckedAt: time.Now()}
+		err := verifyBinary(ctx, entry, binaryPath, &result)
+		if err != nil {
+			result.Error = err.Error()
+		}
+		if setErr := plugin.DefaultRegistry.SetVerification(id, result); setErr != nil {
+			ctx.Logger.Warnf("plugin %s: record signature verification: %v", entry.Name, setErr)
+		}
+		return err
+	}
+}
+
+func verifyBinary(ctx dutyContext.Context, entry *plugin.Entry, binaryPath string, result *plugin.Verification) error {
+	if entry.Spec.Signature == nil {
+		if ctx.Properties().On(false, local.PropertyRequireSignature) {
+			return ctx.Oops().Wrapf(local.ErrUnsigned, "plugin %s refused by %s policy", entry.Name, local.PropertyRequireSignature)
+		}
+		return nil
+	}
+
+	result.Signed = true
+	signer, err := local.VerifyBinary(ctx, binaryPath, *entry.Spec.Signature)
+	if err != nil {
+		return ctx.Oops().Wrapf(err, "plugin %s signature verification failed", entry.Name)
+	}
+
+	result.Verified = true
+	result.Signer = signer
+	ctx.Logger.V(2).Infof("plugin %s: binary %s signed by %s", entry.Name, binaryPath, signer)
+	return nil
+}
@@ -2,11 +2,13 @@ package reconciler
 
 import (
 	"fmt"
+	"reflect"
 	"strings"
 
 	"github.com/flanksource/duty/context"
 	"github.com/flanksource/duty/query/grammar"
 	"github.com/google/uuid"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	k8stypes "k8s.io/apimachinery/pkg/types"
 
@@ -47,7 +49,8 @@ func PersistPluginFromCRD(ctx context.Context, p *v1.Plugin) error {
 		return err
 	}
 
-	binaryChanged := previous != nil && (previous.Spec.Source != p.Spec.Source || previous.Spec.Version != p.Spec.Version)
+	binaryChanged := previous != nil && (previous.Spec.Source != p.Spec.Source || previous.Spec.Version != p.Spec.Version ||
+		!reflect.DeepEqual(previous.Spec.Signature, p.Spec.Signature))
 	if binaryChanged {
 		if err := machinery.StopPlugin(id); err != nil {
 			return err
@@ -55,6 +58,7 @@ func PersistPluginFromCRD(ctx context.Context, p *v1.Plugin) error {
 	}
 
 	if err := machinery.StartPlugin(ctx, id); err != nil {
+		setSignatureCondition(p, plugin.DefaultRegistry.Get(id))
 		if previous != nil {
 			if _, rollbackErr := plugin.DefaultRegistry.Upsert(previous.ID, previous.Namespace, previous.Name, previous.Spec); rollbackErr != nil {
 				ctx.Logger.Errorf("plugin %s: rollback registry entry: %v", id, rollbackErr)
@@ -68,10 +72,12 @@ func PersistPluginFromCRD(ctx context.Context, p *v1.Plugin) error {
 		}
 		return err
 	}
-	if entry := plugin.DefaultRegistry.Get(id); entry != nil && entry.Manifest != nil {
+	entry := plugin.DefaultRegistry.Get(id)
+	if entry != nil && entry.Manifest != nil {
 		p.Status.PluginVersion = entry.Manifest.Version
 		p.Status.InstalledPath = entry.InstalledPath
 	}
+	setSignatureCondition(p, entry)
 	if err := db.UpdatePluginStatus(ctx, id, p.Status); err != nil {
 		ctx.Logger.V(2).Infof("plugin %s: failed to update persisted status: %v", p.Name, err)
 	}
@@ -116,6 +122,11 @@ func DeletePlugin(ctx context.Context, id string) error {
 			ctx.Logger.V(2).Infof("plugin %s: drop manifest cache: %v", entry.Name, err)
 		}
 	}
+	// The plugin's durable state is keyed by the CRD UID, which a re-created
+	// CRD won't reuse.
+	if err := db.DeleteAllPluginKV(ctx, pluginID); err != nil {
+		ctx.Logger.Warnf("plugin %s: drop kv state: %v", pluginID, err)
+	}
 	return db.DeletePlugin(ctx, pluginID.String())
 }
 
@@ -178,6 +189,39 @@ func ReplayPlugins(ctx context.Context) error {
 	return nil
 }
 
+// setSignatureCondition reports the latest binary signature check of a local
+// plugin on its status. Remote and proxied plugins have no binary to verify.
+func setSignatureCondition(p *v1.Plugin, entry *plugin.Entry) {
+	if entry == nil || entry.Verification == nil {
+		return
+	}
+
+	v := entry.Verification
+	condition := metav1.Condition{
+		Type:               v1.PluginConditionSignatureVerified,
+		ObservedGeneration: p.Generation,
+	}
+	switch {
+	case v.Verified:
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = "Verified"
+		condition.Message = fmt.Sprintf("signed by %s", v.Signer)
+	case !v.Signed && v.Error == "":
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "Unsigned"
+		condition.Message = "plugin does not declare spec.signature"
+	case !v.Signed:
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "Unsigned"
+		condition.Message = v.Error
+	default:
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "VerificationFailed"
+		condition.Message = v.Error
+	}
+	meta.SetStatusCondition(&p.Status.Conditions, condition)
+}
+
 func validatePluginSelector(p *v1.Plugin) error {
 	selector := p.Spec.Selector
 	if selector.IsEmpty() {
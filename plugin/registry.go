@@ -14,6 +14,7 @@ import (
 	"sort"
 	"strings"
 	"sync"
+	"time"
 
 	v1 "github.com/flanksource/incident-commander/api/v1"
 	"github.com/flanksource/incident-commander/plugin/api"
@@ -46,6 +47,22 @@ type Entry struct {
 	// An agent id implies this is a proxied plugin.
 	// The agent hosts it and mission-control must proxy all operation calls to it.
 	AgentID *uuid.UUID
+
+	// Verification is the outcome of the most recent signature check of the
+	// installed binary. Nil until a local plugin binary has been checked.
+	Verification *Verification
+}
+
+// Verification is the outcome of verifying a plugin binary's signature.
+type Verification struct {
+	// Signed is false when the plugin does not declare spec.signature.
+	Signed   bool
+	Verified bool
+	Signer   string
+	Error    string
+	// Binary is the path that was checked.
+	Binary    string
+	CheckedAt time.Time
 }
 
 // Registry is the host-side in-memory store of plugins.
@@ -61,6 +78,9 @@ type Registry struct {
 	// namespace. Bare-name lookups are resolved by scanning plugins so duplicate
 	// names can be reported as ambiguous instead of stored in this index.
 	refs map[string]uuid.UUID
+
+	watchersMu sync.RWMutex
+	watchers   []func()
 }
 
 // DefaultRegistry is the singleton used by the kopper reconciler and by every echo
@@ -92,6 +112,7 @@ func (r *Registry) Upsert(id uuid.UUID, namespace, name string, spec v1.PluginSp
 
 // UpsertProxied registers a plugin runtime reported by an authenticated agent.
 func (r *Registry) UpsertProxied(id uuid.UUID, namespace, name string, spec v1.PluginSpec, manifest *api.PluginManifest, agentID uuid.UUID) (*Entry, error) {
+	defer r.notify()
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
@@ -135,8 +156,27 @@ func (r *Registry) upsertLocked(id uuid.UUID, namespace, name string, spec v1.Pl
 	return e, nil
 }
 
+// Watch registers fn to be called whenever a plugin's manifest or runtime
+// changes, or a plugin is removed. Callbacks run after the registry lock is
+// released, so they may read the registry but must not block for long.
+func (r *Registry) Watch(fn func()) {
+	r.watchersMu.Lock()
+	defer r.watchersMu.Unlock()
+	r.watchers = append(r.watchers, fn)
+}
+
+func (r *Registry) notify() {
+	r.watchersMu.RLock()
+	watchers := append([]func(){}, r.watchers...)
+	r.watchersMu.RUnlock()
+	for _, fn := range watchers {
+		fn()
+	}
+}
+
 // SetManifest stores the manifest a plugin returned from RegisterPlugin.
 func (r *Registry) SetManifest(id uuid.UUID, m *api.PluginManifest) error {
+	defer r.notify()
 	r.mu.Lock()
 	defer r.mu.Unlock()
 	e, ok := r.plugins[id]
@@ -205,6 +245,7 @@ func (r *Registry) Resolve(ref string) (*Entry, error) {
 
 // SetRuntime stores the running-process handle for a plugin.
 func (r *Registry) SetRuntime(id uuid.UUID, runtime Runtime) error {
+	defer r.notify()
 	r.mu.Lock()
 	defer r.mu.Unlock()
 	e, ok := r.plugins[id]
@@ -227,8 +268,21 @@ func (r *Registry) SetInstalledPath(id uuid.UUID, path string) error {
 	return nil
 }
 
+// SetVerification stores the outcome of the latest binary signature check.
+func (r *Registry) SetVerification(id uuid.UUID, v Verification) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	e, ok := r.plugins[id]
+	if !ok {
+		return fmt.Errorf("plugin %s not registered", id)
+	}
+	e.Verification = &v
+	return nil
+}
+
 // SetRuntimeIfAbsent stores the runtime only when no runtime is already active.
 func (r *Registry) SetRuntimeIfAbsent(id uuid.UUID, runtime Runtime) (bool, error) {
+	defer r.notify()
 	r.mu.Lock()
 	defer r.mu.Unlock()
 	e, ok := r.plugins[id]
@@ -244,6 +298,7 @@ func (r *Registry) SetRuntimeIfAbsent(id uuid.UUID, runtime Runtime) (bool, erro
 
 // PopRuntime removes and returns the running-process handle for a plugin.
 func (r *Registry) PopRuntime(id uuid.UUID) Runtime {
+	defer r.notify()
 	r.mu.Lock()
 	defer r.mu.Unlock()
 	e, ok := r.plugins[id]
@@ -258,6 +313,7 @@ func (r *Registry) PopRuntime(id uuid.UUID) Runtime {
 // Remove drops a plugin from the registry. Callers are responsible for
 // stopping the supervisor first.
 func (r *Registry) Remove(id uuid.UUID) {
+	defer r.notify()
 	r.mu.Lock()
 	defer r.mu.Unlock()
 	if e, ok := r.plugins[id]; ok {
@@ -4,8 +4,11 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"sync"
+	"time"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/protobuf/types/known/structpb"
 
 	pluginpb "github.com/flanksource/incident-commander/plugin/api"
@@ -41,6 +44,23 @@ type HostClient interface {
 
 	// ReadArtifact retrieves an artifact previously written via the host.
 	ReadArtifact(ctx context.Context, ref *pluginpb.ArtifactRef) (*pluginpb.Artifact, error)
+
+	// KVGet reads a key from the plugin's durable state. configItemID scopes the
+	// key to a config item; pass "" for plugin wide keys.
+	KVGet(ctx context.Context, key, configItemID string) (*pluginpb.KVEntry, error)
+
+	// KVPut creates or overwrites a key. A ttl of 0 never expires.
+	KVPut(ctx context.Context, key, configItemID string, value []byte, ttl time.Duration) (*pluginpb.KVEntry, error)
+
+	// KVDelete removes a key. Deleting a missing key is not an error.
+	KVDelete(ctx context.Context, key, configItemID string) error
+
+	// KVList returns the entries whose key starts with prefix, ordered by key.
+	KVList(ctx context.Context, prefix, configItemID string) ([]*pluginpb.KVEntry, error)
+
+	// KVCompareAndSwap writes a key only if its version still equals
+	// expectedVersion (0 = the key must not exist) and returns the stored entry.
+	KVCompareAndSwap(ctx context.Context, key, configItemID string, expectedVersion int64, value []byte, ttl time.Duration) (bool, *pluginpb.KVEntry, error)
 }
 
 type hostClient struct {
@@ -52,6 +72,41 @@ func newHostClient(conn *grpc.ClientConn, token string) *hostClient {
 	return &hostClient{c: pluginpb.NewHostServiceClient(conn), invocationToken: token}
 }
 
+// newBackgroundHostClient returns a HostClient for calls made outside an
+// invocation. Every call carries the plugin's background token, which is
+// swapped for the refreshed one the host returns as it nears expiry.
+func newBackgroundHostClient(conn *grpc.ClientConn, token string) *hostClient {
+	return &hostClient{c: pluginpb.NewHostServiceClient(&backgroundConn{conn: conn, token: token})}
+}
+
+type backgroundConn struct {
+	conn  *grpc.ClientConn
+	mu    sync.Mutex
+	token string
+}
+
+func (b *backgroundConn) currentToken() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.token
+}
+
+func (b *backgroundConn) Invoke(ctx context.Context, method string, args, reply any, opts ...grpc.CallOption) error {
+	var header metadata.MD
+	opts = append(opts, grpc.Header(&header))
+	err := b.conn.Invoke(withInvocationToken(ctx, b.currentToken()), method, args, reply, opts...)
+	if values := header.Get(pluginpb.BackgroundTokenGRPCMetadataKey); len(values) > 0 && values[0] != "" {
+		b.mu.Lock()
+		b.token = values[0]
+		b.mu.Unlock()
+	}
+	return err
+}
+
+func (b *backgroundConn) NewStream(ctx context.Context, desc *grpc.StreamDesc, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	return b.conn.NewStream(withInvocationToken(ctx, b.currentToken()), desc, method, opts...)
+}
+
 func (h *hostClient) authContext(ctx context.Context) context.Context {
 	return withInvocationToken(ctx, h.invocationToken)
 }
@@ -98,6 +153,46 @@ func (h *hostClient) ReadArtifact(ctx context.Context, ref *pluginpb.ArtifactRef
 	return h.c.ReadArtifact(h.authContext(ctx), ref)
 }
 
+func (h *hostClient) KVGet(ctx context.Context, key, configItemID string) (*pluginpb.KVEntry, error) {
+	return h.c.KVGet(h.authContext(ctx), &pluginpb.KVGetRequest{Key: key, ConfigItemId: configItemID})
+}
+
+func (h *hostClient) KVPut(ctx context.Context, key, configItemID string, value []byte, ttl time.Duration) (*pluginpb.KVEntry, error) {
+	return h.c.KVPut(h.authContext(ctx), &pluginpb.KVPutRequest{
+		Key:          key,
+		ConfigItemId: configItemID,
+		Value:        value,
+		TtlSeconds:   int64(ttl / time.Second),
+	})
+}
+
+func (h *hostClient) KVDelete(ctx context.Context, key, configItemID string) error {
+	_, err := h.c.KVDelete(h.authContext(ctx), &pluginpb.KVDeleteRequest{Key: key, ConfigItemId: configItemID})
+	return err
+}
+
+func (h *hostClient) KVList(ctx context.Context, prefix, configItemID string) ([]*pluginpb.KVEntry, error) {
+	resp, err := h.c.KVList(h.authContext(ctx), &pluginpb.KVListRequest{Prefix: prefix, ConfigItemId: configItemID})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Entries, nil
+}
+
+func (h *hostClient) KVCompareAndSwap(ctx context.Context, key, configItemID string, expectedVersion int64, value []byte, ttl time.Duration) (bool, *pluginpb.KVEntry, error) {
+	resp, err := h.c.KVCompareAndSwap(h.authContext(ctx), &pluginpb.KVCompareAndSwapRequest{
+		Key:             key,
+		ConfigItemId:    configItemID,
+		ExpectedVersion: expectedVersion,
+		Value:           value,
+		TtlSeconds:      int64(ttl / time.Second),
+	})
+	if err != nil {
+		return false, nil, err
+	}
+	return resp.Swapped, resp.Entry, nil
+}
+
 // settingsFromStruct decodes a *structpb.Struct into a JSON-shaped map[string]any.
 // Used when passing CRD spec.properties through Configure().
 func settingsFromStruct(s *structpb.Struct) (map[string]any, error) {
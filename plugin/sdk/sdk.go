@@ -35,6 +35,14 @@ type Plugin interface {
 	Operations() []Operation
 }
 
+// BackgroundHostReceiver is implemented by plugins that call back into the
+// host outside an operation, e.g. from a polling loop. After RegisterPlugin the
+// SDK hands it a HostClient authenticated as the plugin itself; the host only
+// serves KV calls on it.
+type BackgroundHostReceiver interface {
+	SetBackgroundHost(host HostClient)
+}
+
 // Operation is a runtime handler for a named operation declared in the
 // plugin's manifest. Handler serves unary gRPC invocation. HTTPHandler serves
 // the operation's declared HTTP methods at /__mc/operations/<operation-name>.
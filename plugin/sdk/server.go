@@ -93,6 +93,17 @@ func (s *pluginServer) RegisterPlugin(ctx context.Context, req *pluginpb.Registe
 		s.mu.Unlock()
 	}
 
+	if receiver, ok := s.impl.(BackgroundHostReceiver); ok {
+		if token := req.Env[pluginpb.BackgroundTokenEnvKey]; token != "" {
+			s.mu.Lock()
+			conn := s.mcgPRCConn
+			s.mu.Unlock()
+			if conn != nil {
+				receiver.SetBackgroundHost(newBackgroundHostClient(conn, token))
+			}
+		}
+	}
+
 	manifest := s.impl.Manifest()
 	return s.finishRegister(manifest)
 }
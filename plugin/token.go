@@ -7,18 +7,36 @@ import (
 
 	"github.com/flanksource/incident-commander/api"
 	"github.com/flanksource/incident-commander/auth/signing"
+	pluginAPI "github.com/flanksource/incident-commander/plugin/api"
 	"github.com/golang-jwt/jwt/v4"
 	"github.com/google/uuid"
 )
 
 var PluginJWTTTL = 5 * time.Minute
 
+// PluginBackgroundJWTTTL bounds the lifetime of the token a plugin uses for
+// host callbacks made outside an invocation. The host re-issues it before it
+// expires (see BackgroundTokenGRPCMetadataKey).
+var PluginBackgroundJWTTTL = 24 * time.Hour
+
+// BackgroundSubject is the subject of background tokens. They act for the
+// plugin itself rather than on behalf of a user.
+const BackgroundSubject = "plugin-background"
+
 // InvocationTokenClaims identifies the plugin invocation context carried between
 // Mission Control, plugins, and plugin host callbacks.
 type InvocationTokenClaims struct {
 	Plugin uuid.UUID `json:"pluginID"`
 	Depth  int       `json:"depth,omitempty"`
 	Roles  []string  `json:"roles,omitempty"`
+
+	// ConfigID is the config item the invocation was authorized for. Host
+	// callbacks may only address config-scoped state of this item.
+	ConfigID string `json:"configID,omitempty"`
+
+	// Background marks a token issued to the plugin process at registration
+	// for host callbacks that happen outside any invocation.
+	Background bool `json:"background,omitempty"`
 	jwt.RegisteredClaims
 }
 
@@ -30,7 +48,8 @@ func (c *InvocationTokenClaims) VerifyExpiresAt(cmp int64, req bool) bool {
 }
 
 // MintInvocationToken creates a short-lived token for invoking a specific plugin.
-func MintInvocationToken(subject string, pluginID uuid.UUID, depth int, roles ...string) (string, error) {
+// configID is the config item the invocation targets, or "" when there is none.
+func MintInvocationToken(subject string, pluginID uuid.UUID, configID string, depth int, roles ...string) (string, error) {
 	if subject == "" {
 		return "", fmt.Errorf("plugin invocation subject is required")
 	}
@@ -40,9 +59,10 @@ func MintInvocationToken(subject string, pluginID uuid.UUID, depth int, roles ..
 
 	now := time.Now()
 	claims := InvocationTokenClaims{
-		Plugin: pluginID,
-		Depth:  depth,
-		Roles:  append([]string(nil), roles...),
+		Plugin:   pluginID,
+		Depth:    depth,
+		Roles:    append([]string(nil), roles...),
+		ConfigID: configID,
 		RegisteredClaims: jwt.RegisteredClaims{
 			Issuer:    signing.Issuer,
 			Subject:   subject,
@@ -56,6 +76,41 @@ func MintInvocationToken(subject string, pluginID uuid.UUID, depth int, roles ..
 	return signing.NewJWT(signing.AudiencePluginInvocation, &claims)
 }
 
+// MintBackgroundToken creates the token a plugin process presents for host
+// callbacks it makes on its own, e.g. KV access from a background loop. It
+// carries no user, roles or config item.
+func MintBackgroundToken(pluginID uuid.UUID) (string, error) {
+	if pluginID == uuid.Nil {
+		return "", fmt.Errorf("plugin id is required")
+	}
+
+	now := time.Now()
+	claims := InvocationTokenClaims{
+		Plugin:     pluginID,
+		Background: true,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    signing.Issuer,
+			Subject:   BackgroundSubject,
+			Audience:  jwt.ClaimStrings{string(signing.AudiencePluginInvocation)},
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(PluginBackgroundJWTTTL)),
+		},
+	}
+
+	return signing.NewJWT(signing.AudiencePluginInvocation, &claims)
+}
+
+// RegisterEnv returns the RegisterRequest.Env the host sends a plugin process
+// when it registers it.
+func RegisterEnv(pluginID uuid.UUID) (map[string]string, error) {
+	token, err := MintBackgroundToken(pluginID)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{pluginAPI.BackgroundTokenEnvKey: token}, nil
+}
+
 // ValidateInvocationToken validates a locally-signed invocation token without an
 // expected plugin ID. Use this when the plugin identity must come from the token
 // itself, such as plugin HostService callbacks, where the host resolves the
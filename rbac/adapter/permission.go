@@ -56,7 +56,8 @@ func (a *PermissionAdapter) LoadPolicy(model model.Model) error {
 	}
 
 	var permissions []models.Permission
-	if err := a.ctx.DB().Where("deleted_at IS NULL AND error IS NULL").Find(&permissions).Error; err != nil {
+	// Permissions past their until, e.g. expired elevations, no longer apply.
+	if err := a.ctx.DB().Where("deleted_at IS NULL AND error IS NULL AND (until IS NULL OR until > NOW())").Find(&permissions).Error; err != nil {
 		return fmt.Errorf("failed to load permissions: %w", err)
 	}
 
@@ -0,0 +1,31 @@
+package rbac
+
+import (
+	"github.com/flanksource/duty/context"
+
+	"github.com/flanksource/incident-commander/db"
+)
+
+// GetBreakGlass returns the active break-glass activation the user acts
+// under, or nil if there's none. Only people created for an activation are
+// looked up, and never from a cache, so an ended activation stops counting
+// at once.
+func GetBreakGlass(ctx context.Context) (*db.BreakGlassActivation, error) {
+	user := ctx.User()
+	if user == nil || !db.IsBreakGlassPerson(*user) {
+		return nil, nil
+	}
+	return db.GetBreakGlassActivationOfPerson(ctx, user.ID)
+}
+
+// breakGlassAdmin reports whether the user acts under an active break-glass
+// activation. They are admins even before the enforcer reloads the role
+// granted to them by the CLI.
+func breakGlassAdmin(ctx context.Context) bool {
+	activation, err := GetBreakGlass(ctx)
+	if err != nil {
+		ctx.Errorf("failed to get break-glass activation of %s: %v", ctx.User().ID, err)
+		return false
+	}
+	return activation != nil
+}
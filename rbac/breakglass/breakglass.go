@@ -0,0 +1,377 @@
+// Package breakglass grants time-limited admin access with a sealed emergency
+// credential, for when the identity provider is down. Every activation is
+// announced to the configured recipients and every request made under it is
+// marked in the audit log.
+package breakglass
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/flanksource/commons/duration"
+	dutyAPI "github.com/flanksource/duty/api"
+	"github.com/flanksource/duty/context"
+	dutyRBAC "github.com/flanksource/duty/rbac"
+	"github.com/flanksource/duty/rbac/policy"
+	"github.com/flanksource/duty/secret"
+	"github.com/google/uuid"
+	"github.com/samber/lo"
+
+	v1 "github.com/flanksource/incident-commander/api/v1"
+	"github.com/flanksource/incident-commander/audit"
+	"github.com/flanksource/incident-commander/auth"
+	"github.com/flanksource/incident-commander/db"
+	"github.com/flanksource/incident-commander/notification"
+)
+
+const (
+	// Route is what the audit log records as the route of CLI actions.
+	Route = "token break-glass"
+
+	defaultDuration    = time.Hour
+	defaultMaxDuration = 4 * time.Hour
+
+	secretSize = 32
+)
+
+// SealRequest seals a new credential, split into Shares of which Threshold
+// are needed to activate it.
+type SealRequest struct {
+	Shares    int
+	Threshold int
+	SealedBy  string
+}
+
+// ActivateRequest activates the credential on behalf of the Operator.
+type ActivateRequest struct {
+	Operator string `json:"operator"`
+	Reason   string `json:"reason"`
+	Duration string `json:"duration"`
+}
+
+// Activation is an activation and the admin access token it was issued.
+type Activation struct {
+	db.BreakGlassActivation
+	Token secret.Sensitive `json:"-"`
+}
+
+// Seal generates a new credential, revoking the previous one. It returns the
+// hex encoded credential, or its shares. They aren't stored and can't be
+// recovered.
+func Seal(ctx context.Context, req SealRequest) ([]string, error) {
+	if req.Shares <= 0 {
+		req.Shares = 1
+	}
+	if req.Threshold <= 0 {
+		req.Threshold = req.Shares
+	}
+	if req.Shares > 1 && req.Threshold < 2 {
+		return nil, dutyAPI.Errorf(dutyAPI.EINVALID, "threshold must be at least 2 when splitting into shares")
+	}
+
+	key := make([]byte, secretSize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, ctx.Oops().Wrapf(err, "failed to generate break-glass credential")
+	}
+
+	parts := []string{hex.EncodeToString(key)}
+	if req.Shares > 1 {
+		shares, err := Split(key, req.Shares, req.Threshold)
+		if err != nil {
+			return nil, dutyAPI.Errorf(dutyAPI.EINVALID, "%v", err)
+		}
+		parts = lo.Map(shares, func(share []byte, _ int) string { return hex.EncodeToString(share) })
+	} else {
+		req.Threshold = 1
+	}
+
+	credential := db.BreakGlassCredential{
+		Hash:      hash(key),
+		Shares:    req.Shares,
+		Threshold: req.Threshold,
+		SealedBy:  req.SealedBy,
+	}
+	if err := db.SealBreakGlassCredential(ctx, &credential); err != nil {
+		return nil, err
+	}
+
+	record(ctx, "seal", db.AuditDecisionAllow, nil)
+	ctx.Infof("break-glass credential %s sealed by %s (%d of %d shares)", credential.ID, req.SealedBy, credential.Threshold, credential.Shares)
+	return parts, nil
+}
+
+// Activate checks the credential, or enough of its shares, and issues an admin
+// access token that expires after the requested duration.
+func Activate(ctx context.Context, parts []string, req ActivateRequest) (*Activation, error) {
+	if strings.TrimSpace(req.Operator) == "" {
+		return nil, dutyAPI.Errorf(dutyAPI.EINVALID, "operator is required")
+	} else if strings.TrimSpace(req.Reason) == "" {
+		return nil, dutyAPI.Errorf(dutyAPI.EINVALID, "reason is required")
+	}
+
+	d := defaultDuration
+	if req.Duration != "" {
+		parsed, err := duration.ParseDuration(req.Duration)
+		if err != nil {
+			return nil, dutyAPI.Errorf(dutyAPI.EINVALID, "invalid duration %q: %v", req.Duration, err)
+		}
+		d = time.Duration(parsed)
+	}
+	if maxDuration := ctx.Properties().Duration("break_glass.max_duration", defaultMaxDuration); d <= 0 || d > maxDuration {
+		return nil, dutyAPI.Errorf(dutyAPI.EINVALID, "duration must be between 0 and %s", maxDuration)
+	}
+
+	credential, err := db.GetBreakGlassCredential(ctx)
+	if err != nil {
+		return nil, err
+	} else if credential == nil {
+		return nil, dutyAPI.Errorf(dutyAPI.ENOTFOUND, "no break-glass credential is sealed")
+	}
+
+	if err := verify(credential, parts); err != nil {
+		record(ctx, "activate", db.AuditDecisionDeny, nil)
+		ctx.Warnf("break-glass activation by %s denied: %v", req.Operator, err)
+		return nil, dutyAPI.Errorf(dutyAPI.EUNAUTHORIZED, "invalid break-glass credential")
+	}
+
+	suffix := uuid.NewString()[:8]
+	person, err := db.CreatePerson(ctx, fmt.Sprintf("Break Glass (%s)", req.Operator), db.BreakGlassEmail(suffix), db.PersonTypeAccessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	token, accessToken, err := db.CreateAccessToken(ctx, person.ID, "break-glass-"+suffix, &d, nil, false)
+	if err != nil {
+		return nil, err
+	}
+
+	activation := Activation{
+		BreakGlassActivation: db.BreakGlassActivation{
+			CredentialID:  credential.ID,
+			PersonID:      person.ID,
+			AccessTokenID: accessToken.ID,
+			Operator:      req.Operator,
+			Reason:        req.Reason,
+			ExpiresAt:     lo.FromPtr(accessToken.ExpiresAt),
+		},
+		Token: token,
+	}
+	if err := db.CreateBreakGlassActivation(ctx, &activation.BreakGlassActivation); err != nil {
+		return nil, err
+	}
+
+	// The middleware honours the activation until the enforcer of the server
+	// reloads the role.
+	if dutyRBAC.Enforcer() != nil {
+		if err := dutyRBAC.AddRoleForUser(person.ID.String(), policy.RoleAdmin); err != nil {
+			return nil, ctx.Oops().Wrapf(err, "failed to grant admin to break-glass activation %s", activation.ID)
+		}
+	}
+
+	record(ctx, "activate", db.AuditDecisionAllow, &activation.BreakGlassActivation)
+	notify(ctx, activation.BreakGlassActivation,
+		fmt.Sprintf("URGENT: break-glass access activated by %s", req.Operator),
+		fmt.Sprintf("%s activated break-glass admin access until %s: %s", req.Operator, activation.ExpiresAt.Format(time.RFC1123), req.Reason))
+
+	ctx.Warnf("break-glass activation %s by %s until %s: %s", activation.ID, req.Operator, activation.ExpiresAt.Format(time.RFC3339), req.Reason)
+	return &activation, nil
+}
+
+// End ends the activation before it expires.
+func End(ctx context.Context, id uuid.UUID) (*db.BreakGlassActivation, error) {
+	activation, err := db.GetBreakGlassActivation(ctx, id)
+	if err != nil {
+		return nil, err
+	} else if activation == nil {
+		return nil, dutyAPI.Errorf(dutyAPI.ENOTFOUND, "break-glass activation %s not found", id)
+	} else if activation.EndedAt != nil {
+		return nil, dutyAPI.Errorf(dutyAPI.ECONFLICT, "break-glass activation %s has already ended", id)
+	}
+
+	var by *uuid.UUID
+	if user := ctx.User(); user != nil {
+		by = &user.ID
+	}
+	if err := end(ctx, *activation, by, "ended"); err != nil {
+		return nil, err
+	}
+	return db.GetBreakGlassActivation(ctx, id)
+}
+
+// Expire ends the activations past their expiry. It returns the number of
+// activations it ended.
+func Expire(ctx context.Context) (int, error) {
+	expired, err := db.GetExpiredBreakGlassActivations(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	var ended int
+	var errs []error
+	for _, activation := range expired {
+		if err := end(ctx, activation, nil, "expired"); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		ended++
+	}
+
+	if len(errs) > 0 {
+		return ended, fmt.Errorf("failed to expire %d break-glass activations: %v", len(errs), errs)
+	}
+	return ended, nil
+}
+
+// end takes back the admin role of the activation and revokes its access
+// token. The role goes first: should that fail the activation stays open and
+// is retried by Expire.
+func end(ctx context.Context, activation db.BreakGlassActivation, by *uuid.UUID, status string) error {
+	if dutyRBAC.Enforcer() != nil {
+		if err := dutyRBAC.DeleteRoleForUser(activation.PersonID.String(), policy.RoleAdmin); err != nil {
+			return ctx.Oops().Wrapf(err, "failed to remove admin from break-glass activation %s", activation.ID)
+		}
+	}
+
+	if ok, err := db.EndBreakGlassActivation(ctx, activation.ID, by); err != nil || !ok {
+		// Already ended
+		return err
+	}
+
+	if err := auth.DeleteAccessToken(ctx, activation.AccessTokenID.String()); err != nil {
+		return ctx.Oops().Wrapf(err, "failed to delete access token of break-glass activation %s", activation.ID)
+	}
+
+	auth.InvalidateRLSCacheForUser(activation.PersonID.String())
+
+	activation.EndedAt, activation.EndedBy = lo.ToPtr(time.Now()), by
+	record(ctx, status, db.AuditDecisionAllow, &activation)
+	notify(ctx, activation,
+		fmt.Sprintf("Break-glass access of %s %s", activation.Operator, status),
+		fmt.Sprintf("Break-glass admin access activated by %s on %s has %s.", activation.Operator, activation.CreatedAt.Format(time.RFC1123), status))
+
+	ctx.Infof("break-glass activation %s of %s %s", activation.ID, activation.Operator, status)
+	return nil
+}
+
+// verify checks the credential, combining the shares when it was split.
+func verify(credential *db.BreakGlassCredential, parts []string) error {
+	var decoded [][]byte
+	for _, part := range parts {
+		if part = strings.TrimSpace(part); part == "" {
+			continue
+		}
+		b, err := hex.DecodeString(part)
+		if err != nil {
+			return fmt.Errorf("credential is not hex encoded")
+		}
+		decoded = append(decoded, b)
+	}
+
+	var key []byte
+	switch {
+	case credential.Threshold <= 1 && len(decoded) == 1:
+		key = decoded[0]
+	case credential.Threshold > 1 && len(decoded) >= credential.Threshold:
+		var err error
+		if key, err = Combine(decoded); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("%d of %d shares are required, got %d", credential.Threshold, credential.Shares, len(decoded))
+	}
+
+	if subtle.ConstantTimeCompare([]byte(hash(key)), []byte(credential.Hash)) != 1 {
+		return fmt.Errorf("credential doesn't match")
+	}
+	return nil
+}
+
+func hash(key []byte) string {
+	sum := sha256.Sum256(key)
+	return hex.EncodeToString(sum[:])
+}
+
+// record writes a break-glass action to the audit log. Actions taken from the
+// CLI don't pass through the audit middleware.
+func record(ctx context.Context, action, decision string, activation *db.BreakGlassActivation) {
+	entry := db.AuditLog{
+		Method:   "CLI",
+		Route:    Route,
+		Path:     Route + " " + action,
+		Object:   policy.ObjectRBAC,
+		Action:   action,
+		Decision: decision,
+	}
+	if user := ctx.User(); user != nil {
+		entry.ActorID = &user.ID
+	}
+	if activation != nil {
+		entry.BreakGlassID = &activation.ID
+	}
+
+	if err := audit.Record(ctx, &entry); err != nil {
+		ctx.Errorf("failed to audit break-glass %s: %v", action, err)
+	}
+}
+
+// Recipients returns the recipients of break-glass notifications from the
+// comma separated break_glass.notify property: emails, person ids, team:<name>,
+// connection://<type>/<name> or shoutrrr urls.
+func Recipients(ctx context.Context) []v1.NotificationRecipientSpec {
+	var recipients []v1.NotificationRecipientSpec
+	for _, item := range strings.Split(ctx.Properties().String("break_glass.notify", ""), ",") {
+		item = strings.TrimSpace(item)
+		switch {
+		case item == "":
+			continue
+		case strings.HasPrefix(item, "connection://"):
+			recipients = append(recipients, v1.NotificationRecipientSpec{Connection: item})
+		case strings.Contains(item, "://"):
+			recipients = append(recipients, v1.NotificationRecipientSpec{URL: item})
+		case strings.HasPrefix(item, "team:"):
+			recipients = append(recipients, v1.NotificationRecipientSpec{Team: strings.TrimPrefix(item, "team:")})
+		case strings.Contains(item, "@"):
+			recipients = append(recipients, v1.NotificationRecipientSpec{Email: item})
+		default:
+			recipients = append(recipients, v1.NotificationRecipientSpec{Person: item})
+		}
+	}
+	return recipients
+}
+
+// notify sends a high priority notification to the recipients. A failure to
+// notify doesn't stand in the way of emergency access.
+func notify(ctx context.Context, activation db.BreakGlassActivation, title, message string) {
+	recipients := Recipients(ctx)
+	if len(recipients) == 0 {
+		ctx.Warnf("no recipients configured in break_glass.notify for break-glass activation %s", activation.ID)
+		return
+	}
+
+	celEnv := map[string]any{
+		"activation": map[string]any{
+			"id":         activation.ID.String(),
+			"operator":   activation.Operator,
+			"reason":     activation.Reason,
+			"expires_at": activation.ExpiresAt,
+		},
+	}
+	template := notification.NotificationTemplate{
+		Title:   title,
+		Message: message,
+		Properties: map[string]string{
+			"activation": activation.ID.String(),
+		},
+	}
+
+	for _, recipient := range recipients {
+		if err := notification.SendToRecipient(notification.NewContext(ctx, uuid.Nil), recipient, celEnv, template); err != nil {
+			ctx.Errorf("failed to notify break-glass activation %s: %v", activation.ID, err)
+		}
+	}
+}
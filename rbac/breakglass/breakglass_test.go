@@ -0,0 +1,190 @@
+package breakglass
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/flanksource/commons/properties"
+	dutyAPI "github.com/flanksource/duty/api"
+	"github.com/flanksource/duty/context"
+	"github.com/flanksource/duty/models"
+	dutyRBAC "github.com/flanksource/duty/rbac"
+	"github.com/flanksource/duty/rbac/policy"
+	"github.com/labstack/echo/v4"
+	ginkgo "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/flanksource/incident-commander/db"
+	"github.com/flanksource/incident-commander/rbac"
+	"github.com/flanksource/incident-commander/rbac/adapter"
+)
+
+var _ = ginkgo.Describe("Shamir", func() {
+	secret := []byte("correct horse battery staple")
+
+	ginkgo.It("should recover the secret from any threshold of shares", func() {
+		shares, err := Split(secret, 5, 3)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(shares).To(HaveLen(5))
+
+		for _, subset := range [][][]byte{shares[:3], shares[2:], {shares[0], shares[2], shares[4]}, shares} {
+			combined, err := Combine(subset)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(combined).To(Equal(secret))
+		}
+	})
+
+	ginkgo.It("should not recover the secret from fewer shares", func() {
+		shares, err := Split(secret, 5, 3)
+		Expect(err).ToNot(HaveOccurred())
+
+		combined, err := Combine(shares[:2])
+		Expect(err).ToNot(HaveOccurred())
+		Expect(bytes.Equal(combined, secret)).To(BeFalse())
+	})
+
+	ginkgo.It("should reject invalid shares", func() {
+		_, err := Split(secret, 2, 3)
+		Expect(err).To(HaveOccurred())
+
+		shares, err := Split(secret, 3, 2)
+		Expect(err).ToNot(HaveOccurred())
+		_, err = Combine([][]byte{shares[0], shares[0]})
+		Expect(err).To(HaveOccurred())
+	})
+})
+
+var _ = ginkgo.Describe("Break glass", ginkgo.Ordered, func() {
+	var shares []string
+	var activation *Activation
+
+	// asActivation is the context of a request made with the token of the activation.
+	asActivation := func(activation *Activation) context.Context {
+		var person models.Person
+		Expect(DefaultContext.DB().Where("id = ?", activation.PersonID).First(&person).Error).To(Succeed())
+		return DefaultContext.WithUser(&person)
+	}
+
+	ginkgo.BeforeAll(func() {
+		if dutyRBAC.Enforcer() == nil {
+			Expect(dutyRBAC.Init(DefaultContext, []string{}, adapter.NewPermissionAdapter)).To(Succeed())
+		}
+		properties.Set("break_glass.max_duration", "2h")
+	})
+
+	ginkgo.AfterAll(func() {
+		properties.Set("break_glass.max_duration", "")
+	})
+
+	ginkgo.It("should seal a credential split into shares", func() {
+		var err error
+		shares, err = Seal(DefaultContext, SealRequest{Shares: 3, Threshold: 2, SealedBy: "ops"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(shares).To(HaveLen(3))
+
+		credential, err := db.GetBreakGlassCredential(DefaultContext)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(credential.Threshold).To(Equal(2))
+	})
+
+	ginkgo.It("should validate activations", func() {
+		_, err := Activate(DefaultContext, shares[:2], ActivateRequest{Operator: "ops", Duration: "1h"})
+		Expect(dutyAPI.ErrorCode(err)).To(Equal(dutyAPI.EINVALID))
+
+		_, err = Activate(DefaultContext, shares[:2], ActivateRequest{Operator: "ops", Reason: "too long", Duration: "3h"})
+		Expect(dutyAPI.ErrorCode(err)).To(Equal(dutyAPI.EINVALID))
+	})
+
+	ginkgo.It("should deny too few shares and audit the attempt", func() {
+		_, err := Activate(DefaultContext, shares[:1], ActivateRequest{Operator: "ops", Reason: "idp down"})
+		Expect(dutyAPI.ErrorCode(err)).To(Equal(dutyAPI.EUNAUTHORIZED))
+
+		entries, err := db.SearchAuditLog(DefaultContext, db.AuditLogFilter{Route: Route, Limit: 1})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(entries).To(HaveLen(1))
+		Expect(entries[0].Action).To(Equal("activate"))
+		Expect(entries[0].Decision).To(Equal(db.AuditDecisionDeny))
+	})
+
+	ginkgo.It("should grant admin with enough shares", func() {
+		var err error
+		activation, err = Activate(DefaultContext, shares[1:], ActivateRequest{Operator: "ops", Reason: "idp down", Duration: "30m"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(activation.Token.PlainText()).ToNot(BeEmpty())
+		Expect(activation.ExpiresAt).To(BeTemporally("~", time.Now().Add(30*time.Minute), time.Minute))
+
+		Expect(dutyRBAC.Check(DefaultContext, activation.PersonID.String(), policy.ObjectRBAC, policy.ActionUpdate)).To(BeTrue())
+
+		active, err := rbac.GetBreakGlass(asActivation(activation))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(active.ID).To(Equal(activation.ID))
+
+		active, err = rbac.GetBreakGlass(DefaultContext)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(active).To(BeNil())
+
+		entries, err := db.SearchAuditLog(DefaultContext, db.AuditLogFilter{BreakGlass: true, Limit: 1})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(entries).To(HaveLen(1))
+		Expect(entries[0].BreakGlassID).To(Equal(&activation.ID))
+	})
+
+	ginkgo.It("should take back access when it expires", func() {
+		Expect(DefaultContext.DB().Model(&db.BreakGlassActivation{}).Where("id = ?", activation.ID).
+			Update("expires_at", time.Now().Add(-time.Minute)).Error).To(Succeed())
+
+		expired, err := Expire(DefaultContext)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(expired).To(Equal(1))
+
+		ended, err := db.GetBreakGlassActivation(DefaultContext, activation.ID)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ended.EndedAt).ToNot(BeNil())
+
+		var tokens int64
+		Expect(DefaultContext.DB().Model(&models.AccessToken{}).Where("id = ?", activation.AccessTokenID).Count(&tokens).Error).To(Succeed())
+		Expect(tokens).To(BeZero())
+
+		Expect(dutyRBAC.Check(DefaultContext, activation.PersonID.String(), policy.ObjectRBAC, policy.ActionUpdate)).To(BeFalse())
+
+		active, err := rbac.GetBreakGlass(asActivation(activation))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(active).To(BeNil())
+
+		_, err = End(DefaultContext, activation.ID)
+		Expect(dutyAPI.ErrorCode(err)).To(Equal(dutyAPI.ECONFLICT))
+	})
+
+	ginkgo.It("should deny at once when ended early", func() {
+		var err error
+		activation, err = Activate(DefaultContext, shares[:2], ActivateRequest{Operator: "ops", Reason: "idp still down", Duration: "30m"})
+		Expect(err).ToNot(HaveOccurred())
+
+		ctx := asActivation(activation)
+		e := echo.New()
+		e.GET("/rbac", func(c echo.Context) error { return c.NoContent(http.StatusOK) },
+			func(next echo.HandlerFunc) echo.HandlerFunc {
+				return func(c echo.Context) error {
+					c.SetRequest(c.Request().WithContext(ctx.Wrap(c.Request().Context())))
+					return next(c)
+				}
+			},
+			rbac.Authorization(policy.ObjectRBAC, policy.ActionUpdate))
+		serve := func() int {
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/rbac", nil))
+			return rec.Code
+		}
+
+		Expect(dutyRBAC.CheckContext(ctx, policy.ObjectRBAC, policy.ActionUpdate)).To(BeTrue())
+		Expect(serve()).To(Equal(http.StatusOK))
+
+		_, err = End(DefaultContext, activation.ID)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(dutyRBAC.CheckContext(ctx, policy.ObjectRBAC, policy.ActionUpdate)).To(BeFalse())
+		Expect(serve()).To(Equal(http.StatusForbidden))
+	})
+})
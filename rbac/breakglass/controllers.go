@@ -0,0 +1,55 @@
+package breakglass
+
+import (
+	"net/http"
+
+	"github.com/flanksource/commons/logger"
+	dutyAPI "github.com/flanksource/duty/api"
+	"github.com/flanksource/duty/context"
+	"github.com/flanksource/duty/rbac/policy"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+
+	"github.com/flanksource/incident-commander/db"
+	echoSrv "github.com/flanksource/incident-commander/echo"
+	"github.com/flanksource/incident-commander/rbac"
+)
+
+func init() {
+	echoSrv.RegisterRoutes(RegisterRoutes)
+}
+
+// RegisterRoutes registers the routes to list and end activations. Activation
+// is only possible from the CLI.
+func RegisterRoutes(e *echo.Echo) {
+	logger.Infof("Registering /break-glass routes")
+
+	g := e.Group("/break-glass")
+	g.GET("", ListActivations, rbac.Authorization(policy.ObjectRBAC, policy.ActionRead))
+	g.POST("/:id/end", EndActivation, rbac.Authorization(policy.ObjectRBAC, policy.ActionUpdate))
+}
+
+func ListActivations(c echo.Context) error {
+	ctx := c.Request().Context().(context.Context)
+
+	activations, err := db.ListBreakGlassActivations(ctx, c.QueryParam("active") == "true")
+	if err != nil {
+		return dutyAPI.WriteError(c, err)
+	}
+	return c.JSON(http.StatusOK, dutyAPI.HTTPSuccess{Payload: activations})
+}
+
+func EndActivation(c echo.Context) error {
+	ctx := c.Request().Context().(context.Context)
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return dutyAPI.WriteError(c, dutyAPI.Errorf(dutyAPI.EINVALID, "invalid activation id %q", c.Param("id")))
+	}
+
+	activation, err := End(ctx, id)
+	if err != nil {
+		return dutyAPI.WriteError(c, err)
+	}
+	return c.JSON(http.StatusOK, dutyAPI.HTTPSuccess{Message: "break-glass access ended", Payload: activation})
+}
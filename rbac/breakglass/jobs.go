@@ -0,0 +1,24 @@
+package breakglass
+
+import (
+	"github.com/flanksource/duty/context"
+	"github.com/flanksource/duty/job"
+)
+
+// ExpireBreakGlass revokes the break-glass access past its expiry.
+func ExpireBreakGlass(ctx context.Context) *job.Job {
+	return &job.Job{
+		Name:       "ExpireBreakGlass",
+		Schedule:   "@every 1m",
+		Context:    ctx,
+		Singleton:  true,
+		Retention:  job.RetentionFailed,
+		JobHistory: true,
+		RunNow:     true,
+		Fn: func(run job.JobRuntime) error {
+			expired, err := Expire(run.Context)
+			run.History.SuccessCount = expired
+			return err
+		},
+	}
+}
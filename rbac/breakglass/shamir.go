@@ -0,0 +1,112 @@
+package breakglass
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+)
+
+// Shamir's secret sharing over GF(2^8): every byte of the secret is the
+// constant term of a random polynomial of degree threshold-1, and a share is
+// the polynomial evaluated at the share's x coordinate.
+
+var (
+	gfExp [255]byte
+	gfLog [256]byte
+)
+
+func init() {
+	x := byte(1)
+	for i := range gfExp {
+		gfExp[i] = x
+		gfLog[x] = byte(i)
+
+		// x *= 3, reducing by the AES polynomial
+		doubled := x << 1
+		if x&0x80 != 0 {
+			doubled ^= 0x1b
+		}
+		x ^= doubled
+	}
+}
+
+func gfMul(a, b byte) byte {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	return gfExp[(int(gfLog[a])+int(gfLog[b]))%255]
+}
+
+func gfDiv(a, b byte) byte {
+	if a == 0 {
+		return 0
+	}
+	return gfExp[(int(gfLog[a])-int(gfLog[b])+255)%255]
+}
+
+// Split splits the secret into n shares, any threshold of which recover it.
+// A share is its x coordinate followed by one byte per byte of the secret.
+func Split(secret []byte, n, threshold int) ([][]byte, error) {
+	if len(secret) == 0 {
+		return nil, errors.New("secret is empty")
+	}
+	if threshold < 2 || threshold > n || n > 255 {
+		return nil, fmt.Errorf("threshold must be between 2 and the number of shares (at most 255), got %d of %d", threshold, n)
+	}
+
+	shares := make([][]byte, n)
+	for i := range shares {
+		shares[i] = make([]byte, len(secret)+1)
+		shares[i][0] = byte(i + 1)
+	}
+
+	coefficients := make([]byte, threshold-1)
+	for pos, b := range secret {
+		if _, err := rand.Read(coefficients); err != nil {
+			return nil, err
+		}
+
+		for _, share := range shares {
+			// Horner's method
+			x, y := share[0], byte(0)
+			for i := len(coefficients) - 1; i >= 0; i-- {
+				y = gfMul(y, x) ^ coefficients[i]
+			}
+			share[pos+1] = gfMul(y, x) ^ b
+		}
+	}
+	return shares, nil
+}
+
+// Combine recovers the secret from shares. Fewer shares than the threshold
+// recover a different secret rather than an error.
+func Combine(shares [][]byte) ([]byte, error) {
+	if len(shares) < 2 {
+		return nil, errors.New("at least 2 shares are required")
+	}
+
+	seen := map[byte]bool{}
+	for _, share := range shares {
+		if len(share) < 2 || len(share) != len(shares[0]) {
+			return nil, errors.New("shares must have the same length")
+		} else if share[0] == 0 || seen[share[0]] {
+			return nil, errors.New("shares must be distinct")
+		}
+		seen[share[0]] = true
+	}
+
+	secret := make([]byte, len(shares[0])-1)
+	for pos := range secret {
+		// Lagrange interpolation at x = 0
+		for i, si := range shares {
+			basis := byte(1)
+			for j, sj := range shares {
+				if i != j {
+					basis = gfMul(basis, gfDiv(sj[0], sj[0]^si[0]))
+				}
+			}
+			secret[pos] ^= gfMul(si[pos+1], basis)
+		}
+	}
+	return secret, nil
+}
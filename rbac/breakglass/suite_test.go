@@ -0,0 +1,26 @@
+package breakglass
+
+import (
+	"testing"
+
+	"github.com/flanksource/duty/context"
+	"github.com/flanksource/duty/tests/setup"
+	ginkgo "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/flanksource/incident-commander/db/schema"
+)
+
+func TestBreakGlass(t *testing.T) {
+	RegisterFailHandler(ginkgo.Fail)
+	ginkgo.RunSpecs(t, "Break glass")
+}
+
+var DefaultContext context.Context
+
+var _ = ginkgo.BeforeSuite(func() {
+	DefaultContext = setup.BeforeSuiteFn()
+	Expect(schema.Apply(DefaultContext)).To(Succeed())
+})
+
+var _ = ginkgo.AfterSuite(setup.AfterSuiteFn)
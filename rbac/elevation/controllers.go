@@ -0,0 +1,94 @@
+package elevation
+
+import (
+	"net/http"
+
+	"github.com/flanksource/commons/logger"
+	dutyAPI "github.com/flanksource/duty/api"
+	"github.com/flanksource/duty/context"
+	dutyRBAC "github.com/flanksource/duty/rbac"
+	"github.com/flanksource/duty/rbac/policy"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+
+	"github.com/flanksource/incident-commander/db"
+	echoSrv "github.com/flanksource/incident-commander/echo"
+	"github.com/flanksource/incident-commander/rbac"
+)
+
+func init() {
+	echoSrv.RegisterRoutes(RegisterRoutes)
+}
+
+func RegisterRoutes(e *echo.Echo) {
+	logger.Infof("Registering /rbac/elevations routes")
+
+	// The handlers authorize requesters and approvers themselves, who need not
+	// hold any RBAC permission, so only the scope of access tokens is checked.
+	g := e.Group("/rbac/elevations")
+	g.GET("", ListElevations, rbac.RequireTokenScope(policy.ObjectRBAC, policy.ActionRead))
+	g.POST("", RequestElevation, rbac.RequireTokenScope(policy.ObjectRBAC, policy.ActionUpdate))
+	g.POST("/:id/approve", handle(Approve), rbac.RequireTokenScope(policy.ObjectRBAC, policy.ActionUpdate))
+	g.POST("/:id/reject", handle(Reject), rbac.RequireTokenScope(policy.ObjectRBAC, policy.ActionUpdate))
+	g.POST("/:id/revoke", handle(Revoke), rbac.RequireTokenScope(policy.ObjectRBAC, policy.ActionUpdate))
+}
+
+func RequestElevation(c echo.Context) error {
+	ctx := c.Request().Context().(context.Context)
+
+	var req Request
+	if err := c.Bind(&req); err != nil {
+		return dutyAPI.WriteError(c, dutyAPI.Errorf(dutyAPI.EINVALID, "invalid request body: %v", err))
+	}
+
+	elevation, err := Create(ctx, req)
+	if err != nil {
+		return dutyAPI.WriteError(c, err)
+	}
+	return c.JSON(http.StatusCreated, dutyAPI.HTTPSuccess{Message: "elevation requested", Payload: elevation})
+}
+
+// ListElevations lists the elevations of the current user. RBAC readers and
+// the approvers of elevations see everyone's.
+func ListElevations(c echo.Context) error {
+	ctx := c.Request().Context().(context.Context)
+	user := ctx.User()
+	if user == nil {
+		return dutyAPI.WriteError(c, dutyAPI.Errorf(dutyAPI.EUNAUTHORIZED, "not logged in"))
+	}
+
+	personID := &user.ID
+	if dutyRBAC.CheckContext(ctx, policy.ObjectRBAC, policy.ActionRead) {
+		personID = nil
+	} else if _, err := approverIdentity(ctx, ApprovalPolicy(ctx), user); err == nil {
+		personID = nil
+	}
+
+	var statuses []string
+	if status := c.QueryParam("status"); status != "" {
+		statuses = append(statuses, status)
+	}
+
+	elevations, err := db.ListPermissionElevations(ctx, personID, statuses...)
+	if err != nil {
+		return dutyAPI.WriteError(c, err)
+	}
+	return c.JSON(http.StatusOK, dutyAPI.HTTPSuccess{Payload: elevations})
+}
+
+func handle(fn func(ctx context.Context, id uuid.UUID) (*db.PermissionElevation, error)) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		ctx := c.Request().Context().(context.Context)
+
+		id, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			return dutyAPI.WriteError(c, dutyAPI.Errorf(dutyAPI.EINVALID, "invalid elevation id %q", c.Param("id")))
+		}
+
+		elevation, err := fn(ctx, id)
+		if err != nil {
+			return dutyAPI.WriteError(c, err)
+		}
+		return c.JSON(http.StatusOK, dutyAPI.HTTPSuccess{Message: "elevation " + elevation.Status, Payload: elevation})
+	}
+}
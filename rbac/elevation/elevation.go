@@ -0,0 +1,615 @@
+// Package elevation grants temporary, approved permissions on top of the
+// permanent ones from Permission CRDs.
+package elevation
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/flanksource/commons/collections"
+	"github.com/flanksource/commons/duration"
+	"github.com/flanksource/duty"
+	dutyAPI "github.com/flanksource/duty/api"
+	"github.com/flanksource/duty/context"
+	"github.com/flanksource/duty/models"
+	dutyQuery "github.com/flanksource/duty/query"
+	dutyRBAC "github.com/flanksource/duty/rbac"
+	"github.com/flanksource/duty/rbac/policy"
+	dutyTypes "github.com/flanksource/duty/types"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/samber/lo"
+	"go.opentelemetry.io/otel/trace"
+
+	v1 "github.com/flanksource/incident-commander/api/v1"
+	"github.com/flanksource/incident-commander/audit"
+	"github.com/flanksource/incident-commander/auth"
+	"github.com/flanksource/incident-commander/db"
+)
+
+const (
+	// Source is the source of the permissions and config changes of elevations.
+	Source = "Elevation"
+
+	ChangeTypeGranted = "PermissionElevated"
+	ChangeTypeEnded   = "PermissionElevationEnded"
+
+	defaultMaxDuration = 8 * time.Hour
+	defaultPendingTTL  = 24 * time.Hour
+)
+
+// requestableRoles are the roles that can be requested.
+var requestableRoles = []string{policy.RoleAdmin, policy.RoleEditor, policy.RoleCommander, policy.RoleResponder, policy.RoleViewer}
+
+// Request is a request for a role, or for actions on an object, for a duration.
+type Request struct {
+	Role     string              `json:"role,omitempty"`
+	Actions  []string            `json:"actions,omitempty"`
+	Object   v1.PermissionObject `json:"object,omitempty"`
+	Reason   string              `json:"reason"`
+	Duration string              `json:"duration"`
+}
+
+// ApprovalPolicy returns who approves elevations. It follows the playbook
+// approval model: any or all of the listed people and teams must approve.
+func ApprovalPolicy(ctx context.Context) v1.PlaybookApproval {
+	return v1.PlaybookApproval{
+		Type: v1.PlaybookApprovalType(ctx.Properties().String("rbac.elevation.approval.type", string(v1.PlaybookApprovalTypeAny))),
+		Approvers: v1.PlaybookApprovers{
+			People: splitList(ctx.Properties().String("rbac.elevation.approvers.people", "")),
+			Teams:  splitList(ctx.Properties().String("rbac.elevation.approvers.teams", "")),
+		},
+	}
+}
+
+func splitList(value string) []string {
+	return lo.Compact(lo.Map(strings.Split(value, ","), func(s string, _ int) string { return strings.TrimSpace(s) }))
+}
+
+// Create requests an elevation for the current user.
+func Create(ctx context.Context, req Request) (*db.PermissionElevation, error) {
+	user := ctx.User()
+	if user == nil {
+		return nil, dutyAPI.Errorf(dutyAPI.EUNAUTHORIZED, "not logged in")
+	}
+
+	if strings.TrimSpace(req.Reason) == "" {
+		return nil, dutyAPI.Errorf(dutyAPI.EINVALID, "reason is required")
+	}
+
+	if (req.Role == "") == (len(req.Actions) == 0) {
+		return nil, dutyAPI.Errorf(dutyAPI.EINVALID, "either a role or actions must be requested")
+	}
+
+	var object []byte
+	if req.Role != "" {
+		if !slices.Contains(requestableRoles, req.Role) {
+			return nil, dutyAPI.Errorf(dutyAPI.EINVALID, "role %s cannot be requested. Must be one of %s", req.Role, strings.Join(requestableRoles, ", "))
+		}
+		if roles, err := dutyRBAC.RolesForUser(user.ID.String()); err != nil {
+			return nil, ctx.Oops().Wrapf(err, "failed to get roles of %s", user.ID)
+		} else if slices.Contains(roles, req.Role) {
+			return nil, dutyAPI.Errorf(dutyAPI.EINVALID, "you already have the role %s", req.Role)
+		}
+	} else {
+		if err := req.Object.Validate(); err != nil {
+			return nil, dutyAPI.Errorf(dutyAPI.EINVALID, "invalid object: %v", err)
+		}
+		if !req.Object.MCP && !req.Object.HasSelectors() {
+			return nil, dutyAPI.Errorf(dutyAPI.EINVALID, "an object is required with actions")
+		}
+
+		var err error
+		if object, err = json.Marshal(req.Object); err != nil {
+			return nil, ctx.Oops().Wrap(err)
+		}
+	}
+
+	d, err := duration.ParseDuration(req.Duration)
+	if err != nil {
+		return nil, dutyAPI.Errorf(dutyAPI.EINVALID, "invalid duration %q: %v", req.Duration, err)
+	}
+	maxDuration := ctx.Properties().Duration("rbac.elevation.max_duration", defaultMaxDuration)
+	if d <= 0 || time.Duration(d) > maxDuration {
+		return nil, dutyAPI.Errorf(dutyAPI.EINVALID, "duration must be between 0 and %s", maxDuration)
+	}
+
+	approval := ApprovalPolicy(ctx)
+	if approval.Approvers.Empty() {
+		return nil, dutyAPI.Errorf(dutyAPI.EINVALID, "no approvers are configured for permission elevations")
+	}
+	approvalJSON, err := json.Marshal(approval)
+	if err != nil {
+		return nil, ctx.Oops().Wrap(err)
+	}
+
+	elevation := db.PermissionElevation{
+		PersonID: user.ID,
+		Role:     req.Role,
+		Actions:  req.Actions,
+		Object:   object,
+		Reason:   req.Reason,
+		Duration: time.Duration(d).String(),
+		Status:   db.ElevationStatusPending,
+		Approval: approvalJSON,
+	}
+	if err := db.CreatePermissionElevation(ctx, &elevation); err != nil {
+		return nil, err
+	}
+
+	ctx.Infof("%s requested elevation %s (%s) for %s: %s", user.Email, elevation.ID, describe(elevation), elevation.Duration, elevation.Reason)
+	return &elevation, nil
+}
+
+// Approve approves a pending elevation, granting it once the approval policy
+// it was requested under is satisfied.
+func Approve(ctx context.Context, id uuid.UUID) (*db.PermissionElevation, error) {
+	approver := ctx.User()
+	if approver == nil {
+		return nil, dutyAPI.Errorf(dutyAPI.EUNAUTHORIZED, "not logged in")
+	}
+
+	elevation, err := find(ctx, id)
+	if err != nil {
+		return nil, err
+	} else if elevation.Status != db.ElevationStatusPending {
+		return nil, dutyAPI.Errorf(dutyAPI.ECONFLICT, "elevation %s is %s", id, elevation.Status)
+	} else if elevation.PersonID == approver.ID {
+		return nil, dutyAPI.Errorf(dutyAPI.EFORBIDDEN, "you cannot approve your own elevation")
+	}
+
+	var spec v1.PlaybookApproval
+	if err := json.Unmarshal(elevation.Approval, &spec); err != nil {
+		return nil, ctx.Oops().Wrapf(err, "invalid approval policy of elevation %s", id)
+	}
+
+	approval, err := approverIdentity(ctx, spec, approver)
+	if err != nil {
+		return nil, err
+	}
+
+	var approvals []db.ElevationApproval
+	if len(elevation.Approvals) > 0 {
+		if err := json.Unmarshal(elevation.Approvals, &approvals); err != nil {
+			return nil, ctx.Oops().Wrapf(err, "invalid approvals of elevation %s", id)
+		}
+	}
+	if lo.ContainsBy(approvals, func(a db.ElevationApproval) bool { return a.ApprovedBy == approver.ID }) {
+		return nil, dutyAPI.Errorf(dutyAPI.ECONFLICT, "you have already approved elevation %s", id)
+	}
+	approvals = append(approvals, *approval)
+
+	approvalsJSON, err := json.Marshal(approvals)
+	if err != nil {
+		return nil, ctx.Oops().Wrap(err)
+	}
+	approvalsValue := dutyTypes.JSON(approvalsJSON)
+
+	satisfied, err := isSatisfied(ctx, spec, approvals)
+	if err != nil {
+		return nil, err
+	}
+
+	if !satisfied {
+		if ok, err := db.UpdatePendingPermissionElevation(ctx, id, elevation.Approvals, map[string]any{"approvals": approvalsValue}); err != nil {
+			return nil, err
+		} else if !ok {
+			return nil, dutyAPI.Errorf(dutyAPI.ECONFLICT, "elevation %s was changed by another approver, try again", id)
+		}
+		ctx.Infof("%s approved elevation %s, awaiting further approvals", approver.Email, id)
+		return find(ctx, id)
+	}
+
+	if err := grant(ctx, *elevation, approvalsValue); err != nil {
+		return nil, err
+	}
+	return find(ctx, id)
+}
+
+// Reject rejects a pending elevation. Only its approvers can reject it.
+func Reject(ctx context.Context, id uuid.UUID) (*db.PermissionElevation, error) {
+	approver := ctx.User()
+	if approver == nil {
+		return nil, dutyAPI.Errorf(dutyAPI.EUNAUTHORIZED, "not logged in")
+	}
+
+	elevation, err := find(ctx, id)
+	if err != nil {
+		return nil, err
+	} else if elevation.Status != db.ElevationStatusPending {
+		return nil, dutyAPI.Errorf(dutyAPI.ECONFLICT, "elevation %s is %s", id, elevation.Status)
+	}
+
+	var spec v1.PlaybookApproval
+	if err := json.Unmarshal(elevation.Approval, &spec); err != nil {
+		return nil, ctx.Oops().Wrapf(err, "invalid approval policy of elevation %s", id)
+	}
+	if _, err := approverIdentity(ctx, spec, approver); err != nil {
+		return nil, err
+	}
+
+	if ok, err := db.UpdatePermissionElevation(ctx, id, db.ElevationStatusPending, map[string]any{
+		"status":   db.ElevationStatusRejected,
+		"ended_at": duty.Now(),
+		"ended_by": approver.ID,
+	}); err != nil {
+		return nil, err
+	} else if !ok {
+		return nil, dutyAPI.Errorf(dutyAPI.ECONFLICT, "elevation %s is no longer pending", id)
+	}
+
+	ctx.Infof("%s rejected elevation %s", approver.Email, id)
+	return find(ctx, id)
+}
+
+// Revoke withdraws a pending elevation or ends an active one early. The
+// requester, the approvers and RBAC administrators can revoke an elevation.
+func Revoke(ctx context.Context, id uuid.UUID) (*db.PermissionElevation, error) {
+	user := ctx.User()
+	if user == nil {
+		return nil, dutyAPI.Errorf(dutyAPI.EUNAUTHORIZED, "not logged in")
+	}
+
+	elevation, err := find(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if elevation.PersonID != user.ID && !dutyRBAC.CheckContext(ctx, policy.ObjectRBAC, policy.ActionUpdate) {
+		var spec v1.PlaybookApproval
+		if err := json.Unmarshal(elevation.Approval, &spec); err != nil {
+			return nil, ctx.Oops().Wrapf(err, "invalid approval policy of elevation %s", id)
+		}
+		if _, err := approverIdentity(ctx, spec, user); err != nil {
+			return nil, err
+		}
+	}
+
+	switch elevation.Status {
+	case db.ElevationStatusPending:
+		if ok, err := db.UpdatePermissionElevation(ctx, id, db.ElevationStatusPending, map[string]any{
+			"status":   db.ElevationStatusRevoked,
+			"ended_at": duty.Now(),
+			"ended_by": user.ID,
+		}); err != nil {
+			return nil, err
+		} else if !ok {
+			return nil, dutyAPI.Errorf(dutyAPI.ECONFLICT, "elevation %s is no longer pending", id)
+		}
+
+	case db.ElevationStatusActive:
+		if err := end(ctx, *elevation, db.ElevationStatusRevoked, &user.ID); err != nil {
+			return nil, err
+		}
+
+	default:
+		return nil, dutyAPI.Errorf(dutyAPI.ECONFLICT, "elevation %s is %s", id, elevation.Status)
+	}
+
+	ctx.Infof("%s revoked elevation %s", user.Email, id)
+	return find(ctx, id)
+}
+
+// Expire ends the elevations past their expiry and expires the requests that
+// weren't approved in time. It returns the number of elevations it expired.
+func Expire(ctx context.Context) (int, error) {
+	pendingTTL := ctx.Properties().Duration("rbac.elevation.pending_ttl", defaultPendingTTL)
+	due, err := db.GetDuePermissionElevations(ctx, time.Now().Add(-pendingTTL))
+	if err != nil {
+		return 0, err
+	}
+
+	var expired int
+	var errs []error
+	for _, elevation := range due {
+		if elevation.Status == db.ElevationStatusPending {
+			if ok, err := db.UpdatePermissionElevation(ctx, elevation.ID, db.ElevationStatusPending, map[string]any{
+				"status":   db.ElevationStatusExpired,
+				"ended_at": duty.Now(),
+			}); err != nil {
+				errs = append(errs, err)
+			} else if ok {
+				expired++
+			}
+			continue
+		}
+
+		if err := end(ctx, elevation, db.ElevationStatusExpired, nil); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		expired++
+	}
+
+	if len(errs) > 0 {
+		return expired, fmt.Errorf("failed to expire %d elevations: %v", len(errs), errs)
+	}
+	return expired, nil
+}
+
+// grant activates the elevation: the permission or role is granted until
+// the elevation expires.
+func grant(ctx context.Context, elevation db.PermissionElevation, approvals dutyTypes.JSON) error {
+	d, err := time.ParseDuration(elevation.Duration)
+	if err != nil {
+		return ctx.Oops().Wrapf(err, "invalid duration of elevation %s", elevation.ID)
+	}
+	now := time.Now()
+	expiresAt := now.Add(d)
+
+	var object v1.PermissionObject
+	if len(elevation.Object) > 0 {
+		if err := json.Unmarshal(elevation.Object, &object); err != nil {
+			return ctx.Oops().Wrapf(err, "invalid object of elevation %s", elevation.ID)
+		}
+	}
+
+	configIDs, err := coveredConfigs(ctx, object)
+	if err != nil {
+		return err
+	}
+
+	var roleAdded bool
+	err = ctx.Transaction(func(ctx context.Context, _ trace.Span) error {
+		updates := map[string]any{
+			"status":      db.ElevationStatusActive,
+			"approvals":   approvals,
+			"approved_at": now,
+			"expires_at":  expiresAt,
+			"config_ids":  pq.StringArray(configIDs),
+		}
+
+		if len(elevation.Actions) > 0 {
+			permission := models.Permission{
+				Name:        "elevation-" + elevation.ID.String(),
+				Description: elevation.Reason,
+				Source:      Source,
+				Subject:     elevation.PersonID.String(),
+				SubjectType: models.PermissionSubjectTypePerson,
+				Action:      strings.Join(elevation.Actions, ","),
+				Until:       &expiresAt,
+				CreatedBy:   lo.ToPtr(ctx.User().ID),
+			}
+			if globalObject, ok := object.GlobalObject(); ok {
+				permission.Object = globalObject
+			} else {
+				permission.ObjectSelector = elevation.Object
+			}
+			if err := ctx.DB().Create(&permission).Error; err != nil {
+				return ctx.Oops().Wrapf(err, "failed to create permission of elevation %s", elevation.ID)
+			}
+			updates["permission_id"] = permission.ID
+		}
+
+		// elevation.Approvals are the approvals before this one
+		if ok, err := db.UpdatePendingPermissionElevation(ctx, elevation.ID, elevation.Approvals, updates); err != nil {
+			return err
+		} else if !ok {
+			return dutyAPI.Errorf(dutyAPI.ECONFLICT, "elevation %s was changed by another approver, try again", elevation.ID)
+		}
+
+		elevation.ConfigIDs = configIDs
+		summary := fmt.Sprintf("%s elevated to %s until %s: %s", personName(ctx, elevation.PersonID), describe(elevation), expiresAt.Format(time.RFC3339), elevation.Reason)
+		if err := recordChanges(ctx, elevation, ChangeTypeGranted, summary); err != nil {
+			return err
+		}
+
+		// The enforcer doesn't take part in the transaction: the role is
+		// granted last and taken back below if the transaction doesn't commit.
+		if elevation.Role != "" {
+			added, err := dutyRBAC.Enforcer().AddRoleForUser(elevation.PersonID.String(), elevation.Role)
+			if err != nil {
+				return ctx.Oops().Wrapf(err, "failed to grant role %s", elevation.Role)
+			}
+			roleAdded = added
+		}
+		return nil
+	})
+	if err != nil {
+		if roleAdded {
+			if err := dutyRBAC.DeleteRoleForUser(elevation.PersonID.String(), elevation.Role); err != nil {
+				ctx.Errorf("failed to take back role %s of elevation %s: %v", elevation.Role, elevation.ID, err)
+			}
+		}
+		return err
+	}
+
+	flush(ctx, elevation.PersonID)
+	ctx.Infof("elevation %s granted to %s until %s", elevation.ID, elevation.PersonID, expiresAt.Format(time.RFC3339))
+	return nil
+}
+
+// end takes back what an active elevation granted. The elevation stays
+// active, and is retried by Expire, until the role is removed too.
+func end(ctx context.Context, elevation db.PermissionElevation, status string, by *uuid.UUID) error {
+	var ended bool
+	err := ctx.Transaction(func(ctx context.Context, _ trace.Span) error {
+		ok, err := db.UpdatePermissionElevation(ctx, elevation.ID, db.ElevationStatusActive, map[string]any{
+			"status":   status,
+			"ended_at": duty.Now(),
+			"ended_by": by,
+		})
+		if err != nil || !ok {
+			// Already ended
+			return err
+		}
+		ended = true
+
+		if elevation.PermissionID != nil {
+			if err := db.DeletePermission(ctx, elevation.PermissionID.String()); err != nil {
+				return ctx.Oops().Wrapf(err, "failed to delete permission of elevation %s", elevation.ID)
+			}
+		}
+
+		summary := fmt.Sprintf("%s elevation to %s %s", personName(ctx, elevation.PersonID), describe(elevation), status)
+		if err := recordChanges(ctx, elevation, ChangeTypeEnded, summary); err != nil {
+			return err
+		}
+
+		// Removed within the transaction so that a failure rolls back the
+		// status. Removing the role again on a retry is a no-op.
+		if elevation.Role != "" {
+			if err := dutyRBAC.DeleteRoleForUser(elevation.PersonID.String(), elevation.Role); err != nil {
+				return ctx.Oops().Wrapf(err, "failed to remove role %s of elevation %s", elevation.Role, elevation.ID)
+			}
+		}
+		return nil
+	})
+	if err != nil || !ended {
+		return err
+	}
+
+	flush(ctx, elevation.PersonID)
+	ctx.Infof("elevation %s of %s %s", elevation.ID, elevation.PersonID, status)
+	return nil
+}
+
+// approverIdentity returns the approval the person gives under the approval
+// policy: on their own behalf when listed, or else on behalf of a listed team.
+func approverIdentity(ctx context.Context, spec v1.PlaybookApproval, approver *models.Person) (*db.ElevationApproval, error) {
+	approval := db.ElevationApproval{ApprovedBy: approver.ID, ApprovedAt: time.Now()}
+	if collections.Contains(spec.Approvers.People, approver.Email) {
+		approval.PersonID = &approver.ID
+		return &approval, nil
+	}
+
+	teams, err := db.GetTeamsForUser(ctx, approver.ID.String())
+	if err != nil {
+		return nil, ctx.Oops().Wrapf(err, "failed to get teams of %s", approver.ID)
+	}
+	for _, team := range teams {
+		if collections.Contains(spec.Approvers.Teams, team.Name) {
+			approval.TeamID = &team.ID
+			return &approval, nil
+		}
+	}
+
+	return nil, dutyAPI.Errorf(dutyAPI.EFORBIDDEN, "you are not an approver of permission elevations")
+}
+
+// isSatisfied reports whether the approvals satisfy the approval policy.
+func isSatisfied(ctx context.Context, spec v1.PlaybookApproval, approvals []db.ElevationApproval) (bool, error) {
+	var allowed []uuid.UUID
+	if err := ctx.DB().Raw(`SELECT id FROM teams WHERE name IN ? AND deleted_at IS NULL
+		UNION
+		SELECT id FROM people WHERE email IN ? AND deleted_at IS NULL`,
+		spec.Approvers.Teams, spec.Approvers.People).Scan(&allowed).Error; err != nil {
+		return false, ctx.Oops().Wrapf(err, "failed to get approvers")
+	}
+
+	approved := lo.FilterMap(approvals, func(a db.ElevationApproval, _ int) (uuid.UUID, bool) {
+		if a.PersonID != nil {
+			return *a.PersonID, true
+		}
+		return lo.FromPtr(a.TeamID), a.TeamID != nil
+	})
+
+	if spec.Type == v1.PlaybookApprovalTypeAll {
+		return len(allowed) > 0 && lo.Every(approved, allowed), nil
+	}
+	return lo.Some(approved, allowed), nil
+}
+
+// coveredConfigs returns the config items the object selects, which the
+// grant is audited on.
+func coveredConfigs(ctx context.Context, object v1.PermissionObject) ([]string, error) {
+	if len(object.Configs) == 0 {
+		return nil, nil
+	}
+
+	ids, err := dutyQuery.FindConfigIDsByResourceSelector(ctx, 0, object.Configs...)
+	if err != nil {
+		return nil, ctx.Oops().Wrapf(err, "failed to resolve configs of the elevation")
+	}
+	return lo.Map(ids, func(id uuid.UUID, _ int) string { return id.String() }), nil
+}
+
+// recordChanges records the elevation in the audit log and as a change of the
+// config items it covers. Role elevations, and those of objects other than
+// configs, cover no config items.
+func recordChanges(ctx context.Context, elevation db.PermissionElevation, changeType, summary string) error {
+	entry := db.AuditLog{
+		Method: "ELEVATION",
+		Route:  "/rbac/elevations/:id",
+		Path:   "/rbac/elevations/" + elevation.ID.String(),
+		Object: policy.ObjectRBAC,
+		Action: changeType,
+	}
+	if user := ctx.User(); user != nil {
+		entry.ActorID = &user.ID
+	}
+	if err := audit.Record(ctx, &entry); err != nil {
+		return ctx.Oops().Wrapf(err, "failed to audit elevation %s", elevation.ID)
+	}
+
+	details, err := json.Marshal(map[string]any{
+		"elevation_id": elevation.ID,
+		"person_id":    elevation.PersonID,
+		"role":         elevation.Role,
+		"actions":      elevation.Actions,
+		"reason":       elevation.Reason,
+		"duration":     elevation.Duration,
+	})
+	if err != nil {
+		return ctx.Oops().Wrap(err)
+	}
+
+	now := time.Now()
+	for _, configID := range elevation.ConfigIDs {
+		change := models.ConfigChange{
+			ExternalChangeID: lo.ToPtr(fmt.Sprintf("%s/%s", elevation.ID, changeType)),
+			ConfigID:         configID,
+			ChangeType:       changeType,
+			Severity:         models.SeverityMedium,
+			Source:           Source,
+			Summary:          summary,
+			Details:          details,
+			CreatedAt:        &now,
+			Count:            1,
+		}
+		if user := ctx.User(); user != nil {
+			change.CreatedBy = &user.ID
+		}
+		if err := ctx.DB().Create(&change).Error; err != nil {
+			return ctx.Oops().Wrapf(err, "failed to record elevation %s on config %s", elevation.ID, configID)
+		}
+	}
+	return nil
+}
+
+// flush reloads the RBAC policy and invalidates the cached RLS payload so the
+// grant takes effect, or stops taking effect, immediately.
+func flush(ctx context.Context, personID uuid.UUID) {
+	if err := dutyRBAC.ReloadPolicy(); err != nil {
+		ctx.Errorf("failed to reload rbac policy after elevation of %s: %v", personID, err)
+	}
+	auth.InvalidateRLSCacheForUser(personID.String())
+}
+
+func find(ctx context.Context, id uuid.UUID) (*db.PermissionElevation, error) {
+	elevation, err := db.GetPermissionElevation(ctx, id)
+	if err != nil {
+		return nil, err
+	} else if elevation == nil {
+		return nil, dutyAPI.Errorf(dutyAPI.ENOTFOUND, "elevation %s not found", id)
+	}
+	return elevation, nil
+}
+
+func describe(elevation db.PermissionElevation) string {
+	if elevation.Role != "" {
+		return "role " + elevation.Role
+	}
+	return strings.Join(elevation.Actions, ",")
+}
+
+func personName(ctx context.Context, id uuid.UUID) string {
+	person, err := db.GetUserByID(ctx, id.String())
+	if err != nil {
+		return id.String()
+	}
+	return lo.CoalesceOrEmpty(person.Email, person.Name)
+}
@@ -0,0 +1,199 @@
+package elevation
+
+import (
+	"time"
+
+	"github.com/flanksource/commons/properties"
+	dutyAPI "github.com/flanksource/duty/api"
+	"github.com/flanksource/duty/models"
+	dutyRBAC "github.com/flanksource/duty/rbac"
+	"github.com/flanksource/duty/rbac/policy"
+	"github.com/flanksource/duty/tests/fixtures/dummy"
+	"github.com/flanksource/duty/types"
+	"github.com/google/uuid"
+	ginkgo "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	v1 "github.com/flanksource/incident-commander/api/v1"
+	"github.com/flanksource/incident-commander/db"
+	"github.com/flanksource/incident-commander/rbac/adapter"
+)
+
+var _ = ginkgo.Describe("Elevation", ginkgo.Ordered, func() {
+	var elevation *db.PermissionElevation
+
+	requester := DefaultContext
+	approver := DefaultContext
+	bystander := DefaultContext
+
+	ginkgo.BeforeAll(func() {
+		if dutyRBAC.Enforcer() == nil {
+			Expect(dutyRBAC.Init(DefaultContext, []string{}, adapter.NewPermissionAdapter)).To(Succeed())
+		}
+
+		properties.Set("rbac.elevation.approvers.people", dummy.JohnDoe.Email)
+		properties.Set("rbac.elevation.max_duration", "4h")
+
+		requester = DefaultContext.WithUser(&dummy.JohnWick)
+		approver = DefaultContext.WithUser(&dummy.JohnDoe)
+		bystander = DefaultContext.WithUser(&dummy.AlanTuring)
+	})
+
+	ginkgo.AfterAll(func() {
+		properties.Set("rbac.elevation.approvers.people", "")
+		properties.Set("rbac.elevation.max_duration", "")
+	})
+
+	ginkgo.It("should validate requests", func() {
+		_, err := Create(requester, Request{Role: policy.RoleEditor, Duration: "1h"})
+		Expect(dutyAPI.ErrorCode(err)).To(Equal(dutyAPI.EINVALID))
+
+		_, err = Create(requester, Request{Role: policy.RoleEditor, Actions: []string{policy.ActionUpdate}, Reason: "both", Duration: "1h"})
+		Expect(dutyAPI.ErrorCode(err)).To(Equal(dutyAPI.EINVALID))
+
+		_, err = Create(requester, Request{Role: policy.RoleEditor, Reason: "too long", Duration: "1d"})
+		Expect(dutyAPI.ErrorCode(err)).To(Equal(dutyAPI.EINVALID))
+
+		_, err = Create(requester, Request{Actions: []string{policy.ActionUpdate}, Reason: "no object", Duration: "1h"})
+		Expect(dutyAPI.ErrorCode(err)).To(Equal(dutyAPI.EINVALID))
+	})
+
+	ginkgo.It("should request actions on a config", func() {
+		var err error
+		elevation, err = Create(requester, Request{
+			Actions: []string{policy.ActionUpdate},
+			Object: v1.PermissionObject{Selectors: dutyRBAC.Selectors{
+				Configs: []types.ResourceSelector{{ID: dummy.EKSCluster.ID.String()}},
+			}},
+			Reason:   "INC-42 scale the cluster",
+			Duration: "1h",
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(elevation.Status).To(Equal(db.ElevationStatusPending))
+		Expect(elevation.PermissionID).To(BeNil())
+	})
+
+	ginkgo.It("should only be approved by an approver other than the requester", func() {
+		_, err := Approve(requester, elevation.ID)
+		Expect(dutyAPI.ErrorCode(err)).To(Equal(dutyAPI.EFORBIDDEN))
+
+		_, err = Approve(bystander, elevation.ID)
+		Expect(dutyAPI.ErrorCode(err)).To(Equal(dutyAPI.EFORBIDDEN))
+	})
+
+	ginkgo.It("should grant the permission on approval", func() {
+		var err error
+		elevation, err = Approve(approver, elevation.ID)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(elevation.Status).To(Equal(db.ElevationStatusActive))
+		Expect(elevation.PermissionID).ToNot(BeNil())
+		Expect(elevation.ExpiresAt).ToNot(BeNil())
+		Expect([]string(elevation.ConfigIDs)).To(ConsistOf(dummy.EKSCluster.ID.String()))
+
+		var permission models.Permission
+		Expect(DefaultContext.DB().Where("id = ?", *elevation.PermissionID).First(&permission).Error).To(Succeed())
+		Expect(permission.Subject).To(Equal(dummy.JohnWick.ID.String()))
+		Expect(permission.Source).To(Equal(Source))
+		Expect(permission.Until).ToNot(BeNil())
+
+		policies, err := dutyRBAC.Enforcer().GetFilteredPolicy(5, elevation.PermissionID.String())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(policies).ToNot(BeEmpty())
+
+		var changes []models.ConfigChange
+		Expect(DefaultContext.DB().Where("config_id = ? AND change_type = ?", dummy.EKSCluster.ID, ChangeTypeGranted).Find(&changes).Error).To(Succeed())
+		Expect(changes).To(HaveLen(1))
+
+		elevations, err := db.GetRBACElevations(DefaultContext, []uuid.UUID{dummy.EKSCluster.ID}, time.Now().Add(-time.Hour))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(elevations).To(HaveLen(1))
+		Expect(elevations[0].Status).To(Equal(db.ElevationStatusActive))
+		Expect(elevations[0].ExpiresAt).To(BeTemporally("~", *elevation.ExpiresAt, time.Second))
+	})
+
+	ginkgo.It("should take the permission back on expiry", func() {
+		Expect(DefaultContext.DB().Model(&db.PermissionElevation{}).Where("id = ?", elevation.ID).
+			Update("expires_at", time.Now().Add(-time.Minute)).Error).To(Succeed())
+
+		expired, err := Expire(DefaultContext)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(expired).To(Equal(1))
+
+		elevation, err = db.GetPermissionElevation(DefaultContext, elevation.ID)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(elevation.Status).To(Equal(db.ElevationStatusExpired))
+
+		var permission models.Permission
+		Expect(DefaultContext.DB().Where("id = ?", *elevation.PermissionID).First(&permission).Error).To(Succeed())
+		Expect(permission.DeletedAt).ToNot(BeNil())
+
+		policies, err := dutyRBAC.Enforcer().GetFilteredPolicy(5, elevation.PermissionID.String())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(policies).To(BeEmpty())
+
+		var changes []models.ConfigChange
+		Expect(DefaultContext.DB().Where("config_id = ? AND change_type = ?", dummy.EKSCluster.ID, ChangeTypeEnded).Find(&changes).Error).To(Succeed())
+		Expect(changes).To(HaveLen(1))
+	})
+
+	ginkgo.It("should not overwrite a concurrent approval", func() {
+		properties.Set("rbac.elevation.approvers.people", dummy.JohnDoe.Email+","+dummy.AlanTuring.Email)
+		defer properties.Set("rbac.elevation.approvers.people", dummy.JohnDoe.Email)
+
+		pending, err := Create(requester, Request{Role: policy.RoleEditor, Reason: "INC-43 rollback", Duration: "30m"})
+		Expect(err).ToNot(HaveOccurred())
+
+		stale := []byte(`[{"approved_by":"` + dummy.AlanTuring.ID.String() + `"}]`)
+		ok, err := db.UpdatePendingPermissionElevation(DefaultContext, pending.ID, nil, map[string]any{"approvals": types.JSON(stale)})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ok).To(BeTrue())
+
+		ok, err = db.UpdatePendingPermissionElevation(DefaultContext, pending.ID, nil, map[string]any{"approvals": types.JSON(`[]`)})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ok).To(BeFalse())
+
+		Expect(DefaultContext.DB().Model(&db.PermissionElevation{}).Where("id = ?", pending.ID).
+			Update("status", db.ElevationStatusRejected).Error).To(Succeed())
+	})
+
+	ginkgo.It("should audit and list role elevations", func() {
+		roleElevation, err := Create(requester, Request{Role: policy.RoleEditor, Reason: "INC-44 deploy", Duration: "30m"})
+		Expect(err).ToNot(HaveOccurred())
+
+		roleElevation, err = Approve(approver, roleElevation.ID)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(roleElevation.Status).To(Equal(db.ElevationStatusActive))
+		Expect(roleElevation.ConfigIDs).To(BeEmpty())
+
+		var entries []db.AuditLog
+		Expect(DefaultContext.DB().Where("path = ? AND action = ?", "/rbac/elevations/"+roleElevation.ID.String(), ChangeTypeGranted).
+			Find(&entries).Error).To(Succeed())
+		Expect(entries).To(HaveLen(1))
+
+		elevations, err := db.GetRBACElevations(DefaultContext, []uuid.UUID{dummy.EKSCluster.ID}, time.Now().Add(-time.Hour))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(elevations).To(ContainElement(HaveField("Role", policy.RoleEditor)))
+
+		_, err = Revoke(approver, roleElevation.ID)
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	ginkgo.It("should reject a role request", func() {
+		roleElevation, err := Create(requester, Request{Role: policy.RoleEditor, Reason: "deploy hotfix", Duration: "30m"})
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = Reject(bystander, roleElevation.ID)
+		Expect(dutyAPI.ErrorCode(err)).To(Equal(dutyAPI.EFORBIDDEN))
+
+		roleElevation, err = Reject(approver, roleElevation.ID)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(roleElevation.Status).To(Equal(db.ElevationStatusRejected))
+
+		_, err = Approve(approver, roleElevation.ID)
+		Expect(dutyAPI.ErrorCode(err)).To(Equal(dutyAPI.ECONFLICT))
+
+		roles, err := dutyRBAC.RolesForUser(dummy.JohnWick.ID.String())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(roles).ToNot(ContainElement(policy.RoleEditor))
+	})
+})
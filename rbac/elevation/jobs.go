@@ -0,0 +1,24 @@
+package elevation
+
+import (
+	"github.com/flanksource/duty/context"
+	"github.com/flanksource/duty/job"
+)
+
+// ExpireElevations takes back the elevations past their expiry.
+func ExpireElevations(ctx context.Context) *job.Job {
+	return &job.Job{
+		Name:       "ExpireElevations",
+		Schedule:   "@every 1m",
+		Context:    ctx,
+		Singleton:  true,
+		Retention:  job.RetentionFailed,
+		JobHistory: true,
+		RunNow:     true,
+		Fn: func(run job.JobRuntime) error {
+			expired, err := Expire(run.Context)
+			run.History.SuccessCount = expired
+			return err
+		},
+	}
+}
@@ -3,9 +3,12 @@ package rbac
 import (
 	"errors"
 	"net/http"
+	"reflect"
 	"strings"
+	"sync"
 
 	"github.com/flanksource/duty/context"
+	"github.com/flanksource/duty/query"
 	"github.com/flanksource/duty/rbac"
 	"github.com/flanksource/duty/rbac/policy"
 	"github.com/labstack/echo/v4"
@@ -15,10 +18,34 @@ var (
 	ErrNoUserID          = errors.New("unauthorized. User not found for RBAC")
 	ErrAccessDenied      = errors.New("unauthorized. Access Denied")
 	ErrMisconfiguredRBAC = errors.New("unauthorized. RBAC policy not configured correctly")
+	ErrTokenNetwork      = errors.New("unauthorized. Access token not allowed from this network")
+	ErrTokenRoute        = errors.New("unauthorized. Access token not allowed on this route")
 )
 
 type MiddlewareFunc = func(echo.HandlerFunc) echo.HandlerFunc
 
+const decisionKey = "rbac.decision"
+
+// Decision is the outcome of the authorization of a request.
+type Decision struct {
+	Object  string
+	Action  string
+	Allowed bool
+}
+
+func setDecision(c echo.Context, object, action string, allowed bool) {
+	c.Set(decisionKey, &Decision{Object: object, Action: action, Allowed: allowed})
+}
+
+// GetDecision returns the authorization decision of the request, or nil if
+// the request wasn't authorized by RBAC.
+func GetDecision(c echo.Context) *Decision {
+	if d, ok := c.Get(decisionKey).(*Decision); ok {
+		return d
+	}
+	return nil
+}
+
 func Playbook(action string) MiddlewareFunc {
 	return Authorization(policy.ObjectPlaybooks, action)
 }
@@ -35,6 +62,32 @@ func Canary(action string) MiddlewareFunc {
 	return Authorization(policy.ObjectCanary, action)
 }
 
+// AgentObject is the object of a single agent, e.g. agent:prod-eu.
+func AgentObject(name string) string {
+	return policy.ObjectAgent + ":" + name
+}
+
+// Agent authorizes the action on the agent of the :agent route parameter. It
+// is allowed on every agent or on that agent alone, by name.
+func Agent(action string) MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			object := policy.ObjectAgent
+
+			ctx := c.Request().Context().(context.Context)
+			if ctx.User() != nil && !rbac.CheckContext(ctx, object, action) {
+				if agent, err := query.FindCachedAgent(ctx, c.Param("agent")); err != nil {
+					ctx.Errorf("failed to get agent %s: %v", c.Param("agent"), err)
+				} else if agent != nil {
+					object = AgentObject(agent.Name)
+				}
+			}
+
+			return Authorization(object, action)(next)(c)
+		}
+	}
+}
+
 func DbMiddleware() MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
@@ -57,7 +110,8 @@ func DbMiddleware() MiddlewareFunc {
 			ctx := c.Request().Context().(context.Context)
 			user := ctx.User()
 
-			if !rbac.CheckContext(ctx, object, action) {
+			if !rbac.CheckContext(ctx, object, action) && !breakGlassAdmin(ctx) {
+				setDecision(c, object, action, false)
 				c.Response().Header().Add("X-Rbac-Subject", user.ID.String())
 				c.Response().Header().Add("X-Rbac-Object", object)
 				c.Response().Header().Add("X-Rbac-Action", action)
@@ -65,6 +119,12 @@ func DbMiddleware() MiddlewareFunc {
 				return c.String(http.StatusForbidden, ErrAccessDenied.Error())
 			}
 
+			if err := CheckTokenScope(c, ctx, object, action); err != nil {
+				setDecision(c, object, action, false)
+				return c.String(http.StatusForbidden, err.Error())
+			}
+
+			setDecision(c, object, action, true)
 			return next(c)
 		}
 	}
@@ -93,7 +153,8 @@ func Authorization(object, action string) MiddlewareFunc {
 				return c.String(http.StatusForbidden, ErrMisconfiguredRBAC.Error())
 			}
 
-			if !rbac.CheckContext(ctx, object, action) {
+			if !rbac.CheckContext(ctx, object, action) && !breakGlassAdmin(ctx) {
+				setDecision(c, object, action, false)
 				c.Response().Header().Add("X-Rbac-Subject", u.ID.String())
 				c.Response().Header().Add("X-Rbac-Object", object)
 				c.Response().Header().Add("X-Rbac-Action", action)
@@ -101,7 +162,143 @@ func Authorization(object, action string) MiddlewareFunc {
 				return c.String(http.StatusForbidden, ErrAccessDenied.Error())
 			}
 
+			if err := CheckTokenScope(c, ctx, object, action); err != nil {
+				setDecision(c, object, action, false)
+				return c.String(http.StatusForbidden, err.Error())
+			}
+
+			setDecision(c, object, action, true)
 			return next(c)
 		}
 	}
 }
+
+// CheckTokenScope rejects requests of a scoped access token made from outside
+// its networks or for an object and action outside its permissions.
+func CheckTokenScope(c echo.Context, ctx context.Context, object, action string) error {
+	scope, err := GetTokenScope(ctx, ctx.User().ID)
+	if err != nil {
+		ctx.Errorf("failed to get token scope of %s: %v", ctx.User().ID, err)
+		return ErrAccessDenied
+	} else if scope == nil {
+		return nil
+	}
+
+	if !scope.AllowsIP(c.RealIP()) {
+		return ErrTokenNetwork
+	}
+	if !scope.Allows(object, action) {
+		c.Response().Header().Add("X-Rbac-Subject", ctx.User().ID.String())
+		c.Response().Header().Add("X-Rbac-Object", object)
+		c.Response().Header().Add("X-Rbac-Action", action)
+		return ErrAccessDenied
+	}
+	return nil
+}
+
+// RequireTokenScope checks the scope of access tokens on routes whose handlers
+// authorize the caller themselves, recording the object and action the route
+// stands for.
+func RequireTokenScope(object, action string) MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			ctx := c.Request().Context().(context.Context)
+			if ctx.User() == nil {
+				return c.String(http.StatusUnauthorized, "Not logged in")
+			}
+
+			if err := CheckTokenScope(c, ctx, object, action); err != nil {
+				setDecision(c, object, action, false)
+				return c.String(http.StatusForbidden, err.Error())
+			}
+
+			setDecision(c, object, action, true)
+			return next(c)
+		}
+	}
+}
+
+var (
+	scopeCheckersMu sync.RWMutex
+	// scopeCheckers are the code pointers of the middlewares that check the
+	// scope of access tokens. The closures of a function share it.
+	scopeCheckers = map[uintptr]bool{}
+
+	scopedRoutesMu sync.RWMutex
+	scopedRoutes   = map[string]bool{}
+)
+
+func init() {
+	RegisterScopeChecker(Authorization("", ""))
+	RegisterScopeChecker(Agent(""))
+	RegisterScopeChecker(DbMiddleware())
+	RegisterScopeChecker(RequireTokenScope("", ""))
+}
+
+// RegisterScopeChecker marks the middleware, and every other closure of the
+// function that returned it, as checking the scope of access tokens.
+func RegisterScopeChecker(middleware MiddlewareFunc) {
+	scopeCheckersMu.Lock()
+	defer scopeCheckersMu.Unlock()
+	scopeCheckers[reflect.ValueOf(middleware).Pointer()] = true
+}
+
+func isScopeChecker(middleware echo.MiddlewareFunc) bool {
+	scopeCheckersMu.RLock()
+	defer scopeCheckersMu.RUnlock()
+	return scopeCheckers[reflect.ValueOf(middleware).Pointer()]
+}
+
+func routeKey(method, path string) string {
+	return method + " " + path
+}
+
+// TrackScopedRoutes records the routes added to e with a middleware that
+// checks the scope of access tokens. It must be called before any route is
+// added.
+func TrackScopedRoutes(e *echo.Echo) {
+	e.OnAddRouteHandler = func(_ string, route echo.Route, _ echo.HandlerFunc, middlewares []echo.MiddlewareFunc) {
+		for _, middleware := range middlewares {
+			if isScopeChecker(middleware) {
+				scopedRoutesMu.Lock()
+				scopedRoutes[routeKey(route.Method, route.Path)] = true
+				scopedRoutesMu.Unlock()
+				return
+			}
+		}
+	}
+}
+
+func isScopedRoute(method, path string) bool {
+	scopedRoutesMu.RLock()
+	defer scopedRoutesMu.RUnlock()
+	return scopedRoutes[routeKey(method, path)] || scopedRoutes[routeKey(echo.RouteNotFound, path)]
+}
+
+// EnforceTokenScopes rejects requests of scoped access tokens made from
+// outside their networks, and those to routes that don't check the object and
+// action of the token.
+func EnforceTokenScopes(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		ctx := c.Request().Context().(context.Context)
+		if ctx.User() == nil {
+			return next(c)
+		}
+
+		scope, err := GetTokenScope(ctx, ctx.User().ID)
+		if err != nil {
+			ctx.Errorf("failed to get token scope of %s: %v", ctx.User().ID, err)
+			return c.String(http.StatusForbidden, ErrAccessDenied.Error())
+		} else if scope == nil {
+			return next(c)
+		}
+
+		if !scope.AllowsIP(c.RealIP()) {
+			return c.String(http.StatusForbidden, ErrTokenNetwork.Error())
+		}
+		if !isScopedRoute(c.Request().Method, c.Path()) {
+			return c.String(http.StatusForbidden, ErrTokenRoute.Error())
+		}
+		return next(c)
+	}
+}
@@ -4,6 +4,17 @@ import (
 	"github.com/flanksource/duty/rbac/policy"
 )
 
+// ActionAgentSession grants opening remote exec and port-forward sessions
+// through an agent.
+const ActionAgentSession = "agent:session"
+
+// ObjectSCIM is the SCIM provisioning API. Only access tokens that were
+// explicitly scoped to it can use the API.
+const ObjectSCIM = "scim"
+
+// ObjectAudit is the audit log of the API.
+const ObjectAudit = "audit"
+
 var (
 	AllPermissions []policy.Permission
 )
@@ -14,4 +25,12 @@ func init() {
 			AllPermissions = append(AllPermissions, policy.NewPermission([]string{"", obj, act}))
 		}
 	}
+
+	AllPermissions = append(AllPermissions, policy.NewPermission([]string{"", policy.ObjectAgent, ActionAgentSession}))
+
+	for _, act := range []string{policy.ActionCreate, policy.ActionRead, policy.ActionUpdate, policy.ActionDelete} {
+		AllPermissions = append(AllPermissions, policy.NewPermission([]string{"", ObjectSCIM, act}))
+	}
+
+	AllPermissions = append(AllPermissions, policy.NewPermission([]string{"", ObjectAudit, policy.ActionRead}))
 }
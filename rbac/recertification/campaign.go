@@ -0,0 +1,583 @@
+// Package recertification runs access recertification campaigns: owners of
+// config items periodically approve or revoke the access recorded in
+// config_access, and sign off on the outcome.
+package recertification
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/flanksource/commons/duration"
+	dutyAPI "github.com/flanksource/duty/api"
+	"github.com/flanksource/duty/context"
+	"github.com/flanksource/duty/models"
+	dutyQuery "github.com/flanksource/duty/query"
+	"github.com/flanksource/duty/types"
+	"github.com/google/uuid"
+	"github.com/samber/lo"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/flanksource/incident-commander/api"
+	v1 "github.com/flanksource/incident-commander/api/v1"
+	"github.com/flanksource/incident-commander/db"
+	"github.com/flanksource/incident-commander/playbook"
+)
+
+const (
+	// Source is the source of the access reviews recorded by campaigns.
+	Source = "AccessCampaign"
+
+	defaultReminderInterval = 24 * time.Hour
+)
+
+// Spec defines the access a campaign recertifies, who reviews it and by when.
+type Spec struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+
+	// Configs select the config items whose access is reviewed.
+	Configs []types.ResourceSelector `json:"configs,omitempty"`
+
+	// Applications are the applications, as namespace/name, whose logins,
+	// or else all mapped configs, are reviewed.
+	Applications []string `json:"applications,omitempty"`
+
+	Reviewers Reviewers `json:"reviewers"`
+
+	DueDate time.Time `json:"due_date"`
+
+	// ReminderInterval is how often reviewers with pending decisions are
+	// reminded. Defaults to 24h.
+	ReminderInterval string `json:"reminder_interval,omitempty"`
+
+	// RevocationPlaybook is run on the config item of every revoked access.
+	RevocationPlaybook *RevocationPlaybook `json:"revocation_playbook,omitempty"`
+}
+
+// Reviewers are who decide on the access to a config item: its owner when
+// known, or else the listed people and teams.
+type Reviewers struct {
+	// OwnerTag is the tag, or label, of a config item naming its owner:
+	// the email of a person or the name of a team.
+	OwnerTag string `json:"owner_tag,omitempty"`
+
+	// People are the emails of the reviewers.
+	People []string `json:"people,omitempty"`
+
+	// Teams are the names of the reviewing teams.
+	Teams []string `json:"teams,omitempty"`
+}
+
+func (t Reviewers) Empty() bool {
+	return t.OwnerTag == "" && len(t.People) == 0 && len(t.Teams) == 0
+}
+
+// RevocationPlaybook references the playbook that takes away revoked access.
+//
+// Besides Params, the run receives the parameters user, email, role,
+// config_access_id, campaign and comment.
+type RevocationPlaybook struct {
+	// Name of the playbook, or namespace/name.
+	Name   string            `json:"name"`
+	Params map[string]string `json:"params,omitempty"`
+}
+
+// Decision is the decision of a reviewer on an item.
+type Decision struct {
+	Decision string `json:"decision"`
+	Comment  string `json:"comment,omitempty"`
+}
+
+// reviewer is a person or a team reviewing items.
+type reviewer struct {
+	PersonID *uuid.UUID
+	TeamID   *uuid.UUID
+}
+
+// Open snapshots the current access to the configs of the spec and assigns
+// every grant to its reviewers.
+func Open(ctx context.Context, spec Spec) (*db.AccessCampaign, error) {
+	if strings.TrimSpace(spec.Name) == "" {
+		return nil, dutyAPI.Errorf(dutyAPI.EINVALID, "name is required")
+	}
+	if len(spec.Configs) == 0 && len(spec.Applications) == 0 {
+		return nil, dutyAPI.Errorf(dutyAPI.EINVALID, "configs or applications are required")
+	}
+	if spec.Reviewers.Empty() {
+		return nil, dutyAPI.Errorf(dutyAPI.EINVALID, "reviewers are required")
+	}
+	if !spec.DueDate.After(time.Now()) {
+		return nil, dutyAPI.Errorf(dutyAPI.EINVALID, "due date must be in the future")
+	}
+	if _, err := reminderInterval(spec); err != nil {
+		return nil, err
+	}
+	if spec.RevocationPlaybook != nil {
+		if _, err := findPlaybook(ctx, spec.RevocationPlaybook.Name); err != nil {
+			return nil, err
+		}
+	}
+
+	configIDs, err := resolveConfigs(ctx, spec)
+	if err != nil {
+		return nil, err
+	}
+
+	grants, err := db.GetAccessGrants(ctx, configIDs)
+	if err != nil {
+		return nil, err
+	} else if len(grants) == 0 {
+		return nil, dutyAPI.Errorf(dutyAPI.EINVALID, "the selected configs have no access to review")
+	}
+
+	fallback, err := resolveReviewers(ctx, spec.Reviewers.People, spec.Reviewers.Teams)
+	if err != nil {
+		return nil, err
+	}
+
+	owners := map[string][]reviewer{}
+	items := make([]db.AccessCampaignItem, 0, len(grants))
+	for _, grant := range grants {
+		reviewers := fallback
+		if owner := ownerOf(grant, spec.Reviewers.OwnerTag); owner != "" {
+			if _, ok := owners[owner]; !ok {
+				owners[owner] = resolveOwner(ctx, owner)
+			}
+			if len(owners[owner]) > 0 {
+				reviewers = owners[owner]
+			}
+		}
+		if len(reviewers) == 0 {
+			return nil, dutyAPI.Errorf(dutyAPI.EINVALID, "config %s has no owner in the tag %s and no fallback reviewers", grant.ConfigName, spec.Reviewers.OwnerTag)
+		}
+
+		item := db.AccessCampaignItem{
+			ConfigAccessID:  grant.ID,
+			ConfigID:        grant.ConfigID,
+			ConfigName:      grant.ConfigName,
+			ConfigType:      grant.ConfigType,
+			ScraperID:       grant.ScraperID,
+			ExternalUserID:  grant.ExternalUserID,
+			ExternalGroupID: grant.ExternalGroupID,
+			ExternalRoleID:  grant.ExternalRoleID,
+			User:            grant.User,
+			Email:           grant.Email,
+			Role:            grant.Role,
+			Decision:        db.AccessDecisionPending,
+		}
+		for _, r := range reviewers {
+			if r.PersonID != nil {
+				item.ReviewerPersonIDs = append(item.ReviewerPersonIDs, r.PersonID.String())
+			} else {
+				item.ReviewerTeamIDs = append(item.ReviewerTeamIDs, r.TeamID.String())
+			}
+		}
+		items = append(items, item)
+	}
+
+	specJSON, err := json.Marshal(spec)
+	if err != nil {
+		return nil, ctx.Oops().Wrap(err)
+	}
+
+	campaign := db.AccessCampaign{
+		Name:        spec.Name,
+		Description: spec.Description,
+		Spec:        specJSON,
+		Status:      db.AccessCampaignStatusOpen,
+		DueAt:       spec.DueDate,
+	}
+	if user := ctx.User(); user != nil {
+		campaign.CreatedBy = &user.ID
+	}
+	if err := db.CreateAccessCampaign(ctx, &campaign, items); err != nil {
+		return nil, err
+	}
+
+	ctx.Infof("opened access campaign %s (%s) with %d items due %s", campaign.Name, campaign.ID, len(items), campaign.DueAt.Format(time.RFC3339))
+	return &campaign, nil
+}
+
+// Tasks returns the pending items the current user reviews, in person or
+// through one of their teams.
+func Tasks(ctx context.Context) ([]db.AccessCampaignItem, error) {
+	user := ctx.User()
+	if user == nil {
+		return nil, dutyAPI.Errorf(dutyAPI.EUNAUTHORIZED, "not logged in")
+	}
+
+	teamIDs, err := teamsOf(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	return db.GetAccessCampaignTasks(ctx, []uuid.UUID{user.ID}, teamIDs)
+}
+
+// Decide records the decision of the current user on a pending item. Approved
+// access is marked as reviewed; revoked access is handed to the revocation
+// playbook of the campaign.
+func Decide(ctx context.Context, itemID uuid.UUID, decision Decision) (*db.AccessCampaignItem, error) {
+	user := ctx.User()
+	if user == nil {
+		return nil, dutyAPI.Errorf(dutyAPI.EUNAUTHORIZED, "not logged in")
+	}
+
+	if decision.Decision != db.AccessDecisionApproved && decision.Decision != db.AccessDecisionRevoked {
+		return nil, dutyAPI.Errorf(dutyAPI.EINVALID, "decision must be %s or %s", db.AccessDecisionApproved, db.AccessDecisionRevoked)
+	}
+
+	item, err := db.GetAccessCampaignItem(ctx, itemID)
+	if err != nil {
+		return nil, err
+	} else if item == nil {
+		return nil, dutyAPI.Errorf(dutyAPI.ENOTFOUND, "access campaign item %s not found", itemID)
+	}
+
+	campaign, err := find(ctx, item.CampaignID)
+	if err != nil {
+		return nil, err
+	}
+	if campaign.Status != db.AccessCampaignStatusOpen {
+		return nil, dutyAPI.Errorf(dutyAPI.ECONFLICT, "access campaign %s is %s", campaign.Name, campaign.Status)
+	}
+	if item.Decision != db.AccessDecisionPending {
+		return nil, dutyAPI.Errorf(dutyAPI.ECONFLICT, "access of %s to %s was already %s", item.User, item.ConfigName, item.Decision)
+	}
+
+	if ok, err := isReviewer(ctx, *item, user.ID); err != nil {
+		return nil, err
+	} else if !ok {
+		return nil, dutyAPI.Errorf(dutyAPI.EFORBIDDEN, "you are not a reviewer of the access of %s to %s", item.User, item.ConfigName)
+	}
+
+	var spec Spec
+	if err := json.Unmarshal(campaign.Spec, &spec); err != nil {
+		return nil, ctx.Oops().Wrapf(err, "invalid spec of access campaign %s", campaign.ID)
+	}
+
+	now := time.Now()
+	item.Decision = decision.Decision
+	item.DecidedBy = &user.ID
+	item.DecidedAt = &now
+	item.Comment = decision.Comment
+	item.Error = ""
+
+	// The item is claimed first so that concurrent reviewers can't both decide
+	// and run the revocation playbook twice. A revocation is only saved along
+	// with the queued run of its playbook.
+	err = ctx.Transaction(func(ctx context.Context, _ trace.Span) error {
+		if ok, err := db.DecideAccessCampaignItem(ctx, item); err != nil {
+			return err
+		} else if !ok {
+			return dutyAPI.Errorf(dutyAPI.ECONFLICT, "access of %s to %s was already decided", item.User, item.ConfigName)
+		}
+
+		if decision.Decision == db.AccessDecisionApproved {
+			return recordReview(ctx, *item)
+		} else if spec.RevocationPlaybook == nil {
+			return nil
+		}
+
+		run, err := revoke(ctx, *campaign, *spec.RevocationPlaybook, *item)
+		if err != nil {
+			return err
+		}
+		item.PlaybookRunID = &run.ID
+		if err := ctx.DB().Model(item).Update("playbook_run_id", run.ID).Error; err != nil {
+			return ctx.Oops().Wrapf(err, "failed to save revocation run of access campaign item %s", item.ID)
+		}
+		return nil
+	})
+	if err != nil {
+		if dutyAPI.ErrorCode(err) != dutyAPI.ECONFLICT && decision.Decision == db.AccessDecisionRevoked {
+			// The item stays pending, with the reason the revocation failed
+			if uerr := ctx.DB().Model(&db.AccessCampaignItem{}).Where("id = ? AND decision = ?", item.ID, db.AccessDecisionPending).
+				Update("error", err.Error()).Error; uerr != nil {
+				ctx.Errorf("failed to save revocation error of access campaign item %s: %v", item.ID, uerr)
+			}
+		}
+		return nil, err
+	}
+
+	ctx.Infof("%s %s the access of %s (%s) to %s in campaign %s", user.Email, item.Decision, item.User, item.Role, item.ConfigName, campaign.Name)
+	return item, nil
+}
+
+// SignOff closes a campaign once every item is decided, recording a digest of
+// the decisions so later changes to them can be detected.
+func SignOff(ctx context.Context, id uuid.UUID) (*db.AccessCampaign, error) {
+	user := ctx.User()
+	if user == nil {
+		return nil, dutyAPI.Errorf(dutyAPI.EUNAUTHORIZED, "not logged in")
+	}
+
+	campaign, err := find(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if campaign.Status != db.AccessCampaignStatusOpen {
+		return nil, dutyAPI.Errorf(dutyAPI.ECONFLICT, "access campaign %s is %s", campaign.Name, campaign.Status)
+	}
+
+	items, err := db.GetAccessCampaignItems(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if pending := lo.CountBy(items, func(i db.AccessCampaignItem) bool { return i.Decision == db.AccessDecisionPending }); pending > 0 {
+		return nil, dutyAPI.Errorf(dutyAPI.ECONFLICT, "access campaign %s has %d pending decisions", campaign.Name, pending)
+	}
+
+	digest, err := Digest(items)
+	if err != nil {
+		return nil, ctx.Oops().Wrap(err)
+	}
+
+	now := time.Now()
+	campaign.Status = db.AccessCampaignStatusSignedOff
+	campaign.SignedOffAt = &now
+	campaign.SignedOffBy = &user.ID
+	campaign.Digest = digest
+	if err := ctx.DB().Model(campaign).Select("status", "signed_off_at", "signed_off_by", "digest").Updates(campaign).Error; err != nil {
+		return nil, ctx.Oops().Wrapf(err, "failed to sign off access campaign %s", campaign.ID)
+	}
+
+	ctx.Infof("%s signed off access campaign %s (%s)", user.Email, campaign.Name, digest)
+	return campaign, nil
+}
+
+// Digest returns the sha256 of the decisions on the items.
+func Digest(items []db.AccessCampaignItem) (string, error) {
+	type decision struct {
+		ID        uuid.UUID  `json:"id"`
+		Access    string     `json:"config_access_id"`
+		Decision  string     `json:"decision"`
+		DecidedBy *uuid.UUID `json:"decided_by"`
+		DecidedAt *time.Time `json:"decided_at"`
+		Comment   string     `json:"comment"`
+	}
+
+	decisions := lo.Map(items, func(i db.AccessCampaignItem, _ int) decision {
+		var decidedAt *time.Time
+		if i.DecidedAt != nil {
+			decidedAt = lo.ToPtr(i.DecidedAt.UTC().Truncate(time.Microsecond))
+		}
+		return decision{ID: i.ID, Access: i.ConfigAccessID, Decision: i.Decision, DecidedBy: i.DecidedBy, DecidedAt: decidedAt, Comment: i.Comment}
+	})
+	sort.Slice(decisions, func(a, b int) bool { return decisions[a].ID.String() < decisions[b].ID.String() })
+
+	data, err := json.Marshal(decisions)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func resolveConfigs(ctx context.Context, spec Spec) ([]uuid.UUID, error) {
+	selectors := slices.Clone(spec.Configs)
+	for _, ref := range spec.Applications {
+		namespace, name, ok := strings.Cut(ref, "/")
+		if !ok {
+			return nil, dutyAPI.Errorf(dutyAPI.EINVALID, "application %q must be namespace/name", ref)
+		}
+
+		app, err := db.FindApplication(ctx, namespace, name)
+		if err != nil {
+			return nil, ctx.Oops().Wrapf(err, "failed to get application %s", ref)
+		} else if app == nil {
+			return nil, dutyAPI.Errorf(dutyAPI.EINVALID, "application %s not found", ref)
+		}
+
+		application, err := v1.ApplicationFromModel(*app)
+		if err != nil {
+			return nil, ctx.Oops().Wrapf(err, "invalid application %s", ref)
+		}
+		selectors = append(selectors, application.AccessSelectors()...)
+	}
+
+	if len(selectors) == 0 {
+		return nil, dutyAPI.Errorf(dutyAPI.EINVALID, "the applications map no configs")
+	}
+
+	ids, err := dutyQuery.FindConfigIDsByResourceSelector(ctx, 0, selectors...)
+	if err != nil {
+		return nil, ctx.Oops().Wrapf(err, "failed to resolve the configs of the campaign")
+	}
+	return lo.Uniq(ids), nil
+}
+
+func resolveReviewers(ctx context.Context, people, teams []string) ([]reviewer, error) {
+	var reviewers []reviewer
+	for _, email := range people {
+		person, err := dutyQuery.FindPerson(ctx, email)
+		if err != nil {
+			return nil, ctx.Oops().Wrapf(err, "failed to get person %s", email)
+		} else if person == nil {
+			return nil, dutyAPI.Errorf(dutyAPI.EINVALID, "reviewer %s not found", email)
+		}
+		reviewers = append(reviewers, reviewer{PersonID: &person.ID})
+	}
+
+	for _, name := range teams {
+		team, err := dutyQuery.FindTeam(ctx, name)
+		if err != nil {
+			return nil, ctx.Oops().Wrapf(err, "failed to get team %s", name)
+		} else if team == nil {
+			return nil, dutyAPI.Errorf(dutyAPI.EINVALID, "reviewing team %s not found", name)
+		}
+		reviewers = append(reviewers, reviewer{TeamID: &team.ID})
+	}
+
+	return reviewers, nil
+}
+
+// resolveOwner returns the person, or else the team, an owner tag names. An
+// unknown owner falls back to the reviewers of the campaign.
+func resolveOwner(ctx context.Context, owner string) []reviewer {
+	if person, err := dutyQuery.FindPerson(ctx, owner); err == nil && person != nil {
+		return []reviewer{{PersonID: &person.ID}}
+	}
+	if team, err := dutyQuery.FindTeam(ctx, owner); err == nil && team != nil {
+		return []reviewer{{TeamID: &team.ID}}
+	}
+
+	ctx.Warnf("owner %s is neither a person nor a team", owner)
+	return nil
+}
+
+func ownerOf(grant db.AccessGrant, tag string) string {
+	if tag == "" {
+		return ""
+	}
+	if owner := grant.Tags[tag]; owner != "" {
+		return owner
+	}
+	return grant.Labels[tag]
+}
+
+func isReviewer(ctx context.Context, item db.AccessCampaignItem, personID uuid.UUID) (bool, error) {
+	if slices.Contains(item.ReviewerPersonIDs, personID.String()) {
+		return true, nil
+	}
+
+	teamIDs, err := teamsOf(ctx, personID)
+	if err != nil {
+		return false, err
+	}
+	return lo.SomeBy(teamIDs, func(id uuid.UUID) bool { return slices.Contains(item.ReviewerTeamIDs, id.String()) }), nil
+}
+
+func teamsOf(ctx context.Context, personID uuid.UUID) ([]uuid.UUID, error) {
+	teams, err := db.GetTeamsForUser(ctx, personID.String())
+	if err != nil {
+		return nil, ctx.Oops().Wrapf(err, "failed to get teams of %s", personID)
+	}
+	return lo.Map(teams, func(t models.Team, _ int) uuid.UUID { return t.ID }), nil
+}
+
+// recordReview marks the grant as reviewed and, when the grant came from a
+// scraper, records the access review alongside the scraped ones.
+func recordReview(ctx context.Context, item db.AccessCampaignItem) error {
+	if err := ctx.DB().Model(&models.ConfigAccess{}).
+		Where("id = ?", item.ConfigAccessID).
+		Updates(map[string]any{"last_reviewed_at": item.DecidedAt, "last_reviewed_by": item.DecidedBy}).Error; err != nil {
+		return ctx.Oops().Wrapf(err, "failed to mark access %s as reviewed", item.ConfigAccessID)
+	}
+
+	if item.ScraperID == nil || item.ExternalRoleID == nil {
+		return nil
+	}
+
+	review := models.AccessReview{
+		ID:              uuid.New(),
+		ScraperID:       *item.ScraperID,
+		ConfigID:        item.ConfigID,
+		ExternalUserID:  item.ExternalUserID,
+		ExternalGroupID: item.ExternalGroupID,
+		ExternalRoleID:  *item.ExternalRoleID,
+		CreatedBy:       item.DecidedBy,
+		Source:          Source,
+	}
+	if err := ctx.DB().Create(&review).Error; err != nil {
+		return ctx.Oops().Wrapf(err, "failed to record access review of %s", item.ConfigAccessID)
+	}
+	return nil
+}
+
+// revoke runs the revocation playbook on the config of the item. The run is
+// made by the system user, since the campaign authorizes the revocation
+// rather than the reviewer.
+func revoke(ctx context.Context, campaign db.AccessCampaign, ref RevocationPlaybook, item db.AccessCampaignItem) (*models.PlaybookRun, error) {
+	pb, err := findPlaybook(ctx, ref.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	params := playbook.PlaybookRuntimeParameters{
+		"user":             item.User,
+		"email":            item.Email,
+		"role":             item.Role,
+		"config_access_id": item.ConfigAccessID,
+		"campaign":         campaign.Name,
+		"comment":          item.Comment,
+	}
+	for k, v := range ref.Params {
+		params[k] = v
+	}
+
+	runCtx := ctx
+	if api.SystemUserID != nil {
+		runCtx = ctx.WithUser(&models.Person{ID: *api.SystemUserID})
+	}
+
+	run, err := playbook.Run(runCtx, pb, playbook.RunParams{ConfigID: &item.ConfigID, Params: params})
+	if err != nil {
+		return nil, fmt.Errorf("failed to run revocation playbook %s: %w", ref.Name, err)
+	}
+	return run, nil
+}
+
+func findPlaybook(ctx context.Context, ref string) (*models.Playbook, error) {
+	var playbooks []models.Playbook
+	q := ctx.DB().Where("deleted_at IS NULL")
+	if namespace, name, ok := strings.Cut(ref, "/"); ok {
+		q = q.Where("namespace = ? AND name = ?", namespace, name)
+	} else {
+		q = q.Where("name = ?", ref)
+	}
+	if err := q.Limit(1).Find(&playbooks).Error; err != nil {
+		return nil, ctx.Oops().Wrapf(err, "failed to get playbook %s", ref)
+	}
+	if len(playbooks) == 0 {
+		return nil, dutyAPI.Errorf(dutyAPI.EINVALID, "revocation playbook %s not found", ref)
+	}
+	return &playbooks[0], nil
+}
+
+func reminderInterval(spec Spec) (time.Duration, error) {
+	if spec.ReminderInterval == "" {
+		return defaultReminderInterval, nil
+	}
+
+	d, err := duration.ParseDuration(spec.ReminderInterval)
+	if err != nil || d <= 0 {
+		return 0, dutyAPI.Errorf(dutyAPI.EINVALID, "invalid reminder interval %q", spec.ReminderInterval)
+	}
+	return time.Duration(d), nil
+}
+
+func find(ctx context.Context, id uuid.UUID) (*db.AccessCampaign, error) {
+	campaign, err := db.GetAccessCampaign(ctx, id)
+	if err != nil {
+		return nil, err
+	} else if campaign == nil {
+		return nil, dutyAPI.Errorf(dutyAPI.ENOTFOUND, "access campaign %s not found", id)
+	}
+	return campaign, nil
+}
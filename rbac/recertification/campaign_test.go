@@ -0,0 +1,150 @@
+package recertification
+
+import (
+	"time"
+
+	dutyAPI "github.com/flanksource/duty/api"
+	"github.com/flanksource/duty/models"
+	"github.com/flanksource/duty/tests/fixtures/dummy"
+	"github.com/flanksource/duty/types"
+	ginkgo "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/flanksource/incident-commander/db"
+)
+
+var _ = ginkgo.Describe("Access campaign", ginkgo.Ordered, func() {
+	var campaign *db.AccessCampaign
+	var items []db.AccessCampaignItem
+
+	reviewer := DefaultContext
+	bystander := DefaultContext
+
+	ginkgo.BeforeAll(func() {
+		reviewer = DefaultContext.WithUser(&dummy.JohnDoe)
+		bystander = DefaultContext.WithUser(&dummy.JohnWick)
+	})
+
+	spec := func() Spec {
+		return Spec{
+			Name:      "rds-quarterly",
+			Configs:   []types.ResourceSelector{{ID: dummy.RDSInstance.ID.String()}},
+			Reviewers: Reviewers{OwnerTag: "owner", People: []string{dummy.JohnDoe.Email}},
+			DueDate:   time.Now().Add(7 * 24 * time.Hour),
+		}
+	}
+
+	ginkgo.It("should validate the spec", func() {
+		invalid := spec()
+		invalid.DueDate = time.Now().Add(-time.Hour)
+		_, err := Open(reviewer, invalid)
+		Expect(dutyAPI.ErrorCode(err)).To(Equal(dutyAPI.EINVALID))
+
+		invalid = spec()
+		invalid.Reviewers = Reviewers{}
+		_, err = Open(reviewer, invalid)
+		Expect(dutyAPI.ErrorCode(err)).To(Equal(dutyAPI.EINVALID))
+
+		invalid = spec()
+		invalid.Reviewers.People = []string{"nobody@example.com"}
+		_, err = Open(reviewer, invalid)
+		Expect(dutyAPI.ErrorCode(err)).To(Equal(dutyAPI.EINVALID))
+	})
+
+	ginkgo.It("should snapshot the access to the configs", func() {
+		var err error
+		campaign, err = Open(reviewer, spec())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(campaign.Status).To(Equal(db.AccessCampaignStatusOpen))
+
+		items, err = db.GetAccessCampaignItems(DefaultContext, campaign.ID)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(items).ToNot(BeEmpty())
+
+		accessIDs := make([]string, 0, len(items))
+		for _, item := range items {
+			Expect(item.Decision).To(Equal(db.AccessDecisionPending))
+			Expect([]string(item.ReviewerPersonIDs)).To(ContainElement(dummy.JohnDoe.ID.String()))
+			accessIDs = append(accessIDs, item.ConfigAccessID)
+		}
+		Expect(accessIDs).To(ContainElements(dummy.AliceRDSAccess.ID, dummy.BobRDSAccess.ID))
+	})
+
+	ginkgo.It("should list the tasks of the reviewer only", func() {
+		tasks, err := Tasks(reviewer)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(tasks).To(HaveLen(len(items)))
+
+		tasks, err = Tasks(bystander)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(tasks).To(BeEmpty())
+	})
+
+	ginkgo.It("should only accept decisions of reviewers", func() {
+		_, err := Decide(bystander, items[0].ID, Decision{Decision: db.AccessDecisionApproved})
+		Expect(dutyAPI.ErrorCode(err)).To(Equal(dutyAPI.EFORBIDDEN))
+
+		_, err = Decide(reviewer, items[0].ID, Decision{Decision: "maybe"})
+		Expect(dutyAPI.ErrorCode(err)).To(Equal(dutyAPI.EINVALID))
+	})
+
+	ginkgo.It("should not sign off with pending decisions", func() {
+		_, err := SignOff(reviewer, campaign.ID)
+		Expect(dutyAPI.ErrorCode(err)).To(Equal(dutyAPI.ECONFLICT))
+	})
+
+	ginkgo.It("should approve and revoke access", func() {
+		for i, item := range items {
+			decision := Decision{Decision: db.AccessDecisionApproved}
+			if item.ConfigAccessID == dummy.BobRDSAccess.ID {
+				decision = Decision{Decision: db.AccessDecisionRevoked, Comment: "left the team"}
+			}
+
+			decided, err := Decide(reviewer, item.ID, decision)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(decided.Decision).To(Equal(decision.Decision))
+			Expect(decided.DecidedBy).To(Equal(&dummy.JohnDoe.ID))
+			items[i] = *decided
+		}
+
+		_, err := Decide(reviewer, items[0].ID, Decision{Decision: db.AccessDecisionRevoked})
+		Expect(dutyAPI.ErrorCode(err)).To(Equal(dutyAPI.ECONFLICT))
+
+		// A reviewer that read the item while it was pending loses the claim
+		stale := items[0]
+		stale.Decision = db.AccessDecisionRevoked
+		ok, err := db.DecideAccessCampaignItem(DefaultContext, &stale)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ok).To(BeFalse())
+
+		var access models.ConfigAccess
+		Expect(DefaultContext.DB().Where("id = ?", dummy.AliceRDSAccess.ID).First(&access).Error).To(Succeed())
+		Expect(access.LastReviewedBy).To(Equal(&dummy.JohnDoe.ID))
+	})
+
+	ginkgo.It("should sign off with a digest of the decisions", func() {
+		signed, err := SignOff(reviewer, campaign.ID)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(signed.Status).To(Equal(db.AccessCampaignStatusSignedOff))
+
+		stored, err := db.GetAccessCampaignItems(DefaultContext, campaign.ID)
+		Expect(err).ToNot(HaveOccurred())
+		digest, err := Digest(stored)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(signed.Digest).To(Equal(digest))
+
+		_, err = SignOff(reviewer, campaign.ID)
+		Expect(dutyAPI.ErrorCode(err)).To(Equal(dutyAPI.ECONFLICT))
+	})
+
+	ginkgo.It("should report the decisions", func() {
+		r, err := BuildReport(DefaultContext, campaign.ID)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(r.Summary.Total).To(Equal(len(items)))
+		Expect(r.Summary.Revoked).To(Equal(1))
+		Expect(r.Summary.Pending).To(BeZero())
+		Expect(r.Campaign.SignedOffBy).To(Equal(dummy.JohnDoe.Email))
+		Expect(r.Reviewers).To(HaveLen(1))
+		Expect(r.Reviewers[0].Name).To(Equal(dummy.JohnDoe.Email))
+	})
+})
@@ -0,0 +1,186 @@
+package recertification
+
+import (
+	"mime"
+	"net/http"
+	"time"
+
+	"github.com/flanksource/commons/logger"
+	dutyAPI "github.com/flanksource/duty/api"
+	"github.com/flanksource/duty/context"
+	"github.com/flanksource/duty/rbac/policy"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+
+	"github.com/flanksource/incident-commander/db"
+	echoSrv "github.com/flanksource/incident-commander/echo"
+	"github.com/flanksource/incident-commander/rbac"
+)
+
+func init() {
+	echoSrv.RegisterRoutes(RegisterRoutes)
+}
+
+func RegisterRoutes(e *echo.Echo) {
+	logger.Infof("Registering /access-campaigns routes")
+
+	g := e.Group("/access-campaigns")
+	g.GET("", ListCampaigns, rbac.Authorization(policy.ObjectRBAC, policy.ActionRead))
+	g.POST("", OpenCampaign, rbac.Authorization(policy.ObjectRBAC, policy.ActionUpdate))
+	// Reviewers need not hold any RBAC permission, the handlers check that the
+	// user reviews the items, so only the scope of access tokens is checked.
+	g.GET("/tasks", ListTasks, rbac.RequireTokenScope(policy.ObjectRBAC, policy.ActionRead))
+	g.POST("/items/:id/decision", DecideItem, rbac.RequireTokenScope(policy.ObjectRBAC, policy.ActionUpdate))
+	g.GET("/:id", GetCampaign, rbac.Authorization(policy.ObjectRBAC, policy.ActionRead))
+	g.POST("/:id/signoff", SignOffCampaign, rbac.Authorization(policy.ObjectRBAC, policy.ActionUpdate))
+	g.GET("/:id/report", CampaignReport, rbac.Authorization(policy.ObjectRBAC, policy.ActionRead))
+}
+
+func OpenCampaign(c echo.Context) error {
+	ctx := c.Request().Context().(context.Context)
+
+	var spec Spec
+	if err := c.Bind(&spec); err != nil {
+		return dutyAPI.WriteError(c, dutyAPI.Errorf(dutyAPI.EINVALID, "invalid request body: %v", err))
+	}
+
+	campaign, err := Open(ctx, spec)
+	if err != nil {
+		return dutyAPI.WriteError(c, err)
+	}
+
+	if _, err := Remind(ctx, *campaign, true); err != nil {
+		ctx.Warnf("failed to notify the reviewers of access campaign %s: %v", campaign.Name, err)
+	}
+	return c.JSON(http.StatusCreated, dutyAPI.HTTPSuccess{Message: "access campaign opened", Payload: campaign})
+}
+
+func ListCampaigns(c echo.Context) error {
+	ctx := c.Request().Context().(context.Context)
+
+	var statuses []string
+	if status := c.QueryParam("status"); status != "" {
+		statuses = append(statuses, status)
+	}
+
+	campaigns, err := db.ListAccessCampaigns(ctx, statuses...)
+	if err != nil {
+		return dutyAPI.WriteError(c, err)
+	}
+	return c.JSON(http.StatusOK, dutyAPI.HTTPSuccess{Payload: campaigns})
+}
+
+func GetCampaign(c echo.Context) error {
+	ctx := c.Request().Context().(context.Context)
+
+	id, err := parseID(c)
+	if err != nil {
+		return dutyAPI.WriteError(c, err)
+	}
+
+	campaign, err := find(ctx, id)
+	if err != nil {
+		return dutyAPI.WriteError(c, err)
+	}
+
+	items, err := db.GetAccessCampaignItems(ctx, id)
+	if err != nil {
+		return dutyAPI.WriteError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, dutyAPI.HTTPSuccess{Payload: map[string]any{
+		"campaign": campaign,
+		"items":    items,
+	}})
+}
+
+// ListTasks lists the pending decisions of the current user.
+func ListTasks(c echo.Context) error {
+	ctx := c.Request().Context().(context.Context)
+
+	items, err := Tasks(ctx)
+	if err != nil {
+		return dutyAPI.WriteError(c, err)
+	}
+	return c.JSON(http.StatusOK, dutyAPI.HTTPSuccess{Payload: items})
+}
+
+func DecideItem(c echo.Context) error {
+	ctx := c.Request().Context().(context.Context)
+
+	id, err := parseID(c)
+	if err != nil {
+		return dutyAPI.WriteError(c, err)
+	}
+
+	var decision Decision
+	if err := c.Bind(&decision); err != nil {
+		return dutyAPI.WriteError(c, dutyAPI.Errorf(dutyAPI.EINVALID, "invalid request body: %v", err))
+	}
+
+	item, err := Decide(ctx, id, decision)
+	if err != nil {
+		return dutyAPI.WriteError(c, err)
+	}
+	return c.JSON(http.StatusOK, dutyAPI.HTTPSuccess{Message: "access " + item.Decision, Payload: item})
+}
+
+func SignOffCampaign(c echo.Context) error {
+	ctx := c.Request().Context().(context.Context)
+
+	id, err := parseID(c)
+	if err != nil {
+		return dutyAPI.WriteError(c, err)
+	}
+
+	campaign, err := SignOff(ctx, id)
+	if err != nil {
+		return dutyAPI.WriteError(c, err)
+	}
+	return c.JSON(http.StatusOK, dutyAPI.HTTPSuccess{Message: "access campaign signed off", Payload: campaign})
+}
+
+// CampaignReport returns the report of a campaign as json, facet-html or
+// facet-pdf (the default).
+func CampaignReport(c echo.Context) error {
+	ctx := c.Request().Context().(context.Context)
+
+	id, err := parseID(c)
+	if err != nil {
+		return dutyAPI.WriteError(c, err)
+	}
+
+	r, err := BuildReport(ctx, id)
+	if err != nil {
+		return dutyAPI.WriteError(c, err)
+	}
+
+	var format, contentType, extension string
+	switch c.QueryParam("format") {
+	case "json":
+		return c.JSON(http.StatusOK, r)
+	case "html", "facet-html":
+		format, contentType, extension = "html", "text/html; charset=utf-8", "html"
+	case "", "pdf", "facet-pdf":
+		format, contentType, extension = "pdf", "application/pdf", "pdf"
+	default:
+		return dutyAPI.WriteError(c, dutyAPI.Errorf(dutyAPI.EINVALID, "invalid format %q", c.QueryParam("format")))
+	}
+
+	data, err := RenderFacet(ctx, r, format)
+	if err != nil {
+		return dutyAPI.WriteError(c, err)
+	}
+
+	filename := "access-campaign-" + r.Campaign.Name + "-" + time.Now().Format("2006-01-02") + "." + extension
+	c.Response().Header().Set(echo.HeaderContentDisposition, mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+	return c.Blob(http.StatusOK, contentType, data)
+}
+
+func parseID(c echo.Context) (uuid.UUID, error) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return uuid.Nil, dutyAPI.Errorf(dutyAPI.EINVALID, "invalid id %q", c.Param("id"))
+	}
+	return id, nil
+}
@@ -0,0 +1,127 @@
+package recertification
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/flanksource/duty/context"
+	"github.com/flanksource/duty/job"
+	"github.com/google/uuid"
+
+	v1 "github.com/flanksource/incident-commander/api/v1"
+	"github.com/flanksource/incident-commander/db"
+	"github.com/flanksource/incident-commander/notification"
+)
+
+// RemindReviewers reminds the reviewers of open campaigns of their pending
+// decisions, once every reminder interval of the campaign.
+func RemindReviewers(ctx context.Context) *job.Job {
+	return &job.Job{
+		Name:       "RemindAccessReviewers",
+		Schedule:   "@every 15m",
+		Context:    ctx,
+		Singleton:  true,
+		Retention:  job.RetentionFailed,
+		JobHistory: true,
+		RunNow:     true,
+		Fn: func(run job.JobRuntime) error {
+			campaigns, err := db.ListAccessCampaigns(run.Context, db.AccessCampaignStatusOpen)
+			if err != nil {
+				return err
+			}
+
+			for _, campaign := range campaigns {
+				if sent, err := Remind(run.Context, campaign, false); err != nil {
+					run.History.AddErrorf("campaign %s: %v", campaign.Name, err)
+				} else {
+					run.History.SuccessCount += sent
+				}
+			}
+			return nil
+		},
+	}
+}
+
+// Remind notifies every reviewer with pending decisions in the campaign and
+// returns the number of reminders sent. Unless forced, reviewers are reminded
+// at most once per reminder interval.
+func Remind(ctx context.Context, campaign db.AccessCampaign, force bool) (int, error) {
+	var spec Spec
+	if err := json.Unmarshal(campaign.Spec, &spec); err != nil {
+		return 0, ctx.Oops().Wrapf(err, "invalid spec of access campaign %s", campaign.ID)
+	}
+
+	interval, err := reminderInterval(spec)
+	if err != nil {
+		return 0, err
+	}
+	if !force && campaign.LastRemindedAt != nil && time.Since(*campaign.LastRemindedAt) < interval {
+		return 0, nil
+	}
+
+	items, err := db.GetAccessCampaignItems(ctx, campaign.ID)
+	if err != nil {
+		return 0, err
+	}
+
+	pending := map[string]int{}
+	for _, item := range items {
+		if item.Decision != db.AccessDecisionPending {
+			continue
+		}
+		for _, id := range item.ReviewerPersonIDs {
+			pending["person/"+id]++
+		}
+		for _, id := range item.ReviewerTeamIDs {
+			pending["team/"+id]++
+		}
+	}
+
+	var sent int
+	var errs []error
+	for key, count := range pending {
+		kind, id, _ := strings.Cut(key, "/")
+
+		var recipient v1.NotificationRecipientSpec
+		if kind == "person" {
+			recipient.Person = id
+		} else {
+			recipient.Team = id
+		}
+
+		celEnv := map[string]any{
+			"campaign": map[string]any{"id": campaign.ID.String(), "name": campaign.Name, "due_at": campaign.DueAt},
+			"pending":  count,
+		}
+		if err := notification.SendToRecipient(notification.NewContext(ctx, uuid.Nil), recipient, celEnv, reminder(campaign, count)); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", key, err))
+			continue
+		}
+		sent++
+	}
+
+	if err := ctx.DB().Model(&campaign).Update("last_reminded_at", time.Now()).Error; err != nil {
+		return sent, ctx.Oops().Wrapf(err, "failed to update reminder time of access campaign %s", campaign.ID)
+	}
+	if len(errs) > 0 {
+		return sent, fmt.Errorf("failed to remind %d reviewers: %v", len(errs), errs)
+	}
+	return sent, nil
+}
+
+func reminder(campaign db.AccessCampaign, pending int) notification.NotificationTemplate {
+	due := fmt.Sprintf("due %s", campaign.DueAt.Format(time.RFC1123))
+	if campaign.Overdue() {
+		due = fmt.Sprintf("overdue since %s", campaign.DueAt.Format(time.RFC1123))
+	}
+
+	return notification.NotificationTemplate{
+		Title:   fmt.Sprintf("Access review: %s", campaign.Name),
+		Message: fmt.Sprintf("You have %d access grants to approve or revoke in the campaign %s, %s.", pending, campaign.Name, due),
+		Properties: map[string]string{
+			"campaign": campaign.ID.String(),
+		},
+	}
+}
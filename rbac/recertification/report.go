@@ -0,0 +1,169 @@
+package recertification
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/flanksource/duty/context"
+	"github.com/flanksource/duty/models"
+	"github.com/google/uuid"
+	"github.com/samber/lo"
+
+	"github.com/flanksource/incident-commander/api"
+	"github.com/flanksource/incident-commander/db"
+	"github.com/flanksource/incident-commander/report"
+)
+
+// BuildReport returns the report of the decisions of a campaign.
+func BuildReport(ctx context.Context, id uuid.UUID) (*api.AccessCampaignReport, error) {
+	campaign, err := find(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := db.GetAccessCampaignItems(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	names := newNameCache(ctx)
+	r := api.AccessCampaignReport{
+		Title:       fmt.Sprintf("Access Review: %s", campaign.Name),
+		GeneratedAt: time.Now(),
+		Campaign: api.AccessCampaignInfo{
+			ID:          campaign.ID.String(),
+			Name:        campaign.Name,
+			Description: campaign.Description,
+			Status:      campaign.Status,
+			CreatedAt:   campaign.CreatedAt,
+			DueAt:       campaign.DueAt,
+			Overdue:     campaign.Overdue(),
+			SignedOffAt: campaign.SignedOffAt,
+			Digest:      campaign.Digest,
+		},
+		Reviewers: []api.AccessCampaignReviewer{},
+		Decisions: make([]api.AccessCampaignDecision, 0, len(items)),
+	}
+	if campaign.CreatedBy != nil {
+		r.Campaign.CreatedBy = names.person(*campaign.CreatedBy)
+	}
+	if campaign.SignedOffBy != nil {
+		r.Campaign.SignedOffBy = names.person(*campaign.SignedOffBy)
+	}
+
+	reviewers := map[string]*api.AccessCampaignReviewer{}
+	var order []string
+	tally := func(name, kind, decision string) {
+		key := kind + "/" + name
+		if _, ok := reviewers[key]; !ok {
+			reviewers[key] = &api.AccessCampaignReviewer{Name: name, Type: kind}
+			order = append(order, key)
+		}
+		rv := reviewers[key]
+		rv.Total++
+		switch decision {
+		case db.AccessDecisionApproved:
+			rv.Approved++
+		case db.AccessDecisionRevoked:
+			rv.Revoked++
+		default:
+			rv.Pending++
+		}
+	}
+
+	for _, item := range items {
+		decision := api.AccessCampaignDecision{
+			ConfigID:   item.ConfigID.String(),
+			ConfigName: item.ConfigName,
+			ConfigType: item.ConfigType,
+			User:       item.User,
+			Email:      item.Email,
+			Role:       item.Role,
+			Reviewers:  []string{},
+			Decision:   item.Decision,
+			DecidedAt:  item.DecidedAt,
+			Comment:    item.Comment,
+			Error:      item.Error,
+		}
+		if item.DecidedBy != nil {
+			decision.DecidedBy = names.person(*item.DecidedBy)
+		}
+		for _, id := range item.ReviewerPersonIDs {
+			name := names.person(uuid.MustParse(id))
+			decision.Reviewers = append(decision.Reviewers, name)
+			tally(name, "person", item.Decision)
+		}
+		for _, id := range item.ReviewerTeamIDs {
+			name := names.team(uuid.MustParse(id))
+			decision.Reviewers = append(decision.Reviewers, name)
+			tally(name, "team", item.Decision)
+		}
+		r.Decisions = append(r.Decisions, decision)
+
+		r.Summary.Total++
+		switch item.Decision {
+		case db.AccessDecisionApproved:
+			r.Summary.Approved++
+		case db.AccessDecisionRevoked:
+			r.Summary.Revoked++
+		default:
+			r.Summary.Pending++
+		}
+		if item.Error != "" {
+			r.Summary.Failed++
+		}
+	}
+	r.Summary.Configs = len(lo.UniqBy(items, func(i db.AccessCampaignItem) uuid.UUID { return i.ConfigID }))
+
+	for _, key := range order {
+		r.Reviewers = append(r.Reviewers, *reviewers[key])
+	}
+	return &r, nil
+}
+
+// RenderFacet renders the report of a campaign as html or pdf.
+func RenderFacet(ctx context.Context, r *api.AccessCampaignReport, format string) ([]byte, error) {
+	result, err := report.Render(ctx, r, format, "AccessCampaignReport.tsx", "", nil)
+	if err != nil {
+		return nil, ctx.Oops().Wrapf(err, "failed to render access campaign %s report", format)
+	}
+	return result.Data, nil
+}
+
+type nameCache struct {
+	ctx    context.Context
+	people map[uuid.UUID]string
+	teams  map[uuid.UUID]string
+}
+
+func newNameCache(ctx context.Context) *nameCache {
+	return &nameCache{ctx: ctx, people: map[uuid.UUID]string{}, teams: map[uuid.UUID]string{}}
+}
+
+func (t *nameCache) person(id uuid.UUID) string {
+	if name, ok := t.people[id]; ok {
+		return name
+	}
+
+	name := id.String()
+	var person models.Person
+	if err := t.ctx.DB().Select("name", "email").Where("id = ?", id).Limit(1).Find(&person).Error; err == nil {
+		name = lo.CoalesceOrEmpty(person.Email, person.Name, name)
+	}
+	t.people[id] = name
+	return name
+}
+
+func (t *nameCache) team(id uuid.UUID) string {
+	if name, ok := t.teams[id]; ok {
+		return name
+	}
+
+	name := id.String()
+	var team models.Team
+	if err := t.ctx.DB().Select("name").Where("id = ?", id).Limit(1).Find(&team).Error; err == nil && team.Name != "" {
+		name = team.Name
+	}
+	t.teams[id] = name
+	return name
+}
@@ -0,0 +1,52 @@
+package simulate
+
+import (
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/flanksource/commons/logger"
+	dutyAPI "github.com/flanksource/duty/api"
+	"github.com/flanksource/duty/context"
+	"github.com/flanksource/duty/rbac/policy"
+	"github.com/labstack/echo/v4"
+
+	echoSrv "github.com/flanksource/incident-commander/echo"
+	"github.com/flanksource/incident-commander/rbac"
+)
+
+func init() {
+	echoSrv.RegisterRoutes(RegisterRoutes)
+}
+
+func RegisterRoutes(e *echo.Echo) {
+	logger.Infof("Registering /rbac/simulate routes")
+
+	e.POST("/rbac/simulate", SimulateHandler, rbac.Authorization(policy.ObjectRBAC, policy.ActionRead))
+}
+
+// SimulateHandler accepts either a JSON Request or, with a YAML content type,
+// the manifests of the objects to simulate.
+func SimulateHandler(c echo.Context) error {
+	ctx := c.Request().Context().(context.Context)
+
+	var req Request
+	if strings.Contains(c.Request().Header.Get(echo.HeaderContentType), "yaml") {
+		body, err := io.ReadAll(c.Request().Body)
+		if err != nil {
+			return dutyAPI.WriteError(c, dutyAPI.Errorf(dutyAPI.EINVALID, "failed to read request body: %v", err))
+		}
+		if req, err = ParseManifests(body); err != nil {
+			return dutyAPI.WriteError(c, dutyAPI.Errorf(dutyAPI.EINVALID, "invalid manifests: %v", err))
+		}
+	} else if err := c.Bind(&req); err != nil {
+		return dutyAPI.WriteError(c, dutyAPI.Errorf(dutyAPI.EINVALID, "invalid request body: %v", err))
+	}
+
+	result, err := Simulate(ctx, req)
+	if err != nil {
+		return dutyAPI.WriteError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, dutyAPI.HTTPSuccess{Payload: result})
+}
@@ -0,0 +1,443 @@
+// Package simulate evaluates proposed Permission, PermissionGroup and Scope
+// objects alongside the existing ones, without persisting them, and reports
+// the access they would add and take away.
+package simulate
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/casbin/casbin/v2"
+	"github.com/casbin/casbin/v2/model"
+	dutyAPI "github.com/flanksource/duty/api"
+	"github.com/flanksource/duty/context"
+	"github.com/flanksource/duty/models"
+	dutyRBAC "github.com/flanksource/duty/rbac"
+	"github.com/flanksource/duty/rbac/policy"
+	"github.com/flanksource/duty/rls"
+	"github.com/google/uuid"
+	"github.com/samber/lo"
+	"go.opentelemetry.io/otel/trace"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8sTypes "k8s.io/apimachinery/pkg/types"
+
+	v1 "github.com/flanksource/incident-commander/api/v1"
+	"github.com/flanksource/incident-commander/auth"
+	"github.com/flanksource/incident-commander/db"
+	"github.com/flanksource/incident-commander/rbac/adapter"
+	"github.com/flanksource/incident-commander/utils"
+)
+
+// errRollback rolls back the transaction the proposed objects are saved in.
+var errRollback = errors.New("rollback simulation")
+
+// Request holds the objects to simulate. Objects with the name and namespace
+// of an existing one replace it.
+type Request struct {
+	Permissions      []v1.Permission      `json:"permissions,omitempty"`
+	PermissionGroups []v1.PermissionGroup `json:"permissionGroups,omitempty"`
+	Scopes           []v1.Scope           `json:"scopes,omitempty"`
+}
+
+func (r Request) Empty() bool {
+	return len(r.Permissions) == 0 && len(r.PermissionGroups) == 0 && len(r.Scopes) == 0
+}
+
+// ParseManifests reads the Permission, PermissionGroup and Scope objects from
+// a multi-document YAML or JSON manifest. Other kinds are ignored, and objects
+// without a namespace are put in the default one.
+func ParseManifests(data []byte) (Request, error) {
+	var req Request
+
+	objects, err := utils.GetUnstructuredObjects(data)
+	if err != nil {
+		return req, err
+	}
+
+	for _, obj := range objects {
+		if obj.GetNamespace() == "" {
+			obj.SetNamespace("default")
+		}
+
+		var target any
+		switch obj.GetKind() {
+		case "Permission":
+			req.Permissions = append(req.Permissions, v1.Permission{})
+			target = &req.Permissions[len(req.Permissions)-1]
+		case "PermissionGroup":
+			req.PermissionGroups = append(req.PermissionGroups, v1.PermissionGroup{})
+			target = &req.PermissionGroups[len(req.PermissionGroups)-1]
+		case "Scope":
+			req.Scopes = append(req.Scopes, v1.Scope{})
+			target = &req.Scopes[len(req.Scopes)-1]
+		default:
+			continue
+		}
+
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, target); err != nil {
+			return req, fmt.Errorf("failed to parse %s %s/%s: %w", obj.GetKind(), obj.GetNamespace(), obj.GetName(), err)
+		}
+	}
+
+	return req, nil
+}
+
+// Access is a subject being allowed or denied an action on an object.
+type Access struct {
+	Subject string `json:"subject"`
+	// Name is the name or email of the person or team the subject is.
+	Name   string `json:"name,omitempty"`
+	Object string `json:"object"`
+	Action string `json:"action"`
+	Effect string `json:"effect"`
+	// Condition is the resource selector of ABAC policies.
+	Condition string `json:"condition,omitempty"`
+}
+
+func (a Access) key() string {
+	return strings.Join([]string{a.Subject, a.Object, a.Action, a.Effect, a.Condition}, "\x00")
+}
+
+// RLSScope is a row level security scope on a resource type, or with Resource
+// "scope" the id of a Scope granting access to generated view rows.
+type RLSScope struct {
+	Resource string `json:"resource"`
+	rls.Scope
+}
+
+// RLSChange is the change to the RLS payload of a person.
+type RLSChange struct {
+	Subject string       `json:"subject"`
+	Name    string       `json:"name,omitempty"`
+	Before  *rls.Payload `json:"before"`
+	After   *rls.Payload `json:"after"`
+	Gained  []RLSScope   `json:"gained,omitempty"`
+	Lost    []RLSScope   `json:"lost,omitempty"`
+}
+
+type Result struct {
+	Gained []Access    `json:"gained"`
+	Lost   []Access    `json:"lost"`
+	RLS    []RLSChange `json:"rls"`
+}
+
+// Simulate saves the proposed objects in a transaction that's rolled back,
+// and diffs the effective access before and after.
+func Simulate(ctx context.Context, req Request) (*Result, error) {
+	if req.Empty() {
+		return nil, dutyAPI.Errorf(dutyAPI.EINVALID, "no Permission, PermissionGroup or Scope to simulate")
+	}
+
+	before, err := loadEnforcer(ctx)
+	if err != nil {
+		return nil, err
+	}
+	beforeAccess, err := effectiveAccess(before)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		afterAccess map[string]Access
+		candidates  []string
+		afterRLS    = map[string]*rls.Payload{}
+	)
+	err = ctx.Transaction(func(txCtx context.Context, _ trace.Span) error {
+		if err := persist(txCtx, req); err != nil {
+			return err
+		}
+
+		after, err := loadEnforcer(txCtx)
+		if err != nil {
+			return err
+		}
+		if afterAccess, err = effectiveAccess(after); err != nil {
+			return err
+		}
+
+		if candidates, err = people(txCtx, lo.Uniq(append(subjects(beforeAccess), subjects(afterAccess)...))); err != nil {
+			return err
+		}
+		for _, personID := range candidates {
+			if afterRLS[personID], err = rlsPayload(txCtx, after, personID); err != nil {
+				return err
+			}
+		}
+
+		return errRollback
+	})
+	if err != nil && !errors.Is(err, errRollback) {
+		return nil, err
+	}
+
+	result := &Result{
+		Gained: diff(afterAccess, beforeAccess),
+		Lost:   diff(beforeAccess, afterAccess),
+	}
+
+	for _, personID := range candidates {
+		beforeRLS, err := rlsPayload(ctx, before, personID)
+		if err != nil {
+			return nil, err
+		}
+
+		change := RLSChange{
+			Subject: personID,
+			Before:  beforeRLS,
+			After:   afterRLS[personID],
+			Gained:  diffScopes(afterRLS[personID], beforeRLS),
+			Lost:    diffScopes(beforeRLS, afterRLS[personID]),
+		}
+		if len(change.Gained) > 0 || len(change.Lost) > 0 || change.Before.Disable != change.After.Disable {
+			result.RLS = append(result.RLS, change)
+		}
+	}
+
+	if err := result.resolveNames(ctx); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// persist saves the proposed objects the way their controllers do. Objects
+// replacing an existing one keep its id.
+func persist(ctx context.Context, req Request) error {
+	for _, scope := range req.Scopes {
+		uid, err := existingUID(ctx, &models.Scope{}, scope.Namespace, scope.Name)
+		if err != nil {
+			return err
+		}
+		scope.UID = uid
+		if err := db.PersistScopeFromCRD(ctx, &scope); err != nil {
+			return ctx.Oops().Wrapf(err, "failed to save scope %s/%s", scope.Namespace, scope.Name)
+		}
+	}
+
+	for _, group := range req.PermissionGroups {
+		uid, err := existingUID(ctx, &models.PermissionGroup{}, group.Namespace, group.Name)
+		if err != nil {
+			return err
+		}
+		group.UID = uid
+		if err := db.PersistPermissionGroupFromCRD(ctx, &group); err != nil {
+			return ctx.Oops().Wrapf(err, "failed to save permission group %s/%s", group.Namespace, group.Name)
+		}
+	}
+
+	for _, permission := range req.Permissions {
+		uid, err := existingUID(ctx, &models.Permission{}, permission.Namespace, permission.Name)
+		if err != nil {
+			return err
+		}
+		permission.UID = uid
+		if err := db.PersistPermissionFromCRD(ctx, &permission); err != nil {
+			return ctx.Oops().Wrapf(err, "failed to save permission %s/%s", permission.Namespace, permission.Name)
+		}
+	}
+
+	return nil
+}
+
+func existingUID(ctx context.Context, model any, namespace, name string) (k8sTypes.UID, error) {
+	var ids []string
+	if err := ctx.DB().Model(model).Select("id").
+		Where("namespace = ? AND name = ? AND deleted_at IS NULL", namespace, name).
+		Limit(1).Find(&ids).Error; err != nil {
+		return "", ctx.Oops().Wrapf(err, "failed to look up %s/%s", namespace, name)
+	}
+	if len(ids) == 0 {
+		return k8sTypes.UID(uuid.NewString()), nil
+	}
+	return k8sTypes.UID(ids[0]), nil
+}
+
+// loadEnforcer loads the policies as seen by the context's database into an
+// enforcer of its own, leaving the global one untouched.
+func loadEnforcer(ctx context.Context) (*casbin.Enforcer, error) {
+	m, err := model.NewModelFromString(dutyRBAC.DefaultModel)
+	if err != nil {
+		return nil, ctx.Oops().Wrapf(err, "failed to create rbac model")
+	}
+
+	enforcer, err := casbin.NewEnforcer(m, adapter.NewPermissionAdapter(ctx, nil))
+	if err != nil {
+		return nil, ctx.Oops().Wrapf(err, "failed to load policies")
+	}
+	return enforcer, nil
+}
+
+// effectiveAccess expands every policy to the subject it's granted to and to
+// everyone inheriting it through roles and permission groups.
+func effectiveAccess(enforcer *casbin.Enforcer) (map[string]Access, error) {
+	policies, err := enforcer.GetPolicy()
+	if err != nil {
+		return nil, err
+	}
+
+	access := map[string]Access{}
+	for _, p := range policies {
+		if len(p) < 4 {
+			continue
+		}
+
+		users, err := enforcer.GetImplicitUsersForRole(p[0])
+		if err != nil {
+			return nil, err
+		}
+
+		for _, subject := range append([]string{p[0]}, users...) {
+			a := Access{Subject: subject, Object: p[1], Action: p[2], Effect: p[3]}
+			if len(p) > 4 {
+				a.Condition = p[4]
+			}
+			access[a.key()] = a
+		}
+	}
+
+	return access, nil
+}
+
+// diff returns the access in a that's not in b.
+func diff(a, b map[string]Access) []Access {
+	var output []Access
+	for key, access := range a {
+		if _, ok := b[key]; !ok {
+			output = append(output, access)
+		}
+	}
+
+	sort.Slice(output, func(i, j int) bool { return output[i].key() < output[j].key() })
+	return output
+}
+
+func subjects(access map[string]Access) []string {
+	var output []string
+	for _, a := range access {
+		output = append(output, a.Subject)
+	}
+	return output
+}
+
+// people returns the subjects that are people.
+func people(ctx context.Context, subjects []string) ([]string, error) {
+	ids := lo.Filter(subjects, func(s string, _ int) bool { return uuid.Validate(s) == nil })
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	var personIDs []string
+	if err := ctx.DB().Model(&models.Person{}).Select("id").
+		Where("id IN ? AND deleted_at IS NULL", ids).
+		Order("id").Find(&personIDs).Error; err != nil {
+		return nil, ctx.Oops().Wrapf(err, "failed to get people")
+	}
+	return personIDs, nil
+}
+
+// rlsPayload builds the payload the person would get, which like
+// auth.GetRLSPayload disables RLS for anyone that isn't a guest.
+func rlsPayload(ctx context.Context, enforcer *casbin.Enforcer, personID string) (*rls.Payload, error) {
+	roles, err := enforcer.GetImplicitRolesForUser(personID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !lo.Contains(roles, policy.RoleGuest) {
+		return &rls.Payload{Disable: true}, nil
+	}
+
+	return auth.BuildRLSPayload(ctx, personID, roles)
+}
+
+// diffScopes returns the scopes in a that aren't in b.
+func diffScopes(a, b *rls.Payload) []RLSScope {
+	existing := map[string]struct{}{}
+	for _, scope := range rlsScopes(b) {
+		existing[scope.Resource+scope.Fingerprint()] = struct{}{}
+	}
+
+	var output []RLSScope
+	for _, scope := range rlsScopes(a) {
+		if _, ok := existing[scope.Resource+scope.Fingerprint()]; !ok {
+			output = append(output, scope)
+		}
+	}
+	return output
+}
+
+func rlsScopes(payload *rls.Payload) []RLSScope {
+	if payload == nil || payload.Disable {
+		return nil
+	}
+
+	var output []RLSScope
+	for resource, scopes := range map[string][]rls.Scope{
+		"config":    payload.Config,
+		"component": payload.Component,
+		"playbook":  payload.Playbook,
+		"canary":    payload.Canary,
+		"view":      payload.View,
+	} {
+		for _, scope := range scopes {
+			output = append(output, RLSScope{Resource: resource, Scope: scope})
+		}
+	}
+	for _, id := range payload.Scopes {
+		output = append(output, RLSScope{Resource: "scope", Scope: rls.Scope{ID: id}})
+	}
+
+	slices.SortFunc(output, func(a, b RLSScope) int {
+		return strings.Compare(a.Resource+a.Fingerprint(), b.Resource+b.Fingerprint())
+	})
+	return output
+}
+
+// resolveNames names the people and teams among the subjects.
+func (r *Result) resolveNames(ctx context.Context) error {
+	var ids []string
+	for _, a := range append(r.Gained, r.Lost...) {
+		if uuid.Validate(a.Subject) == nil {
+			ids = append(ids, a.Subject)
+		}
+	}
+	for _, c := range r.RLS {
+		ids = append(ids, c.Subject)
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	ids = lo.Uniq(ids)
+
+	var people []models.Person
+	if err := ctx.DB().Select("id", "name", "email").Where("id IN ?", ids).Find(&people).Error; err != nil {
+		return ctx.Oops().Wrapf(err, "failed to get people")
+	}
+	var teams []models.Team
+	if err := ctx.DB().Select("id", "name").Where("id IN ?", ids).Find(&teams).Error; err != nil {
+		return ctx.Oops().Wrapf(err, "failed to get teams")
+	}
+
+	names := map[string]string{}
+	for _, p := range people {
+		names[p.ID.String()] = lo.CoalesceOrEmpty(p.Email, p.Name)
+	}
+	for _, t := range teams {
+		names[t.ID.String()] = t.Name
+	}
+
+	for i := range r.Gained {
+		r.Gained[i].Name = names[r.Gained[i].Subject]
+	}
+	for i := range r.Lost {
+		r.Lost[i].Name = names[r.Lost[i].Subject]
+	}
+	for i := range r.RLS {
+		r.RLS[i].Name = names[r.RLS[i].Subject]
+	}
+
+	return nil
+}
@@ -0,0 +1,122 @@
+package simulate
+
+import (
+	"github.com/flanksource/duty/models"
+	dutyRBAC "github.com/flanksource/duty/rbac"
+	"github.com/flanksource/duty/rbac/policy"
+	"github.com/flanksource/duty/tests/setup"
+	"github.com/flanksource/duty/types"
+	"github.com/google/uuid"
+	ginkgo "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sTypes "k8s.io/apimachinery/pkg/types"
+
+	v1 "github.com/flanksource/incident-commander/api/v1"
+	"github.com/flanksource/incident-commander/db"
+	"github.com/flanksource/incident-commander/rbac/adapter"
+)
+
+var _ = ginkgo.Describe("Simulate", ginkgo.Ordered, func() {
+	var guest *models.Person
+
+	ginkgo.BeforeAll(func() {
+		if dutyRBAC.Enforcer() == nil {
+			Expect(dutyRBAC.Init(DefaultContext, []string{}, adapter.NewPermissionAdapter)).To(Succeed())
+		}
+
+		guest = setup.CreateUserWithRole(DefaultContext, "Simulated Guest", "simulated-guest@test.com", policy.RoleGuest)
+	})
+
+	ginkgo.It("should parse manifests", func() {
+		req, err := ParseManifests([]byte(`
+apiVersion: mission-control.flanksource.com/v1
+kind: Scope
+metadata:
+  name: prod
+spec:
+  targets:
+    - config:
+        tagSelector: env=prod
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: ignored
+`))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(req.Permissions).To(BeEmpty())
+		Expect(req.Scopes).To(HaveLen(1))
+		Expect(req.Scopes[0].Namespace).To(Equal("default"))
+		Expect(req.Scopes[0].Spec.Targets[0].Config.TagSelector).To(Equal("env=prod"))
+	})
+
+	ginkgo.It("should report gained access and RLS scopes without saving", func() {
+		req, err := ParseManifests([]byte(`
+apiVersion: mission-control.flanksource.com/v1
+kind: Permission
+metadata:
+  name: simulated-guest-read
+spec:
+  subject:
+    person: simulated-guest@test.com
+  actions: [read]
+  object:
+    configs:
+      - name: simulated-cluster
+`))
+		Expect(err).ToNot(HaveOccurred())
+
+		result, err := Simulate(DefaultContext, req)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.Lost).To(BeEmpty())
+		Expect(result.Gained).To(ContainElement(And(
+			HaveField("Subject", guest.ID.String()),
+			HaveField("Name", guest.Email),
+			HaveField("Action", policy.ActionRead),
+			HaveField("Effect", "allow"),
+		)))
+
+		Expect(result.RLS).To(HaveLen(1))
+		Expect(result.RLS[0].Subject).To(Equal(guest.ID.String()))
+		Expect(result.RLS[0].Gained).To(ConsistOf(And(
+			HaveField("Resource", "config"),
+			HaveField("Names", []string{"simulated-cluster"}),
+		)))
+
+		var count int64
+		Expect(DefaultContext.DB().Model(&models.Permission{}).Where("name = ?", "simulated-guest-read").Count(&count).Error).To(Succeed())
+		Expect(count).To(BeZero())
+	})
+
+	ginkgo.It("should report lost access when replacing a permission", func() {
+		existing := v1.Permission{
+			ObjectMeta: metav1.ObjectMeta{Name: "simulated-guest-playbooks", Namespace: "default", UID: k8sTypes.UID(uuid.NewString())},
+			Spec: v1.PermissionSpec{
+				Subject: v1.PermissionSubject{Person: guest.Email},
+				Actions: []string{policy.ActionRead, policy.ActionPlaybookRun},
+				Object: v1.PermissionObject{Selectors: dutyRBAC.Selectors{
+					Playbooks: []types.ResourceSelector{{Name: "simulated-playbook"}},
+				}},
+			},
+		}
+		Expect(db.PersistPermissionFromCRD(DefaultContext, &existing)).To(Succeed())
+
+		proposed := *existing.DeepCopy()
+		proposed.UID = ""
+		proposed.Spec.Actions = []string{policy.ActionRead}
+
+		result, err := Simulate(DefaultContext, Request{Permissions: []v1.Permission{proposed}})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.Gained).To(BeEmpty())
+		Expect(result.Lost).ToNot(BeEmpty())
+		for _, access := range result.Lost {
+			Expect(access.Subject).To(Equal(guest.ID.String()))
+			Expect(access.Action).To(Equal(policy.ActionPlaybookRun))
+		}
+
+		var permission models.Permission
+		Expect(DefaultContext.DB().Where("id = ?", existing.UID).First(&permission).Error).To(Succeed())
+		Expect(permission.Action).To(Equal(policy.ActionRead + "," + policy.ActionPlaybookRun))
+	})
+})
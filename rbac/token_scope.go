@@ -0,0 +1,148 @@
+package rbac
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/flanksource/duty/context"
+	"github.com/flanksource/duty/rbac"
+	"github.com/flanksource/duty/rbac/policy"
+	"github.com/google/uuid"
+	"github.com/patrickmn/go-cache"
+
+	"github.com/flanksource/incident-commander/db"
+)
+
+// TokenScope restricts an access token to a subset of the permissions of its
+// creator: the objects and actions it may use, the Scope of the rows it may
+// read and the networks it may be used from.
+type TokenScope struct {
+	Permissions []policy.Permission
+	ScopeID     *uuid.UUID
+	CIDRs       []*net.IPNet
+}
+
+// tokenScopes caches the scope of access token people. A nil value marks a
+// person that isn't a scoped access token.
+var tokenScopes = cache.New(time.Minute, 5*time.Minute)
+
+// GetTokenScope returns the scope of the access token of the person, or nil
+// if the person isn't a scoped access token.
+func GetTokenScope(ctx context.Context, personID uuid.UUID) (*TokenScope, error) {
+	if cached, ok := tokenScopes.Get(personID.String()); ok {
+		return cached.(*TokenScope), nil
+	}
+
+	row, err := db.GetAccessTokenScope(ctx, personID)
+	if err != nil {
+		return nil, err
+	}
+
+	var scope *TokenScope
+	if row != nil {
+		if scope, err = NewTokenScope(*row); err != nil {
+			return nil, ctx.Oops().Wrapf(err, "invalid scope of access token %s", row.AccessTokenID)
+		}
+	}
+
+	tokenScopes.SetDefault(personID.String(), scope)
+	return scope, nil
+}
+
+// FlushTokenScope drops the cached scope of the access token person.
+func FlushTokenScope(personID string) {
+	tokenScopes.Delete(personID)
+}
+
+func NewTokenScope(row db.AccessTokenScope) (*TokenScope, error) {
+	scope := TokenScope{ScopeID: row.ScopeID}
+	if len(row.Permissions) > 0 {
+		if err := json.Unmarshal(row.Permissions, &scope.Permissions); err != nil {
+			return nil, err
+		}
+	}
+
+	cidrs, err := ParseCIDRs(row.CIDRs)
+	if err != nil {
+		return nil, err
+	}
+	scope.CIDRs = cidrs
+	return &scope, nil
+}
+
+// ParseCIDRs parses the networks a token may be used from. A bare IP is a
+// network of that single address.
+func ParseCIDRs(values []string) ([]*net.IPNet, error) {
+	var networks []*net.IPNet
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if !strings.Contains(value, "/") {
+			if ip := net.ParseIP(value); ip != nil {
+				bits := 32
+				if ip.To4() == nil {
+					bits = 128
+				}
+				value = fmt.Sprintf("%s/%d", value, bits)
+			}
+		}
+
+		_, network, err := net.ParseCIDR(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cidr %q: %w", value, err)
+		}
+		networks = append(networks, network)
+	}
+	return networks, nil
+}
+
+// Allows reports whether the token may perform the action on the object. The
+// permissions of its creator are checked separately by the enforcer.
+func (t TokenScope) Allows(object, action string) bool {
+	if len(t.Permissions) == 0 {
+		return true
+	}
+
+	return slices.ContainsFunc(t.Permissions, func(p policy.Permission) bool {
+		if p.Deny || (p.Object != object && p.Object != "*") {
+			return false
+		}
+		return p.Action == policy.ActionAll || slices.Contains(strings.Split(p.Action, ","), action)
+	})
+}
+
+// AllowsIP reports whether the token may be used from the address.
+func (t TokenScope) AllowsIP(address string) bool {
+	if len(t.CIDRs) == 0 {
+		return true
+	}
+
+	ip := net.ParseIP(address)
+	if ip == nil {
+		return false
+	}
+	return slices.ContainsFunc(t.CIDRs, func(network *net.IPNet) bool { return network.Contains(ip) })
+}
+
+// EffectivePermissions returns the permissions the person holds, limited by
+// their token scope if they are an access token.
+func EffectivePermissions(ctx context.Context, personID uuid.UUID) ([]policy.Permission, error) {
+	scope, err := GetTokenScope(ctx, personID)
+	if err != nil {
+		return nil, err
+	}
+
+	var effective []policy.Permission
+	for _, p := range AllPermissions {
+		if scope != nil && !scope.Allows(p.Object, p.Action) {
+			continue
+		}
+		if rbac.Check(ctx, personID.String(), p.Object, p.Action) {
+			effective = append(effective, policy.Permission{Object: p.Object, Action: p.Action})
+		}
+	}
+	return effective, nil
+}
@@ -0,0 +1,65 @@
+package rbac
+
+import (
+	"net/http"
+
+	"github.com/flanksource/duty/rbac/policy"
+	"github.com/labstack/echo/v4"
+	ginkgo "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = ginkgo.Describe("TokenScope", func() {
+	ginkgo.It("should allow everything without restrictions", func() {
+		scope := TokenScope{}
+		Expect(scope.Allows(policy.ObjectPlaybooks, policy.ActionPlaybookRun)).To(BeTrue())
+		Expect(scope.AllowsIP("203.0.113.1")).To(BeTrue())
+	})
+
+	ginkgo.It("should only allow the listed objects and actions", func() {
+		scope := TokenScope{Permissions: []policy.Permission{
+			{Object: policy.ObjectCatalog, Action: policy.ActionRead},
+			{Object: policy.ObjectPlaybooks, Action: "read,playbook:run"},
+			{Object: policy.ObjectCanary, Action: policy.ActionAll},
+		}}
+
+		Expect(scope.Allows(policy.ObjectCatalog, policy.ActionRead)).To(BeTrue())
+		Expect(scope.Allows(policy.ObjectCatalog, policy.ActionUpdate)).To(BeFalse())
+		Expect(scope.Allows(policy.ObjectPlaybooks, policy.ActionPlaybookRun)).To(BeTrue())
+		Expect(scope.Allows(policy.ObjectPlaybooks, policy.ActionDelete)).To(BeFalse())
+		Expect(scope.Allows(policy.ObjectCanary, policy.ActionDelete)).To(BeTrue())
+		Expect(scope.Allows(policy.ObjectTopology, policy.ActionRead)).To(BeFalse())
+	})
+
+	ginkgo.It("should only allow the listed networks", func() {
+		cidrs, err := ParseCIDRs([]string{"10.0.0.0/8", "192.168.1.1", "2001:db8::1"})
+		Expect(err).ToNot(HaveOccurred())
+		scope := TokenScope{CIDRs: cidrs}
+
+		Expect(scope.AllowsIP("10.1.2.3")).To(BeTrue())
+		Expect(scope.AllowsIP("192.168.1.1")).To(BeTrue())
+		Expect(scope.AllowsIP("192.168.1.2")).To(BeFalse())
+		Expect(scope.AllowsIP("2001:db8::1")).To(BeTrue())
+		Expect(scope.AllowsIP("not-an-ip")).To(BeFalse())
+
+		_, err = ParseCIDRs([]string{"10.0.0.0/33"})
+		Expect(err).To(HaveOccurred())
+	})
+
+	ginkgo.It("should only track routes that check the token scope", func() {
+		e := echo.New()
+		TrackScopedRoutes(e)
+
+		noop := func(c echo.Context) error { return c.NoContent(http.StatusOK) }
+		e.GET("/scoped/catalog", noop, Catalog(policy.ActionRead))
+		e.POST("/scoped/self", noop, RequireTokenScope(policy.ObjectRBAC, policy.ActionUpdate))
+		e.GET("/unscoped", noop, func(next echo.HandlerFunc) echo.HandlerFunc { return next })
+		e.Group("/scoped/db", DbMiddleware())
+
+		Expect(isScopedRoute(http.MethodGet, "/scoped/catalog")).To(BeTrue())
+		Expect(isScopedRoute(http.MethodPost, "/scoped/catalog")).To(BeFalse())
+		Expect(isScopedRoute(http.MethodPost, "/scoped/self")).To(BeTrue())
+		Expect(isScopedRoute(http.MethodGet, "/unscoped")).To(BeFalse())
+		Expect(isScopedRoute(http.MethodGet, "/scoped/db/*")).To(BeTrue())
+	})
+})
@@ -31,6 +31,8 @@ func Export(ctx context.Context, opts Options, format string) ([]byte, error) {
 		return RenderFacetHTML(ctx, report, opts.View)
 	case "pdf", "facet-pdf":
 		return RenderFacetPDF(ctx, report, opts.View)
+	case "xlsx":
+		return renderXLSX(report, opts.View)
 	default:
 		return json.MarshalIndent(report, "", "  ")
 	}
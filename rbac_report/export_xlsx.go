@@ -0,0 +1,76 @@
+package rbac_report
+
+import (
+	"github.com/flanksource/incident-commander/api"
+	"github.com/flanksource/incident-commander/report/xlsx"
+)
+
+// renderXLSX renders the access of the report, grouped by resource or by user
+// like the CSV export, followed by a sheet of the changelog.
+func renderXLSX(report *api.RBACReport, view string) ([]byte, error) {
+	access := accessByResourceSheet(report)
+	if view == "user" {
+		access = accessByUserSheet(report)
+	}
+
+	changelog := xlsx.Sheet{Name: "Changelog", Columns: []xlsx.Column{
+		{Name: "Date", Type: xlsx.DateTime},
+		{Name: "Config Name"}, {Name: "Change Type"}, {Name: "User"}, {Name: "Role"}, {Name: "Source"}, {Name: "Description"},
+	}}
+	for _, c := range report.Changelog {
+		changelog.AddRow(c.Date, xlsx.NewLink(c.ConfigName, api.ConfigPermalink(c.ConfigID)),
+			c.ChangeType, c.User, c.Role, c.Source, c.Description)
+	}
+
+	return xlsx.Render(access, changelog)
+}
+
+func accessByResourceSheet(report *api.RBACReport) xlsx.Sheet {
+	sheet := xlsx.Sheet{Name: "Access", Columns: []xlsx.Column{
+		{Name: "Config Name"}, {Name: "Config Type"}, {Name: "User Name"}, {Name: "Email"},
+		{Name: "Role"}, {Name: "Role Source"}, {Name: "Source System"},
+		{Name: "Created", Type: xlsx.DateTime},
+		{Name: "Last Sign In", Type: xlsx.DateTime},
+		{Name: "Last Reviewed", Type: xlsx.DateTime},
+		{Name: "Stale", Type: xlsx.Boolean},
+		{Name: "Review Overdue", Type: xlsx.Boolean},
+	}}
+
+	for _, resource := range report.Resources {
+		for _, u := range resource.Users {
+			sheet.AddRow(
+				xlsx.NewLink(resource.ConfigName, api.ConfigPermalink(resource.ConfigID)),
+				resource.ConfigType, u.UserName, u.Email,
+				u.Role, u.RoleSource, u.SourceSystem,
+				u.CreatedAt, u.LastSignedInAt, u.LastReviewedAt,
+				u.IsStale, u.IsReviewOverdue,
+			)
+		}
+	}
+	return sheet
+}
+
+func accessByUserSheet(report *api.RBACReport) xlsx.Sheet {
+	sheet := xlsx.Sheet{Name: "Access", Columns: []xlsx.Column{
+		{Name: "User Name"}, {Name: "Email"}, {Name: "Config Name"}, {Name: "Config Type"},
+		{Name: "Role"}, {Name: "Role Source"},
+		{Name: "Created", Type: xlsx.DateTime},
+		{Name: "Last Sign In", Type: xlsx.DateTime},
+		{Name: "Last Reviewed", Type: xlsx.DateTime},
+		{Name: "Stale", Type: xlsx.Boolean},
+		{Name: "Review Overdue", Type: xlsx.Boolean},
+	}}
+
+	for _, user := range report.Users {
+		for _, r := range user.Resources {
+			sheet.AddRow(
+				user.UserName, user.Email,
+				xlsx.NewLink(r.ConfigName, api.ConfigPermalink(r.ConfigID)), r.ConfigType,
+				r.Role, r.RoleSource,
+				r.CreatedAt, r.LastSignedInAt, r.LastReviewedAt,
+				r.IsStale, r.IsReviewOverdue,
+			)
+		}
+	}
+	return sheet
+}
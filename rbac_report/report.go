@@ -73,6 +73,12 @@ func BuildReport(ctx context.Context, opts Options) (*api.RBACReport, error) {
 	}
 	attachTemporaryAccessToResources(resources, tempAccess)
 
+	elevations, err := db.GetRBACElevations(ctx, configIDs, since)
+	if err != nil {
+		return nil, ctx.Oops().Wrapf(err, "failed to query permission elevations")
+	}
+	attachElevationsToResources(resources, elevations)
+
 	ctx.Logger.V(3).Infof("Changelog: %d entries, temporary access: %d entries", len(changelog), len(tempAccess))
 
 	subject, parents := resolveSubjectAndParents(ctx, configItems, configMap)
@@ -129,6 +135,18 @@ func attachTemporaryAccessToResources(resources []api.RBACResource, entries []ap
 	}
 }
 
+func attachElevationsToResources(resources []api.RBACResource, entries []api.RBACElevation) {
+	byConfig := make(map[string][]api.RBACElevation)
+	for _, entry := range entries {
+		byConfig[entry.ConfigID] = append(byConfig[entry.ConfigID], entry)
+	}
+	for i := range resources {
+		if entries, ok := byConfig[resources[i].ConfigID]; ok {
+			resources[i].Elevations = entries
+		}
+	}
+}
+
 func extractUniqueConfigIDs(rows []db.RBACAccessRow) []uuid.UUID {
 	seen := make(map[uuid.UUID]struct{})
 	var ids []uuid.UUID
@@ -0,0 +1,80 @@
+import React from 'react';
+import { Document, Page, Header, Footer, Section, CompactTable, Badge } from '@flanksource/facet';
+import type { AccessCampaignReport, AccessCampaignDecision } from './access-campaign-types.ts';
+import PageHeader from './components/PageHeader.tsx';
+import PageFooter from './components/PageFooter.tsx';
+import { formatDateTime } from './components/utils.ts';
+
+interface Props {
+  data: AccessCampaignReport;
+}
+
+function decisionBadge(d: AccessCampaignDecision) {
+  const status = d.decision === 'approved' ? 'success' : d.decision === 'revoked' ? 'error' : 'warning';
+  return <Badge variant="status" status={status} value={d.decision} size="xs" shape="rounded" />;
+}
+
+export default function AccessCampaignReportPage({ data }: Props) {
+  const { campaign, summary } = data;
+  const reviewers = data.reviewers || [];
+  const decisions = data.decisions || [];
+
+  const signOff = campaign.signedOffAt
+    ? `Signed off by ${campaign.signedOffBy || 'unknown'} on ${formatDateTime(campaign.signedOffAt)}`
+    : campaign.overdue
+      ? `Not signed off — overdue since ${formatDateTime(campaign.dueAt)}`
+      : `Not signed off — due ${formatDateTime(campaign.dueAt)}`;
+
+  return (
+    <Document pageSize="a4-landscape" margins={{ top: 1, bottom: 1, left: 5, right: 5 }}>
+      <Header height={8}>
+        <PageHeader subtitle="Access Review" />
+      </Header>
+      <Footer height={10}>
+        <PageFooter generatedAt={data.generatedAt} />
+      </Footer>
+
+      <Page>
+        <Section variant="hero" title={data.title} size="md">
+          {campaign.description && <div className="text-sm text-gray-600 mb-2">{campaign.description}</div>}
+          <div className="text-xs text-gray-700">
+            <div>Opened {formatDateTime(campaign.createdAt)}{campaign.createdBy ? ` by ${campaign.createdBy}` : ''}</div>
+            <div className={campaign.signedOffAt ? 'text-green-700 font-semibold' : 'text-red-600 font-semibold'}>{signOff}</div>
+            {campaign.digest && <div className="font-mono text-gray-500">sha256 {campaign.digest}</div>}
+          </div>
+          <CompactTable
+            variant="reference"
+            columns={['Configs', 'Access', 'Approved', 'Revoked', 'Pending', 'Failed Revocations']}
+            data={[[summary.configs, summary.total, summary.approved, summary.revoked, summary.pending, summary.failed]]}
+          />
+        </Section>
+
+        {reviewers.length > 0 && (
+          <Section variant="hero" title="Reviewers" size="md">
+            <CompactTable
+              variant="reference"
+              columns={['Reviewer', 'Type', 'Access', 'Approved', 'Revoked', 'Pending']}
+              data={reviewers.map((r) => [r.name, r.type, r.total, r.approved, r.revoked, r.pending])}
+            />
+          </Section>
+        )}
+
+        <Section variant="hero" title="Decisions" size="md">
+          <CompactTable
+            variant="reference"
+            columns={['Config', 'User', 'Role', 'Reviewers', 'Decision', 'Decided By', 'Comment']}
+            data={decisions.map((d) => [
+              <span>{d.configName} <span className="text-gray-400">{d.configType}</span></span>,
+              d.email ? `${d.user} (${d.email})` : d.user,
+              d.role || '—',
+              d.reviewers.join(', '),
+              decisionBadge(d),
+              d.decidedBy ? `${d.decidedBy}${d.decidedAt ? ` ${formatDateTime(d.decidedAt)}` : ''}` : '—',
+              d.error ? <span className="text-red-600">{d.comment ? `${d.comment} — ` : ''}{d.error}</span> : d.comment || '',
+            ])}
+          />
+        </Section>
+      </Page>
+    </Document>
+  );
+}
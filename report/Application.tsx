@@ -7,6 +7,8 @@ import AccessControlSection from './components/AccessControlSection.tsx';
 import IncidentsSection from './components/IncidentsSection.tsx';
 import BackupsSection from './components/BackupsSection.tsx';
 import FindingsSection from './components/FindingsSection.tsx';
+import SLOSection from './components/SLOSection.tsx';
+import BackupComplianceSection from './components/BackupComplianceSection.tsx';
 import LocationsSection from './components/LocationsSection.tsx';
 import DynamicSection from './components/DynamicSection.tsx';
 import CoverPage from './components/CoverPage.tsx';
@@ -50,6 +52,10 @@ export default function ApplicationReport({ data }: ApplicationReportProps) {
           <BackupsSection backups={data.backups} />
         )}
         <FindingsSection findings={data.findings} />
+        {data.slos.length > 0 && <SLOSection slos={data.slos} />}
+        {data.backupCompliance.length > 0 && (
+          <BackupComplianceSection compliance={data.backupCompliance} />
+        )}
         {data.sections.map((section, idx) => (
           <DynamicSection key={idx} section={section} />
         ))}
@@ -14,6 +14,7 @@ import ArtifactAppendix from './components/ArtifactAppendix.tsx';
 import AuditPage from './components/AuditPage.tsx';
 import CoverPage from './components/CoverPage.tsx';
 import CatalogList from './components/CatalogList.tsx';
+import CatalogDiffSection from './components/CatalogDiffSection.tsx';
 import { formatDateTime } from './components/utils.ts';
 
 const catalogReportCss = `
@@ -153,6 +154,8 @@ export default function CatalogReportPage({ data }: CatalogReportProps) {
       <Page>
         <CatalogList entries={data.entries} groupBy={data.groupBy} relationshipTree={data.relationshipTree} />
 
+        {data.diff && <CatalogDiffSection diff={data.diff} />}
+
         {data.groupBy === 'config' && (data.entries || []).map((entry, idx) => (
           <React.Fragment key={entry.configItem?.id || idx}>
             <ConfigGroupHeader group={{ configItem: entry.configItem as any, changes: entry.changes, analyses: entry.analyses, access: entry.access, accessLogs: entry.accessLogs }} />
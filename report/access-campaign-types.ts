@@ -0,0 +1,55 @@
+export interface AccessCampaignInfo {
+  id: string;
+  name: string;
+  description?: string;
+  status: string;
+  createdBy?: string;
+  createdAt: string;
+  dueAt: string;
+  overdue: boolean;
+  signedOffBy?: string;
+  signedOffAt?: string | null;
+  digest?: string;
+}
+
+export interface AccessCampaignSummary {
+  total: number;
+  approved: number;
+  revoked: number;
+  pending: number;
+  failed: number;
+  configs: number;
+}
+
+export interface AccessCampaignReviewer {
+  name: string;
+  type: string;
+  total: number;
+  approved: number;
+  revoked: number;
+  pending: number;
+}
+
+export interface AccessCampaignDecision {
+  configId: string;
+  configName: string;
+  configType: string;
+  user: string;
+  email?: string;
+  role?: string;
+  reviewers: string[];
+  decision: string;
+  decidedBy?: string;
+  decidedAt?: string | null;
+  comment?: string;
+  error?: string;
+}
+
+export interface AccessCampaignReport {
+  title: string;
+  generatedAt: string;
+  campaign: AccessCampaignInfo;
+  summary: AccessCampaignSummary;
+  reviewers: AccessCampaignReviewer[];
+  decisions: AccessCampaignDecision[];
+}
@@ -117,7 +117,35 @@ export interface CatalogReportConfigJSON {
   json: string;
 }
 
+export interface CatalogReportSummary {
+  id?: string;
+  title: string;
+  hash?: string;
+  itemCount: number;
+  generatedAt: string;
+}
+
+export interface CatalogReportUser {
+  userId: string;
+  userName: string;
+  email?: string;
+  userType?: string;
+}
+
+export interface CatalogReportDiff {
+  against: CatalogReportSummary;
+  newConfigs: Array<ConfigItem & { permalink?: string }>;
+  removedConfigs: Array<ConfigItem & { permalink?: string }>;
+  newInsights: ConfigAnalysis[];
+  resolvedInsights: ConfigAnalysis[];
+  grantsAdded: CatalogReportAccess[];
+  grantsRevoked: CatalogReportAccess[];
+  newUsers: CatalogReportUser[];
+}
+
 export interface CatalogReportData {
+  id?: string;
+  hash?: string;
   title: string;
   generatedAt: string;
   publicURL?: string;
@@ -158,6 +186,7 @@ export interface CatalogReportData {
   relationshipTree?: CatalogReportTreeNode;
   entries?: CatalogReportEntry[];
   audit?: CatalogReportAudit;
+  diff?: CatalogReportDiff;
 }
 
 export interface CatalogReportEntry {
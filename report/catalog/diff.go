@@ -0,0 +1,115 @@
+package catalog
+
+import (
+	"github.com/flanksource/duty/models"
+
+	"github.com/flanksource/incident-commander/api"
+)
+
+// DiffReports returns what changed in current since previous: configs that
+// were added or removed, insights that were raised or resolved, access that
+// was granted or revoked and users that didn't have any access before.
+// Insights and access are only compared when both reports include them.
+func DiffReports(previous, current *api.CatalogReport) *api.CatalogReportDiff {
+	diff := &api.CatalogReportDiff{
+		Against: api.CatalogReportSummary{
+			ID:          previous.ID,
+			Title:       previous.Title,
+			Hash:        previous.Hash,
+			ItemCount:   len(previous.Entries),
+			GeneratedAt: previous.GeneratedAt,
+		},
+		NewConfigs:       []api.CatalogReportConfigItem{},
+		RemovedConfigs:   []api.CatalogReportConfigItem{},
+		NewInsights:      []api.CatalogReportAnalysis{},
+		ResolvedInsights: []api.CatalogReportAnalysis{},
+		GrantsAdded:      []api.CatalogReportAccess{},
+		GrantsRevoked:    []api.CatalogReportAccess{},
+		NewUsers:         []api.CatalogReportUser{},
+	}
+
+	configKey := func(c api.CatalogReportConfigItem) string { return c.ID }
+	diff.NewConfigs, diff.RemovedConfigs = diffByKey(reportConfigs(previous), reportConfigs(current), configKey)
+
+	if previous.Sections.Insights && current.Sections.Insights {
+		insightKey := func(a api.CatalogReportAnalysis) string { return a.ID }
+		diff.NewInsights, diff.ResolvedInsights = diffByKey(openInsights(previous), openInsights(current), insightKey)
+	}
+
+	if previous.Sections.Access && current.Sections.Access {
+		previousAccess, currentAccess := reportAccess(previous), reportAccess(current)
+		grantKey := func(a api.CatalogReportAccess) string { return a.ConfigID + "/" + a.UserID + "/" + a.Role }
+		diff.GrantsAdded, diff.GrantsRevoked = diffByKey(previousAccess, currentAccess, grantKey)
+
+		userKey := func(a api.CatalogReportAccess) string { return a.UserID }
+		newUsers, _ := diffByKey(previousAccess, currentAccess, userKey)
+		for _, a := range newUsers {
+			diff.NewUsers = append(diff.NewUsers, api.CatalogReportUser{
+				UserID:   a.UserID,
+				UserName: a.UserName,
+				Email:    a.Email,
+				UserType: a.UserType,
+			})
+		}
+	}
+
+	return diff
+}
+
+// diffByKey returns the items of current whose key isn't in previous and the
+// items of previous whose key isn't in current. Each key is returned once.
+func diffByKey[T any](previous, current []T, key func(T) string) (added, removed []T) {
+	previousKeys := make(map[string]bool, len(previous))
+	for _, item := range previous {
+		previousKeys[key(item)] = true
+	}
+	currentKeys := make(map[string]bool, len(current))
+	for _, item := range current {
+		currentKeys[key(item)] = true
+	}
+
+	added, removed = []T{}, []T{}
+	seen := map[string]bool{}
+	for _, item := range current {
+		if k := key(item); !previousKeys[k] && !seen[k] {
+			seen[k] = true
+			added = append(added, item)
+		}
+	}
+	seen = map[string]bool{}
+	for _, item := range previous {
+		if k := key(item); !currentKeys[k] && !seen[k] {
+			seen[k] = true
+			removed = append(removed, item)
+		}
+	}
+	return added, removed
+}
+
+func reportConfigs(r *api.CatalogReport) []api.CatalogReportConfigItem {
+	configs := make([]api.CatalogReportConfigItem, 0, len(r.Entries))
+	for _, entry := range r.Entries {
+		configs = append(configs, entry.ConfigItem)
+	}
+	return configs
+}
+
+func openInsights(r *api.CatalogReport) []api.CatalogReportAnalysis {
+	var insights []api.CatalogReportAnalysis
+	for _, entry := range r.Entries {
+		for _, a := range entry.Analyses {
+			if a.Status != models.AnalysisStatusResolved {
+				insights = append(insights, a)
+			}
+		}
+	}
+	return insights
+}
+
+func reportAccess(r *api.CatalogReport) []api.CatalogReportAccess {
+	var access []api.CatalogReportAccess
+	for _, entry := range r.Entries {
+		access = append(access, entry.Access...)
+	}
+	return access
+}
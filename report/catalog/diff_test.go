@@ -0,0 +1,88 @@
+package catalog
+
+import (
+	"time"
+
+	"github.com/flanksource/duty/models"
+	ginkgo "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/flanksource/incident-commander/api"
+)
+
+var _ = ginkgo.Describe("DiffReports", func() {
+	sections := api.CatalogReportSections{Insights: true, Access: true}
+	grant := func(configID, userID, role string) api.CatalogReportAccess {
+		return api.CatalogReportAccess{ConfigID: configID, UserID: userID, UserName: userID, Role: role}
+	}
+
+	previous := &api.CatalogReport{
+		ID:       "previous",
+		Sections: sections,
+		Entries: []api.CatalogReportEntry{
+			{
+				ConfigItem: api.CatalogReportConfigItem{ID: "a", Name: "a"},
+				Analyses:   []api.CatalogReportAnalysis{{ID: "i1"}, {ID: "i2"}},
+				Access:     []api.CatalogReportAccess{grant("a", "alice", "admin"), grant("a", "bob", "reader")},
+			},
+			{ConfigItem: api.CatalogReportConfigItem{ID: "b", Name: "b"}},
+		},
+	}
+	current := &api.CatalogReport{
+		Sections: sections,
+		Entries: []api.CatalogReportEntry{
+			{
+				ConfigItem: api.CatalogReportConfigItem{ID: "a", Name: "a"},
+				Analyses:   []api.CatalogReportAnalysis{{ID: "i1"}, {ID: "i2", Status: models.AnalysisStatusResolved}, {ID: "i3"}},
+				Access:     []api.CatalogReportAccess{grant("a", "alice", "admin"), grant("a", "alice", "writer"), grant("a", "carol", "reader")},
+			},
+			{ConfigItem: api.CatalogReportConfigItem{ID: "c", Name: "c"}},
+		},
+	}
+
+	ginkgo.It("diffs configs", func() {
+		diff := DiffReports(previous, current)
+		Expect(diff.Against.ID).To(Equal("previous"))
+		Expect(diff.NewConfigs).To(ConsistOf(HaveField("ID", "c")))
+		Expect(diff.RemovedConfigs).To(ConsistOf(HaveField("ID", "b")))
+	})
+
+	ginkgo.It("diffs open insights", func() {
+		diff := DiffReports(previous, current)
+		Expect(diff.NewInsights).To(ConsistOf(HaveField("ID", "i3")))
+		Expect(diff.ResolvedInsights).To(ConsistOf(HaveField("ID", "i2")))
+	})
+
+	ginkgo.It("diffs grants and users", func() {
+		diff := DiffReports(previous, current)
+		Expect(diff.GrantsAdded).To(ConsistOf(grant("a", "alice", "writer"), grant("a", "carol", "reader")))
+		Expect(diff.GrantsRevoked).To(ConsistOf(grant("a", "bob", "reader")))
+		Expect(diff.NewUsers).To(ConsistOf(api.CatalogReportUser{UserID: "carol", UserName: "carol"}))
+	})
+
+	ginkgo.It("skips sections that are missing from either report", func() {
+		withoutAccess := *previous
+		withoutAccess.Sections = api.CatalogReportSections{Insights: true}
+		diff := DiffReports(&withoutAccess, current)
+		Expect(diff.GrantsAdded).To(BeEmpty())
+		Expect(diff.NewUsers).To(BeEmpty())
+		Expect(diff.NewInsights).To(HaveLen(1))
+	})
+})
+
+var _ = ginkgo.Describe("ParseReportTime", func() {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	ginkgo.It("parses dates and durations", func() {
+		t, err := ParseReportTime("2025-03-31", now)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(t).To(Equal(time.Date(2025, 3, 31, 0, 0, 0, 0, time.UTC)))
+
+		t, err = ParseReportTime("90d", now)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(t).To(Equal(now.Add(-90 * 24 * time.Hour)))
+
+		_, err = ParseReportTime("last quarter", now)
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -14,6 +14,7 @@ import (
 
 	"github.com/flanksource/incident-commander/api"
 	"github.com/flanksource/incident-commander/db"
+	"github.com/flanksource/incident-commander/report/findings"
 	"github.com/flanksource/incident-commander/report/scraper"
 )
 
@@ -58,6 +59,12 @@ func exportCatalogData(ctx context.Context, report api.CatalogReport, opts Optio
 		result.Data, result.SrcDir, result.Entry, result.DataFile, err = renderFacetResult(ctx, &report, "html")
 	case "pdf", "facet-pdf":
 		result.Data, result.SrcDir, result.Entry, result.DataFile, err = renderFacetResult(ctx, &report, "pdf")
+	case "xlsx":
+		result.Data, err = RenderXLSX(&report)
+	case "sarif":
+		result.Data, err = findings.RenderSARIF(findings.FromCatalogReport(&report))
+	case "ocsf":
+		result.Data, err = findings.RenderOCSF(findings.FromCatalogReport(&report), report.GeneratedAt)
 	default:
 		result.Data, err = json.MarshalIndent(report, "", "  ")
 	}
@@ -79,6 +86,8 @@ func Build(ctx context.Context, configs []models.ConfigItem, opts Options) (api.
 // BuildSelection builds a report from explicit roots and their owned items.
 // Recursive selections emit one entry per selected item without querying any
 // descendant more than once.
+// The report is diffed against a persisted report when opts.DiffAgainst is set
+// and is persisted itself with opts.Persist.
 func BuildSelection(ctx context.Context, selection Selection, opts Options) (api.CatalogReport, error) {
 	opts = opts.WithDefaults()
 	buildOpts := opts
@@ -117,6 +126,27 @@ func BuildSelection(ctx context.Context, selection Selection, opts Options) (api
 			})
 		}
 	}
+
+	if opts.DiffAgainst != "" || opts.Persist {
+		selectionKey := SelectionKey(selection, opts)
+		if opts.DiffAgainst != "" {
+			previous, err := LoadPreviousReport(ctx, opts.DiffAgainst, selectionKey, r.GeneratedAt)
+			if err != nil {
+				return api.CatalogReport{}, err
+			}
+			r.Diff = DiffReports(previous, r)
+		}
+
+		if opts.Persist {
+			opts.progressf("saving report")
+			// The report is still returned: failing to keep it only
+			// prevents later reports from diffing against it.
+			if err := SaveReport(ctx, r, selectionKey); err != nil {
+				ctx.Errorf("failed to save catalog report %q: %v", r.Title, err)
+			}
+		}
+	}
+
 	return *r, nil
 }
 
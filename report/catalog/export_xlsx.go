@@ -0,0 +1,87 @@
+package catalog
+
+import (
+	"github.com/flanksource/incident-commander/api"
+	"github.com/flanksource/incident-commander/report/xlsx"
+)
+
+// RenderXLSX renders the report as a workbook with a sheet of the config
+// items followed by one for each enabled section.
+// Section rows are read from the entries since the report level lists are
+// dropped when grouping by config.
+func RenderXLSX(r *api.CatalogReport) ([]byte, error) {
+	configs := xlsx.Sheet{Name: "Configs", Columns: []xlsx.Column{
+		{Name: "Name"}, {Name: "Type"}, {Name: "Class"}, {Name: "Status"}, {Name: "Health"},
+		{Name: "Changes", Type: xlsx.Integer},
+		{Name: "Insights", Type: xlsx.Integer},
+		{Name: "Access", Type: xlsx.Integer},
+		{Name: "Created", Type: xlsx.DateTime},
+		{Name: "Updated", Type: xlsx.DateTime},
+	}}
+
+	changes := xlsx.Sheet{Name: "Changes", Columns: []xlsx.Column{
+		{Name: "Config"}, {Name: "Config Type"}, {Name: "Change Type"}, {Name: "Category"}, {Name: "Severity"},
+		{Name: "Source"}, {Name: "Summary"}, {Name: "Created By"},
+		{Name: "Count", Type: xlsx.Integer},
+		{Name: "Created", Type: xlsx.DateTime},
+	}}
+
+	insights := xlsx.Sheet{Name: "Insights", Columns: []xlsx.Column{
+		{Name: "Config"}, {Name: "Config Type"}, {Name: "Analyzer"}, {Name: "Type"}, {Name: "Severity"},
+		{Name: "Status"}, {Name: "Source"}, {Name: "Summary"}, {Name: "Message"},
+		{Name: "First Observed", Type: xlsx.DateTime},
+		{Name: "Last Observed", Type: xlsx.DateTime},
+	}}
+
+	access := xlsx.Sheet{Name: "Access", Columns: []xlsx.Column{
+		{Name: "Config"}, {Name: "Config Type"}, {Name: "User"}, {Name: "Email"}, {Name: "User Type"}, {Name: "Role"},
+		{Name: "Granted", Type: xlsx.DateTime},
+		{Name: "Last Signed In", Type: xlsx.DateTime},
+		{Name: "Last Reviewed", Type: xlsx.DateTime},
+	}}
+
+	accessLogs := xlsx.Sheet{Name: "Access Logs", Columns: []xlsx.Column{
+		{Name: "Config"}, {Name: "Config Type"}, {Name: "User"},
+		{Name: "MFA", Type: xlsx.Boolean},
+		{Name: "Count", Type: xlsx.Integer},
+		{Name: "Time", Type: xlsx.DateTime},
+	}}
+
+	for _, entry := range r.Entries {
+		ci := entry.ConfigItem
+		configs.AddRow(xlsx.NewLink(ci.Name, ci.Permalink), ci.Type, ci.ConfigClass, ci.Status, ci.Health,
+			entry.ChangeCount, entry.InsightCount, entry.AccessCount, ci.CreatedAt, ci.UpdatedAt)
+
+		for _, c := range entry.Changes {
+			changes.AddRow(xlsx.NewLink(c.ConfigName, c.Permalink), c.ConfigType, c.ChangeType, c.Category, c.Severity,
+				c.Source, c.Summary, c.CreatedBy, c.Count, c.CreatedAt)
+		}
+		for _, a := range entry.Analyses {
+			insights.AddRow(xlsx.NewLink(a.ConfigName, a.Permalink), a.ConfigType, a.Analyzer, a.AnalysisType, a.Severity,
+				a.Status, a.Source, a.Summary, a.Message, a.FirstObserved, a.LastObserved)
+		}
+		for _, a := range entry.Access {
+			access.AddRow(xlsx.NewLink(a.ConfigName, a.Permalink), a.ConfigType, a.UserName, a.Email, a.UserType, a.Role,
+				a.CreatedAt, a.LastSignedInAt, a.LastReviewedAt)
+		}
+		for _, l := range entry.AccessLogs {
+			accessLogs.AddRow(xlsx.NewLink(l.ConfigName, l.Permalink), l.ConfigType, l.UserName, l.MFA, l.Count, l.CreatedAt)
+		}
+	}
+
+	sheets := []xlsx.Sheet{configs}
+	if r.Sections.Changes {
+		sheets = append(sheets, changes)
+	}
+	if r.Sections.Insights {
+		sheets = append(sheets, insights)
+	}
+	if r.Sections.Access {
+		sheets = append(sheets, access)
+	}
+	if r.Sections.AccessLogs {
+		sheets = append(sheets, accessLogs)
+	}
+
+	return xlsx.Render(sheets...)
+}
@@ -0,0 +1,143 @@
+package catalog
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/flanksource/commons/duration"
+	dutyAPI "github.com/flanksource/duty/api"
+	"github.com/flanksource/duty/context"
+	"github.com/google/uuid"
+
+	"github.com/flanksource/incident-commander/api"
+	"github.com/flanksource/incident-commander/db"
+)
+
+// SelectionKey identifies the roots, recursion and filters of a selection so
+// that reports built for the same selection can be found by date.
+func SelectionKey(selection Selection, opts Options) string {
+	ids := make([]string, 0, len(selection.Roots))
+	for _, root := range selection.Roots {
+		ids = append(ids, root.ID.String())
+	}
+	sort.Strings(ids)
+
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s|recursive=%t|filter=%s", strings.Join(ids, ","), opts.Recursive, opts.Settings.FilterQuery())))
+	return hex.EncodeToString(sum[:])
+}
+
+// SaveReport persists the report and sets its id and hash.
+// The raw config JSON and artifact contents aren't kept.
+func SaveReport(ctx context.Context, r *api.CatalogReport, selectionKey string) error {
+	stored := *r
+	stored.ID, stored.Hash, stored.Diff, stored.Audit = "", "", nil, nil
+	stored.ConfigJSON, stored.ConfigJSONItems = nil, nil
+	stored.Entries = make([]api.CatalogReportEntry, len(r.Entries))
+	for i, entry := range r.Entries {
+		entry.ConfigJSON = nil
+		entry.Changes = withoutArtifactData(entry.Changes)
+		stored.Entries[i] = entry
+	}
+	stored.Changes = withoutArtifactData(r.Changes)
+
+	data, err := json.Marshal(stored)
+	if err != nil {
+		return fmt.Errorf("failed to marshal catalog report: %w", err)
+	}
+	sum := sha256.Sum256(data)
+
+	record := &db.CatalogReportRecord{
+		Title:        r.Title,
+		SelectionKey: selectionKey,
+		Hash:         hex.EncodeToString(sum[:]),
+		ItemCount:    len(r.Entries),
+		Report:       data,
+		GeneratedAt:  r.GeneratedAt,
+	}
+	if ctx.User() != nil {
+		record.CreatedBy = &ctx.User().ID
+	}
+	if err := db.SaveCatalogReport(ctx, record); err != nil {
+		return err
+	}
+	r.ID, r.Hash = record.ID.String(), record.Hash
+
+	retention := ctx.Properties().Duration("catalog.report.retention", 365*24*time.Hour)
+	if deleted, err := db.DeleteCatalogReportsBefore(ctx, time.Now().Add(-retention)); err != nil {
+		return err
+	} else if deleted > 0 {
+		ctx.Logger.V(3).Infof("deleted %d expired catalog reports", deleted)
+	}
+	return nil
+}
+
+func withoutArtifactData(changes []api.CatalogReportChange) []api.CatalogReportChange {
+	out := make([]api.CatalogReportChange, len(changes))
+	for i, change := range changes {
+		if len(change.Artifacts) > 0 {
+			artifacts := make([]api.CatalogReportArtifact, len(change.Artifacts))
+			for j, artifact := range change.Artifacts {
+				artifact.DataURI = ""
+				artifacts[j] = artifact
+			}
+			change.Artifacts = artifacts
+		}
+		out[i] = change
+	}
+	return out
+}
+
+// LoadPreviousReport returns the persisted report to diff against.
+// against is the id of a report of the same selection, or a date (RFC3339, YYYY-MM-DD or a
+// duration ago e.g. 90d) for the latest report of the selection at that time.
+func LoadPreviousReport(ctx context.Context, against, selectionKey string, now time.Time) (*api.CatalogReport, error) {
+	var record *db.CatalogReportRecord
+	var err error
+	if id, parseErr := uuid.Parse(against); parseErr == nil {
+		if record, err = db.GetCatalogReport(ctx, id); err != nil {
+			return nil, err
+		} else if record == nil {
+			return nil, dutyAPI.Errorf(dutyAPI.ENOTFOUND, "catalog report %s not found", against)
+		} else if record.SelectionKey != selectionKey {
+			return nil, dutyAPI.Errorf(dutyAPI.EINVALID, "catalog report %s was generated for a different selection", against)
+		}
+	} else {
+		asOf, err := ParseReportTime(against, now)
+		if err != nil {
+			return nil, err
+		}
+		if record, err = db.GetCatalogReportAsOf(ctx, selectionKey, asOf); err != nil {
+			return nil, err
+		} else if record == nil {
+			return nil, dutyAPI.Errorf(dutyAPI.ENOTFOUND, "no catalog report of the selection was generated before %s", asOf.Format(time.RFC3339))
+		}
+	}
+
+	var previous api.CatalogReport
+	if err := json.Unmarshal(record.Report, &previous); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal catalog report %s: %w", record.ID, err)
+	}
+	previous.ID, previous.Hash = record.ID.String(), record.Hash
+	return &previous, nil
+}
+
+// ParseReportTime parses an RFC3339 timestamp, a YYYY-MM-DD date or a
+// duration that is relative to now e.g. 90d.
+func ParseReportTime(value string, now time.Time) (time.Time, error) {
+	for _, layout := range []string{time.RFC3339, time.DateOnly} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+
+	d, err := duration.ParseDuration(value)
+	if err != nil {
+		return time.Time{}, dutyAPI.Errorf(dutyAPI.EINVALID, "%q is neither a report id, a date nor a duration", value)
+	}
+	return now.Add(-time.Duration(d)), nil
+}
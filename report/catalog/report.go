@@ -48,6 +48,12 @@ type Options struct {
 
 	// Progress receives human-readable build progress messages.
 	Progress func(format string, args ...any)
+
+	// Persist saves the report so that later reports can be diffed against it.
+	Persist bool
+	// DiffAgainst is the id or date of a persisted report to diff against.
+	// See LoadPreviousReport.
+	DiffAgainst string
 }
 
 // progressf forwards a progress message to the Progress callback, if any.
@@ -0,0 +1,51 @@
+import React from 'react';
+import { Section, Badge, CompactTable } from '@flanksource/facet';
+import type { ApplicationBackupCompliance } from '../types.ts';
+import { formatDateTime, formatDurationMs } from './utils.ts';
+
+interface Props {
+  compliance: ApplicationBackupCompliance[];
+}
+
+function objective(target?: string, violated?: boolean) {
+  if (!target) return '—';
+  return <span className={violated ? 'font-semibold text-red-600' : 'text-green-700'}>{target}</span>;
+}
+
+export default function BackupComplianceSection({ compliance }: Props) {
+  const columns = ['Datasource', 'Environment', 'RPO', 'RTO', 'Restore Test', 'Last Backup', 'Last Restore', 'Status'];
+  const rows = compliance.map((c) => {
+    const violated = new Set((c.violations ?? []).map((v) => v.objective));
+    return [
+      c.name,
+      c.environment || '—',
+      objective(c.rpo, violated.has('rpo')),
+      c.rto && c.restoreDurationSeconds !== undefined
+        ? <span>{objective(c.rto, violated.has('rto'))} ({formatDurationMs(c.restoreDurationSeconds * 1000)})</span>
+        : objective(c.rto, violated.has('rto')),
+      objective(c.restoreTestInterval, violated.has('restore_test')),
+      c.lastBackup ? formatDateTime(c.lastBackup) : '—',
+      c.lastRestore ? formatDateTime(c.lastRestore) : '—',
+      <Badge
+        variant="status"
+        status={c.compliant ? 'success' : 'error'}
+        value={c.compliant ? 'compliant' : 'violated'}
+        size="xs"
+        shape="rounded"
+      />,
+    ];
+  });
+
+  const violations = compliance.flatMap((c) => (c.violations ?? []).map((v) => `${c.name}: ${v.message}`));
+
+  return (
+    <Section variant="hero" title="Backup Compliance" size="md">
+      <CompactTable variant="reference" columns={columns} data={rows} />
+      {violations.length > 0 && (
+        <div className="mt-2 text-xs text-red-600">
+          {violations.map((v, i) => <div key={i}>{v}</div>)}
+        </div>
+      )}
+    </Section>
+  );
+}
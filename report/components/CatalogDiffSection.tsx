@@ -0,0 +1,118 @@
+import React from 'react';
+import { Section } from '@flanksource/facet';
+import type { CatalogReportDiff, CatalogReportAccess, CatalogReportUser } from '../catalog-report-types.ts';
+import type { ConfigAnalysis, ConfigItem } from '../config-types.ts';
+import { formatDateTime, SEVERITY_COLORS } from './utils.ts';
+
+interface Props {
+  diff: CatalogReportDiff;
+}
+
+function DiffGroup({ title, count, tone, children }: {
+  title: string;
+  count: number;
+  tone: 'added' | 'removed';
+  children: React.ReactNode;
+}) {
+  if (count === 0) return null;
+  const color = tone === 'added' ? 'text-green-700 border-green-200' : 'text-red-700 border-red-200';
+  return (
+    <div className="mb-[2mm]">
+      <div className={`text-xs font-semibold border-b pb-[0.3mm] mb-[0.5mm] ${color}`}>
+        {title}
+        <span className="font-normal text-slate-400 ml-[1mm]">({count})</span>
+      </div>
+      <div className="flex flex-col">{children}</div>
+    </div>
+  );
+}
+
+function Row({ children }: { children: React.ReactNode }) {
+  return (
+    <div className="flex items-center gap-[1.5mm] py-[0.55mm] border-b border-slate-50 last:border-b-0 text-xs text-slate-700">
+      {children}
+    </div>
+  );
+}
+
+function ConfigRow({ config }: { config: ConfigItem & { permalink?: string } }) {
+  return (
+    <Row>
+      {config.permalink
+        ? <a href={config.permalink} className="font-semibold text-blue-600" style={{ textDecoration: 'none' }}>{config.name}</a>
+        : <span className="font-semibold text-slate-900">{config.name}</span>}
+      {config.type && <span className="text-slate-400">{config.type}</span>}
+    </Row>
+  );
+}
+
+function InsightRow({ analysis }: { analysis: ConfigAnalysis }) {
+  const severity = analysis.severity || 'info';
+  return (
+    <Row>
+      <span className="w-[14mm] shrink-0 font-medium" style={{ color: SEVERITY_COLORS[severity] }}>{severity}</span>
+      <span className="font-semibold text-slate-900">{analysis.analyzer}</span>
+      {analysis.configName && <span className="text-slate-500">{analysis.configName}</span>}
+      {analysis.summary && <span className="text-slate-400 truncate">{analysis.summary}</span>}
+    </Row>
+  );
+}
+
+function GrantRow({ access }: { access: CatalogReportAccess }) {
+  return (
+    <Row>
+      <span className="font-semibold text-slate-900">{access.userName}</span>
+      <span className="text-slate-500">{access.role}</span>
+      {access.configName && <span className="text-slate-400">{access.configName}</span>}
+    </Row>
+  );
+}
+
+function UserRow({ user }: { user: CatalogReportUser }) {
+  return (
+    <Row>
+      <span className="font-semibold text-slate-900">{user.userName}</span>
+      {user.email && <span className="text-slate-500">{user.email}</span>}
+      {user.userType && <span className="text-slate-400">{user.userType}</span>}
+    </Row>
+  );
+}
+
+export default function CatalogDiffSection({ diff }: Props) {
+  const total = diff.newConfigs.length + diff.removedConfigs.length +
+    diff.newInsights.length + diff.resolvedInsights.length +
+    diff.grantsAdded.length + diff.grantsRevoked.length + diff.newUsers.length;
+
+  return (
+    <Section variant="hero" title="Changes Since Previous Report" size="md">
+      <div className="text-xs text-slate-500 mb-[2mm]">
+        Compared with {diff.against.title ? `"${diff.against.title}" ` : 'report '}
+        generated {formatDateTime(diff.against.generatedAt)} ({diff.against.itemCount} configs)
+      </div>
+
+      {total === 0 && <div className="text-xs text-slate-400">No differences</div>}
+
+      <DiffGroup title="New Configs" count={diff.newConfigs.length} tone="added">
+        {diff.newConfigs.map((c, idx) => <ConfigRow key={c.id || idx} config={c} />)}
+      </DiffGroup>
+      <DiffGroup title="Removed Configs" count={diff.removedConfigs.length} tone="removed">
+        {diff.removedConfigs.map((c, idx) => <ConfigRow key={c.id || idx} config={c} />)}
+      </DiffGroup>
+      <DiffGroup title="New Insights" count={diff.newInsights.length} tone="added">
+        {diff.newInsights.map((a, idx) => <InsightRow key={a.id || idx} analysis={a} />)}
+      </DiffGroup>
+      <DiffGroup title="Resolved Insights" count={diff.resolvedInsights.length} tone="removed">
+        {diff.resolvedInsights.map((a, idx) => <InsightRow key={a.id || idx} analysis={a} />)}
+      </DiffGroup>
+      <DiffGroup title="Access Granted" count={diff.grantsAdded.length} tone="added">
+        {diff.grantsAdded.map((a, idx) => <GrantRow key={`${a.configId}-${a.userId}-${a.role}-${idx}`} access={a} />)}
+      </DiffGroup>
+      <DiffGroup title="Access Revoked" count={diff.grantsRevoked.length} tone="removed">
+        {diff.grantsRevoked.map((a, idx) => <GrantRow key={`${a.configId}-${a.userId}-${a.role}-${idx}`} access={a} />)}
+      </DiffGroup>
+      <DiffGroup title="New Users" count={diff.newUsers.length} tone="added">
+        {diff.newUsers.map((u, idx) => <UserRow key={u.userId || idx} user={u} />)}
+      </DiffGroup>
+    </Section>
+  );
+}
@@ -169,6 +169,28 @@ function ConfigsSection({ section }: { section: ApplicationSection }) {
   return <CompactTable variant="reference" columns={['Name', 'Type', 'Status', 'Health', 'Labels']} data={rows} />;
 }
 
+function DORASection({ section }: { section: ApplicationSection }) {
+  const seconds = (s?: number) => (s === undefined || s === null ? '-' : formatDurationMs(s * 1000));
+  const rows = (section.dora ?? []).map((m) => [
+    m.environment,
+    String(m.deployments),
+    m.deploymentFrequency.toFixed(2),
+    seconds(m.leadTimeSeconds),
+    m.changeFailureRate === undefined || m.changeFailureRate === null
+      ? '-'
+      : `${m.changeFailureRate.toFixed(1)}% (${m.failedDeployments}/${m.deployments})`,
+    seconds(m.mttrSeconds),
+    String(m.incidents),
+  ]);
+  return (
+    <CompactTable
+      variant="reference"
+      columns={['Environment', 'Deployments', 'Per Day', 'Lead Time', 'Change Failure Rate', 'MTTR', 'Incidents']}
+      data={rows}
+    />
+  );
+}
+
 export default function DynamicSection({ section }: Props) {
   if (section.type === 'changes' && !(section.changes ?? []).length) {
     return null;
@@ -182,6 +204,8 @@ export default function DynamicSection({ section }: Props) {
     content = <ChangesSection section={section} />;
   } else if (section.type === 'configs') {
     content = <ConfigsSection section={section} />;
+  } else if (section.type === 'dora') {
+    content = <DORASection section={section} />;
   }
 
   if (!content) {
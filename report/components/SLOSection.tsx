@@ -0,0 +1,58 @@
+import React from 'react';
+import { Section, Badge, CompactTable } from '@flanksource/facet';
+import type { ApplicationSLOStatus } from '../types.ts';
+import { formatDateTime } from './utils.ts';
+
+interface Props {
+  slos: ApplicationSLOStatus[];
+}
+
+function formatPercent(value?: number): string {
+  return value === undefined || value === null ? '—' : `${value.toFixed(2)}%`;
+}
+
+function budgetColor(budget?: number): string {
+  if (budget === undefined || budget === null) return 'text-gray-400';
+  if (budget <= 0) return 'text-red-600';
+  if (budget < 25) return 'text-orange-600';
+  return 'text-green-700';
+}
+
+function burnBadge(slo: ApplicationSLOStatus) {
+  if (slo.error) {
+    return <Badge variant="status" status="warning" value="error" size="xs" shape="rounded" />;
+  }
+  if (!slo.burn) {
+    return <Badge variant="status" status="success" value="ok" size="xs" shape="rounded" />;
+  }
+  return (
+    <Badge
+      variant="status"
+      status={slo.burn === 'fast' ? 'error' : 'warning'}
+      value={`${slo.burn} burn`}
+      size="xs"
+      shape="rounded"
+    />
+  );
+}
+
+export default function SLOSection({ slos }: Props) {
+  const columns = ['Objective', 'Target', 'Window', 'Attainment', 'Error Budget', 'Burn', 'Evaluated'];
+  const rows = slos.map((slo) => [
+    slo.description ? `${slo.name} — ${slo.description}` : slo.name,
+    `${slo.target}%`,
+    slo.window,
+    formatPercent(slo.attainment),
+    <span className={`font-semibold ${budgetColor(slo.errorBudgetRemaining)}`}>
+      {formatPercent(slo.errorBudgetRemaining)}
+    </span>,
+    burnBadge(slo),
+    formatDateTime(slo.evaluatedAt),
+  ]);
+
+  return (
+    <Section variant="hero" title="Service Level Objectives" size="md">
+      <CompactTable variant="reference" columns={columns} data={rows} />
+    </Section>
+  );
+}
@@ -0,0 +1,84 @@
+// Package findings exports config insights in the formats security tooling
+// expects: SARIF 2.1.0 for code scanning and OCSF findings for SIEMs.
+package findings
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+
+	"github.com/flanksource/duty/context"
+	"github.com/flanksource/duty/models"
+	"github.com/flanksource/duty/query"
+	"github.com/flanksource/duty/types"
+	"github.com/samber/lo"
+
+	"github.com/flanksource/incident-commander/api"
+)
+
+const (
+	ProductName = "Mission Control"
+	VendorName  = "Flanksource"
+	ProductURL  = "https://flanksource.com/docs"
+)
+
+// Fingerprint identifies a finding across exports so that downstream tools
+// can deduplicate it. An insight keeps its id while it's open, resolved or
+// silenced, and a config can have several insights of the same analyzer.
+func Fingerprint(a api.CatalogReportAnalysis) string {
+	sum := sha256.Sum256([]byte(key(a)))
+	return hex.EncodeToString(sum[:])
+}
+
+// key is the id of the insight, or what identifies insights without one.
+func key(a api.CatalogReportAnalysis) string {
+	if a.ID != "" {
+		return a.ID
+	}
+	return strings.Join([]string{a.ConfigID, a.Analyzer, a.AnalysisType, a.Source}, "/")
+}
+
+// FromCatalogReport returns the insights of the report, once each.
+func FromCatalogReport(r *api.CatalogReport) []api.CatalogReportAnalysis {
+	var all []api.CatalogReportAnalysis
+	for _, entry := range r.Entries {
+		all = append(all, entry.Analyses...)
+	}
+	for _, group := range r.ConfigGroups {
+		all = append(all, group.Analyses...)
+	}
+	all = append(all, r.Analyses...)
+
+	return lo.UniqBy(all, key)
+}
+
+// Query returns the insights of the configs matching the selectors.
+// Resolved insights are only included when includeResolved is set.
+func Query(ctx context.Context, includeResolved bool, selectors ...types.ResourceSelector) ([]api.CatalogReportAnalysis, error) {
+	configs, err := query.FindConfigsByResourceSelector(ctx, -1, selectors...)
+	if err != nil {
+		return nil, ctx.Oops().Wrapf(err, "failed to find configs")
+	}
+	if len(configs) == 0 {
+		return []api.CatalogReportAnalysis{}, nil
+	}
+
+	byID := lo.SliceToMap(configs, func(c models.ConfigItem) (string, models.ConfigItem) { return c.ID.String(), c })
+
+	q := ctx.DB().Where("config_id IN ?", lo.Keys(byID))
+	if !includeResolved {
+		q = q.Where("status != ?", models.AnalysisStatusResolved)
+	}
+
+	var analyses []models.ConfigAnalysis
+	if err := q.Order("config_id, analyzer").Find(&analyses).Error; err != nil {
+		return nil, ctx.Oops().Wrapf(err, "failed to find insights")
+	}
+
+	out := make([]api.CatalogReportAnalysis, 0, len(analyses))
+	for _, a := range analyses {
+		config := byID[a.ConfigID.String()]
+		out = append(out, api.NewCatalogReportAnalysis(a, lo.FromPtr(config.Name), lo.FromPtr(config.Type)))
+	}
+	return out, nil
+}
@@ -0,0 +1,152 @@
+package findings
+
+import (
+	"encoding/json"
+	"time"
+
+	ginkgo "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/flanksource/incident-commander/api"
+)
+
+var testAnalyses = []api.CatalogReportAnalysis{
+	{
+		ID:            "a1",
+		ConfigID:      "c1",
+		ConfigName:    "payments",
+		ConfigType:    "Kubernetes::Deployment",
+		Analyzer:      "privileged-container",
+		Summary:       "Container runs as privileged",
+		Message:       "spec.containers[0].securityContext.privileged is true",
+		Status:        "open",
+		Severity:      "high",
+		AnalysisType:  "security",
+		Source:        "kubescape",
+		FirstObserved: "2026-01-02T03:04:05Z",
+		LastObserved:  "2026-01-03T03:04:05Z",
+	},
+	{
+		ID:           "a2",
+		ConfigID:     "c2",
+		ConfigName:   "orders",
+		Analyzer:     "privileged-container",
+		Status:       "resolved",
+		Severity:     "high",
+		AnalysisType: "security",
+	},
+	{
+		ID:           "a3",
+		ConfigID:     "c1",
+		ConfigName:   "payments",
+		Analyzer:     "CIS-5.1.1",
+		Summary:      "Encryption at rest disabled",
+		Status:       "silenced",
+		Severity:     "medium",
+		AnalysisType: "compliance",
+		Source:       "CIS",
+	},
+}
+
+var _ = ginkgo.Describe("Fingerprint", func() {
+	ginkgo.It("is stable across exports of the same insight", func() {
+		a := testAnalyses[0]
+		b := a
+		b.Summary, b.Status, b.LastObserved = "changed", "resolved", ""
+		Expect(Fingerprint(a)).To(Equal(Fingerprint(b)))
+		Expect(Fingerprint(a)).NotTo(Equal(Fingerprint(testAnalyses[1])))
+	})
+
+	ginkgo.It("tells apart insights of the same analyzer on a config", func() {
+		a := testAnalyses[0]
+		b := a
+		b.ID, b.AnalysisType, b.Message = "a4", "compliance", "privileged containers aren't allowed by policy"
+		Expect(Fingerprint(a)).NotTo(Equal(Fingerprint(b)))
+
+		a.ID, b.ID = "", ""
+		Expect(Fingerprint(a)).NotTo(Equal(Fingerprint(b)))
+		Expect(FromCatalogReport(&api.CatalogReport{Analyses: []api.CatalogReportAnalysis{a, b, a}})).To(HaveLen(2))
+	})
+
+	ginkgo.It("deduplicates the insights of a report", func() {
+		r := &api.CatalogReport{
+			Entries:  []api.CatalogReportEntry{{Analyses: testAnalyses[:2]}},
+			Analyses: testAnalyses,
+		}
+		Expect(FromCatalogReport(r)).To(HaveLen(3))
+	})
+})
+
+var _ = ginkgo.Describe("SARIF", func() {
+	ginkgo.It("maps analyzers to rules and insights to results", func() {
+		log := NewSARIF(testAnalyses)
+		Expect(log.Version).To(Equal("2.1.0"))
+		Expect(log.Runs).To(HaveLen(1))
+
+		run := log.Runs[0]
+		Expect(run.Tool.Driver.Rules).To(HaveLen(2))
+		Expect(run.Results).To(HaveLen(3))
+
+		open := run.Results[0]
+		Expect(open.RuleID).To(Equal("privileged-container"))
+		Expect(open.RuleIndex).To(Equal(0))
+		Expect(open.Level).To(Equal("error"))
+		Expect(open.Message.Text).To(Equal("Container runs as privileged"))
+		Expect(open.PartialFingerprints).To(HaveKeyWithValue(fingerprintKey, Fingerprint(testAnalyses[0])))
+		Expect(open.Locations[0].LogicalLocations[0].FullyQualifiedName).To(Equal("Kubernetes::Deployment/payments"))
+		Expect(open.Properties).To(HaveKeyWithValue("security-severity", "8.0"))
+
+		resolved := run.Results[1]
+		Expect(resolved.Kind).To(Equal("pass"))
+		Expect(resolved.Level).To(Equal("none"))
+
+		silenced := run.Results[2]
+		Expect(silenced.RuleIndex).To(Equal(1))
+		Expect(silenced.Level).To(Equal("warning"))
+		Expect(silenced.Suppressions).To(HaveLen(1))
+	})
+
+	ginkgo.It("renders valid JSON", func() {
+		data, err := RenderSARIF(testAnalyses)
+		Expect(err).NotTo(HaveOccurred())
+
+		var out map[string]any
+		Expect(json.Unmarshal(data, &out)).To(Succeed())
+		Expect(out).To(HaveKeyWithValue("$schema", SARIFSchema))
+	})
+})
+
+var _ = ginkgo.Describe("OCSF", func() {
+	now := time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	ginkgo.It("maps security insights to vulnerability findings", func() {
+		findings := NewOCSF(testAnalyses, now)
+		Expect(findings).To(HaveLen(3))
+
+		f := findings[0]
+		Expect(f.ClassUID).To(Equal(OCSFClassVulnerabilityFinding))
+		Expect(f.TypeUID).To(Equal(200201))
+		Expect(f.SeverityID).To(Equal(4))
+		Expect(f.StatusID).To(Equal(1))
+		Expect(f.FindingInfo.UID).To(Equal(Fingerprint(testAnalyses[0])))
+		Expect(f.FindingInfo.FirstSeenTime).To(Equal(time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC).UnixMilli()))
+		Expect(f.Time).To(Equal(f.FindingInfo.LastSeenTime))
+		Expect(f.Vulnerabilities).To(HaveLen(1))
+		Expect(f.Resources).To(ConsistOf(OCSFResource{UID: "c1", Name: "payments", Type: "Kubernetes::Deployment"}))
+	})
+
+	ginkgo.It("closes resolved findings", func() {
+		f := NewOCSF(testAnalyses, now)[1]
+		Expect(f.ActivityID).To(Equal(3))
+		Expect(f.TypeUID).To(Equal(200203))
+		Expect(f.Status).To(Equal("Resolved"))
+		Expect(f.Time).To(Equal(now.UnixMilli()))
+	})
+
+	ginkgo.It("maps other insights to compliance findings", func() {
+		f := NewOCSF(testAnalyses, now)[2]
+		Expect(f.ClassUID).To(Equal(OCSFClassComplianceFinding))
+		Expect(f.Status).To(Equal("Suppressed"))
+		Expect(f.Compliance).To(Equal(&OCSFCompliance{Control: "CIS-5.1.1", Standards: []string{"CIS"}, Status: "Fail"}))
+	})
+})
@@ -0,0 +1,204 @@
+package findings
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/flanksource/duty/models"
+	"github.com/samber/lo"
+
+	"github.com/flanksource/incident-commander/api"
+)
+
+// OCSFVersion is the version of the OCSF schema the findings conform to.
+const OCSFVersion = "1.3.0"
+
+// OCSF classes of the Findings category.
+const (
+	OCSFCategoryFindings = 2
+
+	OCSFClassVulnerabilityFinding = 2002
+	OCSFClassComplianceFinding    = 2003
+)
+
+// OCSF activities of a finding.
+const (
+	ocsfActivityCreate = 1
+	ocsfActivityClose  = 3
+)
+
+type OCSFFinding struct {
+	ActivityID   int    `json:"activity_id"`
+	ActivityName string `json:"activity_name"`
+	CategoryUID  int    `json:"category_uid"`
+	CategoryName string `json:"category_name"`
+	ClassUID     int    `json:"class_uid"`
+	ClassName    string `json:"class_name"`
+	TypeUID      int    `json:"type_uid"`
+	SeverityID   int    `json:"severity_id"`
+	Severity     string `json:"severity"`
+	StatusID     int    `json:"status_id"`
+	Status       string `json:"status"`
+	// Time is the time of the event in milliseconds since the epoch.
+	Time    int64  `json:"time"`
+	Message string `json:"message,omitempty"`
+
+	Metadata        OCSFMetadata        `json:"metadata"`
+	FindingInfo     OCSFFindingInfo     `json:"finding_info"`
+	Resources       []OCSFResource      `json:"resources,omitempty"`
+	Compliance      *OCSFCompliance     `json:"compliance,omitempty"`
+	Vulnerabilities []OCSFVulnerability `json:"vulnerabilities,omitempty"`
+	Unmapped        map[string]any      `json:"unmapped,omitempty"`
+}
+
+type OCSFMetadata struct {
+	Version string      `json:"version"`
+	Product OCSFProduct `json:"product"`
+}
+
+type OCSFProduct struct {
+	Name       string `json:"name"`
+	VendorName string `json:"vendor_name"`
+	Version    string `json:"version,omitempty"`
+}
+
+type OCSFFindingInfo struct {
+	UID           string   `json:"uid"`
+	Title         string   `json:"title"`
+	Desc          string   `json:"desc,omitempty"`
+	Types         []string `json:"types,omitempty"`
+	SrcURL        string   `json:"src_url,omitempty"`
+	FirstSeenTime int64    `json:"first_seen_time,omitempty"`
+	LastSeenTime  int64    `json:"last_seen_time,omitempty"`
+}
+
+type OCSFResource struct {
+	UID  string `json:"uid"`
+	Name string `json:"name,omitempty"`
+	Type string `json:"type,omitempty"`
+}
+
+type OCSFCompliance struct {
+	Control   string   `json:"control,omitempty"`
+	Standards []string `json:"standards"`
+	Status    string   `json:"status,omitempty"`
+}
+
+type OCSFVulnerability struct {
+	Title    string `json:"title"`
+	Desc     string `json:"desc,omitempty"`
+	Severity string `json:"severity,omitempty"`
+}
+
+// NewOCSF maps security insights to Vulnerability Findings and all others to
+// Compliance Findings. Resolved insights close their finding.
+func NewOCSF(analyses []api.CatalogReportAnalysis, now time.Time) []OCSFFinding {
+	out := make([]OCSFFinding, 0, len(analyses))
+	for _, a := range analyses {
+		title := lo.CoalesceOrEmpty(a.Summary, a.Analyzer)
+		finding := OCSFFinding{
+			ActivityID:   ocsfActivityCreate,
+			ActivityName: "Create",
+			CategoryUID:  OCSFCategoryFindings,
+			CategoryName: "Findings",
+			SeverityID:   ocsfSeverityID(a.Severity),
+			Severity:     ocsfSeverityName(a.Severity),
+			StatusID:     1,
+			Status:       "New",
+			Time:         lo.CoalesceOrEmpty(parseMillis(a.LastObserved), now.UnixMilli()),
+			Message:      title,
+			Metadata: OCSFMetadata{
+				Version: OCSFVersion,
+				Product: OCSFProduct{Name: ProductName, VendorName: VendorName, Version: api.BuildVersion},
+			},
+			FindingInfo: OCSFFindingInfo{
+				UID:           Fingerprint(a),
+				Title:         title,
+				Desc:          a.Message,
+				Types:         lo.Compact([]string{a.AnalysisType}),
+				SrcURL:        a.Permalink,
+				FirstSeenTime: parseMillis(a.FirstObserved),
+				LastSeenTime:  parseMillis(a.LastObserved),
+			},
+		}
+
+		if a.ConfigID != "" {
+			finding.Resources = []OCSFResource{{UID: a.ConfigID, Name: a.ConfigName, Type: a.ConfigType}}
+		}
+
+		switch a.Status {
+		case models.AnalysisStatusResolved:
+			finding.ActivityID, finding.ActivityName = ocsfActivityClose, "Close"
+			finding.StatusID, finding.Status = 4, "Resolved"
+		case models.AnalysisStatusSilenced:
+			finding.StatusID, finding.Status = 3, "Suppressed"
+		}
+
+		if models.AnalysisType(a.AnalysisType) == models.AnalysisTypeSecurity {
+			finding.ClassUID, finding.ClassName = OCSFClassVulnerabilityFinding, "Vulnerability Finding"
+			finding.Vulnerabilities = []OCSFVulnerability{{Title: title, Desc: a.Message, Severity: finding.Severity}}
+		} else {
+			finding.ClassUID, finding.ClassName = OCSFClassComplianceFinding, "Compliance Finding"
+			finding.Compliance = &OCSFCompliance{
+				Control:   a.Analyzer,
+				Standards: lo.Compact([]string{a.Source}),
+				Status:    lo.Ternary(a.Status == models.AnalysisStatusResolved, "Pass", "Fail"),
+			}
+		}
+		finding.TypeUID = finding.ClassUID*100 + finding.ActivityID
+
+		if len(a.Properties) > 0 {
+			finding.Unmapped = map[string]any{"properties": a.Properties}
+		}
+
+		out = append(out, finding)
+	}
+	return out
+}
+
+// RenderOCSF renders the insights as a JSON array of OCSF findings.
+func RenderOCSF(analyses []api.CatalogReportAnalysis, now time.Time) ([]byte, error) {
+	return json.MarshalIndent(NewOCSF(analyses, now), "", "  ")
+}
+
+func ocsfSeverityID(severity string) int {
+	switch models.Severity(severity) {
+	case models.SeverityCritical:
+		return 5
+	case models.SeverityHigh:
+		return 4
+	case models.SeverityMedium:
+		return 3
+	case models.SeverityLow:
+		return 2
+	case models.SeverityInfo:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func ocsfSeverityName(severity string) string {
+	switch models.Severity(severity) {
+	case models.SeverityCritical:
+		return "Critical"
+	case models.SeverityHigh:
+		return "High"
+	case models.SeverityMedium:
+		return "Medium"
+	case models.SeverityLow:
+		return "Low"
+	case models.SeverityInfo:
+		return "Informational"
+	default:
+		return "Unknown"
+	}
+}
+
+func parseMillis(value string) int64 {
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return 0
+	}
+	return t.UnixMilli()
+}
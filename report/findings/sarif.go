@@ -0,0 +1,207 @@
+package findings
+
+import (
+	"encoding/json"
+
+	"github.com/flanksource/duty/models"
+	"github.com/samber/lo"
+
+	"github.com/flanksource/incident-commander/api"
+)
+
+const (
+	SARIFVersion     = "2.1.0"
+	SARIFSchema      = "https://json.schemastore.org/sarif-2.1.0.json"
+	SARIFContentType = "application/sarif+json"
+
+	// fingerprintKey is the key of the partial fingerprint of each result.
+	fingerprintKey = "missionControlFingerprint/v1"
+)
+
+type SARIFLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []SARIFRun `json:"runs"`
+}
+
+type SARIFRun struct {
+	Tool    SARIFTool     `json:"tool"`
+	Results []SARIFResult `json:"results"`
+}
+
+type SARIFTool struct {
+	Driver SARIFDriver `json:"driver"`
+}
+
+type SARIFDriver struct {
+	Name           string      `json:"name"`
+	Organization   string      `json:"organization,omitempty"`
+	InformationURI string      `json:"informationUri,omitempty"`
+	Version        string      `json:"version,omitempty"`
+	Rules          []SARIFRule `json:"rules"`
+}
+
+type SARIFRule struct {
+	ID         string          `json:"id"`
+	Name       string          `json:"name,omitempty"`
+	Properties SARIFProperties `json:"properties,omitempty"`
+}
+
+type SARIFMessage struct {
+	Text string `json:"text"`
+}
+
+type SARIFResult struct {
+	RuleID              string             `json:"ruleId"`
+	RuleIndex           int                `json:"ruleIndex"`
+	Kind                string             `json:"kind,omitempty"`
+	Level               string             `json:"level"`
+	Message             SARIFMessage       `json:"message"`
+	Locations           []SARIFLocation    `json:"locations"`
+	PartialFingerprints map[string]string  `json:"partialFingerprints"`
+	Suppressions        []SARIFSuppression `json:"suppressions,omitempty"`
+	Properties          SARIFProperties    `json:"properties,omitempty"`
+}
+
+type SARIFLocation struct {
+	PhysicalLocation *SARIFPhysicalLocation `json:"physicalLocation,omitempty"`
+	LogicalLocations []SARIFLogicalLocation `json:"logicalLocations,omitempty"`
+}
+
+type SARIFPhysicalLocation struct {
+	ArtifactLocation SARIFArtifactLocation `json:"artifactLocation"`
+}
+
+type SARIFArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type SARIFLogicalLocation struct {
+	Name               string `json:"name,omitempty"`
+	FullyQualifiedName string `json:"fullyQualifiedName,omitempty"`
+	Kind               string `json:"kind,omitempty"`
+}
+
+type SARIFSuppression struct {
+	Kind string `json:"kind"`
+}
+
+type SARIFProperties map[string]any
+
+// NewSARIF maps the insights to the results of a single run. Each analyzer
+// is a rule. Resolved insights are reported as passing results and silenced
+// ones as suppressed.
+func NewSARIF(analyses []api.CatalogReportAnalysis) SARIFLog {
+	rules := []SARIFRule{}
+	ruleIndex := map[string]int{}
+	for _, a := range analyses {
+		if _, ok := ruleIndex[a.Analyzer]; ok {
+			continue
+		}
+		ruleIndex[a.Analyzer] = len(rules)
+		rule := SARIFRule{ID: a.Analyzer, Name: a.Analyzer, Properties: SARIFProperties{}}
+		if a.AnalysisType != "" {
+			rule.Properties["tags"] = []string{a.AnalysisType}
+		}
+		if a.Source != "" {
+			rule.Properties["source"] = a.Source
+		}
+		rules = append(rules, rule)
+	}
+
+	results := make([]SARIFResult, 0, len(analyses))
+	for _, a := range analyses {
+		result := SARIFResult{
+			RuleID:              a.Analyzer,
+			RuleIndex:           ruleIndex[a.Analyzer],
+			Level:               sarifLevel(a.Severity),
+			Message:             SARIFMessage{Text: lo.CoalesceOrEmpty(a.Summary, a.Message, a.Analyzer)},
+			Locations:           []SARIFLocation{sarifLocation(a)},
+			PartialFingerprints: map[string]string{fingerprintKey: Fingerprint(a)},
+			Properties: SARIFProperties{
+				"security-severity": securitySeverity(a.Severity),
+				"configId":          a.ConfigID,
+			},
+		}
+		switch a.Status {
+		case models.AnalysisStatusResolved:
+			result.Kind, result.Level = "pass", "none"
+		case models.AnalysisStatusSilenced:
+			result.Suppressions = []SARIFSuppression{{Kind: "external"}}
+		}
+		if a.Message != "" && a.Message != result.Message.Text {
+			result.Properties["details"] = a.Message
+		}
+		if a.FirstObserved != "" {
+			result.Properties["firstObserved"] = a.FirstObserved
+		}
+		if a.LastObserved != "" {
+			result.Properties["lastObserved"] = a.LastObserved
+		}
+		results = append(results, result)
+	}
+
+	return SARIFLog{
+		Schema:  SARIFSchema,
+		Version: SARIFVersion,
+		Runs: []SARIFRun{{
+			Tool: SARIFTool{Driver: SARIFDriver{
+				Name:           ProductName,
+				Organization:   VendorName,
+				InformationURI: ProductURL,
+				Version:        api.BuildVersion,
+				Rules:          rules,
+			}},
+			Results: results,
+		}},
+	}
+}
+
+// RenderSARIF renders the insights as a SARIF log.
+func RenderSARIF(analyses []api.CatalogReportAnalysis) ([]byte, error) {
+	return json.MarshalIndent(NewSARIF(analyses), "", "  ")
+}
+
+// sarifLocation locates the finding on its config. Configs aren't files so
+// the artifact is the config's permalink.
+func sarifLocation(a api.CatalogReportAnalysis) SARIFLocation {
+	location := SARIFLocation{
+		LogicalLocations: []SARIFLogicalLocation{{
+			Name:               a.ConfigName,
+			FullyQualifiedName: lo.Ternary(a.ConfigType != "", a.ConfigType+"/"+a.ConfigName, a.ConfigName),
+			Kind:               "resource",
+		}},
+	}
+	if uri := lo.CoalesceOrEmpty(a.Permalink, lo.Ternary(a.ConfigID != "", "config/"+a.ConfigID, "")); uri != "" {
+		location.PhysicalLocation = &SARIFPhysicalLocation{ArtifactLocation: SARIFArtifactLocation{URI: uri}}
+	}
+	return location
+}
+
+func sarifLevel(severity string) string {
+	switch models.Severity(severity) {
+	case models.SeverityCritical, models.SeverityHigh:
+		return "error"
+	case models.SeverityMedium:
+		return "warning"
+	default:
+		return "note"
+	}
+}
+
+// securitySeverity is the CVSS-like score GitHub code scanning uses to rank
+// results.
+func securitySeverity(severity string) string {
+	switch models.Severity(severity) {
+	case models.SeverityCritical:
+		return "9.5"
+	case models.SeverityHigh:
+		return "8.0"
+	case models.SeverityMedium:
+		return "5.5"
+	case models.SeverityLow:
+		return "2.0"
+	default:
+		return "0.0"
+	}
+}
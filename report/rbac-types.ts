@@ -30,6 +30,7 @@ export interface RBACResource {
   users: RBACUserRole[];
   changelog: RBACChangeEntry[];
   temporaryAccess?: RBACTemporaryAccess[];
+  elevations?: RBACElevation[];
 }
 
 export interface RBACChangeEntry {
@@ -53,6 +54,18 @@ export interface RBACTemporaryAccess {
   duration: string;
 }
 
+export interface RBACElevation {
+  configId: string;
+  user: string;
+  email: string;
+  role: string;
+  reason: string;
+  status: string;
+  grantedAt: string;
+  expiresAt: string;
+  endedAt?: string | null;
+}
+
 export interface RBACSummary {
   totalUsers: number;
   totalResources: number;
@@ -163,13 +163,65 @@ export interface ApplicationConfigItem {
   labels?: Record<string, string>;
 }
 
+export interface ApplicationDORAMetrics {
+  environment: string;
+  window: string;
+  from: string;
+  to: string;
+  deployments: number;
+  deploymentFrequency: number;
+  leadTimeSeconds?: number;
+  failedDeployments: number;
+  changeFailureRate?: number;
+  incidents: number;
+  restores: number;
+  mttrSeconds?: number;
+}
+
 export interface ApplicationSection {
-  type: 'view' | 'changes' | 'configs';
+  type: 'view' | 'changes' | 'configs' | 'dora';
   title: string;
   icon?: string;
   view?: ApplicationViewData;
   changes?: ApplicationChange[];
   configs?: ApplicationConfigItem[];
+  dora?: ApplicationDORAMetrics[];
+}
+
+export interface ApplicationSLOStatus {
+  name: string;
+  description?: string;
+  target: number;
+  window: string;
+  good: number;
+  total: number;
+  attainment?: number;
+  errorBudgetRemaining?: number;
+  burnRates?: Record<string, number>;
+  burn?: 'fast' | 'slow';
+  evaluatedAt: string;
+  error?: string;
+}
+
+export interface BackupViolation {
+  objective: 'rpo' | 'rto' | 'restore_test';
+  target: string;
+  message: string;
+}
+
+export interface ApplicationBackupCompliance {
+  configId: string;
+  name: string;
+  type?: string;
+  environment?: string;
+  rpo?: string;
+  rto?: string;
+  restoreTestInterval?: string;
+  lastBackup?: string;
+  lastRestore?: string;
+  restoreDurationSeconds?: number;
+  compliant: boolean;
+  violations?: BackupViolation[];
 }
 
 export interface Application {
@@ -186,5 +238,7 @@ export interface Application {
   backups: ApplicationBackup[];
   restores: ApplicationBackupRestore[];
   findings: ApplicationFinding[];
+  slos: ApplicationSLOStatus[];
+  backupCompliance: ApplicationBackupCompliance[];
   sections: ApplicationSection[];
 }
@@ -0,0 +1,387 @@
+// Package xlsx renders tabular report data as an Excel workbook with one
+// worksheet per section, typed cells, frozen headers, auto-filters and
+// hyperlinks.
+package xlsx
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/xuri/excelize/v2"
+)
+
+const (
+	ContentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	Extension   = ".xlsx"
+)
+
+// Excel limits.
+const (
+	maxSheetName   = 31
+	maxCellLength  = 32767
+	maxColumnWidth = 60
+	minColumnWidth = 8
+)
+
+// CellType is the type of the cells of a column.
+type CellType string
+
+const (
+	String   CellType = "string"
+	Number   CellType = "number"
+	Integer  CellType = "integer"
+	Boolean  CellType = "boolean"
+	DateTime CellType = "datetime"
+	// Duration cells hold a time.Duration or nanoseconds.
+	Duration CellType = "duration"
+)
+
+// Column is a column of a worksheet.
+type Column struct {
+	Name string
+	Type CellType
+}
+
+// Link is a cell value rendered as a hyperlink.
+type Link struct {
+	Text string
+	URL  string
+}
+
+// NewLink returns a hyperlink cell, or just the text when there's no URL.
+func NewLink(text, url string) any {
+	if url == "" {
+		return text
+	}
+	return Link{Text: text, URL: url}
+}
+
+// Sheet is a worksheet. Rows are positional and match the columns.
+type Sheet struct {
+	Name    string
+	Columns []Column
+	Rows    [][]any
+}
+
+// AddRow appends a row to the sheet.
+func (s *Sheet) AddRow(values ...any) {
+	s.Rows = append(s.Rows, values)
+}
+
+type styles struct {
+	header, link, dateTime, duration int
+}
+
+// Render returns the workbook of the given sheets.
+// Sheet names are made valid and unique as Excel requires.
+func Render(sheets ...Sheet) ([]byte, error) {
+	file := excelize.NewFile()
+	defer func() { _ = file.Close() }()
+
+	s, err := newStyles(file)
+	if err != nil {
+		return nil, err
+	}
+
+	used := map[string]bool{}
+	for i, sheet := range sheets {
+		name := SheetName(sheet.Name, used)
+		if i == 0 {
+			if err := file.SetSheetName(file.GetSheetName(0), name); err != nil {
+				return nil, fmt.Errorf("failed to rename sheet %s: %w", name, err)
+			}
+		} else if _, err := file.NewSheet(name); err != nil {
+			return nil, fmt.Errorf("failed to create sheet %s: %w", name, err)
+		}
+
+		if err := writeSheet(file, s, name, sheet); err != nil {
+			return nil, fmt.Errorf("sheet %s: %w", name, err)
+		}
+	}
+
+	buf, err := file.WriteToBuffer()
+	if err != nil {
+		return nil, fmt.Errorf("failed to write workbook: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func newStyles(file *excelize.File) (*styles, error) {
+	var s styles
+	var err error
+
+	if s.header, err = file.NewStyle(&excelize.Style{
+		Font:   &excelize.Font{Bold: true},
+		Fill:   excelize.Fill{Type: "pattern", Color: []string{"#E8F4FD"}, Pattern: 1},
+		Border: []excelize.Border{{Type: "bottom", Color: "#000000", Style: 1}},
+	}); err != nil {
+		return nil, fmt.Errorf("failed to create header style: %w", err)
+	}
+
+	if s.link, err = file.NewStyle(&excelize.Style{
+		Font: &excelize.Font{Color: "#1265BE", Underline: "single"},
+	}); err != nil {
+		return nil, fmt.Errorf("failed to create link style: %w", err)
+	}
+
+	dateTimeFormat := "yyyy-mm-dd hh:mm:ss"
+	if s.dateTime, err = file.NewStyle(&excelize.Style{CustomNumFmt: &dateTimeFormat}); err != nil {
+		return nil, fmt.Errorf("failed to create datetime style: %w", err)
+	}
+
+	durationFormat := "[h]:mm:ss"
+	if s.duration, err = file.NewStyle(&excelize.Style{CustomNumFmt: &durationFormat}); err != nil {
+		return nil, fmt.Errorf("failed to create duration style: %w", err)
+	}
+
+	return &s, nil
+}
+
+func writeSheet(file *excelize.File, s *styles, name string, sheet Sheet) error {
+	if len(sheet.Columns) == 0 {
+		return nil
+	}
+
+	widths := make([]int, len(sheet.Columns))
+	for i, col := range sheet.Columns {
+		cell, _ := excelize.CoordinatesToCellName(i+1, 1)
+		if err := file.SetCellStr(name, cell, col.Name); err != nil {
+			return err
+		}
+		widths[i] = len(col.Name)
+	}
+
+	for r, row := range sheet.Rows {
+		for i, col := range sheet.Columns {
+			if i >= len(row) {
+				break
+			}
+
+			cell, _ := excelize.CoordinatesToCellName(i+1, r+2)
+			width, err := writeCell(file, s, name, cell, col.Type, row[i])
+			if err != nil {
+				return fmt.Errorf("cell %s: %w", cell, err)
+			}
+			widths[i] = max(widths[i], width)
+		}
+	}
+
+	lastColumn, _ := excelize.ColumnNumberToName(len(sheet.Columns))
+	if err := file.SetCellStyle(name, "A1", lastColumn+"1", s.header); err != nil {
+		return err
+	}
+
+	for i, width := range widths {
+		column, _ := excelize.ColumnNumberToName(i + 1)
+		if err := file.SetColWidth(name, column, column, float64(min(max(width+2, minColumnWidth), maxColumnWidth))); err != nil {
+			return err
+		}
+	}
+
+	if err := file.SetPanes(name, &excelize.Panes{
+		Freeze:      true,
+		YSplit:      1,
+		TopLeftCell: "A2",
+		ActivePane:  "bottomLeft",
+	}); err != nil {
+		return fmt.Errorf("failed to freeze header: %w", err)
+	}
+
+	if err := file.AutoFilter(name, fmt.Sprintf("A1:%s%d", lastColumn, len(sheet.Rows)+1), nil); err != nil {
+		return fmt.Errorf("failed to add auto filter: %w", err)
+	}
+
+	return nil
+}
+
+// writeCell writes the value as the type of the column and returns the
+// display width of the cell.
+func writeCell(file *excelize.File, s *styles, sheet, cell string, cellType CellType, value any) (int, error) {
+	switch v := value.(type) {
+	case nil:
+		return 0, nil
+	case Link:
+		if err := file.SetCellStr(sheet, cell, v.Text); err != nil {
+			return 0, err
+		}
+		if v.URL != "" {
+			if err := file.SetCellHyperLink(sheet, cell, v.URL, "External"); err != nil {
+				return 0, err
+			}
+			if err := file.SetCellStyle(sheet, cell, cell, s.link); err != nil {
+				return 0, err
+			}
+		}
+		return len(v.Text), nil
+	case *time.Time:
+		if v == nil {
+			return 0, nil
+		}
+		return writeCell(file, s, sheet, cell, cellType, *v)
+	case *string:
+		if v == nil {
+			return 0, nil
+		}
+		return writeCell(file, s, sheet, cell, cellType, *v)
+	}
+
+	switch cellType {
+	case DateTime:
+		if t, ok := toTime(value); ok {
+			if err := file.SetCellValue(sheet, cell, t.UTC()); err != nil {
+				return 0, err
+			}
+			return 19, file.SetCellStyle(sheet, cell, cell, s.dateTime)
+		}
+
+	case Duration:
+		if d, ok := toDuration(value); ok {
+			// Excel durations are fractions of a day.
+			if err := file.SetCellFloat(sheet, cell, d.Hours()/24, -1, 64); err != nil {
+				return 0, err
+			}
+			return 10, file.SetCellStyle(sheet, cell, cell, s.duration)
+		}
+
+	case Number, Integer:
+		if f, ok := toFloat(value); ok {
+			if cellType == Integer {
+				return len(strconv.FormatInt(int64(f), 10)), file.SetCellInt(sheet, cell, int64(f))
+			}
+			return len(strconv.FormatFloat(f, 'f', -1, 64)), file.SetCellFloat(sheet, cell, f, -1, 64)
+		}
+
+	case Boolean:
+		if b, ok := value.(bool); ok {
+			return 5, file.SetCellBool(sheet, cell, b)
+		} else if b, err := strconv.ParseBool(fmt.Sprint(value)); err == nil {
+			return 5, file.SetCellBool(sheet, cell, b)
+		}
+	}
+
+	text := truncate(toString(value), maxCellLength)
+	return utf8.RuneCountInString(text), file.SetCellStr(sheet, cell, text)
+}
+
+func toString(value any) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	case time.Time:
+		return v.Format(time.RFC3339)
+	case fmt.Stringer:
+		return v.String()
+	case map[string]any, []any, map[string]string, []string:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(b)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func toTime(value any) (time.Time, bool) {
+	switch v := value.(type) {
+	case time.Time:
+		return v, !v.IsZero()
+	case string:
+		for _, layout := range []string{time.RFC3339Nano, "2006-01-02 15:04:05.999999-07", "2006-01-02 15:04:05", "2006-01-02"} {
+			if t, err := time.Parse(layout, v); err == nil {
+				return t, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+func toDuration(value any) (time.Duration, bool) {
+	switch v := value.(type) {
+	case time.Duration:
+		return v, true
+	case string:
+		d, err := time.ParseDuration(v)
+		return d, err == nil
+	}
+
+	if f, ok := toFloat(value); ok {
+		return time.Duration(f), true
+	}
+	return 0, false
+}
+
+func toFloat(value any) (float64, bool) {
+	switch v := value.(type) {
+	case int:
+		return float64(v), true
+	case int8:
+		return float64(v), true
+	case int16:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint:
+		return float64(v), true
+	case uint8:
+		return float64(v), true
+	case uint16:
+		return float64(v), true
+	case uint32:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case float32:
+		return float64(v), true
+	case float64:
+		return v, true
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// SheetName returns a valid worksheet name that isn't in used, and marks it
+// as used. Excel names are at most 31 characters, can't contain []:*?/\ and
+// are case-insensitively unique.
+func SheetName(name string, used map[string]bool) string {
+	name = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`[]:*?/\`, r) {
+			return '-'
+		}
+		return r
+	}, strings.TrimSpace(name))
+	name = strings.Trim(name, "'")
+	if name == "" {
+		name = "Sheet"
+	}
+
+	candidate := truncate(name, maxSheetName)
+	for i := 2; used[strings.ToLower(candidate)]; i++ {
+		suffix := fmt.Sprintf(" (%d)", i)
+		candidate = truncate(name, maxSheetName-len(suffix)) + suffix
+	}
+
+	used[strings.ToLower(candidate)] = true
+	return candidate
+}
+
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n])
+}
@@ -0,0 +1,103 @@
+package xlsx
+
+import (
+	"bytes"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	ginkgo "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/xuri/excelize/v2"
+)
+
+var _ = ginkgo.Describe("XLSX", func() {
+	ginkgo.Describe("SheetName", func() {
+		ginkgo.It("removes invalid characters", func() {
+			Expect(SheetName("a/b:c[d]", map[string]bool{})).To(Equal("a-b-c-d-"))
+		})
+
+		ginkgo.It("truncates long names", func() {
+			Expect(SheetName("abcdefghijklmnopqrstuvwxyz0123456789", map[string]bool{})).To(HaveLen(31))
+		})
+
+		ginkgo.It("dedupes names case-insensitively", func() {
+			used := map[string]bool{}
+			Expect(SheetName("Changes", used)).To(Equal("Changes"))
+			Expect(SheetName("changes", used)).To(Equal("changes (2)"))
+			Expect(SheetName("", used)).To(Equal("Sheet"))
+		})
+	})
+
+	ginkgo.Describe("Render", func() {
+		created := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+		sheet := Sheet{Name: "Configs", Columns: []Column{
+			{Name: "Name"},
+			{Name: "Count", Type: Integer},
+			{Name: "Healthy", Type: Boolean},
+			{Name: "Created", Type: DateTime},
+			{Name: "Age", Type: Duration},
+		}}
+		sheet.AddRow(Link{Text: "api", URL: "https://example.com/catalog/1"}, 3, true, created, 36*time.Hour)
+		sheet.AddRow("db", "4", "false", created.Format(time.RFC3339), nil)
+
+		var file *excelize.File
+
+		ginkgo.BeforeEach(func() {
+			data, err := Render(sheet, Sheet{Name: "Configs", Columns: []Column{{Name: "Name"}}})
+			Expect(err).ToNot(HaveOccurred())
+
+			file, err = excelize.OpenReader(bytes.NewReader(data))
+			Expect(err).ToNot(HaveOccurred())
+			ginkgo.DeferCleanup(file.Close)
+		})
+
+		ginkgo.It("creates a sheet for each section", func() {
+			Expect(file.GetSheetList()).To(Equal([]string{"Configs", "Configs (2)"}))
+		})
+
+		ginkgo.It("writes typed cells", func() {
+			rows, err := file.GetRows("Configs", excelize.Options{RawCellValue: true})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(rows[0]).To(Equal([]string{"Name", "Count", "Healthy", "Created", "Age"}))
+			Expect(rows[1][:3]).To(Equal([]string{"api", "3", "1"}))
+			Expect(rows[1][4]).To(Equal("1.5"))
+			Expect(rows[2][:3]).To(Equal([]string{"db", "4", "0"}))
+
+			cellType, err := file.GetCellType("Configs", "D3")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(cellType).ToNot(Equal(excelize.CellTypeSharedString))
+		})
+
+		ginkgo.It("truncates long text on a character boundary", func() {
+			long := Sheet{Name: "Long", Columns: []Column{{Name: "Text"}}}
+			long.AddRow(strings.Repeat("é", maxCellLength+1))
+
+			data, err := Render(long)
+			Expect(err).ToNot(HaveOccurred())
+			f, err := excelize.OpenReader(bytes.NewReader(data))
+			Expect(err).ToNot(HaveOccurred())
+			defer f.Close()
+
+			text, err := f.GetCellValue("Long", "A2")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(utf8.ValidString(text)).To(BeTrue())
+			Expect(utf8.RuneCountInString(text)).To(Equal(maxCellLength))
+		})
+
+		ginkgo.It("links cells", func() {
+			ok, url, err := file.GetCellHyperLink("Configs", "A2")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ok).To(BeTrue())
+			Expect(url).To(Equal("https://example.com/catalog/1"))
+		})
+
+		ginkgo.It("freezes the header", func() {
+			panes, err := file.GetPanes("Configs")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(panes.Freeze).To(BeTrue())
+			Expect(panes.YSplit).To(Equal(1))
+		})
+	})
+})
@@ -0,0 +1,217 @@
+package scim
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/flanksource/commons/logger"
+	dutyAPI "github.com/flanksource/duty/api"
+	"github.com/flanksource/duty/context"
+	dutyRBAC "github.com/flanksource/duty/rbac"
+	"github.com/flanksource/duty/rbac/policy"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/samber/lo"
+
+	"github.com/flanksource/incident-commander/api"
+	"github.com/flanksource/incident-commander/auth"
+	echoSrv "github.com/flanksource/incident-commander/echo"
+	"github.com/flanksource/incident-commander/rbac"
+)
+
+const (
+	BasePath = "/scim/v2"
+
+	defaultPageSize = 100
+	maxPageSize     = 1000
+)
+
+func init() {
+	echoSrv.RegisterRoutes(RegisterRoutes)
+}
+
+func RegisterRoutes(e *echo.Echo) {
+	logger.Infof("Registering %s routes", BasePath)
+
+	g := e.Group(BasePath, Authenticate)
+	g.GET("/ServiceProviderConfig", ServiceProviderConfig)
+	g.GET("/ResourceTypes", ResourceTypes)
+
+	g.GET("/Users", ListUsers)
+	g.POST("/Users", CreateUser)
+	g.GET("/Users/:id", GetUser)
+	g.PUT("/Users/:id", ReplaceUser)
+	g.PATCH("/Users/:id", PatchUser)
+	g.DELETE("/Users/:id", DeleteUser)
+
+	g.GET("/Groups", ListGroups)
+	g.POST("/Groups", CreateGroup)
+	g.GET("/Groups/:id", GetGroup)
+	g.PUT("/Groups/:id", ReplaceGroup)
+	g.PATCH("/Groups/:id", PatchGroup)
+	g.DELETE("/Groups/:id", DeleteGroup)
+}
+
+// Authenticate only accepts access tokens that were explicitly scoped to the
+// scim object. Tokens that merely inherit the permission from their creator,
+// e.g. unscoped tokens of admins, are rejected. The networks of the token
+// are enforced like on every other route.
+func Authenticate(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		ctx := c.Request().Context().(context.Context)
+
+		header := c.Request().Header.Get(echo.HeaderAuthorization)
+		token, ok := strings.CutPrefix(header, "Bearer ")
+		if !ok || token == "" {
+			c.Response().Header().Set(echo.HeaderWWWAuthenticate, `Bearer realm="scim"`)
+			return writeError(c, dutyAPI.Errorf(dutyAPI.EUNAUTHORIZED, "bearer access token required"))
+		}
+
+		_, person, err := auth.AuthenticateAccessToken(ctx, token)
+		if err != nil {
+			c.Response().Header().Set(echo.HeaderWWWAuthenticate, `Bearer realm="scim"`)
+			return writeError(c, dutyAPI.Errorf(dutyAPI.EUNAUTHORIZED, "invalid access token"))
+		}
+
+		action := lo.Ternary(c.Request().Method == http.MethodPut, policy.ActionUpdate, dutyRBAC.GetActionFromHttpMethod(c.Request().Method))
+		ctx = ctx.WithUser(person)
+		if !hasSCIMScope(person.ID, action) || !dutyRBAC.CheckContext(ctx, rbac.ObjectSCIM, action) {
+			return writeError(c, dutyAPI.Errorf(dutyAPI.EFORBIDDEN, "access token is not scoped to %s:%s", rbac.ObjectSCIM, action))
+		}
+		if err := rbac.CheckTokenScope(c, ctx, rbac.ObjectSCIM, action); err != nil {
+			return writeError(c, dutyAPI.Errorf(dutyAPI.EFORBIDDEN, "%s", err.Error()))
+		}
+
+		c.SetRequest(c.Request().WithContext(ctx))
+		return next(c)
+	}
+}
+
+// hasSCIMScope reports whether the token person was explicitly granted the
+// action on the scim object.
+func hasSCIMScope(personID uuid.UUID, action string) bool {
+	if dutyRBAC.Enforcer() == nil || action == "" {
+		return false
+	}
+
+	permissions, err := dutyRBAC.Enforcer().GetPermissionsForUser(personID.String())
+	if err != nil {
+		logger.Errorf("failed to get permissions of %s: %v", personID, err)
+		return false
+	}
+
+	// [subject, object, action, effect, condition, id]
+	return lo.ContainsBy(permissions, func(p []string) bool {
+		return len(p) > 3 && p[1] == rbac.ObjectSCIM && (p[2] == action || p[2] == "*") && p[3] == "allow"
+	})
+}
+
+func ServiceProviderConfig(c echo.Context) error {
+	return writeJSON(c, http.StatusOK, map[string]any{
+		"schemas":        []string{SchemaServiceProviderConfig},
+		"patch":          map[string]bool{"supported": true},
+		"bulk":           map[string]any{"supported": false, "maxOperations": 0, "maxPayloadSize": 0},
+		"filter":         map[string]any{"supported": true, "maxResults": maxPageSize},
+		"changePassword": map[string]bool{"supported": false},
+		"sort":           map[string]bool{"supported": false},
+		"etag":           map[string]bool{"supported": false},
+		"authenticationSchemes": []map[string]any{{
+			"type":        "oauthbearertoken",
+			"name":        "Access Token",
+			"description": "Mission Control access token scoped to the scim object",
+			"primary":     true,
+		}},
+	})
+}
+
+func ResourceTypes(c echo.Context) error {
+	resourceTypes := []any{
+		map[string]any{
+			"schemas":  []string{SchemaResourceType},
+			"id":       "User",
+			"name":     "User",
+			"endpoint": "/Users",
+			"schema":   SchemaUser,
+		},
+		map[string]any{
+			"schemas":  []string{SchemaResourceType},
+			"id":       "Group",
+			"name":     "Group",
+			"endpoint": "/Groups",
+			"schema":   SchemaGroup,
+		},
+	}
+	return writeJSON(c, http.StatusOK, ListResponse{
+		Schemas:      []string{SchemaListResponse},
+		TotalResults: int64(len(resourceTypes)),
+		StartIndex:   1,
+		ItemsPerPage: len(resourceTypes),
+		Resources:    resourceTypes,
+	})
+}
+
+// pagination returns the 1-based start index and the page size of a list request.
+func pagination(c echo.Context) (startIndex, count int) {
+	startIndex, _ = strconv.Atoi(c.QueryParam("startIndex"))
+	startIndex = max(startIndex, 1)
+
+	count, err := strconv.Atoi(c.QueryParam("count"))
+	if err != nil {
+		count = defaultPageSize
+	}
+	return startIndex, min(max(count, 0), maxPageSize)
+}
+
+func listResponse[T any](resources []T, total int64, startIndex int) ListResponse {
+	return ListResponse{
+		Schemas:      []string{SchemaListResponse},
+		TotalResults: total,
+		StartIndex:   startIndex,
+		ItemsPerPage: len(resources),
+		Resources:    lo.Map(resources, func(r T, _ int) any { return r }),
+	}
+}
+
+func writeJSON(c echo.Context, status int, body any) error {
+	c.Response().Header().Set(echo.HeaderContentType, ContentType)
+	return c.JSON(status, body)
+}
+
+// writeError writes the error in the SCIM error format.
+func writeError(c echo.Context, err error) error {
+	code := dutyAPI.ErrorCode(err)
+	status := dutyAPI.ErrorStatusCode(code)
+
+	scimErr := Error{
+		Schemas: []string{SchemaError},
+		Status:  strconv.Itoa(status),
+		Detail:  dutyAPI.ErrorMessage(err),
+	}
+	switch code {
+	case dutyAPI.ECONFLICT:
+		scimErr.ScimType = "uniqueness"
+	case dutyAPI.EINVALID:
+		scimErr.ScimType = "invalidValue"
+	}
+
+	if status >= http.StatusInternalServerError {
+		logger.Errorf("scim %s %s: %v", c.Request().Method, c.Request().URL.Path, err)
+		scimErr.Detail = "internal error"
+	}
+
+	return writeJSON(c, status, scimErr)
+}
+
+// bind decodes the request body, which identity providers send as application/scim+json.
+func bind(c echo.Context, out any) error {
+	if err := json.NewDecoder(c.Request().Body).Decode(out); err != nil {
+		return dutyAPI.Errorf(dutyAPI.EINVALID, "invalid request body: %v", err)
+	}
+	return nil
+}
+
+func location(resource, id string) string {
+	return strings.TrimRight(api.PublicURL, "/") + BasePath + "/" + resource + "/" + id
+}
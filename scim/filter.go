@@ -0,0 +1,44 @@
+package scim
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	dutyAPI "github.com/flanksource/duty/api"
+)
+
+// filterPattern matches the equality filters identity providers use to look
+// up existing resources, e.g. `userName eq "jane@example.com"`.
+var filterPattern = regexp.MustCompile(`(?i)^\s*([a-z][a-z0-9.$]*)\s+eq\s+("(?:[^"\\]|\\.)*"|true|false)\s*$`)
+
+// Filter is an equality filter on an attribute.
+type Filter struct {
+	// Attribute is lowercased as SCIM attribute names are case-insensitive.
+	Attribute string
+	Value     string
+}
+
+// ParseFilter parses an `attribute eq "value"` filter.
+// An empty filter returns nil.
+func ParseFilter(filter string) (*Filter, error) {
+	if strings.TrimSpace(filter) == "" {
+		return nil, nil
+	}
+
+	matches := filterPattern.FindStringSubmatch(filter)
+	if matches == nil {
+		return nil, dutyAPI.Errorf(dutyAPI.EINVALID, "unsupported filter %q: only `attribute eq \"value\"` is supported", filter)
+	}
+
+	value := matches[2]
+	if strings.HasPrefix(value, `"`) {
+		unquoted, err := strconv.Unquote(value)
+		if err != nil {
+			return nil, dutyAPI.Errorf(dutyAPI.EINVALID, "invalid filter value %s", value)
+		}
+		value = unquoted
+	}
+
+	return &Filter{Attribute: strings.ToLower(matches[1]), Value: value}, nil
+}
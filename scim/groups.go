@@ -0,0 +1,295 @@
+package scim
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/flanksource/duty"
+	dutyAPI "github.com/flanksource/duty/api"
+	"github.com/flanksource/duty/context"
+	"github.com/flanksource/duty/models"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/samber/lo"
+
+	"github.com/flanksource/incident-commander/db"
+	dbModels "github.com/flanksource/incident-commander/db/models"
+)
+
+// Source is the source of the teams and team memberships provisioned over SCIM.
+const Source = "SCIM"
+
+// groupFilters maps the filterable group attributes to their condition.
+var groupFilters = map[string]string{
+	"id":          "id::text = ?",
+	"displayname": "name = ?",
+}
+
+func ListGroups(c echo.Context) error {
+	ctx := c.Request().Context().(context.Context)
+
+	filter, err := ParseFilter(c.QueryParam("filter"))
+	if err != nil {
+		return writeError(c, err)
+	}
+
+	var where []any
+	if filter != nil {
+		condition, ok := groupFilters[filter.Attribute]
+		if !ok {
+			return writeError(c, dutyAPI.Errorf(dutyAPI.EINVALID, "filtering groups by %s is not supported", filter.Attribute))
+		}
+		where = []any{condition, filter.Value}
+	}
+
+	startIndex, count := pagination(c)
+	teams, total, err := db.ListTeams(ctx, startIndex-1, count, where...)
+	if err != nil {
+		return writeError(c, err)
+	}
+
+	groups, err := toGroups(ctx, !excludesMembers(c), teams...)
+	if err != nil {
+		return writeError(c, err)
+	}
+	return writeJSON(c, http.StatusOK, listResponse(groups, total, startIndex))
+}
+
+func GetGroup(c echo.Context) error {
+	ctx := c.Request().Context().(context.Context)
+
+	team, err := findTeam(ctx, c.Param("id"))
+	if err != nil {
+		return writeError(c, err)
+	}
+	return writeGroup(c, ctx, http.StatusOK, *team)
+}
+
+func CreateGroup(c echo.Context) error {
+	ctx := c.Request().Context().(context.Context)
+
+	var group Group
+	if err := bind(c, &group); err != nil {
+		return writeError(c, err)
+	}
+
+	if strings.TrimSpace(group.DisplayName) == "" {
+		return writeError(c, dutyAPI.Errorf(dutyAPI.EINVALID, "displayName is required"))
+	}
+	if err := checkTeamNameAvailable(ctx, group.DisplayName, uuid.Nil); err != nil {
+		return writeError(c, err)
+	}
+
+	systemUser, err := db.GetSystemUser(ctx)
+	if err != nil {
+		return writeError(c, err)
+	}
+
+	team := dbModels.Team{
+		Name:      group.DisplayName,
+		Source:    lo.ToPtr(Source),
+		CreatedBy: systemUser.ID,
+	}
+	if err := ctx.DB().Create(&team).Error; err != nil {
+		return writeError(c, ctx.Oops().Wrapf(err, "failed to create team %s", group.DisplayName))
+	}
+	ctx.Infof("scim: created team %s (%s)", team.ID, team.Name)
+
+	if err := syncMembers(ctx, team.ID, group.Members); err != nil {
+		return writeError(c, err)
+	}
+
+	c.Response().Header().Set(echo.HeaderLocation, location("Groups", team.ID.String()))
+	return writeGroup(c, ctx, http.StatusCreated, team)
+}
+
+func ReplaceGroup(c echo.Context) error {
+	ctx := c.Request().Context().(context.Context)
+
+	team, err := findTeam(ctx, c.Param("id"))
+	if err != nil {
+		return writeError(c, err)
+	}
+
+	var group Group
+	if err := bind(c, &group); err != nil {
+		return writeError(c, err)
+	}
+
+	return updateGroup(c, ctx, *team, group)
+}
+
+func PatchGroup(c echo.Context) error {
+	ctx := c.Request().Context().(context.Context)
+
+	team, err := findTeam(ctx, c.Param("id"))
+	if err != nil {
+		return writeError(c, err)
+	}
+
+	var patch PatchRequest
+	if err := bind(c, &patch); err != nil {
+		return writeError(c, err)
+	}
+
+	groups, err := toGroups(ctx, true, *team)
+	if err != nil {
+		return writeError(c, err)
+	}
+
+	doc, err := newDocument(groups[0])
+	if err != nil {
+		return writeError(c, err)
+	}
+	if err := doc.Apply(patch.Operations); err != nil {
+		return writeError(c, err)
+	}
+
+	var group Group
+	if err := doc.decode(&group); err != nil {
+		return writeError(c, err)
+	}
+
+	return updateGroup(c, ctx, *team, group)
+}
+
+// DeleteGroup deletes teams provisioned over SCIM. Teams from other sources,
+// e.g. the Team CRD, are kept and only lose their SCIM memberships.
+func DeleteGroup(c echo.Context) error {
+	ctx := c.Request().Context().(context.Context)
+
+	team, err := findTeam(ctx, c.Param("id"))
+	if err != nil {
+		return writeError(c, err)
+	}
+
+	if err := db.SyncTeamMembers(ctx, team.ID, nil, Source); err != nil {
+		return writeError(c, err)
+	}
+
+	if lo.FromPtr(team.Source) == Source {
+		if err := db.DeleteTeam(ctx, team.ID.String()); err != nil {
+			return writeError(c, ctx.Oops().Wrapf(err, "failed to delete team %s", team.ID))
+		}
+		ctx.Infof("scim: deleted team %s (%s)", team.ID, team.Name)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// updateGroup renames the team and makes the group members its SCIM members.
+func updateGroup(c echo.Context, ctx context.Context, team dbModels.Team, group Group) error {
+	if group.DisplayName != "" && group.DisplayName != team.Name {
+		if err := checkTeamNameAvailable(ctx, group.DisplayName, team.ID); err != nil {
+			return writeError(c, err)
+		}
+		if err := ctx.DB().Model(&dbModels.Team{}).Where("id = ?", team.ID).
+			Updates(map[string]any{"name": group.DisplayName, "updated_at": duty.Now()}).Error; err != nil {
+			return writeError(c, ctx.Oops().Wrapf(err, "failed to rename team %s", team.ID))
+		}
+	}
+
+	if err := syncMembers(ctx, team.ID, group.Members); err != nil {
+		return writeError(c, err)
+	}
+
+	updated, err := findTeam(ctx, team.ID.String())
+	if err != nil {
+		return writeError(c, err)
+	}
+	return writeGroup(c, ctx, http.StatusOK, *updated)
+}
+
+// syncMembers makes the members the SCIM members of the team.
+// Members from other sources, e.g. the Team CRD, are kept.
+func syncMembers(ctx context.Context, teamID uuid.UUID, members []Reference) error {
+	ids := lo.Uniq(lo.Map(members, func(m Reference, _ int) string { return m.Value }))
+	for _, id := range ids {
+		if _, err := findPerson(ctx, id); err != nil {
+			return dutyAPI.Errorf(dutyAPI.EINVALID, "member %s is not a user", id)
+		}
+	}
+
+	return db.SyncTeamMembers(ctx, teamID, ids, Source)
+}
+
+func checkTeamNameAvailable(ctx context.Context, name string, teamID uuid.UUID) error {
+	var count int64
+	if err := ctx.DB().Model(&dbModels.Team{}).
+		Where("name = ? AND id != ? AND deleted_at IS NULL", name, teamID).
+		Count(&count).Error; err != nil {
+		return ctx.Oops().Wrapf(err, "failed to check team name %s", name)
+	}
+	if count > 0 {
+		return dutyAPI.Errorf(dutyAPI.ECONFLICT, "a group named %s already exists", name)
+	}
+	return nil
+}
+
+func findTeam(ctx context.Context, id string) (*dbModels.Team, error) {
+	teamID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, dutyAPI.Errorf(dutyAPI.ENOTFOUND, "group %s not found", id)
+	}
+
+	team, err := db.GetTeam(ctx, teamID)
+	if err != nil {
+		return nil, err
+	} else if team == nil {
+		return nil, dutyAPI.Errorf(dutyAPI.ENOTFOUND, "group %s not found", id)
+	}
+	return team, nil
+}
+
+func writeGroup(c echo.Context, ctx context.Context, status int, team dbModels.Team) error {
+	groups, err := toGroups(ctx, true, team)
+	if err != nil {
+		return writeError(c, err)
+	}
+	return writeJSON(c, status, groups[0])
+}
+
+func toGroups(ctx context.Context, withMembers bool, teams ...dbModels.Team) ([]Group, error) {
+	var members map[uuid.UUID][]models.Person
+	if withMembers {
+		var err error
+		if members, err = db.GetTeamMembers(ctx, lo.Map(teams, func(t dbModels.Team, _ int) uuid.UUID { return t.ID })...); err != nil {
+			return nil, err
+		}
+	}
+
+	groups := make([]Group, 0, len(teams))
+	for _, team := range teams {
+		group := Group{
+			Schemas:     []string{SchemaGroup},
+			ID:          team.ID.String(),
+			DisplayName: team.Name,
+			Meta: &Meta{
+				ResourceType: "Group",
+				Created:      &team.CreatedAt,
+				LastModified: &team.UpdatedAt,
+				Location:     location("Groups", team.ID.String()),
+			},
+		}
+		for _, person := range members[team.ID] {
+			group.Members = append(group.Members, Reference{
+				Value:   person.ID.String(),
+				Display: person.Name,
+				Ref:     location("Users", person.ID.String()),
+			})
+		}
+		groups = append(groups, group)
+	}
+	return groups, nil
+}
+
+// excludesMembers reports whether the request excludes the members attribute,
+// which identity providers do to avoid listing large groups.
+func excludesMembers(c echo.Context) bool {
+	for _, attribute := range strings.Split(c.QueryParam("excludedAttributes"), ",") {
+		if strings.EqualFold(strings.TrimSpace(attribute), "members") {
+			return true
+		}
+	}
+	return false
+}
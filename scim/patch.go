@@ -0,0 +1,230 @@
+package scim
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	dutyAPI "github.com/flanksource/duty/api"
+	"github.com/samber/lo"
+)
+
+// valuePathPattern matches paths that select elements of a multi-valued
+// attribute, e.g. `emails[type eq "work"].value` or `members[value eq "..."]`.
+var valuePathPattern = regexp.MustCompile(`^([^\[\]]+)\[(.+)\](?:\.(.+))?$`)
+
+// document is the JSON representation of a resource that patch operations are
+// applied to.
+type document map[string]any
+
+func newDocument(resource any) (document, error) {
+	b, err := json.Marshal(resource)
+	if err != nil {
+		return nil, err
+	}
+	var doc document
+	return doc, json.Unmarshal(b, &doc)
+}
+
+// decode converts the patched document back into the resource.
+func (d document) decode(out any) error {
+	b, err := json.Marshal(d)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(b, out); err != nil {
+		return dutyAPI.Errorf(dutyAPI.EINVALID, "invalid patched resource: %v", err)
+	}
+	return nil
+}
+
+// Apply applies the PATCH operations (RFC 7644 3.5.2) to the document.
+func (d document) Apply(operations []PatchOperation) error {
+	for _, op := range operations {
+		var value any
+		if len(op.Value) > 0 {
+			if err := json.Unmarshal(op.Value, &value); err != nil {
+				return dutyAPI.Errorf(dutyAPI.EINVALID, "invalid value of %s operation: %v", op.Op, err)
+			}
+		}
+
+		switch strings.ToLower(op.Op) {
+		case "add", "replace":
+			add := strings.EqualFold(op.Op, "add")
+			if op.Path == "" {
+				values, ok := value.(map[string]any)
+				if !ok {
+					return dutyAPI.Errorf(dutyAPI.EINVALID, "%s operation without a path requires an object value", op.Op)
+				}
+				for path, v := range values {
+					if err := d.set(path, v, add); err != nil {
+						return err
+					}
+				}
+			} else if err := d.set(op.Path, value, add); err != nil {
+				return err
+			}
+
+		case "remove":
+			if op.Path == "" {
+				return dutyAPI.Errorf(dutyAPI.EINVALID, "remove operation requires a path")
+			}
+			if err := d.remove(op.Path, value); err != nil {
+				return err
+			}
+
+		default:
+			return dutyAPI.Errorf(dutyAPI.EINVALID, "unsupported patch operation %q", op.Op)
+		}
+	}
+	return nil
+}
+
+// key returns the key of the attribute, matching it case-insensitively.
+func (d document) key(attribute string) string {
+	for k := range d {
+		if strings.EqualFold(k, attribute) {
+			return k
+		}
+	}
+	return attribute
+}
+
+func (d document) set(path string, value any, add bool) error {
+	if matches := valuePathPattern.FindStringSubmatch(path); matches != nil {
+		return d.setElements(matches[1], matches[2], matches[3], value)
+	}
+
+	// Extension schema attributes, e.g. the enterprise user, aren't stored.
+	if strings.HasPrefix(strings.ToLower(path), "urn:") {
+		return nil
+	}
+
+	attribute, subAttribute, nested := strings.Cut(path, ".")
+	key := d.key(attribute)
+	if nested {
+		sub, _ := d[key].(map[string]any)
+		if sub == nil {
+			sub = map[string]any{}
+			d[key] = sub
+		}
+		return document(sub).set(subAttribute, value, add)
+	}
+
+	// Adding to a multi-valued attribute appends the new values.
+	if existing, ok := d[key].([]any); ok && add {
+		if values, ok := value.([]any); ok {
+			for _, v := range values {
+				if !lo.ContainsBy(existing, func(e any) bool { return sameElement(e, v) }) {
+					existing = append(existing, v)
+				}
+			}
+			d[key] = existing
+			return nil
+		}
+	}
+
+	d[key] = value
+	return nil
+}
+
+// setElements sets the sub attribute of the elements of the multi-valued
+// attribute that match the filter, adding an element when none match.
+func (d document) setElements(attribute, filter, subAttribute string, value any) error {
+	f, err := ParseFilter(filter)
+	if err != nil {
+		return err
+	}
+
+	key := d.key(attribute)
+	elements, _ := d[key].([]any)
+
+	matched := false
+	for i, element := range elements {
+		e, ok := element.(map[string]any)
+		if !ok || !f.Matches(e) {
+			continue
+		}
+		matched = true
+		if subAttribute == "" {
+			elements[i] = value
+		} else {
+			e[document(e).key(subAttribute)] = value
+		}
+	}
+
+	if !matched {
+		element := map[string]any{f.Attribute: f.Value}
+		if subAttribute == "" {
+			if v, ok := value.(map[string]any); ok {
+				element = v
+			}
+		} else {
+			element[subAttribute] = value
+		}
+		elements = append(elements, element)
+	}
+
+	d[key] = elements
+	return nil
+}
+
+func (d document) remove(path string, value any) error {
+	if matches := valuePathPattern.FindStringSubmatch(path); matches != nil {
+		if matches[3] != "" {
+			return dutyAPI.Errorf(dutyAPI.EINVALID, "removing sub attribute %s is not supported", matches[3])
+		}
+
+		f, err := ParseFilter(matches[2])
+		if err != nil {
+			return err
+		}
+		key := d.key(matches[1])
+		elements, _ := d[key].([]any)
+		d[key] = lo.Reject(elements, func(e any, _ int) bool {
+			m, ok := e.(map[string]any)
+			return ok && f.Matches(m)
+		})
+		return nil
+	}
+
+	attribute, subAttribute, nested := strings.Cut(path, ".")
+	key := d.key(attribute)
+	if nested {
+		if sub, ok := d[key].(map[string]any); ok {
+			return document(sub).remove(subAttribute, value)
+		}
+		return nil
+	}
+
+	// Removing values from a multi-valued attribute, as Azure AD does for
+	// group members, only removes the given values.
+	if existing, ok := d[key].([]any); ok {
+		if values, ok := value.([]any); ok {
+			d[key] = lo.Reject(existing, func(e any, _ int) bool {
+				return lo.ContainsBy(values, func(v any) bool { return sameElement(e, v) })
+			})
+			return nil
+		}
+	}
+
+	delete(d, key)
+	return nil
+}
+
+// Matches reports whether the attribute of the element equals the filter value.
+func (f Filter) Matches(element map[string]any) bool {
+	v, ok := element[document(element).key(f.Attribute)]
+	return ok && strings.EqualFold(fmt.Sprint(v), f.Value)
+}
+
+// sameElement compares elements of multi-valued attributes by their value.
+func sameElement(a, b any) bool {
+	am, aok := a.(map[string]any)
+	bm, bok := b.(map[string]any)
+	if aok && bok {
+		return fmt.Sprint(am["value"]) == fmt.Sprint(bm["value"])
+	}
+	return fmt.Sprint(a) == fmt.Sprint(b)
+}
@@ -0,0 +1,284 @@
+package scim
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	"github.com/flanksource/duty/models"
+	dutyRBAC "github.com/flanksource/duty/rbac"
+	"github.com/flanksource/duty/rbac/policy"
+	"github.com/flanksource/duty/tests/fixtures/dummy"
+	"github.com/labstack/echo/v4"
+	ginkgo "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/flanksource/incident-commander/db"
+	dbModels "github.com/flanksource/incident-commander/db/models"
+	"github.com/flanksource/incident-commander/rbac"
+	"github.com/flanksource/incident-commander/rbac/adapter"
+)
+
+var _ = ginkgo.Describe("Filter", func() {
+	ginkgo.It("should parse equality filters", func() {
+		f, err := ParseFilter(`userName eq "jane@example.com"`)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(*f).To(Equal(Filter{Attribute: "username", Value: "jane@example.com"}))
+
+		f, err = ParseFilter(`active EQ true`)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(*f).To(Equal(Filter{Attribute: "active", Value: "true"}))
+
+		f, err = ParseFilter("")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(f).To(BeNil())
+	})
+
+	ginkgo.It("should reject unsupported filters", func() {
+		_, err := ParseFilter(`userName sw "jane"`)
+		Expect(err).To(HaveOccurred())
+
+		_, err = ParseFilter(`userName eq "a" and active eq true`)
+		Expect(err).To(HaveOccurred())
+	})
+})
+
+var _ = ginkgo.Describe("Patch", func() {
+	apply := func(resource any, operations string) document {
+		doc, err := newDocument(resource)
+		Expect(err).ToNot(HaveOccurred())
+
+		var ops []PatchOperation
+		Expect(json.Unmarshal([]byte(operations), &ops)).To(Succeed())
+		Expect(doc.Apply(ops)).To(Succeed())
+		return doc
+	}
+
+	ginkgo.It("should replace attributes with and without a path", func() {
+		doc := apply(User{UserName: "jane@example.com", Active: new(Bool(true))}, `[
+			{"op": "Replace", "path": "active", "value": "False"},
+			{"op": "replace", "value": {"displayName": "Jane", "name.givenName": "Jane"}}
+		]`)
+
+		var user User
+		Expect(doc.decode(&user)).To(Succeed())
+		Expect(bool(*user.Active)).To(BeFalse())
+		Expect(user.DisplayName).To(Equal("Jane"))
+		Expect(user.Name.GivenName).To(Equal("Jane"))
+	})
+
+	ginkgo.It("should update elements selected by a value path", func() {
+		doc := apply(User{Emails: []Email{{Value: "old@example.com", Type: "work"}}}, `[
+			{"op": "replace", "path": "emails[type eq \"work\"].value", "value": "new@example.com"}
+		]`)
+
+		var user User
+		Expect(doc.decode(&user)).To(Succeed())
+		Expect(user.Emails).To(HaveLen(1))
+		Expect(user.Emails[0].Value).To(Equal("new@example.com"))
+	})
+
+	ginkgo.It("should add and remove group members", func() {
+		group := Group{Members: []Reference{{Value: "a"}, {Value: "b"}}}
+		doc := apply(group, `[
+			{"op": "add", "path": "members", "value": [{"value": "b"}, {"value": "c"}]},
+			{"op": "remove", "path": "members[value eq \"a\"]"},
+			{"op": "remove", "path": "members", "value": [{"value": "b"}]}
+		]`)
+
+		Expect(doc.decode(&group)).To(Succeed())
+		Expect(group.Members).To(Equal([]Reference{{Value: "c"}}))
+	})
+})
+
+var _ = ginkgo.Describe("Provisioning", ginkgo.Ordered, func() {
+	var (
+		e          *echo.Echo
+		tokenAll   string
+		tokenRead  string
+		unscoped   string
+		jane       User
+		engineers  Group
+		crdTeam    dbModels.Team
+		janeTokens []models.AccessToken
+	)
+
+	request := func(token, method, path, body string) (int, []byte) {
+		req := httptest.NewRequest(method, BasePath+path, strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, ContentType)
+		if token != "" {
+			req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec.Code, rec.Body.Bytes()
+	}
+
+	// createToken creates an access token for the creator, explicitly allowing
+	// the scim actions like a token created with a scim scope.
+	createToken := func(name string, creator *models.Person, actions ...string) string {
+		result, err := db.CreateAccessTokenForPerson(DefaultContext, creator, name, time.Hour, false)
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = dutyRBAC.Enforcer().AddGroupingPolicy(result.Person.ID.String(), creator.ID.String())
+		Expect(err).ToNot(HaveOccurred())
+		for _, action := range actions {
+			_, err = dutyRBAC.Enforcer().AddPermissionForUser(result.Person.ID.String(), rbac.ObjectSCIM, action, "allow", "", "na")
+			Expect(err).ToNot(HaveOccurred())
+		}
+		return result.Token.PlainText()
+	}
+
+	ginkgo.BeforeAll(func() {
+		if dutyRBAC.Enforcer() == nil {
+			Expect(dutyRBAC.Init(DefaultContext, []string{}, adapter.NewPermissionAdapter)).To(Succeed())
+		}
+		Expect(dutyRBAC.AddRoleForUser(dummy.JohnDoe.ID.String(), policy.RoleAdmin)).To(Succeed())
+
+		e = echo.New()
+		e.Use(func(next echo.HandlerFunc) echo.HandlerFunc {
+			return func(c echo.Context) error {
+				c.SetRequest(c.Request().WithContext(DefaultContext.Wrap(c.Request().Context())))
+				return next(c)
+			}
+		})
+		RegisterRoutes(e)
+
+		tokenAll = createToken("scim-all", &dummy.JohnDoe, policy.ActionCreate, policy.ActionRead, policy.ActionUpdate, policy.ActionDelete)
+		tokenRead = createToken("scim-read", &dummy.JohnDoe, policy.ActionRead)
+		unscoped = createToken("scim-unscoped", &dummy.JohnDoe)
+
+		crdTeam = dbModels.Team{Name: "scim-crd-team", Source: new(models.SourceCRD), CreatedBy: dummy.JohnDoe.ID}
+		Expect(DefaultContext.DB().Create(&crdTeam).Error).To(Succeed())
+	})
+
+	ginkgo.It("should reject requests without a scoped token", func() {
+		code, _ := request("", http.MethodGet, "/Users", "")
+		Expect(code).To(Equal(http.StatusUnauthorized))
+
+		code, _ = request("invalid", http.MethodGet, "/Users", "")
+		Expect(code).To(Equal(http.StatusUnauthorized))
+
+		// The creator is an admin, but the token isn't scoped to scim
+		code, _ = request(unscoped, http.MethodGet, "/Users", "")
+		Expect(code).To(Equal(http.StatusForbidden))
+
+		code, _ = request(tokenRead, http.MethodPost, "/Users", `{"userName": "nobody@example.com"}`)
+		Expect(code).To(Equal(http.StatusForbidden))
+	})
+
+	ginkgo.It("should reject a scoped token used from outside its networks", func() {
+		result, err := db.CreateAccessTokenForPerson(DefaultContext, &dummy.JohnDoe, "scim-office", time.Hour, false)
+		Expect(err).ToNot(HaveOccurred())
+		_, err = dutyRBAC.Enforcer().AddGroupingPolicy(result.Person.ID.String(), dummy.JohnDoe.ID.String())
+		Expect(err).ToNot(HaveOccurred())
+		_, err = dutyRBAC.Enforcer().AddPermissionForUser(result.Person.ID.String(), rbac.ObjectSCIM, policy.ActionRead, "allow", "", "na")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(db.SaveAccessTokenScope(DefaultContext, &db.AccessTokenScope{
+			PersonID:      result.Person.ID,
+			AccessTokenID: result.AccessToken.ID,
+			CIDRs:         []string{"10.0.0.0/8"},
+		})).To(Succeed())
+
+		// httptest requests come from 192.0.2.1
+		code, _ := request(result.Token.PlainText(), http.MethodGet, "/Users", "")
+		Expect(code).To(Equal(http.StatusForbidden))
+	})
+
+	ginkgo.It("should create a user", func() {
+		code, body := request(tokenAll, http.MethodPost, "/Users", `{
+			"schemas": ["urn:ietf:params:scim:schemas:core:2.0:User"],
+			"userName": "jane.scim@example.com",
+			"externalId": "jane-1",
+			"name": {"givenName": "Jane", "familyName": "Scim"},
+			"active": true
+		}`)
+		Expect(code).To(Equal(http.StatusCreated), string(body))
+		Expect(json.Unmarshal(body, &jane)).To(Succeed())
+		Expect(jane.ID).ToNot(BeEmpty())
+		Expect(jane.DisplayName).To(Equal("Jane Scim"))
+		Expect(jane.Emails[0].Value).To(Equal("jane.scim@example.com"))
+
+		code, _ = request(tokenAll, http.MethodPost, "/Users", `{"userName": "JANE.scim@example.com"}`)
+		Expect(code).To(Equal(http.StatusConflict))
+	})
+
+	ginkgo.It("should find the user by userName", func() {
+		code, body := request(tokenRead, http.MethodGet, `/Users?filter=userName+eq+%22jane.scim%40example.com%22`, "")
+		Expect(code).To(Equal(http.StatusOK), string(body))
+
+		var list struct {
+			TotalResults int64  `json:"totalResults"`
+			Resources    []User `json:"Resources"`
+		}
+		Expect(json.Unmarshal(body, &list)).To(Succeed())
+		Expect(list.TotalResults).To(Equal(int64(1)))
+		Expect(list.Resources[0].ID).To(Equal(jane.ID))
+	})
+
+	ginkgo.It("should sync group members without touching other sources", func() {
+		code, body := request(tokenAll, http.MethodPost, "/Groups", `{"displayName": "scim-engineers", "members": [{"value": "`+jane.ID+`"}]}`)
+		Expect(code).To(Equal(http.StatusCreated), string(body))
+		Expect(json.Unmarshal(body, &engineers)).To(Succeed())
+		Expect(engineers.Members).To(HaveLen(1))
+
+		Expect(DefaultContext.DB().Create(&dbModels.TeamMember{TeamID: crdTeam.ID, PersonID: dummy.JohnWick.ID, Source: models.SourceCRD}).Error).To(Succeed())
+		code, body = request(tokenAll, http.MethodPatch, "/Groups/"+crdTeam.ID.String(), `{
+			"schemas": ["urn:ietf:params:scim:api:messages:2.0:PatchOp"],
+			"Operations": [{"op": "add", "path": "members", "value": [{"value": "`+jane.ID+`"}]}]
+		}`)
+		Expect(code).To(Equal(http.StatusOK), string(body))
+
+		var group Group
+		Expect(json.Unmarshal(body, &group)).To(Succeed())
+		Expect(group.Members).To(ConsistOf(
+			HaveField("Value", jane.ID),
+			HaveField("Value", dummy.JohnWick.ID.String()),
+		))
+
+		code, body = request(tokenAll, http.MethodGet, "/Users/"+jane.ID, "")
+		Expect(code).To(Equal(http.StatusOK))
+		var user User
+		Expect(json.Unmarshal(body, &user)).To(Succeed())
+		Expect(user.Groups).To(HaveLen(2))
+	})
+
+	ginkgo.It("should deprovision a deactivated user", func() {
+		person, err := db.GetUserByID(DefaultContext, jane.ID)
+		Expect(err).ToNot(HaveOccurred())
+		_, err = db.CreateAccessTokenForPerson(DefaultContext, &person, "jane-token", time.Hour, false)
+		Expect(err).ToNot(HaveOccurred())
+
+		code, body := request(tokenAll, http.MethodPatch, "/Users/"+jane.ID, `{
+			"schemas": ["urn:ietf:params:scim:api:messages:2.0:PatchOp"],
+			"Operations": [{"op": "replace", "value": {"active": false}}]
+		}`)
+		Expect(code).To(Equal(http.StatusOK), string(body))
+
+		var user User
+		Expect(json.Unmarshal(body, &user)).To(Succeed())
+		Expect(bool(*user.Active)).To(BeFalse())
+		Expect(user.Groups).To(BeEmpty())
+
+		Expect(DefaultContext.DB().Where("created_by = ?", jane.ID).Find(&janeTokens).Error).To(Succeed())
+		Expect(janeTokens).To(BeEmpty())
+
+		var members []dbModels.TeamMember
+		Expect(DefaultContext.DB().Where("team_id = ?", crdTeam.ID).Find(&members).Error).To(Succeed())
+		Expect(members).To(ConsistOf(HaveField("PersonID", dummy.JohnWick.ID)))
+	})
+
+	ginkgo.It("should only delete groups provisioned over scim", func() {
+		code, _ := request(tokenAll, http.MethodDelete, "/Groups/"+engineers.ID, "")
+		Expect(code).To(Equal(http.StatusNoContent))
+		code, _ = request(tokenAll, http.MethodGet, "/Groups/"+engineers.ID, "")
+		Expect(code).To(Equal(http.StatusNotFound))
+
+		code, _ = request(tokenAll, http.MethodDelete, "/Groups/"+crdTeam.ID.String(), "")
+		Expect(code).To(Equal(http.StatusNoContent))
+		code, _ = request(tokenAll, http.MethodGet, "/Groups/"+crdTeam.ID.String(), "")
+		Expect(code).To(Equal(http.StatusOK))
+	})
+})
@@ -0,0 +1,355 @@
+package scim
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/flanksource/duty"
+	dutyAPI "github.com/flanksource/duty/api"
+	"github.com/flanksource/duty/context"
+	"github.com/flanksource/duty/models"
+	dutyRBAC "github.com/flanksource/duty/rbac"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/samber/lo"
+
+	"github.com/flanksource/incident-commander/auth"
+	"github.com/flanksource/incident-commander/db"
+	"github.com/flanksource/incident-commander/vars"
+)
+
+// userFilters maps the filterable user attributes to their condition.
+var userFilters = map[string]string{
+	"id":           "id::text = ?",
+	"username":     "LOWER(email) = LOWER(?)",
+	"emails":       "LOWER(email) = LOWER(?)",
+	"emails.value": "LOWER(email) = LOWER(?)",
+	"externalid":   "external_id = ?",
+	"displayname":  "name = ?",
+}
+
+func ListUsers(c echo.Context) error {
+	ctx := c.Request().Context().(context.Context)
+
+	filter, err := ParseFilter(c.QueryParam("filter"))
+	if err != nil {
+		return writeError(c, err)
+	}
+
+	var where []any
+	if filter != nil {
+		condition, ok := userFilters[filter.Attribute]
+		if !ok {
+			return writeError(c, dutyAPI.Errorf(dutyAPI.EINVALID, "filtering users by %s is not supported", filter.Attribute))
+		}
+		where = []any{condition, filter.Value}
+	}
+
+	startIndex, count := pagination(c)
+	people, total, err := db.ListProvisionedPeople(ctx, startIndex-1, count, where...)
+	if err != nil {
+		return writeError(c, err)
+	}
+
+	users, err := toUsers(ctx, people...)
+	if err != nil {
+		return writeError(c, err)
+	}
+	return writeJSON(c, http.StatusOK, listResponse(users, total, startIndex))
+}
+
+func GetUser(c echo.Context) error {
+	ctx := c.Request().Context().(context.Context)
+
+	person, err := findPerson(ctx, c.Param("id"))
+	if err != nil {
+		return writeError(c, err)
+	}
+	return writeUser(c, ctx, http.StatusOK, *person)
+}
+
+func CreateUser(c echo.Context) error {
+	ctx := c.Request().Context().(context.Context)
+
+	var user User
+	if err := bind(c, &user); err != nil {
+		return writeError(c, err)
+	}
+
+	email := user.Email()
+	if email == "" {
+		return writeError(c, dutyAPI.Errorf(dutyAPI.EINVALID, "userName or emails must contain an email"))
+	}
+	if err := checkEmailAvailable(ctx, email, uuid.Nil); err != nil {
+		return writeError(c, err)
+	}
+
+	person, err := db.CreateUser(ctx, models.Person{
+		Name:       userDisplayName(user),
+		Email:      email,
+		ExternalID: user.ExternalID,
+	})
+	if err != nil {
+		return writeError(c, ctx.Oops().Wrapf(err, "failed to create person %s", email))
+	}
+	ctx.Infof("scim: created person %s (%s)", person.ID, email)
+
+	if user.Active != nil && !bool(*user.Active) {
+		if err := deprovision(ctx, person.ID); err != nil {
+			return writeError(c, err)
+		}
+	}
+
+	created, err := findPerson(ctx, person.ID.String())
+	if err != nil {
+		return writeError(c, err)
+	}
+	c.Response().Header().Set(echo.HeaderLocation, location("Users", person.ID.String()))
+	return writeUser(c, ctx, http.StatusCreated, *created)
+}
+
+func ReplaceUser(c echo.Context) error {
+	ctx := c.Request().Context().(context.Context)
+
+	person, err := findPerson(ctx, c.Param("id"))
+	if err != nil {
+		return writeError(c, err)
+	}
+
+	var user User
+	if err := bind(c, &user); err != nil {
+		return writeError(c, err)
+	}
+
+	return updateUser(c, ctx, *person, user)
+}
+
+func PatchUser(c echo.Context) error {
+	ctx := c.Request().Context().(context.Context)
+
+	person, err := findPerson(ctx, c.Param("id"))
+	if err != nil {
+		return writeError(c, err)
+	}
+
+	var patch PatchRequest
+	if err := bind(c, &patch); err != nil {
+		return writeError(c, err)
+	}
+
+	users, err := toUsers(ctx, *person)
+	if err != nil {
+		return writeError(c, err)
+	}
+
+	doc, err := newDocument(users[0])
+	if err != nil {
+		return writeError(c, err)
+	}
+	if err := doc.Apply(patch.Operations); err != nil {
+		return writeError(c, err)
+	}
+
+	var user User
+	if err := doc.decode(&user); err != nil {
+		return writeError(c, err)
+	}
+
+	// The name is served as both displayName and name.formatted, so use
+	// whichever of them the patch changed.
+	original := users[0]
+	if user.Name != nil && user.Name.Formatted == original.Name.Formatted && (user.Name.GivenName != "" || user.Name.FamilyName != "") {
+		user.Name.Formatted = ""
+	}
+	if user.DisplayName == original.DisplayName && user.Name.Display() != original.Name.Display() {
+		user.DisplayName = ""
+	}
+
+	return updateUser(c, ctx, *person, user)
+}
+
+// DeleteUser deprovisions the person. The person is kept, deactivated, so that
+// the record of what they did stays intact.
+func DeleteUser(c echo.Context) error {
+	ctx := c.Request().Context().(context.Context)
+
+	person, err := findPerson(ctx, c.Param("id"))
+	if err != nil {
+		return writeError(c, err)
+	}
+
+	if err := deprovision(ctx, person.ID); err != nil {
+		return writeError(c, err)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// updateUser replaces the attributes of the person with the user's and
+// (de)activates them.
+func updateUser(c echo.Context, ctx context.Context, person db.ProvisionedPerson, user User) error {
+	updates := map[string]any{}
+	if name := userDisplayName(user); name != "" && name != person.Name {
+		updates["name"] = name
+	}
+	if email := user.Email(); email != "" && !strings.EqualFold(email, person.Email) {
+		if err := checkEmailAvailable(ctx, email, person.ID); err != nil {
+			return writeError(c, err)
+		}
+		updates["email"] = email
+	}
+	// The external id is kept when the identity provider doesn't send one as
+	// other logins, e.g. OIDC, link the person by it.
+	if user.ExternalID != "" && user.ExternalID != person.ExternalID {
+		updates["external_id"] = user.ExternalID
+	}
+
+	if len(updates) > 0 {
+		updates["updated_at"] = duty.Now()
+		if err := ctx.DB().Model(&models.Person{}).Where("id = ?", person.ID).Updates(updates).Error; err != nil {
+			return writeError(c, ctx.Oops().Wrapf(err, "failed to update person %s", person.ID))
+		}
+	}
+
+	if user.Active != nil {
+		active := bool(*user.Active)
+		if !active && person.DeletedAt == nil {
+			if err := deprovision(ctx, person.ID); err != nil {
+				return writeError(c, err)
+			}
+		} else if active && person.DeletedAt != nil {
+			if err := reactivate(ctx, person); err != nil {
+				return writeError(c, err)
+			}
+		}
+	}
+
+	updated, err := findPerson(ctx, person.ID.String())
+	if err != nil {
+		return writeError(c, err)
+	}
+	return writeUser(c, ctx, http.StatusOK, *updated)
+}
+
+// deprovision deactivates the person, removes them from their teams and roles
+// and revokes their access tokens.
+func deprovision(ctx context.Context, personID uuid.UUID) error {
+	if err := db.DeactivatePerson(ctx, personID); err != nil {
+		return err
+	}
+
+	if err := auth.RevokeAccessOfPerson(ctx, personID.String()); err != nil {
+		return err
+	}
+
+	if dutyRBAC.Enforcer() != nil {
+		if err := dutyRBAC.DeleteAllRolesForUser(personID.String()); err != nil {
+			return ctx.Oops().Wrapf(err, "failed to delete roles of %s", personID)
+		}
+	}
+
+	if vars.AuthMode == auth.Kratos {
+		if err := db.UpdateIdentityState(ctx, personID.String(), auth.IdentityStateInactive); err != nil {
+			return ctx.Oops().Wrapf(err, "failed to deactivate identity %s", personID)
+		}
+	}
+
+	ctx.Infof("scim: deprovisioned person %s", personID)
+	return nil
+}
+
+func reactivate(ctx context.Context, person db.ProvisionedPerson) error {
+	if err := checkEmailAvailable(ctx, person.Email, person.ID); err != nil {
+		return err
+	}
+	if err := db.ReactivatePerson(ctx, person.ID); err != nil {
+		return err
+	}
+
+	if vars.AuthMode == auth.Kratos {
+		if err := db.UpdateIdentityState(ctx, person.ID.String(), auth.IdentityStateActive); err != nil {
+			return ctx.Oops().Wrapf(err, "failed to activate identity %s", person.ID)
+		}
+	}
+
+	ctx.Infof("scim: reactivated person %s", person.ID)
+	return nil
+}
+
+// checkEmailAvailable returns a conflict when another active person has the email.
+func checkEmailAvailable(ctx context.Context, email string, personID uuid.UUID) error {
+	if email == "" {
+		return nil
+	}
+
+	var count int64
+	if err := ctx.DB().Model(&models.Person{}).
+		Where("LOWER(email) = LOWER(?) AND id != ? AND deleted_at IS NULL", email, personID).
+		Count(&count).Error; err != nil {
+		return ctx.Oops().Wrapf(err, "failed to check email %s", email)
+	}
+	if count > 0 {
+		return dutyAPI.Errorf(dutyAPI.ECONFLICT, "a user with the email %s already exists", email)
+	}
+	return nil
+}
+
+func findPerson(ctx context.Context, id string) (*db.ProvisionedPerson, error) {
+	personID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, dutyAPI.Errorf(dutyAPI.ENOTFOUND, "user %s not found", id)
+	}
+
+	person, err := db.GetProvisionedPerson(ctx, personID)
+	if err != nil {
+		return nil, err
+	} else if person == nil {
+		return nil, dutyAPI.Errorf(dutyAPI.ENOTFOUND, "user %s not found", id)
+	}
+	return person, nil
+}
+
+func writeUser(c echo.Context, ctx context.Context, status int, person db.ProvisionedPerson) error {
+	users, err := toUsers(ctx, person)
+	if err != nil {
+		return writeError(c, err)
+	}
+	return writeJSON(c, status, users[0])
+}
+
+func toUsers(ctx context.Context, people ...db.ProvisionedPerson) ([]User, error) {
+	teams, err := db.GetTeamsOfPeople(ctx, lo.Map(people, func(p db.ProvisionedPerson, _ int) uuid.UUID { return p.ID })...)
+	if err != nil {
+		return nil, err
+	}
+
+	users := make([]User, 0, len(people))
+	for _, p := range people {
+		user := User{
+			Schemas:     []string{SchemaUser},
+			ID:          p.ID.String(),
+			ExternalID:  p.ExternalID,
+			UserName:    lo.CoalesceOrEmpty(p.Email, p.Name),
+			Name:        &Name{Formatted: p.Name},
+			DisplayName: p.Name,
+			Active:      lo.ToPtr(Bool(p.DeletedAt == nil)),
+			Meta: &Meta{
+				ResourceType: "User",
+				Created:      &p.CreatedAt,
+				LastModified: lo.CoalesceOrEmpty(p.UpdatedAt, &p.CreatedAt),
+				Location:     location("Users", p.ID.String()),
+			},
+		}
+		if p.Email != "" {
+			user.Emails = []Email{{Value: p.Email, Type: "work", Primary: true}}
+		}
+		for _, team := range teams[p.ID] {
+			user.Groups = append(user.Groups, Reference{Value: team.ID.String(), Display: team.Name, Ref: location("Groups", team.ID.String())})
+		}
+		users = append(users, user)
+	}
+	return users, nil
+}
+
+func userDisplayName(user User) string {
+	return lo.CoalesceOrEmpty(user.DisplayName, user.Name.Display(), user.UserName)
+}
@@ -0,0 +1,79 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	stdhttp "net/http"
+	"net/url"
+
+	"github.com/flanksource/incident-commander/clientapi"
+)
+
+// AgentExec starts a command on an agent. The returned stream carries
+// clientapi agent session frames; the caller must close it.
+func (c *Client) AgentExec(ctx context.Context, agent string, command []string) (io.ReadWriteCloser, error) {
+	if len(command) == 0 {
+		return nil, errors.New("command is required")
+	}
+	return c.upgrade(ctx, fmt.Sprintf("/agent/%s/exec", url.PathEscape(agent)), url.Values{"command": command}, clientapi.AgentExecProtocol)
+}
+
+// AgentForward opens a TCP connection from an agent to address. The
+// returned stream carries the raw bytes; the caller must close it.
+func (c *Client) AgentForward(ctx context.Context, agent, address string) (io.ReadWriteCloser, error) {
+	return c.upgrade(ctx, fmt.Sprintf("/agent/%s/forward", url.PathEscape(agent)), url.Values{"address": {address}}, clientapi.AgentForwardProtocol)
+}
+
+// upgrade switches a GET request to protocol and returns the raw connection.
+// It bypasses the commons client whose middleware buffers response bodies.
+func (c *Client) upgrade(ctx context.Context, path string, query url.Values, protocol string) (io.ReadWriteCloser, error) {
+	u := c.serverURL + c.apiPath(path)
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+
+	req, err := stdhttp.NewRequestWithContext(ctx, stdhttp.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", protocol)
+	req.Header.Set("User-Agent", "mission-control-cli")
+
+	if c.tokenProvider != nil {
+		token, err := c.tokenProvider(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+	} else if c.authHeader != "" {
+		req.Header.Set("Authorization", c.authHeader)
+	}
+
+	resp, err := (&stdhttp.Client{
+		CheckRedirect: func(*stdhttp.Request, []*stdhttp.Request) error { return stdhttp.ErrUseLastResponse },
+	}).Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != stdhttp.StatusSwitchingProtocols {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+		if resp.StatusCode == stdhttp.StatusNotFound {
+			return nil, fmt.Errorf("%w: %w", ErrNotFound, newServerError(resp.StatusCode, body))
+		}
+		return nil, newServerError(resp.StatusCode, body)
+	}
+
+	conn, ok := resp.Body.(io.ReadWriteCloser)
+	if !ok {
+		_ = resp.Body.Close()
+		return nil, fmt.Errorf("server did not switch to %s", protocol)
+	}
+	return conn, nil
+}
@@ -0,0 +1,64 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/flanksource/incident-commander/clientapi"
+	ginkgo "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = ginkgo.Describe("AgentExec", func() {
+	ginkgo.It("upgrades the request and streams frames", func() {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer ginkgo.GinkgoRecover()
+			Expect(r.URL.Path).To(Equal("/agent/edge/exec"))
+			Expect(r.URL.Query()["command"]).To(Equal([]string{"echo", "hi"}))
+			Expect(r.Header.Get("Upgrade")).To(Equal(clientapi.AgentExecProtocol))
+			Expect(r.Header.Get("Authorization")).To(Equal("Bearer token"))
+
+			conn, rw, err := http.NewResponseController(w).Hijack()
+			Expect(err).ToNot(HaveOccurred())
+			defer conn.Close()
+
+			_, _ = rw.WriteString("HTTP/1.1 101 Switching Protocols\r\nConnection: Upgrade\r\nUpgrade: " + clientapi.AgentExecProtocol + "\r\n\r\n")
+			Expect(clientapi.WriteAgentSessionFrame(rw, clientapi.FrameStdout, []byte("hi\n"))).To(Succeed())
+			Expect(clientapi.WriteAgentSessionExit(rw, 3)).To(Succeed())
+			Expect(rw.Flush()).To(Succeed())
+		}))
+		defer server.Close()
+
+		stream, err := New(server.URL, "token").AgentExec(context.Background(), "edge", []string{"echo", "hi"})
+		Expect(err).ToNot(HaveOccurred())
+		defer stream.Close()
+
+		reader := bufio.NewReader(stream)
+		frame, err := clientapi.ReadAgentSessionFrame(reader)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(frame.Type).To(Equal(clientapi.FrameStdout))
+		Expect(string(frame.Payload)).To(Equal("hi\n"))
+
+		frame, err = clientapi.ReadAgentSessionFrame(reader)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(frame.ExitCode()).To(Equal(3))
+
+		_, err = clientapi.ReadAgentSessionFrame(reader)
+		Expect(err).To(MatchError(io.EOF))
+	})
+
+	ginkgo.It("returns the server error when the upgrade is refused", func() {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"error":"forbidden"}`))
+		}))
+		defer server.Close()
+
+		_, err := New(server.URL, "token").AgentForward(context.Background(), "edge", "db:5432")
+		Expect(err).To(MatchError(ContainSubstring("server 403: forbidden")))
+	})
+})
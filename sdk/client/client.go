@@ -44,6 +44,7 @@ type ClientOption func(*Client)
 type Client struct {
 	*http.Client
 	serverURL     string
+	authHeader    string
 	tokenProvider TokenProvider
 }
 
@@ -65,7 +66,7 @@ func NewWithAuthHeader(serverURL, authHeader string, opts ...ClientOption) *Clie
 	if authHeader != "" {
 		client = client.Header("Authorization", authHeader)
 	}
-	out := &Client{Client: httpobservability.Apply(client), serverURL: strings.TrimRight(serverURL, "/")}
+	out := &Client{Client: httpobservability.Apply(client), serverURL: strings.TrimRight(serverURL, "/"), authHeader: authHeader}
 	for _, opt := range opts {
 		if opt != nil {
 			opt(out)
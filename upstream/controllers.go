@@ -22,6 +22,8 @@ import (
 	"github.com/flanksource/incident-commander/plugin/gateway"
 	"github.com/flanksource/incident-commander/push"
 	"github.com/flanksource/incident-commander/rbac"
+	"github.com/flanksource/incident-commander/upstream/distribution"
+	"github.com/flanksource/incident-commander/upstream/outbox"
 	"github.com/flanksource/incident-commander/upstream/tunnel"
 )
 
@@ -35,6 +37,14 @@ func RegisterRoutes(e *echo.Echo) {
 	logger.Infof("Registering /upstream routes")
 
 	e.POST("/push/topology", push.PushTopology, rbac.Topology(policy.ActionUpdate))
+	e.GET("/agent-distribution/drift", GetAgentDistributionDrift, rbac.Authorization(policy.ObjectAgent, policy.ActionRead))
+	e.GET("/agent-outbox/status", GetAgentOutboxStatus, rbac.Authorization(policy.ObjectAgent, policy.ActionRead))
+	e.GET("/outbox/status", GetOutboxStatus, rbac.Authorization(policy.ObjectAgent, policy.ActionRead))
+
+	agentSessions := e.Group("/agent/:agent", rbac.Agent(rbac.ActionAgentSession))
+	agentSessions.GET("/exec", tunnel.Exec)
+	agentSessions.GET("/forward", tunnel.Forward)
+	tunnel.RegisterAgentRoutes(e)
 
 	upstreamGroup := e.Group(
 		"/upstream",
@@ -50,6 +60,7 @@ func RegisterRoutes(e *echo.Echo) {
 
 	upstreamGroup.GET("/canary/pull", PullCanaries)
 	upstreamGroup.GET("/scrapeconfig/pull", PullScrapeConfigs)
+	upstreamGroup.GET("/distribution/pull", PullAgentDistribution)
 
 	upstreamGroup.POST("/artifacts/:id", artifactsPushHandler)
 
@@ -119,6 +130,56 @@ func PullScrapeConfigs(c echo.Context) error {
 	return c.JSON(http.StatusOK, scrapeConfigs)
 }
 
+// PullAgentDistribution returns the resources distributed to the agent.
+func PullAgentDistribution(c echo.Context) error {
+	ctx := c.Request().Context().(context.Context)
+	agent := ctx.Agent()
+
+	resources, err := distribution.ResourcesForAgent(ctx, *agent)
+	if err != nil {
+		return dutyAPI.WriteError(c, ctx.Oops().Wrapf(err, "error fetching agent distribution for agent(name=%s)", agent.Name))
+	}
+
+	return c.JSON(http.StatusOK, resources)
+}
+
+// GetAgentDistributionDrift returns the state of the distributed resources on every agent.
+func GetAgentDistributionDrift(c echo.Context) error {
+	ctx := c.Request().Context().(context.Context)
+
+	drift, err := distribution.GetDrift(ctx, c.QueryParam("agent"))
+	if err != nil {
+		return dutyAPI.WriteError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, drift)
+}
+
+// GetOutboxStatus returns the backlog of this agent's outbox.
+func GetOutboxStatus(c echo.Context) error {
+	ctx := c.Request().Context().(context.Context)
+
+	status, err := outbox.GetStatus(ctx)
+	if err != nil {
+		return dutyAPI.WriteError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, status)
+}
+
+// GetAgentOutboxStatus returns the outbox backlog every agent last reported
+// and when each agent's data was last fully reconciled.
+func GetAgentOutboxStatus(c echo.Context) error {
+	ctx := c.Request().Context().(context.Context)
+
+	statuses, err := outbox.ListAgentStatus(ctx, c.QueryParam("agent"))
+	if err != nil {
+		return dutyAPI.WriteError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, statuses)
+}
+
 func artifactsPushHandler(c echo.Context) error {
 	ctx := c.Request().Context().(context.Context)
 	artifactID := c.Param("id")
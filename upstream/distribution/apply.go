@@ -0,0 +1,221 @@
+package distribution
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/flanksource/duty/context"
+	"github.com/flanksource/duty/models"
+	"github.com/flanksource/duty/types"
+	"github.com/flanksource/duty/upstream"
+	"github.com/google/uuid"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sTypes "k8s.io/apimachinery/pkg/types"
+
+	v1 "github.com/flanksource/incident-commander/api/v1"
+	"github.com/flanksource/incident-commander/db"
+)
+
+type kindHandler struct {
+	model  any
+	apply  func(ctx context.Context, meta metav1.ObjectMeta, spec []byte) error
+	delete func(ctx context.Context, id string) error
+}
+
+var handlers = map[string]kindHandler{
+	v1.AgentDistributionKindConnection: {
+		model: &models.Connection{},
+		apply: func(ctx context.Context, meta metav1.ObjectMeta, spec []byte) error {
+			obj := v1.Connection{ObjectMeta: meta}
+			if err := json.Unmarshal(spec, &obj.Spec); err != nil {
+				return err
+			}
+			return db.PersistConnectionFromCRD(ctx, &obj)
+		},
+		delete: db.DeleteConnection,
+	},
+	v1.AgentDistributionKindPlaybook: {
+		model: &models.Playbook{},
+		apply: func(ctx context.Context, meta metav1.ObjectMeta, spec []byte) error {
+			obj := v1.Playbook{ObjectMeta: meta}
+			if err := json.Unmarshal(spec, &obj.Spec); err != nil {
+				return err
+			}
+			return db.PersistPlaybookFromCRD(ctx, &obj)
+		},
+		delete: db.DeletePlaybook,
+	},
+	v1.AgentDistributionKindNotification: {
+		model: &models.Notification{},
+		apply: func(ctx context.Context, meta metav1.ObjectMeta, spec []byte) error {
+			obj := v1.Notification{ObjectMeta: meta}
+			if err := json.Unmarshal(spec, &obj.Spec); err != nil {
+				return err
+			}
+			return db.PersistNotificationFromCRD(ctx, &obj)
+		},
+		delete: db.DeleteNotification,
+	},
+	v1.AgentDistributionKindView: {
+		model: &models.View{},
+		apply: func(ctx context.Context, meta metav1.ObjectMeta, spec []byte) error {
+			obj := v1.View{ObjectMeta: meta}
+			if err := json.Unmarshal(spec, &obj.Spec); err != nil {
+				return err
+			}
+			return db.PersistViewFromCRD(ctx, &obj)
+		},
+		delete: db.DeleteView,
+	},
+}
+
+// Pull fetches the resources distributed to this agent from the upstream.
+func Pull(ctx context.Context, client *upstream.UpstreamClient) ([]Resource, error) {
+	resp, err := client.R(ctx).QueryParam(upstream.AgentNameQueryParam, client.AgentName).Get("distribution/pull")
+	if err != nil {
+		return nil, fmt.Errorf("error pulling agent distribution from upstream: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if !resp.IsOK() {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return nil, fmt.Errorf("upstream server returned error status[%d]: %s", resp.StatusCode, string(body))
+	}
+
+	var resources []Resource
+	if err := json.NewDecoder(resp.Body).Decode(&resources); err != nil {
+		return nil, fmt.Errorf("error decoding agent distribution: %w", err)
+	}
+	return resources, nil
+}
+
+// Apply persists the resources to the local database and removes the
+// previously distributed resources that are no longer distributed.
+//
+// It returns the status of every resource as job history to be pushed to
+// the upstream, and an error if pruning failed.
+func Apply(ctx context.Context, agentName string, resources []Resource) ([]models.JobHistory, error) {
+	hostname, _ := os.Hostname()
+
+	statuses := make([]models.JobHistory, 0, len(resources))
+	for _, kind := range v1.AgentDistributionKinds {
+		for _, r := range resources {
+			if r.Kind != kind {
+				continue
+			}
+
+			start := time.Now()
+			err := applyResource(ctx, r)
+			if err != nil {
+				ctx.Warnf("failed to apply distributed %s: %v", r, err)
+			}
+			statuses = append(statuses, status(agentName, hostname, r, start, err))
+		}
+	}
+
+	return statuses, prune(ctx, resources)
+}
+
+func applyResource(ctx context.Context, r Resource) error {
+	handler, ok := handlers[r.Kind]
+	if !ok {
+		return fmt.Errorf("unsupported kind %s", r.Kind)
+	}
+
+	id, err := uuid.Parse(r.UID)
+	if err != nil {
+		return fmt.Errorf("invalid uid %q: %w", r.UID, err)
+	}
+
+	meta := metav1.ObjectMeta{
+		Name:       r.Name,
+		Namespace:  r.Namespace,
+		UID:        k8sTypes.UID(r.UID),
+		Generation: r.Generation,
+		Labels:     r.Labels,
+	}
+	if err := handler.apply(ctx, meta, r.Spec); err != nil {
+		return err
+	}
+
+	return ctx.DB().Model(handler.model).Where("id = ?", id).Update("source", Source).Error
+}
+
+// prune deletes the previously distributed resources that are no longer
+// distributed. Resources that failed to apply are left as they were.
+func prune(ctx context.Context, resources []Resource) error {
+	keep := map[string][]uuid.UUID{}
+	for _, r := range resources {
+		if id, err := uuid.Parse(r.UID); err == nil {
+			keep[r.Kind] = append(keep[r.Kind], id)
+		}
+	}
+
+	var errs []error
+	for _, kind := range v1.AgentDistributionKinds {
+		handler := handlers[kind]
+
+		q := ctx.DB().Model(handler.model).Where("source = ? AND deleted_at IS NULL", Source)
+		if len(keep[kind]) > 0 {
+			q = q.Where("id NOT IN ?", keep[kind])
+		}
+
+		var stale []string
+		if err := q.Pluck("id", &stale).Error; err != nil {
+			errs = append(errs, fmt.Errorf("failed to list stale %s: %w", kind, err))
+			continue
+		}
+
+		for _, id := range stale {
+			if err := handler.delete(ctx, id); err != nil {
+				errs = append(errs, fmt.Errorf("failed to delete stale %s %s: %w", kind, id, err))
+				continue
+			}
+			ctx.Infof("deleted %s %s that is no longer distributed", kind, id)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// status reports the outcome of applying a resource. The id is stable for an
+// agent & resource so that the upstream keeps a single row per pair.
+func status(agentName, hostname string, r Resource, start time.Time, err error) models.JobHistory {
+	end := time.Now()
+	history := models.JobHistory{
+		Name:           JobName,
+		Hostname:       hostname,
+		ResourceType:   ResourceType,
+		ResourceID:     r.UID,
+		TimeStart:      start,
+		TimeEnd:        &end,
+		DurationMillis: end.Sub(start).Milliseconds(),
+		Status:         models.StatusSuccess,
+		SuccessCount:   1,
+		Details: types.JSONMap{
+			"kind":         r.Kind,
+			"name":         r.Name,
+			"namespace":    r.Namespace,
+			"generation":   r.Generation,
+			"distribution": r.Distribution,
+		},
+	}
+
+	if id, parseErr := uuid.Parse(r.UID); parseErr == nil {
+		history.ID = uuid.NewSHA1(id, []byte(agentName))
+	} else {
+		history.ID = uuid.New()
+	}
+
+	if err != nil {
+		history.Status = models.StatusFailed
+		history.SuccessCount, history.ErrorCount = 0, 1
+		history.Details["error"] = err.Error()
+	}
+
+	return history
+}
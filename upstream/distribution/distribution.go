@@ -0,0 +1,204 @@
+// Package distribution distributes resources declared with AgentDistribution
+// on the upstream to the selected agents.
+//
+// Agents pull the resources targeted at them, persist them in their local
+// database and report the applied generation of every resource back to the
+// upstream as job history.
+package distribution
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/flanksource/duty/context"
+	"github.com/flanksource/duty/models"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	v1 "github.com/flanksource/incident-commander/api/v1"
+)
+
+const (
+	// JobName is the job history name agents report applied resources with.
+	JobName = "AgentDistribution"
+
+	// ResourceType is the job history resource type of a distributed resource.
+	ResourceType = "agent_distribution"
+
+	// Source marks the resources in the agent's database that were applied from a distribution.
+	Source = "AgentDistribution"
+
+	apiGroup   = "mission-control.flanksource.com"
+	apiVersion = "v1"
+)
+
+// ErrInlineSecret is returned for resources that hold secrets inline, which
+// are never sent to the agents.
+var ErrInlineSecret = errors.New("inline secrets can't be distributed")
+
+// Resource is a distributed resource as sent to the agents.
+type Resource struct {
+	Kind       string            `json:"kind"`
+	Name       string            `json:"name"`
+	Namespace  string            `json:"namespace,omitempty"`
+	UID        string            `json:"uid"`
+	Generation int64             `json:"generation"`
+	Labels     map[string]string `json:"labels,omitempty"`
+
+	// Distribution is the namespace/name of the AgentDistribution the resource was distributed by.
+	Distribution string          `json:"distribution"`
+	Spec         json.RawMessage `json:"spec"`
+}
+
+func (r Resource) String() string {
+	return fmt.Sprintf("%s/%s/%s", r.Kind, r.Namespace, r.Name)
+}
+
+// ListDistributions returns all the AgentDistributions on the upstream cluster.
+func ListDistributions(ctx context.Context) ([]v1.AgentDistribution, error) {
+	kc, err := ctx.Kubernetes()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get kubernetes client: %w", err)
+	}
+
+	client, err := kc.GetClientByGroupVersionKind(ctx, apiGroup, apiVersion, "AgentDistribution")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get client for AgentDistribution: %w", err)
+	}
+
+	list, err := client.Namespace(metav1.NamespaceAll).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list AgentDistributions: %w", err)
+	}
+
+	distributions := make([]v1.AgentDistribution, 0, len(list.Items))
+	for _, item := range list.Items {
+		var d v1.AgentDistribution
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(item.Object, &d); err != nil {
+			return nil, fmt.Errorf("invalid AgentDistribution %s/%s: %w", item.GetNamespace(), item.GetName(), err)
+		}
+		distributions = append(distributions, d)
+	}
+
+	return distributions, nil
+}
+
+// resolver fetches the referenced resources from the upstream cluster,
+// fetching each resource only once.
+type resolver struct {
+	ctx   context.Context
+	cache map[string]*Resource
+}
+
+func newResolver(ctx context.Context) *resolver {
+	return &resolver{ctx: ctx, cache: map[string]*Resource{}}
+}
+
+// resolve returns nil if the referenced resource doesn't exist.
+func (t *resolver) resolve(d v1.AgentDistribution, ref v1.AgentDistributionResource) (*Resource, error) {
+	namespace := ref.Namespace
+	if namespace == "" {
+		namespace = d.Namespace
+	}
+
+	key := fmt.Sprintf("%s/%s/%s", ref.Kind, namespace, ref.Name)
+	if r, ok := t.cache[key]; ok {
+		return r, nil
+	}
+
+	kc, err := t.ctx.Kubernetes()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get kubernetes client: %w", err)
+	}
+
+	client, err := kc.GetClientByGroupVersionKind(t.ctx, apiGroup, apiVersion, ref.Kind)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get client for %s: %w", ref.Kind, err)
+	}
+
+	obj, err := client.Namespace(namespace).Get(t.ctx, ref.Name, metav1.GetOptions{})
+	if err != nil {
+		if apiErrors.IsNotFound(err) {
+			t.cache[key] = nil
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get %s: %w", key, err)
+	}
+
+	spec, err := json.Marshal(obj.Object["spec"])
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal spec of %s: %w", key, err)
+	}
+
+	// Secrets are only distributed as references, which agents resolve from
+	// their own cluster.
+	if ref.Kind == v1.AgentDistributionKindConnection {
+		secrets, err := inlineSecrets(spec)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check %s for secrets: %w", key, err)
+		} else if len(secrets) > 0 {
+			return nil, fmt.Errorf("%w: %s has inline values for %s, use valueFrom", ErrInlineSecret, key, strings.Join(secrets, ", "))
+		}
+	}
+
+	r := &Resource{
+		Kind:         ref.Kind,
+		Name:         obj.GetName(),
+		Namespace:    obj.GetNamespace(),
+		UID:          string(obj.GetUID()),
+		Generation:   obj.GetGeneration(),
+		Labels:       obj.GetLabels(),
+		Distribution: fmt.Sprintf("%s/%s", d.Namespace, d.Name),
+		Spec:         spec,
+	}
+	t.cache[key] = r
+	return r, nil
+}
+
+// forAgent returns the resources distributed to the agent.
+// A resource referenced by multiple distributions is only returned once.
+func (t *resolver) forAgent(distributions []v1.AgentDistribution, agent models.Agent) ([]Resource, error) {
+	seen := map[string]struct{}{}
+	var resources []Resource
+	for _, d := range distributions {
+		if !d.Spec.Agents.Matches(agent) {
+			continue
+		}
+
+		for _, ref := range d.Spec.Resources {
+			r, err := t.resolve(d, ref)
+			if errors.Is(err, ErrInlineSecret) {
+				t.ctx.Errorf("agent distribution %s/%s: %v", d.Namespace, d.Name, err)
+				continue
+			} else if err != nil {
+				return nil, err
+			} else if r == nil {
+				t.ctx.Warnf("agent distribution %s/%s references %s %s which does not exist", d.Namespace, d.Name, ref.Kind, ref.Name)
+				continue
+			}
+
+			if _, ok := seen[r.UID]; ok {
+				continue
+			}
+			seen[r.UID] = struct{}{}
+			resources = append(resources, *r)
+		}
+	}
+
+	sort.Slice(resources, func(i, j int) bool { return resources[i].String() < resources[j].String() })
+	return resources, nil
+}
+
+// ResourcesForAgent returns the resources distributed to the agent.
+func ResourcesForAgent(ctx context.Context, agent models.Agent) ([]Resource, error) {
+	distributions, err := ListDistributions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return newResolver(ctx).forAgent(distributions, agent)
+}
@@ -0,0 +1,156 @@
+package distribution
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/flanksource/duty/context"
+	"github.com/flanksource/duty/models"
+	"github.com/google/uuid"
+	"github.com/samber/lo"
+)
+
+// Drift statuses of a distributed resource on an agent.
+const (
+	// DriftStatusInSync means the agent applied the latest generation.
+	DriftStatusInSync = "InSync"
+
+	// DriftStatusOutOfSync means the agent last reported an older generation.
+	DriftStatusOutOfSync = "OutOfSync"
+
+	// DriftStatusFailed means the agent failed to apply the latest generation.
+	DriftStatusFailed = "Failed"
+
+	// DriftStatusPending means the agent hasn't reported the resource yet.
+	DriftStatusPending = "Pending"
+)
+
+// Drift is the state of a distributed resource on an agent.
+type Drift struct {
+	AgentID            uuid.UUID  `json:"agent_id"`
+	Agent              string     `json:"agent"`
+	Distribution       string     `json:"distribution"`
+	Kind               string     `json:"kind"`
+	Name               string     `json:"name"`
+	Namespace          string     `json:"namespace,omitempty"`
+	UID                string     `json:"uid"`
+	Generation         int64      `json:"generation"`
+	ReportedGeneration int64      `json:"reported_generation,omitempty"`
+	Status             string     `json:"status"`
+	Error              string     `json:"error,omitempty"`
+	ReportedAt         *time.Time `json:"reported_at,omitempty"`
+}
+
+// GetDrift compares the resources distributed to every agent against the
+// generation the agent last reported. agentName optionally limits it to a
+// single agent. The result is sorted by agent and resource.
+func GetDrift(ctx context.Context, agentName string) ([]Drift, error) {
+	q := ctx.DB().Where("deleted_at IS NULL")
+	if agentName != "" {
+		q = q.Where("name = ?", agentName)
+	}
+
+	var agents []models.Agent
+	if err := q.Order("name").Find(&agents).Error; err != nil {
+		return nil, fmt.Errorf("failed to list agents: %w", err)
+	}
+	if len(agents) == 0 {
+		return nil, nil
+	}
+
+	distributions, err := ListDistributions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r := newResolver(ctx)
+	desired := map[uuid.UUID][]Resource{}
+	for _, agent := range agents {
+		if desired[agent.ID], err = r.forAgent(distributions, agent); err != nil {
+			return nil, err
+		}
+	}
+
+	var reports []models.JobHistory
+	if err := ctx.DB().Where("name = ? AND resource_type = ?", JobName, ResourceType).
+		Where("agent_id IN ?", lo.Map(agents, func(a models.Agent, _ int) uuid.UUID { return a.ID })).
+		Find(&reports).Error; err != nil {
+		return nil, fmt.Errorf("failed to list agent distribution reports: %w", err)
+	}
+
+	return computeDrift(agents, desired, reports), nil
+}
+
+func computeDrift(agents []models.Agent, desired map[uuid.UUID][]Resource, reports []models.JobHistory) []Drift {
+	type reportKey struct {
+		agentID    uuid.UUID
+		resourceID string
+	}
+
+	latest := map[reportKey]models.JobHistory{}
+	for _, report := range reports {
+		key := reportKey{report.AgentID, report.ResourceID}
+		if existing, ok := latest[key]; !ok || reportTime(report).After(reportTime(existing)) {
+			latest[key] = report
+		}
+	}
+
+	var drift []Drift
+	for _, agent := range agents {
+		for _, r := range desired[agent.ID] {
+			d := Drift{
+				AgentID:      agent.ID,
+				Agent:        agent.Name,
+				Distribution: r.Distribution,
+				Kind:         r.Kind,
+				Name:         r.Name,
+				Namespace:    r.Namespace,
+				UID:          r.UID,
+				Generation:   r.Generation,
+				Status:       DriftStatusPending,
+			}
+
+			if report, ok := latest[reportKey{agent.ID, r.UID}]; ok {
+				d.ReportedAt = lo.ToPtr(reportTime(report))
+				d.ReportedGeneration = reportedGeneration(report)
+				if msg, ok := report.Details["error"].(string); ok {
+					d.Error = msg
+				}
+
+				switch {
+				case d.ReportedGeneration != r.Generation:
+					d.Status = DriftStatusOutOfSync
+				case report.Status == models.StatusFailed:
+					d.Status = DriftStatusFailed
+				default:
+					d.Status = DriftStatusInSync
+				}
+			}
+
+			drift = append(drift, d)
+		}
+	}
+
+	return drift
+}
+
+func reportTime(h models.JobHistory) time.Time {
+	if h.TimeEnd != nil {
+		return *h.TimeEnd
+	}
+	return h.TimeStart
+}
+
+// reportedGeneration reads the generation from the job history details,
+// which is a float64 once it has been through JSON.
+func reportedGeneration(h models.JobHistory) int64 {
+	switch v := h.Details["generation"].(type) {
+	case float64:
+		return int64(v)
+	case int64:
+		return v
+	case int:
+		return int64(v)
+	}
+	return 0
+}
@@ -0,0 +1,62 @@
+package distribution
+
+import (
+	"errors"
+	"time"
+
+	"github.com/flanksource/duty/models"
+	"github.com/google/uuid"
+	ginkgo "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = ginkgo.Describe("Drift", func() {
+	agent := models.Agent{ID: uuid.New(), Name: "prod"}
+
+	resource := func(generation int64) Resource {
+		return Resource{Kind: "Playbook", Name: "restart", Namespace: "default", UID: uuid.NewString(), Generation: generation}
+	}
+
+	report := func(r Resource, generation int64, err error) models.JobHistory {
+		r.Generation = generation
+		h := status(agent.Name, "host", r, time.Now(), err)
+		h.AgentID = agent.ID
+
+		// Reports read back from the database have their details decoded from JSON.
+		h.Details["generation"] = float64(generation)
+		return h
+	}
+
+	ginkgo.It("is pending until the agent reports", func() {
+		r := resource(1)
+		drift := computeDrift([]models.Agent{agent}, map[uuid.UUID][]Resource{agent.ID: {r}}, nil)
+		Expect(drift).To(HaveLen(1))
+		Expect(drift[0].Status).To(Equal(DriftStatusPending))
+	})
+
+	ginkgo.It("is in sync when the latest generation was applied", func() {
+		r := resource(3)
+		drift := computeDrift([]models.Agent{agent}, map[uuid.UUID][]Resource{agent.ID: {r}}, []models.JobHistory{report(r, 3, nil)})
+		Expect(drift[0].Status).To(Equal(DriftStatusInSync))
+		Expect(drift[0].ReportedGeneration).To(Equal(int64(3)))
+	})
+
+	ginkgo.It("is out of sync when an older generation was reported", func() {
+		r := resource(4)
+		drift := computeDrift([]models.Agent{agent}, map[uuid.UUID][]Resource{agent.ID: {r}}, []models.JobHistory{report(r, 3, nil)})
+		Expect(drift[0].Status).To(Equal(DriftStatusOutOfSync))
+	})
+
+	ginkgo.It("reports failures", func() {
+		r := resource(2)
+		drift := computeDrift([]models.Agent{agent}, map[uuid.UUID][]Resource{agent.ID: {r}}, []models.JobHistory{report(r, 2, errors.New("invalid spec"))})
+		Expect(drift[0].Status).To(Equal(DriftStatusFailed))
+		Expect(drift[0].Error).To(Equal("invalid spec"))
+	})
+
+	ginkgo.It("uses a stable report id per agent and resource", func() {
+		r := resource(1)
+		Expect(status("prod", "", r, time.Now(), nil).ID).To(Equal(status("prod", "", r, time.Now(), nil).ID))
+		Expect(status("prod", "", r, time.Now(), nil).ID).ToNot(Equal(status("staging", "", r, time.Now(), nil).ID))
+	})
+})
@@ -0,0 +1,76 @@
+package distribution
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// secretKeys are the parts of field names that hold secrets.
+var secretKeys = []string{"password", "secret", "token", "key", "certificate", "credential", "bearer", "webhook"}
+
+// inlineSecrets returns the paths of the fields of a spec that hold a secret
+// inline, i.e. with a value rather than valueFrom. URLs hold a secret when
+// they carry a password.
+func inlineSecrets(spec json.RawMessage) ([]string, error) {
+	var obj any
+	if err := json.Unmarshal(spec, &obj); err != nil {
+		return nil, fmt.Errorf("invalid spec: %w", err)
+	}
+
+	var paths []string
+	var walk func(path, key string, v any)
+	walk = func(path, key string, v any) {
+		switch v := v.(type) {
+		case map[string]any:
+			if value, ok := envVarValue(v); ok {
+				if value != "" && isSecret(key, value) {
+					paths = append(paths, path)
+				}
+				return
+			}
+			for k, child := range v {
+				walk(strings.TrimPrefix(path+"."+k, "."), k, child)
+			}
+		case []any:
+			for i, child := range v {
+				walk(fmt.Sprintf("%s[%d]", path, i), key, child)
+			}
+		}
+	}
+	walk("", "", obj)
+
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// envVarValue returns the inline value of an EnvVar.
+func envVarValue(m map[string]any) (string, bool) {
+	value, ok := m["value"].(string)
+	if !ok {
+		return "", false
+	}
+	for k := range m {
+		if k != "name" && k != "value" && k != "valueFrom" {
+			return "", false
+		}
+	}
+	return value, true
+}
+
+func isSecret(key, value string) bool {
+	key = strings.ToLower(key)
+	for _, s := range secretKeys {
+		if strings.Contains(key, s) {
+			return true
+		}
+	}
+
+	if u, err := url.Parse(value); err == nil && u.User != nil {
+		_, hasPassword := u.User.Password()
+		return hasPassword
+	}
+	return false
+}
@@ -0,0 +1,31 @@
+package distribution
+
+import (
+	ginkgo "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = ginkgo.Describe("inlineSecrets", func() {
+	ginkgo.It("finds secrets with inline values", func() {
+		secrets, err := inlineSecrets([]byte(`{
+			"postgres": {
+				"url": {"value": "postgres://app:hunter2@db:5432/app"},
+				"username": {"value": "app"},
+				"password": {"value": "hunter2"}
+			},
+			"http": {
+				"url": {"value": "https://prometheus:9090"},
+				"bearer": {"valueFrom": {"secretKeyRef": {"name": "prometheus", "key": "token"}}}
+			},
+			"aws": {"secretKey": {"value": ""}, "region": "eu-west-1"}
+		}`))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(secrets).To(Equal([]string{"postgres.password", "postgres.url"}))
+	})
+
+	ginkgo.It("allows secrets from references", func() {
+		secrets, err := inlineSecrets([]byte(`{"slack": {"token": {"valueFrom": {"secretKeyRef": {"name": "slack", "key": "token"}}}}}`))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(secrets).To(BeEmpty())
+	})
+})
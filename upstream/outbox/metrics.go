@@ -0,0 +1,70 @@
+package outbox
+
+import "github.com/prometheus/client_golang/prometheus"
+
+func init() {
+	prometheus.MustRegister(depthGauge, sizeGauge, oldestAgeGauge, enqueuedCounter, deliveredCounter, failedCounter, droppedCounter)
+}
+
+var (
+	depthGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name:      "depth",
+			Subsystem: "agent_outbox",
+			Help:      "Number of items waiting to be delivered to the upstream",
+		},
+		[]string{"kind"},
+	)
+
+	sizeGauge = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name:      "size_bytes",
+			Subsystem: "agent_outbox",
+			Help:      "Total size of the items waiting to be delivered to the upstream",
+		},
+	)
+
+	oldestAgeGauge = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name:      "oldest_age_seconds",
+			Subsystem: "agent_outbox",
+			Help:      "Age of the oldest item waiting to be delivered to the upstream",
+		},
+	)
+
+	enqueuedCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name:      "enqueued_total",
+			Subsystem: "agent_outbox",
+			Help:      "Total number of items added to the outbox",
+		},
+		[]string{"kind"},
+	)
+
+	deliveredCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name:      "delivered_total",
+			Subsystem: "agent_outbox",
+			Help:      "Total number of outbox items delivered to the upstream",
+		},
+		[]string{"kind"},
+	)
+
+	failedCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name:      "delivery_error_total",
+			Subsystem: "agent_outbox",
+			Help:      "Total number of failed outbox deliveries",
+		},
+		[]string{"kind"},
+	)
+
+	droppedCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name:      "dropped_total",
+			Subsystem: "agent_outbox",
+			Help:      "Total number of outbox items evicted to make room for higher priority items",
+		},
+		[]string{"kind"},
+	)
+)
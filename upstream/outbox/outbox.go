@@ -0,0 +1,334 @@
+// Package outbox is a durable queue of data an agent must deliver to the
+// upstream. Producers enqueue what they failed to deliver and a job drains
+// the queue in priority order, backing off while the upstream is unreachable.
+package outbox
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/flanksource/duty/context"
+	"github.com/flanksource/duty/upstream"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/samber/lo"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Kinds of items with a built-in handler.
+const (
+	// KindPush carries upstream.PushData for the /upstream/push endpoint.
+	KindPush = "push"
+
+	// KindArtifact carries an ArtifactPayload. Its handler is registered by
+	// the artifacts package.
+	KindArtifact = "artifact"
+)
+
+// Priorities of items. Higher priorities are delivered first and are the
+// last to be evicted when the outbox is full.
+const (
+	PriorityLow    = 0
+	PriorityNormal = 50
+	PriorityHigh   = 100
+)
+
+const (
+	defaultMaxItems    = 10000
+	defaultMaxSize     = 256 * 1024 * 1024
+	defaultBackoffBase = 10 * time.Second
+	defaultBackoffMax  = 30 * time.Minute
+)
+
+// ErrFull is returned when an item doesn't fit in the outbox and there is no
+// lower priority item to evict in its place.
+var ErrFull = errors.New("agent outbox is full")
+
+// Item is a row of the agent_outbox table.
+type Item struct {
+	ID       uuid.UUID `gorm:"default:generate_ulid()"`
+	Kind     string
+	Priority int
+	Payload  []byte
+	Size     int
+	// Source is the table of the rows the item carries, SourceIDs their ids.
+	// The rows are acknowledged when the item is delivered.
+	Source        string         `gorm:"default:NULL"`
+	SourceIDs     pq.StringArray `gorm:"type:uuid[]"`
+	Attempts      int
+	LastError     string
+	NextAttemptAt time.Time
+	CreatedAt     time.Time
+}
+
+func (Item) TableName() string { return "agent_outbox" }
+
+// ArtifactPayload is the payload of a KindArtifact item.
+type ArtifactPayload struct {
+	ID uuid.UUID `json:"id"`
+}
+
+// Handler delivers the payload of an item to the upstream.
+type Handler func(ctx context.Context, client *upstream.UpstreamClient, payload []byte) error
+
+// Ack marks the rows of a source as delivered.
+type Ack func(tx *gorm.DB, ids []string) error
+
+var (
+	handlersMu sync.RWMutex
+	handlers   = map[string]Handler{
+		KindPush: deliverPush,
+	}
+	acks = map[string]Ack{}
+)
+
+// RegisterAck sets how the rows of source are marked delivered. Producers
+// keep the rows they queue pending until then, so a row whose item is evicted
+// is picked up again.
+func RegisterAck(source string, ack Ack) {
+	handlersMu.Lock()
+	defer handlersMu.Unlock()
+	acks[source] = ack
+}
+
+func getAck(source string) (Ack, bool) {
+	handlersMu.RLock()
+	defer handlersMu.RUnlock()
+	ack, ok := acks[source]
+	return ack, ok
+}
+
+// NotQueued is the condition that excludes the rows of source that are
+// waiting in the outbox.
+func NotQueued(source string) clause.Expr {
+	return gorm.Expr("id NOT IN (SELECT UNNEST(source_ids) FROM agent_outbox WHERE source = ?)", source)
+}
+
+// RegisterHandler sets the handler that delivers items of kind.
+func RegisterHandler(kind string, handler Handler) {
+	handlersMu.Lock()
+	defer handlersMu.Unlock()
+	handlers[kind] = handler
+}
+
+func getHandler(kind string) (Handler, bool) {
+	handlersMu.RLock()
+	defer handlersMu.RUnlock()
+	h, ok := handlers[kind]
+	return h, ok
+}
+
+func deliverPush(ctx context.Context, client *upstream.UpstreamClient, payload []byte) error {
+	var data upstream.PushData
+	if err := json.Unmarshal(payload, &data); err != nil {
+		return fmt.Errorf("invalid push payload: %w", err)
+	}
+	return client.Push(ctx, &data)
+}
+
+// Enqueue durably stores data to be delivered later. data is marshalled to
+// JSON unless it already is a []byte.
+//
+// When the outbox is over its item or size limit, the oldest items of a
+// lower priority are evicted to make room. ErrFull is returned when that
+// isn't possible, in which case the caller should keep its own state so
+// the data is retried from there.
+func Enqueue(ctx context.Context, kind string, priority int, data any) error {
+	return enqueue(ctx, kind, priority, data, "", nil)
+}
+
+// EnqueueRows enqueues data that carries the rows of source with the ids.
+// The rows are acknowledged with the Ack registered for source once the item
+// is delivered, not before.
+func EnqueueRows(ctx context.Context, source string, ids []uuid.UUID, kind string, priority int, data any) error {
+	if _, ok := getAck(source); !ok {
+		return fmt.Errorf("no outbox ack for source %q", source)
+	}
+	return enqueue(ctx, kind, priority, data, source, lo.Map(ids, func(id uuid.UUID, _ int) string { return id.String() }))
+}
+
+func enqueue(ctx context.Context, kind string, priority int, data any, source string, sourceIDs []string) error {
+	if _, ok := getHandler(kind); !ok {
+		return fmt.Errorf("no outbox handler for kind %q", kind)
+	}
+
+	payload, ok := data.([]byte)
+	if !ok {
+		var err error
+		if payload, err = json.Marshal(data); err != nil {
+			return fmt.Errorf("failed to marshal %s outbox item: %w", kind, err)
+		}
+	}
+
+	item := Item{
+		Kind:          kind,
+		Priority:      priority,
+		Payload:       payload,
+		Size:          len(payload),
+		Source:        source,
+		SourceIDs:     sourceIDs,
+		NextAttemptAt: time.Now(),
+	}
+
+	maxItems := ctx.Properties().Int("outbox.max_items", defaultMaxItems)
+	maxSize := int64(ctx.Properties().Int("outbox.max_size", defaultMaxSize))
+	if int64(item.Size) > maxSize {
+		return fmt.Errorf("%w: %s item of %d bytes exceeds the %d byte limit", ErrFull, kind, item.Size, maxSize)
+	}
+
+	var evicted []Item
+	err := ctx.DB().Transaction(func(tx *gorm.DB) error {
+		// Serialize writers so the limits can't be raced.
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", "agent_outbox").Error; err != nil {
+			return err
+		}
+
+		var usage struct {
+			Count int64
+			Size  int64
+		}
+		if err := tx.Model(&Item{}).Select("COUNT(*) AS count, COALESCE(SUM(size), 0) AS size").Scan(&usage).Error; err != nil {
+			return err
+		}
+
+		for usage.Count+1 > int64(maxItems) || usage.Size+int64(item.Size) > maxSize {
+			var victims []Item
+			if err := tx.Select("id, kind, priority, size, created_at").
+				Where("priority < ?", priority).
+				Order("priority, created_at").Limit(1).Find(&victims).Error; err != nil {
+				return err
+			}
+			if len(victims) == 0 {
+				return ErrFull
+			}
+
+			if err := tx.Delete(&Item{}, "id = ?", victims[0].ID).Error; err != nil {
+				return err
+			}
+			evicted = append(evicted, victims[0])
+			usage.Count--
+			usage.Size -= int64(victims[0].Size)
+		}
+
+		return tx.Create(&item).Error
+	})
+	if err != nil {
+		if errors.Is(err, ErrFull) {
+			return err
+		}
+		return fmt.Errorf("failed to enqueue %s outbox item: %w", kind, err)
+	}
+
+	for _, victim := range evicted {
+		ctx.Warnf("evicted %s outbox item %s queued at %s to make room for a %s item", victim.Kind, victim.ID, victim.CreatedAt.Format(time.RFC3339), kind)
+		droppedCounter.WithLabelValues(victim.Kind).Inc()
+	}
+	enqueuedCounter.WithLabelValues(kind).Inc()
+	return nil
+}
+
+// DrainResult summarizes a Drain.
+type DrainResult struct {
+	Delivered int `json:"delivered"`
+	Failed    int `json:"failed"`
+}
+
+// Drain delivers the due items, highest priority first. It stops at the
+// first failure as the upstream is most likely unreachable; the failed item
+// is retried after an exponential backoff.
+func Drain(ctx context.Context, client *upstream.UpstreamClient, batchSize int) (DrainResult, error) {
+	var result DrainResult
+	base := ctx.Properties().Duration("outbox.backoff.base", defaultBackoffBase)
+	maxBackoff := ctx.Properties().Duration("outbox.backoff.max", defaultBackoffMax)
+
+	for {
+		var items []Item
+		if err := ctx.DB().Where("next_attempt_at <= NOW()").
+			Order("priority DESC, created_at").
+			Limit(batchSize).
+			Find(&items).Error; err != nil {
+			return result, fmt.Errorf("failed to list outbox items: %w", err)
+		}
+
+		if len(items) == 0 {
+			if err := markReconciled(ctx); err != nil {
+				return result, err
+			}
+			return result, nil
+		}
+
+		for _, item := range items {
+			err := deliver(ctx, client, item)
+			if err == nil {
+				if err := ack(ctx, item); err != nil {
+					return result, err
+				}
+				result.Delivered++
+				deliveredCounter.WithLabelValues(item.Kind).Inc()
+				continue
+			}
+
+			result.Failed++
+			failedCounter.WithLabelValues(item.Kind).Inc()
+			next := time.Now().Add(Backoff(item.Attempts+1, base, maxBackoff))
+			if uerr := ctx.DB().Model(&Item{}).Where("id = ?", item.ID).Updates(map[string]any{
+				"attempts":        item.Attempts + 1,
+				"last_error":      err.Error(),
+				"next_attempt_at": next,
+			}).Error; uerr != nil {
+				return result, fmt.Errorf("failed to update outbox item: %w", uerr)
+			}
+
+			return result, fmt.Errorf("failed to deliver %s outbox item %s: %w", item.Kind, item.ID, err)
+		}
+	}
+}
+
+// ack removes a delivered item and marks the rows it carried delivered.
+func ack(ctx context.Context, item Item) error {
+	err := ctx.DB().Transaction(func(tx *gorm.DB) error {
+		if item.Source != "" && len(item.SourceIDs) > 0 {
+			fn, ok := getAck(item.Source)
+			if !ok {
+				return fmt.Errorf("no outbox ack for source %q", item.Source)
+			}
+			if err := fn(tx, item.SourceIDs); err != nil {
+				return err
+			}
+		}
+		return tx.Delete(&Item{}, "id = ?", item.ID).Error
+	})
+	if err != nil {
+		return fmt.Errorf("failed to acknowledge delivered outbox item %s: %w", item.ID, err)
+	}
+	return nil
+}
+
+func deliver(ctx context.Context, client *upstream.UpstreamClient, item Item) error {
+	handler, ok := getHandler(item.Kind)
+	if !ok {
+		return fmt.Errorf("no outbox handler for kind %q", item.Kind)
+	}
+	return handler(ctx, client, item.Payload)
+}
+
+// Backoff returns the delay before the given attempt: base doubled for
+// every previous attempt, capped at max.
+func Backoff(attempt int, base, max time.Duration) time.Duration {
+	if attempt < 1 {
+		return 0
+	}
+
+	delay := base
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if delay >= max || delay <= 0 {
+			return max
+		}
+	}
+	return min(delay, max)
+}
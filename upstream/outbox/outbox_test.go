@@ -0,0 +1,149 @@
+package outbox
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/flanksource/duty/context"
+	"github.com/flanksource/duty/models"
+	"github.com/flanksource/duty/types"
+	"github.com/flanksource/duty/upstream"
+	"github.com/google/uuid"
+	ginkgo "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/samber/lo"
+	"gorm.io/gorm"
+)
+
+var _ = ginkgo.Describe("EnqueueRows", ginkgo.Ordered, func() {
+	const kind, source = "test", "test_rows"
+
+	var (
+		rows    = []uuid.UUID{uuid.New(), uuid.New()}
+		pending = uuid.New()
+		acked   []string
+		failing bool
+	)
+
+	ginkgo.BeforeAll(func() {
+		RegisterHandler(kind, func(ctx context.Context, client *upstream.UpstreamClient, payload []byte) error {
+			if failing {
+				return errors.New("upstream is unreachable")
+			}
+			return nil
+		})
+		RegisterAck(source, func(tx *gorm.DB, ids []string) error {
+			acked = append(acked, ids...)
+			return nil
+		})
+	})
+
+	ginkgo.AfterAll(func() {
+		Expect(DefaultContext.DB().Delete(&Item{}, "kind = ?", kind).Error).To(Succeed())
+	})
+
+	notQueued := func() []uuid.UUID {
+		var ids []uuid.UUID
+		Expect(DefaultContext.DB().Table("(VALUES (?::uuid), (?::uuid), (?::uuid)) AS test_rows(id)", rows[0], rows[1], pending).
+			Where(NotQueued(source)).
+			Pluck("id", &ids).Error).To(Succeed())
+		return ids
+	}
+
+	ginkgo.It("keeps queued rows pending until they are delivered", func() {
+		Expect(EnqueueRows(DefaultContext, source, rows, kind, PriorityNormal, map[string]any{"rows": rows})).To(Succeed())
+
+		Expect(notQueued()).To(ConsistOf(pending))
+		Expect(acked).To(BeEmpty())
+	})
+
+	ginkgo.It("doesn't acknowledge the rows when the delivery fails", func() {
+		failing = true
+		_, err := Drain(DefaultContext, nil, 10)
+		Expect(err).To(HaveOccurred())
+
+		Expect(acked).To(BeEmpty())
+		Expect(notQueued()).To(ConsistOf(pending))
+	})
+
+	ginkgo.It("acknowledges the rows once they are delivered", func() {
+		failing = false
+		Expect(DefaultContext.DB().Model(&Item{}).Where("kind = ?", kind).Update("next_attempt_at", time.Now()).Error).To(Succeed())
+
+		result, err := Drain(DefaultContext, nil, 10)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.Delivered).To(Equal(1))
+
+		Expect(acked).To(ConsistOf(rows[0].String(), rows[1].String()))
+		Expect(notQueued()).To(ConsistOf(rows[0], rows[1], pending))
+	})
+
+	ginkgo.It("keeps when the outbox was reconciled", func() {
+		reconciledAt, err := getReconciledAt(DefaultContext)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(reconciledAt).ToNot(BeNil())
+		Expect(*reconciledAt).To(BeTemporally("~", time.Now(), time.Minute))
+
+		status, err := GetStatus(DefaultContext)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(status.ReconciledAt).To(Equal(reconciledAt))
+	})
+
+	ginkgo.It("rejects rows of a source without an ack", func() {
+		Expect(EnqueueRows(DefaultContext, "unknown", rows, kind, PriorityNormal, nil)).ToNot(Succeed())
+	})
+})
+
+var _ = ginkgo.Describe("Backoff", func() {
+	ginkgo.It("doubles the delay on every attempt up to the max", func() {
+		base, max := 10*time.Second, 5*time.Minute
+
+		Expect(Backoff(0, base, max)).To(Equal(time.Duration(0)))
+		Expect(Backoff(1, base, max)).To(Equal(10 * time.Second))
+		Expect(Backoff(2, base, max)).To(Equal(20 * time.Second))
+		Expect(Backoff(5, base, max)).To(Equal(160 * time.Second))
+		Expect(Backoff(6, base, max)).To(Equal(max))
+		Expect(Backoff(500, base, max)).To(Equal(max))
+	})
+})
+
+var _ = ginkgo.Describe("agentStatuses", func() {
+	ginkgo.It("uses the latest report of every agent", func() {
+		edge := models.Agent{ID: uuid.New(), Name: "edge"}
+		silent := models.Agent{ID: uuid.New(), Name: "silent"}
+
+		oldest := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+		reconciled := oldest.Add(-time.Hour)
+
+		// Round trip the details through JSON as the upstream stores them.
+		var details types.JSONMap
+		b, err := json.Marshal(map[string]any{
+			"depth":         12,
+			"oldest_at":     oldest,
+			"reconciled_at": reconciled,
+			"last_error":    "connection refused",
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(json.Unmarshal(b, &details)).To(Succeed())
+
+		reports := []models.JobHistory{
+			{AgentID: edge.ID, TimeStart: oldest.Add(-2 * time.Hour), Details: types.JSONMap{"depth": float64(1)}},
+			{AgentID: edge.ID, TimeStart: oldest, Details: details},
+		}
+
+		statuses := agentStatuses([]models.Agent{edge, silent}, reports)
+		Expect(statuses).To(HaveLen(2))
+
+		Expect(statuses[0].Agent).To(Equal("edge"))
+		Expect(statuses[0].Depth).To(Equal(int64(12)))
+		Expect(statuses[0].ReportedAt).To(Equal(lo.ToPtr(oldest)))
+		Expect(statuses[0].OldestAt.Equal(oldest)).To(BeTrue())
+		Expect(statuses[0].ReconciledAt.Equal(reconciled)).To(BeTrue())
+		Expect(statuses[0].LastError).To(Equal("connection refused"))
+
+		Expect(statuses[1].Agent).To(Equal("silent"))
+		Expect(statuses[1].ReportedAt).To(BeNil())
+		Expect(statuses[1].Depth).To(BeZero())
+	})
+})
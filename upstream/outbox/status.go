@@ -0,0 +1,236 @@
+package outbox
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/flanksource/duty/context"
+	"github.com/flanksource/duty/models"
+	"github.com/flanksource/duty/types"
+	"github.com/flanksource/duty/upstream"
+	"github.com/google/uuid"
+	"github.com/samber/lo"
+)
+
+// Job history an agent pushes to report its outbox to the upstream.
+const (
+	ReportJobName      = "AgentOutbox"
+	ReportResourceType = "agent_outbox"
+)
+
+// markReconciled records that everything queued has been delivered. It's
+// stored in agent_outbox_state so that it survives restarts.
+func markReconciled(ctx context.Context) error {
+	var count int64
+	if err := ctx.DB().Model(&Item{}).Count(&count).Error; err != nil {
+		return fmt.Errorf("failed to count outbox items: %w", err)
+	}
+
+	// Items that are backing off are not due but still pending.
+	if count > 0 {
+		return nil
+	}
+
+	if err := ctx.DB().Exec(`INSERT INTO agent_outbox_state (id, reconciled_at) VALUES (TRUE, NOW())
+		ON CONFLICT (id) DO UPDATE SET reconciled_at = EXCLUDED.reconciled_at`).Error; err != nil {
+		return fmt.Errorf("failed to record outbox reconciliation: %w", err)
+	}
+	return nil
+}
+
+// getReconciledAt returns when the outbox was last drained completely.
+func getReconciledAt(ctx context.Context) (*time.Time, error) {
+	var reconciledAt []time.Time
+	if err := ctx.DB().Table("agent_outbox_state").Pluck("reconciled_at", &reconciledAt).Error; err != nil {
+		return nil, fmt.Errorf("failed to get outbox reconciliation: %w", err)
+	}
+	if len(reconciledAt) == 0 {
+		return nil, nil
+	}
+	return &reconciledAt[0], nil
+}
+
+// Status is the backlog of the outbox.
+type Status struct {
+	Depth            int64            `json:"depth"`
+	Size             int64            `json:"size"`
+	Kinds            map[string]int64 `json:"kinds,omitempty"`
+	OldestAt         *time.Time       `json:"oldest_at,omitempty"`
+	OldestAgeSeconds float64          `json:"oldest_age_seconds"`
+	NextAttemptAt    *time.Time       `json:"next_attempt_at,omitempty"`
+	LastError        string           `json:"last_error,omitempty"`
+
+	// ReconciledAt is when the outbox was last drained completely.
+	ReconciledAt *time.Time `json:"reconciled_at,omitempty"`
+}
+
+// GetStatus returns the backlog of the outbox and updates its metrics.
+func GetStatus(ctx context.Context) (Status, error) {
+	var rows []struct {
+		Kind          string
+		Count         int64
+		Size          int64
+		OldestAt      time.Time
+		NextAttemptAt time.Time
+	}
+	if err := ctx.DB().Model(&Item{}).
+		Select("kind, COUNT(*) AS count, COALESCE(SUM(size), 0) AS size, MIN(created_at) AS oldest_at, MIN(next_attempt_at) AS next_attempt_at").
+		Group("kind").
+		Scan(&rows).Error; err != nil {
+		return Status{}, fmt.Errorf("failed to get outbox status: %w", err)
+	}
+
+	status := Status{Kinds: map[string]int64{}}
+	for _, row := range rows {
+		status.Depth += row.Count
+		status.Size += row.Size
+		status.Kinds[row.Kind] = row.Count
+		if status.OldestAt == nil || row.OldestAt.Before(*status.OldestAt) {
+			status.OldestAt = lo.ToPtr(row.OldestAt)
+		}
+		if status.NextAttemptAt == nil || row.NextAttemptAt.Before(*status.NextAttemptAt) {
+			status.NextAttemptAt = lo.ToPtr(row.NextAttemptAt)
+		}
+	}
+	if status.OldestAt != nil {
+		status.OldestAgeSeconds = time.Since(*status.OldestAt).Seconds()
+	}
+
+	if status.Depth > 0 {
+		var lastError []string
+		if err := ctx.DB().Model(&Item{}).Where("attempts > 0").
+			Order("next_attempt_at DESC").Limit(1).
+			Pluck("last_error", &lastError).Error; err != nil {
+			return Status{}, fmt.Errorf("failed to get outbox status: %w", err)
+		}
+		if len(lastError) > 0 {
+			status.LastError = lastError[0]
+		}
+	}
+
+	reconciledAt, err := getReconciledAt(ctx)
+	if err != nil {
+		return Status{}, err
+	}
+	status.ReconciledAt = reconciledAt
+
+	depthGauge.Reset()
+	for kind, count := range status.Kinds {
+		depthGauge.WithLabelValues(kind).Set(float64(count))
+	}
+	sizeGauge.Set(float64(status.Size))
+	oldestAgeGauge.Set(status.OldestAgeSeconds)
+
+	return status, nil
+}
+
+// Report pushes the status to the upstream as a job history with a stable id
+// per agent so that the upstream keeps a single row per agent.
+func Report(ctx context.Context, client *upstream.UpstreamClient, agentName string, status Status) error {
+	now := time.Now()
+	history := models.JobHistory{
+		ID:           uuid.NewSHA1(uuid.NameSpaceOID, []byte(ReportResourceType+"/"+agentName)),
+		Name:         ReportJobName,
+		ResourceType: ReportResourceType,
+		ResourceID:   agentName,
+		TimeStart:    now,
+		TimeEnd:      &now,
+		Status:       models.StatusSuccess,
+		Details: types.JSONMap{
+			"depth":         status.Depth,
+			"size":          status.Size,
+			"kinds":         status.Kinds,
+			"oldest_at":     status.OldestAt,
+			"reconciled_at": status.ReconciledAt,
+			"last_error":    status.LastError,
+		},
+	}
+	if status.Depth > 0 {
+		history.Status = models.StatusWarning
+	}
+
+	return client.Push(ctx, &upstream.PushData{JobHistory: []models.JobHistory{history}})
+}
+
+// AgentStatus is the outbox of an agent as last reported to the upstream.
+type AgentStatus struct {
+	AgentID      uuid.UUID  `json:"agent_id"`
+	Agent        string     `json:"agent"`
+	LastSeen     *time.Time `json:"last_seen,omitempty"`
+	ReportedAt   *time.Time `json:"reported_at,omitempty"`
+	Depth        int64      `json:"depth"`
+	OldestAt     *time.Time `json:"oldest_at,omitempty"`
+	ReconciledAt *time.Time `json:"reconciled_at,omitempty"`
+	LastError    string     `json:"last_error,omitempty"`
+}
+
+// ListAgentStatus returns the last reported outbox of every agent, or of a
+// single agent when agentName is set. Agents that never reported are listed
+// without a ReportedAt.
+func ListAgentStatus(ctx context.Context, agentName string) ([]AgentStatus, error) {
+	q := ctx.DB().Where("deleted_at IS NULL")
+	if agentName != "" {
+		q = q.Where("name = ?", agentName)
+	}
+
+	var agents []models.Agent
+	if err := q.Order("name").Find(&agents).Error; err != nil {
+		return nil, fmt.Errorf("failed to list agents: %w", err)
+	}
+	if len(agents) == 0 {
+		return nil, nil
+	}
+
+	var reports []models.JobHistory
+	if err := ctx.DB().Where("name = ? AND resource_type = ?", ReportJobName, ReportResourceType).
+		Where("agent_id IN ?", lo.Map(agents, func(a models.Agent, _ int) uuid.UUID { return a.ID })).
+		Find(&reports).Error; err != nil {
+		return nil, fmt.Errorf("failed to list agent outbox reports: %w", err)
+	}
+
+	return agentStatuses(agents, reports), nil
+}
+
+func agentStatuses(agents []models.Agent, reports []models.JobHistory) []AgentStatus {
+	latest := map[uuid.UUID]models.JobHistory{}
+	for _, report := range reports {
+		if existing, ok := latest[report.AgentID]; !ok || report.TimeStart.After(existing.TimeStart) {
+			latest[report.AgentID] = report
+		}
+	}
+
+	statuses := make([]AgentStatus, 0, len(agents))
+	for _, agent := range agents {
+		status := AgentStatus{AgentID: agent.ID, Agent: agent.Name, LastSeen: agent.LastSeen}
+		if report, ok := latest[agent.ID]; ok {
+			status.ReportedAt = lo.ToPtr(report.TimeStart)
+			status.OldestAt = detailTime(report.Details, "oldest_at")
+			status.ReconciledAt = detailTime(report.Details, "reconciled_at")
+			if depth, ok := report.Details["depth"].(float64); ok {
+				status.Depth = int64(depth)
+			}
+			if msg, ok := report.Details["last_error"].(string); ok {
+				status.LastError = msg
+			}
+		}
+		statuses = append(statuses, status)
+	}
+
+	return statuses
+}
+
+// detailTime reads a timestamp from job history details, which is a string
+// once it has been through JSON.
+func detailTime(details types.JSONMap, key string) *time.Time {
+	switch v := details[key].(type) {
+	case string:
+		if t, err := time.Parse(time.RFC3339Nano, v); err == nil {
+			return &t
+		}
+	case time.Time:
+		return &v
+	case *time.Time:
+		return v
+	}
+	return nil
+}
@@ -0,0 +1,244 @@
+package tunnel
+
+import (
+	gocontext "context"
+	"errors"
+	"io"
+	"net"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	dutyAPI "github.com/flanksource/duty/api"
+	"github.com/flanksource/duty/context"
+	"github.com/labstack/echo/v4"
+
+	"github.com/flanksource/incident-commander/auth"
+	"github.com/flanksource/incident-commander/clientapi"
+)
+
+// Endpoints the agent serves over the tunnel for remote sessions.
+const (
+	AgentExecEndpoint    = "/agent-session/exec"
+	AgentForwardEndpoint = "/agent-session/forward"
+)
+
+// RegisterAgentRoutes registers the agent side of remote sessions.
+// They are only served to the upstream over the tunnel.
+func RegisterAgentRoutes(e *echo.Echo) {
+	e.GET(AgentExecEndpoint, agentExec)
+	e.GET(AgentForwardEndpoint, agentForward)
+}
+
+func requireTrustedUpstream(c echo.Context, property string, enabledByDefault bool) error {
+	ctx := c.Request().Context().(context.Context)
+	if !auth.IsTrustedUpstream(c.Request().Context()) {
+		return ctx.Oops().Code(dutyAPI.EFORBIDDEN).Errorf("agent sessions can only be opened by the upstream")
+	}
+
+	if !ctx.Properties().On(enabledByDefault, property) {
+		return ctx.Oops().Code(dutyAPI.EFORBIDDEN).Errorf("%s is disabled on this agent", property)
+	}
+
+	return nil
+}
+
+// agentForward connects the upgraded stream to an address reachable from the agent.
+func agentForward(c echo.Context) error {
+	ctx := c.Request().Context().(context.Context)
+	if err := requireTrustedUpstream(c, "agent.session.forward", false); err != nil {
+		return dutyAPI.WriteError(c, err)
+	}
+
+	address := c.QueryParam("address")
+	if _, _, err := net.SplitHostPort(address); err != nil {
+		return dutyAPI.WriteError(c, ctx.Oops().Code(dutyAPI.EINVALID).Errorf("address must be host:port: %v", err))
+	}
+
+	targets := ctx.Properties().String("agent.session.forward.targets", "")
+	if !forwardAllowed(strings.Split(targets, ","), address) {
+		return dutyAPI.WriteError(c, ctx.Oops().Code(dutyAPI.EFORBIDDEN).Errorf("%s is not in agent.session.forward.targets", address))
+	}
+
+	dialer := net.Dialer{Timeout: 10 * time.Second}
+	target, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return dutyAPI.WriteError(c, ctx.Oops().Code(dutyAPI.EINVALID).Errorf("failed to connect to %s: %v", address, err))
+	}
+	defer target.Close()
+
+	conn, err := hijackUpgrade(c, clientapi.AgentForwardProtocol)
+	if err != nil {
+		return dutyAPI.WriteError(c, err)
+	} else if conn == nil {
+		return nil
+	}
+	defer conn.Close()
+
+	ctx.Infof("forwarding tunnel session to %s", address)
+	splice(conn, target, nil, nil)
+	return nil
+}
+
+// forwardAllowed reports whether the address matches one of the targets,
+// each a host or CIDR with a port or *: db.internal:5432, 10.0.0.0/8:*,
+// [fd00::/8]:443. Hosts are matched as written, CIDRs only match IPs.
+func forwardAllowed(targets []string, address string) bool {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return false
+	}
+	ip := net.ParseIP(host)
+
+	for _, target := range targets {
+		targetHost, targetPort, err := net.SplitHostPort(strings.TrimSpace(target))
+		if err != nil || (targetPort != "*" && targetPort != port) {
+			continue
+		}
+
+		if _, network, err := net.ParseCIDR(targetHost); err == nil {
+			if ip != nil && network.Contains(ip) {
+				return true
+			}
+		} else if strings.EqualFold(targetHost, host) {
+			return true
+		}
+	}
+	return false
+}
+
+// agentExec runs a command with its stdin, stdout and stderr carried as frames
+// over the upgraded stream.
+func agentExec(c echo.Context) error {
+	ctx := c.Request().Context().(context.Context)
+	if err := requireTrustedUpstream(c, "agent.session.exec", false); err != nil {
+		return dutyAPI.WriteError(c, err)
+	}
+
+	command := c.QueryParams()["command"]
+	if len(command) == 0 || command[0] == "" {
+		return dutyAPI.WriteError(c, ctx.Oops().Code(dutyAPI.EINVALID).Errorf("command is required"))
+	}
+
+	conn, err := hijackUpgrade(c, clientapi.AgentExecProtocol)
+	if err != nil {
+		return dutyAPI.WriteError(c, err)
+	} else if conn == nil {
+		return nil
+	}
+	defer conn.Close()
+
+	timeout := ctx.Properties().Duration("agent.session.exec.timeout", time.Hour)
+	execCtx, cancel := gocontext.WithTimeout(gocontext.Background(), timeout)
+	defer cancel()
+
+	ctx.Infof("running tunnel exec session: %v", command)
+	code := runExec(execCtx, conn, command)
+	_ = clientapi.WriteAgentSessionExit(conn, code)
+	return nil
+}
+
+func runExec(ctx gocontext.Context, conn net.Conn, command []string) int {
+	out := &frameWriter{w: conn}
+
+	cmd := exec.CommandContext(ctx, command[0], command[1:]...) //nolint:gosec // authorised by the upstream
+	cmd.Stdout = out.stream(clientapi.FrameStdout)
+	cmd.Stderr = out.stream(clientapi.FrameStderr)
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		_, _ = cmd.Stderr.Write([]byte(err.Error() + "\n"))
+		return 127
+	}
+
+	if err := cmd.Start(); err != nil {
+		_, _ = cmd.Stderr.Write([]byte(err.Error() + "\n"))
+		return 127
+	}
+
+	go func() {
+		defer stdin.Close()
+		for {
+			frame, err := clientapi.ReadAgentSessionFrame(conn)
+			if err != nil {
+				// The client is gone, there is no one to read the output.
+				if cmd.Process != nil {
+					_ = cmd.Process.Kill()
+				}
+				return
+			}
+
+			switch frame.Type {
+			case clientapi.FrameStdin:
+				if _, err := stdin.Write(frame.Payload); err != nil {
+					return
+				}
+			case clientapi.FrameStdinEOF:
+				return
+			}
+		}
+	}()
+
+	if err := cmd.Wait(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return exitErr.ExitCode()
+		}
+		_, _ = cmd.Stderr.Write([]byte(err.Error() + "\n"))
+		return 1
+	}
+	return 0
+}
+
+// frameWriter serializes frames written concurrently by stdout and stderr.
+type frameWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (f *frameWriter) stream(typ clientapi.FrameType) io.Writer {
+	return writerFunc(func(p []byte) (int, error) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+
+		for chunk := p; len(chunk) > 0; {
+			n := min(len(chunk), clientapi.MaxFramePayload)
+			if err := clientapi.WriteAgentSessionFrame(f.w, typ, chunk[:n]); err != nil {
+				return 0, err
+			}
+			chunk = chunk[n:]
+		}
+		return len(p), nil
+	})
+}
+
+type writerFunc func(p []byte) (int, error)
+
+func (f writerFunc) Write(p []byte) (int, error) { return f(p) }
+
+// splice copies between a and b until either side closes. onAB and onBA, if
+// set, observe the bytes copied in each direction.
+func splice(a, b net.Conn, onAB, onBA func([]byte)) {
+	var wg sync.WaitGroup
+	copyHalf := func(dst, src net.Conn, observe func([]byte)) {
+		defer wg.Done()
+		var r io.Reader = src
+		if observe != nil {
+			r = io.TeeReader(src, writerFunc(func(p []byte) (int, error) {
+				observe(p)
+				return len(p), nil
+			}))
+		}
+		_, _ = io.Copy(dst, r)
+
+		// Unblock the other direction.
+		_ = dst.Close()
+		_ = src.Close()
+	}
+
+	wg.Add(2)
+	go copyHalf(b, a, onAB)
+	go copyHalf(a, b, onBA)
+	wg.Wait()
+}
@@ -0,0 +1,26 @@
+package tunnel
+
+import (
+	"testing"
+
+	"github.com/onsi/gomega"
+)
+
+func TestForwardAllowed(t *testing.T) {
+	g := gomega.NewWithT(t)
+
+	targets := []string{"db.internal:5432", "10.0.0.0/8:*", "[fd00::/8]:443", "not-a-target"}
+	for address, allowed := range map[string]bool{
+		"db.internal:5432":  true,
+		"db.internal:22":    false,
+		"10.1.2.3:22":       true,
+		"11.0.0.1:22":       false,
+		"[fd00::1]:443":     true,
+		"[fd00::1]:80":      false,
+		"metadata.internal": false,
+	} {
+		g.Expect(forwardAllowed(targets, address)).To(gomega.Equal(allowed), address)
+	}
+
+	g.Expect(forwardAllowed(nil, "db.internal:5432")).To(gomega.BeFalse())
+}
@@ -1,6 +1,7 @@
 package tunnel
 
 import (
+	"net"
 	"net/http"
 
 	"github.com/google/uuid"
@@ -22,43 +23,62 @@ func YamuxSessionCreate(c echo.Context) error {
 			Errorf("authenticated agent is required"))
 	}
 
+	conn, err := hijackUpgrade(c, "yamux")
+	if err != nil {
+		return dutyAPI.WriteError(c, err)
+	} else if conn == nil {
+		return nil
+	}
+
+	session, err := yamux.Server(conn, nil)
+	if err != nil {
+		_ = conn.Close()
+		return err
+	}
+
+	defaultManager.Register(agent.ID, session)
+
+	go func() {
+		<-session.CloseChan()
+		defaultManager.DeleteIfSame(agent.ID, session)
+	}()
+
+	return nil
+}
+
+// hijackUpgrade takes over the connection of the request and completes the
+// switch to the given protocol. It returns a nil connection without an error
+// when the client went away before the switch completed.
+func hijackUpgrade(c echo.Context, protocol string) (net.Conn, error) {
+	ctx := c.Request().Context().(context.Context)
+
 	hijacker, ok := c.Response().Writer.(http.Hijacker)
 	if !ok {
-		return dutyAPI.WriteError(c, ctx.Oops().
+		return nil, ctx.Oops().
 			Code(dutyAPI.EINTERNAL).
-			Errorf("response writer does not support hijacking"))
+			Errorf("response writer does not support hijacking")
 	}
 
 	conn, rw, err := hijacker.Hijack()
 	if err != nil {
-		return dutyAPI.WriteError(c, ctx.Oops().Wrap(err))
+		return nil, ctx.Oops().Wrap(err)
 	}
 
 	if _, err := rw.WriteString(
 		"HTTP/1.1 101 Switching Protocols\r\n" +
 			"Connection: Upgrade\r\n" +
-			"Upgrade: yamux\r\n\r\n",
+			"Upgrade: " + protocol + "\r\n\r\n",
 	); err != nil {
 		_ = conn.Close()
-		return nil
+		return nil, nil
 	}
 	if err := rw.Flush(); err != nil {
 		_ = conn.Close()
-		return nil
+		return nil, nil
 	}
 
-	session, err := yamux.Server(conn, nil)
-	if err != nil {
-		_ = conn.Close()
-		return err
+	if rw.Reader.Buffered() > 0 {
+		return bufferedConn{Conn: conn, reader: rw.Reader}, nil
 	}
-
-	defaultManager.Register(agent.ID, session)
-
-	go func() {
-		<-session.CloseChan()
-		defaultManager.DeleteIfSame(agent.ID, session)
-	}()
-
-	return nil
+	return conn, nil
 }
@@ -0,0 +1,63 @@
+package tunnel
+
+import (
+	"bufio"
+	gocontext "context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DialAgent opens a stream to the agent over its tunnel and upgrades it to
+// protocol by requesting path on the agent.
+func DialAgent(ctx gocontext.Context, agentID uuid.UUID, path string, query url.Values, protocol string) (net.Conn, error) {
+	conn, err := Open(ctx, agentID)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := mintUpstreamToken()
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+
+	u := url.URL{Scheme: "http", Host: "agent", Path: path, RawQuery: query.Encode()}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", protocol)
+	req.Header.Set(UpstreamAuthHeader, token)
+
+	_ = conn.SetDeadline(time.Now().Add(30 * time.Second))
+	if err := req.Write(conn); err != nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("write agent upgrade request: %w", err)
+	}
+
+	reader := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(reader, req)
+	if err != nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("read agent upgrade response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		b, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		_ = resp.Body.Close()
+		_ = conn.Close()
+		return nil, fmt.Errorf("agent refused the session: HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(b)))
+	}
+
+	_ = conn.SetDeadline(time.Time{})
+	return bufferedConn{Conn: conn, reader: reader}, nil
+}
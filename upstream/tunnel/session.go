@@ -0,0 +1,277 @@
+package tunnel
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/flanksource/artifacts"
+	dutyAPI "github.com/flanksource/duty/api"
+	"github.com/flanksource/duty/context"
+	"github.com/flanksource/duty/models"
+	"github.com/flanksource/duty/query"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+
+	"github.com/flanksource/incident-commander/api"
+	mcArtifacts "github.com/flanksource/incident-commander/artifacts"
+	"github.com/flanksource/incident-commander/clientapi"
+)
+
+// Job history of remote sessions opened through an agent.
+const (
+	SessionJobName      = "AgentSession"
+	SessionResourceType = "agent"
+)
+
+// Exec runs a command on an agent. The client upgrades the request to
+// clientapi.AgentExecProtocol and exchanges frames with the command.
+func Exec(c echo.Context) error {
+	ctx := c.Request().Context().(context.Context)
+
+	command := c.QueryParams()["command"]
+	if len(command) == 0 || command[0] == "" {
+		return dutyAPI.WriteError(c, ctx.Oops().Code(dutyAPI.EINVALID).Errorf("command is required"))
+	}
+
+	return openSession(c, "exec", AgentExecEndpoint, url.Values{"command": command}, clientapi.AgentExecProtocol,
+		func(s *sessionRecorder, client, agent net.Conn) {
+			s.history.AddDetails("command", command)
+			relayExec(s, client, agent)
+		})
+}
+
+// Forward opens a TCP connection from an agent to an address reachable by
+// the agent. The client upgrades the request to clientapi.AgentForwardProtocol.
+func Forward(c echo.Context) error {
+	ctx := c.Request().Context().(context.Context)
+
+	address := c.QueryParam("address")
+	if _, _, err := net.SplitHostPort(address); err != nil {
+		return dutyAPI.WriteError(c, ctx.Oops().Code(dutyAPI.EINVALID).Errorf("address must be host:port: %v", err))
+	}
+
+	return openSession(c, "forward", AgentForwardEndpoint, url.Values{"address": {address}}, clientapi.AgentForwardProtocol,
+		func(s *sessionRecorder, client, agent net.Conn) {
+			s.history.AddDetails("address", address)
+			splice(client, agent, s.input, s.output)
+		})
+}
+
+func openSession(c echo.Context, sessionType, endpoint string, query url.Values, protocol string, relay func(s *sessionRecorder, client, agent net.Conn)) error {
+	ctx := c.Request().Context().(context.Context)
+
+	agent, err := queryAgent(ctx, c.Param("agent"))
+	if err != nil {
+		return dutyAPI.WriteError(c, err)
+	}
+
+	// Sessions are only allowed when their transcript can be kept
+	if api.DefaultArtifactConnection == "" {
+		return dutyAPI.WriteError(c, ctx.Oops().Code(dutyAPI.ENOTIMPLEMENTED).Errorf("agent sessions are recorded and need an artifact connection"))
+	}
+
+	s := newSessionRecorder(ctx, sessionType, agent)
+	agentConn, err := DialAgent(ctx, agent.ID, endpoint, query, protocol)
+	if err != nil {
+		s.finish(ctx, err)
+		if errors.Is(err, ErrSessionNotFound) {
+			return dutyAPI.WriteError(c, ctx.Oops().Code(dutyAPI.ENOTFOUND).Errorf("agent %s is not connected", agent.Name))
+		}
+		return dutyAPI.WriteError(c, ctx.Oops().Code(dutyAPI.EINVALID).Wrap(err))
+	}
+	defer agentConn.Close()
+
+	clientConn, err := hijackUpgrade(c, protocol)
+	if err != nil {
+		s.finish(ctx, err)
+		return dutyAPI.WriteError(c, err)
+	} else if clientConn == nil {
+		s.finish(ctx, errors.New("client disconnected before the session started"))
+		return nil
+	}
+	defer clientConn.Close()
+
+	ctx.Infof("opened %s session on agent %s", sessionType, agent.Name)
+	relay(s, clientConn, agentConn)
+	s.finish(ctx, nil)
+	return nil
+}
+
+func queryAgent(ctx context.Context, idOrName string) (*models.Agent, error) {
+	agent, err := query.FindCachedAgent(ctx, idOrName)
+	if err != nil {
+		return nil, ctx.Oops().Wrap(err)
+	} else if agent == nil {
+		return nil, ctx.Oops().Code(dutyAPI.ENOTFOUND).Errorf("agent %s not found", idOrName)
+	}
+	return agent, nil
+}
+
+// relayExec forwards frames between the client and the agent until the
+// agent sends the exit frame or either side goes away.
+func relayExec(s *sessionRecorder, client, agent net.Conn) {
+	go func() {
+		for {
+			frame, err := clientapi.ReadAgentSessionFrame(client)
+			if err != nil {
+				_ = agent.Close()
+				return
+			}
+
+			switch frame.Type {
+			case clientapi.FrameStdin, clientapi.FrameStdinEOF:
+			default:
+				// Only the agent speaks the other frames
+				continue
+			}
+
+			s.input(frame.Payload)
+			if err := clientapi.WriteAgentSessionFrame(agent, frame.Type, frame.Payload); err != nil {
+				return
+			}
+		}
+	}()
+
+	for {
+		frame, err := clientapi.ReadAgentSessionFrame(agent)
+		if err != nil {
+			if !errors.Is(err, io.EOF) && !errors.Is(err, net.ErrClosed) {
+				s.history.AddErrorf("failed to read from agent: %v", err)
+			}
+			return
+		}
+
+		if frame.Type == clientapi.FrameExit {
+			s.history.AddDetails("exit_code", frame.ExitCode())
+		} else {
+			s.output(frame.Payload)
+		}
+
+		if err := clientapi.WriteAgentSessionFrame(client, frame.Type, frame.Payload); err != nil || frame.Type == clientapi.FrameExit {
+			return
+		}
+	}
+}
+
+// sessionRecorder keeps the audit trail of a session: a job history with the
+// session's metadata and a transcript in the asciicast v2 format.
+type sessionRecorder struct {
+	mu         sync.Mutex
+	history    *models.JobHistory
+	transcript bytes.Buffer
+	maxSize    int
+	truncated  bool
+	bytesIn    int64
+	bytesOut   int64
+}
+
+func newSessionRecorder(ctx context.Context, sessionType string, agent *models.Agent) *sessionRecorder {
+	s := &sessionRecorder{
+		history: models.NewJobHistory(ctx.Logger, SessionJobName, SessionResourceType, agent.ID.String()).Start(),
+		maxSize: ctx.Properties().Int("agent.session.transcript.max_size", 10*1024*1024),
+	}
+
+	s.history.ID = uuid.New()
+	s.history.AgentID = agent.ID
+	s.history.AddDetails("type", sessionType)
+	s.history.AddDetails("agent", agent.Name)
+	if user := ctx.User(); user != nil {
+		s.history.AddDetails("user_id", user.ID.String())
+		s.history.AddDetails("user", user.Name)
+	}
+
+	header, _ := json.Marshal(map[string]any{
+		"version":   2,
+		"width":     80,
+		"height":    24,
+		"timestamp": s.history.TimeStart.Unix(),
+		"title":     fmt.Sprintf("%s on %s", sessionType, agent.Name),
+	})
+	s.transcript.Write(header)
+	s.transcript.WriteByte('\n')
+	return s
+}
+
+func (s *sessionRecorder) input(p []byte) {
+	s.record("i", p)
+}
+
+func (s *sessionRecorder) output(p []byte) {
+	s.record("o", p)
+}
+
+func (s *sessionRecorder) record(event string, p []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if event == "i" {
+		s.bytesIn += int64(len(p))
+	} else {
+		s.bytesOut += int64(len(p))
+	}
+
+	if s.truncated || len(p) == 0 {
+		return
+	}
+
+	line, _ := json.Marshal([]any{time.Since(s.history.TimeStart).Seconds(), event, strings.ToValidUTF8(string(p), "�")})
+	if s.transcript.Len()+len(line)+1 > s.maxSize {
+		s.truncated = true
+		return
+	}
+	s.transcript.Write(line)
+	s.transcript.WriteByte('\n')
+}
+
+// finish persists the job history and saves the metadata & transcript as
+// its artifacts.
+func (s *sessionRecorder) finish(ctx context.Context, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err != nil {
+		s.history.AddError(err.Error())
+	} else {
+		s.history.IncrSuccess()
+	}
+	s.history.AddDetails("bytes_in", s.bytesIn)
+	s.history.AddDetails("bytes_out", s.bytesOut)
+	s.history.AddDetails("transcript_truncated", s.truncated)
+	s.history.End()
+
+	if err := s.history.Persist(ctx.DB()); err != nil {
+		ctx.Errorf("failed to persist agent session history: %v", err)
+		return
+	}
+
+	metadata, _ := json.Marshal(s.history)
+	prefix := fmt.Sprintf("agent-sessions/%s/", s.history.ID)
+	if err := mcArtifacts.SaveJobHistoryArtifacts(ctx, s.history.ID, []artifacts.Artifact{
+		{
+			Path:        prefix + "session.json",
+			ContentType: "application/json",
+			Content:     io.NopCloser(bytes.NewReader(metadata)),
+		},
+		{
+			Path:        prefix + "transcript.cast",
+			ContentType: "application/x-asciicast",
+			Content:     io.NopCloser(bytes.NewReader(s.transcript.Bytes())),
+		},
+	}); err != nil {
+		ctx.Errorf("failed to save the transcript of agent session %s: %v", s.history.ID, err)
+		s.history.AddErrorf("failed to save the transcript: %v", err)
+		s.history.AddDetails("transcript_lost", true)
+		s.history.End()
+		if err := s.history.Persist(ctx.DB()); err != nil {
+			ctx.Errorf("failed to persist agent session history: %v", err)
+		}
+	}
+}
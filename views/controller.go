@@ -3,6 +3,7 @@ package views
 import (
 	"errors"
 	"net/http"
+	"time"
 
 	"github.com/flanksource/commons/logger"
 	dutyAPI "github.com/flanksource/duty/api"
@@ -44,6 +45,12 @@ func RegisterRoutes(e *echo.Echo) {
 	g.POST("/:namespace/:name", GetViewByNamespaceName)
 	g.POST("/:id", GetViewByID)
 
+	// Historical snapshots of views that keep history
+	g.GET("/:namespace/:name/snapshots", HandleListViewSnapshots)
+	g.POST("/:namespace/:name/snapshots", HandleListViewSnapshots)
+	g.GET("/:namespace/:name/diff", HandleDiffViewSnapshots)
+	g.POST("/:namespace/:name/diff", HandleDiffViewSnapshots)
+
 	e.GET("/dashboard", HandleGetDashboard, rbac.Authorization(policy.ObjectViews, policy.ActionRead))
 }
 
@@ -166,13 +173,21 @@ func GetViewByNamespaceName(c echo.Context) error {
 	namespace := c.Param("namespace")
 	name := c.Param("name")
 
-	// Fetch the view to check ABAC permissions
+	if err := checkViewAccess(ctx, namespace, name); err != nil {
+		return dutyAPI.WriteError(c, err)
+	}
+
+	return getViewByNamespaceName(ctx, c, namespace, name)
+}
+
+// checkViewAccess checks the ABAC permissions of the caller on the view.
+func checkViewAccess(ctx context.Context, namespace, name string) error {
 	var view models.View
 	if err := ctx.DB().Select("id, namespace, name").Where("namespace = ? AND name = ?", namespace, name).Where("deleted_at IS NULL").First(&view).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return dutyAPI.WriteError(c, dutyAPI.Errorf(dutyAPI.ENOTFOUND, "view(namespace=%s, name=%s) not found", namespace, name))
+			return dutyAPI.Errorf(dutyAPI.ENOTFOUND, "view(namespace=%s, name=%s) not found", namespace, name)
 		}
-		return dutyAPI.WriteError(c, err)
+		return err
 	}
 
 	// Check ABAC permissions for this specific view
@@ -180,17 +195,54 @@ func GetViewByNamespaceName(c echo.Context) error {
 		View: view,
 	}
 	if !dutyRBAC.HasPermission(ctx, ctx.Subject(), attr, policy.ActionRead) {
-		return dutyAPI.WriteError(c, ctx.Oops().Code(dutyAPI.EFORBIDDEN).Errorf("access denied to view %s/%s", view.Namespace, view.Name))
+		return ctx.Oops().Code(dutyAPI.EFORBIDDEN).Errorf("access denied to view %s/%s", view.Namespace, view.Name)
 	}
 
-	return getViewByNamespaceName(ctx, c, namespace, name)
+	return nil
 }
 
 type viewRequestPostBody struct {
 	Variables map[string]string `json:"variables"`
 }
 
+// requestVariables returns the variables of a POST request body.
+func requestVariables(c echo.Context) ([]ViewOption, error) {
+	if c.Request().Method != http.MethodPost {
+		return nil, nil
+	}
+
+	var request viewRequestPostBody
+	if err := c.Bind(&request); err != nil {
+		return nil, dutyAPI.Errorf(dutyAPI.EINVALID, "invalid request body: %s", err.Error())
+	}
+
+	var opts []ViewOption
+	for k, v := range request.Variables {
+		opts = append(opts, WithVariable(k, v))
+	}
+	return opts, nil
+}
+
 func getViewByNamespaceName(ctx context.Context, c echo.Context, namespace, name string) error {
+	variables, err := requestVariables(c)
+	if err != nil {
+		return dutyAPI.WriteError(c, err)
+	}
+
+	// asOf reads the view from its history instead of the live results
+	if asOf := c.QueryParam("asOf"); asOf != "" {
+		t, err := ParseSnapshotTime(asOf, time.Now())
+		if err != nil {
+			return dutyAPI.WriteError(c, dutyAPI.Errorf(dutyAPI.EINVALID, "invalid asOf: %s", err.Error()))
+		}
+
+		response, err := ReadViewSnapshot(ctx, namespace, name, t, variables...)
+		if err != nil {
+			return dutyAPI.WriteError(c, err)
+		}
+		return c.JSON(http.StatusOK, response)
+	}
+
 	cacheControl := c.Request().Header.Get("Cache-Control")
 	headerMaxAge, headerRefreshTimeout, err := utils.ParseCacheControlHeader(cacheControl)
 	if err != nil {
@@ -205,16 +257,7 @@ func getViewByNamespaceName(ctx context.Context, c echo.Context, namespace, name
 		opts = append(opts, WithRefreshTimeout(headerRefreshTimeout))
 	}
 
-	if c.Request().Method == http.MethodPost {
-		var request viewRequestPostBody
-		if err := c.Bind(&request); err != nil {
-			return dutyAPI.WriteError(c, dutyAPI.Errorf(dutyAPI.EINVALID, "invalid request body: %s", err.Error()))
-		}
-
-		for k, v := range request.Variables {
-			opts = append(opts, WithVariable(k, v))
-		}
-	}
+	opts = append(opts, variables...)
 
 	response, err := ReadOrPopulateViewTable(ctx, namespace, name, opts...)
 	if err != nil {
@@ -224,6 +267,54 @@ func getViewByNamespaceName(ctx context.Context, c echo.Context, namespace, name
 	return c.JSON(http.StatusOK, response)
 }
 
+// HandleListViewSnapshots lists the snapshots of a view request, latest first.
+func HandleListViewSnapshots(c echo.Context) error {
+	ctx := c.Request().Context().(context.Context)
+
+	namespace := c.Param("namespace")
+	name := c.Param("name")
+	if err := checkViewAccess(ctx, namespace, name); err != nil {
+		return dutyAPI.WriteError(c, err)
+	}
+
+	variables, err := requestVariables(c)
+	if err != nil {
+		return dutyAPI.WriteError(c, err)
+	}
+
+	snapshots, err := ListViewSnapshots(ctx, namespace, name, variables...)
+	if err != nil {
+		return dutyAPI.WriteError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, snapshots)
+}
+
+// HandleDiffViewSnapshots returns the rows added, removed and changed between
+// two snapshots of a view request.
+// The from and to query params are snapshot ids or points in time.
+func HandleDiffViewSnapshots(c echo.Context) error {
+	ctx := c.Request().Context().(context.Context)
+
+	namespace := c.Param("namespace")
+	name := c.Param("name")
+	if err := checkViewAccess(ctx, namespace, name); err != nil {
+		return dutyAPI.WriteError(c, err)
+	}
+
+	variables, err := requestVariables(c)
+	if err != nil {
+		return dutyAPI.WriteError(c, err)
+	}
+
+	diff, err := DiffViewSnapshots(ctx, namespace, name, c.QueryParam("from"), c.QueryParam("to"), variables...)
+	if err != nil {
+		return dutyAPI.WriteError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, diff)
+}
+
 // Takes config id as a query param and returns all the available views
 // that can be placed on the given config.
 func HandleViewList(c echo.Context) error {
@@ -108,6 +108,8 @@ func formatResult(ctx context.Context, result *api.ViewResult, format string, fa
 	switch format {
 	case "csv":
 		return renderViewCSV(result)
+	case "xlsx":
+		return renderViewXLSX(result)
 	case "json", "":
 		return json.MarshalIndent(result.Serialized(), "", "  ")
 	case "yaml":
@@ -125,6 +127,8 @@ func formatMultiResult(ctx context.Context, multi *api.MultiViewResult, format s
 	switch format {
 	case "csv":
 		return renderMultiViewCSV(multi)
+	case "xlsx":
+		return renderMultiViewXLSX(multi)
 	case "json", "":
 		serialized := make([]api.SerializedView, len(multi.Views))
 		for i := range multi.Views {
@@ -147,18 +151,23 @@ func formatMultiResult(ctx context.Context, multi *api.MultiViewResult, format s
 }
 
 func Export(ctx context.Context, view *v1.View, vars map[string]string, format string, facetOpts *v1.FacetOptions) ([]byte, error) {
-	request := &requestOpt{
-		variables:   vars,
-		includeRows: true,
-	}
+	_, data, err := ExportWithResult(ctx, view, vars, format, facetOpts)
+	return data, err
+}
 
-	result, err := Run(ctx, view, request)
+// ExportWithResult is Export that also returns the result the export was
+// rendered from, e.g. to inspect its rows.
+func ExportWithResult(ctx context.Context, view *v1.View, vars map[string]string, format string, facetOpts *v1.FacetOptions) (*api.ViewResult, []byte, error) {
+	result, err := runAndNormalize(ctx, view, vars)
 	if err != nil {
-		return nil, fmt.Errorf("failed to run view: %w", err)
+		return nil, nil, err
 	}
 
-	normalizeRows(result)
-	return formatResult(ctx, result, format, facetOpts)
+	data, err := formatResult(ctx, result, format, facetOpts)
+	if err != nil {
+		return nil, nil, err
+	}
+	return result, data, nil
 }
 
 // normalizeRows converts []byte and JSON-string cell values into proper
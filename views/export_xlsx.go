@@ -0,0 +1,120 @@
+package views
+
+import (
+	"fmt"
+	"strings"
+
+	pkgView "github.com/flanksource/duty/view"
+	"github.com/samber/lo"
+
+	"github.com/flanksource/incident-commander/api"
+	"github.com/flanksource/incident-commander/report/xlsx"
+)
+
+func renderViewXLSX(result *api.ViewResult) ([]byte, error) {
+	return xlsx.Render(viewSheets(result)...)
+}
+
+func renderMultiViewXLSX(multi *api.MultiViewResult) ([]byte, error) {
+	var sheets []xlsx.Sheet
+	for i := range multi.Views {
+		sheets = append(sheets, viewSheets(&multi.Views[i])...)
+	}
+	return xlsx.Render(sheets...)
+}
+
+// viewSheets returns a worksheet with the rows of the view followed by one
+// for each of its resolved viewRef sections.
+func viewSheets(result *api.ViewResult) []xlsx.Sheet {
+	sheets := []xlsx.Sheet{viewSheet(lo.CoalesceOrEmpty(result.Title, result.Name), result)}
+	for _, section := range result.SectionResults {
+		if section.View != nil {
+			sheets = append(sheets, viewSheet(section.Title, section.View))
+		}
+	}
+	return sheets
+}
+
+func viewSheet(name string, result *api.ViewResult) xlsx.Sheet {
+	sheet := xlsx.Sheet{Name: name}
+
+	attributesIndex := -1
+	var columns []int
+	for i, c := range result.Columns {
+		if c.Type == pkgView.ColumnTypeAttributes {
+			attributesIndex = i
+		}
+		if c.Hidden || isInternalColumn(c) {
+			continue
+		}
+		columns = append(columns, i)
+		sheet.Columns = append(sheet.Columns, xlsx.Column{Name: c.Name, Type: xlsxCellType(c.Type)})
+	}
+
+	for _, row := range result.Rows {
+		var attributes map[string]any
+		if attributesIndex >= 0 && attributesIndex < len(row) {
+			attributes, _ = row[attributesIndex].(map[string]any)
+		}
+
+		values := make([]any, len(columns))
+		for j, i := range columns {
+			if i >= len(row) {
+				continue
+			}
+			values[j] = row[i]
+
+			if link := columnLink(result.Columns[i], attributes); link != "" && row[i] != nil {
+				values[j] = xlsx.Link{Text: fmt.Sprint(row[i]), URL: link}
+			}
+		}
+		sheet.Rows = append(sheet.Rows, values)
+	}
+
+	return sheet
+}
+
+// columnLink returns the UI link of a cell from the row attributes: the
+// evaluated url of the column or the config of a config_item column.
+func columnLink(column pkgView.ColumnDef, attributes map[string]any) string {
+	columnAttributes, _ := attributes[column.Name].(map[string]any)
+	if columnAttributes == nil {
+		return ""
+	}
+
+	if url, ok := columnAttributes["url"].(string); ok && url != "" {
+		if !strings.HasPrefix(url, "/") {
+			return url
+		} else if api.FrontendURL != "" {
+			return api.FrontendURL + url
+		}
+	}
+
+	switch config := columnAttributes["config"].(type) {
+	case map[string]string:
+		return api.ConfigPermalink(config["id"])
+	case map[string]any:
+		if id, ok := config["id"].(string); ok {
+			return api.ConfigPermalink(id)
+		}
+	}
+
+	return ""
+}
+
+func xlsxCellType(columnType pkgView.ColumnType) xlsx.CellType {
+	switch columnType {
+	case pkgView.ColumnTypeNumber, pkgView.ColumnTypeDecimal, pkgView.ColumnTypeGauge, pkgView.ColumnTypeMillicore:
+		return xlsx.Number
+	case pkgView.ColumnTypeBytes:
+		return xlsx.Integer
+	case pkgView.ColumnTypeBoolean:
+		return xlsx.Boolean
+	case pkgView.ColumnTypeDateTime:
+		return xlsx.DateTime
+	case pkgView.ColumnTypeDuration:
+		return xlsx.Duration
+	default:
+		return xlsx.String
+	}
+}
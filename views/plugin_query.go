@@ -0,0 +1,77 @@
+package views
+
+import (
+	"bytes"
+	gocontext "context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	dutyAPI "github.com/flanksource/duty/api"
+	"github.com/flanksource/duty/context"
+	"github.com/flanksource/duty/dataquery"
+
+	v1 "github.com/flanksource/incident-commander/api/v1"
+	"github.com/flanksource/incident-commander/plugin/gateway"
+	"github.com/flanksource/incident-commander/plugin/machinery"
+)
+
+const defaultPluginQueryTimeout = time.Minute
+
+// executePluginQuery invokes the plugin operation on behalf of the user
+// refreshing the view, with the same plugin roles, RBAC and invocation audit
+// as the plugin invoke route, and returns its result as rows.
+func executePluginQuery(ctx context.Context, q v1.PluginViewQuery) ([]dataquery.QueryResultRow, error) {
+	if ctx.User() == nil {
+		return nil, dutyAPI.Errorf(dutyAPI.EUNAUTHORIZED, "plugin %s can only be queried on behalf of a user", q.Name)
+	}
+
+	params := []byte("{}")
+	if len(q.Params) > 0 {
+		var err error
+		if params, err = json.Marshal(q.Params); err != nil {
+			return nil, fmt.Errorf("failed to marshal plugin params: %w", err)
+		}
+	}
+
+	entry, err := machinery.ResolvePlugin(ctx, q.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	timeoutCtx, cancel := gocontext.WithTimeout(ctx, ctx.Properties().Duration("view.plugin.timeout", defaultPluginQueryTimeout))
+	defer cancel()
+
+	resp, err := gateway.InvokeOperationAsUser(ctx.Wrap(timeoutCtx).WithUser(ctx.User()), entry, q.Operation, q.ConfigID, params)
+	if err != nil {
+		return nil, fmt.Errorf("plugin %s operation %s failed: %w", q.Name, q.Operation, err)
+	}
+
+	return pluginResultRows(resp.Result)
+}
+
+// pluginResultRows decodes an operation result into rows. The result must be
+// a JSON array of objects or a single object.
+func pluginResultRows(result []byte) ([]dataquery.QueryResultRow, error) {
+	result = bytes.TrimSpace(result)
+	if len(result) == 0 || bytes.Equal(result, []byte("null")) {
+		return nil, nil
+	}
+
+	switch result[0] {
+	case '[':
+		var rows []dataquery.QueryResultRow
+		if err := json.Unmarshal(result, &rows); err != nil {
+			return nil, dutyAPI.Errorf(dutyAPI.EINVALID, "plugin result must be an array of objects: %v", err)
+		}
+		return rows, nil
+	case '{':
+		var row dataquery.QueryResultRow
+		if err := json.Unmarshal(result, &row); err != nil {
+			return nil, dutyAPI.Errorf(dutyAPI.EINVALID, "invalid plugin result: %v", err)
+		}
+		return []dataquery.QueryResultRow{row}, nil
+	default:
+		return nil, dutyAPI.Errorf(dutyAPI.EINVALID, "plugin result must be a JSON array of objects or an object")
+	}
+}
@@ -0,0 +1,35 @@
+package views
+
+import (
+	"github.com/flanksource/duty/dataquery"
+	ginkgo "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = ginkgo.Describe("pluginResultRows", func() {
+	ginkgo.It("maps an array of objects to rows", func() {
+		rows, err := pluginResultRows([]byte(`[{"name":"a","count":1},{"name":"b","count":2}]`))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(rows).To(Equal([]dataquery.QueryResultRow{
+			{"name": "a", "count": float64(1)},
+			{"name": "b", "count": float64(2)},
+		}))
+	})
+
+	ginkgo.It("maps a single object to one row", func() {
+		rows, err := pluginResultRows([]byte(` {"name":"a"}`))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(rows).To(HaveLen(1))
+	})
+
+	ginkgo.It("treats an empty result as no rows", func() {
+		rows, err := pluginResultRows([]byte("null"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(rows).To(BeEmpty())
+	})
+
+	ginkgo.It("rejects scalar results", func() {
+		_, err := pluginResultRows([]byte(`"text"`))
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -55,7 +55,14 @@ func Run(ctx context.Context, view *v1.View, request *requestOpt) (*api.ViewResu
 	for queryName, q := range view.Spec.Queries {
 		eg.Go(func() error {
 			queryStart := time.Now()
-			results, err := pkgView.ExecuteQuery(ctx, q.Query)
+
+			var results []dataquery.QueryResultRow
+			var err error
+			if q.Plugin != nil {
+				results, err = executePluginQuery(ctx, *q.Plugin)
+			} else {
+				results, err = pkgView.ExecuteQuery(ctx, q.Query)
+			}
 			if err != nil {
 				return fmt.Errorf("failed to execute view query '%s': %w", queryName, err)
 			}
@@ -0,0 +1,395 @@
+package views
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/flanksource/commons/duration"
+	dutyAPI "github.com/flanksource/duty/api"
+	"github.com/flanksource/duty/context"
+	pkgView "github.com/flanksource/duty/view"
+	"github.com/google/uuid"
+	"github.com/samber/lo"
+
+	"github.com/flanksource/incident-commander/api"
+	v1 "github.com/flanksource/incident-commander/api/v1"
+	"github.com/flanksource/incident-commander/auth"
+	"github.com/flanksource/incident-commander/db"
+)
+
+// snapshotView saves the result of a view refresh as a snapshot if the view
+// keeps history and the last snapshot of the request is older than the
+// history interval. Snapshots past the retention or beyond the max count are
+// pruned at the same time.
+func snapshotView(ctx context.Context, view *v1.View, result *api.ViewResult, request *requestOpt) error {
+	history := view.Spec.Cache.History
+	if history == nil {
+		return nil
+	}
+
+	interval, err := history.GetInterval()
+	if err != nil {
+		return fmt.Errorf("invalid history interval: %w", err)
+	}
+	retention, err := history.GetRetention()
+	if err != nil {
+		return fmt.Errorf("invalid history retention: %w", err)
+	}
+
+	uid, err := view.GetUUID()
+	if err != nil {
+		return fmt.Errorf("failed to get view uid: %w", err)
+	}
+
+	last, err := db.GetLastViewSnapshotTime(ctx, uid, request.Fingerprint())
+	if err != nil {
+		return err
+	} else if last != nil && time.Since(*last) < interval {
+		return nil
+	}
+
+	columns := lo.Filter(result.Columns, func(col pkgView.ColumnDef, _ int) bool {
+		return col.Name != pkgView.ReservedColumnGrants
+	})
+	rows := snapshotRows(result.Columns, result.Rows)
+	columnsJSON, err := json.Marshal(columns)
+	if err != nil {
+		return fmt.Errorf("failed to marshal snapshot columns: %w", err)
+	}
+	rowsJSON, err := json.Marshal(rows)
+	if err != nil {
+		return fmt.Errorf("failed to marshal snapshot rows: %w", err)
+	}
+	panelsJSON, err := json.Marshal(result.Panels)
+	if err != nil {
+		return fmt.Errorf("failed to marshal snapshot panels: %w", err)
+	}
+
+	snapshot := db.ViewSnapshot{
+		ViewID:             uid,
+		RequestFingerprint: request.Fingerprint(),
+		Columns:            columnsJSON,
+		Rows:               rowsJSON,
+		Panels:             panelsJSON,
+		RowCount:           len(rows),
+	}
+
+	if err := db.SaveViewSnapshot(ctx, &snapshot); err != nil {
+		return err
+	}
+
+	if deleted, err := db.DeleteViewSnapshotsBefore(ctx, uid, time.Now().Add(-retention)); err != nil {
+		return err
+	} else if deleted > 0 {
+		ctx.Logger.V(3).Infof("deleted %d expired snapshots of view %s", deleted, view.GetNamespacedName())
+	}
+
+	if deleted, err := db.DeleteViewSnapshotsBeyond(ctx, uid, request.Fingerprint(), history.GetMaxSnapshots()); err != nil {
+		return err
+	} else if deleted > 0 {
+		ctx.Logger.V(3).Infof("deleted %d excess snapshots of view %s", deleted, view.GetNamespacedName())
+	}
+
+	return nil
+}
+
+// snapshotRows converts the positional rows of a view result to objects
+// keyed by column name. The grants are dropped: they are only valid at the
+// time of the snapshot.
+func snapshotRows(columns []pkgView.ColumnDef, rows []pkgView.Row) []map[string]any {
+	output := make([]map[string]any, 0, len(rows))
+	for _, row := range rows {
+		m := make(map[string]any, len(columns))
+		for i, col := range columns {
+			if col.Name == pkgView.ReservedColumnGrants {
+				continue
+			}
+			if i < len(row) {
+				m[col.Name] = row[i]
+			}
+		}
+		output = append(output, m)
+	}
+	return output
+}
+
+// ParseSnapshotTime parses a point in time given either as an RFC3339
+// timestamp or as a duration ago, e.g. "24h" or "7d".
+func ParseSnapshotTime(value string, now time.Time) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+
+	d, err := duration.ParseDuration(value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%q is neither an RFC3339 timestamp nor a duration", value)
+	}
+	return now.Add(-time.Duration(d)), nil
+}
+
+// getHistoryView returns the view with its request resolved for reading
+// snapshots. It fails if the view doesn't keep history.
+func getHistoryView(ctx context.Context, namespace, name string, opts ...ViewOption) (*v1.View, uuid.UUID, *requestOpt, []api.ViewVariableWithOptions, error) {
+	view, err := db.GetView(ctx, namespace, name)
+	if err != nil {
+		return nil, uuid.Nil, nil, nil, fmt.Errorf("failed to get view: %w", err)
+	} else if view == nil {
+		return nil, uuid.Nil, nil, nil, dutyAPI.Errorf(dutyAPI.ENOTFOUND, "view %s/%s not found", namespace, name)
+	} else if view.Spec.Cache.History == nil {
+		return nil, uuid.Nil, nil, nil, dutyAPI.Errorf(dutyAPI.EINVALID, "view %s/%s does not keep history", namespace, name)
+	}
+
+	uid, err := view.GetUUID()
+	if err != nil {
+		return nil, uuid.Nil, nil, nil, fmt.Errorf("failed to get view uid: %w", err)
+	}
+
+	request, variables, err := prepareRequest(ctx, view, opts...)
+	if err != nil {
+		return nil, uuid.Nil, nil, nil, err
+	}
+
+	return view, uid, request, variables, nil
+}
+
+// ReadViewSnapshot returns the latest snapshot of the view request taken at
+// or before asOf.
+func ReadViewSnapshot(ctx context.Context, namespace, name string, asOf time.Time, opts ...ViewOption) (*api.ViewSnapshotResult, error) {
+	view, uid, request, variables, err := getHistoryView(ctx, namespace, name, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot, err := db.GetViewSnapshotAsOf(ctx, uid, request.Fingerprint(), asOf)
+	if err != nil {
+		return nil, err
+	} else if snapshot == nil {
+		return nil, dutyAPI.Errorf(dutyAPI.ENOTFOUND, "view %s/%s has no snapshot as of %s", namespace, name, asOf.Format(time.RFC3339))
+	}
+
+	columns, rows, err := readSnapshotRows(ctx, snapshot)
+	if err != nil {
+		return nil, err
+	}
+
+	var panels []api.PanelResult
+	if len(snapshot.Panels) > 0 {
+		if err := json.Unmarshal(snapshot.Panels, &panels); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal snapshot panels: %w", err)
+		}
+	}
+
+	return &api.ViewSnapshotResult{
+		ViewResult: api.ViewResult{
+			Namespace:          view.Namespace,
+			Name:               view.Name,
+			Title:              view.Spec.Display.Title,
+			Icon:               view.Spec.Display.Icon,
+			ResponseSource:     api.ViewResponseSourceSnapshot,
+			LastRefreshedAt:    snapshot.CreatedAt,
+			RequestFingerprint: snapshot.RequestFingerprint,
+			Columns: lo.Filter(columns, func(col pkgView.ColumnDef, _ int) bool {
+				return col.Name != pkgView.ReservedColumnGrants
+			}),
+			Panels:    panels,
+			Variables: variables,
+			Card:      view.Spec.Display.Card,
+			Table:     view.Spec.Display.Table,
+			Sections:  view.Spec.Sections,
+		},
+		SnapshotID: snapshot.ID,
+		SnapshotAt: snapshot.CreatedAt,
+		Data:       rows,
+	}, nil
+}
+
+// ListViewSnapshots returns the snapshots of the view request, latest first.
+func ListViewSnapshots(ctx context.Context, namespace, name string, opts ...ViewOption) ([]api.ViewSnapshotSummary, error) {
+	_, uid, request, _, err := getHistoryView(ctx, namespace, name, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshots, err := db.ListViewSnapshots(ctx, uid, request.Fingerprint())
+	if err != nil {
+		return nil, err
+	}
+
+	return lo.Map(snapshots, func(s db.ViewSnapshot, _ int) api.ViewSnapshotSummary {
+		return api.ViewSnapshotSummary{ID: s.ID, CreatedAt: s.CreatedAt, RowCount: s.RowCount}
+	}), nil
+}
+
+// DiffViewSnapshots compares two snapshots of the view request.
+// from and to are either snapshot ids or points in time accepted by
+// ParseSnapshotTime. An empty to compares against the latest snapshot.
+func DiffViewSnapshots(ctx context.Context, namespace, name, from, to string, opts ...ViewOption) (*api.ViewDiff, error) {
+	_, uid, request, _, err := getHistoryView(ctx, namespace, name, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	if from == "" {
+		return nil, dutyAPI.Errorf(dutyAPI.EINVALID, "from is required")
+	}
+	if to == "" {
+		to = time.Now().Format(time.RFC3339)
+	}
+
+	var snapshots [2]*db.ViewSnapshot
+	for i, ref := range []string{from, to} {
+		if snapshots[i], err = resolveSnapshot(ctx, uid, request.Fingerprint(), ref); err != nil {
+			return nil, err
+		} else if snapshots[i] == nil {
+			return nil, dutyAPI.Errorf(dutyAPI.ENOTFOUND, "view %s/%s has no snapshot for %q", namespace, name, ref)
+		}
+	}
+
+	_, fromRows, err := readSnapshotRows(ctx, snapshots[0])
+	if err != nil {
+		return nil, err
+	}
+	toColumns, toRows, err := readSnapshotRows(ctx, snapshots[1])
+	if err != nil {
+		return nil, err
+	}
+
+	var keys []string
+	for _, col := range toColumns {
+		if col.PrimaryKey {
+			keys = append(keys, col.Name)
+		}
+	}
+
+	diff := diffRows(keys, fromRows, toRows)
+	diff.From = api.ViewSnapshotSummary{ID: snapshots[0].ID, CreatedAt: snapshots[0].CreatedAt, RowCount: len(fromRows)}
+	diff.To = api.ViewSnapshotSummary{ID: snapshots[1].ID, CreatedAt: snapshots[1].CreatedAt, RowCount: len(toRows)}
+	return diff, nil
+}
+
+// resolveSnapshot returns the snapshot referred to by either its id or a
+// point in time.
+func resolveSnapshot(ctx context.Context, viewID uuid.UUID, fingerprint, ref string) (*db.ViewSnapshot, error) {
+	if id, err := uuid.Parse(ref); err == nil {
+		return db.GetViewSnapshot(ctx, viewID, id)
+	}
+
+	asOf, err := ParseSnapshotTime(ref, time.Now())
+	if err != nil {
+		return nil, dutyAPI.Errorf(dutyAPI.EINVALID, "invalid snapshot %s", err.Error())
+	}
+	return db.GetViewSnapshotAsOf(ctx, viewID, fingerprint, asOf)
+}
+
+// readSnapshotRows decodes the columns and rows of a snapshot.
+//
+// Snapshots don't keep the grants of their rows, so callers subject to row
+// level security can't read them.
+func readSnapshotRows(ctx context.Context, snapshot *db.ViewSnapshot) ([]pkgView.ColumnDef, []map[string]any, error) {
+	payload, err := auth.GetRLSPayload(ctx)
+	if err != nil {
+		return nil, nil, err
+	} else if !payload.Disable {
+		return nil, nil, dutyAPI.Errorf(dutyAPI.EFORBIDDEN, "view history is not available with row level security")
+	}
+
+	var columns []pkgView.ColumnDef
+	if len(snapshot.Columns) > 0 {
+		if err := json.Unmarshal(snapshot.Columns, &columns); err != nil {
+			return nil, nil, fmt.Errorf("failed to unmarshal snapshot columns: %w", err)
+		}
+	}
+
+	var rows []map[string]any
+	if len(snapshot.Rows) > 0 {
+		if err := json.Unmarshal(snapshot.Rows, &rows); err != nil {
+			return nil, nil, fmt.Errorf("failed to unmarshal snapshot rows: %w", err)
+		}
+	}
+
+	// Snapshots taken before the grants were dropped still have them.
+	rows = lo.Map(rows, func(row map[string]any, _ int) map[string]any {
+		return lo.OmitByKeys(row, []string{pkgView.ReservedColumnGrants})
+	})
+	return columns, rows, nil
+}
+
+// diffRows compares two sets of rows matched on the given key columns.
+// Without key columns every column is part of the key, so rows are only
+// ever added or removed. Reserved columns are ignored.
+func diffRows(keys []string, from, to []map[string]any) *api.ViewDiff {
+	rowKey := func(row map[string]any) (string, map[string]any) {
+		columns := keys
+		if len(columns) == 0 {
+			columns = lo.Filter(lo.Keys(row), func(c string, _ int) bool { return !isReservedColumn(c) })
+			sort.Strings(columns)
+		}
+
+		key := make(map[string]any, len(columns))
+		values := make([]any, 0, len(columns))
+		for _, c := range columns {
+			key[c] = row[c]
+			values = append(values, row[c])
+		}
+		b, _ := json.Marshal(values)
+		return string(b), key
+	}
+
+	before := make(map[string]map[string]any, len(from))
+	for _, row := range from {
+		k, _ := rowKey(row)
+		before[k] = row
+	}
+
+	diff := &api.ViewDiff{
+		Added:   []map[string]any{},
+		Removed: []map[string]any{},
+		Changed: []api.ViewRowChange{},
+	}
+
+	seen := make(map[string]bool, len(to))
+	for _, row := range to {
+		k, key := rowKey(row)
+		seen[k] = true
+
+		previous, ok := before[k]
+		if !ok {
+			diff.Added = append(diff.Added, row)
+			continue
+		}
+
+		if fields := changedFields(previous, row); len(fields) > 0 {
+			diff.Changed = append(diff.Changed, api.ViewRowChange{Key: key, Before: previous, After: row, Fields: fields})
+		}
+	}
+
+	for _, row := range from {
+		if k, _ := rowKey(row); !seen[k] {
+			diff.Removed = append(diff.Removed, row)
+		}
+	}
+
+	return diff
+}
+
+// changedFields returns the sorted non-reserved columns whose values differ.
+func changedFields(before, after map[string]any) []string {
+	var fields []string
+	for _, c := range lo.Union(lo.Keys(before), lo.Keys(after)) {
+		if isReservedColumn(c) {
+			continue
+		}
+		if !reflect.DeepEqual(before[c], after[c]) {
+			fields = append(fields, c)
+		}
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+func isReservedColumn(name string) bool {
+	return name == pkgView.ReservedColumnAttributes || name == pkgView.ReservedColumnGrants
+}
@@ -0,0 +1,127 @@
+package views
+
+import (
+	gocontext "context"
+	"fmt"
+	"sync"
+
+	"github.com/flanksource/duty/context"
+	"github.com/flanksource/duty/job"
+	"github.com/flanksource/duty/models"
+	"github.com/robfig/cron/v3"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/flanksource/incident-commander/api"
+	v1 "github.com/flanksource/incident-commander/api/v1"
+	"github.com/flanksource/incident-commander/db"
+)
+
+type snapshotScheduleEntry struct {
+	CronID     cron.EntryID
+	Schedule   string
+	Generation int64
+}
+
+var (
+	snapshotScheduleMu      sync.Mutex
+	snapshotScheduleEntries = make(map[types.UID]snapshotScheduleEntry)
+)
+
+// ScheduleViewSnapshots keeps a cron entry on the scheduler for every view
+// with a cache.history.schedule, refreshing and snapshotting the view on it.
+func ScheduleViewSnapshots(ctx context.Context, scheduler *cron.Cron) *job.Job {
+	return &job.Job{
+		Name:       "ScheduleViewSnapshots",
+		Schedule:   "@every 1m",
+		Context:    ctx,
+		Singleton:  true,
+		Retention:  job.RetentionFailed,
+		JobHistory: true,
+		RunNow:     true,
+		Fn: func(run job.JobRuntime) error {
+			return syncSnapshotSchedules(run, scheduler)
+		},
+	}
+}
+
+func syncSnapshotSchedules(run job.JobRuntime, scheduler *cron.Cron) error {
+	ctx := run.Context
+	viewList, err := db.GetViewsWithHistorySchedule(ctx)
+	if err != nil {
+		return err
+	}
+
+	snapshotScheduleMu.Lock()
+	defer snapshotScheduleMu.Unlock()
+
+	active := make(map[types.UID]bool)
+	for _, view := range viewList {
+		schedule := view.Spec.Cache.History.Schedule
+		active[view.UID] = true
+		existing, exists := snapshotScheduleEntries[view.UID]
+		if exists && existing.Schedule == schedule && existing.Generation == view.Generation {
+			continue
+		}
+
+		if exists {
+			scheduler.Remove(existing.CronID)
+			delete(snapshotScheduleEntries, view.UID)
+		}
+
+		namespace, name := view.Namespace, view.Name
+		cronID, err := scheduler.AddFunc(schedule, func() {
+			// Create a fresh context for each snapshot rather than
+			// capturing the sync job's context, which may be cancelled.
+			cronCtx := context.NewContext(gocontext.Background()).WithDB(ctx.DB(), ctx.Pool())
+			if err := SnapshotView(cronCtx, namespace, name); err != nil {
+				cronCtx.Logger.Warnf("failed to snapshot view %s/%s: %v", namespace, name, err)
+			}
+		})
+		if err != nil {
+			run.History.AddErrorf("invalid history schedule %q of view %s/%s: %v", schedule, view.Namespace, view.Name, err)
+			delete(active, view.UID)
+			continue
+		}
+
+		snapshotScheduleEntries[view.UID] = snapshotScheduleEntry{CronID: cronID, Schedule: schedule, Generation: view.Generation}
+		run.History.IncrSuccess()
+	}
+
+	for uid, entry := range snapshotScheduleEntries {
+		if !active[uid] {
+			scheduler.Remove(entry.CronID)
+			delete(snapshotScheduleEntries, uid)
+		}
+	}
+
+	return nil
+}
+
+// SnapshotView refreshes the view with its default variables, as the system
+// user, and snapshots the result.
+func SnapshotView(ctx context.Context, namespace, name string) error {
+	ctx = ctx.WithName("viewSnapshot").WithNamespace(namespace)
+	if api.SystemUserID != nil {
+		ctx = ctx.WithUser(&models.Person{ID: *api.SystemUserID})
+	}
+
+	view, err := db.GetView(ctx, namespace, name)
+	if err != nil {
+		return fmt.Errorf("failed to get view: %w", err)
+	} else if view == nil || view.Spec.Cache.History == nil {
+		return nil
+	}
+
+	return snapshotViewNow(ctx, view)
+}
+
+func snapshotViewNow(ctx context.Context, view *v1.View) error {
+	request, _, err := prepareRequest(ctx, view)
+	if err != nil {
+		return err
+	}
+
+	// populateView snapshots the result
+	_, err = populateView(ctx, view, request)
+	return err
+}
@@ -0,0 +1,103 @@
+package views
+
+import (
+	"time"
+
+	pkgView "github.com/flanksource/duty/view"
+	ginkgo "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/samber/lo"
+
+	v1 "github.com/flanksource/incident-commander/api/v1"
+	"github.com/flanksource/incident-commander/db"
+)
+
+var _ = ginkgo.Describe("View snapshots", func() {
+	ginkgo.Describe("diffRows", func() {
+		from := []map[string]any{
+			{"name": "a", "status": "healthy", pkgView.ReservedColumnAttributes: map[string]any{"x": 1}},
+			{"name": "b", "status": "healthy"},
+		}
+		to := []map[string]any{
+			{"name": "a", "status": "unhealthy", pkgView.ReservedColumnAttributes: map[string]any{"x": 2}},
+			{"name": "c", "status": "healthy"},
+		}
+
+		ginkgo.It("matches rows on the primary key", func() {
+			diff := diffRows([]string{"name"}, from, to)
+			Expect(diff.Added).To(Equal([]map[string]any{to[1]}))
+			Expect(diff.Removed).To(Equal([]map[string]any{from[1]}))
+			Expect(diff.Changed).To(HaveLen(1))
+			Expect(diff.Changed[0].Key).To(Equal(map[string]any{"name": "a"}))
+			Expect(diff.Changed[0].Fields).To(Equal([]string{"status"}))
+		})
+
+		ginkgo.It("only adds and removes rows without a primary key", func() {
+			diff := diffRows(nil, from, to)
+			Expect(diff.Added).To(HaveLen(2))
+			Expect(diff.Removed).To(HaveLen(2))
+			Expect(diff.Changed).To(BeEmpty())
+		})
+
+		ginkgo.It("ignores changes to reserved columns", func() {
+			diff := diffRows([]string{"name"}, from[:1], []map[string]any{{"name": "a", "status": "healthy"}})
+			Expect(diff.Added).To(BeEmpty())
+			Expect(diff.Removed).To(BeEmpty())
+			Expect(diff.Changed).To(BeEmpty())
+		})
+	})
+
+	ginkgo.Describe("snapshotRows", func() {
+		ginkgo.It("keys the rows by column and drops the grants", func() {
+			columns := []pkgView.ColumnDef{{Name: "name"}, {Name: pkgView.ReservedColumnGrants}}
+			rows := snapshotRows(columns, []pkgView.Row{{"a", []string{"scope-1"}}})
+			Expect(rows).To(Equal([]map[string]any{{"name": "a"}}))
+		})
+	})
+
+	ginkgo.Describe("ParseSnapshotTime", func() {
+		now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+		ginkgo.It("parses timestamps", func() {
+			t, err := ParseSnapshotTime("2025-05-01T00:00:00Z", now)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(t).To(Equal(time.Date(2025, 5, 1, 0, 0, 0, 0, time.UTC)))
+		})
+
+		ginkgo.It("parses durations as time ago", func() {
+			t, err := ParseSnapshotTime("7d", now)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(t).To(Equal(now.Add(-7 * 24 * time.Hour)))
+		})
+
+		ginkgo.It("rejects anything else", func() {
+			_, err := ParseSnapshotTime("yesterday", now)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	ginkgo.Describe("SnapshotView", func() {
+		ginkgo.It("refreshes and snapshots a view with a history schedule", func() {
+			viewObj, err := loadViewFromYAML("cache-test-view.yaml")
+			Expect(err).ToNot(HaveOccurred())
+			viewObj.Name = "scheduled-history"
+			viewObj.UID = "0ff2a7e4-52a3-4c5e-9f3b-8f1f5b2b6a10"
+			viewObj.Spec.Cache.History = &v1.ViewCacheHistory{Schedule: "@every 1h"}
+			Expect(db.PersistViewFromCRD(DefaultContext, viewObj)).To(Succeed())
+
+			scheduled, err := db.GetViewsWithHistorySchedule(DefaultContext)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(lo.Map(scheduled, func(v v1.View, _ int) string { return v.Name })).To(ContainElement(viewObj.Name))
+
+			Expect(SnapshotView(DefaultContext, viewObj.Namespace, viewObj.Name)).To(Succeed())
+
+			uid, err := viewObj.GetUUID()
+			Expect(err).ToNot(HaveOccurred())
+			request, _, err := prepareRequest(DefaultContext, viewObj)
+			Expect(err).ToNot(HaveOccurred())
+			last, err := db.GetLastViewSnapshotTime(DefaultContext, uid, request.Fingerprint())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(last).ToNot(BeNil())
+		})
+	})
+})
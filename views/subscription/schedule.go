@@ -0,0 +1,95 @@
+package subscription
+
+import (
+	gocontext "context"
+	"sync"
+
+	"github.com/flanksource/duty/context"
+	"github.com/flanksource/duty/job"
+	"github.com/robfig/cron/v3"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+type scheduleEntry struct {
+	CronID     cron.EntryID
+	Schedule   string
+	Generation int64
+}
+
+var (
+	scheduleMu      sync.Mutex
+	scheduleEntries = make(map[types.UID]scheduleEntry)
+)
+
+// ScheduleViewSubscriptions keeps a cron entry for every ViewSubscription on
+// the scheduler.
+func ScheduleViewSubscriptions(ctx context.Context, scheduler *cron.Cron) *job.Job {
+	return &job.Job{
+		Name:       "ScheduleViewSubscriptions",
+		Schedule:   "@every 1m",
+		Context:    ctx,
+		Singleton:  true,
+		Retention:  job.RetentionFailed,
+		JobHistory: true,
+		RunNow:     true,
+		Fn: func(run job.JobRuntime) error {
+			return syncSchedules(run, scheduler)
+		},
+	}
+}
+
+func syncSchedules(run job.JobRuntime, scheduler *cron.Cron) error {
+	ctx := run.Context
+	subscriptions, err := ListSubscriptions(ctx)
+	if err != nil {
+		return err
+	}
+
+	scheduleMu.Lock()
+	defer scheduleMu.Unlock()
+
+	active := make(map[types.UID]bool)
+	for _, sub := range subscriptions {
+		schedule, err := sub.Spec.CronSchedule()
+		if err != nil {
+			run.History.AddErrorf("view subscription %s/%s: %v", sub.Namespace, sub.Name, err)
+			continue
+		}
+
+		active[sub.UID] = true
+		existing, exists := scheduleEntries[sub.UID]
+		if exists && existing.Schedule == schedule && existing.Generation == sub.Generation {
+			continue
+		}
+
+		if exists {
+			scheduler.Remove(existing.CronID)
+			delete(scheduleEntries, sub.UID)
+		}
+
+		subscription := sub
+		cronID, err := scheduler.AddFunc(schedule, func() {
+			// Create a fresh context for each delivery rather than
+			// capturing the sync job's context, which may be cancelled.
+			cronCtx := context.NewContext(gocontext.Background()).WithDB(ctx.DB(), ctx.Pool())
+			Deliver(systemContext(cronCtx, subscription), subscription)
+		})
+		if err != nil {
+			run.History.AddErrorf("failed to register cron for view subscription %s/%s: %v", sub.Namespace, sub.Name, err)
+			delete(active, sub.UID)
+			continue
+		}
+
+		scheduleEntries[sub.UID] = scheduleEntry{CronID: cronID, Schedule: schedule, Generation: sub.Generation}
+		run.History.IncrSuccess()
+	}
+
+	for uid, entry := range scheduleEntries {
+		if !active[uid] {
+			scheduler.Remove(entry.CronID)
+			delete(scheduleEntries, uid)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,195 @@
+// Package subscription delivers the export of a view to the recipients of a
+// ViewSubscription on its schedule.
+//
+// Every delivery is recorded as a job history of the subscription with the
+// outcome of each recipient.
+package subscription
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/flanksource/commons/utils"
+	"github.com/flanksource/duty/context"
+	"github.com/flanksource/duty/models"
+	"github.com/google/uuid"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/flanksource/incident-commander/api"
+	v1 "github.com/flanksource/incident-commander/api/v1"
+	"github.com/flanksource/incident-commander/db"
+	"github.com/flanksource/incident-commander/notification"
+	"github.com/flanksource/incident-commander/views"
+)
+
+// Job history of a delivery.
+const (
+	JobName      = "ViewSubscription"
+	ResourceType = "view_subscription"
+
+	apiGroup   = "mission-control.flanksource.com"
+	apiVersion = "v1"
+)
+
+// ListSubscriptions returns all the ViewSubscriptions on the cluster.
+func ListSubscriptions(ctx context.Context) ([]v1.ViewSubscription, error) {
+	kc, err := ctx.Kubernetes()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get kubernetes client: %w", err)
+	}
+
+	client, err := kc.GetClientByGroupVersionKind(ctx, apiGroup, apiVersion, "ViewSubscription")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get client for ViewSubscription: %w", err)
+	}
+
+	list, err := client.Namespace(metav1.NamespaceAll).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list ViewSubscriptions: %w", err)
+	}
+
+	subscriptions := make([]v1.ViewSubscription, 0, len(list.Items))
+	for _, item := range list.Items {
+		var s v1.ViewSubscription
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(item.Object, &s); err != nil {
+			return nil, fmt.Errorf("invalid ViewSubscription %s/%s: %w", item.GetNamespace(), item.GetName(), err)
+		}
+		subscriptions = append(subscriptions, s)
+	}
+
+	return subscriptions, nil
+}
+
+// Deliver exports the view of the subscription and sends it to every
+// recipient. The returned job history has already been persisted.
+func Deliver(ctx context.Context, sub v1.ViewSubscription) *models.JobHistory {
+	history := models.NewJobHistory(ctx.Logger, JobName, ResourceType, string(sub.UID)).Start()
+	history.AddDetails("subscription", fmt.Sprintf("%s/%s", sub.Namespace, sub.Name))
+	history.AddDetails("view", fmt.Sprintf("%s/%s", sub.GetViewNamespace(), sub.Spec.View.Name))
+	history.AddDetails("format", utils.Coalesce(sub.Spec.Format, v1.ViewSubscriptionFormatPDF))
+
+	if err := deliver(ctx, sub, history); err != nil {
+		history.AddError(err.Error())
+	}
+
+	history.End()
+	if err := history.Persist(ctx.DB()); err != nil {
+		ctx.Errorf("failed to persist view subscription history: %v", err)
+	}
+	return history
+}
+
+func deliver(ctx context.Context, sub v1.ViewSubscription, history *models.JobHistory) error {
+	view, err := db.GetView(ctx, sub.GetViewNamespace(), sub.Spec.View.Name)
+	if err != nil {
+		return fmt.Errorf("failed to get view: %w", err)
+	} else if view == nil {
+		return fmt.Errorf("view %s/%s not found", sub.GetViewNamespace(), sub.Spec.View.Name)
+	}
+
+	result, data, err := views.ExportWithResult(ctx, view, sub.Spec.Variables, sub.Spec.ExportFormat(), sub.Spec.Facet)
+	if err != nil {
+		return fmt.Errorf("failed to export view: %w", err)
+	}
+
+	history.AddDetails("rows", len(result.Rows))
+	history.AddDetails("size", len(data))
+	if sub.Spec.SkipEmpty && len(result.Rows) == 0 {
+		history.AddDetails("skipped", "view has no rows")
+		history.Status = models.StatusSkipped
+		return nil
+	}
+
+	now := time.Now()
+	title := utils.Coalesce(sub.Spec.Title, result.Title, view.Name)
+	template := notification.NotificationTemplate{
+		Title:   title,
+		Message: utils.Coalesce(sub.Spec.Message, fmt.Sprintf("%s as of %s (%d rows)", title, now.Format(time.RFC1123), len(result.Rows))),
+		Attachments: []notification.Attachment{{
+			Filename:    AttachmentName(view.Name, sub.Spec.Format, now),
+			ContentType: ContentType(sub.Spec.Format),
+			Content:     data,
+		}},
+	}
+
+	var recipients []map[string]any
+	for i, to := range sub.Spec.To {
+		recipient := map[string]any{"recipient": describeRecipient(to)}
+
+		celEnv := map[string]any{
+			"subscription": map[string]any{"name": sub.Name, "namespace": sub.Namespace},
+			"view":         map[string]any{"name": view.Name, "namespace": view.Namespace, "title": result.Title},
+			"rows":         len(result.Rows),
+		}
+		if err := notification.SendToRecipient(notification.NewContext(ctx, uuid.Nil), to, celEnv, template); err != nil {
+			history.AddErrorf("to[%d] %s: %v", i, recipient["recipient"], err)
+			recipient["error"] = err.Error()
+		} else {
+			history.IncrSuccess()
+		}
+		recipients = append(recipients, recipient)
+	}
+	history.AddDetails("recipients", recipients)
+
+	return nil
+}
+
+// AttachmentName returns the file name of the export of a view.
+func AttachmentName(viewName, format string, at time.Time) string {
+	extension := utils.Coalesce(format, v1.ViewSubscriptionFormatPDF)
+	return fmt.Sprintf("%s-%s.%s", viewName, at.Format("2006-01-02"), extension)
+}
+
+// ContentType returns the MIME type of a subscription format.
+func ContentType(format string) string {
+	switch format {
+	case v1.ViewSubscriptionFormatPDF, "":
+		return "application/pdf"
+	case v1.ViewSubscriptionFormatHTML:
+		return "text/html"
+	case v1.ViewSubscriptionFormatCSV:
+		return "text/csv"
+	case v1.ViewSubscriptionFormatJSON:
+		return "application/json"
+	case v1.ViewSubscriptionFormatYAML:
+		return "application/yaml"
+	default:
+		return "application/octet-stream"
+	}
+}
+
+// describeRecipient identifies a recipient in the send history without
+// leaking the credentials a shoutrrr URL may carry.
+func describeRecipient(to v1.NotificationRecipientSpec) string {
+	switch {
+	case to.Person != "":
+		return "person:" + to.Person
+	case to.Team != "":
+		return "team:" + to.Team
+	case to.Email != "":
+		return "email:" + to.Email
+	case to.Connection != "":
+		return "connection:" + to.Connection
+	case to.URL != "":
+		service, _, _ := strings.Cut(to.URL, ":")
+		return "url:" + service
+	case to.Playbook != nil:
+		return "playbook:" + *to.Playbook
+	case to.Webhook != nil:
+		return "webhook"
+	}
+	return "none"
+}
+
+// systemContext returns the context scheduled deliveries run as.
+func systemContext(ctx context.Context, sub v1.ViewSubscription) context.Context {
+	ctx = ctx.WithName("viewSubscription").
+		WithNamespace(sub.Namespace).
+		WithLoggingValues("subscription", sub.Namespace+"/"+sub.Name)
+	if api.SystemUserID != nil {
+		ctx = ctx.WithUser(&models.Person{ID: *api.SystemUserID})
+	}
+	return ctx
+}
@@ -0,0 +1,38 @@
+package subscription
+
+import (
+	"time"
+
+	ginkgo "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/samber/lo"
+
+	v1 "github.com/flanksource/incident-commander/api/v1"
+)
+
+var _ = ginkgo.Describe("Attachment", func() {
+	at := time.Date(2026, 10, 19, 8, 0, 0, 0, time.UTC)
+
+	ginkgo.It("names the attachment after the view and date", func() {
+		Expect(AttachmentName("unhealthy", "", at)).To(Equal("unhealthy-2026-10-19.pdf"))
+		Expect(AttachmentName("unhealthy", v1.ViewSubscriptionFormatCSV, at)).To(Equal("unhealthy-2026-10-19.csv"))
+	})
+
+	ginkgo.It("returns the content type of the format", func() {
+		Expect(ContentType("")).To(Equal("application/pdf"))
+		Expect(ContentType(v1.ViewSubscriptionFormatHTML)).To(Equal("text/html"))
+		Expect(ContentType(v1.ViewSubscriptionFormatCSV)).To(Equal("text/csv"))
+	})
+})
+
+var _ = ginkgo.Describe("describeRecipient", func() {
+	ginkgo.It("omits the credentials of a url", func() {
+		Expect(describeRecipient(v1.NotificationRecipientSpec{URL: "slack://token@channel"})).To(Equal("url:slack"))
+	})
+
+	ginkgo.It("describes named recipients", func() {
+		Expect(describeRecipient(v1.NotificationRecipientSpec{Email: "ops@example.com"})).To(Equal("email:ops@example.com"))
+		Expect(describeRecipient(v1.NotificationRecipientSpec{Team: "sre"})).To(Equal("team:sre"))
+		Expect(describeRecipient(v1.NotificationRecipientSpec{Playbook: lo.ToPtr("notify")})).To(Equal("playbook:notify"))
+	})
+})
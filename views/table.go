@@ -51,9 +51,16 @@ type requestOpt struct {
 	refreshTimeout *time.Duration
 	includeRows    bool
 	variables      map[string]string
+
+	// subject is who the rows are cached for when the view has queries whose
+	// results depend on who runs them, e.g. plugin queries.
+	subject string
 }
 
 func (t requestOpt) Fingerprint() string {
+	if t.subject != "" {
+		return hash.Sha256Hex(collections.SortedMap(t.variables) + "\x00" + t.subject)
+	}
 	return hash.Sha256Hex(collections.SortedMap(t.variables))
 }
 
@@ -491,33 +498,9 @@ func ReadOrPopulateViewTable(ctx context.Context, namespace, name string, opts .
 		return nil, dutyAPI.Errorf(dutyAPI.ENOTFOUND, "view %s/%s not found", namespace, name)
 	}
 
-	// Process request options first to get user-selected variable values
-	request := &requestOpt{}
-	for _, opt := range opts {
-		opt(request)
-	}
-
-	// Populate variables with user selections considered
-	var variables []api.ViewVariableWithOptions
-	if err := auth.WithRLS(ctx, func(ctx context.Context) error {
-		variables, view.Spec.Templating, err = populateViewVariables(ctx, view.Spec.Templating, request.variables)
-		return err
-	}); err != nil {
-		return nil, fmt.Errorf("failed to populate view variables: %w", err)
-	}
-
-	// For all the variables, that were not provided in the request,
-	// set them to their default values (or the first value if no default is set)
-	if request.variables == nil {
-		request.variables = make(map[string]string)
-	}
-	for _, v := range variables {
-		if _, ok := request.variables[v.Key]; !ok {
-			if defaultValue := getDefaultValue(v); defaultValue != "" {
-				request.variables[v.Key] = defaultValue
-			}
-			// Skip setting if no default and no options (don't add empty string)
-		}
+	request, variables, err := prepareRequest(ctx, view, opts...)
+	if err != nil {
+		return nil, err
 	}
 
 	cacheOptions, err := view.GetCacheOptions(lo.FromPtr(request.maxAge), lo.FromPtr(request.refreshTimeout))
@@ -577,6 +560,58 @@ func ReadOrPopulateViewTable(ctx context.Context, namespace, name string, opts .
 	return result, nil
 }
 
+// prepareRequest applies the request options and populates the view variables.
+// Variables that were not provided in the request are set to their defaults
+// so that the request fingerprint is the same with or without them.
+func prepareRequest(ctx context.Context, view *v1.View, opts ...ViewOption) (*requestOpt, []api.ViewVariableWithOptions, error) {
+	// Process request options first to get user-selected variable values
+	request := &requestOpt{}
+	for _, opt := range opts {
+		opt(request)
+	}
+
+	// Populate variables with user selections considered
+	var variables []api.ViewVariableWithOptions
+	if err := auth.WithRLS(ctx, func(ctx context.Context) error {
+		var err error
+		variables, view.Spec.Templating, err = populateViewVariables(ctx, view.Spec.Templating, request.variables)
+		return err
+	}); err != nil {
+		return nil, nil, fmt.Errorf("failed to populate view variables: %w", err)
+	}
+
+	// For all the variables, that were not provided in the request,
+	// set them to their default values (or the first value if no default is set)
+	if request.variables == nil {
+		request.variables = make(map[string]string)
+	}
+	for _, v := range variables {
+		if _, ok := request.variables[v.Key]; !ok {
+			if defaultValue := getDefaultValue(v); defaultValue != "" {
+				request.variables[v.Key] = defaultValue
+			}
+			// Skip setting if no default and no options (don't add empty string)
+		}
+	}
+
+	// Plugin operations run with the plugin roles of the user, so their rows
+	// aren't shared with other users.
+	if hasPluginQueries(view) && ctx.User() != nil {
+		request.subject = ctx.User().ID.String()
+	}
+
+	return request, variables, nil
+}
+
+func hasPluginQueries(view *v1.View) bool {
+	for _, q := range view.Spec.Queries {
+		if q.Plugin != nil {
+			return true
+		}
+	}
+	return false
+}
+
 // handleViewRefresh deduplicates concurrent view refresh operations using singleflight
 func handleViewRefresh(ctx context.Context, view *v1.View, cacheOptions *v1.CacheOptions, tableExists bool, request *requestOpt) (*api.ViewResult, *refreshInfo, error) {
 	done := make(chan struct{})
@@ -736,6 +771,10 @@ func populateView(ctx context.Context, view *v1.View, request *requestOpt) (*api
 		return result, err
 	}
 
+	if err := snapshotView(ctx, view, result, request); err != nil {
+		ctx.Logger.Warnf("failed to snapshot view %s: %v", view.GetNamespacedName(), err)
+	}
+
 	if !request.includeRows {
 		result.Rows = nil // don't return rows. UI uses postgREST to get the table rows.
 	}
@@ -0,0 +1,90 @@
+package threshold
+
+import (
+	"sync"
+	"time"
+
+	"github.com/flanksource/duty/context"
+	"github.com/flanksource/duty/job"
+	"github.com/flanksource/duty/models"
+	k8sTypes "k8s.io/apimachinery/pkg/types"
+
+	"github.com/flanksource/incident-commander/api"
+	v1 "github.com/flanksource/incident-commander/api/v1"
+	"github.com/flanksource/incident-commander/db"
+	"github.com/flanksource/incident-commander/views"
+)
+
+var (
+	lastEvaluatedMu sync.Mutex
+	lastEvaluated   = make(map[k8sTypes.UID]time.Time)
+)
+
+// EvaluateViewThresholds evaluates the panel thresholds of every view once
+// per the max age of the view's cache.
+func EvaluateViewThresholds(ctx context.Context) *job.Job {
+	return &job.Job{
+		Name:       "EvaluateViewThresholds",
+		Schedule:   "@every 1m",
+		Context:    ctx,
+		Singleton:  true,
+		Retention:  job.RetentionFailed,
+		JobHistory: true,
+		RunNow:     true,
+		Fn: func(run job.JobRuntime) error {
+			return evaluateDueViews(run)
+		},
+	}
+}
+
+func evaluateDueViews(run job.JobRuntime) error {
+	ctx := run.Context
+	if api.SystemUserID != nil {
+		ctx = ctx.WithUser(&models.Person{ID: *api.SystemUserID})
+	}
+
+	viewList, err := db.GetViewsWithPanelThresholds(ctx)
+	if err != nil {
+		return err
+	}
+
+	lastEvaluatedMu.Lock()
+	defer lastEvaluatedMu.Unlock()
+
+	for _, view := range viewList {
+		due, err := isDue(view, time.Now())
+		if err != nil {
+			run.History.AddErrorf("view %s/%s: %v", view.Namespace, view.Name, err)
+			continue
+		} else if !due {
+			continue
+		}
+
+		// ReadOrPopulateViewTable refreshes the cache when it has expired so the
+		// thresholds are evaluated on the same results the view shows.
+		result, err := views.ReadOrPopulateViewTable(ctx, view.Namespace, view.Name)
+		if err != nil {
+			run.History.AddErrorf("failed to run view %s/%s: %v", view.Namespace, view.Name, err)
+			continue
+		}
+		lastEvaluated[view.UID] = time.Now()
+
+		if err := EvaluateView(ctx, view, result.Panels, run.History); err != nil {
+			run.History.AddErrorf("view %s/%s: %v", view.Namespace, view.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// isDue returns true when the view's thresholds haven't been evaluated
+// within the max age of its cache.
+func isDue(view v1.View, now time.Time) (bool, error) {
+	cacheOptions, err := view.GetCacheOptions(0, 0)
+	if err != nil {
+		return false, err
+	}
+
+	last, ok := lastEvaluated[view.UID]
+	return !ok || now.Sub(last) >= cacheOptions.MaxAge, nil
+}
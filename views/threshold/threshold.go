@@ -0,0 +1,235 @@
+// Package threshold evaluates the thresholds declared on view panels and
+// emits view.threshold.breached / view.threshold.recovered events when they
+// change state.
+//
+// The last state of every threshold is kept as a job history with a stable id
+// so that transitions survive restarts.
+package threshold
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/flanksource/duty/context"
+	"github.com/flanksource/duty/models"
+	"github.com/flanksource/duty/types"
+	"github.com/flanksource/gomplate/v3"
+	"github.com/google/uuid"
+	"github.com/samber/lo"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/flanksource/incident-commander/api"
+	v1 "github.com/flanksource/incident-commander/api/v1"
+	"github.com/flanksource/incident-commander/events"
+)
+
+// Job history that holds the last state of a panel threshold.
+const (
+	JobName      = "ViewThreshold"
+	ResourceType = "view_panel_threshold"
+)
+
+// maxEventRows caps the rows carried by an event so that a long timeseries
+// doesn't bloat the event queue.
+const maxEventRows = 100
+
+// StateID returns the id of the state of a panel threshold.
+// It's also the event_id of the events of the threshold.
+func StateID(viewID, panel, threshold string) uuid.UUID {
+	return uuid.NewSHA1(uuid.NameSpaceOID, []byte(fmt.Sprintf("%s/%s/%s/%s", ResourceType, viewID, panel, threshold)))
+}
+
+// PanelEnv returns the CEL env a threshold condition is evaluated with.
+//
+// value is the value of the first row, or of the last row for timeseries
+// where rows are ordered by time. The values and rows are under panel as
+// lists are only usable in CEL when nested.
+func PanelEnv(panel api.PanelResult) map[string]any {
+	key := panel.ValueKey()
+	rows := make([]any, 0, len(panel.Rows))
+	values := make([]any, 0, len(panel.Rows))
+	for _, row := range panel.Rows {
+		rows = append(rows, map[string]any(row))
+		values = append(values, row[key])
+	}
+
+	var value any
+	if len(values) > 0 {
+		value = lo.Ternary(panel.Type == api.PanelTypeTimeseries, values[len(values)-1], values[0])
+	}
+
+	return map[string]any{
+		"value": value,
+		"panel": map[string]any{
+			"name":   panel.Name,
+			"type":   string(panel.Type),
+			"value":  value,
+			"values": values,
+			"rows":   rows,
+		},
+	}
+}
+
+// Transition returns the event to emit when a threshold that was in the
+// previous state is evaluated to breached. It's empty when nothing changed.
+// A threshold without a previous state is considered to have been ok.
+func Transition(previous *models.JobHistory, breached bool) string {
+	wasBreached := previous != nil && previous.Status == models.StatusFailed
+	switch {
+	case breached && !wasBreached:
+		return api.EventViewThresholdBreached
+	case !breached && wasBreached:
+		return api.EventViewThresholdRecovered
+	}
+	return ""
+}
+
+// EvaluateView evaluates the thresholds of the view against its panel
+// results and emits an event for every threshold that changed state.
+// Thresholds that fail to evaluate are recorded on the history and keep
+// their previous state.
+func EvaluateView(ctx context.Context, view v1.View, panels []api.PanelResult, history *models.JobHistory) error {
+	var previous []models.JobHistory
+	if err := ctx.DB().Where("name = ? AND resource_type = ? AND resource_id = ?", JobName, ResourceType, string(view.UID)).
+		Find(&previous).Error; err != nil {
+		return fmt.Errorf("failed to get the state of the thresholds: %w", err)
+	}
+	states := lo.SliceToMap(previous, func(h models.JobHistory) (uuid.UUID, models.JobHistory) { return h.ID, h })
+
+	results := lo.SliceToMap(panels, func(p api.PanelResult) (string, api.PanelResult) { return p.Name, p })
+	evaluated := map[uuid.UUID]bool{}
+	for _, panel := range view.Spec.Panels {
+		if len(panel.Thresholds) == 0 {
+			continue
+		}
+
+		result, ok := results[panel.Name]
+		if !ok {
+			history.AddErrorf("view %s/%s: panel %s has no result", view.Namespace, view.Name, panel.Name)
+			continue
+		}
+		env := PanelEnv(result)
+
+		for _, threshold := range panel.Thresholds {
+			id := StateID(string(view.UID), panel.Name, threshold.Name)
+			evaluated[id] = true
+
+			breached, err := ctx.RunTemplateBool(gomplate.Template{Expression: threshold.Condition}, env)
+			if err != nil {
+				history.AddErrorf("view %s/%s: panel %s: threshold %s: %v", view.Namespace, view.Name, panel.Name, threshold.Name, err)
+				continue
+			}
+
+			var prev *models.JobHistory
+			if state, ok := states[id]; ok {
+				prev = &state
+			}
+
+			if err := save(ctx, view, panel, threshold, env, id, prev, breached); err != nil {
+				history.AddErrorf("view %s/%s: panel %s: threshold %s: %v", view.Namespace, view.Name, panel.Name, threshold.Name, err)
+				continue
+			}
+			history.IncrSuccess()
+		}
+	}
+
+	// Forget the thresholds that were removed from the view.
+	var stale []uuid.UUID
+	for id := range states {
+		if !evaluated[id] {
+			stale = append(stale, id)
+		}
+	}
+	if len(stale) > 0 {
+		if err := ctx.DB().Where("id IN ?", stale).Delete(&models.JobHistory{}).Error; err != nil {
+			return fmt.Errorf("failed to delete the state of removed thresholds: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// save persists the state of a threshold along with the event of its
+// transition, if any.
+func save(ctx context.Context, view v1.View, panel api.PanelDef, threshold api.PanelThreshold, env map[string]any, id uuid.UUID, previous *models.JobHistory, breached bool) error {
+	message := threshold.Message
+	if message != "" {
+		if rendered, err := ctx.RunTemplate(gomplate.Template{Template: message}, env); err != nil {
+			ctx.Warnf("failed to render message of threshold %s on panel %s: %v", threshold.Name, panel.Name, err)
+		} else {
+			message = rendered
+		}
+	}
+
+	now := time.Now()
+	transition := Transition(previous, breached)
+	since := now
+	if previous != nil && transition == "" {
+		if t, err := time.Parse(time.RFC3339Nano, fmt.Sprint(previous.Details["since"])); err == nil {
+			since = t
+		}
+	}
+
+	state := models.JobHistory{
+		ID:           id,
+		Name:         JobName,
+		ResourceType: ResourceType,
+		ResourceID:   string(view.UID),
+		TimeStart:    now,
+		TimeEnd:      &now,
+		Status:       lo.Ternary(breached, models.StatusFailed, models.StatusSuccess),
+		Details: types.JSONMap{
+			"view":      view.Namespace + "/" + view.Name,
+			"panel":     panel.Name,
+			"threshold": threshold.Name,
+			"condition": threshold.Condition,
+			"value":     env["value"],
+			"message":   message,
+			"since":     since.Format(time.RFC3339Nano),
+		},
+	}
+
+	return ctx.Transaction(func(ctx context.Context, _ trace.Span) error {
+		if err := ctx.DB().Save(&state).Error; err != nil {
+			return fmt.Errorf("failed to save threshold state: %w", err)
+		}
+
+		if transition == "" {
+			return nil
+		}
+
+		panelEnv := env["panel"].(map[string]any)
+		rows := panelEnv["rows"].([]any)
+		event := api.ViewThresholdEvent{
+			ViewID:    string(view.UID),
+			View:      view.Name,
+			Namespace: view.Namespace,
+			Panel:     panel.Name,
+			PanelType: string(panel.Type),
+			Threshold: threshold.Name,
+			Condition: threshold.Condition,
+			Severity:  threshold.Severity,
+			Message:   message,
+			Value:     env["value"],
+			Values:    panelEnv["values"].([]any),
+		}
+		for _, row := range rows[:min(len(rows), maxEventRows)] {
+			event.Rows = append(event.Rows, row.(map[string]any))
+		}
+
+		properties, err := event.Properties()
+		if err != nil {
+			return err
+		}
+
+		if err := ctx.DB().Clauses(events.EventQueueOnConflictClause).Create(&models.Event{
+			Name:       transition,
+			EventID:    id,
+			Properties: properties,
+		}).Error; err != nil {
+			return fmt.Errorf("failed to create %s event: %w", transition, err)
+		}
+
+		return nil
+	})
+}
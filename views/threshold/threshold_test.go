@@ -0,0 +1,85 @@
+package threshold
+
+import (
+	"github.com/flanksource/duty/dataquery"
+	"github.com/flanksource/duty/models"
+	"github.com/flanksource/gomplate/v3"
+	ginkgo "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/flanksource/incident-commander/api"
+)
+
+var _ = ginkgo.Describe("PanelEnv", func() {
+	ginkgo.It("uses the first row as the value of a number panel", func() {
+		env := PanelEnv(api.PanelResult{
+			PanelMeta: api.PanelMeta{Name: "failing", Type: api.PanelTypeNumber},
+			Rows:      []dataquery.QueryResultRow{{"value": 5}},
+		})
+		Expect(env["value"]).To(Equal(5))
+		Expect(env["panel"]).To(HaveKeyWithValue("values", []any{5}))
+	})
+
+	ginkgo.It("uses the last row and the value key of a timeseries panel", func() {
+		env := PanelEnv(api.PanelResult{
+			PanelMeta: api.PanelMeta{
+				Name:       "unhealthy %",
+				Type:       api.PanelTypeTimeseries,
+				Timeseries: &api.PanelTimeseriesConfig{ValueKey: "percent"},
+			},
+			Rows: []dataquery.QueryResultRow{{"percent": 4.0}, {"percent": 12.5}},
+		})
+		Expect(env["value"]).To(Equal(12.5))
+		Expect(env["panel"]).To(HaveKeyWithValue("values", []any{4.0, 12.5}))
+	})
+
+	ginkgo.It("has no value when the panel has no rows", func() {
+		env := PanelEnv(api.PanelResult{PanelMeta: api.PanelMeta{Type: api.PanelTypeGauge}})
+		Expect(env["value"]).To(BeNil())
+		Expect(env["panel"]).To(HaveKeyWithValue("rows", BeEmpty()))
+	})
+
+	ginkgo.It("evaluates conditions on the panel values", func() {
+		env := PanelEnv(api.PanelResult{
+			PanelMeta: api.PanelMeta{Type: api.PanelTypeBargauge},
+			Rows:      []dataquery.QueryResultRow{{"name": "a", "value": 2}, {"name": "b", "value": 11}},
+		})
+
+		for expr, expected := range map[string]bool{
+			"value > 3":                false,
+			"values.exists(v, v > 10)": true,
+			"rows.filter(r, r.value > 1).size() == 2": true,
+		} {
+			ok, err := gomplate.RunTemplateBool(env, gomplate.Template{Expression: expr})
+			Expect(err).ToNot(HaveOccurred(), expr)
+			Expect(ok).To(Equal(expected), expr)
+		}
+	})
+})
+
+var _ = ginkgo.Describe("Transition", func() {
+	breached := &models.JobHistory{Status: models.StatusFailed}
+	ok := &models.JobHistory{Status: models.StatusSuccess}
+
+	ginkgo.It("emits breached when a threshold starts failing", func() {
+		Expect(Transition(nil, true)).To(Equal(api.EventViewThresholdBreached))
+		Expect(Transition(ok, true)).To(Equal(api.EventViewThresholdBreached))
+	})
+
+	ginkgo.It("emits recovered when a breached threshold passes", func() {
+		Expect(Transition(breached, false)).To(Equal(api.EventViewThresholdRecovered))
+	})
+
+	ginkgo.It("emits nothing when the state is unchanged", func() {
+		Expect(Transition(nil, false)).To(BeEmpty())
+		Expect(Transition(ok, false)).To(BeEmpty())
+		Expect(Transition(breached, true)).To(BeEmpty())
+	})
+})
+
+var _ = ginkgo.Describe("StateID", func() {
+	ginkgo.It("is stable per view, panel and threshold", func() {
+		Expect(StateID("view", "panel", "a")).To(Equal(StateID("view", "panel", "a")))
+		Expect(StateID("view", "panel", "a")).ToNot(Equal(StateID("view", "panel", "b")))
+	})
+})